@@ -2,8 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/noah-loop/backend/api-gateway/internal/domain/entity"
@@ -23,10 +28,18 @@ type GatewayService struct {
 	config          GatewayConfig
 	logger          infrastructure.Logger
 	metrics         *infrastructure.MetricsRegistry
+	transport       *http.Transport
 	healthTicker    *time.Ticker
 	stopHealthCheck chan bool
 }
 
+const (
+	// upstreamDialTimeout 连接上游服务的超时时间
+	upstreamDialTimeout = 5 * time.Second
+	// upstreamResponseTimeout 等待上游服务响应头的超时时间
+	upstreamResponseTimeout = 30 * time.Second
+)
+
 // GatewayConfig 网关配置接口
 type GatewayConfig interface {
 	GetGatewayName() string
@@ -40,6 +53,8 @@ type ServiceConfig struct {
 	Host string
 	Port int
 	Path string
+	// PublicRoutes 不需要认证的路由，路径相对于 /api/v1/<服务名>，可带方法前缀，如 "GET /models"、"/health"
+	PublicRoutes []string
 }
 
 // NewGatewayService 创建网关应用服务
@@ -61,10 +76,25 @@ func NewGatewayService(
 		config:          config,
 		logger:          logger,
 		metrics:         metrics,
+		transport:       newProxyTransport(),
 		stopHealthCheck: make(chan bool, 1),
 	}
 }
 
+// newProxyTransport 创建代理上游服务使用的连接池
+func newProxyTransport() *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   upstreamDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: upstreamResponseTimeout,
+	}
+}
+
 // Initialize 初始化网关
 func (gs *GatewayService) Initialize() error {
 	ctx := context.Background()
@@ -125,8 +155,32 @@ func (gs *GatewayService) registerService(ctx context.Context, config ServiceCon
 	return nil
 }
 
-// setupDefaultRoutes 设置默认路由
+// setupDefaultRoutes 设置默认路由，公开路由先于服务的通配路由注册
 func (gs *GatewayService) setupDefaultRoutes() {
+	for _, serviceConfig := range gs.config.GetServices() {
+		for _, publicRoute := range serviceConfig.PublicRoutes {
+			method, path := "ANY", strings.TrimSpace(publicRoute)
+			if prefix, rest, found := strings.Cut(path, " "); found {
+				method, path = strings.ToUpper(prefix), strings.TrimSpace(rest)
+			}
+			route, err := valueobject.NewRoute(valueobject.RouteConfig{
+				Pattern:     "/api/v1/" + serviceConfig.Name + "/" + strings.TrimPrefix(path, "/"),
+				ServiceName: serviceConfig.Name,
+				Method:      method,
+				Public:      true,
+			})
+			if err != nil {
+				gs.logger.Error("Failed to create public route",
+					zap.String("service", serviceConfig.Name),
+					zap.String("route", publicRoute),
+					zap.Error(err))
+				continue
+			}
+			
+			gs.gateway.AddRoute(route)
+		}
+	}
+	
 	for _, service := range gs.gateway.GetAllServices() {
 		route, err := valueobject.NewRoute(valueobject.RouteConfig{
 			Pattern:     "/api/v1/" + service.GetName() + "/*",
@@ -144,13 +198,15 @@ func (gs *GatewayService) setupDefaultRoutes() {
 	}
 }
 
-// ProxyRequest 代理请求
-func (gs *GatewayService) ProxyRequest(serviceName string, req *http.Request) (*http.Response, error) {
+// ProxyRequest 将请求反向代理到服务，path为服务路由前缀之后的路径
+// 保留请求方法，复制请求头和响应头，流式转发请求体和响应体；
+// 上游连接失败或超时记为熔断器失败，返回错误时尚未写入响应
+func (gs *GatewayService) ProxyRequest(serviceName string, w http.ResponseWriter, req *http.Request, path string) error {
 	// 检查熔断器
 	circuitBreaker, exists := gs.circuitBreakers[serviceName]
 	if exists {
 		if err := circuitBreaker.CanExecute(); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	
@@ -160,7 +216,7 @@ func (gs *GatewayService) ProxyRequest(serviceName string, req *http.Request) (*
 		if circuitBreaker != nil {
 			circuitBreaker.RecordFailure()
 		}
-		return nil, err
+		return err
 	}
 	
 	// 检查服务健康状态
@@ -168,29 +224,96 @@ func (gs *GatewayService) ProxyRequest(serviceName string, req *http.Request) (*
 		if circuitBreaker != nil {
 			circuitBreaker.RecordFailure()
 		}
-		return nil, gs.createServiceUnavailableResponse()
+		return gs.createServiceUnavailableResponse()
+	}
+	
+	target, err := url.Parse(service.GetURL())
+	if err != nil {
+		return err
 	}
 	
-	// 执行请求（这里简化处理，实际需要实现HTTP代理）
 	start := time.Now()
+	statusCode := 0
+	var proxyErr error
 	
-	// 模拟请求执行
-	resp := &http.Response{
-		StatusCode: http.StatusOK,
-		Header:     make(http.Header),
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Scheme = target.Scheme
+			pr.Out.URL.Host = target.Host
+			pr.Out.URL.Path = joinProxyPath(service.GetPath(), path)
+			pr.Out.URL.RawPath = ""
+			pr.Out.Host = ""
+			pr.SetXForwarded()
+			pr.Out.Header.Set("X-Gateway", "noah-loop-gateway")
+			pr.Out.Header.Set("X-Gateway-Version", gs.gateway.GetVersion())
+			pr.Out.Header.Set("X-Forwarded-By", gs.gateway.GetName())
+			pr.Out.Header.Set("X-Original-Host", pr.In.Host)
+		},
+		Transport: gs.transport,
+		// 立即刷新响应，支持SSE等流式响应
+		FlushInterval: -1,
+		ModifyResponse: func(resp *http.Response) error {
+			statusCode = resp.StatusCode
+			return nil
+		},
+		// 不写入响应，由调用方按错误类型返回
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			proxyErr = err
+		},
 	}
-	resp.Header.Set("X-Proxy-Service", serviceName)
+	proxy.ServeHTTP(w, req)
 	
-	// 记录成功
-	if circuitBreaker != nil {
-		circuitBreaker.RecordSuccess()
+	duration := time.Since(start)
+	if proxyErr == nil {
+		if circuitBreaker != nil {
+			circuitBreaker.RecordSuccess()
+		}
+		gs.recordProxyMetrics(serviceName, statusCode, duration)
+		return nil
 	}
 	
-	// 记录指标
-	duration := time.Since(start)
-	gs.recordProxyMetrics(serviceName, resp.StatusCode, duration)
+	// 客户端断开连接不是上游故障
+	if errors.Is(req.Context().Err(), context.Canceled) {
+		return proxyErr
+	}
 	
-	return resp, nil
+	if circuitBreaker != nil {
+		circuitBreaker.RecordFailure()
+	}
+	if isTimeoutError(proxyErr) {
+		gs.recordProxyMetrics(serviceName, http.StatusGatewayTimeout, duration)
+		return &UpstreamTimeoutError{
+			Message: "Upstream service timeout: " + serviceName,
+			Err:     proxyErr,
+		}
+	}
+	gs.recordProxyMetrics(serviceName, http.StatusBadGateway, duration)
+	return proxyErr
+}
+
+// joinProxyPath 拼接服务路径和请求路径
+func joinProxyPath(servicePath, path string) string {
+	if path == "" || path == "/" {
+		if servicePath == "" {
+			return "/"
+		}
+		return servicePath
+	}
+	return strings.TrimSuffix(servicePath, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// isTimeoutError 检查是否为连接或等待响应超时
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsPublicRoute 检查请求是否匹配不需要认证的公开路由
+func (gs *GatewayService) IsPublicRoute(method, path string) bool {
+	return gs.gateway.IsPublicRoute(method, path)
 }
 
 // createServiceUnavailableResponse 创建服务不可用响应
@@ -209,6 +332,20 @@ func (e *ServiceUnavailableError) Error() string {
 	return e.Message
 }
 
+// UpstreamTimeoutError 上游服务超时错误
+type UpstreamTimeoutError struct {
+	Message string
+	Err     error
+}
+
+func (e *UpstreamTimeoutError) Error() string {
+	return e.Message
+}
+
+func (e *UpstreamTimeoutError) Unwrap() error {
+	return e.Err
+}
+
 // GetServiceStatus 获取服务状态
 func (gs *GatewayService) GetServiceStatus() map[string]interface{} {
 	status := make(map[string]interface{})
@@ -348,6 +485,7 @@ func (gs *GatewayService) recordProxyMetrics(serviceName string, statusCode int,
 // Shutdown 关闭网关
 func (gs *GatewayService) Shutdown() {
 	gs.StopHealthChecker()
+	gs.transport.CloseIdleConnections()
 	gs.gateway.Stop()
 	gs.gateway.MarkStopped()
 	
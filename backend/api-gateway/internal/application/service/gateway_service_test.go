@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/api-gateway/internal/infrastructure/repository"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// testGatewayConfig 只包含一个agent服务的网关配置
+type testGatewayConfig struct {
+	host string
+	port int
+}
+
+func (c testGatewayConfig) GetGatewayName() string    { return "gateway" }
+func (c testGatewayConfig) GetGatewayVersion() string { return "1.2.3" }
+func (c testGatewayConfig) GetServices() map[string]ServiceConfig {
+	return map[string]ServiceConfig{
+		"agent": {Name: "agent", Host: c.host, Port: c.port, Path: "/api/v1/agent"},
+	}
+}
+
+// newTestGatewayService 创建代理到upstream的网关服务，返回记录日志的observer
+func newTestGatewayService(t *testing.T, upstream string) (*GatewayService, *observer.ObservedLogs) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(upstream, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	gs := NewGatewayService(testGatewayConfig{host: host, port: portNumber}, repository.NewInMemoryServiceRepository(), zap.New(core), nil)
+	if err := gs.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	service, err := gs.gateway.GetService("agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.UpdateHealth(true)
+	return gs, logs
+}
+
+// proxiedStatusCodes 返回代理指标中记录的状态码
+func proxiedStatusCodes(logs *observer.ObservedLogs) []int64 {
+	var codes []int64
+	for _, entry := range logs.FilterMessage("Proxy request completed").All() {
+		codes = append(codes, entry.ContextMap()["status_code"].(int64))
+	}
+	return codes
+}
+
+// newGatewayFrontend 把 /api/v1/agent 下的请求交给网关代理
+func newGatewayFrontend(gs *GatewayService) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := gs.ProxyRequest("agent", w, r, strings.TrimPrefix(r.URL.Path, "/api/v1/agent")); err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, err.Error())
+		}
+	}))
+}
+
+func TestProxyRequestForwardsToUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-URI", r.URL.RequestURI())
+		w.Header().Set("X-Body", string(body))
+		w.Header().Set("X-Custom", r.Header.Get("X-Custom"))
+		w.Header().Set("X-Forwarded", r.Header.Get("X-Forwarded-For"))
+		w.Header().Set("X-Version", r.Header.Get("X-Gateway-Version"))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+	}))
+	defer upstream.Close()
+	gs, logs := newTestGatewayService(t, upstream.URL)
+	frontend := newGatewayFrontend(gs)
+	defer frontend.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, frontend.URL+"/api/v1/agent/agents/7?x=1&y=a%2Fb", strings.NewReader("payload"))
+	req.Header.Set("X-Custom", "custom")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusCreated || string(body) != "created" {
+		t.Fatalf("response = %d %q", resp.StatusCode, body)
+	}
+	for header, want := range map[string]string{
+		"X-Method":    http.MethodPut,
+		"X-URI":       "/api/v1/agent/agents/7?x=1&y=a%2Fb",
+		"X-Body":      "payload",
+		"X-Custom":    "custom",
+		"X-Forwarded": "127.0.0.1",
+		"X-Version":   "1.2.3",
+	} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if codes := proxiedStatusCodes(logs); len(codes) != 1 || codes[0] != http.StatusCreated {
+		t.Fatalf("recorded status codes = %v, want the upstream status", codes)
+	}
+}
+
+func TestProxyRequestStreamsResponse(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: one\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "data: two\n\n")
+	}))
+	defer upstream.Close()
+	gs, _ := newTestGatewayService(t, upstream.URL)
+	frontend := newGatewayFrontend(gs)
+	defer frontend.Close()
+
+	resp, err := http.Get(frontend.URL + "/api/v1/agent/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// 上游未结束响应时已刷新的事件即可读到
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != "data: one\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	close(release)
+	rest, _ := io.ReadAll(reader)
+	if string(rest) != "\ndata: two\n\n" {
+		t.Fatalf("rest = %q", rest)
+	}
+}
+
+func TestProxyRequestConnectionErrorOpensCircuitBreaker(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstreamURL := upstream.URL
+	upstream.Close()
+	gs, logs := newTestGatewayService(t, upstreamURL)
+
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		if err := gs.ProxyRequest("agent", rec, httptest.NewRequest(http.MethodGet, "/api/v1/agent/x", nil), "/x"); err == nil {
+			t.Fatal("connection error should be returned")
+		}
+		if rec.Body.Len() != 0 {
+			t.Fatalf("response written on error: %q", rec.Body.String())
+		}
+	}
+	if codes := proxiedStatusCodes(logs); len(codes) != 5 || codes[0] != http.StatusBadGateway {
+		t.Fatalf("recorded status codes = %v", codes)
+	}
+
+	err := gs.ProxyRequest("agent", httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/agent/x", nil), "/x")
+	if state := gs.circuitBreakers["agent"].GetStateName(); state != "OPEN" {
+		t.Fatalf("circuit breaker state = %s", state)
+	}
+	if err == nil || !strings.Contains(err.Error(), "CIRCUIT_BREAKER_OPEN") {
+		t.Fatalf("expected open circuit breaker, got %v", err)
+	}
+}
+
+func TestProxyRequestUpstreamTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer upstream.Close()
+	gs, logs := newTestGatewayService(t, upstream.URL)
+	gs.transport.ResponseHeaderTimeout = 50 * time.Millisecond
+
+	err := gs.ProxyRequest("agent", httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/agent/x", nil), "")
+	var timeoutErr *UpstreamTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected upstream timeout, got %v", err)
+	}
+	if failures := gs.circuitBreakers["agent"].GetFailureCount(); failures != 1 {
+		t.Fatalf("circuit breaker failures = %d", failures)
+	}
+	if codes := proxiedStatusCodes(logs); len(codes) != 1 || codes[0] != http.StatusGatewayTimeout {
+		t.Fatalf("recorded status codes = %v", codes)
+	}
+}
+
+func TestProxyRequestClientCancelIsNotFailure(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer upstream.Close()
+	gs, _ := newTestGatewayService(t, upstream.URL)
+
+	// 客户端在上游响应前断开连接
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/agent/x", nil).WithContext(ctx)
+	if err := gs.ProxyRequest("agent", httptest.NewRecorder(), req, ""); err == nil {
+		t.Fatal("cancelled request should return an error")
+	}
+	if failures := gs.circuitBreakers["agent"].GetFailureCount(); failures != 0 {
+		t.Fatalf("client cancel counted as %d circuit breaker failures", failures)
+	}
+}
+
+func TestJoinProxyPath(t *testing.T) {
+	tests := []struct {
+		servicePath, path, want string
+	}{
+		{"/api/v1/agent", "", "/api/v1/agent"},
+		{"/api/v1/agent", "/", "/api/v1/agent"},
+		{"/api/v1/agent/", "/a/b", "/api/v1/agent/a/b"},
+		{"", "/a", "/a"},
+		{"", "", "/"},
+	}
+	for _, tt := range tests {
+		if got := joinProxyPath(tt.servicePath, tt.path); got != tt.want {
+			t.Errorf("joinProxyPath(%q, %q) = %q, want %q", tt.servicePath, tt.path, got, tt.want)
+		}
+	}
+}
@@ -175,6 +175,20 @@ func (g *Gateway) FindRoute(path string) (*valueobject.Route, error) {
 	return nil, domain.NewDomainError("ROUTE_NOT_FOUND", "Route not found for path: "+path)
 }
 
+// IsPublicRoute 检查请求是否匹配公开路由
+func (g *Gateway) IsPublicRoute(method, path string) bool {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	
+	for _, route := range g.routes {
+		if route.IsPublic() && route.MatchesMethod(method) && route.Matches(path) {
+			return true
+		}
+	}
+	
+	return false
+}
+
 // Start 启动网关
 func (g *Gateway) Start() {
 	g.mutex.Lock()
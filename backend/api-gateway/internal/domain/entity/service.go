@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -185,7 +187,7 @@ func (s *Service) RemoveMetadata(key string) {
 
 // GetURL 获取完整URL
 func (s *Service) GetURL() string {
-	return "http://" + s.host + ":" + string(rune(s.port))
+	return "http://" + net.JoinHostPort(s.host, strconv.Itoa(s.port))
 }
 
 // GetHealthCheckURL 获取健康检查URL
@@ -14,6 +14,7 @@ type Route struct {
 	method      string
 	pathRewrite string
 	middleware  []string
+	public      bool
 	regex       *regexp.Regexp
 }
 
@@ -24,6 +25,7 @@ type RouteConfig struct {
 	Method      string // GET, POST, PUT, DELETE, ANY
 	PathRewrite string
 	Middleware  []string
+	Public      bool // 公开路由，不需要认证
 }
 
 // NewRoute 创建路由值对象
@@ -57,6 +59,7 @@ func NewRoute(config RouteConfig) (*Route, error) {
 		method:      config.Method,
 		pathRewrite: config.PathRewrite,
 		middleware:  config.Middleware,
+		public:      config.Public,
 		regex:       regex,
 	}, nil
 }
@@ -121,6 +124,11 @@ func (r *Route) GetMiddleware() []string {
 	return middleware
 }
 
+// IsPublic 是否为不需要认证的公开路由
+func (r *Route) IsPublic() bool {
+	return r.public
+}
+
 // RewritePath 重写路径
 func (r *Route) RewritePath(originalPath string) string {
 	if r.pathRewrite == "" {
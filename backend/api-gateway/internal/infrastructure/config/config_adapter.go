@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"strings"
+
 	"github.com/noah-loop/backend/api-gateway/internal/application/service"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 )
@@ -63,5 +66,26 @@ func (c *ConfigAdapter) GetServices() map[string]service.ServiceConfig {
 		Path: "/api/v1/orchestrator",
 	}
 	
+	// 公开路由
+	for name, routes := range parsePublicRoutes(os.Getenv("GATEWAY_PUBLIC_ROUTES")) {
+		if serviceConfig, ok := services[name]; ok {
+			serviceConfig.PublicRoutes = routes
+			services[name] = serviceConfig
+		}
+	}
+	
 	return services
 }
+
+// parsePublicRoutes 解析公开路由配置，格式为逗号分隔的 服务名:[方法 ]路径，如 "llm:GET /models,agent:/health"
+func parsePublicRoutes(value string) map[string][]string {
+	routes := make(map[string][]string)
+	for _, entry := range strings.Split(value, ",") {
+		name, route, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || name == "" || strings.TrimSpace(route) == "" {
+			continue
+		}
+		routes[name] = append(routes[name], strings.TrimSpace(route))
+	}
+	return routes
+}
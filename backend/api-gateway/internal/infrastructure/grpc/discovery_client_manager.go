@@ -140,11 +140,11 @@ func (dcm *DiscoveryClientManager) updateServiceConnections(serviceName string,
 	
 	// 为新发现的服务实例创建连接
 	for _, service := range services {
-		if service.Health != "healthy" {
+		if !isHealthy(service) {
 			continue // 跳过不健康的实例
 		}
 		
-		instanceKey := fmt.Sprintf("%s:%d", service.Address, service.GRPCPort)
+		instanceKey := grpcAddress(service)
 		
 		// 如果连接已存在，复用
 		if conn, exists := currentConns[instanceKey]; exists {
@@ -154,7 +154,7 @@ func (dcm *DiscoveryClientManager) updateServiceConnections(serviceName string,
 		}
 		
 		// 创建新连接
-		address := fmt.Sprintf("%s:%d", service.Address, service.GRPCPort)
+		address := grpcAddress(service)
 		conn, err := dcm.createConnection(address)
 		if err != nil {
 			dcm.logger.Error("Failed to create connection",
@@ -185,12 +185,22 @@ func (dcm *DiscoveryClientManager) updateServiceConnections(serviceName string,
 	dcm.updateClients(serviceName, services)
 }
 
+// grpcAddress 服务实例注册的gRPC端点地址
+func grpcAddress(service *etcd.ServiceInfo) string {
+	return fmt.Sprintf("%s:%d", service.GRPC.Host, service.GRPC.Port)
+}
+
+// isHealthy 服务实例最近一次上报的健康状态是否为健康
+func isHealthy(service *etcd.ServiceInfo) bool {
+	return service.Health == etcd.HealthStatusHealthy
+}
+
 // updateClients 更新客户端
 func (dcm *DiscoveryClientManager) updateClients(serviceName string, services []*etcd.ServiceInfo) {
 	// 获取健康的服务实例
 	healthyServices := make([]*etcd.ServiceInfo, 0)
 	for _, service := range services {
-		if service.Health == "healthy" {
+		if isHealthy(service) {
 			healthyServices = append(healthyServices, service)
 		}
 	}
@@ -210,7 +220,7 @@ func (dcm *DiscoveryClientManager) updateClients(serviceName string, services []
 	}
 	
 	// 获取连接
-	instanceKey := fmt.Sprintf("%s:%d", selectedService.Address, selectedService.GRPCPort)
+	instanceKey := grpcAddress(selectedService)
 	conn, exists := dcm.connections[serviceName][instanceKey]
 	if !exists {
 		dcm.logger.Error("Connection not found for selected instance",
@@ -64,35 +64,26 @@ func (h *GatewayHandler) ProxyRequest(serviceName string) gin.HandlerFunc {
 		c.Header("X-Proxy-Service", serviceName)
 		c.Header("X-Gateway", "noah-loop-gateway")
 		
-		// 执行代理请求（简化版本）
-		resp, err := h.gatewayService.ProxyRequest(serviceName, c.Request)
-		if err != nil {
+		// 执行代理请求，响应由代理直接写入
+		if err := h.gatewayService.ProxyRequest(serviceName, c.Writer, c.Request, c.Param("path")); err != nil {
+			// 请求已超时或客户端已断开时，不再写入错误响应
+			if c.Request.Context().Err() != nil {
+				h.logger.Warn("Proxy request aborted",
+					zap.String("service", serviceName),
+					zap.String("path", c.Request.URL.Path),
+					zap.Error(err))
+				return
+			}
 			h.handleProxyError(c, serviceName, err)
 			return
 		}
 		
-		// 设置响应状态码和头部
-		for key, values := range resp.Header {
-			for _, value := range values {
-				c.Header(key, value)
-			}
-		}
-		c.Status(resp.StatusCode)
-		
 		// 记录处理时间
 		duration := time.Since(start)
 		h.logger.Debug("Proxy request completed",
 			zap.String("service", serviceName),
-			zap.Int("status_code", resp.StatusCode),
+			zap.Int("status_code", c.Writer.Status()),
 			zap.Duration("duration", duration))
-		
-		// 返回成功响应（简化版本）
-		c.JSON(resp.StatusCode, gin.H{
-			"success":      true,
-			"message":      "Request proxied successfully",
-			"service":      serviceName,
-			"request_id":   c.GetString("request_id"),
-		})
 	}
 }
 
@@ -114,6 +105,14 @@ func (h *GatewayHandler) handleProxyError(c *gin.Context, serviceName string, er
 			"service":    serviceName,
 			"request_id": c.GetString("request_id"),
 		})
+	case *service.UpstreamTimeoutError:
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"success":    false,
+			"message":    err.Error(),
+			"error":      "upstream_timeout",
+			"service":    serviceName,
+			"request_id": c.GetString("request_id"),
+		})
 	default:
 		// 检查是否为熔断器错误
 		if isCircuitBreakerError(err) {
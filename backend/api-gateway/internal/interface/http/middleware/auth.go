@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+const (
+	// UserIDHeader 转发给下游服务的用户ID请求头
+	UserIDHeader = "X-User-ID"
+	// UserScopesHeader 转发给下游服务的权限范围请求头，空格分隔
+	UserScopesHeader = "X-User-Scopes"
+
+	// ContextKeyUserID gin上下文中的用户ID键
+	ContextKeyUserID = "user_id"
+	// ContextKeyScopes gin上下文中的权限范围键
+	ContextKeyScopes = "scopes"
+	// ContextKeyClaims gin上下文中的JWT声明键
+	ContextKeyClaims = "claims"
+)
+
+// AuthConfig JWT认证配置
+type AuthConfig struct {
+	Enabled      bool
+	HMACSecret   string         // HS256签名密钥
+	RSAPublicKey *rsa.PublicKey // RS256公钥
+	JWKSURL      string         // RS256公钥集地址，按kid选择公钥
+	Issuer       string         // 非空时校验iss
+	Audience     string         // 非空时校验aud
+	Leeway       time.Duration  // 校验过期时间时允许的时钟偏差
+}
+
+// Claims 验证通过的JWT声明
+type Claims struct {
+	UserID string
+	Scopes []string
+	Raw    jwt.MapClaims
+}
+
+// HasScope 检查是否有指定权限范围
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type claimsContextKey struct{}
+
+// ClaimsFromContext 从请求上下文获取验证通过的JWT声明
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok
+}
+
+// AuthMiddleware JWT认证中间件
+// 校验 Authorization: Bearer 令牌，支持HS256和RS256；令牌缺失、无效或过期时返回401。
+// 验证通过后声明写入请求上下文和gin上下文，用户ID和权限范围通过X-User-ID和X-User-Scopes转发给下游服务，
+// 客户端自带的同名请求头总是被移除，未开启认证时也是如此。isPublic返回true的路由不需要认证
+func AuthMiddleware(config AuthConfig, isPublic func(method, path string) bool, logger infrastructure.Logger) gin.HandlerFunc {
+	if !config.Enabled {
+		// 未开启认证时同样移除，客户端不能伪造身份
+		return func(c *gin.Context) {
+			stripIdentityHeaders(c)
+			c.Next()
+		}
+	}
+
+	verifier := newTokenVerifier(config)
+
+	return func(c *gin.Context) {
+		stripIdentityHeaders(c)
+
+		if isPublic != nil && isPublic(c.Request.Method, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		tokenString, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			unauthorized(c, "missing_token", "Missing bearer token")
+			return
+		}
+
+		claims, err := verifier.verify(c.Request.Context(), tokenString)
+		if err != nil {
+			logger.Debug("JWT verification failed",
+				zap.String("path", c.Request.URL.Path),
+				zap.Error(err))
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				unauthorized(c, "token_expired", "Token has expired")
+			} else {
+				unauthorized(c, "invalid_token", "Invalid token")
+			}
+			return
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), claimsContextKey{}, claims))
+		c.Request.Header.Set(UserIDHeader, claims.UserID)
+		if len(claims.Scopes) > 0 {
+			c.Request.Header.Set(UserScopesHeader, strings.Join(claims.Scopes, " "))
+		}
+		c.Set(ContextKeyUserID, claims.UserID)
+		c.Set(ContextKeyScopes, claims.Scopes)
+		c.Set(ContextKeyClaims, claims)
+
+		c.Next()
+	}
+}
+
+// stripIdentityHeaders 移除客户端自带的身份请求头，身份只能由网关写入
+func stripIdentityHeaders(c *gin.Context) {
+	c.Request.Header.Del(UserIDHeader)
+	c.Request.Header.Del(UserScopesHeader)
+}
+
+// bearerToken 从Authorization头中取出Bearer令牌
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthorized 返回401
+func unauthorized(c *gin.Context, code, message string) {
+	c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error=%q`, code))
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"success":    false,
+		"message":    message,
+		"error":      code,
+		"request_id": c.GetString("request_id"),
+	})
+	c.Abort()
+}
+
+// tokenVerifier JWT校验器
+type tokenVerifier struct {
+	config  AuthConfig
+	jwks    *jwksKeySet
+	methods []string
+}
+
+// newTokenVerifier 创建JWT校验器，只接受已配置密钥的签名算法
+func newTokenVerifier(config AuthConfig) *tokenVerifier {
+	v := &tokenVerifier{config: config}
+	if config.HMACSecret != "" {
+		v.methods = append(v.methods, jwt.SigningMethodHS256.Alg())
+	}
+	if config.RSAPublicKey != nil || config.JWKSURL != "" {
+		v.methods = append(v.methods, jwt.SigningMethodRS256.Alg())
+	}
+	if config.JWKSURL != "" {
+		v.jwks = newJWKSKeySet(config.JWKSURL)
+	}
+	return v
+}
+
+// verify 校验令牌的签名、过期时间、签发者和受众，返回声明
+func (v *tokenVerifier) verify(ctx context.Context, tokenString string) (*Claims, error) {
+	if len(v.methods) == 0 {
+		return nil, errors.New("no signing key configured")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods),
+		jwt.WithLeeway(v.config.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if v.config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.config.Issuer))
+	}
+	if v.config.Audience != "" {
+		options = append(options, jwt.WithAudience(v.config.Audience))
+	}
+
+	mapClaims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, mapClaims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return []byte(v.config.HMACSecret), nil
+		case jwt.SigningMethodRS256.Alg():
+			kid, _ := token.Header["kid"].(string)
+			if v.jwks != nil && (kid != "" || v.config.RSAPublicKey == nil) {
+				return v.jwks.key(ctx, kid)
+			}
+			return v.config.RSAPublicKey, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := mapClaims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+	if subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	return &Claims{
+		UserID: subject,
+		Scopes: scopesFromClaims(mapClaims),
+		Raw:    mapClaims,
+	}, nil
+}
+
+// scopesFromClaims 读取权限范围，支持空格分隔的scope字符串和scopes/scp列表
+func scopesFromClaims(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	for _, key := range []string{"scopes", "scp"} {
+		switch value := claims[key].(type) {
+		case string:
+			return strings.Fields(value)
+		case []interface{}:
+			scopes := make([]string, 0, len(value))
+			for _, item := range value {
+				if s, ok := item.(string); ok && s != "" {
+					scopes = append(scopes, s)
+				}
+			}
+			return scopes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// newAuthTestEngine 创建使用认证中间件的路由，/public 为公开路由，处理器返回收到的身份
+func newAuthTestEngine(config AuthConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AuthMiddleware(config, func(method, path string) bool { return path == "/public" }, zap.NewNop()))
+	handler := func(c *gin.Context) {
+		userID := ""
+		if claims, ok := ClaimsFromContext(c.Request.Context()); ok {
+			userID = claims.UserID
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"context": userID,
+			"header":  c.Request.Header.Get(UserIDHeader),
+			"scopes":  c.Request.Header.Get(UserScopesHeader),
+		})
+	}
+	router.GET("/private", handler)
+	router.GET("/public", handler)
+	return router
+}
+
+// serveAuth 发送请求，spoofedUserID非空时伪造身份请求头
+func serveAuth(router *gin.Engine, path, token, spoofedUserID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if spoofedUserID != "" {
+		req.Header.Set(UserIDHeader, spoofedUserID)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// expectUnauthorized 检查401响应和错误码
+func expectUnauthorized(t *testing.T, rec *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error != code {
+		t.Fatalf("error = %q, want %q", body.Error, code)
+	}
+	if !strings.Contains(rec.Header().Get("WWW-Authenticate"), code) {
+		t.Fatalf("WWW-Authenticate = %q", rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestAuthMiddlewareHS256(t *testing.T) {
+	router := newAuthTestEngine(AuthConfig{Enabled: true, HMACSecret: "secret"})
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	t.Run("valid", func(t *testing.T) {
+		token := signHS256(t, "secret", jwt.MapClaims{"sub": "u1", "scope": "read write", "exp": expiresAt})
+		rec := serveAuth(router, "/private", token, "spoofed")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		if body := rec.Body.String(); body != `{"context":"u1","header":"u1","scopes":"read write"}` {
+			t.Fatalf("body = %s", body)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		token := signHS256(t, "secret", jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(-time.Hour).Unix()})
+		expectUnauthorized(t, serveAuth(router, "/private", token, ""), "token_expired")
+	})
+	t.Run("wrong signature", func(t *testing.T) {
+		token := signHS256(t, "other", jwt.MapClaims{"sub": "u1", "exp": expiresAt})
+		expectUnauthorized(t, serveAuth(router, "/private", token, ""), "invalid_token")
+	})
+	t.Run("missing", func(t *testing.T) {
+		expectUnauthorized(t, serveAuth(router, "/private", "", ""), "missing_token")
+	})
+	t.Run("without expiry", func(t *testing.T) {
+		token := signHS256(t, "secret", jwt.MapClaims{"sub": "u1"})
+		expectUnauthorized(t, serveAuth(router, "/private", token, ""), "invalid_token")
+	})
+	t.Run("unexpected algorithm", func(t *testing.T) {
+		token := signRS256(t, generateRSAKey(t), "", jwt.MapClaims{"sub": "u1", "exp": expiresAt})
+		expectUnauthorized(t, serveAuth(router, "/private", token, ""), "invalid_token")
+	})
+}
+
+func TestAuthMiddlewarePublicRouteStripsIdentity(t *testing.T) {
+	// 公开路由和关闭认证时不校验令牌，但不转发伪造的身份
+	rec := serveAuth(newAuthTestEngine(AuthConfig{Enabled: true, HMACSecret: "secret"}), "/public", "", "spoofed")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "spoofed") {
+		t.Fatalf("public route: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	rec = serveAuth(newAuthTestEngine(AuthConfig{}), "/private", "", "spoofed")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "spoofed") {
+		t.Fatalf("auth disabled: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthMiddlewareRS256(t *testing.T) {
+	key := generateRSAKey(t)
+	router := newAuthTestEngine(AuthConfig{Enabled: true, RSAPublicKey: &key.PublicKey, Issuer: "issuer"})
+	claims := jwt.MapClaims{"sub": "u2", "scopes": []string{"a"}, "iss": "issuer", "exp": time.Now().Add(time.Hour).Unix()}
+
+	if rec := serveAuth(router, "/private", signRS256(t, key, "", claims), ""); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	expectUnauthorized(t, serveAuth(router, "/private", signRS256(t, generateRSAKey(t), "", claims), ""), "invalid_token")
+
+	claims["iss"] = "someone-else"
+	expectUnauthorized(t, serveAuth(router, "/private", signRS256(t, key, "", claims), ""), "invalid_token")
+}
+
+func TestAuthMiddlewareJWKS(t *testing.T) {
+	key := generateRSAKey(t)
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+	router := newAuthTestEngine(AuthConfig{Enabled: true, JWKSURL: server.URL})
+	claims := jwt.MapClaims{"sub": "u3", "exp": time.Now().Add(time.Hour).Unix()}
+
+	for i := 0; i < 2; i++ {
+		if rec := serveAuth(router, "/private", signRS256(t, key, "k1", claims), ""); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	// 未知kid在最小刷新间隔内不重新获取公钥集
+	expectUnauthorized(t, serveAuth(router, "/private", signRS256(t, generateRSAKey(t), "unknown", claims), ""), "invalid_token")
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("key set fetched %d times, want 1", n)
+	}
+}
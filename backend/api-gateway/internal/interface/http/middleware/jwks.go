@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksCacheTTL 公钥集缓存时间
+	jwksCacheTTL = time.Hour
+	// jwksMinRefreshInterval 遇到未知kid时重新获取公钥集的最小间隔，避免伪造kid的请求频繁拉取
+	jwksMinRefreshInterval = time.Minute
+	// jwksFetchTimeout 获取公钥集的超时时间
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// jwksKeySet 从JWKS地址获取并缓存的RS256公钥
+type jwksKeySet struct {
+	url       string
+	client    *http.Client
+	mutex     sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// newJWKSKeySet 创建公钥集，首次使用时获取
+func newJWKSKeySet(url string) *jwksKeySet {
+	return &jwksKeySet{
+		url:    url,
+		client: &http.Client{Timeout: jwksFetchTimeout},
+	}
+}
+
+// key 按kid获取公钥，kid为空且公钥集只有一个公钥时使用该公钥
+// 缓存过期或kid未知时重新获取，两次获取至少间隔jwksMinRefreshInterval
+func (s *jwksKeySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, found := s.lookup(kid)
+	stale := time.Since(s.fetchedAt) > jwksCacheTTL
+	if (!found || stale) && time.Since(s.fetchedAt) > jwksMinRefreshInterval {
+		keys, err := s.fetch(ctx)
+		s.fetchedAt = time.Now()
+		if err != nil {
+			// 获取失败时沿用已缓存的公钥
+			if !found {
+				return nil, err
+			}
+			return key, nil
+		}
+		s.keys = keys
+		key, found = s.lookup(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("no signing key found for kid %q", kid)
+	}
+	return key, nil
+}
+
+// lookup 在缓存中查找公钥
+func (s *jwksKeySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" {
+		if len(s.keys) == 1 {
+			for _, key := range s.keys {
+				return key, true
+			}
+		}
+		return nil, false
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetch 获取公钥集，忽略非RSA公钥
+func (s *jwksKeySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range document.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := rsaPublicKeyFromJWK(jwk.N, jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// rsaPublicKeyFromJWK 由JWK的模数n和指数e构造RSA公钥
+func rsaPublicKeyFromJWK(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(modulus) == 0 || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("invalid RSA key parameters")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
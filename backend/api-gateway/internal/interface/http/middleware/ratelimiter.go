@@ -1,41 +1,124 @@
 package middleware
 
 import (
-	"net/http"
-	"sync"
-	"time"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/ratelimit"
+	"github.com/noah-loop/backend/shared/pkg/utils"
+	"go.uber.org/zap"
 )
 
-// RateLimiter 限流中间件（DDD版本）
-func RateLimiter() gin.HandlerFunc {
-	// 创建基于IP的限流器映射
-	limiters := &sync.Map{}
-	
-	// 每分钟最多100个请求，突发允许20个请求
-	rps := rate.Every(time.Minute / 100)
-	burst := 20
+const (
+	// rateLimitScope 网关限流在429响应和指标中的scope
+	rateLimitScope = "gateway"
 
+	// DefaultRateLimitPerMinute 默认每个客户端每分钟的请求数
+	DefaultRateLimitPerMinute = 100
+	// DefaultRateLimitBurst 默认每个客户端允许的突发请求数
+	DefaultRateLimitBurst = 20
+)
+
+// RateLimitConfig 网关限流配置
+type RateLimitConfig struct {
+	Enabled bool
+	// Default 全局规则，未匹配路由规则的请求使用
+	Default ratelimit.Rule
+	// Routes 路由规则，按顺序匹配，匹配的请求使用独立的令牌桶
+	Routes []RouteRateLimit
+	// APIKeyHeader 未认证的请求按该请求头中的API Key区分客户端，为空时不使用
+	APIKeyHeader string
+}
+
+// DefaultRateLimitConfig 默认限流配置
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled:      true,
+		Default:      ratelimit.PerMinute(DefaultRateLimitPerMinute, DefaultRateLimitBurst),
+		APIKeyHeader: "X-API-Key",
+	}
+}
+
+// RouteRateLimit 路由限流规则
+type RouteRateLimit struct {
+	Service string // 服务名
+	Method  string // 请求方法，ANY表示所有方法
+	Path    string // 路径前缀，相对于 /api/v1/<服务名>
+	Rule    ratelimit.Rule
+}
+
+// Name 路由规则的名称，格式与配置相同，如 "llm:POST /chat"
+func (r RouteRateLimit) Name() string {
+	if r.Method == "" || r.Method == "ANY" {
+		return r.Service + ":" + r.Path
+	}
+	return r.Service + ":" + r.Method + " " + r.Path
+}
+
+// Matches 请求是否匹配路由规则，路径按段匹配前缀
+func (r RouteRateLimit) Matches(method, path string) bool {
+	if r.Method != "" && r.Method != "ANY" && r.Method != method {
+		return false
+	}
+	prefix := strings.TrimSuffix("/api/v1/"+r.Service+"/"+strings.TrimPrefix(r.Path, "/"), "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// RateLimiter 按客户端限流的中间件，每个客户端每条规则一个令牌桶
+// 客户端依次按JWT用户ID、API Key和客户端IP区分，因此需要在认证中间件之后注册；
+// 存储不可用时放行请求，避免限流存储故障导致网关不可用
+func RateLimiter(config RateLimitConfig, store ratelimit.Store, logger infrastructure.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		
-		// 获取或创建限流器
-		limiterInterface, _ := limiters.LoadOrStore(ip, rate.NewLimiter(rps, burst))
-		limiter := limiterInterface.(*rate.Limiter)
-
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"success":    false,
-				"message":    "Rate limit exceeded. Please try again later.",
-				"error":      "rate_limit_exceeded",
-				"request_id": c.GetString("request_id"),
-			})
-			c.Abort()
+		if !config.Enabled {
+			c.Next()
 			return
 		}
 
+		rule, bucket, route := config.Default, "global", c.FullPath()
+		for _, routeLimit := range config.Routes {
+			if routeLimit.Matches(c.Request.Method, c.Request.URL.Path) {
+				rule, bucket, route = routeLimit.Rule, "route:"+routeLimit.Name(), routeLimit.Name()
+				break
+			}
+		}
+		if rule.Unlimited() {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take(c.Request.Context(), bucket+":"+clientIdentity(c, config.APIKeyHeader), rule)
+		if err != nil {
+			logger.Warn("Rate limit store unavailable, allowing request", zap.String("route", route), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !result.Allowed {
+			metrics.IncRateLimitThrottled(rateLimitScope, route)
+			utils.RateLimitExceededResponse(c, rateLimitScope, result.Info)
+			return
+		}
+
+		utils.SetRateLimitHeaders(c, result.Info)
 		c.Next()
 	}
 }
+
+// clientIdentity 客户端标识：已认证的请求使用JWT用户ID，其次是API Key，最后是客户端IP
+// API Key只保存摘要，避免在限流存储中出现明文
+func clientIdentity(c *gin.Context, apiKeyHeader string) string {
+	if userID := c.GetString(ContextKeyUserID); userID != "" {
+		return "user:" + userID
+	}
+	if apiKeyHeader != "" {
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noah-loop/backend/shared/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// newRateLimitTestEngine 创建使用限流中间件的路由，X-Test-User请求头模拟认证后的用户
+func newRateLimitTestEngine(config RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	api.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set(ContextKeyUserID, userID)
+		}
+	})
+	api.Use(RateLimiter(config, ratelimit.NewMemoryStore(), zap.NewNop()))
+	api.Any("/llm/*path", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+// serveRateLimited 从同一个客户端IP发送请求
+func serveRateLimited(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "1.2.3.4:5678"
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// throttledCount 返回路由被限流拒绝的请求数
+func throttledCount(t *testing.T, route string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "noah_loop_rate_limit_throttled_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["scope"] == rateLimitScope && labels["route"] == route {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.Default = ratelimit.PerMinute(60, 2)
+	router := newRateLimitTestEngine(config)
+	before := throttledCount(t, "/api/v1/llm/*path")
+
+	for i := 0; i < 2; i++ {
+		rec := serveRateLimited(router, http.MethodGet, "/api/v1/llm/models", nil)
+		if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: status = %d, headers = %v", i, rec.Code, rec.Header())
+		}
+	}
+
+	rec := serveRateLimited(router, http.MethodGet, "/api/v1/llm/models", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" || !strings.Contains(rec.Body.String(), `"scope":"gateway"`) {
+		t.Fatalf("headers = %v, body = %s", rec.Header(), rec.Body.String())
+	}
+	if got := throttledCount(t, "/api/v1/llm/*path") - before; got != 1 {
+		t.Fatalf("throttled metric increased by %v, want 1", got)
+	}
+}
+
+func TestRateLimiterClientIdentity(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.Default = ratelimit.PerMinute(60, 1)
+	router := newRateLimitTestEngine(config)
+
+	serveRateLimited(router, http.MethodGet, "/api/v1/llm/models", nil)
+	if rec := serveRateLimited(router, http.MethodGet, "/api/v1/llm/models", nil); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("ip bucket: status = %d", rec.Code)
+	}
+
+	// 同一IP上的认证用户和API Key使用各自的令牌桶
+	for _, headers := range []map[string]string{{"X-Test-User": "u1"}, {"X-API-Key": "key"}} {
+		if rec := serveRateLimited(router, http.MethodGet, "/api/v1/llm/models", headers); rec.Code != http.StatusOK {
+			t.Fatalf("%v: status = %d", headers, rec.Code)
+		}
+	}
+}
+
+func TestRateLimiterRouteRule(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.Default = ratelimit.PerMinute(60, 2)
+	config.Routes = []RouteRateLimit{{Service: "llm", Method: http.MethodPost, Path: "/chat", Rule: ratelimit.PerMinute(60, 1)}}
+	router := newRateLimitTestEngine(config)
+	before := throttledCount(t, "llm:POST /chat")
+
+	rec := serveRateLimited(router, http.MethodPost, "/api/v1/llm/chat/completions", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("status = %d, headers = %v", rec.Code, rec.Header())
+	}
+	if rec := serveRateLimited(router, http.MethodPost, "/api/v1/llm/chat", nil); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("route bucket: status = %d", rec.Code)
+	}
+	if got := throttledCount(t, "llm:POST /chat") - before; got != 1 {
+		t.Fatalf("throttled metric increased by %v, want 1", got)
+	}
+
+	// 按段匹配前缀，/chatty 和其他方法使用全局规则
+	for _, req := range [][2]string{{http.MethodPost, "/api/v1/llm/chatty"}, {http.MethodGet, "/api/v1/llm/chat"}} {
+		if rec := serveRateLimited(router, req[0], req[1], nil); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("%s %s: status = %d, headers = %v", req[0], req[1], rec.Code, rec.Header())
+		}
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.Enabled = false
+	config.Default = ratelimit.PerMinute(60, 1)
+	router := newRateLimitTestEngine(config)
+
+	for i := 0; i < 5; i++ {
+		if rec := serveRateLimited(router, http.MethodGet, "/api/v1/llm/models", nil); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d", i, rec.Code)
+		}
+	}
+}
@@ -10,23 +10,40 @@ import (
 	"github.com/noah-loop/backend/api-gateway/internal/interface/http/middleware"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	sharedMiddleware "github.com/noah-loop/backend/shared/pkg/middleware"
+	"github.com/noah-loop/backend/shared/pkg/ratelimit"
 )
 
 // Router API网关路由器
 type Router struct {
 	gatewayService *service.GatewayService
 	handler        *handler.GatewayHandler
+	authConfig     middleware.AuthConfig
+	rateLimit      middleware.RateLimitConfig
+	rateLimitStore ratelimit.Store
+	bodyLimit      sharedMiddleware.BodyLimitConfig
 	logger         infrastructure.Logger
 	metrics        *infrastructure.MetricsRegistry
 }
 
 // NewRouter 创建路由器实例
-func NewRouter(gatewayService *service.GatewayService, logger infrastructure.Logger, metrics *infrastructure.MetricsRegistry) *Router {
+func NewRouter(
+	gatewayService *service.GatewayService,
+	authConfig middleware.AuthConfig,
+	rateLimit middleware.RateLimitConfig,
+	rateLimitStore ratelimit.Store,
+	bodyLimit sharedMiddleware.BodyLimitConfig,
+	logger infrastructure.Logger,
+	metrics *infrastructure.MetricsRegistry,
+) *Router {
 	handler := handler.NewGatewayHandler(gatewayService, logger)
 	
 	return &Router{
 		gatewayService: gatewayService,
 		handler:        handler,
+		authConfig:     authConfig,
+		rateLimit:      rateLimit,
+		rateLimitStore: rateLimitStore,
+		bodyLimit:      bodyLimit,
 		logger:         logger,
 		metrics:        metrics,
 	}
@@ -69,8 +86,10 @@ func (r *Router) setupGlobalMiddlewares(router *gin.Engine) {
 		router.Use(sharedMiddleware.MetricsMiddleware(r.metrics))
 	}
 	
+	// 超限的请求体在转发前拒绝
+	router.Use(sharedMiddleware.BodyLimit(r.bodyLimit))
+	
 	// API网关专用中间件
-	router.Use(middleware.RateLimiter())
 	router.Use(middleware.Timeout(30 * time.Second))
 	router.Use(middleware.CircuitBreaker())
 }
@@ -94,8 +113,11 @@ func (r *Router) setupManagementRoutes(router *gin.Engine) {
 func (r *Router) setupProxyRoutes(router *gin.Engine) {
 	api := router.Group("/api/v1")
 	
-	// 可选的认证中间件（目前注释掉，后续可以启用）
-	// api.Use(middleware.Authentication())
+	// JWT认证，公开路由不需要认证
+	api.Use(middleware.AuthMiddleware(r.authConfig, r.gatewayService.IsPublicRoute, r.logger))
+
+	// 按客户端限流，在认证之后执行以便按JWT用户ID区分客户端
+	api.Use(middleware.RateLimiter(r.rateLimit, r.rateLimitStore, r.logger))
 
 	// Agent服务路由代理
 	agentGroup := api.Group("/agent")
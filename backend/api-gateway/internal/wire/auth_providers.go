@@ -0,0 +1,74 @@
+package wire
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/noah-loop/backend/api-gateway/internal/interface/http/middleware"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
+	"go.uber.org/zap"
+)
+
+// ProvideAuthConfig 从环境变量读取网关JWT认证配置
+// 未设置GATEWAY_JWT_SECRET时读取etcd中的jwt/secret作为HS256密钥
+func ProvideAuthConfig(secretManager *etcd.SecretManager, logger infrastructure.Logger) middleware.AuthConfig {
+	authConfig := middleware.AuthConfig{
+		HMACSecret: os.Getenv("GATEWAY_JWT_SECRET"),
+		JWKSURL:    os.Getenv("GATEWAY_JWT_JWKS_URL"),
+		Issuer:     os.Getenv("GATEWAY_JWT_ISSUER"),
+		Audience:   os.Getenv("GATEWAY_JWT_AUDIENCE"),
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("GATEWAY_AUTH_ENABLED")); err == nil {
+		authConfig.Enabled = enabled
+	}
+	if !authConfig.Enabled {
+		return authConfig
+	}
+
+	if authConfig.HMACSecret == "" && secretManager != nil {
+		if secret, err := secretManager.GetSecret(context.Background(), "jwt/secret"); err == nil && secret != "" {
+			authConfig.HMACSecret = secret
+		}
+	}
+	if leeway, err := time.ParseDuration(os.Getenv("GATEWAY_JWT_LEEWAY")); err == nil && leeway > 0 {
+		authConfig.Leeway = leeway
+	}
+
+	// RS256公钥，PEM格式的PKIX公钥
+	if keyPEM := os.Getenv("GATEWAY_JWT_PUBLIC_KEY"); keyPEM != "" {
+		if key, err := parseRSAPublicKey(keyPEM); err != nil {
+			logger.Error("Invalid GATEWAY_JWT_PUBLIC_KEY", zap.Error(err))
+		} else {
+			authConfig.RSAPublicKey = key
+		}
+	}
+
+	if authConfig.HMACSecret == "" && authConfig.RSAPublicKey == nil && authConfig.JWKSURL == "" {
+		logger.Warn("Gateway authentication is enabled but no signing key is configured, all authenticated requests will be rejected")
+	}
+	return authConfig
+}
+
+// parseRSAPublicKey 解析PEM格式的RSA公钥
+func parseRSAPublicKey(keyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,114 @@
+package wire
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/noah-loop/backend/api-gateway/internal/interface/http/middleware"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ProvideRateLimitConfig 从环境变量读取网关限流配置
+func ProvideRateLimitConfig(logger infrastructure.Logger) middleware.RateLimitConfig {
+	config := middleware.DefaultRateLimitConfig()
+
+	if enabled, err := strconv.ParseBool(os.Getenv("GATEWAY_RATE_LIMIT_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	requests, burst := middleware.DefaultRateLimitPerMinute, middleware.DefaultRateLimitBurst
+	if value, err := strconv.Atoi(os.Getenv("GATEWAY_RATE_LIMIT_PER_MINUTE")); err == nil && value >= 0 {
+		requests = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("GATEWAY_RATE_LIMIT_BURST")); err == nil && value >= 0 {
+		burst = value
+	}
+	config.Default = ratelimit.PerMinute(requests, burst)
+	if header, ok := os.LookupEnv("GATEWAY_RATE_LIMIT_KEY_HEADER"); ok {
+		config.APIKeyHeader = strings.TrimSpace(header)
+	}
+
+	for _, entry := range strings.Split(os.Getenv("GATEWAY_RATE_LIMIT_ROUTES"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, err := parseRouteRateLimit(entry, burst)
+		if err != nil {
+			logger.Error("Invalid GATEWAY_RATE_LIMIT_ROUTES entry", zap.String("entry", entry), zap.Error(err))
+			continue
+		}
+		config.Routes = append(config.Routes, route)
+	}
+
+	return config
+}
+
+// parseRouteRateLimit 解析路由限流规则，格式为 服务名:[方法 ]路径=每分钟请求数[/突发请求数]，
+// 如 "llm:POST /chat=30/5"，未指定突发请求数时使用全局的突发请求数
+func parseRouteRateLimit(entry string, defaultBurst int) (middleware.RouteRateLimit, error) {
+	routeSpec, limitSpec, found := strings.Cut(strings.TrimSpace(entry), "=")
+	if !found {
+		return middleware.RouteRateLimit{}, fmt.Errorf("missing limit")
+	}
+	service, path, found := strings.Cut(strings.TrimSpace(routeSpec), ":")
+	if !found || strings.TrimSpace(service) == "" {
+		return middleware.RouteRateLimit{}, fmt.Errorf("missing service name")
+	}
+
+	method, path := "ANY", strings.TrimSpace(path)
+	if prefix, rest, found := strings.Cut(path, " "); found {
+		method, path = strings.ToUpper(prefix), strings.TrimSpace(rest)
+	}
+	if !strings.HasPrefix(path, "/") {
+		return middleware.RouteRateLimit{}, fmt.Errorf("path must start with /")
+	}
+
+	requestsSpec, burstSpec, hasBurst := strings.Cut(strings.TrimSpace(limitSpec), "/")
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsSpec))
+	if err != nil || requests < 0 {
+		return middleware.RouteRateLimit{}, fmt.Errorf("invalid requests per minute %q", requestsSpec)
+	}
+	burst := defaultBurst
+	if hasBurst {
+		burst, err = strconv.Atoi(strings.TrimSpace(burstSpec))
+		if err != nil || burst < 0 {
+			return middleware.RouteRateLimit{}, fmt.Errorf("invalid burst %q", burstSpec)
+		}
+	}
+
+	return middleware.RouteRateLimit{
+		Service: strings.TrimSpace(service),
+		Method:  method,
+		Path:    path,
+		Rule:    ratelimit.PerMinute(requests, burst),
+	}, nil
+}
+
+// ProvideRateLimitStore 创建限流令牌桶存储
+// GATEWAY_RATE_LIMIT_STORE为redis时多个网关实例共享配额，连接地址读取REDIS_ADDR、REDIS_PASSWORD和REDIS_DB
+func ProvideRateLimitStore(config middleware.RateLimitConfig, logger infrastructure.Logger) (ratelimit.Store, func(), error) {
+	backend := strings.ToLower(os.Getenv("GATEWAY_RATE_LIMIT_STORE"))
+	if !config.Enabled || backend == "" || backend == ratelimit.StoreMemory {
+		store := ratelimit.NewMemoryStore()
+		return store, func() { _ = store.Close() }, nil
+	}
+	if backend != ratelimit.StoreRedis {
+		return nil, nil, fmt.Errorf("unsupported rate limit store %q", backend)
+	}
+
+	options := &redis.Options{Addr: "localhost:6379", Password: os.Getenv("REDIS_PASSWORD")}
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		options.Addr = addr
+	}
+	if db, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil && db >= 0 {
+		options.DB = db
+	}
+
+	store := ratelimit.NewRedisStore(redis.NewClient(options), "gateway:ratelimit:")
+	logger.Info("Gateway rate limiting uses redis store", zap.String("addr", options.Addr))
+	return store, func() { _ = store.Close() }, nil
+}
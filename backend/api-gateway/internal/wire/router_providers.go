@@ -0,0 +1,11 @@
+package wire
+
+import (
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/middleware"
+)
+
+// ProvideBodyLimitConfig 读取请求体大小限制，环境变量为GATEWAY_MAX_BODY_SIZE和GATEWAY_MAX_BODY_SIZE_ROUTES
+func ProvideBodyLimitConfig(logger infrastructure.Logger) middleware.BodyLimitConfig {
+	return middleware.LoadBodyLimitConfig("GATEWAY", logger)
+}
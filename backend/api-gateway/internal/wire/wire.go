@@ -94,6 +94,10 @@ var GatewayServiceProviderSet = wire.NewSet(
 
 // GatewayHandlerProviderSet HTTP处理器提供者集合
 var GatewayHandlerProviderSet = wire.NewSet(
+	ProvideAuthConfig,
+	ProvideRateLimitConfig,
+	ProvideRateLimitStore,
+	ProvideBodyLimitConfig,
 	handler.NewGatewayHandler,
 	router.NewRouter,
 )
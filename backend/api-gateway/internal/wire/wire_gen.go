@@ -30,7 +30,14 @@ func InitializeGatewayApp() (*GatewayApp, func(), error) {
 	serviceRepository := repository.NewInMemoryServiceRepository()
 	gatewayService := service.NewGatewayService(configAdapter, serviceRepository, logger, metricsRegistry)
 	gatewayHandler := handler.NewGatewayHandler(gatewayService, logger)
-	routerRouter := router.NewRouter(gatewayService, logger, metricsRegistry)
+	authConfig := ProvideAuthConfig(nil, logger)
+	rateLimitConfig := ProvideRateLimitConfig(logger)
+	store, cleanup, err := ProvideRateLimitStore(rateLimitConfig, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	bodyLimitConfig := ProvideBodyLimitConfig(logger)
+	routerRouter := router.NewRouter(gatewayService, authConfig, rateLimitConfig, store, bodyLimitConfig, logger, metricsRegistry)
 	gatewayApp := &GatewayApp{
 		GatewayService: gatewayService,
 		Handler:        gatewayHandler,
@@ -39,5 +46,6 @@ func InitializeGatewayApp() (*GatewayApp, func(), error) {
 		Config:         infrastructureConfig,
 	}
 	return gatewayApp, func() {
+		cleanup()
 	}, nil
 }
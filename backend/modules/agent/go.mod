@@ -4,10 +4,16 @@ go 1.21
 
 replace github.com/noah-loop/backend/shared => ../../shared
 
+replace github.com/noah-loop/backend/api-gateway => ../../api-gateway
+
 require (
 	github.com/noah-loop/backend/shared v0.0.0-00010101000000-000000000000
+	github.com/noah-loop/backend/api-gateway v0.0.0-00010101000000-000000000000
 	github.com/gin-gonic/gin v1.9.1
 	github.com/google/uuid v1.4.0
+	github.com/sashabaranov/go-openai v1.17.9
 	gorm.io/gorm v1.25.5
 	github.com/fsnotify/fsnotify v1.7.0
+	google.golang.org/grpc v1.59.0
+	google.golang.org/protobuf v1.31.0
 )
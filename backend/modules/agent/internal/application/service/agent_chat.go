@@ -0,0 +1,107 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+)
+
+const (
+	// AgentConfigModel 智能体配置中的对话模型键，未配置时使用提供商的默认模型
+	AgentConfigModel = "model"
+	// AgentConfigTemperature 智能体配置中的采样温度键
+	AgentConfigTemperature = "temperature"
+	// AgentConfigMaxTokens 智能体配置中的单次回复最大token数键，未配置时由提供商决定
+	AgentConfigMaxTokens = "max_tokens"
+	// AgentConfigHistoryMessages 智能体配置中的对话历史条数键，对话时最多带上本会话最近的这么多条消息
+	AgentConfigHistoryMessages = "history_messages"
+)
+
+const (
+	// DefaultChatTemperature 默认采样温度
+	DefaultChatTemperature = 0.7
+	// DefaultChatHistoryMessages 默认带上的对话历史条数
+	DefaultChatHistoryMessages = 20
+
+	userMessagePrefix      = "User: "
+	assistantMessagePrefix = "Assistant: "
+)
+
+// chatOptions 对话的模型参数
+type chatOptions struct {
+	Model           string
+	Temperature     float64
+	MaxTokens       int
+	HistoryMessages int
+}
+
+// agentChatOptions 读取智能体配置中的模型参数，未配置或无效的值使用默认值
+func agentChatOptions(agent *domain.Agent) chatOptions {
+	options := chatOptions{
+		Temperature:     DefaultChatTemperature,
+		HistoryMessages: DefaultChatHistoryMessages,
+	}
+
+	if model, ok := agent.Config[AgentConfigModel].(string); ok {
+		options.Model = strings.TrimSpace(model)
+	}
+	if temperature, ok := toFloat(agent.Config[AgentConfigTemperature]); ok && temperature >= 0 && temperature <= 2 {
+		options.Temperature = temperature
+	}
+	if maxTokens, ok := toFloat(agent.Config[AgentConfigMaxTokens]); ok && maxTokens > 0 {
+		options.MaxTokens = int(maxTokens)
+	}
+	if history, ok := toFloat(agent.Config[AgentConfigHistoryMessages]); ok && history >= 0 {
+		options.HistoryMessages = int(history)
+	}
+
+	return options
+}
+
+// buildChatMessages 构建对话消息：渲染后的系统提示 + 本会话最近的对话历史 + 当前用户消息
+// 历史按时间顺序排列，超出条数或上下文窗口（扣除回复预留的token）时优先保留最近的消息
+func buildChatMessages(agent *domain.Agent, systemPrompt string, history []*domain.Memory, message string, options chatOptions) []LLMMessage {
+	messages := make([]LLMMessage, 0, len(history)+2)
+	if prompt := strings.TrimSpace(systemPrompt); prompt != "" {
+		messages = append(messages, LLMMessage{Role: "system", Content: prompt})
+	}
+
+	// 粗略按每个token约3个字符估算
+	budget := (agent.ContextWindow - options.MaxTokens) * 3
+	if budget <= 0 {
+		budget = 12000
+	}
+	budget -= len(systemPrompt) + len(message)
+
+	turns := make([]LLMMessage, 0, options.HistoryMessages)
+	for i := len(history) - 1; i >= 0 && len(turns) < options.HistoryMessages; i-- {
+		turn := conversationTurn(history[i])
+		if budget < len(turn.Content) {
+			break
+		}
+		budget -= len(turn.Content)
+		turns = append(turns, turn)
+	}
+
+	// 恢复时间顺序
+	for i := len(turns) - 1; i >= 0; i-- {
+		messages = append(messages, turns[i])
+	}
+
+	return append(messages, LLMMessage{Role: "user", Content: message})
+}
+
+// conversationTurn 将对话记忆还原为消息，记忆内容以"User: "或"Assistant: "开头
+func conversationTurn(memory *domain.Memory) LLMMessage {
+	if content, ok := strings.CutPrefix(memory.Content, assistantMessagePrefix); ok {
+		return LLMMessage{Role: "assistant", Content: content}
+	}
+	return LLMMessage{Role: "user", Content: strings.TrimPrefix(memory.Content, userMessagePrefix)}
+}
+
+// newConversationMemory 创建会话的对话记忆
+func newConversationMemory(prefix, content, sessionID string) *domain.Memory {
+	memory := domain.NewMemory(prefix+content, domain.MemoryTypeConversation, 0.7)
+	memory.Context[domain.MemoryContextSessionID] = sessionID
+	return memory
+}
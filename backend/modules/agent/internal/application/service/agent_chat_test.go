@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+)
+
+// stubLLMProvider 回复"reply to <最后一条消息>"并记录请求
+type stubLLMProvider struct {
+	requests []*LLMChatRequest
+	err      error
+}
+
+func (p *stubLLMProvider) Chat(ctx context.Context, request *LLMChatRequest) (*LLMChatResponse, error) {
+	p.requests = append(p.requests, request)
+	if p.err != nil {
+		return nil, p.err
+	}
+	last := request.Messages[len(request.Messages)-1].Content
+	return &LLMChatResponse{Content: "reply to " + last, Model: "stub", TokensUsed: 5}, nil
+}
+
+// newChatTestService 创建带有对话智能体和桩提供商的服务
+func newChatTestService() (*AgentService, *domain.Agent, *stubLLMProvider) {
+	agent := domain.NewAgent("assistant", domain.AgentTypeConversational, uuid.New())
+	agent.Memory = domain.NewAgentMemory(agent.ID)
+	agent.SystemPrompt = "You are helpful."
+	agent.Config = map[string]interface{}{
+		AgentConfigModel:       "gpt-4o",
+		AgentConfigTemperature: 0.2,
+		AgentConfigMaxTokens:   float64(256),
+	}
+	service, _ := newTestAgentService(agent, nil)
+	provider := &stubLLMProvider{}
+	service.RegisterLLMProvider(provider)
+	return service, agent, provider
+}
+
+// chat 在会话中发送一条消息
+func chat(t *testing.T, service *AgentService, agent *domain.Agent, sessionID uuid.UUID, message string) string {
+	t.Helper()
+	cmd := NewChatCommand()
+	cmd.AgentID = agent.ID
+	cmd.SessionID = sessionID
+	cmd.Message = message
+	result, err := service.ChatWithAgent(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Data.(map[string]interface{})["response"].(string)
+}
+
+func TestChatWithAgentAssemblesPrompt(t *testing.T) {
+	service, agent, provider := newChatTestService()
+	session := uuid.New()
+
+	if response := chat(t, service, agent, session, "hi"); response != "reply to hi" {
+		t.Fatalf("response = %q", response)
+	}
+	chat(t, service, agent, session, "second")
+	chat(t, service, agent, uuid.New(), "other session")
+
+	// 系统提示 + 本会话历史 + 当前消息，使用智能体配置的模型参数
+	request := provider.requests[1]
+	if request.Model != "gpt-4o" || request.Temperature != 0.2 || request.MaxTokens != 256 {
+		t.Fatalf("model = %q, temperature = %v, max tokens = %d", request.Model, request.Temperature, request.MaxTokens)
+	}
+	want := []LLMMessage{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "reply to hi"},
+		{Role: "user", Content: "second"},
+	}
+	if !reflect.DeepEqual(request.Messages, want) {
+		t.Fatalf("messages = %+v, want %+v", request.Messages, want)
+	}
+	if messages := provider.requests[2].Messages; len(messages) != 2 {
+		t.Fatalf("another session's history leaked into the prompt: %+v", messages)
+	}
+}
+
+func TestChatWithAgentRecordsBothTurns(t *testing.T) {
+	service, agent, _ := newChatTestService()
+	session := uuid.New()
+	chat(t, service, agent, session, "hi")
+	chat(t, service, agent, session, "second")
+
+	conversation := domain.MemoryTypeConversation
+	var turns []string
+	for _, memory := range agent.Memory.GetSessionMemories(session, &conversation) {
+		turns = append(turns, memory.Content)
+	}
+	if got := strings.Join(turns, "|"); got != "User: hi|Assistant: reply to hi|User: second|Assistant: reply to second" {
+		t.Fatalf("memories = %s", got)
+	}
+	if agent.Status != domain.AgentStatusIdle {
+		t.Fatalf("agent status = %s", agent.Status)
+	}
+}
+
+func TestChatWithAgentProviderFailureRecordsNothing(t *testing.T) {
+	service, agent, provider := newChatTestService()
+	provider.err = errors.New("provider unavailable")
+
+	cmd := NewChatCommand()
+	cmd.AgentID = agent.ID
+	cmd.Message = "hi"
+	if _, err := service.ChatWithAgent(context.Background(), cmd); err == nil {
+		t.Fatal("provider error should be returned")
+	}
+	if len(agent.Memory.Memories) != 0 || agent.Status != domain.AgentStatusIdle {
+		t.Fatalf("memories = %d, status = %s", len(agent.Memory.Memories), agent.Status)
+	}
+
+	// 未配置提供商时拒绝对话
+	service.llmProvider = nil
+	if _, err := service.ChatWithAgent(context.Background(), cmd); err == nil {
+		t.Fatal("chat without a provider should fail")
+	}
+}
+
+func TestAgentChatOptionsDefaults(t *testing.T) {
+	agent := domain.NewAgent("assistant", domain.AgentTypeConversational, uuid.New())
+	options := agentChatOptions(agent)
+	if options.Model != "" || options.Temperature != DefaultChatTemperature || options.HistoryMessages != DefaultChatHistoryMessages {
+		t.Fatalf("options = %+v", options)
+	}
+
+	// 无效的值使用默认值
+	agent.Config[AgentConfigTemperature] = 5.0
+	agent.Config[AgentConfigMaxTokens] = -1
+	if options := agentChatOptions(agent); options.Temperature != DefaultChatTemperature || options.MaxTokens != 0 {
+		t.Fatalf("options = %+v", options)
+	}
+}
+
+func TestBuildChatMessagesLimitsHistory(t *testing.T) {
+	agent := domain.NewAgent("assistant", domain.AgentTypeConversational, uuid.New())
+	var history []*domain.Memory
+	for _, content := range []string{"User: a", "Assistant: b", "User: c", "Assistant: d"} {
+		history = append(history, domain.NewMemory(content, domain.MemoryTypeConversation, 0.7))
+	}
+
+	// 超出条数时保留最近的消息
+	agent.Config[AgentConfigHistoryMessages] = "2"
+	messages := buildChatMessages(agent, "", history, "e", agentChatOptions(agent))
+	want := []LLMMessage{{Role: "user", Content: "c"}, {Role: "assistant", Content: "d"}, {Role: "user", Content: "e"}}
+	if !reflect.DeepEqual(messages, want) {
+		t.Fatalf("messages = %+v, want %+v", messages, want)
+	}
+
+	// 上下文窗口不足时同样丢弃较早的消息
+	agent.Config[AgentConfigHistoryMessages] = 20
+	agent.ContextWindow = 1
+	if messages := buildChatMessages(agent, "", history, "e", agentChatOptions(agent)); !reflect.DeepEqual(messages, want) {
+		t.Fatalf("messages = %+v, want %+v", messages, want)
+	}
+}
+
+func TestChatWithAgentRespectsMemoryCapacity(t *testing.T) {
+	service, agent, _ := newChatTestService()
+	agent.Memory.Capacity = 4
+	if err := agent.Memory.AddMemory(domain.NewMemory("vital", domain.MemoryTypeLearned, 1.0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 对话记忆参与淘汰，重要的记忆保留，最早的对话先被淘汰
+	session := uuid.New()
+	for _, message := range []string{"one", "two", "three"} {
+		chat(t, service, agent, session, message)
+	}
+	var contents []string
+	for _, memory := range agent.Memory.Memories {
+		contents = append(contents, memory.Content)
+	}
+	if got := strings.Join(contents, "|"); got != "vital|Assistant: reply to two|User: three|Assistant: reply to three" {
+		t.Fatalf("memories = %s", got)
+	}
+}
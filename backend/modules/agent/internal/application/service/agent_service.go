@@ -3,11 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
 	"github.com/noah-loop/backend/modules/agent/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/application"
+	"github.com/noah-loop/backend/shared/pkg/httpclient"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"go.uber.org/zap"
 )
@@ -21,6 +24,12 @@ type AgentService struct {
 	logger              infrastructure.Logger
 	metrics             *infrastructure.MetricsRegistry
 	toolExecutors       map[domain.ToolType]ToolExecutor
+	llmProvider         LLMProvider
+	embeddingService    EmbeddingService
+	httpClient          *http.Client
+	budgetService       *BudgetService
+	transcriptRepo      domain.TranscriptRepository
+	approverScope       string
 }
 
 // NewAgentService 创建智能体服务
@@ -40,6 +49,8 @@ func NewAgentService(
 		logger:            logger,
 		metrics:           metrics,
 		toolExecutors:     make(map[domain.ToolType]ToolExecutor),
+		httpClient:        httpclient.New(httpclient.DefaultConfig()),
+		approverScope:     domain.DefaultApproverScope,
 	}
 }
 
@@ -48,6 +59,30 @@ func (s *AgentService) RegisterToolExecutor(toolType domain.ToolType, executor T
 	s.toolExecutors[toolType] = executor
 }
 
+// RegisterLLMProvider 注册大模型提供商
+func (s *AgentService) RegisterLLMProvider(provider LLMProvider) {
+	s.llmProvider = provider
+}
+
+// SetHTTPClient 设置工具执行器使用的出站HTTP客户端
+func (s *AgentService) SetHTTPClient(client *http.Client) {
+	if client != nil {
+		s.httpClient = client
+	}
+}
+
+// SetBudgetService 设置成本核算服务，设置后付费调用前检查预算并记录用量
+func (s *AgentService) SetBudgetService(budgetService *BudgetService) {
+	s.budgetService = budgetService
+}
+
+// SetApproverScope 设置审批工具调用需要的权限范围，为空时保持默认值
+func (s *AgentService) SetApproverScope(scope string) {
+	if scope != "" {
+		s.approverScope = scope
+	}
+}
+
 // CreateAgent 创建智能体
 func (s *AgentService) CreateAgent(ctx context.Context, cmd *CreateAgentCommand) (*application.Result, error) {
 	if err := cmd.Validate(); err != nil {
@@ -64,6 +99,9 @@ func (s *AgentService) CreateAgent(ctx context.Context, cmd *CreateAgentCommand)
 	// 创建记忆系统
 	memory := domain.NewAgentMemory(agent.ID)
 	memory.Capacity = cmd.MemoryCapacity
+	if cmd.MemoryEvictionPolicy != "" {
+		memory.EvictionPolicy = cmd.MemoryEvictionPolicy
+	}
 	agent.Memory = memory
 	
 	// 保存智能体
@@ -89,8 +127,13 @@ func (s *AgentService) CreateAgent(ctx context.Context, cmd *CreateAgentCommand)
 	return &application.Result{Success: true, Data: agent}, nil
 }
 
-// ExecuteTool 执行工具
+// ExecuteTool 执行工具，流式工具的输出片段合并后一并返回
 func (s *AgentService) ExecuteTool(ctx context.Context, cmd *ExecuteToolCommand) (*application.Result, error) {
+	return s.executeTool(ctx, cmd, nil)
+}
+
+// executeTool 执行工具，onChunk不为nil时只接受流式工具，并将输出片段逐个交给onChunk
+func (s *AgentService) executeTool(ctx context.Context, cmd *ExecuteToolCommand, onChunk ToolChunkHandler) (*application.Result, error) {
 	if err := cmd.Validate(); err != nil {
 		return &application.Result{Success: false, Error: err.Error()}, err
 	}
@@ -112,6 +155,11 @@ func (s *AgentService) ExecuteTool(ctx context.Context, cmd *ExecuteToolCommand)
 		return &application.Result{Success: false, Error: "tool is disabled"}, fmt.Errorf("tool is disabled")
 	}
 	
+	// 流式调用只接受流式工具
+	if onChunk != nil && tool.ExecutionMode != domain.ExecutionModeStream {
+		return &application.Result{Success: false, Error: ErrToolNotStreamable.Error()}, ErrToolNotStreamable
+	}
+	
 	// 检查智能体是否可以使用该工具
 	if !agent.CanUse(tool.Name) {
 		return &application.Result{Success: false, Error: "agent cannot use this tool"}, fmt.Errorf("agent cannot use this tool")
@@ -122,20 +170,41 @@ func (s *AgentService) ExecuteTool(ctx context.Context, cmd *ExecuteToolCommand)
 		return &application.Result{Success: false, Error: err.Error()}, err
 	}
 	
+	// 检查预算
+	if err := s.checkBudget(ctx, agent); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
 	// 创建执行记录
 	execution := domain.NewToolExecution(tool.ID, agent.ID, cmd.Input)
+	if cmd.SessionID != uuid.Nil {
+		execution.Context[domain.ExecutionContextSessionID] = cmd.SessionID.String()
+	}
+	
+	// 需要审批的工具先等待人工审批，审批通过后再执行
+	if tool.NeedsApproval() {
+		return s.requestToolApproval(ctx, tool, agent, execution)
+	}
 	execution.Status = domain.ExecutionStatusRunning
 	
 	// 保存执行记录
 	if err := s.toolExecutionRepo.Save(ctx, execution); err != nil {
 		return &application.Result{Success: false, Error: "failed to save execution"}, err
 	}
+	s.recordToolCall(ctx, tool, execution)
 	
+	return s.runToolExecution(ctx, tool, agent, execution, onChunk)
+}
+
+// runToolExecution 按工具的执行模式执行已保存为运行中的执行记录
+// 流式工具的输出片段交给onChunk，onChunk为nil时只合并到执行记录的输出中
+func (s *AgentService) runToolExecution(ctx context.Context, tool *domain.Tool, agent *domain.Agent, execution *domain.ToolExecution, onChunk ToolChunkHandler) (*application.Result, error) {
 	// 获取执行器
 	executor, exists := s.toolExecutors[tool.Type]
 	if !exists {
 		execution.Fail("no executor found for tool type", 0)
 		s.toolExecutionRepo.Save(ctx, execution)
+		s.recordToolResult(ctx, execution)
 		return &application.Result{Success: false, Error: "no executor found"}, fmt.Errorf("no executor found")
 	}
 	
@@ -145,6 +214,8 @@ func (s *AgentService) ExecuteTool(ctx context.Context, cmd *ExecuteToolCommand)
 		return s.executeSyncTool(ctx, tool, agent, execution, executor)
 	case domain.ExecutionModeAsync:
 		return s.executeAsyncTool(ctx, tool, agent, execution, executor)
+	case domain.ExecutionModeStream:
+		return s.executeStreamTool(ctx, tool, agent, execution, executor, onChunk)
 	default:
 		return &application.Result{Success: false, Error: "unsupported execution mode"}, fmt.Errorf("unsupported execution mode")
 	}
@@ -156,13 +227,15 @@ func (s *AgentService) executeSyncTool(ctx context.Context, tool *domain.Tool, a
 	
 	// 执行工具
 	result, err := executor.Execute(ctx, &ToolExecutionRequest{
-		Tool:      tool,
-		Agent:     agent,
-		Input:     execution.Input,
-		Context:   execution.Context,
+		Tool:       tool,
+		Agent:      agent,
+		Input:      execution.Input,
+		Context:    execution.Context,
+		HTTPClient: s.httpClient,
 	})
 	
 	duration := time.Since(startTime)
+	s.recordToolUsage(ctx, agent, tool, execution)
 	
 	if err != nil {
 		// 执行失败
@@ -171,6 +244,7 @@ func (s *AgentService) executeSyncTool(ctx context.Context, tool *domain.Tool, a
 		
 		s.toolExecutionRepo.Save(ctx, execution)
 		s.toolRepo.Save(ctx, tool)
+		s.recordToolResult(ctx, execution)
 		
 		return &application.Result{Success: false, Error: err.Error()}, err
 	}
@@ -181,6 +255,7 @@ func (s *AgentService) executeSyncTool(ctx context.Context, tool *domain.Tool, a
 		
 		s.toolExecutionRepo.Save(ctx, execution)
 		s.toolRepo.Save(ctx, tool)
+		s.recordToolResult(ctx, execution)
 		
 		// 记录工具使用指标
 		if s.metrics != nil {
@@ -195,8 +270,9 @@ func (s *AgentService) executeSyncTool(ctx context.Context, tool *domain.Tool, a
 		// 让智能体学习执行结果
 		if result.ShouldLearn {
 			knowledge := fmt.Sprintf("Used tool %s with result: %v", tool.Name, result.Output)
-			agent.Learn(knowledge, 0.5)
-			s.agentRepo.Save(ctx, agent)
+			if _, err := s.learn(ctx, agent, knowledge, 0.5); err == nil {
+				s.agentRepo.Save(ctx, agent)
+			}
 		}
 		
 		return &application.Result{Success: true, Data: execution}, nil
@@ -204,26 +280,30 @@ func (s *AgentService) executeSyncTool(ctx context.Context, tool *domain.Tool, a
 
 // executeAsyncTool 异步执行工具
 func (s *AgentService) executeAsyncTool(ctx context.Context, tool *domain.Tool, agent *domain.Agent, execution *domain.ToolExecution, executor ToolExecutor) (*application.Result, error) {
-	// 异步执行
+	// 异步执行，不随请求结束而取消，但保留追踪上下文使外部调用与本次请求关联
+	ctx = context.WithoutCancel(ctx)
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
 				s.logger.Error("Panic in executeAsyncTool", zap.Any("panic", r))
 				execution.Fail(fmt.Sprintf("panic: %v", r), 0)
-				s.toolExecutionRepo.Save(context.Background(), execution)
+				s.toolExecutionRepo.Save(ctx, execution)
+				s.recordToolResult(ctx, execution)
 			}
 		}()
 		
 		startTime := time.Now()
 		
-		result, err := executor.Execute(context.Background(), &ToolExecutionRequest{
-			Tool:    tool,
-			Agent:   agent,
-			Input:   execution.Input,
-			Context: execution.Context,
+		result, err := executor.Execute(ctx, &ToolExecutionRequest{
+			Tool:       tool,
+			Agent:      agent,
+			Input:      execution.Input,
+			Context:    execution.Context,
+			HTTPClient: s.httpClient,
 		})
 		
 		duration := time.Since(startTime)
+		s.recordToolUsage(ctx, agent, tool, execution)
 		
 		if err != nil {
 			execution.Fail(err.Error(), duration)
@@ -235,13 +315,15 @@ func (s *AgentService) executeAsyncTool(ctx context.Context, tool *domain.Tool,
 			// 让智能体学习
 			if result.ShouldLearn {
 				knowledge := fmt.Sprintf("Used tool %s with result: %v", tool.Name, result.Output)
-				agent.Learn(knowledge, 0.5)
-				s.agentRepo.Save(context.Background(), agent)
+				if _, err := s.learn(ctx, agent, knowledge, 0.5); err == nil {
+					s.agentRepo.Save(ctx, agent)
+				}
 			}
 		}
 		
-		s.toolExecutionRepo.Save(context.Background(), execution)
-		s.toolRepo.Save(context.Background(), tool)
+		s.toolExecutionRepo.Save(ctx, execution)
+		s.toolRepo.Save(ctx, tool)
+		s.recordToolResult(ctx, execution)
 		
 		// 发布完成事件
 		if s.eventBus != nil {
@@ -251,7 +333,7 @@ func (s *AgentService) executeAsyncTool(ctx context.Context, tool *domain.Tool,
 				"tool_id":      tool.ID,
 				"status":       execution.Status,
 			}
-			s.eventBus.Publish(context.Background(), 
+			s.eventBus.Publish(ctx, 
 				&application.BaseDomainEvent{
 					EventType:   "tool.execution.completed",
 					AggregateID: execution.ID,
@@ -267,43 +349,75 @@ func (s *AgentService) executeAsyncTool(ctx context.Context, tool *domain.Tool,
 }
 
 // ChatWithAgent 与智能体对话
+// 以渲染后的系统提示和本会话最近的对话记忆构建消息调用大模型，模型参数取自智能体配置；调用成功后用户和助手的发言都记入记忆
+// 设置了运行记录存储时，发言、读取的记忆、发给大模型的请求和回复（或错误）依次记入会话的运行记录
 func (s *AgentService) ChatWithAgent(ctx context.Context, cmd *ChatCommand) (*application.Result, error) {
 	if err := cmd.Validate(); err != nil {
 		return &application.Result{Success: false, Error: err.Error()}, err
 	}
 	
+	if s.llmProvider == nil {
+		return &application.Result{Success: false, Error: "llm provider not configured"}, fmt.Errorf("llm provider not configured")
+	}
+	
 	// 获取智能体
 	agent, err := s.agentRepo.FindByID(ctx, cmd.AgentID)
 	if err != nil {
 		return &application.Result{Success: false, Error: "agent not found"}, err
 	}
 	
+	// 检查预算
+	if err := s.checkBudget(ctx, agent); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	// 收集会话对话历史，当前消息尚未记入记忆
+	options := agentChatOptions(agent)
+	var history []*domain.Memory
+	if agent.Memory != nil {
+		memoryType := domain.MemoryTypeConversation
+		history = agent.Memory.GetSessionMemories(cmd.SessionID, &memoryType)
+	}
+	
 	// 更新智能体状态
 	agent.ChangeStatus(domain.AgentStatusBusy)
 	
-	// 将对话添加到记忆中
-	if agent.Memory != nil {
-		conversationMemory := domain.NewMemory(
-			fmt.Sprintf("User: %s", cmd.Message),
-			domain.MemoryTypeConversation,
-			0.7,
-		)
-		agent.Memory.AddMemory(conversationMemory)
+	request := &LLMChatRequest{
+		Model:       options.Model,
+		Messages:    buildChatMessages(agent, s.renderSystemPrompt(agent, time.Now()), history, cmd.Message, options),
+		MaxTokens:   options.MaxTokens,
+		Temperature: options.Temperature,
 	}
 	
-	// TODO: 实现与大模型的对话逻辑
-	// 这里应该调用LLM服务进行对话处理
+	// 本次对话的每一步记入会话的运行记录
+	transcript := newTranscriptRecorder(agent.ID, cmd.SessionID, uuid.New())
+	transcript.addChatRequest(cmd, history, request)
 	
-	response := "这是一个模拟回复" // 临时回复
+	startTime := time.Now()
+	response, err := s.llmProvider.Chat(ctx, request)
+	if err != nil {
+		agent.ChangeStatus(domain.AgentStatusIdle)
+		s.logger.Error("Failed to chat with agent",
+			zap.String("agent_id", agent.ID.String()),
+			zap.String("session_id", cmd.SessionID.String()),
+			zap.Error(err))
+		transcript.addError(err, time.Since(startTime))
+		s.saveTranscript(ctx, transcript)
+		return &application.Result{Success: false, Error: "failed to generate response"}, err
+	}
+	transcript.addChatResponse(response, time.Since(startTime))
+	s.saveTranscript(ctx, transcript)
+	s.recordLLMUsage(ctx, agent, cmd.SessionID, response)
 	
-	// 将回复添加到记忆中
+	// 将对话和回复添加到记忆中
 	if agent.Memory != nil {
-		responseMemory := domain.NewMemory(
-			fmt.Sprintf("Assistant: %s", response),
-			domain.MemoryTypeConversation,
-			0.7,
-		)
-		agent.Memory.AddMemory(responseMemory)
+		sessionID := cmd.SessionID.String()
+		if err := agent.Memory.AddMemory(newConversationMemory(userMessagePrefix, cmd.Message, sessionID)); err != nil {
+			s.logger.Warn("Failed to remember user message", zap.Error(err))
+		}
+		if err := agent.Memory.AddMemory(newConversationMemory(assistantMessagePrefix, response.Content, sessionID)); err != nil {
+			s.logger.Warn("Failed to remember assistant response", zap.Error(err))
+		}
 	}
 	
 	// 更新智能体状态
@@ -315,11 +429,161 @@ func (s *AgentService) ChatWithAgent(ctx context.Context, cmd *ChatCommand) (*ap
 	}
 	
 	return &application.Result{Success: true, Data: map[string]interface{}{
-		"response": response,
-		"agent_id": agent.ID,
+		"response":    response.Content,
+		"agent_id":    agent.ID,
+		"session_id":  cmd.SessionID,
+		"model":       response.Model,
+		"tokens_used": response.TokensUsed,
 	}}, nil
 }
 
+// SummarizeConversation 生成会话摘要
+// 按时间顺序收集会话的对话记忆，交由大模型生成一段简明摘要；store为true时将摘要保存为摘要记忆
+func (s *AgentService) SummarizeConversation(ctx context.Context, agentID, sessionID uuid.UUID, store bool) (*application.Result, error) {
+	if agentID == uuid.Nil {
+		return &application.Result{Success: false, Error: "agent ID is required"}, fmt.Errorf("agent ID is required")
+	}
+	if sessionID == uuid.Nil {
+		return &application.Result{Success: false, Error: "session ID is required"}, fmt.Errorf("session ID is required")
+	}
+	
+	if s.llmProvider == nil {
+		return &application.Result{Success: false, Error: "llm provider not configured"}, fmt.Errorf("llm provider not configured")
+	}
+	
+	// 获取智能体
+	agent, err := s.agentRepo.FindByID(ctx, agentID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "agent not found"}, err
+	}
+	
+	if agent.Memory == nil {
+		return &application.Result{Success: false, Error: "agent memory not initialized"}, fmt.Errorf("agent memory not initialized")
+	}
+	
+	// 收集会话对话记忆
+	memoryType := domain.MemoryTypeConversation
+	memories := agent.Memory.GetSessionMemories(sessionID, &memoryType)
+	if len(memories) == 0 {
+		return &application.Result{Success: false, Error: "no conversation found for session"}, fmt.Errorf("no conversation found for session %s", sessionID)
+	}
+	
+	// 检查预算
+	if err := s.checkBudget(ctx, agent); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	transcript := buildConversationTranscript(memories, agent.ContextWindow)
+	
+	response, err := s.llmProvider.Chat(ctx, &LLMChatRequest{
+		Messages: []LLMMessage{
+			{Role: "system", Content: conversationSummaryPrompt},
+			{Role: "user", Content: transcript},
+		},
+		MaxTokens:   512,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		s.logger.Error("Failed to summarize conversation",
+			zap.String("agent_id", agentID.String()),
+			zap.String("session_id", sessionID.String()),
+			zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to summarize conversation"}, err
+	}
+	s.recordLLMUsage(ctx, agent, sessionID, response)
+	
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return &application.Result{Success: false, Error: "empty summary returned"}, fmt.Errorf("empty summary returned")
+	}
+	
+	data := map[string]interface{}{
+		"agent_id":      agent.ID,
+		"session_id":    sessionID,
+		"summary":       summary,
+		"message_count": len(memories),
+		"tokens_used":   response.TokensUsed,
+	}
+	
+	// 保存为摘要记忆
+	if store {
+		memory, err := agent.RememberSummary(sessionID, summary, len(memories))
+		if err != nil {
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+		
+		if err := s.agentRepo.Save(ctx, agent); err != nil {
+			s.logger.Error("Failed to save agent", zap.Error(err))
+			return &application.Result{Success: false, Error: "failed to save summary"}, err
+		}
+		
+		for _, event := range agent.GetDomainEvents() {
+			if err := s.eventBus.Publish(ctx, event); err != nil {
+				s.logger.Warn("Failed to publish event", zap.Error(err))
+			}
+		}
+		agent.ClearDomainEvents()
+		
+		data["memory_id"] = memory.ID
+	}
+	
+	return &application.Result{Success: true, Data: data}, nil
+}
+
+// checkBudget 付费调用前检查预算，未设置成本核算服务时不限制
+func (s *AgentService) checkBudget(ctx context.Context, agent *domain.Agent) error {
+	if s.budgetService == nil {
+		return nil
+	}
+	return s.budgetService.CheckBudget(ctx, agent)
+}
+
+// recordLLMUsage 记录大模型调用用量
+func (s *AgentService) recordLLMUsage(ctx context.Context, agent *domain.Agent, sessionID uuid.UUID, response *LLMChatResponse) {
+	if s.budgetService != nil {
+		s.budgetService.RecordLLMUsage(ctx, agent, sessionID, response)
+	}
+}
+
+// recordToolUsage 记录工具调用用量，执行失败同样计费
+func (s *AgentService) recordToolUsage(ctx context.Context, agent *domain.Agent, tool *domain.Tool, execution *domain.ToolExecution) {
+	if s.budgetService != nil {
+		s.budgetService.RecordToolUsage(ctx, agent, tool, execution)
+	}
+}
+
+// conversationSummaryPrompt 会话摘要系统提示
+const conversationSummaryPrompt = "You summarize conversations between a user and an assistant. " +
+	"Write a single concise paragraph covering the main topics, decisions, open questions and any facts the assistant should remember. " +
+	"Reply in the same language as the conversation and do not add anything that was not discussed."
+
+// buildConversationTranscript 构建会话文本，超出上下文窗口时优先保留最近的消息
+func buildConversationTranscript(memories []*domain.Memory, contextWindow int) string {
+	// 粗略按每个token约3个字符估算
+	maxChars := contextWindow * 3
+	if maxChars <= 0 {
+		maxChars = 12000
+	}
+	
+	lines := make([]string, 0, len(memories))
+	total := 0
+	for i := len(memories) - 1; i >= 0; i-- {
+		line := memories[i].Content
+		if total+len(line) > maxChars && len(lines) > 0 {
+			break
+		}
+		lines = append(lines, line)
+		total += len(line) + 1
+	}
+	
+	// 恢复时间顺序
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	
+	return strings.Join(lines, "\n")
+}
+
 // ToolExecutor 工具执行器接口
 type ToolExecutor interface {
 	Execute(ctx context.Context, request *ToolExecutionRequest) (*ToolExecutionResult, error)
@@ -332,6 +596,37 @@ type ToolExecutionRequest struct {
 	Agent   *domain.Agent
 	Input   map[string]interface{}
 	Context map[string]interface{}
+
+	// HTTPClient 共享的出站HTTP客户端，带追踪传播、超时、重试和连接池，执行器应使用它发起外部调用
+	HTTPClient *http.Client
+}
+
+// LLMProvider 大模型提供商接口
+type LLMProvider interface {
+	Chat(ctx context.Context, request *LLMChatRequest) (*LLMChatResponse, error)
+}
+
+// LLMMessage 大模型消息
+type LLMMessage struct {
+	Role    string
+	Content string
+}
+
+// LLMChatRequest 大模型对话请求
+type LLMChatRequest struct {
+	Model       string
+	Messages    []LLMMessage
+	MaxTokens   int
+	Temperature float64
+}
+
+// LLMChatResponse 大模型对话响应
+type LLMChatResponse struct {
+	Content          string
+	Model            string // 实际使用的模型，用于计费
+	TokensUsed       int
+	PromptTokens     int
+	CompletionTokens int
 }
 
 // ToolExecutionResult 工具执行结果
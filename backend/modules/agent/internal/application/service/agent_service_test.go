@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"go.uber.org/zap"
+)
+
+// stubAgentRepository 总是返回同一个智能体
+type stubAgentRepository struct {
+	domain.AgentRepository
+	agent *domain.Agent
+}
+
+func (r *stubAgentRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Agent, error) {
+	return r.agent, nil
+}
+
+func (r *stubAgentRepository) Save(ctx context.Context, agent *domain.Agent) error { return nil }
+
+// stubToolRepository 总是返回同一个工具
+type stubToolRepository struct {
+	domain.ToolRepository
+	tool *domain.Tool
+}
+
+func (r *stubToolRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Tool, error) {
+	return r.tool, nil
+}
+
+func (r *stubToolRepository) Save(ctx context.Context, tool *domain.Tool) error { return nil }
+
+// recordingToolExecutionRepository 记录保存的执行记录
+type recordingToolExecutionRepository struct {
+	domain.ToolExecutionRepository
+	saved []*domain.ToolExecution
+}
+
+func (r *recordingToolExecutionRepository) Save(ctx context.Context, execution *domain.ToolExecution) error {
+	r.saved = append(r.saved, execution)
+	return nil
+}
+
+// newTestAgentService 创建使用agent和tool的智能体服务
+func newTestAgentService(agent *domain.Agent, tool *domain.Tool) (*AgentService, *recordingToolExecutionRepository) {
+	executions := &recordingToolExecutionRepository{}
+	service := NewAgentService(&stubAgentRepository{agent: agent}, &stubToolRepository{tool: tool}, executions, nil, zap.NewNop(), nil)
+	return service, executions
+}
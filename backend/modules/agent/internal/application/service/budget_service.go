@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// ToolConfigCostPerCall 工具配置中的单次调用费用键
+const ToolConfigCostPerCall = "cost_per_call"
+
+// ModelPrice 模型单价，按每1000个token计
+type ModelPrice struct {
+	PromptPer1K     float64
+	CompletionPer1K float64
+}
+
+// PricingConfig 计费配置
+type PricingConfig struct {
+	DefaultModelPrice ModelPrice            // 未单独定价的模型使用的单价
+	ModelPrices       map[string]ModelPrice // 模型名称 -> 单价
+	DefaultToolCost   float64               // 工具未配置cost_per_call时的单次调用费用
+}
+
+// DefaultPricingConfig 默认计费配置，包含默认对话模型的公开单价（美元）
+func DefaultPricingConfig() PricingConfig {
+	return PricingConfig{
+		ModelPrices: map[string]ModelPrice{
+			"gpt-3.5-turbo": {PromptPer1K: 0.0005, CompletionPer1K: 0.0015},
+		},
+	}
+}
+
+// LLMCost 计算大模型调用费用
+func (c PricingConfig) LLMCost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := c.ModelPrices[model]
+	if !ok {
+		price = c.DefaultModelPrice
+	}
+	return float64(promptTokens)/1000*price.PromptPer1K + float64(completionTokens)/1000*price.CompletionPer1K
+}
+
+// ToolCost 计算工具单次调用费用，优先使用工具配置的cost_per_call
+func (c PricingConfig) ToolCost(tool *domain.Tool) float64 {
+	if cost, ok := toFloat(tool.Config[ToolConfigCostPerCall]); ok && cost >= 0 {
+		return cost
+	}
+	return c.DefaultToolCost
+}
+
+// BudgetService 成本核算服务
+// 记录每次付费调用的token用量和费用，在调用前检查智能体及其所有者的预算，达到上限时拒绝调用
+type BudgetService struct {
+	usageRepo  domain.UsageRecordRepository
+	budgetRepo domain.BudgetRepository
+	pricing    PricingConfig
+	logger     infrastructure.Logger
+}
+
+// NewBudgetService 创建成本核算服务
+func NewBudgetService(
+	usageRepo domain.UsageRecordRepository,
+	budgetRepo domain.BudgetRepository,
+	pricing PricingConfig,
+	logger infrastructure.Logger,
+) *BudgetService {
+	return &BudgetService{
+		usageRepo:  usageRepo,
+		budgetRepo: budgetRepo,
+		pricing:    pricing,
+		logger:     logger,
+	}
+}
+
+// CheckBudget 检查智能体和所有者的预算，任一达到上限时返回*domain.BudgetExceededError
+// 检查与记录之间没有加锁，并发调用可能使花费略微超出上限
+func (s *BudgetService) CheckBudget(ctx context.Context, agent *domain.Agent) error {
+	scopes := []struct {
+		scope domain.BudgetScope
+		id    uuid.UUID
+	}{
+		{domain.BudgetScopeAgent, agent.ID},
+		{domain.BudgetScopeOwner, agent.OwnerID},
+	}
+
+	for _, item := range scopes {
+		if item.id == uuid.Nil {
+			continue
+		}
+		status, err := s.budgetStatus(ctx, item.scope, item.id)
+		if err != nil {
+			return fmt.Errorf("failed to check budget: %w", err)
+		}
+		if status == nil || !status.Exceeded {
+			continue
+		}
+
+		s.logger.Warn("Budget exceeded, blocking paid call",
+			zap.String("agent_id", agent.ID.String()),
+			zap.String("scope", string(item.scope)),
+			zap.String("scope_id", item.id.String()),
+			zap.Float64("limit", status.Budget.Limit),
+			zap.Float64("spent", status.Spent))
+		return domain.NewBudgetExceededError(status.Budget, status.Spent)
+	}
+	return nil
+}
+
+// RecordLLMUsage 记录大模型调用用量，记录失败只记日志，不影响调用结果
+func (s *BudgetService) RecordLLMUsage(ctx context.Context, agent *domain.Agent, referenceID uuid.UUID, response *LLMChatResponse) {
+	record := domain.NewUsageRecord(agent, domain.UsageKindLLM, referenceID, response.Model)
+	record.PromptTokens = response.PromptTokens
+	record.CompletionTokens = response.CompletionTokens
+	record.TotalTokens = response.TokensUsed
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+	// 提供商未区分输入输出token时按输入计费
+	if record.PromptTokens == 0 && record.CompletionTokens == 0 {
+		record.Cost = s.pricing.LLMCost(response.Model, record.TotalTokens, 0)
+	} else {
+		record.Cost = s.pricing.LLMCost(response.Model, record.PromptTokens, record.CompletionTokens)
+	}
+	s.save(ctx, record)
+}
+
+// RecordToolUsage 记录工具调用用量，记录失败只记日志，不影响调用结果
+func (s *BudgetService) RecordToolUsage(ctx context.Context, agent *domain.Agent, tool *domain.Tool, execution *domain.ToolExecution) {
+	record := domain.NewUsageRecord(agent, domain.UsageKindTool, execution.ID, tool.Name)
+	record.Cost = s.pricing.ToolCost(tool)
+	s.save(ctx, record)
+}
+
+// save 保存用量记录
+func (s *BudgetService) save(ctx context.Context, record *domain.UsageRecord) {
+	if err := s.usageRepo.Save(ctx, record); err != nil {
+		s.logger.Error("Failed to save usage record",
+			zap.String("agent_id", record.AgentID.String()),
+			zap.String("kind", string(record.Kind)),
+			zap.Float64("cost", record.Cost),
+			zap.Error(err))
+	}
+}
+
+// SetBudget 设置预算，已存在时更新周期和上限
+func (s *BudgetService) SetBudget(ctx context.Context, cmd *SetBudgetCommand) (*BudgetStatus, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	budget, err := s.budgetRepo.FindByScope(ctx, cmd.Scope, cmd.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		budget, err = domain.NewBudget(cmd.Scope, cmd.ScopeID, cmd.Period, cmd.Limit)
+	} else {
+		err = budget.Update(cmd.Period, cmd.Limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.budgetRepo.Save(ctx, budget); err != nil {
+		s.logger.Error("Failed to save budget", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Budget set",
+		zap.String("scope", string(budget.Scope)),
+		zap.String("scope_id", budget.ScopeID.String()),
+		zap.String("period", string(budget.Period)),
+		zap.Float64("limit", budget.Limit))
+
+	return s.statusOf(ctx, budget)
+}
+
+// GetBudgetStatus 获取预算及当前周期的花费，未设置预算时返回nil
+func (s *BudgetService) GetBudgetStatus(ctx context.Context, scope domain.BudgetScope, scopeID uuid.UUID) (*BudgetStatus, error) {
+	return s.budgetStatus(ctx, scope, scopeID)
+}
+
+// DeleteBudget 删除预算，取消上限
+func (s *BudgetService) DeleteBudget(ctx context.Context, scope domain.BudgetScope, scopeID uuid.UUID) error {
+	return s.budgetRepo.Delete(ctx, scope, scopeID)
+}
+
+// GetSpendReport 按维度汇总花费，未指定时间范围时统计本月
+func (s *BudgetService) GetSpendReport(ctx context.Context, query *SpendReportQuery) (*SpendReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	from, to := query.From, query.To
+	if from.IsZero() && to.IsZero() {
+		from = domain.PeriodStart(domain.BudgetPeriodMonthly, time.Now())
+	}
+
+	summaries, err := s.usageRepo.Summarize(ctx, domain.UsageFilter{
+		OwnerID: query.OwnerID,
+		AgentID: query.AgentID,
+		From:    from,
+		To:      to,
+	}, query.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SpendReport{
+		GroupBy: query.GroupBy,
+		From:    from,
+		To:      to,
+		Items:   summaries,
+	}
+	for _, summary := range summaries {
+		report.TotalCalls += summary.Calls
+		report.TotalTokens += summary.TotalTokens
+		report.TotalCost += summary.Cost
+	}
+	return report, nil
+}
+
+// budgetStatus 查询范围的预算状态，未设置预算时返回nil
+func (s *BudgetService) budgetStatus(ctx context.Context, scope domain.BudgetScope, scopeID uuid.UUID) (*BudgetStatus, error) {
+	budget, err := s.budgetRepo.FindByScope(ctx, scope, scopeID)
+	if err != nil || budget == nil {
+		return nil, err
+	}
+	return s.statusOf(ctx, budget)
+}
+
+// statusOf 计算预算当前周期的花费
+func (s *BudgetService) statusOf(ctx context.Context, budget *domain.Budget) (*BudgetStatus, error) {
+	periodStart := budget.PeriodStart(time.Now())
+	spent, err := s.usageRepo.SumCost(ctx, budget.Scope, budget.ScopeID, periodStart)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := budget.Limit - spent
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &BudgetStatus{
+		Budget:      budget,
+		PeriodStart: periodStart,
+		Spent:       spent,
+		Remaining:   remaining,
+		Exceeded:    budget.Exceeded(spent),
+	}, nil
+}
+
+// BudgetStatus 预算状态
+type BudgetStatus struct {
+	Budget      *domain.Budget `json:"budget"`
+	PeriodStart time.Time      `json:"period_start"`
+	Spent       float64        `json:"spent"`
+	Remaining   float64        `json:"remaining"`
+	Exceeded    bool           `json:"exceeded"`
+}
+
+// SpendReport 花费报表
+type SpendReport struct {
+	GroupBy     domain.UsageGroupBy    `json:"group_by"`
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to,omitempty"`
+	TotalCalls  int64                  `json:"total_calls"`
+	TotalTokens int64                  `json:"total_tokens"`
+	TotalCost   float64                `json:"total_cost"`
+	Items       []*domain.UsageSummary `json:"items"`
+}
+
+// toFloat 将配置值转换为浮点数
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
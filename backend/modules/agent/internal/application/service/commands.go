@@ -2,10 +2,12 @@ package service
 
 import (
 	"errors"
+	"time"
 	
 	"github.com/google/uuid"
 	"github.com/noah-loop/backend/modules/agent/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/application"
+	"github.com/noah-loop/backend/shared/pkg/validation"
 )
 
 // CreateAgentCommand 创建智能体命令
@@ -19,6 +21,7 @@ type CreateAgentCommand struct {
 	Config         map[string]interface{}        `json:"config"`
 	Capabilities   []string                      `json:"capabilities"`
 	MemoryCapacity int                           `json:"memory_capacity"`
+	MemoryEvictionPolicy domain.MemoryEvictionPolicy `json:"memory_eviction_policy"`
 }
 
 func NewCreateAgentCommand() *CreateAgentCommand {
@@ -33,13 +36,16 @@ func NewCreateAgentCommand() *CreateAgentCommand {
 	}
 }
 
+// Validate 校验命令，返回全部字段错误，HTTP和gRPC接口共用
 func (c *CreateAgentCommand) Validate() error {
+	var errs validation.Errors
+	
 	if c.Name == "" {
-		return errors.New("agent name is required")
+		errs = append(errs, validation.NewFieldError("name", "agent name is required"))
 	}
 	
 	if c.OwnerID == uuid.Nil {
-		return errors.New("owner ID is required")
+		errs = append(errs, validation.NewFieldError("owner_id", "owner ID is required"))
 	}
 	
 	// 验证智能体类型
@@ -47,7 +53,19 @@ func (c *CreateAgentCommand) Validate() error {
 	case domain.AgentTypeConversational, domain.AgentTypeTask, domain.AgentTypeReflective, domain.AgentTypePlanning, domain.AgentTypeMultiModal:
 		// valid
 	default:
-		return errors.New("invalid agent type")
+		errs = append(errs, validation.NewFieldError("type", "invalid agent type"))
+	}
+	
+	if err := domain.ValidatePromptTemplate(c.SystemPrompt); err != nil {
+		errs = append(errs, validation.NewFieldError("system_prompt", err.Error()))
+	}
+	
+	if c.MemoryEvictionPolicy != "" && !c.MemoryEvictionPolicy.IsValid() {
+		errs = append(errs, validation.NewFieldError("memory_eviction_policy", "memory eviction policy must be importance or lru"))
+	}
+	
+	if len(errs) > 0 {
+		return errs
 	}
 	
 	if c.MemoryCapacity <= 0 {
@@ -82,6 +100,11 @@ func (c *UpdateAgentCommand) Validate() error {
 	if c.AgentID == uuid.Nil {
 		return errors.New("agent ID is required")
 	}
+	if c.SystemPrompt != nil {
+		if err := domain.ValidatePromptTemplate(*c.SystemPrompt); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -92,6 +115,8 @@ type ExecuteToolCommand struct {
 	ToolID  uuid.UUID                 `json:"tool_id" binding:"required"`
 	Input   map[string]interface{}    `json:"input" binding:"required"`
 	Context map[string]interface{}    `json:"context"`
+	// SessionID 发起调用的会话，设置后调用和结果记入该会话的运行记录
+	SessionID uuid.UUID `json:"session_id"`
 }
 
 func NewExecuteToolCommand() *ExecuteToolCommand {
@@ -121,6 +146,49 @@ func (c *ExecuteToolCommand) Validate() error {
 	return nil
 }
 
+// ResolveToolApprovalCommand 审批工具调用命令
+type ResolveToolApprovalCommand struct {
+	application.BaseCommand
+	ExecutionID uuid.UUID `json:"-"`
+	ApproverID     uuid.UUID `json:"-"` // 审批人，取自网关认证的用户身份
+	ApproverScopes []string  `json:"-"` // 审批人的权限范围，取自网关认证的用户身份
+	Approved       bool      `json:"-"`
+	Comment        string    `json:"comment"`
+}
+
+func NewResolveToolApprovalCommand(executionID uuid.UUID, approved bool) *ResolveToolApprovalCommand {
+	return &ResolveToolApprovalCommand{
+		BaseCommand: application.BaseCommand{
+			CommandID:   uuid.New(),
+			CommandType: "resolve_tool_approval",
+		},
+		ExecutionID: executionID,
+		Approved:    approved,
+	}
+}
+
+func (c *ResolveToolApprovalCommand) Validate() error {
+	if c.ExecutionID == uuid.Nil {
+		return errors.New("execution ID is required")
+	}
+	
+	if c.ApproverID == uuid.Nil {
+		return domain.ErrApproverRequired
+	}
+	
+	return nil
+}
+
+// HasScope 审批人是否有指定权限范围
+func (c *ResolveToolApprovalCommand) HasScope(scope string) bool {
+	for _, s := range c.ApproverScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // ChatCommand 对话命令
 type ChatCommand struct {
 	application.BaseCommand
@@ -164,6 +232,8 @@ type CreateToolCommand struct {
 	Config        map[string]interface{}        `json:"config"`
 	ExecutionMode domain.ToolExecutionMode      `json:"execution_mode"`
 	IsPublic      bool                          `json:"is_public"`
+	RequiresApproval bool                       `json:"requires_approval"` // 每次调用都需要人工审批
+	DangerScopes  []domain.DangerScope          `json:"danger_scopes"`     // 危险操作范围，声明后同样需要审批
 }
 
 func NewCreateToolCommand() *CreateToolCommand {
@@ -204,6 +274,10 @@ func (c *CreateToolCommand) Validate() error {
 		return errors.New("invalid execution mode")
 	}
 	
+	if err := domain.ValidateDangerScopes(c.DangerScopes); err != nil {
+		return err
+	}
+	
 	return nil
 }
 
@@ -375,3 +449,72 @@ func (q *SearchMemoryQuery) Validate() error {
 	
 	return nil
 }
+
+// SetBudgetCommand 设置预算命令
+type SetBudgetCommand struct {
+	application.BaseCommand
+	Scope   domain.BudgetScope  `json:"scope" binding:"required"`
+	ScopeID uuid.UUID           `json:"scope_id" binding:"required"`
+	Period  domain.BudgetPeriod `json:"period"`
+	Limit   float64             `json:"limit"`
+}
+
+func NewSetBudgetCommand() *SetBudgetCommand {
+	return &SetBudgetCommand{
+		BaseCommand: application.BaseCommand{
+			CommandID:   uuid.New(),
+			CommandType: "set_budget",
+		},
+		Period: domain.BudgetPeriodMonthly,
+	}
+}
+
+func (c *SetBudgetCommand) Validate() error {
+	if c.Scope != domain.BudgetScopeAgent && c.Scope != domain.BudgetScopeOwner {
+		return errors.New("scope must be agent or owner")
+	}
+	
+	if c.ScopeID == uuid.Nil {
+		return errors.New("scope ID is required")
+	}
+	
+	if c.Limit < 0 {
+		return errors.New("limit cannot be negative")
+	}
+	
+	return nil
+}
+
+// SpendReportQuery 花费报表查询
+type SpendReportQuery struct {
+	application.BaseQuery
+	OwnerID *uuid.UUID          `form:"owner_id"`
+	AgentID *uuid.UUID          `form:"agent_id"`
+	From    time.Time           `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      time.Time           `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	GroupBy domain.UsageGroupBy `form:"group_by,default=owner"`
+}
+
+func NewSpendReportQuery() *SpendReportQuery {
+	return &SpendReportQuery{
+		BaseQuery: application.BaseQuery{
+			QueryID:   uuid.New(),
+			QueryType: "spend_report",
+		},
+		GroupBy: domain.UsageGroupByOwner,
+	}
+}
+
+func (q *SpendReportQuery) Validate() error {
+	switch q.GroupBy {
+	case domain.UsageGroupByOwner, domain.UsageGroupByAgent, domain.UsageGroupByKind, domain.UsageGroupByResource:
+	default:
+		return errors.New("group_by must be one of owner, agent, kind, resource")
+	}
+	
+	if !q.From.IsZero() && !q.To.IsZero() && !q.To.After(q.From) {
+		return errors.New("to must be after from")
+	}
+	
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// ErrEmbeddingServiceNotConfigured 未注册嵌入服务，无法进行语义搜索
+var ErrEmbeddingServiceNotConfigured = errors.New("embedding service not configured")
+
+// EmbeddingService 文本嵌入服务，用于记忆的语义搜索
+type EmbeddingService interface {
+	// GenerateEmbeddings 批量生成嵌入向量，返回的向量与texts一一对应
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error)
+
+	// GetModel 获取模型名称，不同模型生成的向量不可比较
+	GetModel() string
+}
+
+// MemorySearchHit 记忆搜索结果，不包含嵌入向量
+type MemorySearchHit struct {
+	ID          string                 `json:"id"`
+	Content     string                 `json:"content"`
+	Type        domain.MemoryType      `json:"type"`
+	Importance  float64                `json:"importance"`
+	Tags        []string               `json:"tags"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+	AccessCount int                    `json:"access_count"`
+	Score       float64                `json:"score"`
+}
+
+// RegisterEmbeddingService 注册嵌入服务
+func (s *AgentService) RegisterEmbeddingService(embeddingService EmbeddingService) {
+	s.embeddingService = embeddingService
+}
+
+// SearchMemory 语义搜索智能体记忆：将查询生成嵌入向量，按余弦相似度从高到低返回最相近的记忆
+// 缺少当前模型嵌入向量的记忆（早于嵌入服务写入或换了模型）与查询一起补全向量并保存
+func (s *AgentService) SearchMemory(ctx context.Context, query *SearchMemoryQuery) (*application.Result, error) {
+	if err := query.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	if s.embeddingService == nil {
+		return &application.Result{Success: false, Error: ErrEmbeddingServiceNotConfigured.Error()}, ErrEmbeddingServiceNotConfigured
+	}
+
+	agent, err := s.agentRepo.FindByID(ctx, query.AgentID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "agent not found"}, err
+	}
+
+	if agent.Memory == nil {
+		return &application.Result{Success: true, Data: []MemorySearchHit{}}, nil
+	}
+
+	model := s.embeddingService.GetModel()
+	missing := agent.Memory.MemoriesWithoutEmbedding(model, query.Type)
+
+	texts := make([]string, 0, len(missing)+1)
+	texts = append(texts, query.Query)
+	for _, memory := range missing {
+		texts = append(texts, memory.Content)
+	}
+
+	embeddings, err := s.embeddingService.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		s.logger.Error("Failed to embed memory search query", zap.String("agent_id", agent.ID.String()), zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to embed query"}, err
+	}
+	if len(embeddings) != len(texts) {
+		err := fmt.Errorf("embedding service returned %d embeddings for %d texts", len(embeddings), len(texts))
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	for i, memory := range missing {
+		memory.SetEmbedding(embeddings[i+1], model)
+	}
+
+	scored := agent.Memory.SearchSimilar(embeddings[0], model, query.Type, query.Limit)
+
+	// 保存补全的向量和访问计数，失败不影响本次结果
+	if len(missing) > 0 || len(scored) > 0 {
+		if err := s.agentRepo.Save(ctx, agent); err != nil {
+			s.logger.Warn("Failed to save searched memories", zap.String("agent_id", agent.ID.String()), zap.Error(err))
+		}
+	}
+
+	hits := make([]MemorySearchHit, 0, len(scored))
+	for _, result := range scored {
+		hits = append(hits, MemorySearchHit{
+			ID:          result.Memory.ID.String(),
+			Content:     result.Memory.Content,
+			Type:        result.Memory.Type,
+			Importance:  result.Memory.Importance,
+			Tags:        result.Memory.Tags,
+			Context:     result.Memory.Context,
+			AccessCount: result.Memory.AccessCount,
+			Score:       result.Score,
+		})
+	}
+
+	return &application.Result{Success: true, Data: hits}, nil
+}
+
+// Learn 让智能体学习知识，学到的记忆带有嵌入向量以便语义搜索
+func (s *AgentService) Learn(ctx context.Context, cmd *LearnCommand) (*application.Result, error) {
+	if err := cmd.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	agent, err := s.agentRepo.FindByID(ctx, cmd.AgentID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "agent not found"}, err
+	}
+
+	memory, err := s.learn(ctx, agent, cmd.Knowledge, cmd.Importance, cmd.Tags...)
+	if err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	if err := s.agentRepo.Save(ctx, agent); err != nil {
+		s.logger.Error("Failed to save agent", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to save agent"}, err
+	}
+
+	for _, event := range agent.GetDomainEvents() {
+		if err := s.eventBus.Publish(ctx, event); err != nil {
+			s.logger.Warn("Failed to publish event", zap.Error(err))
+		}
+	}
+	agent.ClearDomainEvents()
+
+	return &application.Result{Success: true, Data: memory}, nil
+}
+
+// learn 调用agent.Learn并为学到的记忆生成嵌入向量，调用方负责保存智能体
+// 生成嵌入向量失败时只记录警告，记忆照常保存，之后搜索时再补全
+func (s *AgentService) learn(ctx context.Context, agent *domain.Agent, knowledge string, importance float64, tags ...string) (*domain.Memory, error) {
+	memory, err := agent.Learn(knowledge, importance)
+	if err != nil {
+		return nil, err
+	}
+	memory.Tags = append(memory.Tags, tags...)
+
+	if s.embeddingService == nil {
+		return memory, nil
+	}
+
+	embeddings, err := s.embeddingService.GenerateEmbeddings(ctx, []string{memory.Content})
+	if err != nil || len(embeddings) != 1 {
+		s.logger.Warn("Failed to embed learned memory",
+			zap.String("agent_id", agent.ID.String()),
+			zap.String("memory_id", memory.ID.String()),
+			zap.Error(err))
+		return memory, nil
+	}
+	memory.SetEmbedding(embeddings[0], s.embeddingService.GetModel())
+
+	return memory, nil
+}
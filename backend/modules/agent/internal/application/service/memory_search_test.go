@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+)
+
+// topicEmbedder 按话题关键词生成向量，维度依次为 会议、饮食、天气
+// 同一话题的文本用词不同也得到相近的向量，模拟语义嵌入
+type topicEmbedder struct {
+	calls [][]string
+}
+
+var embedderTopics = [][]string{
+	{"meeting", "appointment", "call", "sync"},
+	{"pizza", "lunch", "eat"},
+	{"rain", "sunny"},
+}
+
+func (e *topicEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	e.calls = append(e.calls, texts)
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := []float64{0.01, 0.01, 0.01}
+		for dimension, words := range embedderTopics {
+			for _, word := range words {
+				if strings.Contains(text, word) {
+					vector[dimension]++
+				}
+			}
+		}
+		embeddings[i] = vector
+	}
+	return embeddings, nil
+}
+
+func (e *topicEmbedder) GetModel() string { return "topic-v1" }
+
+// newMemorySearchTestService 创建注册了话题嵌入服务的智能体服务，智能体已学到三条知识
+func newMemorySearchTestService(t *testing.T) (*AgentService, *domain.Agent, *topicEmbedder) {
+	t.Helper()
+	agent := domain.NewAgent("assistant", domain.AgentTypeConversational, uuid.New())
+	agent.Memory = domain.NewAgentMemory(agent.ID)
+	service, _ := newTestAgentService(agent, nil)
+	embedder := &topicEmbedder{}
+	service.RegisterEmbeddingService(embedder)
+
+	for _, knowledge := range []string{
+		"Customer prefers the weekly sync on Friday",
+		"Schedule: pizza lunch for the team",
+		"It was sunny when we scheduled things",
+	} {
+		if _, err := service.learn(context.Background(), agent, knowledge, 0.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return service, agent, embedder
+}
+
+// searchMemory 执行记忆搜索并返回命中结果
+func searchMemory(t *testing.T, service *AgentService, query *SearchMemoryQuery) []MemorySearchHit {
+	t.Helper()
+	result, err := service.SearchMemory(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Data.([]MemorySearchHit)
+}
+
+func TestLearnStoresEmbedding(t *testing.T) {
+	_, agent, embedder := newMemorySearchTestService(t)
+
+	for _, memory := range agent.Memory.Memories {
+		if !memory.HasEmbedding("topic-v1") {
+			t.Fatalf("learned memory %q has no embedding", memory.Content)
+		}
+	}
+	if len(embedder.calls) != 3 {
+		t.Fatalf("embedder called %d times, want once per learned memory", len(embedder.calls))
+	}
+}
+
+func TestSearchMemoryRanksSemanticMatchesFirst(t *testing.T) {
+	service, agent, embedder := newMemorySearchTestService(t)
+
+	// 早于嵌入服务写入的记忆，与查询用词重合但话题不同
+	legacy := domain.NewMemory("When is it scheduled? It was sunny", domain.MemoryTypeEpisodic, 0.9)
+	if err := agent.Memory.AddMemory(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	query := NewSearchMemoryQuery()
+	query.AgentID = agent.ID
+	query.Query = "when is the meeting scheduled"
+	query.Limit = 3
+	hits := searchMemory(t, service, query)
+
+	if len(hits) != 3 || !strings.Contains(hits[0].Content, "weekly sync") {
+		t.Fatalf("hits = %+v, want the weekly sync first", hits)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Fatalf("hits not ordered by relevance: %+v", hits)
+		}
+	}
+
+	// 缺少向量的记忆与查询一起补全
+	if !legacy.HasEmbedding("topic-v1") {
+		t.Fatal("legacy memory should be embedded during search")
+	}
+	if last := embedder.calls[len(embedder.calls)-1]; len(last) != 2 {
+		t.Fatalf("embedded %q, want the query and the legacy memory", last)
+	}
+
+	query.Query = "where to eat"
+	query.Limit = 10
+	if hits := searchMemory(t, service, query); len(hits) != 4 || !strings.Contains(hits[0].Content, "pizza") {
+		t.Fatalf("hits = %+v, want the pizza lunch first", hits)
+	}
+	if last := embedder.calls[len(embedder.calls)-1]; len(last) != 1 {
+		t.Fatalf("embedded %q, want only the query", last)
+	}
+}
+
+func TestSearchMemoryFiltersByType(t *testing.T) {
+	service, agent, _ := newMemorySearchTestService(t)
+	if err := agent.Memory.AddMemory(domain.NewMemory("Booked a call with the customer", domain.MemoryTypeEpisodic, 0.9)); err != nil {
+		t.Fatal(err)
+	}
+
+	learned := domain.MemoryTypeLearned
+	query := NewSearchMemoryQuery()
+	query.AgentID = agent.ID
+	query.Query = "book an appointment"
+	query.Type = &learned
+	query.Limit = 1
+	hits := searchMemory(t, service, query)
+	if len(hits) != 1 || hits[0].Type != domain.MemoryTypeLearned || !strings.Contains(hits[0].Content, "weekly sync") {
+		t.Fatalf("hits = %+v", hits)
+	}
+}
+
+func TestSearchMemoryWithoutEmbeddingService(t *testing.T) {
+	agent := domain.NewAgent("assistant", domain.AgentTypeConversational, uuid.New())
+	agent.Memory = domain.NewAgentMemory(agent.ID)
+	service, _ := newTestAgentService(agent, nil)
+
+	query := NewSearchMemoryQuery()
+	query.AgentID = agent.ID
+	query.Query = "anything"
+	if _, err := service.SearchMemory(context.Background(), query); err != ErrEmbeddingServiceNotConfigured {
+		t.Fatalf("err = %v, want ErrEmbeddingServiceNotConfigured", err)
+	}
+}
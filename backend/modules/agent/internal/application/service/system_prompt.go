@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// AgentConfigTimezone 智能体配置中的时区键，如"Asia/Shanghai"，用于系统提示中的日期时间变量，未配置时使用UTC
+	AgentConfigTimezone = "timezone"
+	// AgentConfigPromptVariables 智能体配置中的自定义提示变量键，值为对象，同名时内置变量优先
+	AgentConfigPromptVariables = "prompt_variables"
+)
+
+// 系统提示的内置变量
+const (
+	PromptVariableAgentName        = "agent_name"
+	PromptVariableAgentType        = "agent_type"
+	PromptVariableAgentDescription = "agent_description"
+	PromptVariableOwnerID          = "owner_id"
+	PromptVariableCapabilities     = "capabilities"
+	PromptVariableCurrentDate      = "current_date"
+	PromptVariableCurrentTime      = "current_time"
+	PromptVariableCurrentWeekday   = "current_weekday"
+	PromptVariableTimezone         = "timezone"
+)
+
+// systemPromptVariables 生成系统提示变量：智能体信息、当前日期时间和配置中的自定义变量
+func systemPromptVariables(agent *domain.Agent, now time.Time) map[string]string {
+	variables := make(map[string]string)
+	if custom, ok := agent.Config[AgentConfigPromptVariables].(map[string]interface{}); ok {
+		for name, value := range custom {
+			variables[name] = fmt.Sprint(value)
+		}
+	}
+
+	location := time.UTC
+	if name, ok := agent.Config[AgentConfigTimezone].(string); ok && name != "" {
+		if loaded, err := time.LoadLocation(name); err == nil {
+			location = loaded
+		}
+	}
+	now = now.In(location)
+
+	variables[PromptVariableAgentName] = agent.Name
+	variables[PromptVariableAgentType] = string(agent.Type)
+	variables[PromptVariableAgentDescription] = agent.Description
+	variables[PromptVariableOwnerID] = agent.OwnerID.String()
+	variables[PromptVariableCapabilities] = strings.Join(agent.Capabilities, ", ")
+	variables[PromptVariableCurrentDate] = now.Format("2006-01-02")
+	variables[PromptVariableCurrentTime] = now.Format("15:04")
+	variables[PromptVariableCurrentWeekday] = now.Weekday().String()
+	variables[PromptVariableTimezone] = location.String()
+
+	return variables
+}
+
+// renderSystemPrompt 在对话时渲染智能体的系统提示，渲染失败时记录警告并使用原始提示
+func (s *AgentService) renderSystemPrompt(agent *domain.Agent, now time.Time) string {
+	prompt, err := domain.RenderPromptTemplate(agent.SystemPrompt, systemPromptVariables(agent, now))
+	if err != nil {
+		s.logger.Warn("Failed to render system prompt, using it as is",
+			zap.String("agent_id", agent.ID.String()),
+			zap.Error(err))
+		return agent.SystemPrompt
+	}
+	return prompt
+}
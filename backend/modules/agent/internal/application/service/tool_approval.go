@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// requestToolApproval 为需要审批的工具调用创建等待审批的执行记录，不调用工具
+func (s *AgentService) requestToolApproval(ctx context.Context, tool *domain.Tool, agent *domain.Agent, execution *domain.ToolExecution) (*application.Result, error) {
+	execution.RequestApproval(tool.DangerScopes)
+	if err := s.toolExecutionRepo.Save(ctx, execution); err != nil {
+		return &application.Result{Success: false, Error: "failed to save execution"}, err
+	}
+	s.recordToolCall(ctx, tool, execution)
+
+	s.logger.Info("Tool execution awaiting approval",
+		zap.String("execution_id", execution.ID.String()),
+		zap.String("tool_name", tool.Name),
+		zap.String("agent_id", agent.ID.String()))
+	s.publishApprovalEvent(ctx, "tool.execution.approval_requested", execution)
+
+	return &application.Result{Success: true, Data: execution}, nil
+}
+
+// ResolveToolApproval 审批等待中的工具调用，审批人需要有审批权限范围
+// 审批结果以等待审批状态为条件保存，并发审批时只有一个请求生效，其余返回错误；
+// 通过后重新检查工具、智能体权限和预算，按工具的执行模式执行；拒绝后工具不会被调用
+func (s *AgentService) ResolveToolApproval(ctx context.Context, cmd *ResolveToolApprovalCommand) (*application.Result, error) {
+	if err := cmd.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	if !cmd.HasScope(s.approverScope) {
+		return &application.Result{Success: false, Error: domain.ErrApproverNotAllowed.Error()}, domain.ErrApproverNotAllowed
+	}
+
+	execution, err := s.toolExecutionRepo.FindByID(ctx, cmd.ExecutionID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "execution not found"}, err
+	}
+
+	if !cmd.Approved {
+		if err := execution.Reject(cmd.ApproverID, cmd.Comment); err != nil {
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+		if err := s.claimApproval(ctx, execution); err != nil {
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+		s.recordToolResult(ctx, execution)
+		s.logger.Info("Tool execution rejected",
+			zap.String("execution_id", execution.ID.String()),
+			zap.String("approver_id", cmd.ApproverID.String()))
+		s.publishApprovalEvent(ctx, "tool.execution.rejected", execution)
+		return &application.Result{Success: true, Data: execution}, nil
+	}
+
+	if err := execution.Approve(cmd.ApproverID, cmd.Comment); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	if err := s.claimApproval(ctx, execution); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	s.logger.Info("Tool execution approved",
+		zap.String("execution_id", execution.ID.String()),
+		zap.String("approver_id", cmd.ApproverID.String()))
+	s.publishApprovalEvent(ctx, "tool.execution.approved", execution)
+
+	// 等待审批期间工具或智能体可能已变更
+	tool, err := s.toolRepo.FindByID(ctx, execution.ToolID)
+	if err != nil {
+		return s.failApprovedExecution(ctx, execution, fmt.Errorf("tool not found"))
+	}
+	agent, err := s.agentRepo.FindByID(ctx, execution.AgentID)
+	if err != nil {
+		return s.failApprovedExecution(ctx, execution, fmt.Errorf("agent not found"))
+	}
+	if !tool.IsEnabled {
+		return s.failApprovedExecution(ctx, execution, fmt.Errorf("tool is disabled"))
+	}
+	if !agent.CanUse(tool.Name) {
+		return s.failApprovedExecution(ctx, execution, fmt.Errorf("agent cannot use this tool"))
+	}
+	if err := s.checkBudget(ctx, agent); err != nil {
+		return s.failApprovedExecution(ctx, execution, err)
+	}
+
+	return s.runToolExecution(ctx, tool, agent, execution, nil)
+}
+
+// claimApproval 保存审批结果，执行已被其他请求审批时返回工具错误
+func (s *AgentService) claimApproval(ctx context.Context, execution *domain.ToolExecution) error {
+	claimed, err := s.toolExecutionRepo.ResolveApproval(ctx, execution)
+	if err != nil {
+		s.logger.Error("Failed to save tool approval",
+			zap.String("execution_id", execution.ID.String()),
+			zap.Error(err))
+		return fmt.Errorf("failed to save execution: %w", err)
+	}
+	if !claimed {
+		return domain.NewToolError("execution has already been resolved")
+	}
+	return nil
+}
+
+// GetPendingApprovals 获取等待审批的工具调用
+func (s *AgentService) GetPendingApprovals(ctx context.Context) (*application.Result, error) {
+	executions, err := s.toolExecutionRepo.FindByStatus(ctx, domain.ExecutionStatusAwaitingApproval)
+	if err != nil {
+		s.logger.Error("Failed to get pending approvals", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to get pending approvals"}, err
+	}
+	return &application.Result{Success: true, Data: executions}, nil
+}
+
+// failApprovedExecution 审批通过后无法执行时将执行标记为失败
+func (s *AgentService) failApprovedExecution(ctx context.Context, execution *domain.ToolExecution, err error) (*application.Result, error) {
+	execution.Fail(err.Error(), 0)
+	s.toolExecutionRepo.Save(ctx, execution)
+	s.recordToolResult(ctx, execution)
+	return &application.Result{Success: false, Error: err.Error()}, err
+}
+
+// publishApprovalEvent 发布审批相关事件
+func (s *AgentService) publishApprovalEvent(ctx context.Context, eventType string, execution *domain.ToolExecution) {
+	if s.eventBus == nil {
+		return
+	}
+	event := &application.BaseDomainEvent{
+		EventType:   eventType,
+		AggregateID: execution.ID,
+		EventData: map[string]interface{}{
+			"execution_id": execution.ID,
+			"agent_id":     execution.AgentID,
+			"tool_id":      execution.ToolID,
+			"status":       execution.Status,
+			"approval":     execution.Approval,
+		},
+	}
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.Warn("Failed to publish event", zap.Error(err))
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// ErrToolNotStreamable 流式调用的工具不是流式执行模式
+var ErrToolNotStreamable = errors.New("tool does not support streaming")
+
+// StreamingToolExecutor 支持流式输出的工具执行器，用于流式执行模式的工具
+// 未实现该接口的执行器在流式模式下通过Execute执行，整个输出作为唯一的片段
+type StreamingToolExecutor interface {
+	ToolExecutor
+
+	// ExecuteStream 开始执行并返回输出片段通道，执行结束后关闭通道
+	// ctx被取消时应停止执行并关闭通道，不再发送片段
+	ExecuteStream(ctx context.Context, request *ToolExecutionRequest) (<-chan ToolOutputChunk, error)
+}
+
+// ToolOutputChunk 流式工具的输出片段
+type ToolOutputChunk struct {
+	Output map[string]interface{}
+	Err    error // 执行出错时发送，之后不再发送片段
+}
+
+// ToolChunkHandler 处理流式工具的输出片段，返回错误时停止执行
+type ToolChunkHandler func(chunk map[string]interface{}) error
+
+// ExecuteToolStream 流式执行工具，输出片段按顺序交给onChunk，执行记录的输出为所有片段合并的结果
+// 只接受流式执行模式的工具；需要审批的工具返回等待审批的执行记录，审批通过后不再流式返回
+func (s *AgentService) ExecuteToolStream(ctx context.Context, cmd *ExecuteToolCommand, onChunk ToolChunkHandler) (*application.Result, error) {
+	if onChunk == nil {
+		return &application.Result{Success: false, Error: "chunk handler is required"}, fmt.Errorf("chunk handler is required")
+	}
+	return s.executeTool(ctx, cmd, onChunk)
+}
+
+// executeStreamTool 流式执行工具，每个输出片段先合并到执行记录再交给onChunk
+// 执行失败时执行记录保留已收到的片段，结果中同样带有执行记录
+func (s *AgentService) executeStreamTool(ctx context.Context, tool *domain.Tool, agent *domain.Agent, execution *domain.ToolExecution, executor ToolExecutor, onChunk ToolChunkHandler) (*application.Result, error) {
+	startTime := time.Now()
+
+	err := streamToolOutput(ctx, executor, &ToolExecutionRequest{
+		Tool:       tool,
+		Agent:      agent,
+		Input:      execution.Input,
+		Context:    execution.Context,
+		HTTPClient: s.httpClient,
+	}, func(chunk map[string]interface{}) error {
+		execution.AppendChunk(chunk)
+		if onChunk != nil {
+			return onChunk(chunk)
+		}
+		return nil
+	})
+
+	duration := time.Since(startTime)
+	s.recordToolUsage(ctx, agent, tool, execution)
+
+	if err != nil {
+		execution.Fail(err.Error(), duration)
+		tool.RecordUsage(duration, false)
+
+		s.toolExecutionRepo.Save(ctx, execution)
+		s.toolRepo.Save(ctx, tool)
+		s.recordToolResult(ctx, execution)
+
+		return &application.Result{Success: false, Error: err.Error(), Data: execution}, err
+	}
+
+	execution.Complete(execution.Output, duration)
+	tool.RecordUsage(duration, true)
+
+	s.toolExecutionRepo.Save(ctx, execution)
+	s.toolRepo.Save(ctx, tool)
+	s.recordToolResult(ctx, execution)
+
+	s.logger.Info("Tool stream completed",
+		zap.String("tool_name", tool.Name),
+		zap.Int("chunks", execution.Chunks),
+		zap.Duration("duration", duration),
+	)
+
+	return &application.Result{Success: true, Data: execution}, nil
+}
+
+// streamToolOutput 执行工具并将输出片段依次交给emit，emit返回错误时取消执行
+func streamToolOutput(ctx context.Context, executor ToolExecutor, request *ToolExecutionRequest, emit ToolChunkHandler) error {
+	streamer, ok := executor.(StreamingToolExecutor)
+	if !ok {
+		result, err := executor.Execute(ctx, request)
+		if err != nil {
+			return err
+		}
+		return emit(result.Output)
+	}
+
+	// 提前返回时取消执行器，避免执行器阻塞在发送片段上
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks, err := streamer.ExecuteStream(ctx, request)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return nil
+			}
+			if chunk.Err != nil {
+				return chunk.Err
+			}
+			if err := emit(chunk.Output); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
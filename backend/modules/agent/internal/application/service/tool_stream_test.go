@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+)
+
+// fakeStreamingExecutor 依次输出a、b、c三个片段，failAt非负时在该片段处出错
+type fakeStreamingExecutor struct {
+	failAt int
+}
+
+func (e *fakeStreamingExecutor) Execute(ctx context.Context, request *ToolExecutionRequest) (*ToolExecutionResult, error) {
+	return nil, errors.New("streaming executor should not be called synchronously")
+}
+
+func (e *fakeStreamingExecutor) GetSupportedType() domain.ToolType { return domain.ToolTypeCustom }
+
+func (e *fakeStreamingExecutor) ExecuteStream(ctx context.Context, request *ToolExecutionRequest) (<-chan ToolOutputChunk, error) {
+	chunks := make(chan ToolOutputChunk)
+	go func() {
+		defer close(chunks)
+		for i, text := range []string{"a", "b", "c"} {
+			chunk := ToolOutputChunk{Output: map[string]interface{}{"text": text, "index": i}}
+			if i == e.failAt {
+				chunk = ToolOutputChunk{Err: errors.New("tool crashed")}
+			}
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			if chunk.Err != nil {
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// newStreamTestService 创建带有一个指定执行模式工具的服务，返回执行该工具的命令
+func newStreamTestService(mode domain.ToolExecutionMode, executor ToolExecutor) (*AgentService, *recordingToolExecutionRepository, *ExecuteToolCommand) {
+	tool := domain.NewTool("search", domain.ToolTypeCustom, uuid.New())
+	tool.ExecutionMode = mode
+	agent := domain.NewAgent("agent", domain.AgentTypeTask, uuid.New())
+	agent.AddTool(tool)
+
+	service, executions := newTestAgentService(agent, tool)
+	service.RegisterToolExecutor(domain.ToolTypeCustom, executor)
+
+	cmd := NewExecuteToolCommand()
+	cmd.AgentID = agent.ID
+	cmd.ToolID = tool.ID
+	cmd.Input = map[string]interface{}{"q": 1}
+	return service, executions, cmd
+}
+
+func TestExecuteToolStreamThreeChunks(t *testing.T) {
+	service, executions, cmd := newStreamTestService(domain.ExecutionModeStream, &fakeStreamingExecutor{failAt: -1})
+
+	var received []string
+	result, err := service.ExecuteToolStream(context.Background(), cmd, func(chunk map[string]interface{}) error {
+		received = append(received, chunk["text"].(string))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(received, want) {
+		t.Fatalf("received chunks %q, want %q", received, want)
+	}
+
+	// 执行记录合并所有片段，字符串拼接，其他值取最后一个
+	execution := result.Data.(*domain.ToolExecution)
+	if execution.Status != domain.ExecutionStatusCompleted || execution.Chunks != 3 {
+		t.Fatalf("status = %s, chunks = %d", execution.Status, execution.Chunks)
+	}
+	if execution.Output["text"] != "abc" || execution.Output["index"] != 2 {
+		t.Fatalf("output = %v", execution.Output)
+	}
+	if last := executions.saved[len(executions.saved)-1]; last != execution {
+		t.Fatal("final execution record was not saved")
+	}
+}
+
+func TestExecuteToolMergesStreamedChunks(t *testing.T) {
+	// 非流式调用流式工具时同样合并片段
+	service, _, cmd := newStreamTestService(domain.ExecutionModeStream, &fakeStreamingExecutor{failAt: -1})
+	result, err := service.ExecuteTool(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := result.Data.(*domain.ToolExecution).Output; output["text"] != "abc" {
+		t.Fatalf("output = %v", output)
+	}
+}
+
+func TestExecuteToolStreamFailureKeepsChunks(t *testing.T) {
+	service, executions, cmd := newStreamTestService(domain.ExecutionModeStream, &fakeStreamingExecutor{failAt: 2})
+
+	received := 0
+	result, err := service.ExecuteToolStream(context.Background(), cmd, func(chunk map[string]interface{}) error {
+		received++
+		return nil
+	})
+	if err == nil || received != 2 {
+		t.Fatalf("err = %v, received %d chunks", err, received)
+	}
+	execution := result.Data.(*domain.ToolExecution)
+	if execution.Status != domain.ExecutionStatusFailed || execution.Output["text"] != "ab" {
+		t.Fatalf("status = %s, output = %v", execution.Status, execution.Output)
+	}
+	if last := executions.saved[len(executions.saved)-1]; last != execution {
+		t.Fatal("failed execution record was not saved")
+	}
+}
+
+func TestExecuteToolStreamHandlerErrorStopsExecution(t *testing.T) {
+	service, _, cmd := newStreamTestService(domain.ExecutionModeStream, &fakeStreamingExecutor{failAt: -1})
+
+	received := 0
+	_, err := service.ExecuteToolStream(context.Background(), cmd, func(chunk map[string]interface{}) error {
+		received++
+		return context.Canceled
+	})
+	if !errors.Is(err, context.Canceled) || received != 1 {
+		t.Fatalf("err = %v, received %d chunks", err, received)
+	}
+}
+
+func TestExecuteToolStreamRejectsSyncTool(t *testing.T) {
+	service, _, cmd := newStreamTestService(domain.ExecutionModeSync, &fakeStreamingExecutor{failAt: -1})
+	_, err := service.ExecuteToolStream(context.Background(), cmd, func(chunk map[string]interface{}) error { return nil })
+	if !errors.Is(err, ErrToolNotStreamable) {
+		t.Fatalf("expected ErrToolNotStreamable, got %v", err)
+	}
+}
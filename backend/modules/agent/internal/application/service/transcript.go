@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// ErrTranscriptNotConfigured 未配置运行记录存储
+var ErrTranscriptNotConfigured = errors.New("transcript store not configured")
+
+// transcriptRecorder 收集一次运行的步骤，运行结束后一并保存
+type transcriptRecorder struct {
+	agentID   uuid.UUID
+	sessionID uuid.UUID
+	runID     uuid.UUID
+	sequence  int
+	steps     []*domain.TranscriptStep
+}
+
+// newTranscriptRecorder 创建运行记录收集器
+func newTranscriptRecorder(agentID, sessionID, runID uuid.UUID) *transcriptRecorder {
+	return &transcriptRecorder{
+		agentID:   agentID,
+		sessionID: sessionID,
+		runID:     runID,
+	}
+}
+
+// add 追加一步，序号按追加顺序递增
+func (r *transcriptRecorder) add(stepType domain.TranscriptStepType) *domain.TranscriptStep {
+	r.sequence++
+	step := domain.NewTranscriptStep(r.agentID, r.sessionID, r.runID, r.sequence, stepType)
+	r.steps = append(r.steps, step)
+	return step
+}
+
+// addChatRequest 记录对话的输入：用户发言、读取的会话记忆和实际发给大模型的请求
+func (r *transcriptRecorder) addChatRequest(cmd *ChatCommand, history []*domain.Memory, request *LLMChatRequest) {
+	message := r.add(domain.TranscriptStepUserMessage)
+	message.Role = "user"
+	message.Content = cmd.Message
+	if len(cmd.Context) > 0 {
+		message.Data["context"] = cmd.Context
+	}
+
+	memoryIDs := make([]string, 0, len(history))
+	for _, memory := range history {
+		memoryIDs = append(memoryIDs, memory.ID.String())
+	}
+	read := r.add(domain.TranscriptStepMemoryRead)
+	read.Data["memory_type"] = domain.MemoryTypeConversation
+	read.Data["memory_ids"] = memoryIDs
+
+	messages := make([]map[string]interface{}, 0, len(request.Messages))
+	for _, m := range request.Messages {
+		messages = append(messages, map[string]interface{}{"role": m.Role, "content": m.Content})
+	}
+	llmRequest := r.add(domain.TranscriptStepLLMRequest)
+	llmRequest.Data["model"] = request.Model
+	llmRequest.Data["messages"] = messages
+	llmRequest.Data["max_tokens"] = request.MaxTokens
+	llmRequest.Data["temperature"] = request.Temperature
+}
+
+// addChatResponse 记录大模型回复
+func (r *transcriptRecorder) addChatResponse(response *LLMChatResponse, duration time.Duration) {
+	step := r.add(domain.TranscriptStepLLMResponse)
+	step.Role = "assistant"
+	step.Content = response.Content
+	step.Duration = duration
+	step.Data["model"] = response.Model
+	step.Data["prompt_tokens"] = response.PromptTokens
+	step.Data["completion_tokens"] = response.CompletionTokens
+	step.Data["tokens_used"] = response.TokensUsed
+}
+
+// addError 记录中断运行的错误
+func (r *transcriptRecorder) addError(err error, duration time.Duration) {
+	step := r.add(domain.TranscriptStepError)
+	step.Error = err.Error()
+	step.Duration = duration
+}
+
+// SetTranscriptRepository 设置运行记录存储，设置后对话和带会话ID的工具调用逐步记入会话的运行记录
+func (s *AgentService) SetTranscriptRepository(transcriptRepo domain.TranscriptRepository) {
+	s.transcriptRepo = transcriptRepo
+}
+
+// saveTranscript 保存收集到的步骤，保存失败只记录警告，不影响运行结果
+func (s *AgentService) saveTranscript(ctx context.Context, recorder *transcriptRecorder) {
+	if s.transcriptRepo == nil || len(recorder.steps) == 0 {
+		return
+	}
+	if err := s.transcriptRepo.SaveSteps(ctx, recorder.steps); err != nil {
+		s.logger.Warn("Failed to save transcript",
+			zap.String("agent_id", recorder.agentID.String()),
+			zap.String("session_id", recorder.sessionID.String()),
+			zap.String("run_id", recorder.runID.String()),
+			zap.Error(err))
+	}
+}
+
+// recordToolCall 记录会话中发起的工具调用，以执行ID作为运行ID，不属于会话的调用不记录
+func (s *AgentService) recordToolCall(ctx context.Context, tool *domain.Tool, execution *domain.ToolExecution) {
+	sessionID := execution.SessionID()
+	if s.transcriptRepo == nil || sessionID == uuid.Nil {
+		return
+	}
+	recorder := newTranscriptRecorder(execution.AgentID, sessionID, execution.ID)
+	step := recorder.add(domain.TranscriptStepToolCall)
+	step.ToolExecutionID = &execution.ID
+	step.Data["tool_id"] = tool.ID
+	step.Data["tool_name"] = tool.Name
+	step.Data["tool_type"] = tool.Type
+	step.Data["input"] = execution.Input
+	step.Data["status"] = execution.Status
+	s.saveTranscript(ctx, recorder)
+}
+
+// recordToolResult 记录工具调用的最终结果（完成、失败或被拒绝），接在同一执行的调用步骤之后
+func (s *AgentService) recordToolResult(ctx context.Context, execution *domain.ToolExecution) {
+	sessionID := execution.SessionID()
+	if s.transcriptRepo == nil || sessionID == uuid.Nil {
+		return
+	}
+	recorder := newTranscriptRecorder(execution.AgentID, sessionID, execution.ID)
+	recorder.sequence = 1 // 第1步为调用步骤
+	step := recorder.add(domain.TranscriptStepToolResult)
+	step.ToolExecutionID = &execution.ID
+	step.Error = execution.Error
+	step.Duration = execution.Duration
+	step.Data["status"] = execution.Status
+	step.Data["output"] = execution.Output
+	if execution.Chunks > 0 {
+		step.Data["chunks"] = execution.Chunks
+	}
+	if execution.Approval != nil {
+		step.Data["approval"] = execution.Approval
+	}
+	s.saveTranscript(ctx, recorder)
+}
+
+// GetSessionTranscript 获取会话的运行记录
+// 步骤按执行顺序排列：每次对话依次为用户发言、记忆读取、大模型请求和回复（或错误），会话中的工具调用为调用和结果两步
+func (s *AgentService) GetSessionTranscript(ctx context.Context, agentID, sessionID uuid.UUID) (*application.Result, error) {
+	if agentID == uuid.Nil {
+		return &application.Result{Success: false, Error: "agent ID is required"}, fmt.Errorf("agent ID is required")
+	}
+	if sessionID == uuid.Nil {
+		return &application.Result{Success: false, Error: "session ID is required"}, fmt.Errorf("session ID is required")
+	}
+	if s.transcriptRepo == nil {
+		return &application.Result{Success: false, Error: ErrTranscriptNotConfigured.Error()}, ErrTranscriptNotConfigured
+	}
+
+	if _, err := s.agentRepo.FindByID(ctx, agentID); err != nil {
+		return &application.Result{Success: false, Error: "agent not found"}, err
+	}
+
+	steps, err := s.transcriptRepo.FindBySession(ctx, agentID, sessionID)
+	if err != nil {
+		s.logger.Error("Failed to get session transcript",
+			zap.String("agent_id", agentID.String()),
+			zap.String("session_id", sessionID.String()),
+			zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to get transcript"}, err
+	}
+	return &application.Result{Success: true, Data: steps}, nil
+}
@@ -169,10 +169,10 @@ func (a *Agent) RemoveTool(toolID uuid.UUID) error {
 	return NewAgentError("tool not found")
 }
 
-// Learn 学习新知识
-func (a *Agent) Learn(knowledge string, importance float64) error {
+// Learn 学习新知识，返回新增的学习记忆
+func (a *Agent) Learn(knowledge string, importance float64) (*Memory, error) {
 	if a.Memory == nil {
-		return NewAgentError("agent memory not initialized")
+		return nil, NewAgentError("agent memory not initialized")
 	}
 	
 	// 创建记忆条目
@@ -180,7 +180,7 @@ func (a *Agent) Learn(knowledge string, importance float64) error {
 	
 	// 添加到记忆中
 	if err := a.Memory.AddMemory(memory); err != nil {
-		return err
+		return nil, err
 	}
 	
 	a.MarkAsModified()
@@ -192,7 +192,35 @@ func (a *Agent) Learn(knowledge string, importance float64) error {
 	})
 	a.domainEvents = append(a.domainEvents, event)
 	
-	return nil
+	return memory, nil
+}
+
+// RememberSummary 记录会话摘要
+func (a *Agent) RememberSummary(sessionID uuid.UUID, summary string, messageCount int) (*Memory, error) {
+	if a.Memory == nil {
+		return nil, NewAgentError("agent memory not initialized")
+	}
+	
+	memory := NewMemory(summary, MemoryTypeSummary, 0.8)
+	memory.Context[MemoryContextSessionID] = sessionID.String()
+	memory.Context["message_count"] = messageCount
+	memory.Tags = append(memory.Tags, "summary")
+	
+	if err := a.Memory.AddMemory(memory); err != nil {
+		return nil, err
+	}
+	
+	a.MarkAsModified()
+	
+	event := domain.NewDomainEvent("agent.conversation.summarized", a.ID, map[string]interface{}{
+		"agent_id":      a.ID,
+		"session_id":    sessionID,
+		"memory_id":     memory.ID,
+		"message_count": messageCount,
+	})
+	a.domainEvents = append(a.domainEvents, event)
+	
+	return memory, nil
 }
 
 // CanUse 检查是否可以使用工具
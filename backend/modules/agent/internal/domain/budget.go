@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/shared/pkg/domain"
+)
+
+// ErrCodeBudgetExceeded 超出预算错误码
+const ErrCodeBudgetExceeded = "BUDGET_EXCEEDED"
+
+// UsageKind 用量类型
+type UsageKind string
+
+const (
+	UsageKindLLM  UsageKind = "llm"  // 大模型调用
+	UsageKindTool UsageKind = "tool" // 工具调用
+)
+
+// UsageRecord 用量记录，每次付费调用（大模型调用或工具执行）记录一条
+type UsageRecord struct {
+	domain.BaseEntity
+	AgentID          uuid.UUID `json:"agent_id" gorm:"type:uuid;not null;index"`
+	OwnerID          uuid.UUID `json:"owner_id" gorm:"type:uuid;not null;index"`
+	Kind             UsageKind `json:"kind" gorm:"not null;index"`
+	ReferenceID      uuid.UUID `json:"reference_id" gorm:"type:uuid;index"` // 工具执行ID或会话ID
+	Resource         string    `json:"resource" gorm:"index"`               // 模型名称或工具名称
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+// NewUsageRecord 创建用量记录
+func NewUsageRecord(agent *Agent, kind UsageKind, referenceID uuid.UUID, resource string) *UsageRecord {
+	return &UsageRecord{
+		BaseEntity: domain.BaseEntity{
+			ID:        domain.NewEntityID(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		AgentID:     agent.ID,
+		OwnerID:     agent.OwnerID,
+		Kind:        kind,
+		ReferenceID: referenceID,
+		Resource:    resource,
+	}
+}
+
+// BudgetScope 预算范围
+type BudgetScope string
+
+const (
+	BudgetScopeAgent BudgetScope = "agent" // 单个智能体
+	BudgetScopeOwner BudgetScope = "owner" // 所有者名下的全部智能体
+)
+
+// BudgetPeriod 预算周期，按UTC自然周期计算
+type BudgetPeriod string
+
+const (
+	BudgetPeriodDaily   BudgetPeriod = "daily"   // 每日
+	BudgetPeriodMonthly BudgetPeriod = "monthly" // 每月
+	BudgetPeriodTotal   BudgetPeriod = "total"   // 累计，不重置
+)
+
+// Budget 预算上限，同一范围只有一个预算
+type Budget struct {
+	domain.BaseEntity
+	Scope   BudgetScope  `json:"scope" gorm:"not null;uniqueIndex:idx_budget_scope,priority:1"`
+	ScopeID uuid.UUID    `json:"scope_id" gorm:"type:uuid;not null;uniqueIndex:idx_budget_scope,priority:2"`
+	Period  BudgetPeriod `json:"period" gorm:"not null;default:'monthly'"`
+	Limit   float64      `json:"limit" gorm:"column:limit_amount;not null"`
+}
+
+// NewBudget 创建预算
+func NewBudget(scope BudgetScope, scopeID uuid.UUID, period BudgetPeriod, limit float64) (*Budget, error) {
+	budget := &Budget{
+		BaseEntity: domain.BaseEntity{
+			ID:        domain.NewEntityID(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		Scope:   scope,
+		ScopeID: scopeID,
+	}
+	if err := budget.Update(period, limit); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// Update 更新预算周期和上限
+func (b *Budget) Update(period BudgetPeriod, limit float64) error {
+	switch b.Scope {
+	case BudgetScopeAgent, BudgetScopeOwner:
+	default:
+		return NewAgentError(fmt.Sprintf("invalid budget scope: %s", b.Scope))
+	}
+	if b.ScopeID == uuid.Nil {
+		return NewAgentError("budget scope ID is required")
+	}
+	if period == "" {
+		period = BudgetPeriodMonthly
+	}
+	switch period {
+	case BudgetPeriodDaily, BudgetPeriodMonthly, BudgetPeriodTotal:
+	default:
+		return NewAgentError(fmt.Sprintf("invalid budget period: %s", period))
+	}
+	if limit < 0 {
+		return NewAgentError("budget limit cannot be negative")
+	}
+
+	b.Period = period
+	b.Limit = limit
+	b.UpdatedAt = time.Now()
+	return nil
+}
+
+// PeriodStart 当前周期的开始时间
+func (b *Budget) PeriodStart(now time.Time) time.Time {
+	return PeriodStart(b.Period, now)
+}
+
+// Exceeded 判断已用金额是否达到上限
+func (b *Budget) Exceeded(spent float64) bool {
+	return spent >= b.Limit
+}
+
+// PeriodStart 计算周期开始时间，累计周期返回零值
+func PeriodStart(period BudgetPeriod, now time.Time) time.Time {
+	now = now.UTC()
+	switch period {
+	case BudgetPeriodDaily:
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	case BudgetPeriodMonthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}
+	}
+}
+
+// BudgetExceededError 超出预算错误，由服务层返回、接口层转换为402响应
+type BudgetExceededError struct {
+	Scope   BudgetScope
+	ScopeID uuid.UUID
+	Period  BudgetPeriod
+	Limit   float64
+	Spent   float64
+}
+
+// NewBudgetExceededError 创建超出预算错误
+func NewBudgetExceededError(budget *Budget, spent float64) *BudgetExceededError {
+	return &BudgetExceededError{
+		Scope:   budget.Scope,
+		ScopeID: budget.ScopeID,
+		Period:  budget.Period,
+		Limit:   budget.Limit,
+		Spent:   spent,
+	}
+}
+
+// Code 错误码
+func (e *BudgetExceededError) Code() string {
+	return ErrCodeBudgetExceeded
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: %s %s %s budget of %.4f reached (spent %.4f)",
+		ErrCodeBudgetExceeded, e.Scope, e.ScopeID, e.Period, e.Limit, e.Spent)
+}
+
+// AsBudgetExceededError 判断错误是否为超出预算错误
+func AsBudgetExceededError(err error) (*BudgetExceededError, bool) {
+	var budgetErr *BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return budgetErr, true
+	}
+	return nil, false
+}
+
+// UsageGroupBy 用量汇总维度
+type UsageGroupBy string
+
+const (
+	UsageGroupByOwner    UsageGroupBy = "owner"
+	UsageGroupByAgent    UsageGroupBy = "agent"
+	UsageGroupByKind     UsageGroupBy = "kind"
+	UsageGroupByResource UsageGroupBy = "resource"
+)
+
+// UsageFilter 用量查询条件，时间范围为[From, To)，零值表示不限制
+type UsageFilter struct {
+	OwnerID *uuid.UUID
+	AgentID *uuid.UUID
+	From    time.Time
+	To      time.Time
+}
+
+// UsageSummary 按维度汇总的用量
+type UsageSummary struct {
+	Key              string  `json:"key"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageRecordRepository 用量记录仓储接口
+type UsageRecordRepository interface {
+	Save(ctx context.Context, record *UsageRecord) error
+	// SumCost 汇总范围内自since起的花费
+	SumCost(ctx context.Context, scope BudgetScope, scopeID uuid.UUID, since time.Time) (float64, error)
+	// Summarize 按维度汇总用量，按花费降序
+	Summarize(ctx context.Context, filter UsageFilter, groupBy UsageGroupBy) ([]*UsageSummary, error)
+}
+
+// BudgetRepository 预算仓储接口
+type BudgetRepository interface {
+	Save(ctx context.Context, budget *Budget) error
+	// FindByScope 查找范围的预算，不存在时返回nil
+	FindByScope(ctx context.Context, scope BudgetScope, scopeID uuid.UUID) (*Budget, error)
+	Delete(ctx context.Context, scope BudgetScope, scopeID uuid.UUID) error
+}
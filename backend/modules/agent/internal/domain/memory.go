@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"math"
 	"sort"
 	"time"
 	
@@ -18,8 +19,35 @@ const (
 	MemoryTypeKnowledge    MemoryType = "knowledge"    // 知识记忆
 	MemoryTypeEpisodic     MemoryType = "episodic"     // 情节记忆
 	MemoryTypeSemantic     MemoryType = "semantic"     // 语义记忆
+	MemoryTypeSummary      MemoryType = "summary"      // 摘要记忆
 )
 
+// MemoryEvictionPolicy 记忆超出容量时的淘汰策略
+type MemoryEvictionPolicy string
+
+const (
+	// MemoryEvictionImportance 优先淘汰重要性最低的记忆，重要性相同时淘汰最久未访问的
+	MemoryEvictionImportance MemoryEvictionPolicy = "importance"
+	// MemoryEvictionLRU 优先淘汰最久未访问的记忆
+	MemoryEvictionLRU MemoryEvictionPolicy = "lru"
+)
+
+// IsValid 检查淘汰策略是否有效
+func (p MemoryEvictionPolicy) IsValid() bool {
+	switch p {
+	case MemoryEvictionImportance, MemoryEvictionLRU:
+		return true
+	default:
+		return false
+	}
+}
+
+// MemoryContextSessionID 记忆上下文中的会话ID键
+const MemoryContextSessionID = "session_id"
+
+// MemoryContextEmbeddingModel 记忆上下文中生成嵌入向量的模型键
+const MemoryContextEmbeddingModel = "embedding_model"
+
 // Memory 记忆条目
 type Memory struct {
 	domain.BaseEntity
@@ -78,6 +106,41 @@ func (m *Memory) ApplyDecay(decayRate float64) {
 	m.Importance = max(0.0, m.Importance*(1-m.Decay))
 }
 
+// BelongsToSession 检查记忆是否属于指定会话
+func (m *Memory) BelongsToSession(sessionID uuid.UUID) bool {
+	if m.Context == nil {
+		return false
+	}
+	
+	switch v := m.Context[MemoryContextSessionID].(type) {
+	case string:
+		return v == sessionID.String()
+	case uuid.UUID:
+		return v == sessionID
+	default:
+		return false
+	}
+}
+
+// SetEmbedding 设置嵌入向量并记录生成它的模型
+func (m *Memory) SetEmbedding(embedding []float64, model string) {
+	m.Embedding = embedding
+	if m.Context == nil {
+		m.Context = make(map[string]interface{})
+	}
+	m.Context[MemoryContextEmbeddingModel] = model
+	m.UpdatedAt = time.Now()
+}
+
+// HasEmbedding 检查是否有指定模型生成的嵌入向量，不同模型的向量不可比较
+func (m *Memory) HasEmbedding(model string) bool {
+	if len(m.Embedding) == 0 || m.Context == nil {
+		return false
+	}
+	embeddingModel, _ := m.Context[MemoryContextEmbeddingModel].(string)
+	return embeddingModel == model
+}
+
 // GetRelevanceScore 获取相关性评分
 func (m *Memory) GetRelevanceScore() float64 {
 	// 综合考虑重要性、访问频率、时效性
@@ -96,11 +159,15 @@ type AgentMemory struct {
 	Capacity        int       `json:"capacity" gorm:"default:1000"`
 	DecayRate       float64   `json:"decay_rate" gorm:"default:0.01"`
 	ConsolidationThreshold float64 `json:"consolidation_threshold" gorm:"default:0.8"`
+	EvictionPolicy  MemoryEvictionPolicy `json:"eviction_policy" gorm:"default:importance"`
 	
 	// 统计信息
 	TotalMemories   int     `json:"total_memories"`
 	ActiveMemories  int     `json:"active_memories"`
 	MemoryUsage     float64 `json:"memory_usage"` // 使用率
+	
+	// 已淘汰但尚未从存储中删除的记忆ID
+	evictedMemoryIDs []uuid.UUID `gorm:"-"`
 }
 
 // NewAgentMemory 创建智能体记忆系统
@@ -116,22 +183,19 @@ func NewAgentMemory(agentID uuid.UUID) *AgentMemory {
 		Capacity:               1000,
 		DecayRate:              0.01,
 		ConsolidationThreshold: 0.8,
+		EvictionPolicy:         MemoryEvictionImportance,
 		TotalMemories:          0,
 		ActiveMemories:         0,
 		MemoryUsage:            0.0,
 	}
 }
 
-// AddMemory 添加记忆
+// AddMemory 添加记忆，超出容量时按淘汰策略淘汰已有记忆，新加入的记忆不会被淘汰
 func (am *AgentMemory) AddMemory(memory *Memory) error {
 	memory.AgentID = am.AgentID
 	
-	// 检查是否超出容量
-	if len(am.Memories) >= am.Capacity {
-		// 执行记忆整理
-		if err := am.Consolidate(); err != nil {
-			return err
-		}
+	if am.Capacity > 0 && len(am.Memories) >= am.Capacity {
+		am.Evict(len(am.Memories) - am.Capacity + 1)
 	}
 	
 	am.Memories = append(am.Memories, memory)
@@ -140,6 +204,71 @@ func (am *AgentMemory) AddMemory(memory *Memory) error {
 	return nil
 }
 
+// Evict 按淘汰策略淘汰count条记忆，返回被淘汰的记忆
+// 非激活的记忆总是最先淘汰；被淘汰的记忆ID记录下来，由仓储在保存时删除
+func (am *AgentMemory) Evict(count int) []*Memory {
+	if count <= 0 || len(am.Memories) == 0 {
+		return nil
+	}
+	if count > len(am.Memories) {
+		count = len(am.Memories)
+	}
+	
+	candidates := make([]*Memory, len(am.Memories))
+	copy(candidates, am.Memories)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return am.evictBefore(candidates[i], candidates[j])
+	})
+	
+	evicted := candidates[:count]
+	evictedSet := make(map[*Memory]bool, count)
+	for _, memory := range evicted {
+		evictedSet[memory] = true
+		am.evictedMemoryIDs = append(am.evictedMemoryIDs, memory.ID)
+	}
+	
+	// 保持剩余记忆的原有顺序
+	kept := am.Memories[:0]
+	for _, memory := range am.Memories {
+		if !evictedSet[memory] {
+			kept = append(kept, memory)
+		}
+	}
+	for i := len(kept); i < len(am.Memories); i++ {
+		am.Memories[i] = nil
+	}
+	am.Memories = kept
+	
+	am.updateStatistics()
+	return evicted
+}
+
+// evictBefore 按淘汰策略判断a是否应先于b淘汰
+func (am *AgentMemory) evictBefore(a, b *Memory) bool {
+	if a.IsActive != b.IsActive {
+		return !a.IsActive
+	}
+	
+	if am.EvictionPolicy != MemoryEvictionLRU && a.Importance != b.Importance {
+		return a.Importance < b.Importance
+	}
+	
+	if !a.LastAccessed.Equal(b.LastAccessed) {
+		return a.LastAccessed.Before(b.LastAccessed)
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// EvictedMemoryIDs 获取已淘汰但尚未从存储中删除的记忆ID
+func (am *AgentMemory) EvictedMemoryIDs() []uuid.UUID {
+	return am.evictedMemoryIDs
+}
+
+// ClearEvictedMemoryIDs 仓储删除已淘汰的记忆后清空记录
+func (am *AgentMemory) ClearEvictedMemoryIDs() {
+	am.evictedMemoryIDs = nil
+}
+
 // SearchMemories 搜索记忆
 func (am *AgentMemory) SearchMemories(query string, memoryType *MemoryType, limit int) []*Memory {
 	var results []*Memory
@@ -174,6 +303,73 @@ func (am *AgentMemory) SearchMemories(query string, memoryType *MemoryType, limi
 	return results
 }
 
+// ScoredMemory 带相似度的记忆
+type ScoredMemory struct {
+	Memory *Memory
+	Score  float64 // 与查询的余弦相似度
+}
+
+// SearchSimilar 按与查询向量的余弦相似度搜索记忆
+// 只比较model生成的嵌入向量，结果按相似度降序，相似度相同时按相关性评分排序
+func (am *AgentMemory) SearchSimilar(queryEmbedding []float64, model string, memoryType *MemoryType, limit int) []ScoredMemory {
+	var results []ScoredMemory
+	
+	for _, memory := range am.Memories {
+		if !memory.IsActive || !memory.HasEmbedding(model) {
+			continue
+		}
+		
+		if memoryType != nil && memory.Type != *memoryType {
+			continue
+		}
+		
+		if len(memory.Embedding) != len(queryEmbedding) {
+			continue
+		}
+		
+		results = append(results, ScoredMemory{
+			Memory: memory,
+			Score:  CosineSimilarity(queryEmbedding, memory.Embedding),
+		})
+	}
+	
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Memory.GetRelevanceScore() > results[j].Memory.GetRelevanceScore()
+	})
+	
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	
+	for _, result := range results {
+		result.Memory.Access()
+	}
+	
+	return results
+}
+
+// MemoriesWithoutEmbedding 获取缺少指定模型嵌入向量的激活记忆
+func (am *AgentMemory) MemoriesWithoutEmbedding(model string, memoryType *MemoryType) []*Memory {
+	var memories []*Memory
+	
+	for _, memory := range am.Memories {
+		if !memory.IsActive || memory.HasEmbedding(model) {
+			continue
+		}
+		
+		if memoryType != nil && memory.Type != *memoryType {
+			continue
+		}
+		
+		memories = append(memories, memory)
+	}
+	
+	return memories
+}
+
 // Consolidate 记忆整理（遗忘不重要的记忆）
 func (am *AgentMemory) Consolidate() error {
 	if len(am.Memories) == 0 {
@@ -230,6 +426,29 @@ func (am *AgentMemory) GetRecentMemories(limit int, memoryType *MemoryType) []*M
 	return memories
 }
 
+// GetSessionMemories 获取会话记忆（按时间正序）
+func (am *AgentMemory) GetSessionMemories(sessionID uuid.UUID, memoryType *MemoryType) []*Memory {
+	var memories []*Memory
+	
+	for _, memory := range am.Memories {
+		if !memory.IsActive || !memory.BelongsToSession(sessionID) {
+			continue
+		}
+		
+		if memoryType != nil && memory.Type != *memoryType {
+			continue
+		}
+		
+		memories = append(memories, memory)
+	}
+	
+	sort.Slice(memories, func(i, j int) bool {
+		return memories[i].CreatedAt.Before(memories[j].CreatedAt)
+	})
+	
+	return memories
+}
+
 // updateStatistics 更新统计信息
 func (am *AgentMemory) updateStatistics() {
 	am.TotalMemories = len(am.Memories)
@@ -242,7 +461,9 @@ func (am *AgentMemory) updateStatistics() {
 	}
 	
 	am.ActiveMemories = activeCount
-	am.MemoryUsage = float64(am.ActiveMemories) / float64(am.Capacity)
+	if am.Capacity > 0 {
+		am.MemoryUsage = float64(am.ActiveMemories) / float64(am.Capacity)
+	}
 	am.UpdatedAt = time.Now()
 }
 
@@ -262,6 +483,25 @@ func containsTags(tags []string, query string) bool {
 	return false
 }
 
+// CosineSimilarity 计算余弦相似度，长度不同或存在零向量时返回0
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
 func min(a, b float64) float64 {
 	if a < b {
 		return a
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// addMemories 按顺序添加记忆，越靠后的记忆最近访问时间越晚
+func addMemories(t *testing.T, am *AgentMemory, importance map[string]float64, names ...string) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i, name := range names {
+		memory := NewMemory(name, MemoryTypeLearned, importance[name])
+		memory.LastAccessed = base.Add(time.Duration(len(am.Memories)+i) * time.Minute)
+		if err := am.AddMemory(memory); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// memoryContents 返回记忆内容集合
+func memoryContents(am *AgentMemory) map[string]bool {
+	contents := make(map[string]bool, len(am.Memories))
+	for _, memory := range am.Memories {
+		contents[memory.Content] = true
+	}
+	return contents
+}
+
+func TestAddMemoryEvictsLeastImportant(t *testing.T) {
+	am := NewAgentMemory(uuid.New())
+	am.Capacity = 3
+	importance := map[string]float64{"high": 0.9, "low": 0.2, "mid": 0.5, "new1": 0.1, "new2": 0.3}
+	addMemories(t, am, importance, "high", "low", "mid")
+
+	// 新加入的记忆即使重要性最低也不会被立即淘汰
+	addMemories(t, am, importance, "new1")
+	if contents := memoryContents(am); len(contents) != 3 || contents["low"] || !contents["high"] || !contents["new1"] {
+		t.Fatalf("memories = %v, want low evicted", contents)
+	}
+
+	addMemories(t, am, importance, "new2")
+	if contents := memoryContents(am); len(contents) != 3 || contents["new1"] || !contents["high"] || !contents["mid"] {
+		t.Fatalf("memories = %v, want new1 evicted", contents)
+	}
+	if ids := am.EvictedMemoryIDs(); len(ids) != 2 {
+		t.Fatalf("evicted ids = %v, want 2", ids)
+	}
+	am.ClearEvictedMemoryIDs()
+	if ids := am.EvictedMemoryIDs(); len(ids) != 0 {
+		t.Fatalf("evicted ids = %v after clear", ids)
+	}
+}
+
+func TestAddMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	am := NewAgentMemory(uuid.New())
+	am.Capacity = 3
+	am.EvictionPolicy = MemoryEvictionLRU
+	importance := map[string]float64{"old-high": 0.95, "mid": 0.1, "recent": 0.1, "a": 0.5, "b": 0.5}
+	addMemories(t, am, importance, "old-high", "mid", "recent")
+
+	// 非激活的记忆最先淘汰
+	am.Memories[2].IsActive = false
+	addMemories(t, am, importance, "a")
+	if contents := memoryContents(am); contents["recent"] || !contents["old-high"] {
+		t.Fatalf("memories = %v, want the inactive memory evicted", contents)
+	}
+
+	// LRU不考虑重要性
+	addMemories(t, am, importance, "b")
+	if contents := memoryContents(am); contents["old-high"] || !contents["mid"] {
+		t.Fatalf("memories = %v, want the least recently used evicted", contents)
+	}
+}
+
+func TestEvictKeepsOrderOfRemainingMemories(t *testing.T) {
+	am := NewAgentMemory(uuid.New())
+	importance := map[string]float64{"a": 0.5, "b": 0.1, "c": 0.5, "d": 0.9}
+	addMemories(t, am, importance, "a", "b", "c", "d")
+
+	evicted := am.Evict(2)
+	if len(evicted) != 2 || evicted[0].Content != "b" || evicted[1].Content != "a" {
+		t.Fatalf("evicted = %v, want b then a", evicted)
+	}
+	if len(am.Memories) != 2 || am.Memories[0].Content != "c" || am.Memories[1].Content != "d" {
+		t.Fatalf("remaining = %v", am.Memories)
+	}
+	if am.Evict(0) != nil {
+		t.Fatal("evicting nothing should return nil")
+	}
+}
+
+func TestMemoryEvictionPolicyIsValid(t *testing.T) {
+	for policy, want := range map[MemoryEvictionPolicy]bool{
+		MemoryEvictionImportance: true,
+		MemoryEvictionLRU:        true,
+		"random":                 false,
+		"":                       false,
+	} {
+		if got := policy.IsValid(); got != want {
+			t.Errorf("%q.IsValid() = %v, want %v", policy, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// legacyPromptVariablePattern 匹配 {{name}} 形式的变量引用
+var legacyPromptVariablePattern = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// promptTemplateKeywords 模板关键字，不作为变量改写
+var promptTemplateKeywords = map[string]bool{
+	"if":       true,
+	"range":    true,
+	"with":     true,
+	"end":      true,
+	"else":     true,
+	"break":    true,
+	"continue": true,
+	"nil":      true,
+	"true":     true,
+	"false":    true,
+}
+
+// promptTemplateFuncs 提示模板辅助函数
+var promptTemplateFuncs = template.FuncMap{
+	// default 变量为空时使用默认值：{{default "助手" .agent_name}}
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// IsPromptTemplate 提示中是否包含模板语法，不包含时原样使用
+func IsPromptTemplate(prompt string) bool {
+	return strings.Contains(prompt, "{{")
+}
+
+// ValidatePromptTemplate 检查提示模板语法
+func ValidatePromptTemplate(prompt string) error {
+	if !IsPromptTemplate(prompt) {
+		return nil
+	}
+	_, err := parsePromptTemplate(prompt)
+	return err
+}
+
+// RenderPromptTemplate 用变量渲染提示模板，支持 {{name}} 和 {{.name}} 两种写法，缺失的变量渲染为空
+func RenderPromptTemplate(prompt string, variables map[string]string) (string, error) {
+	if !IsPromptTemplate(prompt) {
+		return prompt, nil
+	}
+
+	tmpl, err := parsePromptTemplate(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", NewAgentError("failed to render prompt template: " + err.Error())
+	}
+	return buf.String(), nil
+}
+
+// parsePromptTemplate 解析提示模板，{{name}} 改写为 {{.name}}
+func parsePromptTemplate(prompt string) (*template.Template, error) {
+	normalized := legacyPromptVariablePattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		name := legacyPromptVariablePattern.FindStringSubmatch(match)[1]
+		if promptTemplateKeywords[name] {
+			return match
+		}
+		return "{{." + name + "}}"
+	})
+
+	tmpl, err := template.New("prompt").
+		Option("missingkey=zero").
+		Funcs(promptTemplateFuncs).
+		Parse(normalized)
+	if err != nil {
+		return nil, NewAgentError("invalid prompt template: " + err.Error())
+	}
+	return tmpl, nil
+}
@@ -43,6 +43,10 @@ type Tool struct {
 	IsPublic     bool                   `json:"is_public" gorm:"default:false"`
 	OwnerID      uuid.UUID              `json:"owner_id" gorm:"type:uuid;index"`
 	
+	// 权限：需要审批的工具每次调用都先等待人工审批
+	RequiresApproval bool          `json:"requires_approval" gorm:"default:false"`
+	DangerScopes     []DangerScope `json:"danger_scopes,omitempty" gorm:"serializer:json"`
+	
 	// 使用统计
 	UsageCount   int       `json:"usage_count" gorm:"default:0"`
 	LastUsed     time.Time `json:"last_used"`
@@ -204,6 +208,8 @@ type ToolExecution struct {
 	Error       string                 `json:"error"`
 	Duration    time.Duration          `json:"duration"`
 	Context     map[string]interface{} `json:"context" gorm:"type:jsonb"`
+	Approval    *ToolApproval          `json:"approval,omitempty" gorm:"type:jsonb;serializer:json"` // 需要审批的工具调用的审批记录
+	Chunks      int                    `json:"chunks,omitempty" gorm:"default:0"`                    // 流式执行收到的输出片段数
 	
 	// 关联
 	Tool  *Tool  `json:"tool,omitempty" gorm:"foreignKey:ToolID"`
@@ -220,6 +226,8 @@ const (
 	ExecutionStatusFailed    ExecutionStatus = "failed"
 	ExecutionStatusTimeout   ExecutionStatus = "timeout"
 	ExecutionStatusCancelled ExecutionStatus = "cancelled"
+	ExecutionStatusAwaitingApproval ExecutionStatus = "awaiting_approval" // 等待人工审批
+	ExecutionStatusRejected         ExecutionStatus = "rejected"          // 审批被拒绝
 )
 
 // NewToolExecution 创建工具执行记录
@@ -246,6 +254,25 @@ func (te *ToolExecution) Complete(output map[string]interface{}, duration time.D
 	te.UpdatedAt = time.Now()
 }
 
+// AppendChunk 将流式执行的输出片段合并到输出中
+// 同一键的字符串值依次拼接（如逐段生成的文本），其他类型的值以最新的片段为准
+func (te *ToolExecution) AppendChunk(chunk map[string]interface{}) {
+	if te.Output == nil {
+		te.Output = make(map[string]interface{}, len(chunk))
+	}
+	for key, value := range chunk {
+		if text, ok := value.(string); ok {
+			if previous, ok := te.Output[key].(string); ok {
+				te.Output[key] = previous + text
+				continue
+			}
+		}
+		te.Output[key] = value
+	}
+	te.Chunks++
+	te.UpdatedAt = time.Now()
+}
+
 // Fail 执行失败
 func (te *ToolExecution) Fail(error string, duration time.Duration) {
 	te.Status = ExecutionStatusFailed
@@ -283,4 +310,6 @@ type ToolExecutionRepository interface {
 	FindByToolID(ctx context.Context, toolID uuid.UUID, offset, limit int) ([]*ToolExecution, error)
 	FindByAgentID(ctx context.Context, agentID uuid.UUID, offset, limit int) ([]*ToolExecution, error)
 	FindByStatus(ctx context.Context, status ExecutionStatus) ([]*ToolExecution, error)
+	// ResolveApproval 保存审批结果，只有仍在等待审批的执行会被更新；返回false表示已被其他请求审批
+	ResolveApproval(ctx context.Context, execution *ToolExecution) (bool, error)
 }
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultApproverScope 审批工具调用需要的默认权限范围
+const DefaultApproverScope = "tools:approve"
+
+var (
+	// ErrApproverRequired 审批请求没有认证的用户身份
+	ErrApproverRequired = errors.New("approver identity is required")
+	// ErrApproverNotAllowed 审批人没有审批权限范围
+	ErrApproverNotAllowed = errors.New("approver is not allowed to resolve tool approvals")
+)
+
+// DangerScope 工具的危险操作范围，声明了危险范围的工具每次调用都需要人工审批
+type DangerScope string
+
+const (
+	DangerScopeDeleteData  DangerScope = "delete_data"  // 删除数据
+	DangerScopeModifyData  DangerScope = "modify_data"  // 修改数据
+	DangerScopePayment     DangerScope = "payment"      // 转账、付款等资金操作
+	DangerScopeSendMessage DangerScope = "send_message" // 以用户身份对外发送消息
+)
+
+// ValidateDangerScopes 检查危险范围是否都是已知范围
+func ValidateDangerScopes(scopes []DangerScope) error {
+	for _, scope := range scopes {
+		switch scope {
+		case DangerScopeDeleteData, DangerScopeModifyData, DangerScopePayment, DangerScopeSendMessage:
+		default:
+			return NewToolError(fmt.Sprintf("invalid danger scope: %s", scope))
+		}
+	}
+	return nil
+}
+
+// NeedsApproval 工具调用是否需要人工审批：声明了requires_approval或任一危险范围
+func (t *Tool) NeedsApproval() bool {
+	return t.RequiresApproval || len(t.DangerScopes) > 0
+}
+
+// ToolApproval 工具调用的审批结果
+type ToolApproval struct {
+	DangerScopes []DangerScope `json:"danger_scopes,omitempty"` // 请求审批时工具声明的危险范围
+	RequestedAt  time.Time     `json:"requested_at"`
+	DecidedBy    uuid.UUID     `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time    `json:"decided_at,omitempty"`
+	Approved     bool          `json:"approved"`
+	Comment      string        `json:"comment,omitempty"`
+}
+
+// RequestApproval 将执行置为等待审批，审批通过前不执行工具
+func (te *ToolExecution) RequestApproval(scopes []DangerScope) {
+	te.Status = ExecutionStatusAwaitingApproval
+	te.Approval = &ToolApproval{
+		DangerScopes: scopes,
+		RequestedAt:  time.Now(),
+	}
+	te.UpdatedAt = time.Now()
+}
+
+// Approve 审批通过，执行进入运行中
+func (te *ToolExecution) Approve(approverID uuid.UUID, comment string) error {
+	if err := te.decide(approverID, true, comment); err != nil {
+		return err
+	}
+	te.Status = ExecutionStatusRunning
+	return nil
+}
+
+// Reject 拒绝执行，工具不会被调用
+func (te *ToolExecution) Reject(approverID uuid.UUID, comment string) error {
+	if err := te.decide(approverID, false, comment); err != nil {
+		return err
+	}
+	te.Status = ExecutionStatusRejected
+	te.Error = "rejected by approver"
+	if comment != "" {
+		te.Error += ": " + comment
+	}
+	return nil
+}
+
+// decide 记录审批结果，只有等待审批的执行可以审批
+func (te *ToolExecution) decide(approverID uuid.UUID, approved bool, comment string) error {
+	if te.Status != ExecutionStatusAwaitingApproval || te.Approval == nil {
+		return NewToolError(fmt.Sprintf("execution is %s, not awaiting approval", te.Status))
+	}
+	if approverID == uuid.Nil {
+		return NewToolError("approver ID is required")
+	}
+
+	now := time.Now()
+	te.Approval.DecidedBy = approverID
+	te.Approval.DecidedAt = &now
+	te.Approval.Approved = approved
+	te.Approval.Comment = comment
+	te.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/shared/pkg/domain"
+)
+
+// TranscriptStepType 运行记录步骤类型
+type TranscriptStepType string
+
+const (
+	TranscriptStepUserMessage TranscriptStepType = "user_message" // 用户发言
+	TranscriptStepMemoryRead  TranscriptStepType = "memory_read"  // 读取会话记忆
+	TranscriptStepLLMRequest  TranscriptStepType = "llm_request"  // 发给大模型的完整请求
+	TranscriptStepLLMResponse TranscriptStepType = "llm_response" // 大模型回复
+	TranscriptStepToolCall    TranscriptStepType = "tool_call"    // 发起工具调用
+	TranscriptStepToolResult  TranscriptStepType = "tool_result"  // 工具调用结果
+	TranscriptStepError       TranscriptStepType = "error"        // 运行中断的错误
+)
+
+// TranscriptStep 会话运行记录中的一步
+// 一次对话或一次工具调用是一次运行（RunID），运行内的步骤按Sequence排序，会话内的运行按创建时间排序
+type TranscriptStep struct {
+	domain.BaseEntity
+	AgentID         uuid.UUID              `json:"agent_id" gorm:"type:uuid;not null;index:idx_transcript_session,priority:1"`
+	SessionID       uuid.UUID              `json:"session_id" gorm:"type:uuid;not null;index:idx_transcript_session,priority:2"`
+	RunID           uuid.UUID              `json:"run_id" gorm:"type:uuid;not null;index"`
+	Sequence        int                    `json:"sequence" gorm:"not null"`
+	Type            TranscriptStepType     `json:"type" gorm:"not null"`
+	Role            string                 `json:"role,omitempty"`
+	Content         string                 `json:"content,omitempty" gorm:"type:text"`
+	Data            map[string]interface{} `json:"data,omitempty" gorm:"type:jsonb;serializer:json"`
+	ToolExecutionID *uuid.UUID             `json:"tool_execution_id,omitempty" gorm:"type:uuid;index"`
+	Error           string                 `json:"error,omitempty"`
+	Duration        time.Duration          `json:"duration"`
+}
+
+// NewTranscriptStep 创建运行记录步骤
+func NewTranscriptStep(agentID, sessionID, runID uuid.UUID, sequence int, stepType TranscriptStepType) *TranscriptStep {
+	return &TranscriptStep{
+		BaseEntity: domain.BaseEntity{
+			ID:        domain.NewEntityID(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		AgentID:   agentID,
+		SessionID: sessionID,
+		RunID:     runID,
+		Sequence:  sequence,
+		Type:      stepType,
+		Data:      make(map[string]interface{}),
+	}
+}
+
+// ExecutionContextSessionID 工具执行上下文中的会话ID键
+const ExecutionContextSessionID = "session_id"
+
+// SessionID 发起工具调用的会话，不属于会话时返回uuid.Nil
+func (te *ToolExecution) SessionID() uuid.UUID {
+	switch v := te.Context[ExecutionContextSessionID].(type) {
+	case string:
+		if id, err := uuid.Parse(v); err == nil {
+			return id
+		}
+	case uuid.UUID:
+		return v
+	}
+	return uuid.Nil
+}
+
+// TranscriptRepository 运行记录仓储接口，步骤只追加不修改
+type TranscriptRepository interface {
+	SaveSteps(ctx context.Context, steps []*TranscriptStep) error
+	// FindBySession 按执行顺序返回会话的全部步骤
+	FindBySession(ctx context.Context, agentID, sessionID uuid.UUID) ([]*TranscriptStep, error)
+}
@@ -0,0 +1,155 @@
+package executors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+)
+
+// defaultMaxResponseBytes 默认读取的最大响应体大小
+const defaultMaxResponseBytes = 1 << 20
+
+// APIExecutor API工具执行器
+// 工具配置：url（必填）、method（默认GET）、headers、timeout_seconds、max_response_bytes；
+// GET、HEAD、DELETE请求将输入作为查询参数，其他请求将输入编码为JSON请求体
+type APIExecutor struct {
+	toolType domain.ToolType
+}
+
+// NewAPIExecutor 创建API工具执行器
+func NewAPIExecutor() service.ToolExecutor {
+	return &APIExecutor{toolType: domain.ToolTypeAPI}
+}
+
+// NewWebExecutor 创建网络工具执行器，与API工具的执行方式相同
+func NewWebExecutor() service.ToolExecutor {
+	return &APIExecutor{toolType: domain.ToolTypeWeb}
+}
+
+// Execute 调用工具配置的HTTP接口
+func (e *APIExecutor) Execute(ctx context.Context, request *service.ToolExecutionRequest) (*service.ToolExecutionResult, error) {
+	if request.HTTPClient == nil {
+		return nil, fmt.Errorf("http client is not configured")
+	}
+
+	config := request.Tool.Config
+	rawURL, _ := config["url"].(string)
+	if rawURL == "" {
+		return nil, fmt.Errorf("tool config url is required")
+	}
+	method := http.MethodGet
+	if m, ok := config["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+
+	// 工具级超时，不超过客户端的整体超时
+	if seconds, ok := config["timeout_seconds"].(float64); ok && seconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(seconds*float64(time.Second)))
+		defer cancel()
+	}
+
+	httpReq, err := e.buildRequest(ctx, method, rawURL, request.Input)
+	if err != nil {
+		return nil, err
+	}
+	if headers, ok := config["headers"].(map[string]interface{}); ok {
+		for key, value := range headers {
+			httpReq.Header.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	resp, err := request.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("api request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	maxBytes := int64(defaultMaxResponseBytes)
+	if limit, ok := config["max_response_bytes"].(float64); ok && limit > 0 {
+		maxBytes = int64(limit)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api response: %w", err)
+	}
+	truncated := int64(len(body)) > maxBytes
+	if truncated {
+		body = body[:maxBytes]
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("api returned status %d: %s", resp.StatusCode, truncate(string(body), 512))
+	}
+
+	output := map[string]interface{}{
+		"status_code":  resp.StatusCode,
+		"content_type": resp.Header.Get("Content-Type"),
+		"truncated":    truncated,
+	}
+	var parsed interface{}
+	if !truncated && strings.Contains(resp.Header.Get("Content-Type"), "json") && json.Unmarshal(body, &parsed) == nil {
+		output["body"] = parsed
+	} else {
+		output["body"] = string(body)
+	}
+
+	return &service.ToolExecutionResult{
+		Output: output,
+		Metadata: map[string]interface{}{
+			"method": method,
+			"url":    httpReq.URL.String(),
+		},
+	}, nil
+}
+
+// GetSupportedType 获取支持的工具类型
+func (e *APIExecutor) GetSupportedType() domain.ToolType {
+	return e.toolType
+}
+
+// buildRequest 根据请求方法将输入放入查询参数或JSON请求体
+func (e *APIExecutor) buildRequest(ctx context.Context, method, rawURL string, input map[string]interface{}) (*http.Request, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return nil, fmt.Errorf("invalid tool url: %s", rawURL)
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		query := target.Query()
+		for key, value := range input {
+			query.Set(key, fmt.Sprint(value))
+		}
+		target.RawQuery = query.Encode()
+		return http.NewRequestWithContext(ctx, method, target.String(), nil)
+	default:
+		payload, err := json.Marshal(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode api request: %w", err)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		return httpReq, nil
+	}
+}
+
+// truncate 截断过长的文本
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "..."
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// DefaultOpenAIEmbeddingModel 默认嵌入模型
+const DefaultOpenAIEmbeddingModel = string(openai.AdaEmbeddingV2)
+
+// OpenAIEmbeddingService OpenAI嵌入服务实现，用于记忆的语义搜索
+type OpenAIEmbeddingService struct {
+	client *openai.Client
+	model  string
+	logger infrastructure.Logger
+}
+
+// NewOpenAIEmbeddingService 创建OpenAI嵌入服务，baseURL为空时使用OpenAI官方地址
+func NewOpenAIEmbeddingService(apiKey, baseURL, model string, logger infrastructure.Logger) *OpenAIEmbeddingService {
+	if model == "" {
+		model = DefaultOpenAIEmbeddingModel
+	}
+
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+
+	return &OpenAIEmbeddingService{
+		client: openai.NewClientWithConfig(config),
+		model:  model,
+		logger: logger,
+	}
+}
+
+// GenerateEmbeddings 批量生成嵌入向量，一次请求完成
+func (s *OpenAIEmbeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return [][]float64{}, nil
+	}
+
+	resp, err := s.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model: openai.EmbeddingModel(s.model),
+		Input: texts,
+	})
+	if err != nil {
+		s.logger.Error("OpenAI embedding failed", zap.Error(err))
+		return nil, err
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	// 按Index还原输入顺序
+	embeddings := make([][]float64, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		embedding := make([]float64, len(data.Embedding))
+		for i, value := range data.Embedding {
+			embedding[i] = float64(value)
+		}
+		embeddings[data.Index] = embedding
+	}
+
+	return embeddings, nil
+}
+
+// GetModel 获取模型名称
+func (s *OpenAIEmbeddingService) GetModel() string {
+	return s.model
+}
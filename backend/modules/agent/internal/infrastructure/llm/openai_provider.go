@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// DefaultOpenAIModel 默认对话模型
+const DefaultOpenAIModel = openai.GPT3Dot5Turbo
+
+// OpenAIProvider OpenAI大模型提供商实现，也可用于兼容OpenAI接口的服务（如Azure代理、vLLM、Ollama）
+type OpenAIProvider struct {
+	client *openai.Client
+	model  string
+	logger infrastructure.Logger
+}
+
+// NewOpenAIProvider 创建OpenAI大模型提供商，baseURL为空时使用OpenAI官方地址
+func NewOpenAIProvider(apiKey, baseURL, model string, logger infrastructure.Logger) *OpenAIProvider {
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+
+	return &OpenAIProvider{
+		client: openai.NewClientWithConfig(config),
+		model:  model,
+		logger: logger,
+	}
+}
+
+// Chat 调用对话补全接口
+func (p *OpenAIProvider) Chat(ctx context.Context, request *service.LLMChatRequest) (*service.LLMChatResponse, error) {
+	if len(request.Messages) == 0 {
+		return nil, errors.New("messages are required")
+	}
+
+	model := request.Model
+	if model == "" {
+		model = p.model
+	}
+
+	messages := make([]openai.ChatCompletionMessage, 0, len(request.Messages))
+	for _, msg := range request.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       model,
+		Messages:    messages,
+		MaxTokens:   request.MaxTokens,
+		Temperature: float32(request.Temperature),
+	})
+	if err != nil {
+		p.logger.Error("OpenAI chat completion failed", zap.Error(err))
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("no choices returned")
+	}
+
+	return &service.LLMChatResponse{
+		Content:          resp.Choices[0].Message.Content,
+		Model:            model,
+		TokensUsed:       resp.Usage.TotalTokens,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}, nil
+}
@@ -20,9 +20,24 @@ func NewGormAgentRepository(db *infrastructure.Database) domain.AgentRepository
 	return &GormAgentRepository{db: db}
 }
 
-// Save 保存智能体
+// Save 保存智能体，同时删除记忆系统中已淘汰的记忆
 func (r *GormAgentRepository) Save(ctx context.Context, entity *domain.Agent) error {
-	return r.db.DB.WithContext(ctx).Save(entity).Error
+	if entity.Memory == nil || len(entity.Memory.EvictedMemoryIDs()) == 0 {
+		return r.db.DB.WithContext(ctx).Save(entity).Error
+	}
+	
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domain.Memory{}, "id IN ?", entity.Memory.EvictedMemoryIDs()).Error; err != nil {
+			return err
+		}
+		return tx.Save(entity).Error
+	})
+	if err != nil {
+		return err
+	}
+	
+	entity.Memory.ClearEvictedMemoryIDs()
+	return nil
 }
 
 // FindByID 根据ID查找智能体
@@ -30,6 +45,7 @@ func (r *GormAgentRepository) FindByID(ctx context.Context, id uuid.UUID) (*doma
 	var agent domain.Agent
 	err := r.db.DB.WithContext(ctx).
 		Preload("Memory").
+		Preload("Memory.Memories").
 		Preload("Tools").
 		First(&agent, "id = ?", id).Error
 	if err != nil {
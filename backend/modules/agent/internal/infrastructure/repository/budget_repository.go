@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"gorm.io/gorm"
+)
+
+// usageGroupColumns 用量汇总维度对应的列
+var usageGroupColumns = map[domain.UsageGroupBy]string{
+	domain.UsageGroupByOwner:    "CAST(owner_id AS TEXT)",
+	domain.UsageGroupByAgent:    "CAST(agent_id AS TEXT)",
+	domain.UsageGroupByKind:     "kind",
+	domain.UsageGroupByResource: "resource",
+}
+
+// GormUsageRecordRepository GORM用量记录仓储实现
+type GormUsageRecordRepository struct {
+	db *infrastructure.Database
+}
+
+// NewGormUsageRecordRepository 创建GORM用量记录仓储
+func NewGormUsageRecordRepository(db *infrastructure.Database) domain.UsageRecordRepository {
+	return &GormUsageRecordRepository{db: db}
+}
+
+// Save 保存用量记录
+func (r *GormUsageRecordRepository) Save(ctx context.Context, record *domain.UsageRecord) error {
+	return r.db.DB.WithContext(ctx).Create(record).Error
+}
+
+// SumCost 汇总范围内自since起的花费
+func (r *GormUsageRecordRepository) SumCost(ctx context.Context, scope domain.BudgetScope, scopeID uuid.UUID, since time.Time) (float64, error) {
+	column := "agent_id"
+	if scope == domain.BudgetScopeOwner {
+		column = "owner_id"
+	}
+
+	var total float64
+	err := r.db.DB.WithContext(ctx).
+		Model(&domain.UsageRecord{}).
+		Select("COALESCE(SUM(cost), 0)").
+		Where(column+" = ? AND created_at >= ?", scopeID, since).
+		Scan(&total).Error
+	return total, err
+}
+
+// Summarize 按维度汇总用量，按花费降序
+func (r *GormUsageRecordRepository) Summarize(ctx context.Context, filter domain.UsageFilter, groupBy domain.UsageGroupBy) ([]*domain.UsageSummary, error) {
+	column, ok := usageGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported usage group: %s", groupBy)
+	}
+
+	query := r.db.DB.WithContext(ctx).
+		Model(&domain.UsageRecord{}).
+		Select(column + " AS key, COUNT(*) AS calls, " +
+			"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+			"COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+			"COALESCE(SUM(cost), 0) AS cost")
+
+	if filter.OwnerID != nil {
+		query = query.Where("owner_id = ?", *filter.OwnerID)
+	}
+	if filter.AgentID != nil {
+		query = query.Where("agent_id = ?", *filter.AgentID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var summaries []*domain.UsageSummary
+	err := query.Group(column).Order("cost DESC").Scan(&summaries).Error
+	return summaries, err
+}
+
+// GormBudgetRepository GORM预算仓储实现
+type GormBudgetRepository struct {
+	db *infrastructure.Database
+}
+
+// NewGormBudgetRepository 创建GORM预算仓储
+func NewGormBudgetRepository(db *infrastructure.Database) domain.BudgetRepository {
+	return &GormBudgetRepository{db: db}
+}
+
+// Save 保存预算
+func (r *GormBudgetRepository) Save(ctx context.Context, budget *domain.Budget) error {
+	return r.db.DB.WithContext(ctx).Save(budget).Error
+}
+
+// FindByScope 查找范围的预算，不存在时返回nil
+func (r *GormBudgetRepository) FindByScope(ctx context.Context, scope domain.BudgetScope, scopeID uuid.UUID) (*domain.Budget, error) {
+	var budget domain.Budget
+	err := r.db.DB.WithContext(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		First(&budget).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// Delete 删除预算
+func (r *GormBudgetRepository) Delete(ctx context.Context, scope domain.BudgetScope, scopeID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Delete(&domain.Budget{}, "scope = ? AND scope_id = ?", scope, scopeID).Error
+}
@@ -205,3 +205,16 @@ func (r *GormToolExecutionRepository) FindByStatus(ctx context.Context, status d
 		Find(&executions).Error
 	return executions, err
 }
+
+// ResolveApproval 以等待审批状态为条件更新审批结果，并发审批时只有一个请求更新成功
+func (r *GormToolExecutionRepository) ResolveApproval(ctx context.Context, execution *domain.ToolExecution) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(execution).
+		Where("status = ?", domain.ExecutionStatusAwaitingApproval).
+		Select("status", "approval", "error", "updated_at").
+		Updates(execution)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
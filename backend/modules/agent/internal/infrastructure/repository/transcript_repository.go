@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+)
+
+// GormTranscriptRepository GORM运行记录仓储实现
+type GormTranscriptRepository struct {
+	db *infrastructure.Database
+}
+
+// NewGormTranscriptRepository 创建GORM运行记录仓储
+func NewGormTranscriptRepository(db *infrastructure.Database) domain.TranscriptRepository {
+	return &GormTranscriptRepository{db: db}
+}
+
+// SaveSteps 批量追加步骤
+func (r *GormTranscriptRepository) SaveSteps(ctx context.Context, steps []*domain.TranscriptStep) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	return r.db.DB.WithContext(ctx).Create(&steps).Error
+}
+
+// FindBySession 按执行顺序返回会话的全部步骤
+func (r *GormTranscriptRepository) FindBySession(ctx context.Context, agentID, sessionID uuid.UUID) ([]*domain.TranscriptStep, error) {
+	var steps []*domain.TranscriptStep
+	err := r.db.DB.WithContext(ctx).
+		Where("agent_id = ? AND session_id = ?", agentID, sessionID).
+		Order("created_at ASC, sequence ASC").
+		Find(&steps).Error
+	return steps, err
+}
@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	agentv1 "github.com/noah-loop/backend/api-gateway/proto/agent/v1"
+	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/validation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AgentGRPCHandler Agent gRPC处理器，实现API网关定义的agent.v1.AgentService
+// 请求转换为应用层命令后由命令的Validate校验，与HTTP接口的校验规则和字段名一致
+type AgentGRPCHandler struct {
+	agentv1.UnimplementedAgentServiceServer
+	agentService *service.AgentService
+	logger       infrastructure.Logger
+}
+
+// NewAgentGRPCHandler 创建Agent gRPC处理器
+func NewAgentGRPCHandler(agentService *service.AgentService, logger infrastructure.Logger) *AgentGRPCHandler {
+	return &AgentGRPCHandler{
+		agentService: agentService,
+		logger:       logger,
+	}
+}
+
+// CreateAgent 创建智能体
+func (h *AgentGRPCHandler) CreateAgent(ctx context.Context, req *agentv1.CreateAgentRequest) (*agentv1.CreateAgentResponse, error) {
+	cmd, err := toCreateAgentCommand(req)
+	if err != nil {
+		return nil, validation.StatusError(err)
+	}
+
+	result, err := h.agentService.CreateAgent(ctx, cmd)
+	if err != nil {
+		h.logger.Error("Failed to create agent", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &agentv1.CreateAgentResponse{
+		Agent: toProtoAgent(result.Data.(*domain.Agent)),
+	}, nil
+}
+
+// toCreateAgentCommand 转换创建智能体请求，metadata作为智能体配置保存；owner_id格式错误时返回字段校验错误
+func toCreateAgentCommand(req *agentv1.CreateAgentRequest) (*service.CreateAgentCommand, error) {
+	cmd := service.NewCreateAgentCommand()
+	cmd.Name = req.GetName()
+	cmd.Type = domain.AgentType(req.GetType())
+	cmd.Description = req.GetDescription()
+	cmd.SystemPrompt = req.GetSystemPrompt()
+	for key, value := range req.GetMetadata() {
+		cmd.Config[key] = value
+	}
+	if req.GetMemoryCapacity() > 0 {
+		cmd.MemoryCapacity = int(req.GetMemoryCapacity())
+	}
+
+	if req.GetOwnerId() != "" {
+		ownerID, err := uuid.Parse(req.GetOwnerId())
+		if err != nil {
+			return nil, validation.NewFieldError("owner_id", "invalid owner ID format")
+		}
+		cmd.OwnerID = ownerID
+	}
+
+	return cmd, nil
+}
+
+// toStatusError 将错误转换为gRPC状态码
+func toStatusError(err error) error {
+	switch {
+	case len(validation.FieldErrors(err)) > 0:
+		return validation.StatusError(err)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toProtoAgent 转换智能体为protobuf消息，配置转换为metadata
+func toProtoAgent(agent *domain.Agent) *agentv1.Agent {
+	pb := &agentv1.Agent{
+		Id:             agent.ID.String(),
+		Name:           agent.Name,
+		Type:           string(agent.Type),
+		OwnerId:        agent.OwnerID.String(),
+		Description:    agent.Description,
+		SystemPrompt:   agent.SystemPrompt,
+		MemoryCapacity: int32(agent.MemoryCapacity),
+		Status:         string(agent.Status),
+		Metadata:       toProtoMetadata(agent.Config),
+		CreatedAt:      timestamppb.New(agent.CreatedAt),
+		UpdatedAt:      timestamppb.New(agent.UpdatedAt),
+	}
+	if agent.Memory != nil {
+		pb.MemoryCapacity = int32(agent.Memory.Capacity)
+	}
+	return pb
+}
+
+// toProtoMetadata 将配置转换为字符串映射
+func toProtoMetadata(config map[string]interface{}) map[string]string {
+	if len(config) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]string, len(config))
+	for key, value := range config {
+		metadata[key] = fmt.Sprint(value)
+	}
+	return metadata
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// BudgetHandler 预算和花费HTTP处理器
+type BudgetHandler struct {
+	budgetService *service.BudgetService
+	logger        infrastructure.Logger
+}
+
+// NewBudgetHandler 创建预算处理器
+func NewBudgetHandler(budgetService *service.BudgetService, logger infrastructure.Logger) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+		logger:        logger,
+	}
+}
+
+// SetBudget 设置预算
+func (h *BudgetHandler) SetBudget(c *gin.Context) {
+	cmd := service.NewSetBudgetCommand()
+	if err := c.ShouldBindJSON(cmd); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+	if err := cmd.Validate(); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+
+	status, err := h.budgetService.SetBudget(c.Request.Context(), cmd)
+	if err != nil {
+		h.logger.Error("Failed to set budget", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, status, "Budget set successfully")
+}
+
+// GetBudget 获取预算及当前周期的花费
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	scope, scopeID, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.budgetService.GetBudgetStatus(c.Request.Context(), scope, scopeID)
+	if err != nil {
+		h.logger.Error("Failed to get budget", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	if status == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Budget not found",
+			"error":   "budget_not_found",
+		})
+		return
+	}
+
+	utils.SuccessResponse(c, status, "Budget retrieved successfully")
+}
+
+// DeleteBudget 删除预算
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	scope, scopeID, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.budgetService.DeleteBudget(c.Request.Context(), scope, scopeID); err != nil {
+		h.logger.Error("Failed to delete budget", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, nil, "Budget deleted successfully")
+}
+
+// GetSpendReport 获取花费报表
+func (h *BudgetHandler) GetSpendReport(c *gin.Context) {
+	query := service.NewSpendReportQuery()
+	if err := c.ShouldBindQuery(query); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+	if err := query.Validate(); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+
+	report, err := h.budgetService.GetSpendReport(c.Request.Context(), query)
+	if err != nil {
+		h.logger.Error("Failed to get spend report", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, report, "Spend report retrieved successfully")
+}
+
+// parseBudgetScope 解析路径中的预算范围
+func parseBudgetScope(c *gin.Context) (domain.BudgetScope, uuid.UUID, bool) {
+	scope := domain.BudgetScope(c.Param("scope"))
+	if scope != domain.BudgetScopeAgent && scope != domain.BudgetScopeOwner {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("scope", "scope must be agent or owner"))
+		return "", uuid.Nil, false
+	}
+
+	scopeID, err := uuid.Parse(c.Param("scope_id"))
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("scope_id", "invalid UUID format"))
+		return "", uuid.Nil, false
+	}
+	return scope, scopeID, true
+}
+
+// budgetExceededResponse 将超出预算错误转换为402响应，其他错误返回false
+func budgetExceededResponse(c *gin.Context, err error) bool {
+	budgetErr, ok := domain.AsBudgetExceededError(err)
+	if !ok {
+		return false
+	}
+
+	c.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{
+		"success":  false,
+		"message":  "Budget exceeded. Paid calls are blocked until the budget is raised or the period resets.",
+		"error":    budgetErr.Code(),
+		"scope":    budgetErr.Scope,
+		"scope_id": budgetErr.ScopeID,
+		"period":   budgetErr.Period,
+		"limit":    budgetErr.Limit,
+		"spent":    budgetErr.Spent,
+	})
+	return true
+}
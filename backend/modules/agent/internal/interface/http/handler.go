@@ -1,16 +1,28 @@
 package http
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/utils"
+	"github.com/noah-loop/backend/shared/pkg/validation"
 	"go.uber.org/zap"
 )
 
+const (
+	// userIDHeader 网关认证后转发的用户ID请求头
+	userIDHeader = "X-User-ID"
+	// userScopesHeader 网关认证后转发的权限范围请求头，空格分隔
+	userScopesHeader = "X-User-Scopes"
+)
+
 // AgentHandler 智能体HTTP处理器
 type AgentHandler struct {
 	agentService *service.AgentService
@@ -36,13 +48,17 @@ func (h *AgentHandler) CreateAgent(c *gin.Context) {
 	}
 	
 	result, err := h.agentService.CreateAgent(c.Request.Context(), cmd)
+	if len(validation.FieldErrors(err)) > 0 {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to create agent", zap.Error(err))
 		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
 		return
 	}
 	
-	utils.CreatedResponse(c, result.Data, "Agent created successfully")
+	utils.CreatedResponse(c, toResponse(result.Data), "Agent created successfully")
 }
 
 // GetAgents 获取智能体列表
@@ -124,6 +140,9 @@ func (h *AgentHandler) ChatWithAgent(c *gin.Context) {
 	
 	result, err := h.agentService.ChatWithAgent(c.Request.Context(), cmd)
 	if err != nil {
+		if budgetExceededResponse(c, err) {
+			return
+		}
 		h.logger.Error("Failed to chat with agent", zap.Error(err))
 		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
 		return
@@ -132,6 +151,60 @@ func (h *AgentHandler) ChatWithAgent(c *gin.Context) {
 	utils.SuccessResponse(c, result.Data, "Chat completed successfully")
 }
 
+// SummarizeConversation 生成会话摘要
+func (h *AgentHandler) SummarizeConversation(c *gin.Context) {
+	agentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+	
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("session_id", "invalid UUID format"))
+		return
+	}
+	
+	store := c.DefaultQuery("store", "false") == "true"
+	
+	result, err := h.agentService.SummarizeConversation(c.Request.Context(), agentID, sessionID, store)
+	if err != nil {
+		if budgetExceededResponse(c, err) {
+			return
+		}
+		h.logger.Error("Failed to summarize conversation", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	utils.SuccessResponse(c, result.Data, "Conversation summarized successfully")
+}
+
+// GetSessionTranscript 获取会话的运行记录
+func (h *AgentHandler) GetSessionTranscript(c *gin.Context) {
+	agentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+	
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("session_id", "invalid UUID format"))
+		return
+	}
+	
+	result, err := h.agentService.GetSessionTranscript(c.Request.Context(), agentID, sessionID)
+	if err != nil {
+		h.logger.Error("Failed to get session transcript", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	steps, _ := result.Data.([]*domain.TranscriptStep)
+	utils.SuccessResponse(c, NewTranscriptResponse(agentID, sessionID, steps), "Transcript retrieved successfully")
+}
+
 // LearnAgent 让智能体学习
 func (h *AgentHandler) LearnAgent(c *gin.Context) {
 	idParam := c.Param("id")
@@ -149,8 +222,14 @@ func (h *AgentHandler) LearnAgent(c *gin.Context) {
 		return
 	}
 	
-	// TODO: 实现学习逻辑
-	utils.SuccessResponse(c, nil, "Agent learned successfully")
+	result, err := h.agentService.Learn(c.Request.Context(), cmd)
+	if err != nil {
+		h.logger.Error("Failed to learn", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	utils.SuccessResponse(c, toResponse(result.Data), "Agent learned successfully")
 }
 
 // CreateTool 创建工具
@@ -238,12 +317,102 @@ func (h *AgentHandler) ExecuteTool(c *gin.Context) {
 	
 	result, err := h.agentService.ExecuteTool(c.Request.Context(), cmd)
 	if err != nil {
+		if budgetExceededResponse(c, err) {
+			return
+		}
 		h.logger.Error("Failed to execute tool", zap.Error(err))
 		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
 		return
 	}
 	
-	utils.SuccessResponse(c, result.Data, "Tool executed successfully")
+	if execution, ok := result.Data.(*domain.ToolExecution); ok && execution.Status == domain.ExecutionStatusAwaitingApproval {
+		utils.SuccessResponse(c, NewToolExecutionResponse(execution), "Tool execution is awaiting approval")
+		return
+	}
+	utils.SuccessResponse(c, toResponse(result.Data), "Tool executed successfully")
+}
+
+// GetPendingApprovals 获取等待审批的工具调用
+func (h *AgentHandler) GetPendingApprovals(c *gin.Context) {
+	result, err := h.agentService.GetPendingApprovals(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	utils.SuccessResponse(c, toResponse(result.Data), "Pending approvals retrieved successfully")
+}
+
+// ApproveToolExecution 批准工具调用并执行
+func (h *AgentHandler) ApproveToolExecution(c *gin.Context) {
+	h.resolveToolApproval(c, true)
+}
+
+// RejectToolExecution 拒绝工具调用
+func (h *AgentHandler) RejectToolExecution(c *gin.Context) {
+	h.resolveToolApproval(c, false)
+}
+
+// resolveToolApproval 审批工具调用
+func (h *AgentHandler) resolveToolApproval(c *gin.Context, approved bool) {
+	idParam := c.Param("id")
+	executionID, err := uuid.Parse(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+	
+	cmd := service.NewResolveToolApprovalCommand(executionID, approved)
+	if err := c.ShouldBindJSON(cmd); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+	// 审批人只取自网关认证的身份，请求体中的审批人被忽略
+	if approverID, err := uuid.Parse(c.GetHeader(userIDHeader)); err == nil {
+		cmd.ApproverID = approverID
+	}
+	cmd.ApproverScopes = strings.Fields(c.GetHeader(userScopesHeader))
+	
+	result, err := h.agentService.ResolveToolApproval(c.Request.Context(), cmd)
+	if err != nil {
+		if budgetExceededResponse(c, err) || approverErrorResponse(c, err) {
+			return
+		}
+		var toolErr *domain.ToolError
+		if errors.As(err, &toolErr) {
+			utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("approval", err.Error()))
+			return
+		}
+		h.logger.Error("Failed to resolve tool approval", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	if approved {
+		utils.SuccessResponse(c, toResponse(result.Data), "Tool execution approved")
+		return
+	}
+	utils.SuccessResponse(c, toResponse(result.Data), "Tool execution rejected")
+}
+
+// approverErrorResponse 审批人身份缺失时返回401，没有审批权限范围时返回403，其他错误返回false
+func approverErrorResponse(c *gin.Context, err error) bool {
+	status := 0
+	switch {
+	case errors.Is(err, domain.ErrApproverRequired):
+		status = http.StatusUnauthorized
+	case errors.Is(err, domain.ErrApproverNotAllowed):
+		status = http.StatusForbidden
+	default:
+		return false
+	}
+
+	c.AbortWithStatusJSON(status, gin.H{
+		"success": false,
+		"message": err.Error(),
+		"error":   http.StatusText(status),
+	})
+	return true
 }
 
 // AssignTool 分配工具给智能体
@@ -278,8 +447,22 @@ func (h *AgentHandler) SearchMemory(c *gin.Context) {
 		return
 	}
 	
-	// TODO: 实现记忆搜索逻辑
-	utils.SuccessResponse(c, []interface{}{}, "Memory searched successfully")
+	result, err := h.agentService.SearchMemory(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, service.ErrEmbeddingServiceNotConfigured) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "Semantic memory search requires an embedding service.",
+				"error":   "embedding_service_not_configured",
+			})
+			return
+		}
+		h.logger.Error("Failed to search memory", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	utils.SuccessResponse(c, result.Data, "Memory searched successfully")
 }
 
 // GetRecentMemories 获取最近记忆
@@ -0,0 +1,231 @@
+package http
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+)
+
+// API响应结构，与GORM模型分离：字段名统一为snake_case，不返回嵌入向量、版本号、软删除标记等内部字段，
+// 未设置的时间返回省略而不是零值
+
+// AgentResponse 智能体响应
+type AgentResponse struct {
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Type           domain.AgentType       `json:"type"`
+	Status         domain.AgentStatus     `json:"status"`
+	Description    string                 `json:"description"`
+	SystemPrompt   string                 `json:"system_prompt"`
+	Config         map[string]interface{} `json:"config"`
+	Capabilities   []string               `json:"capabilities"`
+	OwnerID        string                 `json:"owner_id"`
+	IsActive       bool                   `json:"is_active"`
+	LearningRate   float64                `json:"learning_rate"`
+	MemoryCapacity int                    `json:"memory_capacity"`
+	ContextWindow  int                    `json:"context_window"`
+	Memory         *AgentMemoryResponse   `json:"memory,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	LastActiveAt   *time.Time             `json:"last_active_at,omitempty"`
+}
+
+// AgentMemoryResponse 智能体记忆概况，记忆内容通过记忆搜索接口获取
+type AgentMemoryResponse struct {
+	Capacity       int                         `json:"capacity"`
+	EvictionPolicy domain.MemoryEvictionPolicy `json:"eviction_policy"`
+	TotalMemories  int                         `json:"total_memories"`
+	ActiveMemories int                         `json:"active_memories"`
+	MemoryUsage    float64                     `json:"memory_usage"`
+}
+
+// MemoryResponse 记忆响应，不包含嵌入向量
+type MemoryResponse struct {
+	ID           string                 `json:"id"`
+	Type         domain.MemoryType      `json:"type"`
+	Content      string                 `json:"content"`
+	Context      map[string]interface{} `json:"context,omitempty"`
+	Importance   float64                `json:"importance"`
+	AccessCount  int                    `json:"access_count"`
+	Tags         []string               `json:"tags"`
+	IsActive     bool                   `json:"is_active"`
+	CreatedAt    time.Time              `json:"created_at"`
+	LastAccessed *time.Time             `json:"last_accessed,omitempty"`
+}
+
+// ToolExecutionResponse 工具执行响应，耗时以毫秒返回
+type ToolExecutionResponse struct {
+	ID         string                 `json:"id"`
+	ToolID     string                 `json:"tool_id"`
+	AgentID    string                 `json:"agent_id"`
+	Status     domain.ExecutionStatus `json:"status"`
+	Input      map[string]interface{} `json:"input"`
+	Output     map[string]interface{} `json:"output,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+	Chunks     int                    `json:"chunks,omitempty"`
+	Approval   *domain.ToolApproval   `json:"approval,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+// TranscriptResponse 会话运行记录响应
+type TranscriptResponse struct {
+	AgentID   string                    `json:"agent_id"`
+	SessionID string                    `json:"session_id"`
+	Steps     []*TranscriptStepResponse `json:"steps"`
+}
+
+// TranscriptStepResponse 运行记录步骤响应，耗时以毫秒返回
+type TranscriptStepResponse struct {
+	ID              string                    `json:"id"`
+	RunID           string                    `json:"run_id"`
+	Sequence        int                       `json:"sequence"`
+	Type            domain.TranscriptStepType `json:"type"`
+	Role            string                    `json:"role,omitempty"`
+	Content         string                    `json:"content,omitempty"`
+	Data            map[string]interface{}    `json:"data,omitempty"`
+	ToolExecutionID string                    `json:"tool_execution_id,omitempty"`
+	Error           string                    `json:"error,omitempty"`
+	DurationMs      int64                     `json:"duration_ms"`
+	CreatedAt       time.Time                 `json:"created_at"`
+}
+
+// NewAgentResponse 转换智能体
+func NewAgentResponse(agent *domain.Agent) *AgentResponse {
+	resp := &AgentResponse{
+		ID:             agent.ID.String(),
+		Name:           agent.Name,
+		Type:           agent.Type,
+		Status:         agent.Status,
+		Description:    agent.Description,
+		SystemPrompt:   agent.SystemPrompt,
+		Config:         agent.Config,
+		Capabilities:   nonNilStrings(agent.Capabilities),
+		OwnerID:        uuidString(agent.OwnerID),
+		IsActive:       agent.IsActive,
+		LearningRate:   agent.LearningRate,
+		MemoryCapacity: agent.MemoryCapacity,
+		ContextWindow:  agent.ContextWindow,
+		CreatedAt:      agent.CreatedAt,
+		UpdatedAt:      agent.UpdatedAt,
+		LastActiveAt:   optionalTime(agent.LastActiveAt),
+	}
+	if agent.Memory != nil {
+		resp.Memory = &AgentMemoryResponse{
+			Capacity:       agent.Memory.Capacity,
+			EvictionPolicy: agent.Memory.EvictionPolicy,
+			TotalMemories:  agent.Memory.TotalMemories,
+			ActiveMemories: agent.Memory.ActiveMemories,
+			MemoryUsage:    agent.Memory.MemoryUsage,
+		}
+	}
+	return resp
+}
+
+// NewMemoryResponse 转换记忆
+func NewMemoryResponse(memory *domain.Memory) *MemoryResponse {
+	return &MemoryResponse{
+		ID:           memory.ID.String(),
+		Type:         memory.Type,
+		Content:      memory.Content,
+		Context:      memory.Context,
+		Importance:   memory.Importance,
+		AccessCount:  memory.AccessCount,
+		Tags:         nonNilStrings(memory.Tags),
+		IsActive:     memory.IsActive,
+		CreatedAt:    memory.CreatedAt,
+		LastAccessed: optionalTime(memory.LastAccessed),
+	}
+}
+
+// NewToolExecutionResponse 转换工具执行
+func NewToolExecutionResponse(execution *domain.ToolExecution) *ToolExecutionResponse {
+	return &ToolExecutionResponse{
+		ID:         execution.ID.String(),
+		ToolID:     execution.ToolID.String(),
+		AgentID:    execution.AgentID.String(),
+		Status:     execution.Status,
+		Input:      execution.Input,
+		Output:     execution.Output,
+		Error:      execution.Error,
+		DurationMs: execution.Duration.Milliseconds(),
+		Chunks:     execution.Chunks,
+		Approval:   execution.Approval,
+		CreatedAt:  execution.CreatedAt,
+		UpdatedAt:  execution.UpdatedAt,
+	}
+}
+
+// NewTranscriptResponse 转换会话运行记录
+func NewTranscriptResponse(agentID, sessionID uuid.UUID, steps []*domain.TranscriptStep) *TranscriptResponse {
+	resp := &TranscriptResponse{
+		AgentID:   agentID.String(),
+		SessionID: sessionID.String(),
+		Steps:     make([]*TranscriptStepResponse, 0, len(steps)),
+	}
+	for _, step := range steps {
+		stepResp := &TranscriptStepResponse{
+			ID:         step.ID.String(),
+			RunID:      step.RunID.String(),
+			Sequence:   step.Sequence,
+			Type:       step.Type,
+			Role:       step.Role,
+			Content:    step.Content,
+			Data:       step.Data,
+			Error:      step.Error,
+			DurationMs: step.Duration.Milliseconds(),
+			CreatedAt:  step.CreatedAt,
+		}
+		if step.ToolExecutionID != nil {
+			stepResp.ToolExecutionID = step.ToolExecutionID.String()
+		}
+		resp.Steps = append(resp.Steps, stepResp)
+	}
+	return resp
+}
+
+// toResponse 将服务返回的领域模型转换为响应结构，其他数据（如对话结果）原样返回
+func toResponse(data interface{}) interface{} {
+	switch v := data.(type) {
+	case *domain.Agent:
+		return NewAgentResponse(v)
+	case *domain.Memory:
+		return NewMemoryResponse(v)
+	case *domain.ToolExecution:
+		return NewToolExecutionResponse(v)
+	case []*domain.ToolExecution:
+		executions := make([]*ToolExecutionResponse, 0, len(v))
+		for _, execution := range v {
+			executions = append(executions, NewToolExecutionResponse(execution))
+		}
+		return executions
+	default:
+		return data
+	}
+}
+
+// optionalTime 零值时间返回nil，序列化时省略
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// uuidString 空UUID返回空字符串
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+// nonNilStrings 空列表序列化为[]而不是null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
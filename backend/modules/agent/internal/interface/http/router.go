@@ -10,15 +10,19 @@ import (
 
 // Router 路由结构
 type Router struct {
-	handler *AgentHandler
-	metrics *infrastructure.MetricsRegistry
+	handler       *AgentHandler
+	budgetHandler *BudgetHandler
+	metrics       *infrastructure.MetricsRegistry
+	bodyLimit     middleware.BodyLimitConfig
 }
 
 // NewRouter 创建路由实例
-func NewRouter(handler *AgentHandler, metrics *infrastructure.MetricsRegistry) *Router {
+func NewRouter(handler *AgentHandler, budgetHandler *BudgetHandler, metrics *infrastructure.MetricsRegistry, bodyLimit middleware.BodyLimitConfig) *Router {
 	return &Router{
-		handler: handler,
-		metrics: metrics,
+		handler:       handler,
+		budgetHandler: budgetHandler,
+		metrics:       metrics,
+		bodyLimit:     bodyLimit,
 	}
 }
 
@@ -53,6 +57,7 @@ func (r *Router) setupMiddlewares(router *gin.Engine) {
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS(middleware.DefaultCORSConfig()))
 	router.Use(middleware.MetricsMiddleware(r.metrics))
+	router.Use(middleware.BodyLimit(r.bodyLimit))
 }
 
 // setupHealthRoutes 设置健康检查路由
@@ -90,6 +95,8 @@ func (r *Router) registerAgentRoutes(api *gin.RouterGroup) {
 		agents.PUT("/:id", r.handler.UpdateAgent)
 		agents.DELETE("/:id", r.handler.DeleteAgent)
 		agents.POST("/:id/chat", r.handler.ChatWithAgent)
+		agents.POST("/:id/sessions/:session_id/summary", r.handler.SummarizeConversation)
+		agents.GET("/:id/sessions/:session_id/transcript", r.handler.GetSessionTranscript)
 		agents.POST("/:id/learn", r.handler.LearnAgent)
 	}
 
@@ -102,6 +109,7 @@ func (r *Router) registerAgentRoutes(api *gin.RouterGroup) {
 		tools.PUT("/:id", r.handler.UpdateTool)
 		tools.DELETE("/:id", r.handler.DeleteTool)
 		tools.POST("/:id/execute", r.handler.ExecuteTool)
+		tools.POST("/:id/execute/stream", r.handler.ExecuteToolStream)
 	}
 
 	// 工具分配路由
@@ -124,4 +132,21 @@ func (r *Router) registerAgentRoutes(api *gin.RouterGroup) {
 		executions.GET("", r.handler.GetExecutions)
 		executions.GET("/:id", r.handler.GetExecution)
 	}
+
+	// 工具调用审批路由
+	approvals := agent.Group("/approvals")
+	{
+		approvals.GET("", r.handler.GetPendingApprovals)
+		approvals.POST("/:id/approve", r.handler.ApproveToolExecution)
+		approvals.POST("/:id/reject", r.handler.RejectToolExecution)
+	}
+
+	// 预算和花费路由
+	budgets := agent.Group("/budgets")
+	{
+		budgets.PUT("", r.budgetHandler.SetBudget)
+		budgets.GET("/:scope/:scope_id", r.budgetHandler.GetBudget)
+		budgets.DELETE("/:scope/:scope_id", r.budgetHandler.DeleteBudget)
+	}
+	agent.GET("/usage/report", r.budgetHandler.GetSpendReport)
 }
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	"github.com/noah-loop/backend/modules/agent/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// 流式执行的Server-Sent Events事件名
+const (
+	streamEventChunk = "chunk" // 输出片段
+	streamEventDone  = "done"  // 执行完成，数据为执行记录
+	streamEventError = "error" // 执行失败，数据为错误信息和执行ID
+)
+
+// ExecuteToolStream 流式执行工具，通过Server-Sent Events逐个返回输出片段
+// 第一个片段之前出错时返回普通的JSON错误响应；需要审批的工具返回等待审批的执行记录
+func (h *AgentHandler) ExecuteToolStream(c *gin.Context) {
+	toolID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+
+	cmd := service.NewExecuteToolCommand()
+	cmd.ToolID = toolID
+
+	if err := c.ShouldBindJSON(cmd); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+
+	streaming := false
+	result, err := h.agentService.ExecuteToolStream(c.Request.Context(), cmd, func(chunk map[string]interface{}) error {
+		if !streaming {
+			streaming = true
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+		}
+		c.SSEvent(streamEventChunk, chunk)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+
+	if streaming {
+		if err != nil {
+			h.logger.Warn("Tool stream failed", zap.String("tool_id", toolID.String()), zap.Error(err))
+			event := gin.H{"error": err.Error()}
+			if execution, ok := result.Data.(*domain.ToolExecution); ok {
+				event["execution_id"] = execution.ID
+			}
+			c.SSEvent(streamEventError, event)
+		} else {
+			c.SSEvent(streamEventDone, toResponse(result.Data))
+		}
+		c.Writer.Flush()
+		return
+	}
+
+	if err != nil {
+		if budgetExceededResponse(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrToolNotStreamable) {
+			utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("execution_mode", err.Error()))
+			return
+		}
+		h.logger.Error("Failed to execute tool stream", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	if execution, ok := result.Data.(*domain.ToolExecution); ok && execution.Status == domain.ExecutionStatusAwaitingApproval {
+		utils.SuccessResponse(c, NewToolExecutionResponse(execution), "Tool execution is awaiting approval")
+		return
+	}
+	// 工具没有输出任何片段
+	c.SSEvent(streamEventDone, toResponse(result.Data))
+	c.Writer.Flush()
+}
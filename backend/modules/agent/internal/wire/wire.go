@@ -3,13 +3,22 @@
 package wire
 
 import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/google/wire"
 	"github.com/noah-loop/backend/modules/agent/internal/application/service"
 	"github.com/noah-loop/backend/modules/agent/internal/domain"
 	"github.com/noah-loop/backend/modules/agent/internal/infrastructure/executors"
+	grpcHandler "github.com/noah-loop/backend/modules/agent/internal/interface/grpc"
 	httpHandler "github.com/noah-loop/backend/modules/agent/internal/interface/http"
 	"github.com/noah-loop/backend/modules/agent/internal/infrastructure/repository"
+	"github.com/noah-loop/backend/shared/pkg/httpclient"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
 )
 
 // AgentApp Agent应用结构
@@ -17,6 +26,7 @@ type AgentApp struct {
 	AgentService *service.AgentService
 	Handler      *httpHandler.AgentHandler
 	Router       *httpHandler.Router
+	GRPCHandler  *grpcHandler.AgentGRPCHandler
 	Metrics      *infrastructure.MetricsRegistry
 	Database     *infrastructure.Database
 }
@@ -39,6 +49,9 @@ func InitializeAgentApp() (*AgentApp, func(), error) {
 		// HTTP处理器和路由
 		AgentHandlerProviderSet,
 		
+		// gRPC处理器
+		grpcHandler.NewAgentGRPCHandler,
+		
 		// 应用结构
 		wire.Struct(new(AgentApp), "*"),
 		
@@ -54,11 +67,16 @@ var AgentRepositoryProviderSet = wire.NewSet(
 	repository.NewGormAgentRepository,
 	repository.NewGormToolRepository,
 	repository.NewGormToolExecutionRepository,
+	repository.NewGormUsageRecordRepository,
+	repository.NewGormBudgetRepository,
+	repository.NewGormTranscriptRepository,
 )
 
 // AgentServiceProviderSet 应用服务提供者集合
 var AgentServiceProviderSet = wire.NewSet(
 	NewAgentServiceWithExecutors,
+	service.NewBudgetService,
+	NewPricingConfig,
 	// 事件总线暂时为nil
 	wire.Value((interface{})(nil)),
 	wire.Bind(new(interface{}), new(interface{})),
@@ -66,13 +84,16 @@ var AgentServiceProviderSet = wire.NewSet(
 
 // ToolExecutorProviderSet 工具执行器提供者集合
 var ToolExecutorProviderSet = wire.NewSet(
-	executors.NewCalculatorExecutor,
+	NewToolExecutors,
+	NewToolHTTPClient,
 )
 
 // AgentHandlerProviderSet HTTP处理器提供者集合
 var AgentHandlerProviderSet = wire.NewSet(
 	httpHandler.NewAgentHandler,
+	httpHandler.NewBudgetHandler,
 	httpHandler.NewRouter,
+	NewBodyLimitConfig,
 )
 
 // NewAgentServiceWithExecutors 创建带有执行器的Agent服务
@@ -83,15 +104,97 @@ func NewAgentServiceWithExecutors(
 	eventBus interface{},
 	logger infrastructure.Logger,
 	metrics *infrastructure.MetricsRegistry,
-	calculatorExecutor service.ToolExecutor,
+	toolExecutors []service.ToolExecutor,
+	httpClient *http.Client,
+	budgetService *service.BudgetService,
+	transcriptRepo domain.TranscriptRepository,
 ) *service.AgentService {
 	agentService := service.NewAgentService(agentRepo, toolRepo, toolExecutionRepo, eventBus, logger, metrics)
+	agentService.SetHTTPClient(httpClient)
+	agentService.SetBudgetService(budgetService)
+	agentService.SetTranscriptRepository(transcriptRepo)
+	agentService.SetApproverScope(os.Getenv("AGENT_APPROVER_SCOPE"))
 	
 	// 注册工具执行器
-	agentService.RegisterToolExecutor(domain.ToolTypeCalculator, calculatorExecutor)
+	for _, executor := range toolExecutors {
+		agentService.RegisterToolExecutor(executor.GetSupportedType(), executor)
+	}
 	
 	// 启动指标收集
 	agentService.StartMetricsCollection()
 	
 	return agentService
 }
+
+// NewToolExecutors 创建所有工具执行器
+func NewToolExecutors() []service.ToolExecutor {
+	return []service.ToolExecutor{
+		executors.NewCalculatorExecutor(),
+		executors.NewAPIExecutor(),
+		executors.NewWebExecutor(),
+	}
+}
+
+// NewToolHTTPClient 创建工具执行器共享的出站HTTP客户端，支持通过环境变量覆盖
+func NewToolHTTPClient(logger infrastructure.Logger) *http.Client {
+	config := httpclient.DefaultConfig()
+
+	if timeout, err := time.ParseDuration(os.Getenv("TOOL_HTTP_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+	if maxRetries, err := strconv.Atoi(os.Getenv("TOOL_HTTP_MAX_RETRIES")); err == nil && maxRetries >= 0 {
+		config.Retry.MaxAttempts = maxRetries + 1
+	}
+	if maxIdle, err := strconv.Atoi(os.Getenv("TOOL_HTTP_MAX_IDLE_CONNS_PER_HOST")); err == nil && maxIdle > 0 {
+		config.MaxIdleConnsPerHost = maxIdle
+	}
+	if maxConns, err := strconv.Atoi(os.Getenv("TOOL_HTTP_MAX_CONNS_PER_HOST")); err == nil && maxConns > 0 {
+		config.MaxConnsPerHost = maxConns
+	}
+
+	config.Retry.OnRetry = func(attempt int, err error, delay time.Duration) {
+		logger.Warn("Retrying tool http request",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+	}
+
+	return httpclient.New(config)
+}
+
+// NewPricingConfig 创建计费配置，支持通过环境变量覆盖
+// AGENT_LLM_MODEL_PRICES格式为"模型=输入单价:输出单价"，多个模型以逗号分隔，单价按每1000个token计
+func NewPricingConfig(logger infrastructure.Logger) service.PricingConfig {
+	config := service.DefaultPricingConfig()
+
+	if price, err := strconv.ParseFloat(os.Getenv("AGENT_LLM_PROMPT_PRICE_PER_1K"), 64); err == nil && price >= 0 {
+		config.DefaultModelPrice.PromptPer1K = price
+	}
+	if price, err := strconv.ParseFloat(os.Getenv("AGENT_LLM_COMPLETION_PRICE_PER_1K"), 64); err == nil && price >= 0 {
+		config.DefaultModelPrice.CompletionPer1K = price
+	}
+	if cost, err := strconv.ParseFloat(os.Getenv("AGENT_TOOL_DEFAULT_COST"), 64); err == nil && cost >= 0 {
+		config.DefaultToolCost = cost
+	}
+
+	for _, entry := range strings.Split(os.Getenv("AGENT_LLM_MODEL_PRICES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, prices, ok := strings.Cut(entry, "=")
+		promptPrice, completionPrice, ok2 := strings.Cut(prices, ":")
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(promptPrice), 64)
+		completion, err2 := strconv.ParseFloat(strings.TrimSpace(completionPrice), 64)
+		if !ok || !ok2 || err != nil || err2 != nil || prompt < 0 || completion < 0 {
+			logger.Warn("Ignoring invalid model price", zap.String("entry", entry))
+			continue
+		}
+		config.ModelPrices[strings.TrimSpace(model)] = service.ModelPrice{
+			PromptPer1K:     prompt,
+			CompletionPer1K: completion,
+		}
+	}
+
+	return config
+}
@@ -6,10 +6,12 @@
 package wire
 
 import (
-	"github.com/noah-loop/backend/modules/agent/internal/infrastructure/executors"
+	"github.com/noah-loop/backend/modules/agent/internal/application/service"
+	grpcHandler "github.com/noah-loop/backend/modules/agent/internal/interface/grpc"
 	httpHandler "github.com/noah-loop/backend/modules/agent/internal/interface/http"
 	"github.com/noah-loop/backend/modules/agent/internal/infrastructure/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/logging"
 )
 
 // Injectors from wire.go:
@@ -19,7 +21,7 @@ func InitializeAgentApp() (*AgentApp, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	logger, err := infrastructure.ProvideLogger(config)
+	logger, err := logging.ProvideLogger(config)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -32,14 +34,24 @@ func InitializeAgentApp() (*AgentApp, func(), error) {
 	toolExecutionRepository := repository.NewGormToolExecutionRepository(database)
 	v := _wireValue
 	metricsRegistry := infrastructure.ProvideMetrics("agent", logger)
-	toolExecutor := executors.NewCalculatorExecutor()
-	agentService := NewAgentServiceWithExecutors(agentRepository, toolRepository, toolExecutionRepository, v, logger, metricsRegistry, toolExecutor)
+	v2 := NewToolExecutors()
+	client := NewToolHTTPClient(logger)
+	usageRecordRepository := repository.NewGormUsageRecordRepository(database)
+	budgetRepository := repository.NewGormBudgetRepository(database)
+	pricingConfig := NewPricingConfig(logger)
+	budgetService := service.NewBudgetService(usageRecordRepository, budgetRepository, pricingConfig, logger)
+	transcriptRepository := repository.NewGormTranscriptRepository(database)
+	agentService := NewAgentServiceWithExecutors(agentRepository, toolRepository, toolExecutionRepository, v, logger, metricsRegistry, v2, client, budgetService, transcriptRepository)
 	agentHandler := httpHandler.NewAgentHandler(agentService, logger)
-	router := httpHandler.NewRouter(agentHandler, metricsRegistry)
+	budgetHandler := httpHandler.NewBudgetHandler(budgetService, logger)
+	bodyLimitConfig := NewBodyLimitConfig(logger)
+	router := httpHandler.NewRouter(agentHandler, budgetHandler, metricsRegistry, bodyLimitConfig)
+	agentGRPCHandler := grpcHandler.NewAgentGRPCHandler(agentService, logger)
 	agentApp := &AgentApp{
 		AgentService: agentService,
 		Handler:      agentHandler,
 		Router:       router,
+		GRPCHandler:  agentGRPCHandler,
 		Metrics:      metricsRegistry,
 		Database:     database,
 	}
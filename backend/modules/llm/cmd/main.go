@@ -4,25 +4,25 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/gin-gonic/gin"
+	"github.com/noah-loop/backend/modules/llm/internal/application/service"
 	"github.com/noah-loop/backend/modules/llm/internal/domain"
 	"github.com/noah-loop/backend/modules/llm/internal/wire"
 	"github.com/noah-loop/backend/modules/llm/internal/infrastructure/providers"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/readiness"
+	"github.com/noah-loop/backend/shared/pkg/server"
 	"go.uber.org/zap"
 )
 
@@ -37,7 +37,7 @@ func main() {
 	defer cleanup()
 
 	// 初始化基础设施组件
-	infraApp, infraCleanup, err := initializeInfrastructure()
+	infra, infraCleanup, err := server.NewInfrastructure(serviceName, "../../configs")
 	if err != nil {
 		log.Fatalf("Failed to initialize infrastructure: %v", err)
 	}
@@ -47,132 +47,63 @@ func main() {
 		zap.String("service", serviceName),
 		zap.String("version", app.Config.App.Version))
 
-	// 数据库迁移
-	if err := migrateDatabase(app); err != nil {
-		app.Logger.Fatal("Failed to migrate database", zap.Error(err))
-	}
-
-	// 注册服务到etcd
-	if err := registerService(infraApp.ServiceRegistry, infraApp.Config); err != nil {
-		app.Logger.Fatal("Failed to register service", zap.Error(err))
-	}
-	defer deregisterService(infraApp.ServiceRegistry)
-
-	// 注册提供商
-	registerProviders(app, infraApp.SecretManager)
-
-	// 设置HTTP服务器
-	httpServer := setupHTTPServer(app, infraApp)
-	
-	// 设置gRPC服务器
-	grpcServer := setupGRPCServer(app, infraApp)
-
-	// 启动服务器
-	go startHTTPServer(httpServer, infraApp.Config, app.Logger)
-	go startGRPCServer(grpcServer, infraApp.Config, app.Logger)
-
-	// 启动健康检查更新
-	go startHealthUpdater(infraApp.ServiceRegistry, app.Logger)
-
-	// 等待中断信号
-	waitForShutdown(httpServer, grpcServer, infraApp.TracerManager, app.Logger)
-}
-
-// InfrastructureApp 基础设施应用组件
-type InfrastructureApp struct {
-	Config          *infrastructure.Config
-	Logger          infrastructure.Logger
-	TracerManager   *tracing.TracerManager
-	EtcdClient      *etcd.Client
-	ServiceRegistry *etcd.ServiceRegistry
-	ServiceDiscovery *etcd.ServiceDiscovery
-	ConfigManager   *etcd.ConfigManager
-	SecretManager   *etcd.SecretManager
-}
-
-// initializeInfrastructure 初始化基础设施组件
-func initializeInfrastructure() (*InfrastructureApp, func(), error) {
-	// 加载配置
-	config, err := infrastructure.LoadConfig("../../configs")
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
-	}
-
-	// 初始化日志
-	logger := infrastructure.NewZapLogger(config)
-
-	// 初始化链路追踪
-	tracingConfig := tracing.NewTracingConfigFromInfrastructure(config, serviceName)
-	tracerManager, err := tracing.NewTracerManager(tracingConfig, logger)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize tracer: %w", err)
-	}
-
-	// 初始化etcd客户端
-	etcdConfig := etcd.NewConfigFromInfrastructure(config)
-	etcdClient, err := etcd.NewClient(etcdConfig, logger)
+	// 创建就绪闸门，启动步骤完成且依赖检查通过前不接收业务流量
+	gate, err := setupReadiness(app, infra)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize etcd client: %w", err)
-	}
-
-	// 初始化etcd组件
-	serviceRegistry := etcd.NewServiceRegistry(etcdClient, serviceName, logger)
-	serviceDiscovery := etcd.NewServiceDiscovery(etcdClient, logger)
-	configManager := etcd.NewConfigManager(etcdClient, logger)
-	secretManager := etcd.NewSecretManager(etcdClient, logger)
-
-	app := &InfrastructureApp{
-		Config:          config,
-		Logger:          logger,
-		TracerManager:   tracerManager,
-		EtcdClient:      etcdClient,
-		ServiceRegistry: serviceRegistry,
-		ServiceDiscovery: serviceDiscovery,
-		ConfigManager:   configManager,
-		SecretManager:   secretManager,
-	}
-
-	cleanup := func() {
-		tracerManager.Close(context.Background())
-		etcdClient.Close()
-	}
-
-	return app, cleanup, nil
-}
-
-// registerService 注册服务到etcd
-func registerService(registry *etcd.ServiceRegistry, config *infrastructure.Config) error {
-	serviceInfo := &etcd.ServiceInfo{
-		Name:     serviceName,
-		Address:  "localhost", // 在生产环境中应该是实际IP
-		Port:     config.Services.LLM.Port,
-		GRPCPort: config.Services.LLM.GRPCPort,
-		Version:  config.App.Version,
-		Health:   "healthy",
+		app.Logger.Fatal("Failed to setup readiness gate", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 监听etcd中的服务配置，运行时调整日志级别等可变配置
+	infra.WatchConfig(ctx, serviceName)
+
+	rt := server.New(server.Options{
+		Name:        serviceName,
+		Version:     infra.Config.App.Version,
+		Environment: infra.Config.App.Environment,
+		HTTPPort:    infra.Config.Services.LLM.Port,
+		GRPCPort:    infra.Config.Services.LLM.GRPCPort,
+		Handler:     setupRouter(app, infra),
+		// 添加追踪拦截器
+		GRPCOptions: []grpc.ServerOption{
+			grpc.UnaryInterceptor(tracing.UnaryServerInterceptor(infra.TracerManager)),
+			grpc.StreamInterceptor(tracing.StreamServerInterceptor(infra.TracerManager)),
+		},
+		// TODO: 注册LLM gRPC服务
+		// RegisterGRPC: func(s *grpc.Server) { llmpb.RegisterLLMServiceServer(s, app.GRPCHandler) },
+		Gate:     gate,
+		Registry: infra.ServiceRegistry,
+		Logger:   app.Logger,
 		Metadata: map[string]string{
-			"environment": config.App.Environment,
+			"environment": infra.Config.App.Environment,
 			"region":      "local",
 		},
-	}
-
-	return registry.Register(context.Background(), serviceInfo)
-}
-
-// deregisterService 注销服务
-func deregisterService(registry *etcd.ServiceRegistry) {
-	if err := registry.Deregister(context.Background()); err != nil {
-		log.Printf("Failed to deregister service: %v", err)
+	})
+
+	// 数据库迁移、注册提供商，就绪后注册到etcd，收到关闭信号后摘除流量并关闭服务器
+	err = rt.Run(ctx,
+		readiness.Step{Name: "migrate", Run: func(ctx context.Context) error {
+			return migrateDatabase(app)
+		}},
+		readiness.Step{Name: "register-providers", Run: func(ctx context.Context) error {
+			registerProviders(app, infra.SecretManager)
+			return nil
+		}},
+	)
+	if err != nil {
+		app.Logger.Fatal("LLM service stopped with error", zap.Error(err))
 	}
 }
 
-// setupHTTPServer 设置HTTP服务器
-func setupHTTPServer(app *wire.LLMApp, infraApp *InfrastructureApp) *http.Server {
-	// 设置Gin路由
+// setupRouter 设置Gin路由，添加追踪中间件并挂载应用路由
+func setupRouter(app *wire.LLMApp, infra *server.Infrastructure) http.Handler {
 	router := gin.New()
-	
+
 	// 添加追踪中间件
-	router.Use(tracing.GinTracingMiddleware(infraApp.TracerManager))
-	
+	router.Use(tracing.GinTracingMiddleware(infra.TracerManager))
+
 	// 添加其他中间件
 	router.Use(gin.Recovery())
 	router.Use(gin.Logger())
@@ -180,128 +111,24 @@ func setupHTTPServer(app *wire.LLMApp, infraApp *InfrastructureApp) *http.Server
 	// 设置应用路由
 	config := getConfigFromApp(app)
 	appRouter := app.Router.SetupRouter(config)
-	
+
 	// 挂载应用路由
 	router.Any("/*path", gin.WrapH(appRouter))
 
-	return &http.Server{
-		Addr:         fmt.Sprintf(":%d", infraApp.Config.Services.LLM.Port),
-		Handler:      router,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
-	}
-}
-
-// setupGRPCServer 设置gRPC服务器
-func setupGRPCServer(app *wire.LLMApp, infraApp *InfrastructureApp) *grpc.Server {
-	// 创建gRPC服务器，添加追踪拦截器
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor(infraApp.TracerManager)),
-		grpc.StreamInterceptor(tracing.StreamServerInterceptor(infraApp.TracerManager)),
-	)
-
-	// 注册健康检查服务
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(server, healthServer)
-
-	// 设置服务健康状态
-	healthServer.SetServingStatus(serviceName, grpc_health_v1.HealthCheckResponse_SERVING)
-	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// TODO: 注册LLM gRPC服务
-	// llmpb.RegisterLLMServiceServer(server, app.GRPCHandler)
-
-	// 启用反射（开发环境）
-	if infraApp.Config.App.Environment == "development" {
-		reflection.Register(server)
-	}
-
-	return server
+	return router
 }
 
-// startHTTPServer 启动HTTP服务器
-func startHTTPServer(server *http.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	logger.Info("Starting HTTP server",
-		zap.String("addr", server.Addr),
-		zap.String("service", serviceName))
-
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatal("HTTP server failed", zap.Error(err))
-	}
-}
-
-// startGRPCServer 启动gRPC服务器
-func startGRPCServer(server *grpc.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	addr := fmt.Sprintf(":%d", config.Services.LLM.GRPCPort)
-	listener, err := net.Listen("tcp", addr)
+// setupReadiness 创建就绪闸门并添加数据库和etcd检查
+func setupReadiness(app *wire.LLMApp, infra *server.Infrastructure) (*readiness.Gate, error) {
+	sqlDB, err := app.Database.DB.DB()
 	if err != nil {
-		logger.Fatal("Failed to listen for gRPC", zap.String("addr", addr), zap.Error(err))
-	}
-
-	logger.Info("Starting gRPC server",
-		zap.String("addr", addr),
-		zap.String("service", serviceName))
-
-	if err := server.Serve(listener); err != nil {
-		logger.Fatal("gRPC server failed", zap.Error(err))
-	}
-}
-
-// startHealthUpdater 启动健康状态更新器
-func startHealthUpdater(registry *etcd.ServiceRegistry, logger infrastructure.Logger) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		// 检查服务健康状态
-		health := "healthy"
-		if !isServiceHealthy() {
-			health = "unhealthy"
-		}
-
-		// 更新etcd中的健康状态
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := registry.UpdateHealth(ctx, health); err != nil {
-			logger.Error("Failed to update health status", zap.Error(err))
-		}
-		cancel()
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
 	}
-}
 
-// isServiceHealthy 检查服务健康状态
-func isServiceHealthy() bool {
-	// TODO: 实现实际的健康检查逻辑
-	// 例如：检查数据库连接、检查依赖服务等
-	return true
-}
-
-// waitForShutdown 等待关闭信号
-func waitForShutdown(httpServer *http.Server, grpcServer *grpc.Server, tracerManager *tracing.TracerManager, logger infrastructure.Logger) {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down LLM service...")
-
-	// 设置关闭超时
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// 优雅关闭HTTP服务器
-	if err := httpServer.Shutdown(ctx); err != nil {
-		logger.Error("HTTP server forced shutdown", zap.Error(err))
-	}
-
-	// 优雅关闭gRPC服务器
-	grpcServer.GracefulStop()
-
-	// 关闭追踪管理器
-	if err := tracerManager.Close(ctx); err != nil {
-		logger.Error("Failed to close tracer manager", zap.Error(err))
-	}
-
-	logger.Info("LLM service stopped gracefully")
+	gate := readiness.NewGate(readiness.DefaultConfig())
+	gate.AddCheck("database", readiness.PingCheck(sqlDB))
+	gate.AddCheck("etcd", etcd.HealthCheck(infra.EtcdClient))
+	return gate, nil
 }
 
 // migrateDatabase 执行数据库迁移
@@ -322,13 +149,30 @@ func registerProviders(app *wire.LLMApp, secretManager *etcd.SecretManager) {
 	
 	if openaiKey != "" {
 		openaiProvider := providers.NewOpenAIProvider(openaiKey, nil)
-		app.LLMService.RegisterProvider(domain.ProviderOpenAI, openaiProvider)
+		app.LLMService.RegisterProviderWithConfig(domain.ProviderOpenAI, openaiProvider, providerCallConfigFromEnv("OPENAI"))
 		fmt.Println("OpenAI provider registered")
 	} else {
 		fmt.Println("Warning: OpenAI API key not found in etcd or environment variables")
 	}
 }
 
+// providerCallConfigFromEnv 从环境变量读取提供商的超时与重试配置，如 OPENAI_TIMEOUT、OPENAI_MAX_RETRIES、OPENAI_RETRY_DELAY
+func providerCallConfigFromEnv(prefix string) service.ProviderCallConfig {
+	config := service.DefaultProviderCallConfig()
+
+	if timeout, err := time.ParseDuration(os.Getenv(prefix + "_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+	if maxRetries, err := strconv.Atoi(os.Getenv(prefix + "_MAX_RETRIES")); err == nil && maxRetries >= 0 {
+		config.MaxRetries = maxRetries
+	}
+	if retryDelay, err := time.ParseDuration(os.Getenv(prefix + "_RETRY_DELAY")); err == nil && retryDelay > 0 {
+		config.RetryDelay = retryDelay
+	}
+
+	return config
+}
+
 // getConfigFromApp 从应用中获取配置(临时方案)
 func getConfigFromApp(app *wire.LLMApp) *infrastructure.Config {
 	// TODO: 改进配置获取方式
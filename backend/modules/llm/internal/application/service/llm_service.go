@@ -9,14 +9,44 @@ import (
 	"github.com/noah-loop/backend/modules/llm/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/application"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/retry"
 	"go.uber.org/zap"
 )
 
+// ProviderCallConfig 提供商调用配置
+type ProviderCallConfig struct {
+	Timeout    time.Duration // 单次调用超时
+	MaxRetries int           // 超时、限流、5xx等临时错误的最大重试次数
+	RetryDelay time.Duration // 首次重试等待时间，之后按指数退避
+}
+
+// DefaultProviderCallConfig 默认提供商调用配置
+func DefaultProviderCallConfig() ProviderCallConfig {
+	return ProviderCallConfig{
+		Timeout:    60 * time.Second,
+		MaxRetries: 2,
+		RetryDelay: time.Second,
+	}
+}
+
+// retryPolicy 根据配置生成重试策略
+func (c ProviderCallConfig) retryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = c.MaxRetries + 1
+	policy.AttemptTimeout = c.Timeout
+	if c.RetryDelay > 0 {
+		policy.InitialDelay = c.RetryDelay
+	}
+	return policy
+}
+
 // LLMService 大模型应用服务
 type LLMService struct {
 	modelRepo   domain.ModelRepository
 	requestRepo domain.RequestRepository
 	providers   map[domain.ModelProvider]Provider
+	callConfigs map[domain.ModelProvider]ProviderCallConfig
 	eventBus    application.EventBus
 	logger      infrastructure.Logger
 	metrics     *infrastructure.MetricsRegistry
@@ -34,15 +64,22 @@ func NewLLMService(
 		modelRepo:   modelRepo,
 		requestRepo: requestRepo,
 		providers:   make(map[domain.ModelProvider]Provider),
+		callConfigs: make(map[domain.ModelProvider]ProviderCallConfig),
 		eventBus:    eventBus,
 		logger:      logger,
 		metrics:     metrics,
 	}
 }
 
-// RegisterProvider 注册模型提供商
+// RegisterProvider 注册模型提供商，使用默认的调用配置
 func (s *LLMService) RegisterProvider(provider domain.ModelProvider, impl Provider) {
+	s.RegisterProviderWithConfig(provider, impl, DefaultProviderCallConfig())
+}
+
+// RegisterProviderWithConfig 注册模型提供商并指定超时与重试配置
+func (s *LLMService) RegisterProviderWithConfig(provider domain.ModelProvider, impl Provider, config ProviderCallConfig) {
 	s.providers[provider] = impl
+	s.callConfigs[provider] = config
 }
 
 // CreateModel 创建模型
@@ -144,7 +181,7 @@ func (s *LLMService) processRequestAsync(ctx context.Context, request *domain.Re
 	}()
 	
 	// 调用提供商处理
-	response, err := provider.Process(ctx, &ProviderRequest{
+	response, err := s.callProvider(ctx, model, provider, &ProviderRequest{
 		Model:  model,
 		Input:  request.Input,
 		Config: model.Config,
@@ -187,7 +224,40 @@ func (s *LLMService) processRequestAsync(ctx context.Context, request *domain.Re
 	request.ClearDomainEvents()
 }
 
+// callProvider 调用提供商，单次调用受超时限制，临时错误按配置退避重试
+func (s *LLMService) callProvider(ctx context.Context, model *domain.Model, provider Provider, request *ProviderRequest) (*ProviderResponse, error) {
+	config, ok := s.callConfigs[model.Provider]
+	if !ok {
+		config = DefaultProviderCallConfig()
+	}
+	
+	providerName := string(model.Provider)
+	operation := string(model.Type)
+	policy := config.retryPolicy()
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		metrics.IncProviderRetry(providerName, operation)
+		s.logger.Warn("Provider call failed, retrying",
+			zap.String("provider", providerName),
+			zap.String("model", model.Name),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+	}
+	
+	var response *ProviderResponse
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		start := time.Now()
+		var err error
+		response, err = provider.Process(ctx, request)
+		metrics.ObserveProviderCall(providerName, operation, time.Since(start), metrics.ProviderStatus(err))
+		return err
+	})
+	
+	return response, err
+}
+
 // Provider 模型提供商接口
+// Process 返回的不可重试错误（参数错误、鉴权失败等）应使用 retry.Permanent 标记
 type Provider interface {
 	Process(ctx context.Context, request *ProviderRequest) (*ProviderResponse, error)
 	Health(ctx context.Context) error
@@ -8,6 +8,7 @@ import (
 	"github.com/noah-loop/backend/modules/llm/internal/application/service"
 	"github.com/noah-loop/backend/modules/llm/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/retry"
 	openai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 )
@@ -39,7 +40,7 @@ func (p *OpenAIProvider) Process(ctx context.Context, request *service.ProviderR
 	case domain.ModelTypeEmbedding:
 		return p.processEmbedding(ctx, request)
 	default:
-		return nil, fmt.Errorf("unsupported model type: %s", request.Model.Type)
+		return nil, retry.Permanent(fmt.Errorf("unsupported model type: %s", request.Model.Type))
 	}
 }
 
@@ -48,29 +49,29 @@ func (p *OpenAIProvider) processChat(ctx context.Context, request *service.Provi
 	// 解析消息
 	messagesData, ok := request.Input["messages"]
 	if !ok {
-		return nil, errors.New("messages field is required")
+		return nil, invalidInput("messages field is required")
 	}
 	
 	messagesSlice, ok := messagesData.([]interface{})
 	if !ok {
-		return nil, errors.New("messages must be an array")
+		return nil, invalidInput("messages must be an array")
 	}
 	
 	var messages []openai.ChatCompletionMessage
 	for _, msgData := range messagesSlice {
 		msgMap, ok := msgData.(map[string]interface{})
 		if !ok {
-			return nil, errors.New("invalid message format")
+			return nil, invalidInput("invalid message format")
 		}
 		
 		role, ok := msgMap["role"].(string)
 		if !ok {
-			return nil, errors.New("message role is required")
+			return nil, invalidInput("message role is required")
 		}
 		
 		content, ok := msgMap["content"].(string)
 		if !ok {
-			return nil, errors.New("message content is required")
+			return nil, invalidInput("message content is required")
 		}
 		
 		messages = append(messages, openai.ChatCompletionMessage{
@@ -100,7 +101,7 @@ func (p *OpenAIProvider) processChat(ctx context.Context, request *service.Provi
 	resp, err := p.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		p.logger.Error("OpenAI chat completion failed", zap.Error(err))
-		return nil, err
+		return nil, classifyError(err)
 	}
 	
 	// 构建响应
@@ -133,7 +134,7 @@ func (p *OpenAIProvider) processChat(ctx context.Context, request *service.Provi
 func (p *OpenAIProvider) processCompletion(ctx context.Context, request *service.ProviderRequest) (*service.ProviderResponse, error) {
 	prompt, ok := request.Input["prompt"].(string)
 	if !ok {
-		return nil, errors.New("prompt field is required")
+		return nil, invalidInput("prompt field is required")
 	}
 	
 	req := openai.CompletionRequest{
@@ -152,7 +153,7 @@ func (p *OpenAIProvider) processCompletion(ctx context.Context, request *service
 	resp, err := p.client.CreateCompletion(ctx, req)
 	if err != nil {
 		p.logger.Error("OpenAI completion failed", zap.Error(err))
-		return nil, err
+		return nil, classifyError(err)
 	}
 	
 	output := map[string]interface{}{
@@ -181,7 +182,7 @@ func (p *OpenAIProvider) processCompletion(ctx context.Context, request *service
 func (p *OpenAIProvider) processEmbedding(ctx context.Context, request *service.ProviderRequest) (*service.ProviderResponse, error) {
 	text, ok := request.Input["text"].(string)
 	if !ok {
-		return nil, errors.New("text field is required")
+		return nil, invalidInput("text field is required")
 	}
 	
 	req := openai.EmbeddingRequest{
@@ -192,7 +193,7 @@ func (p *OpenAIProvider) processEmbedding(ctx context.Context, request *service.
 	resp, err := p.client.CreateEmbeddings(ctx, req)
 	if err != nil {
 		p.logger.Error("OpenAI embedding failed", zap.Error(err))
-		return nil, err
+		return nil, classifyError(err)
 	}
 	
 	output := map[string]interface{}{
@@ -215,6 +216,26 @@ func (p *OpenAIProvider) processEmbedding(ctx context.Context, request *service.
 	}, nil
 }
 
+// invalidInput 请求参数错误，重试无法恢复
+func invalidInput(message string) error {
+	return retry.Permanent(errors.New(message))
+}
+
+// classifyError 将OpenAI错误分类，只有超时、限流和服务端错误可以重试
+func classifyError(err error) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && !retry.IsTransientStatus(apiErr.HTTPStatusCode) {
+		return retry.Permanent(err)
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && !retry.IsTransientStatus(reqErr.HTTPStatusCode) {
+		return retry.Permanent(err)
+	}
+
+	return err
+}
+
 // Health 健康检查
 func (p *OpenAIProvider) Health(ctx context.Context) error {
 	// 简单的健康检查，可以尝试调用模型列表API
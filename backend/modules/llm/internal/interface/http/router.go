@@ -11,15 +11,17 @@ import (
 
 // Router 路由结构
 type Router struct {
-	handler *LLMHandler
-	metrics *infrastructure.MetricsRegistry
+	handler   *LLMHandler
+	metrics   *infrastructure.MetricsRegistry
+	bodyLimit middleware.BodyLimitConfig
 }
 
 // NewRouter 创建路由实例
-func NewRouter(handler *LLMHandler, metrics *infrastructure.MetricsRegistry) *Router {
+func NewRouter(handler *LLMHandler, metrics *infrastructure.MetricsRegistry, bodyLimit middleware.BodyLimitConfig) *Router {
 	return &Router{
-		handler: handler,
-		metrics: metrics,
+		handler:   handler,
+		metrics:   metrics,
+		bodyLimit: bodyLimit,
 	}
 }
 
@@ -54,6 +56,7 @@ func (r *Router) setupMiddlewares(router *gin.Engine) {
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS(middleware.DefaultCORSConfig()))
 	router.Use(middleware.MetricsMiddleware(r.metrics))
+	router.Use(middleware.BodyLimit(r.bodyLimit))
 }
 
 // setupHealthRoutes 设置健康检查路由
@@ -63,6 +63,7 @@ var LLMServiceProviderSet = wire.NewSet(
 var LLMHandlerProviderSet = wire.NewSet(
 	httpHandler.NewLLMHandler,
 	httpHandler.NewRouter,
+	NewBodyLimitConfig,
 )
 
 // ProvidersProviderSet Provider提供者集合
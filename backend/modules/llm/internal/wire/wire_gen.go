@@ -10,6 +10,7 @@ import (
 	"github.com/noah-loop/backend/modules/llm/internal/infrastructure/repository"
 	httpHandler "github.com/noah-loop/backend/modules/llm/internal/interface/http"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/logging"
 )
 
 // Injectors from wire.go:
@@ -19,7 +20,7 @@ func InitializeLLMApp() (*LLMApp, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	logger, err := infrastructure.ProvideLogger(config)
+	logger, err := logging.ProvideLogger(config)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -33,7 +34,8 @@ func InitializeLLMApp() (*LLMApp, func(), error) {
 	metricsRegistry := infrastructure.ProvideMetrics("llm", logger)
 	llmService := service.NewLLMService(modelRepository, requestRepository, v, logger, metricsRegistry)
 	llmHandler := httpHandler.NewLLMHandler(llmService, logger)
-	router := httpHandler.NewRouter(llmHandler, metricsRegistry)
+	bodyLimitConfig := NewBodyLimitConfig(logger)
+	router := httpHandler.NewRouter(llmHandler, metricsRegistry, bodyLimitConfig)
 	llmApp := &LLMApp{
 		LLMService: llmService,
 		Handler:    llmHandler,
@@ -4,29 +4,30 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/gin-gonic/gin"
 	"github.com/noah-loop/backend/modules/mcp/internal/domain"
 	"github.com/noah-loop/backend/modules/mcp/internal/wire"
+	mcppb "github.com/noah-loop/backend/modules/mcp/proto/mcp/v1"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/readiness"
+	"github.com/noah-loop/backend/shared/pkg/server"
 	"go.uber.org/zap"
 )
 
 const serviceName = "mcp-service"
 
+// cleanupTasksLockKey 清理任务的分布式锁键
+const cleanupTasksLockKey = serviceName + "/cleanup-tasks"
+
 func main() {
 	// 使用wire初始化应用
 	app, cleanup, err := wire.InitializeMCPApp()
@@ -36,7 +37,7 @@ func main() {
 	defer cleanup()
 
 	// 初始化基础设施组件
-	infraApp, infraCleanup, err := initializeInfrastructure()
+	infra, infraCleanup, err := server.NewInfrastructure(serviceName, "../../configs")
 	if err != nil {
 		log.Fatalf("Failed to initialize infrastructure: %v", err)
 	}
@@ -46,132 +47,68 @@ func main() {
 		zap.String("service", serviceName),
 		zap.String("version", app.Config.App.Version))
 
-	// 数据库迁移
-	if err := migrateDatabase(app); err != nil {
-		app.Logger.Fatal("Failed to migrate database", zap.Error(err))
-	}
-
-	// 注册服务到etcd
-	if err := registerService(infraApp.ServiceRegistry, infraApp.Config); err != nil {
-		app.Logger.Fatal("Failed to register service", zap.Error(err))
-	}
-	defer deregisterService(infraApp.ServiceRegistry)
-
-	// 设置HTTP服务器
-	httpServer := setupHTTPServer(app, infraApp)
-	
-	// 设置gRPC服务器
-	grpcServer := setupGRPCServer(app, infraApp)
-
-	// 启动清理任务
-	startCleanupTasks(app)
-
-	// 启动服务器
-	go startHTTPServer(httpServer, infraApp.Config, app.Logger)
-	go startGRPCServer(grpcServer, infraApp.Config, app.Logger)
-
-	// 启动健康检查更新
-	go startHealthUpdater(infraApp.ServiceRegistry, app.Logger)
-
-	// 等待中断信号
-	waitForShutdown(httpServer, grpcServer, infraApp.TracerManager, app.Logger)
-}
-
-// InfrastructureApp 基础设施应用组件
-type InfrastructureApp struct {
-	Config          *infrastructure.Config
-	Logger          infrastructure.Logger
-	TracerManager   *tracing.TracerManager
-	EtcdClient      *etcd.Client
-	ServiceRegistry *etcd.ServiceRegistry
-	ServiceDiscovery *etcd.ServiceDiscovery
-	ConfigManager   *etcd.ConfigManager
-	SecretManager   *etcd.SecretManager
-}
-
-// initializeInfrastructure 初始化基础设施组件
-func initializeInfrastructure() (*InfrastructureApp, func(), error) {
-	// 加载配置
-	config, err := infrastructure.LoadConfig("../../configs")
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
-	}
-
-	// 初始化日志
-	logger := infrastructure.NewZapLogger(config)
-
-	// 初始化链路追踪
-	tracingConfig := tracing.NewTracingConfigFromInfrastructure(config, serviceName)
-	tracerManager, err := tracing.NewTracerManager(tracingConfig, logger)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize tracer: %w", err)
-	}
-
-	// 初始化etcd客户端
-	etcdConfig := etcd.NewConfigFromInfrastructure(config)
-	etcdClient, err := etcd.NewClient(etcdConfig, logger)
+	// 创建就绪闸门，启动步骤完成且依赖检查通过前不接收业务流量
+	gate, err := setupReadiness(app, infra)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize etcd client: %w", err)
-	}
-
-	// 初始化etcd组件
-	serviceRegistry := etcd.NewServiceRegistry(etcdClient, serviceName, logger)
-	serviceDiscovery := etcd.NewServiceDiscovery(etcdClient, logger)
-	configManager := etcd.NewConfigManager(etcdClient, logger)
-	secretManager := etcd.NewSecretManager(etcdClient, logger)
-
-	app := &InfrastructureApp{
-		Config:          config,
-		Logger:          logger,
-		TracerManager:   tracerManager,
-		EtcdClient:      etcdClient,
-		ServiceRegistry: serviceRegistry,
-		ServiceDiscovery: serviceDiscovery,
-		ConfigManager:   configManager,
-		SecretManager:   secretManager,
-	}
-
-	cleanup := func() {
-		tracerManager.Close(context.Background())
-		etcdClient.Close()
-	}
-
-	return app, cleanup, nil
-}
-
-// registerService 注册服务到etcd
-func registerService(registry *etcd.ServiceRegistry, config *infrastructure.Config) error {
-	serviceInfo := &etcd.ServiceInfo{
-		Name:     serviceName,
-		Address:  "localhost", // 在生产环境中应该是实际IP
-		Port:     config.Services.MCP.Port,
-		GRPCPort: config.Services.MCP.GRPCPort,
-		Version:  config.App.Version,
-		Health:   "healthy",
+		app.Logger.Fatal("Failed to setup readiness gate", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 监听etcd中的服务配置，运行时调整日志级别等可变配置
+	infra.WatchConfig(ctx, serviceName)
+
+	rt := server.New(server.Options{
+		Name:        serviceName,
+		Version:     infra.Config.App.Version,
+		Environment: infra.Config.App.Environment,
+		HTTPPort:    infra.Config.Services.MCP.Port,
+		GRPCPort:    infra.Config.Services.MCP.GRPCPort,
+		Handler:     setupRouter(app, infra),
+		// 添加追踪拦截器
+		GRPCOptions: []grpc.ServerOption{
+			grpc.UnaryInterceptor(tracing.UnaryServerInterceptor(infra.TracerManager)),
+			grpc.StreamInterceptor(tracing.StreamServerInterceptor(infra.TracerManager)),
+		},
+		RegisterGRPC: func(s *grpc.Server) {
+			mcppb.RegisterMCPServiceServer(s, app.GRPCHandler)
+		},
+		Gate:     gate,
+		Registry: infra.ServiceRegistry,
+		Logger:   app.Logger,
 		Metadata: map[string]string{
-			"environment": config.App.Environment,
+			"environment": infra.Config.App.Environment,
 			"region":      "local",
 		},
-	}
-
-	return registry.Register(context.Background(), serviceInfo)
-}
-
-// deregisterService 注销服务
-func deregisterService(registry *etcd.ServiceRegistry) {
-	if err := registry.Deregister(context.Background()); err != nil {
-		log.Printf("Failed to deregister service: %v", err)
+	})
+
+	// 就绪后启动清理任务，多实例部署时只由持有任务锁的实例执行；
+	// 关闭时任务停止后释放任务锁，其他实例无需等待租约过期即可接替
+	taskLock := etcd.NewDistributedLock(infra.EtcdClient, app.Logger)
+	rt.Go("cleanup-tasks", func(ctx context.Context) {
+		runCleanupTasks(ctx, app, taskLock)
+		releaseTaskLock(taskLock, app.Logger)
+	})
+
+	// 数据库迁移，就绪后注册到etcd，收到关闭信号后摘除流量并关闭服务器
+	err = rt.Run(ctx,
+		readiness.Step{Name: "migrate", Run: func(ctx context.Context) error {
+			return migrateDatabase(app)
+		}},
+	)
+	if err != nil {
+		app.Logger.Fatal("MCP service stopped with error", zap.Error(err))
 	}
 }
 
-// setupHTTPServer 设置HTTP服务器
-func setupHTTPServer(app *wire.MCPApp, infraApp *InfrastructureApp) *http.Server {
-	// 设置Gin路由
+// setupRouter 设置Gin路由，添加追踪中间件并挂载应用路由
+func setupRouter(app *wire.MCPApp, infra *server.Infrastructure) http.Handler {
 	router := gin.New()
-	
+
 	// 添加追踪中间件
-	router.Use(tracing.GinTracingMiddleware(infraApp.TracerManager))
-	
+	router.Use(tracing.GinTracingMiddleware(infra.TracerManager))
+
 	// 添加其他中间件
 	router.Use(gin.Recovery())
 	router.Use(gin.Logger())
@@ -179,128 +116,24 @@ func setupHTTPServer(app *wire.MCPApp, infraApp *InfrastructureApp) *http.Server
 	// 设置应用路由
 	config := getConfigFromApp(app)
 	appRouter := app.Router.SetupRouter(config)
-	
+
 	// 挂载应用路由
 	router.Any("/*path", gin.WrapH(appRouter))
 
-	return &http.Server{
-		Addr:         fmt.Sprintf(":%d", infraApp.Config.Services.MCP.Port),
-		Handler:      router,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
-	}
-}
-
-// setupGRPCServer 设置gRPC服务器
-func setupGRPCServer(app *wire.MCPApp, infraApp *InfrastructureApp) *grpc.Server {
-	// 创建gRPC服务器，添加追踪拦截器
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor(infraApp.TracerManager)),
-		grpc.StreamInterceptor(tracing.StreamServerInterceptor(infraApp.TracerManager)),
-	)
-
-	// 注册健康检查服务
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(server, healthServer)
-
-	// 设置服务健康状态
-	healthServer.SetServingStatus(serviceName, grpc_health_v1.HealthCheckResponse_SERVING)
-	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// TODO: 注册MCP gRPC服务
-	// mcppb.RegisterMCPServiceServer(server, app.GRPCHandler)
-
-	// 启用反射（开发环境）
-	if infraApp.Config.App.Environment == "development" {
-		reflection.Register(server)
-	}
-
-	return server
+	return router
 }
 
-// startHTTPServer 启动HTTP服务器
-func startHTTPServer(server *http.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	logger.Info("Starting HTTP server",
-		zap.String("addr", server.Addr),
-		zap.String("service", serviceName))
-
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatal("HTTP server failed", zap.Error(err))
-	}
-}
-
-// startGRPCServer 启动gRPC服务器
-func startGRPCServer(server *grpc.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	addr := fmt.Sprintf(":%d", config.Services.MCP.GRPCPort)
-	listener, err := net.Listen("tcp", addr)
+// setupReadiness 创建就绪闸门并添加数据库和etcd检查
+func setupReadiness(app *wire.MCPApp, infra *server.Infrastructure) (*readiness.Gate, error) {
+	sqlDB, err := app.Database.DB.DB()
 	if err != nil {
-		logger.Fatal("Failed to listen for gRPC", zap.String("addr", addr), zap.Error(err))
-	}
-
-	logger.Info("Starting gRPC server",
-		zap.String("addr", addr),
-		zap.String("service", serviceName))
-
-	if err := server.Serve(listener); err != nil {
-		logger.Fatal("gRPC server failed", zap.Error(err))
-	}
-}
-
-// startHealthUpdater 启动健康状态更新器
-func startHealthUpdater(registry *etcd.ServiceRegistry, logger infrastructure.Logger) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		// 检查服务健康状态
-		health := "healthy"
-		if !isServiceHealthy() {
-			health = "unhealthy"
-		}
-
-		// 更新etcd中的健康状态
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := registry.UpdateHealth(ctx, health); err != nil {
-			logger.Error("Failed to update health status", zap.Error(err))
-		}
-		cancel()
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
 	}
-}
 
-// isServiceHealthy 检查服务健康状态
-func isServiceHealthy() bool {
-	// TODO: 实现实际的健康检查逻辑
-	// 例如：检查数据库连接、检查依赖服务等
-	return true
-}
-
-// waitForShutdown 等待关闭信号
-func waitForShutdown(httpServer *http.Server, grpcServer *grpc.Server, tracerManager *tracing.TracerManager, logger infrastructure.Logger) {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down MCP service...")
-
-	// 设置关闭超时
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// 优雅关闭HTTP服务器
-	if err := httpServer.Shutdown(ctx); err != nil {
-		logger.Error("HTTP server forced shutdown", zap.Error(err))
-	}
-
-	// 优雅关闭gRPC服务器
-	grpcServer.GracefulStop()
-
-	// 关闭追踪管理器
-	if err := tracerManager.Close(ctx); err != nil {
-		logger.Error("Failed to close tracer manager", zap.Error(err))
-	}
-
-	logger.Info("MCP service stopped gracefully")
+	gate := readiness.NewGate(readiness.DefaultConfig())
+	gate.AddCheck("database", readiness.PingCheck(sqlDB))
+	gate.AddCheck("etcd", etcd.HealthCheck(infra.EtcdClient))
+	return gate, nil
 }
 
 // migrateDatabase 执行数据库迁移
@@ -318,28 +151,30 @@ func getConfigFromApp(app *wire.MCPApp) *infrastructure.Config {
 	return config
 }
 
-// startCleanupTasks 启动清理任务
-func startCleanupTasks(app *wire.MCPApp) {
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour) // 每小时执行一次清理
-		defer ticker.Stop()
-
-		for range ticker.C {
-			ctx := context.Background()
-
-			// 清理过期会话
-			if err := app.MCPService.CleanupExpiredSessions(ctx); err != nil {
-				fmt.Printf("Failed to cleanup expired sessions: %v\n", err)
-			} else {
-				fmt.Println("Expired sessions cleanup completed")
-			}
+// runCleanupTasks 运行清理任务直到ctx结束，每小时由持有任务锁的实例执行一次
+func runCleanupTasks(ctx context.Context, app *wire.MCPApp, taskLock *etcd.DistributedLock) {
+	taskLock.RunPeriodic(ctx, cleanupTasksLockKey, 1*time.Hour, func(ctx context.Context) {
+		// 清理过期会话
+		if err := app.MCPService.CleanupExpiredSessions(ctx); err != nil {
+			app.Logger.Error("Failed to cleanup expired sessions", zap.Error(err))
+		} else {
+			app.Logger.Info("Expired sessions cleanup completed")
+		}
 
-			// 管理空闲会话（2小时无活动）
-			if err := app.MCPService.ManageIdleSessions(ctx, 2*time.Hour); err != nil {
-				fmt.Printf("Failed to manage idle sessions: %v\n", err)
-			} else {
-				fmt.Println("Idle sessions management completed")
-			}
+		// 管理空闲会话（2小时无活动）
+		if err := app.MCPService.ManageIdleSessions(ctx, 2*time.Hour); err != nil {
+			app.Logger.Error("Failed to manage idle sessions", zap.Error(err))
+		} else {
+			app.Logger.Info("Idle sessions management completed")
 		}
-	}()
+	})
+}
+
+// releaseTaskLock 释放本实例持有的任务锁
+func releaseTaskLock(taskLock *etcd.DistributedLock, logger infrastructure.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := taskLock.Close(ctx); err != nil {
+		logger.Warn("Failed to release task lock", zap.Error(err))
+	}
 }
\ No newline at end of file
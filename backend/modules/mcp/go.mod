@@ -9,5 +9,8 @@ require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/google/uuid v1.4.0
 	github.com/gorilla/websocket v1.5.1
+	github.com/prometheus/client_golang v1.17.0
 	gorm.io/gorm v1.25.5
+	google.golang.org/grpc v1.59.0
+	google.golang.org/protobuf v1.31.0
 )
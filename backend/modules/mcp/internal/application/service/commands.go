@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
@@ -59,6 +61,7 @@ type AddContextCommand struct {
 	Title            string                      `json:"title" binding:"required"`
 	Content          string                      `json:"content" binding:"required"`
 	Metadata         map[string]interface{}      `json:"metadata"`
+	Tags             []string                    `json:"tags"`
 	Priority         int                         `json:"priority"`
 	CompressionLevel domain.CompressionLevel     `json:"compression_level"`
 }
@@ -96,6 +99,10 @@ func (c *AddContextCommand) Validate() error {
 		return errors.New("invalid context type")
 	}
 	
+	if err := validateTags(c.Tags); err != nil {
+		return err
+	}
+	
 	if c.Priority < 1 || c.Priority > 10 {
 		return errors.New("priority must be between 1 and 10")
 	}
@@ -114,11 +121,13 @@ func (c *AddContextCommand) Validate() error {
 // UpdateContextCommand 更新上下文命令
 type UpdateContextCommand struct {
 	application.BaseCommand
-	ContextID   uuid.UUID                 `json:"context_id" binding:"required"`
-	Title       *string                   `json:"title"`
-	Content     *string                   `json:"content"`
-	Priority    *int                      `json:"priority"`
-	Metadata    map[string]interface{}    `json:"metadata"`
+	ContextID       uuid.UUID                 `json:"context_id" binding:"required"`
+	Title           *string                   `json:"title"`
+	Content         *string                   `json:"content"`
+	Priority        *int                      `json:"priority"`
+	Metadata        map[string]interface{}    `json:"metadata"`
+	ExpectedVersion *int                      `json:"expected_version"` // 读取时的版本号，为空时不做并发检查
+	BaseContent     *string                   `json:"base_content"`     // 读取时的内容，版本号不匹配时作为三方合并的基础版本
 }
 
 func NewUpdateContextCommand(contextID uuid.UUID) *UpdateContextCommand {
@@ -140,6 +149,14 @@ func (c *UpdateContextCommand) Validate() error {
 		return errors.New("priority must be between 1 and 10")
 	}
 	
+	if c.ExpectedVersion != nil && *c.ExpectedVersion < 0 {
+		return errors.New("expected version must not be negative")
+	}
+	
+	if c.BaseContent != nil && (c.ExpectedVersion == nil || c.Content == nil) {
+		return errors.New("base content requires expected version and content")
+	}
+	
 	return nil
 }
 
@@ -176,6 +193,36 @@ func (c *UpdateSessionCommand) Validate() error {
 	return nil
 }
 
+// ForkSessionCommand 分叉会话命令
+type ForkSessionCommand struct {
+	application.BaseCommand
+	SessionID     uuid.UUID  `json:"session_id"`
+	UpToContextID *uuid.UUID `json:"up_to_context_id"` // 复制到该上下文为止（包含），为空则复制全部
+	Title         string     `json:"title"`
+}
+
+func NewForkSessionCommand(sessionID uuid.UUID) *ForkSessionCommand {
+	return &ForkSessionCommand{
+		BaseCommand: application.BaseCommand{
+			CommandID:   uuid.New(),
+			CommandType: "fork_session",
+		},
+		SessionID: sessionID,
+	}
+}
+
+func (c *ForkSessionCommand) Validate() error {
+	if c.SessionID == uuid.Nil {
+		return errors.New("session ID is required")
+	}
+	
+	if c.UpToContextID != nil && *c.UpToContextID == uuid.Nil {
+		return errors.New("up to context ID cannot be empty")
+	}
+	
+	return nil
+}
+
 // 查询对象
 
 // GetSessionQuery 获取会话查询
@@ -229,6 +276,7 @@ type GetSessionContextsQuery struct {
 	application.BaseQuery
 	SessionID   uuid.UUID           `form:"session_id" binding:"required"`
 	Type        *domain.ContextType `form:"type"`
+	Tags        []string            `form:"tags"` // 需同时包含所有标签，可重复传参或用逗号分隔
 	MinPriority int                 `form:"min_priority,default=0"`
 	Page        int                 `form:"page,default=1"`
 	PageSize    int                 `form:"page_size,default=20"`
@@ -266,6 +314,60 @@ func (q *GetSessionContextsQuery) Validate() error {
 	return nil
 }
 
+// SearchContextsQuery 搜索会话上下文查询
+type SearchContextsQuery struct {
+	application.BaseQuery
+	SessionID     uuid.UUID           `form:"session_id"`
+	Keyword       string              `form:"q"`
+	Type          *domain.ContextType `form:"type"`
+	Tags          []string            `form:"tags"` // 需同时包含所有标签，可重复传参或用逗号分隔
+	MinPriority   int                 `form:"min_priority,default=0"`
+	CreatedAfter  *time.Time          `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time          `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page          int                 `form:"page,default=1"`
+	PageSize      int                 `form:"page_size,default=20"`
+}
+
+func NewSearchContextsQuery() *SearchContextsQuery {
+	return &SearchContextsQuery{
+		BaseQuery: application.BaseQuery{
+			QueryID:   uuid.New(),
+			QueryType: "search_contexts",
+		},
+		MinPriority: 0,
+		Page:        1,
+		PageSize:    20,
+	}
+}
+
+func (q *SearchContextsQuery) Validate() error {
+	if q.SessionID == uuid.Nil {
+		return errors.New("session ID is required")
+	}
+	
+	if len(q.Keyword) > 256 {
+		return errors.New("search keyword must not exceed 256 characters")
+	}
+	
+	if q.Page <= 0 {
+		return errors.New("page must be greater than 0")
+	}
+	
+	if q.PageSize <= 0 || q.PageSize > 100 {
+		return errors.New("page size must be between 1 and 100")
+	}
+	
+	if q.MinPriority < 0 || q.MinPriority > 10 {
+		return errors.New("min priority must be between 0 and 10")
+	}
+	
+	if q.CreatedAfter != nil && q.CreatedBefore != nil && q.CreatedAfter.After(*q.CreatedBefore) {
+		return errors.New("created_after must be before created_before")
+	}
+	
+	return nil
+}
+
 // GetSessionsQuery 获取会话列表查询
 type GetSessionsQuery struct {
 	application.BaseQuery
@@ -298,3 +400,26 @@ func (q *GetSessionsQuery) Validate() error {
 	
 	return nil
 }
+
+// parseTags 拆分逗号分隔的标签并规范化
+func parseTags(tags []string) []string {
+	var parts []string
+	for _, tag := range tags {
+		parts = append(parts, strings.Split(tag, ",")...)
+	}
+	return domain.NormalizeTags(parts)
+}
+
+// validateTags 校验标签数量和长度
+func validateTags(tags []string) error {
+	normalized := domain.NormalizeTags(tags)
+	if len(normalized) > domain.MaxContextTags {
+		return fmt.Errorf("at most %d tags are allowed", domain.MaxContextTags)
+	}
+	for _, tag := range normalized {
+		if len([]rune(tag)) > domain.MaxContextTagLength {
+			return fmt.Errorf("tag %q exceeds %d characters", tag, domain.MaxContextTagLength)
+		}
+	}
+	return nil
+}
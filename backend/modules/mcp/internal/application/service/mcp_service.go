@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
@@ -101,10 +103,11 @@ func (s *MCPService) AddContext(ctx context.Context, cmd *AddContextCommand) (*a
 	context := domain.NewContext(cmd.SessionID, cmd.Type, cmd.Title, cmd.Content)
 	context.Metadata = cmd.Metadata
 	context.Priority = cmd.Priority
+	context.SetTags(cmd.Tags)
 	
 	// 检查是否需要压缩
 	if context.TokenCount > 1000 && s.compressor != nil {
-		if err := s.compressContext(context, cmd.CompressionLevel); err != nil {
+		if err := s.compressContext(ctx, context, cmd.CompressionLevel); err != nil {
 			s.logger.Warn("Failed to compress context", zap.Error(err))
 		}
 	}
@@ -152,12 +155,93 @@ func (s *MCPService) AddContext(ctx context.Context, cmd *AddContextCommand) (*a
 	return &application.Result{Success: true, Data: context}, nil
 }
 
+// ForkSession 分叉会话
+// 新会话复制源会话到UpToContextID为止的上下文，用于探索不同的对话路径
+func (s *MCPService) ForkSession(ctx context.Context, cmd *ForkSessionCommand) (*application.Result, error) {
+	if err := cmd.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	// 获取源会话
+	source, err := s.sessionRepo.FindByID(ctx, cmd.SessionID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "session not found"}, err
+	}
+	
+	// 获取源会话上下文（按创建时间排序）
+	contexts, err := s.contextRepo.FindBySessionID(ctx, cmd.SessionID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "failed to get contexts"}, err
+	}
+	
+	if cmd.UpToContextID != nil {
+		cutoff := -1
+		for i, c := range contexts {
+			if c.ID == *cmd.UpToContextID {
+				cutoff = i
+				break
+			}
+		}
+		if cutoff < 0 {
+			return &application.Result{Success: false, Error: "context not found in session"}, domain.ErrContextNotFound
+		}
+		contexts = contexts[:cutoff+1]
+	}
+	
+	forked := source.Fork(cmd.Title, contexts)
+	
+	// 会话和复制的上下文在同一事务中保存，失败时不会留下缺少上下文的会话
+	forkedContexts := forked.Contexts
+	if err := s.sessionRepo.SaveWithContexts(ctx, forked, forkedContexts); err != nil {
+		s.logger.Error("Failed to save forked session", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to save session"}, err
+	}
+	
+	// 发布事件
+	for _, event := range forked.GetDomainEvents() {
+		if err := s.eventBus.Publish(ctx, event); err != nil {
+			s.logger.Warn("Failed to publish event", zap.Error(err))
+		}
+	}
+	forked.ClearDomainEvents()
+	
+	s.logger.Info("Session forked",
+		zap.String("source_session_id", source.ID.String()),
+		zap.String("session_id", forked.ID.String()),
+		zap.Int("context_count", len(forkedContexts)),
+	)
+	
+	if s.metrics != nil {
+		go s.updateSessionMetrics()
+	}
+	
+	return &application.Result{Success: true, Data: forked}, nil
+}
+
 // compressContext 压缩上下文
-func (s *MCPService) compressContext(context *domain.Context, level domain.CompressionLevel) error {
+// 压缩器支持保留关键事实时，提取的关键事实同时保存到上下文元数据的key_facts中
+func (s *MCPService) compressContext(ctx context.Context, context *domain.Context, level domain.CompressionLevel) error {
 	if s.compressor == nil {
 		return fmt.Errorf("no compressor available")
 	}
 	
+	if compactor, ok := s.compressor.(FactPreservingCompressor); ok {
+		compaction, err := compactor.Compact(ctx, context.Content, level)
+		if err != nil {
+			return err
+		}
+		if err := context.Compress(level, compaction.Render()); err != nil {
+			return err
+		}
+		if !compaction.Facts.IsEmpty() {
+			if context.Metadata == nil {
+				context.Metadata = make(map[string]interface{})
+			}
+			context.Metadata["key_facts"] = compaction.Facts
+		}
+		return nil
+	}
+	
 	compressedContent, err := s.compressor.Compress(context.Content, level)
 	if err != nil {
 		return err
@@ -180,7 +264,7 @@ func (s *MCPService) GetContext(ctx context.Context, query *GetContextQuery) (*a
 	
 	// 记录访问
 	context.Access()
-	if err := s.contextRepo.Save(ctx, context); err != nil {
+	if err := s.contextRepo.UpdateAccess(ctx, context); err != nil {
 		s.logger.Warn("Failed to update context access", zap.Error(err))
 	}
 	
@@ -201,6 +285,89 @@ func (s *MCPService) GetContext(ctx context.Context, query *GetContextQuery) (*a
 	return &application.Result{Success: true, Data: context}, nil
 }
 
+// maxUpdateAttempts 并发写入冲突时重新读取并合并的最大次数
+const maxUpdateAttempts = 3
+
+// UpdateContext 更新上下文
+// 指定expected_version时使用乐观锁：版本号不匹配且提交了内容时，以base_content为基础版本、
+// 当前存储内容和提交内容做三方合并，互不重叠的修改都会保留，无法自动合并时返回ContextMergeConflictError；
+// 未提供base_content时返回ErrContextVersionConflict。标题、优先级和元数据按最后写入生效
+func (s *MCPService) UpdateContext(ctx context.Context, cmd *UpdateContextCommand) (*application.Result, error) {
+	if err := cmd.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	expectedVersion := cmd.ExpectedVersion
+	baseContent := cmd.BaseContent
+	
+	for attempt := 1; ; attempt++ {
+		current, err := s.contextRepo.FindByID(ctx, cmd.ContextID)
+		if err != nil {
+			return &application.Result{Success: false, Error: "context not found"}, err
+		}
+		
+		content := cmd.Content
+		merged := false
+		if expectedVersion != nil && *expectedVersion != current.Version {
+			if cmd.Content != nil {
+				if baseContent == nil {
+					return &application.Result{Success: false, Error: domain.ErrContextVersionConflict.Error()}, domain.ErrContextVersionConflict
+				}
+				
+				mergedContent, conflicts := domain.MergeText(*baseContent, current.Content, *cmd.Content)
+				if len(conflicts) > 0 {
+					conflictErr := &domain.ContextMergeConflictError{
+						ContextID:      current.ID.String(),
+						CurrentVersion: current.Version,
+						Conflicts:      conflicts,
+					}
+					return &application.Result{Success: false, Error: conflictErr.Error(), Data: conflictErr}, conflictErr
+				}
+				content = &mergedContent
+				merged = true
+			}
+		} else if cmd.Content != nil && baseContent == nil {
+			// 版本一致时当前内容即为基础版本，写入竞争失败后据此合并
+			base := current.Content
+			baseContent = &base
+			version := current.Version
+			expectedVersion = &version
+		}
+		
+		version := current.Version
+		current.Update(cmd.Title, content, cmd.Priority, cmd.Metadata)
+		err = s.contextRepo.UpdateWithVersion(ctx, current, version)
+		if errors.Is(err, domain.ErrContextVersionConflict) && attempt < maxUpdateAttempts {
+			s.logger.Debug("Context update raced with concurrent write, retrying",
+				zap.String("context_id", cmd.ContextID.String()),
+				zap.Int("attempt", attempt),
+			)
+			continue
+		}
+		if err != nil {
+			return &application.Result{Success: false, Error: "failed to update context"}, err
+		}
+		
+		// 发布领域事件
+		for _, event := range current.GetDomainEvents() {
+			if err := s.eventBus.Publish(ctx, event); err != nil {
+				s.logger.Warn("Failed to publish event", zap.Error(err))
+			}
+		}
+		current.ClearDomainEvents()
+		
+		if merged {
+			s.logger.Info("Context merged with concurrent update",
+				zap.String("context_id", current.ID.String()),
+				zap.Int("base_version", *expectedVersion),
+				zap.Int("version", current.Version),
+			)
+		}
+		
+		return &application.Result{Success: true, Data: current}, nil
+	}
+}
+
 // GetSessionContexts 获取会话上下文
 func (s *MCPService) GetSessionContexts(ctx context.Context, query *GetSessionContextsQuery) (*application.Result, error) {
 	if err := query.Validate(); err != nil {
@@ -217,8 +384,14 @@ func (s *MCPService) GetSessionContexts(ctx context.Context, query *GetSessionCo
 	session.UpdateActivity()
 	s.sessionRepo.Save(ctx, session)
 	
-	// 获取上下文
-	contexts, err := s.contextRepo.FindBySessionID(ctx, query.SessionID)
+	// 获取上下文，指定标签时通过标签索引查询
+	tags := parseTags(query.Tags)
+	var contexts []*domain.Context
+	if len(tags) > 0 {
+		contexts, err = s.contextRepo.FindBySessionIDAndTags(ctx, query.SessionID, tags)
+	} else {
+		contexts, err = s.contextRepo.FindBySessionID(ctx, query.SessionID)
+	}
 	if err != nil {
 		return &application.Result{Success: false, Error: "failed to get contexts"}, err
 	}
@@ -260,6 +433,44 @@ func (s *MCPService) GetSessionContexts(ctx context.Context, query *GetSessionCo
 	}}, nil
 }
 
+// SearchContexts 搜索会话上下文
+// 关键词、类型、优先级和时间范围过滤均下推到仓储层执行，并返回分页结果和总数。
+// 压缩上下文的内容已被替换为压缩文本且无法还原原文，带关键词搜索时会被跳过；
+// 未指定关键词时压缩上下文仍会按其他条件返回。
+func (s *MCPService) SearchContexts(ctx context.Context, query *SearchContextsQuery) (*application.Result, error) {
+	if err := query.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	// 确认会话存在
+	if _, err := s.sessionRepo.FindByID(ctx, query.SessionID); err != nil {
+		return &application.Result{Success: false, Error: "session not found"}, err
+	}
+	
+	criteria := domain.ContextSearchCriteria{
+		Keyword:       strings.TrimSpace(query.Keyword),
+		Type:          query.Type,
+		Tags:          parseTags(query.Tags),
+		MinPriority:   query.MinPriority,
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+	}
+	
+	offset := (query.Page - 1) * query.PageSize
+	contexts, total, err := s.contextRepo.FindBySessionIDWithSearch(ctx, query.SessionID, criteria, offset, query.PageSize)
+	if err != nil {
+		s.logger.Error("Failed to search contexts", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to search contexts"}, err
+	}
+	
+	return &application.Result{Success: true, Data: map[string]interface{}{
+		"contexts":  contexts,
+		"total":     total,
+		"page":      query.Page,
+		"page_size": query.PageSize,
+	}}, nil
+}
+
 // CleanupExpiredSessions 清理过期会话
 func (s *MCPService) CleanupExpiredSessions(ctx context.Context) error {
 	expiredSessions, err := s.sessionRepo.FindExpiredSessions(ctx)
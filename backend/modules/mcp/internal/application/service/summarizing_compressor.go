@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/mcp/internal/domain"
+)
+
+// LLMClient 大模型对话接口，由基础设施层实现
+type LLMClient interface {
+	// Complete 发送系统提示词和用户消息，返回模型回复
+	Complete(ctx context.Context, systemPrompt, userPrompt string) (string, error)
+}
+
+// SummarizationConfig 摘要压缩配置
+type SummarizationConfig struct {
+	APIBase string        // 大模型服务地址（OpenAI兼容），为空时使用SimpleCompressor
+	APIKey  string        // API密钥
+	Model   string        // 摘要模型
+	Timeout time.Duration // 单次摘要的超时
+}
+
+// DefaultSummarizationConfig 默认摘要压缩配置
+func DefaultSummarizationConfig() SummarizationConfig {
+	return SummarizationConfig{
+		Model:   "gpt-4o-mini",
+		Timeout: 60 * time.Second,
+	}
+}
+
+// KeyFacts 压缩时从原文提取的关键事实，与摘要一起保存
+type KeyFacts struct {
+	Entities  []string `json:"entities,omitempty"`  // 人物、组织、系统、文件等实体
+	Decisions []string `json:"decisions,omitempty"` // 已做出的决定和结论
+	Numbers   []string `json:"numbers,omitempty"`   // 带说明的数值，如 "预算: 120万元"
+}
+
+// IsEmpty 是否没有任何关键事实
+func (f KeyFacts) IsEmpty() bool {
+	return len(f.Entities) == 0 && len(f.Decisions) == 0 && len(f.Numbers) == 0
+}
+
+// Compaction 摘要压缩结果
+type Compaction struct {
+	Summary string   `json:"summary"`
+	Facts   KeyFacts `json:"key_facts"`
+}
+
+// keyFactsHeader 压缩内容中关键事实段的标题
+const keyFactsHeader = "[关键事实]"
+
+// Render 生成压缩后的上下文内容：摘要在前，关键事实段在后
+func (c *Compaction) Render() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(c.Summary))
+	if c.Facts.IsEmpty() {
+		return b.String()
+	}
+
+	b.WriteString("\n\n" + keyFactsHeader)
+	writeFacts := func(label string, facts []string) {
+		if len(facts) == 0 {
+			return
+		}
+		b.WriteString("\n" + label + ":")
+		for _, fact := range facts {
+			b.WriteString("\n- " + fact)
+		}
+	}
+	writeFacts("实体", c.Facts.Entities)
+	writeFacts("决策", c.Facts.Decisions)
+	writeFacts("数值", c.Facts.Numbers)
+	return b.String()
+}
+
+// FactPreservingCompressor 保留关键事实的压缩器，压缩时同时返回提取的关键事实
+// MCPService压缩上下文时优先使用，关键事实保存到上下文元数据的key_facts中
+type FactPreservingCompressor interface {
+	ContextCompressor
+	Compact(ctx context.Context, content string, level domain.CompressionLevel) (*Compaction, error)
+}
+
+// compactionTargets 各压缩级别的摘要长度相对原文的比例
+var compactionTargets = map[domain.CompressionLevel]float64{
+	domain.CompressionLight:  0.5,
+	domain.CompressionMedium: 0.25,
+	domain.CompressionHeavy:  0.1,
+}
+
+// minSummaryLength 摘要长度的下限（字符），避免短文本被要求压缩成一两个词
+const minSummaryLength = 200
+
+// maxRecoveredNumbers 摘要遗漏的数值最多补回的句子数
+const maxRecoveredNumbers = 20
+
+// compactionSystemPrompt 要求模型生成摘要并提取关键事实，以JSON返回
+const compactionSystemPrompt = `You compact conversation and document context for an AI agent so it uses fewer tokens without losing information the agent needs later.
+Write the summary in the same language as the content. Keep every named entity, decision, number, date, identifier and open question that matters; drop repetition, pleasantries and filler.
+Also extract key facts verbatim from the content:
+- "entities": people, organizations, systems, files, products and other named things
+- "decisions": decisions made, conclusions reached and action items with owners
+- "numbers": every significant number, amount, date or metric, each with a short label, e.g. "budget: $1.2M"
+Reply with a JSON object {"summary": "...", "entities": [...], "decisions": [...], "numbers": [...]} and nothing else.`
+
+// SummarizingCompressor 基于大模型摘要的上下文压缩器
+// 摘要后附带关键事实段（实体、决策、数值），模型遗漏的数值会从原文补回所在的句子；
+// 摘要是有损压缩，Decompress无法还原原文，返回压缩后的内容
+type SummarizingCompressor struct {
+	client  LLMClient
+	timeout time.Duration
+}
+
+// NewSummarizingCompressor 创建摘要压缩器
+func NewSummarizingCompressor(client LLMClient, config SummarizationConfig) *SummarizingCompressor {
+	return &SummarizingCompressor{
+		client:  client,
+		timeout: config.Timeout,
+	}
+}
+
+// Compress 压缩内容，返回附带关键事实的摘要
+func (c *SummarizingCompressor) Compress(content string, level domain.CompressionLevel) (string, error) {
+	compaction, err := c.Compact(context.Background(), content, level)
+	if err != nil {
+		return "", err
+	}
+	return compaction.Render(), nil
+}
+
+// Decompress 摘要无法还原原文，返回压缩后的内容
+func (c *SummarizingCompressor) Decompress(compressedContent string, level domain.CompressionLevel) (string, error) {
+	return compressedContent, nil
+}
+
+// Compact 生成摘要并提取关键事实
+// 压缩结果不比原文短时返回错误，调用方保留原文
+func (c *SummarizingCompressor) Compact(ctx context.Context, content string, level domain.CompressionLevel) (*Compaction, error) {
+	ratio, ok := compactionTargets[level]
+	if !ok {
+		return &Compaction{Summary: content}, nil
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	targetLength := int(float64(len([]rune(content))) * ratio)
+	if targetLength < minSummaryLength {
+		targetLength = minSummaryLength
+	}
+	userPrompt := fmt.Sprintf("Compact the following content. The summary must be at most %d characters.\n\n<content>\n%s\n</content>", targetLength, content)
+
+	reply, err := c.client.Complete(ctx, compactionSystemPrompt, userPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize context: %w", err)
+	}
+
+	compaction, err := parseCompaction(reply)
+	if err != nil {
+		return nil, err
+	}
+	recoverMissingNumbers(content, compaction)
+
+	if len(compaction.Render()) >= len(content) {
+		return nil, errors.New("summary is not shorter than the original content")
+	}
+	return compaction, nil
+}
+
+// parseCompaction 解析模型回复的JSON
+func parseCompaction(reply string) (*Compaction, error) {
+	reply = strings.TrimSpace(reply)
+	// 部分模型会用代码块包裹JSON
+	if strings.HasPrefix(reply, "```") {
+		reply = strings.TrimPrefix(strings.TrimPrefix(reply, "```json"), "```")
+		reply = strings.TrimSpace(strings.TrimSuffix(reply, "```"))
+	}
+
+	var parsed struct {
+		Summary   string   `json:"summary"`
+		Entities  []string `json:"entities"`
+		Decisions []string `json:"decisions"`
+		Numbers   []string `json:"numbers"`
+	}
+	if err := json.Unmarshal([]byte(reply), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse summary: %w", err)
+	}
+	if strings.TrimSpace(parsed.Summary) == "" {
+		return nil, errors.New("summary is empty")
+	}
+
+	return &Compaction{
+		Summary: strings.TrimSpace(parsed.Summary),
+		Facts: KeyFacts{
+			Entities:  cleanFacts(parsed.Entities),
+			Decisions: cleanFacts(parsed.Decisions),
+			Numbers:   cleanFacts(parsed.Numbers),
+		},
+	}, nil
+}
+
+// cleanFacts 去除空白和重复的事实
+func cleanFacts(facts []string) []string {
+	seen := make(map[string]bool, len(facts))
+	cleaned := make([]string, 0, len(facts))
+	for _, fact := range facts {
+		fact = strings.Join(strings.Fields(fact), " ")
+		if fact == "" || seen[fact] {
+			continue
+		}
+		seen[fact] = true
+		cleaned = append(cleaned, fact)
+	}
+	return cleaned
+}
+
+// numberPattern 匹配原文中的数值，包括小数、千分位、百分比和日期时间
+var numberPattern = regexp.MustCompile(`\d+(?:[.,:/-]\d+)*%?`)
+
+// sentenceEnd 句子结束符，英文标点后需要有空白，避免在小数点处切分
+var sentenceEnd = regexp.MustCompile(`[.!?;]+(?:\s+|$)|[。！？；\n]+\s*`)
+
+// recoverMissingNumbers 将摘要和关键事实中都没有出现的数值所在的原文句子补充到数值事实中
+func recoverMissingNumbers(content string, compaction *Compaction) {
+	kept := make(map[string]bool)
+	for _, number := range numberPattern.FindAllString(compaction.Render(), -1) {
+		kept[number] = true
+	}
+
+	recovered := 0
+	for _, sentence := range splitSentences(content) {
+		if recovered >= maxRecoveredNumbers {
+			return
+		}
+		missing := false
+		for _, number := range numberPattern.FindAllString(sentence, -1) {
+			if !kept[number] {
+				missing = true
+				kept[number] = true
+			}
+		}
+		if missing {
+			compaction.Facts.Numbers = append(compaction.Facts.Numbers, sentence)
+			recovered++
+		}
+	}
+}
+
+// splitSentences 按句子结束符切分文本，保留结束符之前的标点
+func splitSentences(content string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(content, -1) {
+		if sentence := strings.TrimSpace(content[start:loc[1]]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = loc[1]
+	}
+	if sentence := strings.TrimSpace(content[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
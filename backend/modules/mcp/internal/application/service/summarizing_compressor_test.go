@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/mcp/internal/domain"
+)
+
+// stubLLMClient 返回固定回复并记录收到的提示词
+type stubLLMClient struct {
+	reply  string
+	err    error
+	prompt string
+}
+
+func (c *stubLLMClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	c.prompt = userPrompt
+	return c.reply, c.err
+}
+
+// meetingNotes 关键事实分散在大量无关内容中的会议记录
+func meetingNotes() string {
+	var b strings.Builder
+	b.WriteString("Meeting notes. Alice from Acme Corp joined the call. ")
+	for i := 0; i < 40; i++ {
+		b.WriteString("We discussed general topics and the weather at length without conclusions. ")
+	}
+	b.WriteString("The team decided to migrate the billing service to PostgreSQL 16. ")
+	b.WriteString("The Q3 budget is 1,200,000 USD. Latency must stay under 250ms. Release date: 2024-09-30.\n")
+	return b.String()
+}
+
+const meetingSummaryReply = "```json\n" + `{
+	"summary": "Alice (Acme Corp) agreed to migrate billing to PostgreSQL 16; Q3 budget 1,200,000 USD.",
+	"entities": ["Alice", "Acme Corp", "PostgreSQL 16", " Alice "],
+	"decisions": ["Migrate billing service to PostgreSQL 16"],
+	"numbers": ["Q3 budget: 1,200,000 USD"]
+}` + "\n```"
+
+func TestCompactPreservesKeyFacts(t *testing.T) {
+	content := meetingNotes()
+	client := &stubLLMClient{reply: meetingSummaryReply}
+	compressor := NewSummarizingCompressor(client, DefaultSummarizationConfig())
+
+	compaction, err := compressor.Compact(context.Background(), content, domain.CompressionMedium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := compaction.Render()
+	if len(rendered) >= len(content)/3 {
+		t.Fatalf("compacted to %d of %d bytes", len(rendered), len(content))
+	}
+	for _, fact := range []string{"Alice", "Acme Corp", "PostgreSQL 16", "1,200,000", "250ms", "2024-09-30", "[关键事实]"} {
+		if !strings.Contains(rendered, fact) {
+			t.Errorf("compacted context lost %q:\n%s", fact, rendered)
+		}
+	}
+
+	// 重复的实体去重，摘要遗漏的数值按原文句子补回
+	if want := []string{"Alice", "Acme Corp", "PostgreSQL 16"}; !reflect.DeepEqual(compaction.Facts.Entities, want) {
+		t.Fatalf("entities = %q, want %q", compaction.Facts.Entities, want)
+	}
+	if want := []string{"Q3 budget: 1,200,000 USD", "Latency must stay under 250ms.", "Release date: 2024-09-30."}; !reflect.DeepEqual(compaction.Facts.Numbers, want) {
+		t.Fatalf("numbers = %q, want %q", compaction.Facts.Numbers, want)
+	}
+	if !strings.Contains(client.prompt, "at most") || !strings.Contains(client.prompt, "Acme Corp") {
+		t.Fatalf("prompt = %q", client.prompt)
+	}
+}
+
+func TestSummarizingCompressorCompress(t *testing.T) {
+	content := meetingNotes()
+	client := &stubLLMClient{reply: meetingSummaryReply}
+	compressor := NewSummarizingCompressor(client, DefaultSummarizationConfig())
+
+	compaction, err := compressor.Compact(context.Background(), content, domain.CompressionHeavy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressed, err := compressor.Compress(content, domain.CompressionHeavy); err != nil || compressed != compaction.Render() {
+		t.Fatalf("compress = %q, %v", compressed, err)
+	}
+
+	// 不压缩时不调用模型
+	client.prompt = ""
+	if compressed, err := compressor.Compress("x", domain.CompressionNone); err != nil || compressed != "x" || client.prompt != "" {
+		t.Fatalf("compress without compression = %q, %v", compressed, err)
+	}
+}
+
+func TestCompactErrors(t *testing.T) {
+	ctx := context.Background()
+
+	// 摘要不比原文短时保留原文
+	client := &stubLLMClient{reply: meetingSummaryReply}
+	if _, err := NewSummarizingCompressor(client, DefaultSummarizationConfig()).Compact(ctx, "short 1", domain.CompressionLight); err == nil {
+		t.Fatal("summary longer than the content should be rejected")
+	}
+
+	for name, client := range map[string]*stubLLMClient{
+		"invalid json":  {reply: "not json"},
+		"empty summary": {reply: `{"summary": " "}`},
+		"llm error":     {err: errors.New("unavailable")},
+	} {
+		if _, err := NewSummarizingCompressor(client, DefaultSummarizationConfig()).Compact(ctx, meetingNotes(), domain.CompressionLight); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("Version 1.2 shipped. Next: 3.4!\n中文句子。第二句")
+	want := []string{"Version 1.2 shipped.", "Next: 3.4!", "中文句子。", "第二句"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sentences = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
@@ -36,6 +38,7 @@ type Context struct {
 	Title          string                    `json:"title"`
 	Content        string                    `json:"content" gorm:"type:text"`
 	Metadata       map[string]interface{}    `json:"metadata" gorm:"type:jsonb"`
+	Tags           []string                  `json:"tags" gorm:"type:jsonb;serializer:json;index:idx_contexts_tags,type:gin"` // 自由标签，已规范化为小写
 	TokenCount     int                       `json:"token_count"`
 	Priority       int                       `json:"priority" gorm:"default:1"`
 	IsCompressed   bool                      `json:"is_compressed" gorm:"default:false"`
@@ -96,6 +99,23 @@ func NewContext(sessionID uuid.UUID, contextType ContextType, title, content str
 	return context
 }
 
+// CloneForSession 深拷贝上下文到指定会话
+func (c *Context) CloneForSession(sessionID uuid.UUID) *Context {
+	cloned := *c
+	cloned.BaseEntity = domain.BaseEntity{
+		ID:        domain.NewEntityID(),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: time.Now(),
+	}
+	cloned.SessionID = sessionID
+	cloned.Metadata = copyMetadata(c.Metadata)
+	cloned.Tags = append([]string(nil), c.Tags...)
+	cloned.Session = nil
+	cloned.domainEvents = make([]domain.DomainEvent, 0)
+	
+	return &cloned
+}
+
 // Access 访问上下文
 func (c *Context) Access() {
 	c.AccessCount++
@@ -150,6 +170,29 @@ func (c *Context) Decompress(originalContent string) error {
 	return nil
 }
 
+// SetTags 设置标签，标签会被规范化
+func (c *Context) SetTags(tags []string) {
+	c.Tags = NormalizeTags(tags)
+	c.MarkAsModified()
+}
+
+// HasTags 检查上下文是否包含所有给定标签，tags需已规范化
+func (c *Context) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range c.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // UpdatePriority 更新优先级
 func (c *Context) UpdatePriority(priority int) {
 	if priority < 1 {
@@ -171,6 +214,45 @@ func (c *Context) UpdatePriority(priority int) {
 	c.domainEvents = append(c.domainEvents, event)
 }
 
+// Update 更新上下文的标题、内容、优先级和元数据，元数据按键合并
+// 更新内容时以新内容为原文，清除压缩状态
+func (c *Context) Update(title, content *string, priority *int, metadata map[string]interface{}) {
+	changed := make([]string, 0, 4)
+	
+	if title != nil && *title != c.Title {
+		c.Title = *title
+		changed = append(changed, "title")
+	}
+	if content != nil && (*content != c.Content || c.IsCompressed) {
+		c.Content = *content
+		c.IsCompressed = false
+		c.CompressionLevel = CompressionNone
+		c.TokenCount = len(c.Content) / 4 // 粗略估算
+		c.OriginalSize = len(c.Content)
+		c.CompressedSize = 0
+		changed = append(changed, "content")
+	}
+	if priority != nil {
+		c.UpdatePriority(*priority)
+	}
+	if len(metadata) > 0 {
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]interface{}, len(metadata))
+		}
+		for key, value := range metadata {
+			c.Metadata[key] = copyMetadataValue(value)
+		}
+		changed = append(changed, "metadata")
+	}
+	c.MarkAsModified()
+	
+	event := domain.NewDomainEvent("context.updated", c.ID, map[string]interface{}{
+		"context_id":     c.ID,
+		"changed_fields": changed,
+	})
+	c.domainEvents = append(c.domainEvents, event)
+}
+
 // GetRelevanceScore 获取相关性评分
 func (c *Context) GetRelevanceScore() float64 {
 	// 基于访问频率、优先级、时效性计算相关性
@@ -204,16 +286,92 @@ func (e *ContextError) Error() string {
 	return e.message
 }
 
+// ErrContextNotFound 上下文不存在
+var ErrContextNotFound = NewContextError("context not found")
+
+// ErrContextVersionConflict 上下文已被其他请求修改，版本号不匹配
+var ErrContextVersionConflict = NewContextError("context version conflict")
+
+// MaxContextTags 单个上下文最多的标签数
+const MaxContextTags = 20
+
+// MaxContextTagLength 单个标签的最大长度
+const MaxContextTagLength = 64
+
+// NormalizeTags 规范化标签：去除首尾空白、转为小写、去掉空标签和重复标签，保持原有顺序
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ContextSearchCriteria 上下文搜索条件
+type ContextSearchCriteria struct {
+	Keyword       string       // 内容关键词（不区分大小写）
+	Type          *ContextType // 上下文类型
+	Tags          []string     // 标签，需同时包含所有标签，需已规范化
+	MinPriority   int          // 最低优先级
+	CreatedAfter  *time.Time   // 创建时间下限
+	CreatedBefore *time.Time   // 创建时间上限
+}
+
 // ContextRepository 上下文仓储接口
 type ContextRepository interface {
 	domain.Repository[*Context]
 	FindBySessionID(ctx context.Context, sessionID uuid.UUID) ([]*Context, error)
+	// FindBySessionIDAndTags 查找会话中同时包含所有给定标签的上下文，tags需已规范化
+	FindBySessionIDAndTags(ctx context.Context, sessionID uuid.UUID, tags []string) ([]*Context, error)
+	FindBySessionIDWithSearch(ctx context.Context, sessionID uuid.UUID, criteria ContextSearchCriteria, offset, limit int) ([]*Context, int64, error)
+	// UpdateWithVersion 仅当存储的版本号等于expectedVersion时写入可编辑字段并递增版本号，否则返回ErrContextVersionConflict
+	UpdateWithVersion(ctx context.Context, context *Context, expectedVersion int) error
+	// UpdateAccess 只更新访问统计，不覆盖并发写入的内容
+	UpdateAccess(ctx context.Context, context *Context) error
 	FindByType(ctx context.Context, contextType ContextType) ([]*Context, error)
 	FindByPriority(ctx context.Context, minPriority int) ([]*Context, error)
 	FindExpiredContexts(ctx context.Context, before time.Time) ([]*Context, error)
 	GetSessionContextSize(ctx context.Context, sessionID uuid.UUID) (int, error)
 }
 
+// copyMetadata 深拷贝元数据
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	
+	copied := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		copied[key] = copyMetadataValue(value)
+	}
+	return copied
+}
+
+func copyMetadataValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyMetadata(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyMetadataValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
 func min(a, b float64) float64 {
 	if a < b {
 		return a
@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxMergeDiffCells 行级差异计算的最大规模（行数乘积），超过时整段视为修改
+const maxMergeDiffCells = 4 << 20
+
+// MergeConflict 三方合并中双方修改重叠且无法自动合并的区域
+type MergeConflict struct {
+	Base   string `json:"base"`   // 共同基础版本中的内容
+	Ours   string `json:"ours"`   // 当前存储版本中的内容
+	Theirs string `json:"theirs"` // 本次提交版本中的内容
+}
+
+// ContextMergeConflictError 上下文并发修改无法自动合并
+type ContextMergeConflictError struct {
+	ContextID      string
+	CurrentVersion int
+	Conflicts      []MergeConflict
+}
+
+func (e *ContextMergeConflictError) Error() string {
+	return fmt.Sprintf("context %s was modified concurrently (current version %d): %d conflicting region(s)",
+		e.ContextID, e.CurrentVersion, len(e.Conflicts))
+}
+
+// mergeHunk 相对基础版本的一处修改：将base[start:end]替换为lines
+type mergeHunk struct {
+	start int
+	end   int
+	lines []string
+}
+
+// MergeText 以行为单位对文本做三方合并
+// ours和theirs都由base修改而来：互不重叠的修改合并保留；双方在同一位置追加内容时按ours、theirs的顺序都保留；
+// 双方修改了重叠的行且结果不同时返回冲突
+func MergeText(base, ours, theirs string) (string, []MergeConflict) {
+	switch {
+	case ours == theirs, base == theirs:
+		return ours, nil
+	case base == ours:
+		return theirs, nil
+	}
+
+	// 统一补齐末尾换行，避免在没有换行结尾的文本后追加时最后一行被视为双方都修改
+	oursTrailing := strings.HasSuffix(ours, "\n")
+	theirsTrailing := strings.HasSuffix(theirs, "\n")
+	baseLines := splitLines(withTrailingNewline(base))
+	oursLines := splitLines(withTrailingNewline(ours))
+	theirsLines := splitLines(withTrailingNewline(theirs))
+
+	oursHunks := diffLines(baseLines, oursLines)
+	theirsHunks := diffLines(baseLines, theirsLines)
+
+	var merged strings.Builder
+	var conflicts []MergeConflict
+	pos, i, j := 0, 0, 0
+	for i < len(oursHunks) || j < len(theirsHunks) {
+		// 取起点最早的修改作为区域起点，并合并与区域重叠的所有修改
+		var regionOurs, regionTheirs []mergeHunk
+		var start, end int
+		if j >= len(theirsHunks) || (i < len(oursHunks) && oursHunks[i].start <= theirsHunks[j].start) {
+			start, end = oursHunks[i].start, oursHunks[i].end
+			regionOurs = append(regionOurs, oursHunks[i])
+			i++
+		} else {
+			start, end = theirsHunks[j].start, theirsHunks[j].end
+			regionTheirs = append(regionTheirs, theirsHunks[j])
+			j++
+		}
+		for {
+			if i < len(oursHunks) && overlapsRegion(oursHunks[i], start, end) {
+				end = maxInt(end, oursHunks[i].end)
+				regionOurs = append(regionOurs, oursHunks[i])
+				i++
+				continue
+			}
+			if j < len(theirsHunks) && overlapsRegion(theirsHunks[j], start, end) {
+				end = maxInt(end, theirsHunks[j].end)
+				regionTheirs = append(regionTheirs, theirsHunks[j])
+				j++
+				continue
+			}
+			break
+		}
+
+		merged.WriteString(strings.Join(baseLines[pos:start], ""))
+		pos = end
+
+		switch {
+		case len(regionTheirs) == 0:
+			merged.WriteString(applyHunks(baseLines, start, end, regionOurs))
+		case len(regionOurs) == 0:
+			merged.WriteString(applyHunks(baseLines, start, end, regionTheirs))
+		default:
+			oursText := applyHunks(baseLines, start, end, regionOurs)
+			theirsText := applyHunks(baseLines, start, end, regionTheirs)
+			switch {
+			case oursText == theirsText:
+				merged.WriteString(oursText)
+			case start == end:
+				// 双方在同一位置追加内容，都保留
+				merged.WriteString(oursText)
+				merged.WriteString(theirsText)
+			default:
+				conflicts = append(conflicts, MergeConflict{
+					Base:   strings.Join(baseLines[start:end], ""),
+					Ours:   oursText,
+					Theirs: theirsText,
+				})
+			}
+		}
+	}
+	merged.WriteString(strings.Join(baseLines[pos:], ""))
+
+	if len(conflicts) > 0 {
+		return "", conflicts
+	}
+
+	result := merged.String()
+	if !oursTrailing && !theirsTrailing {
+		result = strings.TrimSuffix(result, "\n")
+	}
+	return result, nil
+}
+
+// overlapsRegion 判断修改是否与区域重叠，修改按起点排序，起点不早于区域起点
+// 紧邻区域之后的修改不算重叠；区域为纯插入时，同一位置的修改算重叠
+func overlapsRegion(hunk mergeHunk, start, end int) bool {
+	return hunk.start < end || hunk.start == start
+}
+
+// applyHunks 将区域内一方的修改应用到基础版本的base[start:end]
+func applyHunks(baseLines []string, start, end int, hunks []mergeHunk) string {
+	var b strings.Builder
+	pos := start
+	for _, hunk := range hunks {
+		b.WriteString(strings.Join(baseLines[pos:hunk.start], ""))
+		b.WriteString(strings.Join(hunk.lines, ""))
+		pos = hunk.end
+	}
+	b.WriteString(strings.Join(baseLines[pos:end], ""))
+	return b.String()
+}
+
+// diffLines 计算从base到changed的行级修改，按起点排序
+func diffLines(base, changed []string) []mergeHunk {
+	// 去掉公共前后缀，追加类修改无需计算最长公共子序列
+	prefix := 0
+	for prefix < len(base) && prefix < len(changed) && base[prefix] == changed[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(changed)-prefix &&
+		base[len(base)-1-suffix] == changed[len(changed)-1-suffix] {
+		suffix++
+	}
+
+	a := base[prefix : len(base)-suffix]
+	b := changed[prefix : len(changed)-suffix]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxMergeDiffCells {
+		return []mergeHunk{{start: prefix, end: prefix + len(a), lines: b}}
+	}
+
+	// lcs[x][y] 为a[x:]与b[y:]的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for x := range lcs {
+		lcs[x] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				lcs[x][y] = lcs[x+1][y+1] + 1
+			} else {
+				lcs[x][y] = maxInt(lcs[x+1][y], lcs[x][y+1])
+			}
+		}
+	}
+
+	var hunks []mergeHunk
+	x, y := 0, 0
+	hunkX, hunkY := 0, 0
+	flush := func() {
+		if hunkX < x || hunkY < y {
+			hunks = append(hunks, mergeHunk{start: prefix + hunkX, end: prefix + x, lines: b[hunkY:y]})
+		}
+	}
+	for x < len(a) && y < len(b) {
+		switch {
+		case a[x] == b[y]:
+			flush()
+			x++
+			y++
+			hunkX, hunkY = x, y
+		case lcs[x+1][y] >= lcs[x][y+1]:
+			x++
+		default:
+			y++
+		}
+	}
+	x, y = len(a), len(b)
+	flush()
+
+	return hunks
+}
+
+// splitLines 按行拆分文本，每行保留换行符
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// withTrailingNewline 为非空文本补齐末尾换行
+func withTrailingNewline(text string) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
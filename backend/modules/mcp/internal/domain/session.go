@@ -31,6 +31,7 @@ type Session struct {
 	MessageCount   int                       `json:"message_count" gorm:"default:0"`
 	LastActivity   time.Time                 `json:"last_activity"`
 	ExpiresAt      *time.Time                `json:"expires_at"`
+	ParentSessionID *uuid.UUID               `json:"parent_session_id,omitempty" gorm:"type:uuid;index"`
 	
 	// 关联
 	Contexts []*Context `json:"contexts,omitempty" gorm:"foreignKey:SessionID"`
@@ -156,6 +157,41 @@ func (s *Session) removeContext(context *Context) {
 	s.domainEvents = append(s.domainEvents, event)
 }
 
+// Fork 基于给定的上下文分叉出新会话
+// 上下文会被深拷贝到新会话中，修改分叉会话不会影响原会话
+func (s *Session) Fork(title string, contexts []*Context) *Session {
+	if title == "" {
+		title = s.Title
+	}
+	
+	forked := NewSession(s.UserID, s.AgentID, title)
+	forked.Description = s.Description
+	forked.Metadata = copyMetadata(s.Metadata)
+	forked.MaxContextSize = s.MaxContextSize
+	parentID := s.ID
+	forked.ParentSessionID = &parentID
+	if s.ExpiresAt != nil {
+		expiresAt := time.Now().Add(time.Until(*s.ExpiresAt))
+		forked.ExpiresAt = &expiresAt
+	}
+	
+	for _, context := range contexts {
+		cloned := context.CloneForSession(forked.ID)
+		forked.Contexts = append(forked.Contexts, cloned)
+		forked.CurrentSize += cloned.TokenCount
+		forked.MessageCount++
+	}
+	
+	event := domain.NewDomainEvent("session.forked", forked.ID, map[string]interface{}{
+		"session_id":        forked.ID,
+		"parent_session_id": s.ID,
+		"context_count":     len(forked.Contexts),
+	})
+	forked.domainEvents = append(forked.domainEvents, event)
+	
+	return forked
+}
+
 // UpdateActivity 更新活动时间
 func (s *Session) UpdateActivity() {
 	s.LastActivity = time.Now()
@@ -270,6 +306,9 @@ func (e *SessionError) Error() string {
 	return e.message
 }
 
+// ErrSessionNotFound 会话不存在
+var ErrSessionNotFound = NewSessionError("session not found")
+
 // SessionRepository 会话仓储接口
 type SessionRepository interface {
 	domain.Repository[*Session]
@@ -278,4 +317,6 @@ type SessionRepository interface {
 	FindByStatus(ctx context.Context, status SessionStatus) ([]*Session, error)
 	FindExpiredSessions(ctx context.Context) ([]*Session, error)
 	FindIdleSessions(ctx context.Context, idleThreshold time.Duration) ([]*Session, error)
+	// SaveWithContexts 在同一事务中保存会话和新建的上下文，任一写入失败时都不保留
+	SaveWithContexts(ctx context.Context, session *Session, contexts []*Context) error
 }
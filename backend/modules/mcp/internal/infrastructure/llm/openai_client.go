@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/mcp/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+)
+
+// OpenAIClient 调用OpenAI兼容的对话接口（/v1/chat/completions）
+// 兼容OpenAI、vLLM、Ollama等服务，用于上下文摘要压缩
+type OpenAIClient struct {
+	config     service.SummarizationConfig
+	httpClient *http.Client
+}
+
+// chatRequest 对话请求
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+// chatMessage 对话消息
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponse 对话响应
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIClient 创建OpenAI兼容对话客户端
+func NewOpenAIClient(config service.SummarizationConfig) *OpenAIClient {
+	return &OpenAIClient{
+		config:     config,
+		httpClient: &http.Client{},
+	}
+}
+
+// Complete 发送系统提示词和用户消息，要求模型以JSON回复
+func (c *OpenAIClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: c.config.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chat request: %w", err)
+	}
+
+	start := time.Now()
+	content, err := c.doRequest(ctx, body)
+	metrics.ObserveProviderCall("openai", "summarization", time.Since(start), metrics.ProviderStatus(err))
+	return content, err
+}
+
+// doRequest 发起单次HTTP请求，摘要失败时调用方保留原文，因此不重试
+func (c *OpenAIClient) doRequest(ctx context.Context, body []byte) (string, error) {
+	apiURL := strings.TrimRight(c.config.APIBase, "/") + "/v1/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send chat request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read chat response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chat request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var apiResp chatResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal chat response: %w", err)
+	}
+	if len(apiResp.Choices) == 0 {
+		return "", fmt.Errorf("chat response has no choices")
+	}
+	return apiResp.Choices[0].Message.Content, nil
+}
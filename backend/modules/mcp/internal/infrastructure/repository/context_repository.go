@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
@@ -34,7 +36,7 @@ func (r *GormContextRepository) FindByID(ctx context.Context, id uuid.UUID) (*do
 		First(&context, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("context not found")
+			return nil, domain.ErrContextNotFound
 		}
 		return nil, err
 	}
@@ -75,6 +77,115 @@ func (r *GormContextRepository) FindBySessionID(ctx context.Context, sessionID u
 	return contexts, err
 }
 
+// FindBySessionIDAndTags 查找会话中同时包含所有给定标签的上下文
+// 使用jsonb包含查询，命中tags列上的GIN索引
+func (r *GormContextRepository) FindBySessionIDAndTags(ctx context.Context, sessionID uuid.UUID, tags []string) ([]*domain.Context, error) {
+	query := r.db.DB.WithContext(ctx).Where("session_id = ?", sessionID)
+	query, err := whereTags(query, tags)
+	if err != nil {
+		return nil, err
+	}
+	
+	var contexts []*domain.Context
+	err = query.
+		Order("created_at ASC").
+		Find(&contexts).Error
+	return contexts, err
+}
+
+// whereTags 添加标签包含条件，tags为空时不过滤
+func whereTags(query *gorm.DB, tags []string) (*gorm.DB, error) {
+	if len(tags) == 0 {
+		return query, nil
+	}
+	encoded, err := json.Marshal(tags)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where("tags @> ?::jsonb", string(encoded)), nil
+}
+
+// FindBySessionIDWithSearch 根据会话ID和搜索条件分页查找上下文
+// 压缩上下文的内容已被替换为压缩文本且原文不可恢复，因此带关键词搜索时会跳过压缩上下文
+func (r *GormContextRepository) FindBySessionIDWithSearch(ctx context.Context, sessionID uuid.UUID, criteria domain.ContextSearchCriteria, offset, limit int) ([]*domain.Context, int64, error) {
+	query := r.db.DB.WithContext(ctx).
+		Model(&domain.Context{}).
+		Where("session_id = ?", sessionID)
+	
+	if criteria.Keyword != "" {
+		query = query.Where("is_compressed = ? AND content ILIKE ?", false, "%"+escapeLike(criteria.Keyword)+"%")
+	}
+	if criteria.Type != nil {
+		query = query.Where("type = ?", *criteria.Type)
+	}
+	if criteria.MinPriority > 0 {
+		query = query.Where("priority >= ?", criteria.MinPriority)
+	}
+	query, err := whereTags(query, criteria.Tags)
+	if err != nil {
+		return nil, 0, err
+	}
+	if criteria.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *criteria.CreatedAfter)
+	}
+	if criteria.CreatedBefore != nil {
+		query = query.Where("created_at <= ?", *criteria.CreatedBefore)
+	}
+	
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	
+	var contexts []*domain.Context
+	err = query.
+		Order("priority DESC, created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&contexts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	return contexts, total, nil
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// UpdateWithVersion 按版本号条件更新上下文（乐观锁）
+func (r *GormContextRepository) UpdateWithVersion(ctx context.Context, entity *domain.Context, expectedVersion int) error {
+	entity.Version = expectedVersion + 1
+	result := r.db.DB.WithContext(ctx).
+		Model(&domain.Context{}).
+		Where("id = ? AND version = ?", entity.ID, expectedVersion).
+		Select("title", "content", "metadata", "priority", "token_count", "is_compressed",
+			"compression_level", "original_size", "compressed_size", "version", "updated_at").
+		Updates(entity)
+	if result.Error != nil {
+		entity.Version = expectedVersion
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		entity.Version = expectedVersion
+		return domain.ErrContextVersionConflict
+	}
+	return nil
+}
+
+// UpdateAccess 更新上下文访问统计
+func (r *GormContextRepository) UpdateAccess(ctx context.Context, entity *domain.Context) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&domain.Context{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]interface{}{
+			"access_count":  entity.AccessCount,
+			"last_accessed": entity.LastAccessed,
+		}).Error
+}
+
 // FindByType 根据类型查找上下文
 func (r *GormContextRepository) FindByType(ctx context.Context, contextType domain.ContextType) ([]*domain.Context, error) {
 	var contexts []*domain.Context
@@ -26,6 +26,20 @@ func (r *GormSessionRepository) Save(ctx context.Context, entity *domain.Session
 	return r.db.DB.WithContext(ctx).Save(entity).Error
 }
 
+// SaveWithContexts 在同一事务中保存会话和新建的上下文
+// 上下文单独批量写入，会话的Contexts关联不随会话保存
+func (r *GormSessionRepository) SaveWithContexts(ctx context.Context, session *domain.Session, contexts []*domain.Context) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Contexts").Save(session).Error; err != nil {
+			return err
+		}
+		if len(contexts) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(contexts, 100).Error
+	})
+}
+
 // FindByID 根据ID查找会话
 func (r *GormSessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
 	var session domain.Session
@@ -34,7 +48,7 @@ func (r *GormSessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*do
 		First(&session, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("session not found")
+			return nil, domain.ErrSessionNotFound
 		}
 		return nil, err
 	}
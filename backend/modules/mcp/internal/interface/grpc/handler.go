@@ -0,0 +1,278 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/mcp/internal/application/service"
+	"github.com/noah-loop/backend/modules/mcp/internal/domain"
+	mcppb "github.com/noah-loop/backend/modules/mcp/proto/mcp/v1"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MCPGRPCHandler MCP gRPC处理器
+type MCPGRPCHandler struct {
+	mcppb.UnimplementedMCPServiceServer
+	mcpService *service.MCPService
+	logger     infrastructure.Logger
+}
+
+// NewMCPGRPCHandler 创建MCP gRPC处理器
+func NewMCPGRPCHandler(mcpService *service.MCPService, logger infrastructure.Logger) *MCPGRPCHandler {
+	return &MCPGRPCHandler{
+		mcpService: mcpService,
+		logger:     logger,
+	}
+}
+
+// CreateSession 创建会话
+func (h *MCPGRPCHandler) CreateSession(ctx context.Context, req *mcppb.CreateSessionRequest) (*mcppb.CreateSessionResponse, error) {
+	userID, err := parseUUID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	agentID, err := parseUUID("agent_id", req.GetAgentId())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := service.NewCreateSessionCommand()
+	cmd.UserID = userID
+	cmd.AgentID = agentID
+	cmd.Title = req.GetTitle()
+	cmd.Description = req.GetDescription()
+	cmd.MaxContextSize = int(req.GetMaxContextSize())
+	if req.GetMetadata() != nil {
+		cmd.Metadata = req.GetMetadata().AsMap()
+	}
+	if req.GetExpiresIn() != nil {
+		cmd.ExpiresIn = req.GetExpiresIn().AsDuration()
+	}
+
+	if err := cmd.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := h.mcpService.CreateSession(ctx, cmd)
+	if err != nil {
+		h.logger.Error("Failed to create session", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &mcppb.CreateSessionResponse{
+		Session: toProtoSession(result.Data.(*domain.Session)),
+	}, nil
+}
+
+// AddContext 添加上下文
+func (h *MCPGRPCHandler) AddContext(ctx context.Context, req *mcppb.AddContextRequest) (*mcppb.AddContextResponse, error) {
+	sessionID, err := parseUUID("session_id", req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := service.NewAddContextCommand()
+	cmd.SessionID = sessionID
+	cmd.Type = domain.ContextType(req.GetType())
+	cmd.Title = req.GetTitle()
+	cmd.Content = req.GetContent()
+	cmd.CompressionLevel = domain.CompressionLevel(req.GetCompressionLevel())
+	if req.GetPriority() > 0 {
+		cmd.Priority = int(req.GetPriority())
+	}
+	if req.GetMetadata() != nil {
+		cmd.Metadata = req.GetMetadata().AsMap()
+	}
+	cmd.Tags = req.GetTags()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := h.mcpService.AddContext(ctx, cmd)
+	if err != nil {
+		h.logger.Error("Failed to add context", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &mcppb.AddContextResponse{
+		Context: toProtoContext(result.Data.(*domain.Context)),
+	}, nil
+}
+
+// GetContext 获取上下文
+func (h *MCPGRPCHandler) GetContext(ctx context.Context, req *mcppb.GetContextRequest) (*mcppb.GetContextResponse, error) {
+	contextID, err := parseUUID("context_id", req.GetContextId())
+	if err != nil {
+		return nil, err
+	}
+
+	query := service.NewGetContextQuery()
+	query.ContextID = contextID
+	query.Decompress = req.GetDecompress()
+
+	if err := query.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := h.mcpService.GetContext(ctx, query)
+	if err != nil {
+		h.logger.Error("Failed to get context", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &mcppb.GetContextResponse{
+		Context: toProtoContext(result.Data.(*domain.Context)),
+	}, nil
+}
+
+// GetSessionContexts 获取会话上下文
+func (h *MCPGRPCHandler) GetSessionContexts(ctx context.Context, req *mcppb.GetSessionContextsRequest) (*mcppb.GetSessionContextsResponse, error) {
+	sessionID, err := parseUUID("session_id", req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	query := service.NewGetSessionContextsQuery()
+	query.SessionID = sessionID
+	query.MinPriority = int(req.GetMinPriority())
+	if req.GetType() != "" {
+		contextType := domain.ContextType(req.GetType())
+		query.Type = &contextType
+	}
+	query.Tags = req.GetTags()
+	if req.GetPage() > 0 {
+		query.Page = int(req.GetPage())
+	}
+	if req.GetPageSize() > 0 {
+		query.PageSize = int(req.GetPageSize())
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := h.mcpService.GetSessionContexts(ctx, query)
+	if err != nil {
+		h.logger.Error("Failed to get session contexts", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	data := result.Data.(map[string]interface{})
+	contexts := data["contexts"].([]*domain.Context)
+
+	resp := &mcppb.GetSessionContextsResponse{
+		Contexts: make([]*mcppb.Context, 0, len(contexts)),
+		Total:    int32(data["total"].(int)),
+		Page:     int32(query.Page),
+		PageSize: int32(query.PageSize),
+	}
+	for _, c := range contexts {
+		resp.Contexts = append(resp.Contexts, toProtoContext(c))
+	}
+
+	return resp, nil
+}
+
+// CleanupExpiredSessions 清理过期会话
+func (h *MCPGRPCHandler) CleanupExpiredSessions(ctx context.Context, req *mcppb.CleanupExpiredSessionsRequest) (*mcppb.CleanupExpiredSessionsResponse, error) {
+	if err := h.mcpService.CleanupExpiredSessions(ctx); err != nil {
+		h.logger.Error("Failed to cleanup expired sessions", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &mcppb.CleanupExpiredSessionsResponse{Success: true}, nil
+}
+
+// parseUUID 解析UUID参数
+func parseUUID(field, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", field, err)
+	}
+	return id, nil
+}
+
+// toStatusError 将领域错误转换为gRPC状态码
+func toStatusError(err error) error {
+	var sessionErr *domain.SessionError
+	var contextErr *domain.ContextError
+
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrContextNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &sessionErr), errors.As(err, &contextErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toProtoSession 转换会话为protobuf消息
+func toProtoSession(session *domain.Session) *mcppb.Session {
+	pb := &mcppb.Session{
+		Id:             session.ID.String(),
+		UserId:         session.UserID.String(),
+		AgentId:        session.AgentID.String(),
+		Status:         string(session.Status),
+		Title:          session.Title,
+		Description:    session.Description,
+		Metadata:       toProtoStruct(session.Metadata),
+		MaxContextSize: int32(session.MaxContextSize),
+		CurrentSize:    int32(session.CurrentSize),
+		MessageCount:   int32(session.MessageCount),
+		LastActivity:   timestamppb.New(session.LastActivity),
+		CreatedAt:      timestamppb.New(session.CreatedAt),
+		UpdatedAt:      timestamppb.New(session.UpdatedAt),
+	}
+	if session.ExpiresAt != nil {
+		pb.ExpiresAt = timestamppb.New(*session.ExpiresAt)
+	}
+	return pb
+}
+
+// toProtoContext 转换上下文为protobuf消息
+func toProtoContext(c *domain.Context) *mcppb.Context {
+	return &mcppb.Context{
+		Id:               c.ID.String(),
+		SessionId:        c.SessionID.String(),
+		Type:             string(c.Type),
+		Title:            c.Title,
+		Content:          c.Content,
+		Metadata:         toProtoStruct(c.Metadata),
+		TokenCount:       int32(c.TokenCount),
+		Priority:         int32(c.Priority),
+		IsCompressed:     c.IsCompressed,
+		CompressionLevel: int32(c.CompressionLevel),
+		OriginalSize:     int32(c.OriginalSize),
+		CompressedSize:   int32(c.CompressedSize),
+		AccessCount:      int32(c.AccessCount),
+		LastAccessed:     timestamppb.New(c.LastAccessed),
+		CreatedAt:        timestamppb.New(c.CreatedAt),
+		UpdatedAt:        timestamppb.New(c.UpdatedAt),
+		Tags:             c.Tags,
+	}
+}
+
+// toProtoStruct 转换元数据，无法转换时返回nil
+func toProtoStruct(metadata map[string]interface{}) *structpb.Struct {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	s, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil
+	}
+	return s
+}
@@ -1,12 +1,14 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"time"
 	
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/noah-loop/backend/modules/mcp/internal/application/service"
+	"github.com/noah-loop/backend/modules/mcp/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/utils"
 	"go.uber.org/zap"
@@ -137,6 +139,34 @@ func (h *MCPHandler) ExtendSession(c *gin.Context) {
 	utils.SuccessResponse(c, nil, "Session extended successfully")
 }
 
+// ForkSession 分叉会话
+func (h *MCPHandler) ForkSession(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+	
+	cmd := service.NewForkSessionCommand(id)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(cmd); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+			return
+		}
+	}
+	cmd.SessionID = id
+	
+	result, err := h.mcpService.ForkSession(c.Request.Context(), cmd)
+	if err != nil {
+		h.logger.Error("Failed to fork session", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	utils.CreatedResponse(c, result.Data, "Session forked successfully")
+}
+
 // AddContext 添加上下文
 func (h *MCPHandler) AddContext(c *gin.Context) {
 	cmd := service.NewAddContextCommand()
@@ -197,8 +227,30 @@ func (h *MCPHandler) UpdateContext(c *gin.Context) {
 		return
 	}
 	
-	// TODO: 实现更新上下文逻辑
-	utils.SuccessResponse(c, nil, "Context updated successfully")
+	cmd.ContextID = id
+	
+	result, err := h.mcpService.UpdateContext(c.Request.Context(), cmd)
+	if err != nil {
+		var conflictErr *domain.ContextMergeConflictError
+		switch {
+		case errors.As(err, &conflictErr):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":           err.Error(),
+				"current_version": conflictErr.CurrentVersion,
+				"conflicts":       conflictErr.Conflicts,
+			})
+		case errors.Is(err, domain.ErrContextVersionConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrContextNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("Failed to update context", zap.Error(err))
+			utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		}
+		return
+	}
+	
+	utils.SuccessResponse(c, result.Data, "Context updated successfully")
 }
 
 // DeleteContext 删除上下文
@@ -241,6 +293,37 @@ func (h *MCPHandler) GetSessionContexts(c *gin.Context) {
 	utils.SuccessResponse(c, result.Data, "Session contexts retrieved successfully")
 }
 
+// SearchContexts 搜索会话上下文
+func (h *MCPHandler) SearchContexts(c *gin.Context) {
+	sessionIDParam := c.Param("session_id")
+	sessionID, err := uuid.Parse(sessionIDParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("session_id", "invalid UUID format"))
+		return
+	}
+	
+	query := service.NewSearchContextsQuery()
+	if err := c.ShouldBindQuery(query); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+	query.SessionID = sessionID
+	
+	if err := query.Validate(); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+	
+	result, err := h.mcpService.SearchContexts(c.Request.Context(), query)
+	if err != nil {
+		h.logger.Error("Failed to search contexts", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+	
+	utils.SuccessResponse(c, result.Data, "Contexts searched successfully")
+}
+
 // AddContextToSession 向会话添加上下文
 func (h *MCPHandler) AddContextToSession(c *gin.Context) {
 	sessionIDParam := c.Param("session_id")
@@ -10,15 +10,17 @@ import (
 
 // Router 路由结构
 type Router struct {
-	handler *MCPHandler
-	metrics *infrastructure.MetricsRegistry
+	handler   *MCPHandler
+	metrics   *infrastructure.MetricsRegistry
+	bodyLimit middleware.BodyLimitConfig
 }
 
 // NewRouter 创建路由实例
-func NewRouter(handler *MCPHandler, metrics *infrastructure.MetricsRegistry) *Router {
+func NewRouter(handler *MCPHandler, metrics *infrastructure.MetricsRegistry, bodyLimit middleware.BodyLimitConfig) *Router {
 	return &Router{
-		handler: handler,
-		metrics: metrics,
+		handler:   handler,
+		metrics:   metrics,
+		bodyLimit: bodyLimit,
 	}
 }
 
@@ -53,6 +55,7 @@ func (r *Router) setupMiddlewares(router *gin.Engine) {
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS(middleware.DefaultCORSConfig()))
 	router.Use(middleware.MetricsMiddleware(r.metrics))
+	router.Use(middleware.BodyLimit(r.bodyLimit))
 }
 
 // setupHealthRoutes 设置健康检查路由
@@ -90,6 +93,7 @@ func (r *Router) registerMCPRoutes(api *gin.RouterGroup) {
 		sessions.PUT("/:id", r.handler.UpdateSession)
 		sessions.DELETE("/:id", r.handler.DeleteSession)
 		sessions.POST("/:id/extend", r.handler.ExtendSession)
+		sessions.POST("/:id/fork", r.handler.ForkSession)
 	}
 
 	// 上下文管理路由
@@ -105,6 +109,7 @@ func (r *Router) registerMCPRoutes(api *gin.RouterGroup) {
 	sessionContexts := mcp.Group("/sessions/:session_id/contexts")
 	{
 		sessionContexts.GET("", r.handler.GetSessionContexts)
+		sessionContexts.GET("/search", r.handler.SearchContexts)
 		sessionContexts.POST("", r.handler.AddContextToSession)
 	}
 
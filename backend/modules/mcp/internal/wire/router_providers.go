@@ -0,0 +1,11 @@
+package wire
+
+import (
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/middleware"
+)
+
+// NewBodyLimitConfig 读取请求体大小限制，环境变量为MCP_MAX_BODY_SIZE和MCP_MAX_BODY_SIZE_ROUTES
+func NewBodyLimitConfig(logger infrastructure.Logger) middleware.BodyLimitConfig {
+	return middleware.LoadBodyLimitConfig("MCP", logger)
+}
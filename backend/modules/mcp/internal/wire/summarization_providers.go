@@ -0,0 +1,24 @@
+package wire
+
+import (
+	"os"
+	"time"
+
+	"github.com/noah-loop/backend/modules/mcp/internal/application/service"
+)
+
+// NewSummarizationConfig 创建摘要压缩配置，支持通过环境变量覆盖
+func NewSummarizationConfig() service.SummarizationConfig {
+	summarizationConfig := service.DefaultSummarizationConfig()
+
+	summarizationConfig.APIBase = os.Getenv("MCP_SUMMARY_API_BASE")
+	summarizationConfig.APIKey = os.Getenv("MCP_SUMMARY_API_KEY")
+	if model := os.Getenv("MCP_SUMMARY_MODEL"); model != "" {
+		summarizationConfig.Model = model
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("MCP_SUMMARY_TIMEOUT")); err == nil && timeout > 0 {
+		summarizationConfig.Timeout = timeout
+	}
+
+	return summarizationConfig
+}
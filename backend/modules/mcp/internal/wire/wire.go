@@ -6,6 +6,8 @@ import (
 	"github.com/google/wire"
 	"github.com/noah-loop/backend/modules/mcp/internal/application/service"
 	"github.com/noah-loop/backend/modules/mcp/internal/domain"
+	"github.com/noah-loop/backend/modules/mcp/internal/infrastructure/llm"
+	grpcHandler "github.com/noah-loop/backend/modules/mcp/internal/interface/grpc"
 	httpHandler "github.com/noah-loop/backend/modules/mcp/internal/interface/http"
 	"github.com/noah-loop/backend/modules/mcp/internal/infrastructure/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
@@ -13,11 +15,12 @@ import (
 
 // MCPApp MCP应用结构
 type MCPApp struct {
-	MCPService *service.MCPService
-	Handler    *httpHandler.MCPHandler
-	Router     *httpHandler.Router
-	Metrics    *infrastructure.MetricsRegistry
-	Database   *infrastructure.Database
+	MCPService  *service.MCPService
+	Handler     *httpHandler.MCPHandler
+	Router      *httpHandler.Router
+	GRPCHandler *grpcHandler.MCPGRPCHandler
+	Metrics     *infrastructure.MetricsRegistry
+	Database    *infrastructure.Database
 }
 
 // InitializeMCPApp 初始化MCP应用
@@ -35,6 +38,9 @@ func InitializeMCPApp() (*MCPApp, func(), error) {
 		// HTTP处理器和路由
 		MCPHandlerProviderSet,
 		
+		// gRPC处理器
+		MCPGRPCHandlerProviderSet,
+		
 		// 应用结构
 		wire.Struct(new(MCPApp), "*"),
 		
@@ -54,6 +60,7 @@ var MCPRepositoryProviderSet = wire.NewSet(
 // MCPServiceProviderSet 应用服务提供者集合
 var MCPServiceProviderSet = wire.NewSet(
 	NewMCPServiceWithMetrics,
+	NewSummarizationConfig,
 	// 事件总线暂时为nil
 	wire.Value((interface{})(nil)),
 	wire.Bind(new(interface{}), new(interface{})),
@@ -63,6 +70,12 @@ var MCPServiceProviderSet = wire.NewSet(
 var MCPHandlerProviderSet = wire.NewSet(
 	httpHandler.NewMCPHandler,
 	httpHandler.NewRouter,
+	NewBodyLimitConfig,
+)
+
+// MCPGRPCHandlerProviderSet gRPC处理器提供者集合
+var MCPGRPCHandlerProviderSet = wire.NewSet(
+	grpcHandler.NewMCPGRPCHandler,
 )
 
 // NewMCPServiceWithMetrics 创建带有指标收集的MCP服务
@@ -72,9 +85,15 @@ func NewMCPServiceWithMetrics(
 	eventBus interface{},
 	logger infrastructure.Logger,
 	metrics *infrastructure.MetricsRegistry,
+	summarizationConfig service.SummarizationConfig,
 ) *service.MCPService {
 	mcpService := service.NewMCPService(sessionRepo, contextRepo, eventBus, logger, metrics)
 	
+	// 配置了摘要模型时使用保留关键事实的摘要压缩，否则使用默认的简单压缩
+	if summarizationConfig.APIBase != "" {
+		mcpService.SetCompressor(service.NewSummarizingCompressor(llm.NewOpenAIClient(summarizationConfig), summarizationConfig))
+	}
+	
 	// 启动指标收集
 	mcpService.StartMetricsCollection()
 	
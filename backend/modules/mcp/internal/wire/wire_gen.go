@@ -7,9 +7,11 @@ package wire
 
 import (
 	"github.com/noah-loop/backend/modules/mcp/internal/application/service"
+	grpcHandler "github.com/noah-loop/backend/modules/mcp/internal/interface/grpc"
 	httpHandler "github.com/noah-loop/backend/modules/mcp/internal/interface/http"
 	"github.com/noah-loop/backend/modules/mcp/internal/infrastructure/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/logging"
 )
 
 // Injectors from wire.go:
@@ -19,7 +21,7 @@ func InitializeMCPApp() (*MCPApp, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	logger, err := infrastructure.ProvideLogger(config)
+	logger, err := logging.ProvideLogger(config)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -31,15 +33,19 @@ func InitializeMCPApp() (*MCPApp, func(), error) {
 	contextRepository := repository.NewGormContextRepository(database)
 	v := _wireValue
 	metricsRegistry := infrastructure.ProvideMetrics("mcp", logger)
-	mcpService := NewMCPServiceWithMetrics(sessionRepository, contextRepository, v, logger, metricsRegistry)
+	summarizationConfig := NewSummarizationConfig()
+	mcpService := NewMCPServiceWithMetrics(sessionRepository, contextRepository, v, logger, metricsRegistry, summarizationConfig)
 	mcpHandler := httpHandler.NewMCPHandler(mcpService, logger)
-	router := httpHandler.NewRouter(mcpHandler, metricsRegistry)
+	bodyLimitConfig := NewBodyLimitConfig(logger)
+	router := httpHandler.NewRouter(mcpHandler, metricsRegistry, bodyLimitConfig)
+	mcpgrpcHandler := grpcHandler.NewMCPGRPCHandler(mcpService, logger)
 	mcpApp := &MCPApp{
-		MCPService: mcpService,
-		Handler:    mcpHandler,
-		Router:     router,
-		Metrics:    metricsRegistry,
-		Database:   database,
+		MCPService:  mcpService,
+		Handler:     mcpHandler,
+		Router:      router,
+		GRPCHandler: mcpgrpcHandler,
+		Metrics:     metricsRegistry,
+		Database:    database,
 	}
 	return mcpApp, func() {
 	}, nil
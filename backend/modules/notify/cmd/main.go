@@ -4,27 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
 	"github.com/noah-loop/backend/modules/notify/internal/wire"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/readiness"
+	"github.com/noah-loop/backend/shared/pkg/server"
 	"go.uber.org/zap"
 )
 
 const serviceName = "notify-service"
 
+// scheduledTasksLockKey 定时任务的分布式锁键
+const scheduledTasksLockKey = serviceName + "/scheduled-tasks"
+
 func main() {
 	// 使用wire初始化应用
 	app, cleanup, err := wire.InitializeNotifyApp()
@@ -34,7 +34,7 @@ func main() {
 	defer cleanup()
 
 	// 初始化基础设施组件
-	infraApp, infraCleanup, err := initializeInfrastructure()
+	infra, infraCleanup, err := server.NewInfrastructure(serviceName, "../../configs")
 	if err != nil {
 		log.Fatalf("Failed to initialize infrastructure: %v", err)
 	}
@@ -44,245 +44,146 @@ func main() {
 		zap.String("service", serviceName),
 		zap.String("version", app.Config.App.Version))
 
-	// 注册服务到etcd
-	if err := registerService(infraApp.ServiceRegistry, infraApp.Config); err != nil {
-		app.Logger.Fatal("Failed to register service", zap.Error(err))
-	}
-	defer deregisterService(infraApp.ServiceRegistry)
-
-	// 设置HTTP服务器
-	httpServer := setupHTTPServer(app, infraApp)
-
-	// 设置gRPC服务器
-	grpcServer := setupGRPCServer(app, infraApp)
-
-	// 启动服务器
-	go startHTTPServer(httpServer, infraApp.Config, app.Logger)
-	go startGRPCServer(grpcServer, infraApp.Config, app.Logger)
-
-	// 启动健康检查更新
-	go startHealthUpdater(infraApp.ServiceRegistry, app.Logger)
-
-	// 启动定时任务
-	go startScheduledTasks(app, app.Logger)
-
-	// 等待中断信号
-	waitForShutdown(httpServer, grpcServer, infraApp.TracerManager, app.Logger)
-}
-
-// InfrastructureApp 基础设施应用组件
-type InfrastructureApp struct {
-	Config           *infrastructure.Config
-	Logger           infrastructure.Logger
-	TracerManager    *tracing.TracerManager
-	EtcdClient       *etcd.Client
-	ServiceRegistry  *etcd.ServiceRegistry
-	ServiceDiscovery *etcd.ServiceDiscovery
-	ConfigManager    *etcd.ConfigManager
-	SecretManager    *etcd.SecretManager
-}
-
-// initializeInfrastructure 初始化基础设施组件
-func initializeInfrastructure() (*InfrastructureApp, func(), error) {
-	config, err := infrastructure.LoadConfig("../../configs")
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
-	}
-
-	logger := infrastructure.NewZapLogger(config)
-	tracingConfig := tracing.NewTracingConfigFromInfrastructure(config, serviceName)
-	tracerManager, err := tracing.NewTracerManager(tracingConfig, logger)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize tracer: %w", err)
-	}
-
-	etcdConfig := etcd.NewConfigFromInfrastructure(config)
-	etcdClient, err := etcd.NewClient(etcdConfig, logger)
+	// 创建就绪闸门，启动步骤完成且依赖检查通过前不接收业务流量
+	gate, err := setupReadiness(app, infra)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize etcd client: %w", err)
-	}
-
-	serviceRegistry := etcd.NewServiceRegistry(etcdClient, serviceName, logger)
-	serviceDiscovery := etcd.NewServiceDiscovery(etcdClient, logger)
-	configManager := etcd.NewConfigManager(etcdClient, logger)
-	secretManager := etcd.NewSecretManager(etcdClient, logger)
-
-	app := &InfrastructureApp{
-		Config:           config,
-		Logger:           logger,
-		TracerManager:    tracerManager,
-		EtcdClient:       etcdClient,
-		ServiceRegistry:  serviceRegistry,
-		ServiceDiscovery: serviceDiscovery,
-		ConfigManager:    configManager,
-		SecretManager:    secretManager,
-	}
-
-	cleanup := func() {
-		tracerManager.Close(context.Background())
-		etcdClient.Close()
-	}
-
-	return app, cleanup, nil
-}
-
-// registerService 注册服务到etcd
-func registerService(serviceRegistry *etcd.ServiceRegistry, config *infrastructure.Config) error {
-	serviceInfo := etcd.ServiceInfo{
-		Name:    serviceName,
-		Version: config.App.Version,
-		HTTP: etcd.EndpointInfo{
-			Host: "localhost",
-			Port: config.Services.Notify.Port,
-		},
-		GRPC: etcd.EndpointInfo{
-			Host: "localhost",
-			Port: config.Services.Notify.GRPCPort,
-		},
+		app.Logger.Fatal("Failed to setup readiness gate", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 监听etcd中的服务配置，运行时调整日志级别等可变配置
+	infra.WatchConfig(ctx, serviceName)
+
+	rt := server.New(server.Options{
+		Name:           serviceName,
+		Version:        infra.Config.App.Version,
+		Environment:    infra.Config.App.Environment,
+		HTTPPort:       infra.Config.Services.Notify.Port,
+		GRPCPort:       infra.Config.Services.Notify.GRPCPort,
+		Handler:        app.Router.GetEngine(),
+		ReadTimeout:    time.Duration(infra.Config.HTTP.ReadTimeout) * time.Second,
+		WriteTimeout:   time.Duration(infra.Config.HTTP.WriteTimeout) * time.Second,
+		IdleTimeout:    time.Duration(infra.Config.HTTP.IdleTimeout) * time.Second,
+		GRPCOptions:    grpcServerOptions(infra.TracerManager),
+		Gate:           gate,
+		Registry:       infra.ServiceRegistry,
+		Logger:         app.Logger,
+		HealthInterval: 15 * time.Second,
 		Metadata: map[string]string{
-			"environment": config.App.Environment,
+			"environment": infra.Config.App.Environment,
 			"region":      "local",
 		},
-	}
-
-	return serviceRegistry.Register(context.Background(), serviceInfo, 30*time.Second)
-}
-
-// deregisterService 注销服务
-func deregisterService(serviceRegistry *etcd.ServiceRegistry) {
-	if err := serviceRegistry.Deregister(context.Background()); err != nil {
-		log.Printf("Failed to deregister service: %v", err)
-	}
-}
-
-// setupHTTPServer 设置HTTP服务器
-func setupHTTPServer(app *wire.NotifyApp, infraApp *InfrastructureApp) *http.Server {
-	return &http.Server{
-		Addr:         fmt.Sprintf(":%d", infraApp.Config.Services.Notify.Port),
-		Handler:      app.Router.GetEngine(),
-		ReadTimeout:  time.Duration(infraApp.Config.HTTP.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(infraApp.Config.HTTP.WriteTimeout) * time.Second,
-		IdleTimeout:  time.Duration(infraApp.Config.HTTP.IdleTimeout) * time.Second,
+	})
+
+	// 就绪后启动定时任务，多实例部署时只由持有任务锁的实例执行；
+	// 关闭时任务停止后释放任务锁，其他实例无需等待租约过期即可接替
+	taskLock := etcd.NewDistributedLock(infra.EtcdClient, app.Logger)
+	rt.Go("scheduled-tasks", func(ctx context.Context) {
+		runScheduledTasks(ctx, app, taskLock, app.Logger)
+		releaseTaskLock(taskLock, app.Logger)
+	})
+
+	// 数据库迁移，就绪后注册到etcd，收到关闭信号后摘除流量并关闭服务器
+	err = rt.Run(ctx,
+		readiness.Step{Name: "migrate", Run: func(ctx context.Context) error {
+			return migrateDatabase(ctx, app)
+		}},
+	)
+	if err != nil {
+		app.Logger.Fatal("Notify service stopped with error", zap.Error(err))
 	}
 }
 
-// setupGRPCServer 设置gRPC服务器
-func setupGRPCServer(app *wire.NotifyApp, infraApp *InfrastructureApp) *grpc.Server {
+// grpcServerOptions gRPC链路追踪拦截器
+func grpcServerOptions(tracerManager *tracing.TracerManager) []grpc.ServerOption {
 	var opts []grpc.ServerOption
-
-	if infraApp.TracerManager != nil {
-		if unaryInterceptor := infraApp.TracerManager.UnaryServerInterceptor(); unaryInterceptor != nil {
+	if tracerManager != nil {
+		if unaryInterceptor := tracerManager.UnaryServerInterceptor(); unaryInterceptor != nil {
 			opts = append(opts, grpc.UnaryInterceptor(unaryInterceptor))
 		}
-		if streamInterceptor := infraApp.TracerManager.StreamServerInterceptor(); streamInterceptor != nil {
+		if streamInterceptor := tracerManager.StreamServerInterceptor(); streamInterceptor != nil {
 			opts = append(opts, grpc.StreamInterceptor(streamInterceptor))
 		}
 	}
-
-	server := grpc.NewServer(opts...)
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(server, healthServer)
-	healthServer.SetServingStatus(serviceName, grpc_health_v1.HealthCheckResponse_SERVING)
-
-	if infraApp.Config.App.Environment == "development" {
-		reflection.Register(server)
-	}
-
-	return server
-}
-
-// startHTTPServer 启动HTTP服务器
-func startHTTPServer(server *http.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	logger.Info("Starting HTTP server", 
-		zap.String("addr", server.Addr),
-		zap.String("service", serviceName))
-
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatal("Failed to start HTTP server", zap.Error(err))
-	}
-}
-
-// startGRPCServer 启动gRPC服务器
-func startGRPCServer(server *grpc.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	addr := fmt.Sprintf(":%d", config.Services.Notify.GRPCPort)
-	lis, err := net.Listen("tcp", addr)
-	if err != nil {
-		logger.Fatal("Failed to listen on gRPC port", zap.Error(err), zap.String("addr", addr))
-	}
-
-	logger.Info("Starting gRPC server",
-		zap.String("addr", addr),
-		zap.String("service", serviceName))
-
-	if err := server.Serve(lis); err != nil {
-		logger.Fatal("Failed to start gRPC server", zap.Error(err))
-	}
+	return opts
 }
 
-// startHealthUpdater 启动健康状态更新器
-func startHealthUpdater(serviceRegistry *etcd.ServiceRegistry, logger infrastructure.Logger) {
-	ticker := time.NewTicker(15 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		if err := serviceRegistry.UpdateHealth(context.Background(), etcd.HealthStatusHealthy, ""); err != nil {
-			logger.Error("Failed to update health status", zap.Error(err))
-		}
-	}
-}
-
-// startScheduledTasks 启动定时任务
-func startScheduledTasks(app *wire.NotifyApp, logger infrastructure.Logger) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
+// runScheduledTasks 运行定时任务直到ctx结束，每分钟由持有任务锁的实例执行一次
+func runScheduledTasks(ctx context.Context, app *wire.NotifyApp, taskLock *etcd.DistributedLock, logger infrastructure.Logger) {
+	taskLock.RunPeriodic(ctx, scheduledTasksLockKey, 1*time.Minute, func(ctx context.Context) {
 		// 处理定时通知
-		if err := app.NotificationService.ProcessScheduledNotifications(context.Background()); err != nil {
+		if err := app.NotificationService.ProcessScheduledNotifications(ctx); err != nil {
 			logger.Error("Failed to process scheduled notifications", zap.Error(err))
 		}
 
 		// 处理重试通知
-		if err := app.NotificationService.ProcessRetryNotifications(context.Background()); err != nil {
+		if err := app.NotificationService.ProcessRetryNotifications(ctx); err != nil {
 			logger.Error("Failed to process retry notifications", zap.Error(err))
 		}
-	}
-}
 
-// waitForShutdown 等待关闭信号
-func waitForShutdown(httpServer *http.Server, grpcServer *grpc.Server, tracerManager *tracing.TracerManager, logger infrastructure.Logger) {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	
-	<-quit
-	logger.Info("Shutting down Notify service...")
+		// 检查SLA违约通知
+		if err := app.NotificationService.ProcessSLABreaches(ctx); err != nil {
+			logger.Error("Failed to process SLA breaches", zap.Error(err))
+		}
+	})
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// releaseTaskLock 释放本实例持有的任务锁
+func releaseTaskLock(taskLock *etcd.DistributedLock, logger infrastructure.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-
-	if err := httpServer.Shutdown(ctx); err != nil {
-		logger.Error("HTTP server forced to shutdown", zap.Error(err))
+	if err := taskLock.Close(ctx); err != nil {
+		logger.Warn("Failed to release task lock", zap.Error(err))
 	}
+}
 
-	done := make(chan bool, 1)
-	go func() {
-		grpcServer.GracefulStop()
-		done <- true
-	}()
-
-	select {
-	case <-done:
-		logger.Info("gRPC server stopped gracefully")
-	case <-ctx.Done():
-		logger.Warn("gRPC server forced to stop due to timeout")
-		grpcServer.Stop()
+// setupReadiness 创建就绪闸门并添加数据库和etcd检查
+func setupReadiness(app *wire.NotifyApp, infra *server.Infrastructure) (*readiness.Gate, error) {
+	sqlDB, err := app.Database.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
 	}
 
-	if tracerManager != nil {
-		if err := tracerManager.Close(ctx); err != nil {
-			logger.Error("Failed to close tracer", zap.Error(err))
+	gate := readiness.NewGate(readiness.DefaultConfig())
+	gate.AddCheck("database", readiness.PingCheck(sqlDB))
+	gate.AddCheck("etcd", etcd.HealthCheck(infra.EtcdClient))
+	return gate, nil
+}
+
+// migrateDatabase 执行数据库迁移
+func migrateDatabase(ctx context.Context, app *wire.NotifyApp) error {
+	db := app.Database.WithContext(ctx)
+
+	// 引入软删除后幂等键和模板代码只需在未删除的记录中唯一：还没有deleted_at列时删除旧的唯一索引，由AutoMigrate按新条件重建
+	softDeleteIndexes := []struct {
+		model interface{}
+		index string
+	}{
+		{&domain.Notification{}, "idx_notification_idempotency"},
+		{&domain.NotificationTemplate{}, "idx_template_code"},
+	}
+	for _, item := range softDeleteIndexes {
+		migrator := db.Migrator()
+		if migrator.HasTable(item.model) && !migrator.HasColumn(item.model, "DeletedAt") && migrator.HasIndex(item.model, item.index) {
+			if err := migrator.DropIndex(item.model, item.index); err != nil {
+				return err
+			}
 		}
 	}
 
-	logger.Info("Notify service stopped")
+	return db.AutoMigrate(
+		&domain.Notification{},
+		&domain.Recipient{},
+		&domain.NotificationTemplate{},
+		&domain.TemplateVariable{},
+		&domain.TemplateVersion{},
+		&domain.TemplateChannel{},
+		&domain.TemplateLocalization{},
+		&domain.ChannelConfig{},
+		&domain.RecipientGroup{},
+		&domain.RecipientGroupMember{},
+		&domain.EngagementEvent{},
+	)
 }
@@ -10,7 +10,9 @@ require (
 	github.com/google/wire v0.5.0
 	github.com/google/uuid v1.4.0
 	go.uber.org/zap v1.26.0
+	gorm.io/driver/postgres v1.5.4
 	gorm.io/gorm v1.25.5
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.31.0
+	golang.org/x/net v0.18.0
 )
@@ -2,7 +2,16 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
 	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
@@ -12,12 +21,16 @@ import (
 
 // ChannelService 渠道服务
 type ChannelService struct {
-	channelRepo     repository.ChannelRepository
-	emailProvider   EmailProvider
-	smsProvider     SMSProvider
-	pushProvider    PushProvider
-	webhookProvider WebhookProvider
-	logger          infrastructure.Logger
+	channelRepo      repository.ChannelRepository
+	emailProvider    EmailProvider
+	smsProvider      SMSProvider
+	pushProvider     PushProvider
+	webhookProvider  WebhookProvider
+	slackProvider    SlackProvider
+	telegramProvider TelegramProvider
+	rateLimiter      ChannelRateLimiter
+	tracker          *EngagementTracker
+	logger           infrastructure.Logger
 }
 
 // NewChannelService 创建渠道服务
@@ -27,15 +40,23 @@ func NewChannelService(
 	smsProvider SMSProvider,
 	pushProvider PushProvider,
 	webhookProvider WebhookProvider,
+	slackProvider SlackProvider,
+	telegramProvider TelegramProvider,
+	rateLimiter ChannelRateLimiter,
+	tracker *EngagementTracker,
 	logger infrastructure.Logger,
 ) *ChannelService {
 	return &ChannelService{
-		channelRepo:     channelRepo,
-		emailProvider:   emailProvider,
-		smsProvider:     smsProvider,
-		pushProvider:    pushProvider,
-		webhookProvider: webhookProvider,
-		logger:          logger,
+		channelRepo:      channelRepo,
+		emailProvider:    emailProvider,
+		smsProvider:      smsProvider,
+		pushProvider:     pushProvider,
+		webhookProvider:  webhookProvider,
+		slackProvider:    slackProvider,
+		telegramProvider: telegramProvider,
+		rateLimiter:      rateLimiter,
+		tracker:          tracker,
+		logger:           logger,
 	}
 }
 
@@ -200,6 +221,37 @@ func (s *ChannelService) SendToRecipient(ctx context.Context, notification *doma
 		zap.String("recipient_id", recipient.ID),
 		zap.String("channel", string(config.Channel)))
 
+	// 非紧急通知在接收者的免打扰时段内延后发送
+	if until, deferred := domain.QuietHoursDeferral(notification, recipient, config.QuietHoursBypassPriority(), time.Now()); deferred {
+		return &domain.ErrQuietHours{RecipientID: recipient.ID, Until: until}
+	}
+
+	// 模拟发送不联系服务商，不占用渠道的限流配额
+	if notification.DryRun || config.DryRun() {
+		return s.dryRunToRecipient(ctx, notification, recipient, config)
+	}
+
+	if err := s.checkRateLimit(ctx, config); err != nil {
+		return err
+	}
+
+	return s.send(ctx, notification, recipient, config)
+}
+
+// dryRunToRecipient 模拟发送：按正常流程转换格式和构建请求，由模拟发送提供商代替服务商记录请求，
+// 记录的请求保存到接收者的DryRunPayload，供核对渲染结果
+func (s *ChannelService) dryRunToRecipient(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
+	dryRun := newDryRunProvider(s, notification, recipient)
+	err := dryRun.channelService().send(ctx, notification, recipient, config)
+	recipient.DryRunPayload = dryRun.payload()
+	return err
+}
+
+// send 按渠道转换内容格式并交给对应的服务商发送
+func (s *ChannelService) send(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
+	// 按渠道转换内容格式，如短信去除HTML标签、邮件将Markdown渲染为HTML
+	notification = formatForChannel(notification, config)
+
 	switch config.Channel {
 	case domain.ChannelEmail:
 		return s.sendEmail(ctx, notification, recipient, config)
@@ -213,11 +265,63 @@ func (s *ChannelService) SendToRecipient(ctx context.Context, notification *doma
 		return s.sendBark(ctx, notification, recipient, config)
 	case domain.ChannelServerChan:
 		return s.sendServerChan(ctx, notification, recipient, config)
+	case domain.ChannelSlack:
+		return s.sendSlack(ctx, notification, recipient, config)
+	case domain.ChannelTelegram:
+		return s.sendTelegram(ctx, notification, recipient, config)
+	case domain.ChannelDingTalk:
+		return s.sendDingTalk(ctx, notification, recipient, config)
+	case domain.ChannelFeishu:
+		return s.sendFeishu(ctx, notification, recipient, config)
 	default:
 		return domain.NewDomainError("UNSUPPORTED_CHANNEL", "unsupported notification channel")
 	}
 }
 
+// validatePayloadSize 按JSON序列化后的大小检查请求体，maxBytes为0时不限制
+func validatePayloadSize(channel domain.NotificationChannel, payload interface{}, maxBytes int) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return domain.ValidatePayloadSize(channel, len(data), maxBytes)
+}
+
+// checkRateLimit 按渠道和所有者限流，超出rate_limit_per_minute时返回ErrRateLimited
+func (s *ChannelService) checkRateLimit(ctx context.Context, config *domain.ChannelConfig) error {
+	limit := config.RateLimitPerMinute()
+	if s.rateLimiter == nil || limit <= 0 {
+		return nil
+	}
+
+	key := string(config.Channel) + ":" + config.OwnerID
+	allowed, retryAfter, err := s.rateLimiter.Allow(ctx, key, limit)
+	if err != nil {
+		// 限流器不可用时不阻塞发送
+		s.logger.Warn("Channel rate limiter unavailable", zap.String("key", key), zap.Error(err))
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	s.logger.Warn("Channel rate limit exceeded",
+		zap.String("channel", string(config.Channel)),
+		zap.String("owner_id", config.OwnerID),
+		zap.Int("limit_per_minute", limit),
+		zap.Duration("retry_after", retryAfter))
+	return &domain.ErrRateLimited{
+		Channel:    string(config.Channel),
+		OwnerID:    config.OwnerID,
+		Limit:      limit,
+		RetryAfter: retryAfter,
+	}
+}
+
 // sendEmail 发送邮件
 func (s *ChannelService) sendEmail(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
 	if s.emailProvider == nil {
@@ -230,14 +334,38 @@ func (s *ChannelService) sendEmail(ctx context.Context, notification *domain.Not
 		Subject: notification.Title,
 		Content: notification.Content,
 		From:    config.Config["smtp_username"],
+		HTML:    notification.ContentFormat() == domain.TemplateTypeHTML,
 	}
 
 	if fromName, exists := config.GetConfig("from_name"); exists {
 		emailData.FromName = fromName
 	}
 
+	// 使用接收者ID生成Message-ID，退信和送达回执通过它关联到接收者
+	messageID := emailMessageID(recipient.ID, emailData.From)
+	emailData.Headers = map[string]string{"Message-ID": "<" + messageID + ">"}
+
+	// HTML邮件插入打开追踪像素并改写链接，接收者退出追踪时不处理
+	if emailData.HTML && s.tracker.ShouldTrack(recipient, config) {
+		emailData.Content = s.tracker.Instrument(emailData.Content, notification.ID, recipient.ID)
+	}
+
 	// 发送邮件
-	return s.emailProvider.SendEmail(ctx, emailData, config)
+	if err := s.emailProvider.SendEmail(ctx, emailData, config); err != nil {
+		return err
+	}
+
+	recipient.ProviderMessageID = messageID
+	return nil
+}
+
+// emailMessageID 生成邮件Message-ID（不含尖括号），域名取自发件地址
+func emailMessageID(recipientID, from string) string {
+	host := "noah-loop.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		host = from[at+1:]
+	}
+	return recipientID + "@" + host
 }
 
 // sendSMS 发送短信
@@ -246,14 +374,37 @@ func (s *ChannelService) sendSMS(ctx context.Context, notification *domain.Notif
 		return domain.NewDomainError("SMS_PROVIDER_NOT_CONFIGURED", "SMS provider is not configured")
 	}
 
-	// 构建短信数据
-	smsData := &SMSData{
-		Phone:   recipient.GetEffectiveAddress(),
-		Content: notification.Content,
+	// 检查分段数，开启自动拆分时超长内容拆分为多条短信依次发送
+	maxSegments := config.SMSMaxSegments()
+	parts := []string{notification.Content}
+	if config.SMSAutoSplit() {
+		parts = domain.SplitSMS(notification.Content, maxSegments)
+	} else if err := domain.ValidateSMSContent(notification.Content, maxSegments); err != nil {
+		return err
+	}
+
+	for i, content := range parts {
+		// 构建短信数据
+		smsData := &SMSData{
+			Phone:   recipient.GetEffectiveAddress(),
+			Content: content,
+		}
+
+		// 发送短信
+		if err := s.smsProvider.SendSMS(ctx, smsData, config); err != nil {
+			if i > 0 {
+				return fmt.Errorf("failed to send SMS part %d/%d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
+
+		// 拆分发送时使用第一条短信的消息ID关联回执
+		if i == 0 {
+			recipient.ProviderMessageID = smsData.MessageID
+		}
 	}
 
-	// 发送短信
-	return s.smsProvider.SendSMS(ctx, smsData, config)
+	return nil
 }
 
 // sendPush 发送推送通知
@@ -270,6 +421,10 @@ func (s *ChannelService) sendPush(ctx context.Context, notification *domain.Noti
 		Data:        notification.Variables,
 	}
 
+	if err := validatePayloadSize(domain.ChannelPush, pushData, config.MaxPayloadBytes(domain.DefaultPushPayloadSize)); err != nil {
+		return err
+	}
+
 	// 发送推送
 	return s.pushProvider.SendPush(ctx, pushData, config)
 }
@@ -308,6 +463,11 @@ func (s *ChannelService) sendWebhook(ctx context.Context, notification *domain.N
 		webhookData.Headers["X-Webhook-Secret"] = secret
 	}
 
+	// Webhook默认不限制大小，配置了max_payload_bytes时检查请求体
+	if err := validatePayloadSize(domain.ChannelWebhook, webhookData.Data, config.MaxPayloadBytes(0)); err != nil {
+		return err
+	}
+
 	// 发送Webhook
 	return s.webhookProvider.SendWebhook(ctx, webhookData, config)
 }
@@ -329,6 +489,10 @@ func (s *ChannelService) sendBark(ctx context.Context, notification *domain.Noti
 		},
 	}
 
+	if err := validatePayloadSize(domain.ChannelBark, barkData, config.MaxPayloadBytes(domain.DefaultPushPayloadSize)); err != nil {
+		return err
+	}
+
 	// 使用Push provider发送Bark通知
 	return s.pushProvider.SendPush(ctx, barkData, config)
 }
@@ -362,3 +526,333 @@ func (s *ChannelService) sendServerChan(ctx context.Context, notification *domai
 	// 发送Server酱通知
 	return s.webhookProvider.SendWebhook(ctx, webhookData, config)
 }
+
+// sendSlack 发送Slack通知
+func (s *ChannelService) sendSlack(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
+	if s.slackProvider == nil {
+		return domain.NewDomainError("SLACK_PROVIDER_NOT_CONFIGURED", "Slack provider is not configured")
+	}
+
+	slackData := &SlackData{
+		Channel:   config.Config["channel_id"],
+		Text:      fmt.Sprintf("*%s*\n%s", notification.Title, notification.Content),
+		Username:  config.Config["username"],
+		IconEmoji: config.Config["icon_emoji"],
+	}
+
+	// 用户或用户组接收者可以指定Slack频道/用户ID
+	if (recipient.Type == domain.RecipientTypeUser || recipient.Type == domain.RecipientTypeGroup) && recipient.Address != "" {
+		slackData.Channel = recipient.Address
+	}
+
+	// 警报使用Block Kit格式，Text作为通知的回退文本
+	if notification.Type == domain.NotificationTypeAlert {
+		slackData.Blocks = buildSlackAlertBlocks(notification)
+	}
+
+	// 按优先级映射附件颜色
+	slackData.Attachments = []SlackAttachment{
+		{
+			Color: slackPriorityColor(notification.Priority),
+			Fields: []SlackField{
+				{Title: "Priority", Value: string(notification.Priority), Short: true},
+				{Title: "Type", Value: string(notification.Type), Short: true},
+			},
+		},
+	}
+
+	return s.slackProvider.SendSlack(ctx, slackData, config)
+}
+
+// sendTelegram 发送Telegram通知
+func (s *ChannelService) sendTelegram(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
+	if s.telegramProvider == nil {
+		return domain.NewDomainError("TELEGRAM_PROVIDER_NOT_CONFIGURED", "Telegram provider is not configured")
+	}
+
+	parseMode := telegramParseMode(notification.ContentFormat())
+	if mode, exists := config.GetConfig("parse_mode"); exists {
+		parseMode = mode
+	}
+
+	// 重试时从上次发送失败的消息继续，已发送的消息不再重复发送
+	telegramData := &TelegramData{
+		ChatID:    config.Config["chat_id"],
+		Text:      telegramText(notification, parseMode),
+		ParseMode: parseMode,
+		SentParts: recipient.SentParts,
+	}
+
+	// 用户或用户组接收者可以指定chat_id
+	if (recipient.Type == domain.RecipientTypeUser || recipient.Type == domain.RecipientTypeGroup) && recipient.Address != "" {
+		telegramData.ChatID = recipient.Address
+	}
+
+	err := s.telegramProvider.SendTelegram(ctx, telegramData, config)
+	recipient.SentParts = telegramData.SentParts
+	return err
+}
+
+// telegramParseMode 根据模板类型获取Telegram的parse_mode
+func telegramParseMode(format domain.TemplateType) string {
+	switch format {
+	case domain.TemplateTypeMarkdown:
+		return "Markdown"
+	case domain.TemplateTypeHTML:
+		return "HTML"
+	default:
+		return ""
+	}
+}
+
+// telegramText 拼接标题和内容，标题按parse_mode加粗
+func telegramText(notification *domain.Notification, parseMode string) string {
+	if notification.Title == "" {
+		return notification.Content
+	}
+
+	switch parseMode {
+	case "Markdown":
+		return fmt.Sprintf("*%s*\n%s", notification.Title, notification.Content)
+	case "HTML":
+		return fmt.Sprintf("<b>%s</b>\n%s", html.EscapeString(notification.Title), notification.Content)
+	default:
+		return fmt.Sprintf("%s\n%s", notification.Title, notification.Content)
+	}
+}
+
+// sendDingTalk 发送钉钉群机器人通知
+func (s *ChannelService) sendDingTalk(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
+	if s.webhookProvider == nil {
+		return domain.NewDomainError("DINGTALK_PROVIDER_NOT_CONFIGURED", "DingTalk provider is not configured")
+	}
+
+	webhookURL := config.Config["webhook_url"]
+
+	// 配置了secret时钉钉要求加签，未签名的请求会被拒绝
+	if secret, exists := config.GetConfig("secret"); exists {
+		signedURL, err := signDingTalkURL(webhookURL, secret, time.Now())
+		if err != nil {
+			return err
+		}
+		webhookURL = signedURL
+	}
+
+	var atMobiles []string
+	if mobiles, exists := config.GetConfig("at_mobiles"); exists {
+		atMobiles = splitAndTrim(mobiles)
+	}
+	// 手机号类型的接收者会被@
+	if recipient.Type == domain.RecipientTypePhone && recipient.Identifier != "" {
+		atMobiles = append(atMobiles, recipient.Identifier)
+	}
+	atAll := config.Config["at_all"] == "true"
+
+	webhookData := &WebhookData{
+		URL:    webhookURL,
+		Method: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Data: buildDingTalkPayload(notification, atMobiles, atAll),
+	}
+
+	return s.webhookProvider.SendWebhook(ctx, webhookData, config)
+}
+
+// sendFeishu 发送飞书群机器人通知
+func (s *ChannelService) sendFeishu(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, config *domain.ChannelConfig) error {
+	if s.webhookProvider == nil {
+		return domain.NewDomainError("FEISHU_PROVIDER_NOT_CONFIGURED", "Feishu provider is not configured")
+	}
+
+	msgType := config.Config["msg_type"]
+	if msgType == "" {
+		msgType = "text"
+		if notification.ContentFormat() == domain.TemplateTypeMarkdown || notification.Type == domain.NotificationTypeAlert {
+			msgType = "interactive"
+		}
+	}
+
+	payload, err := buildFeishuPayload(notification, msgType)
+	if err != nil {
+		return err
+	}
+
+	// 配置了secret时在消息体中附加签名
+	if secret, exists := config.GetConfig("secret"); exists {
+		timestamp := time.Now().Unix()
+		payload["timestamp"] = strconv.FormatInt(timestamp, 10)
+		payload["sign"] = signFeishu(timestamp, secret)
+	}
+
+	webhookData := &WebhookData{
+		URL:    config.Config["webhook_url"],
+		Method: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Data: payload,
+	}
+
+	return s.webhookProvider.SendWebhook(ctx, webhookData, config)
+}
+
+// buildDingTalkPayload 构建钉钉markdown消息
+// 钉钉要求被@的手机号出现在消息正文中才会生效
+func buildDingTalkPayload(notification *domain.Notification, atMobiles []string, atAll bool) map[string]interface{} {
+	text := fmt.Sprintf("### %s\n\n%s", notification.Title, notification.Content)
+	if len(atMobiles) > 0 {
+		mentions := make([]string, 0, len(atMobiles))
+		for _, mobile := range atMobiles {
+			mentions = append(mentions, "@"+mobile)
+		}
+		text += "\n\n" + strings.Join(mentions, " ")
+	}
+
+	return map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]interface{}{
+			"title": notification.Title,
+			"text":  text,
+		},
+		"at": map[string]interface{}{
+			"atMobiles": atMobiles,
+			"isAtAll":   atAll,
+		},
+	}
+}
+
+// buildFeishuPayload 构建飞书消息，支持text和interactive两种类型
+func buildFeishuPayload(notification *domain.Notification, msgType string) (map[string]interface{}, error) {
+	switch msgType {
+	case "text":
+		return map[string]interface{}{
+			"msg_type": "text",
+			"content": map[string]interface{}{
+				"text": fmt.Sprintf("%s\n%s", notification.Title, notification.Content),
+			},
+		}, nil
+	case "interactive":
+		return map[string]interface{}{
+			"msg_type": "interactive",
+			"card": map[string]interface{}{
+				"header": map[string]interface{}{
+					"title": map[string]interface{}{
+						"tag":     "plain_text",
+						"content": notification.Title,
+					},
+					"template": feishuPriorityTemplate(notification.Priority),
+				},
+				"elements": []map[string]interface{}{
+					{
+						"tag":     "markdown",
+						"content": notification.Content,
+					},
+				},
+			},
+		}, nil
+	default:
+		return nil, domain.NewDomainError("INVALID_CONFIG", "unsupported Feishu msg_type: "+msgType)
+	}
+}
+
+// feishuPriorityTemplate 获取优先级对应的卡片标题颜色
+func feishuPriorityTemplate(priority domain.NotificationPriority) string {
+	switch priority {
+	case domain.NotificationPriorityUrgent:
+		return "red"
+	case domain.NotificationPriorityHigh:
+		return "orange"
+	case domain.NotificationPriorityLow:
+		return "grey"
+	default:
+		return "blue"
+	}
+}
+
+// signDingTalkURL 为钉钉Webhook地址附加timestamp和sign参数
+// 签名为以secret为密钥对"timestamp\nsecret"做HMAC-SHA256后Base64编码，timestamp为毫秒
+func signDingTalkURL(webhookURL, secret string, now time.Time) (string, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", domain.NewDomainError("INVALID_CONFIG", "invalid DingTalk webhook_url: "+err.Error())
+	}
+
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	query := parsed.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", sign)
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String(), nil
+}
+
+// signFeishu 计算飞书机器人签名
+// 以"timestamp\nsecret"为密钥对空消息做HMAC-SHA256后Base64编码，timestamp为秒
+func signFeishu(timestamp int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// splitAndTrim 按逗号拆分并去除空白项
+func splitAndTrim(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// buildSlackAlertBlocks 构建警报消息的Block Kit内容
+func buildSlackAlertBlocks(notification *domain.Notification) []map[string]interface{} {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{
+				"type": "plain_text",
+				"text": ":rotating_light: " + notification.Title,
+			},
+		},
+		{
+			"type": "section",
+			"text": map[string]interface{}{
+				"type": "mrkdwn",
+				"text": notification.Content,
+			},
+		},
+	}
+
+	contextText := fmt.Sprintf("Priority: *%s*", notification.Priority)
+	if notification.Metadata.Source != "" {
+		contextText += fmt.Sprintf(" | Source: %s", notification.Metadata.Source)
+	}
+	blocks = append(blocks, map[string]interface{}{
+		"type": "context",
+		"elements": []map[string]interface{}{
+			{"type": "mrkdwn", "text": contextText},
+		},
+	})
+
+	return blocks
+}
+
+// slackPriorityColor 获取优先级对应的附件颜色
+func slackPriorityColor(priority domain.NotificationPriority) string {
+	switch priority {
+	case domain.NotificationPriorityUrgent:
+		return "#E01E5A"
+	case domain.NotificationPriorityHigh:
+		return "#ECB22E"
+	case domain.NotificationPriorityLow:
+		return "#9E9E9E"
+	default:
+		return "#2EB67D"
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"go.uber.org/zap"
+)
+
+// recordingWebhookProvider 记录发送的Webhook请求
+type recordingWebhookProvider struct {
+	sent []*WebhookData
+}
+
+func (p *recordingWebhookProvider) SendWebhook(ctx context.Context, data *WebhookData, config *domain.ChannelConfig) error {
+	p.sent = append(p.sent, data)
+	return nil
+}
+
+func (p *recordingWebhookProvider) ValidateConfig(config *domain.ChannelConfig) error { return nil }
+
+func (p *recordingWebhookProvider) GetProviderName() string { return "recording" }
+
+func newRobotTestService() (*ChannelService, *recordingWebhookProvider) {
+	webhook := &recordingWebhookProvider{}
+	return NewChannelService(nil, nil, nil, nil, webhook, nil, nil, nil, nil, zap.NewNop()), webhook
+}
+
+func TestSignDingTalkURL(t *testing.T) {
+	signed, err := signDingTalkURL("https://oapi.dingtalk.com/robot/send?access_token=abc", "SEC123", time.UnixMilli(1700000000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _ := url.Parse(signed)
+	query := parsed.Query()
+	if query.Get("access_token") != "abc" {
+		t.Fatalf("access_token lost: %s", signed)
+	}
+	if query.Get("timestamp") != "1700000000000" {
+		t.Fatalf("timestamp = %q, want milliseconds", query.Get("timestamp"))
+	}
+	// 与 printf '1700000000000\nSEC123' | openssl dgst -sha256 -hmac SEC123 -binary | base64 的结果一致
+	if query.Get("sign") != "lkcPI1uoxBY1gUnCnnPH1Kkru0Hqjo7rFpA3haIVhEQ=" {
+		t.Fatalf("sign = %q", query.Get("sign"))
+	}
+}
+
+func TestSignFeishu(t *testing.T) {
+	if sign := signFeishu(1700000000, "SEC123"); sign != "j/tImR0k8vYXRsYw0+GHVQkV1v/J/8obOuMU7PE/KDo=" {
+		t.Fatalf("sign = %q", sign)
+	}
+}
+
+func TestSendDingTalkSignsAndMentionsPhoneRecipient(t *testing.T) {
+	s, webhook := newRobotTestService()
+	notification := &domain.Notification{Title: "Deploy", Content: "done"}
+	recipient := &domain.Recipient{Type: domain.RecipientTypePhone, Identifier: "13800000000"}
+	config := &domain.ChannelConfig{Channel: domain.ChannelDingTalk, Config: map[string]string{
+		"webhook_url": "https://oapi.dingtalk.com/robot/send?access_token=abc",
+		"secret":      "SEC123",
+	}}
+
+	if err := s.sendDingTalk(context.Background(), notification, recipient, config); err != nil {
+		t.Fatal(err)
+	}
+	if len(webhook.sent) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(webhook.sent))
+	}
+
+	parsed, _ := url.Parse(webhook.sent[0].URL)
+	if parsed.Query().Get("sign") == "" || parsed.Query().Get("timestamp") == "" {
+		t.Fatalf("webhook url is not signed: %s", webhook.sent[0].URL)
+	}
+
+	payload := webhook.sent[0].Data
+	if payload["msgtype"] != "markdown" {
+		t.Fatalf("msgtype = %v", payload["msgtype"])
+	}
+	markdown := payload["markdown"].(map[string]interface{})
+	if markdown["text"] != "### Deploy\n\ndone\n\n@13800000000" {
+		t.Fatalf("markdown text = %q", markdown["text"])
+	}
+	at := payload["at"].(map[string]interface{})
+	if mobiles := at["atMobiles"].([]string); len(mobiles) != 1 || mobiles[0] != "13800000000" {
+		t.Fatalf("atMobiles = %v", mobiles)
+	}
+}
+
+func TestSendDingTalkWithoutSecretKeepsURL(t *testing.T) {
+	s, webhook := newRobotTestService()
+	webhookURL := "https://oapi.dingtalk.com/robot/send?access_token=abc"
+	config := &domain.ChannelConfig{Channel: domain.ChannelDingTalk, Config: map[string]string{"webhook_url": webhookURL}}
+
+	if err := s.sendDingTalk(context.Background(), &domain.Notification{Title: "t", Content: "c"}, &domain.Recipient{}, config); err != nil {
+		t.Fatal(err)
+	}
+	if webhook.sent[0].URL != webhookURL {
+		t.Fatalf("url = %s, want %s", webhook.sent[0].URL, webhookURL)
+	}
+}
+
+func TestSendFeishuPayloadTypes(t *testing.T) {
+	s, webhook := newRobotTestService()
+	config := &domain.ChannelConfig{Channel: domain.ChannelFeishu, Config: map[string]string{
+		"webhook_url": "https://open.feishu.cn/open-apis/bot/v2/hook/xxx",
+		"secret":      "SEC123",
+	}}
+
+	text := &domain.Notification{Title: "Hi", Content: "there", Type: domain.NotificationTypeSystem}
+	if err := s.sendFeishu(context.Background(), text, &domain.Recipient{}, config); err != nil {
+		t.Fatal(err)
+	}
+	payload := webhook.sent[0].Data
+	if payload["msg_type"] != "text" || payload["content"].(map[string]interface{})["text"] != "Hi\nthere" {
+		t.Fatalf("unexpected text payload: %v", payload)
+	}
+	if payload["sign"] == nil || payload["timestamp"] == nil {
+		t.Fatalf("payload is not signed: %v", payload)
+	}
+
+	alert := &domain.Notification{Title: "Down", Content: "**db**", Type: domain.NotificationTypeAlert, Priority: domain.NotificationPriorityUrgent}
+	if err := s.sendFeishu(context.Background(), alert, &domain.Recipient{}, config); err != nil {
+		t.Fatal(err)
+	}
+	payload = webhook.sent[1].Data
+	if payload["msg_type"] != "interactive" {
+		t.Fatalf("alert should use interactive card: %v", payload)
+	}
+	header := payload["card"].(map[string]interface{})["header"].(map[string]interface{})
+	if header["template"] != "red" {
+		t.Fatalf("urgent card template = %v", header["template"])
+	}
+
+	config.Config["msg_type"] = "post"
+	if err := s.sendFeishu(context.Background(), text, &domain.Recipient{}, config); err == nil {
+		t.Fatal("expected unsupported msg_type error")
+	}
+}
+
+func TestRobotWebhookConfigRequiresURL(t *testing.T) {
+	for _, channel := range []domain.NotificationChannel{domain.ChannelDingTalk, domain.ChannelFeishu} {
+		config := &domain.ChannelConfig{Channel: channel, IsEnabled: true, Config: map[string]string{"secret": "SEC123"}}
+		if err := config.IsValidForSending(); err == nil {
+			t.Fatalf("%s: expected missing webhook_url error", channel)
+		}
+		config.Config["webhook_url"] = "https://example.com/hook"
+		if err := config.IsValidForSending(); err != nil {
+			t.Fatalf("%s: %v", channel, err)
+		}
+	}
+}
+
+// fixedRateLimiter 按预设结果依次返回的限流器
+type fixedRateLimiter struct {
+	keys    []string
+	results []bool
+}
+
+func (l *fixedRateLimiter) Allow(ctx context.Context, key string, limitPerMinute int) (bool, time.Duration, error) {
+	l.keys = append(l.keys, key)
+	allowed := l.results[0]
+	l.results = l.results[1:]
+	if allowed {
+		return true, 0, nil
+	}
+	return false, 30 * time.Second, nil
+}
+
+func TestSendToRecipientRateLimited(t *testing.T) {
+	webhook := &recordingWebhookProvider{}
+	limiter := &fixedRateLimiter{results: []bool{true, false}}
+	s := NewChannelService(nil, nil, nil, nil, webhook, nil, nil, limiter, nil, zap.NewNop())
+	config := &domain.ChannelConfig{Channel: domain.ChannelWebhook, OwnerID: "u1", Config: map[string]string{
+		"url":                           "https://example.com/hook",
+		domain.ConfigRateLimitPerMinute: "1",
+	}}
+	notification := &domain.Notification{Title: "t", Content: "c"}
+
+	if err := s.SendToRecipient(context.Background(), notification, &domain.Recipient{}, config); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.SendToRecipient(context.Background(), notification, &domain.Recipient{}, config)
+	var rateLimitedErr *domain.ErrRateLimited
+	if !errors.As(err, &rateLimitedErr) || rateLimitedErr.Limit != 1 {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	now := time.Now()
+	if until, deferred := domain.DeferredUntil(err, now); !deferred || !until.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("rate limited send should be deferred by retry_after, got %v %v", until, deferred)
+	}
+	if len(webhook.sent) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(webhook.sent))
+	}
+	if limiter.keys[0] != "webhook:u1" {
+		t.Fatalf("limiter key = %q", limiter.keys[0])
+	}
+
+	// 未配置限额时不调用限流器
+	delete(config.Config, domain.ConfigRateLimitPerMinute)
+	if err := s.SendToRecipient(context.Background(), notification, &domain.Recipient{}, config); err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.keys) != 2 {
+		t.Fatalf("limiter called %d times, want 2", len(limiter.keys))
+	}
+}
+
+func TestSendToRecipientDefersDuringQuietHours(t *testing.T) {
+	s, webhook := newRobotTestService()
+	config := &domain.ChannelConfig{Channel: domain.ChannelWebhook, Config: map[string]string{"url": "https://example.com/hook"}}
+	// 当前时间前后一小时免打扰
+	now := time.Now().UTC()
+	start := now.Add(-time.Hour).Format("15:04")
+	end := now.Add(time.Hour).Format("15:04")
+	recipient := &domain.Recipient{QuietHours: &domain.QuietHours{Start: start, End: end}}
+
+	err := s.SendToRecipient(context.Background(), &domain.Notification{Title: "t", Content: "c", Priority: domain.NotificationPriorityNormal}, recipient, config)
+	var quietHoursErr *domain.ErrQuietHours
+	if !errors.As(err, &quietHoursErr) {
+		t.Fatalf("expected ErrQuietHours, got %v", err)
+	}
+	if len(webhook.sent) != 0 {
+		t.Fatal("notification should not be sent during quiet hours")
+	}
+
+	if err := s.SendToRecipient(context.Background(), &domain.Notification{Title: "t", Content: "c", Priority: domain.NotificationPriorityUrgent}, recipient, config); err != nil {
+		t.Fatal(err)
+	}
+	if len(webhook.sent) != 1 {
+		t.Fatal("urgent notification should bypass quiet hours")
+	}
+}
@@ -8,18 +8,37 @@ import (
 
 // CreateNotificationCommand 创建通知命令
 type CreateNotificationCommand struct {
-	Title       string                        `json:"title" binding:"required"`
-	Content     string                        `json:"content" binding:"required"`
-	Type        domain.NotificationType       `json:"type" binding:"required"`
-	Channel     domain.NotificationChannel    `json:"channel" binding:"required"`
-	Priority    domain.NotificationPriority   `json:"priority,omitempty"`
-	TemplateID  string                        `json:"template_id,omitempty"`
-	Variables   map[string]string             `json:"variables,omitempty"`
-	Recipients  []CreateRecipientCommand      `json:"recipients" binding:"required"`
-	Metadata    *domain.NotificationMetadata  `json:"metadata,omitempty"`
-	ScheduledAt *time.Time                    `json:"scheduled_at,omitempty"`
-	MaxRetries  int                           `json:"max_retries,omitempty"`
-	CreatedBy   string                        `json:"created_by" binding:"required"`
+	Title          string                       `json:"title" binding:"required"`
+	Content        string                       `json:"content" binding:"required"`
+	Type           domain.NotificationType      `json:"type" binding:"required"`
+	Channel        domain.NotificationChannel   `json:"channel" binding:"required"`
+	Priority       domain.NotificationPriority  `json:"priority,omitempty"`
+	TemplateID     string                       `json:"template_id,omitempty"`
+	Variables      map[string]string            `json:"variables,omitempty"`
+	Recipients     []CreateRecipientCommand     `json:"recipients,omitempty"`
+	RecipientGroups []string                    `json:"recipient_groups,omitempty"` // 创建者的接收者组名称，创建时展开为组的当前成员
+	Metadata       *domain.NotificationMetadata `json:"metadata,omitempty"`
+	ScheduledAt    *time.Time                   `json:"scheduled_at,omitempty"`
+	MaxRetries     int                          `json:"max_retries,omitempty"`
+	SLASeconds     int                          `json:"sla_seconds,omitempty"`     // 0表示使用通知类型的默认SLA
+	IdempotencyKey string                       `json:"idempotency_key,omitempty"` // 幂等键，相同创建者重复提交时返回已创建的通知
+	DryRun         bool                         `json:"dry_run,omitempty"`         // 模拟发送，不联系服务商
+	CreatedBy      string                       `json:"created_by" binding:"required"`
+}
+
+// RecordDeliveryReceiptCommand 投递回执命令
+type RecordDeliveryReceiptCommand struct {
+	NotificationID    string                 `json:"-"`
+	RecipientID       string                 `json:"recipient_id" binding:"required"`
+	Status            domain.RecipientStatus `json:"status" binding:"required"` // delivered、bounced、failed
+	ProviderMessageID string                 `json:"provider_message_id,omitempty"`
+}
+
+// RecordEngagementCommand 记录互动事件命令，由追踪接口根据请求构造
+type RecordEngagementCommand struct {
+	Token     string
+	UserAgent string
+	IPAddress string
 }
 
 // CreateRecipientCommand 创建接收者命令
@@ -28,7 +47,45 @@ type CreateRecipientCommand struct {
 	Identifier string                `json:"identifier" binding:"required"`
 	Name       string                `json:"name,omitempty"`
 	Address    string                `json:"address,omitempty"`
+	Locale     string                `json:"locale,omitempty"` // 语言区域，渲染模板时作为locale变量
 	Variables  map[string]string     `json:"variables,omitempty"`
+	QuietHours *domain.QuietHours    `json:"quiet_hours,omitempty"` // 免打扰时段
+	TrackingOptOut bool              `json:"tracking_opt_out,omitempty"` // 不追踪邮件打开和链接点击
+}
+
+// CreateRecipientGroupCommand 创建接收者组命令
+type CreateRecipientGroupCommand struct {
+	Name        string                    `json:"name" binding:"required"`
+	Description string                    `json:"description,omitempty"`
+	Members     []RecipientGroupMemberCmd `json:"members,omitempty"`
+	OwnerID     string                    `json:"owner_id" binding:"required"`
+}
+
+// RecipientGroupMemberCmd 接收者组成员命令
+type RecipientGroupMemberCmd struct {
+	Type       domain.RecipientType         `json:"type" binding:"required"`
+	Identifier string                       `json:"identifier" binding:"required"`
+	Name       string                       `json:"name,omitempty"`
+	Address    string                       `json:"address,omitempty"`
+	Locale     string                       `json:"locale,omitempty"`
+	Channels   []domain.NotificationChannel `json:"channels,omitempty"` // 接收的渠道，为空表示接收所有渠道
+	Variables  map[string]string            `json:"variables,omitempty"`
+	QuietHours *domain.QuietHours           `json:"quiet_hours,omitempty"`
+	TrackingOptOut bool                     `json:"tracking_opt_out,omitempty"`
+}
+
+// AddRecipientGroupMembersCommand 添加接收者组成员命令
+type AddRecipientGroupMembersCommand struct {
+	GroupID string                    `json:"-"`
+	OwnerID string                    `json:"owner_id" binding:"required"`
+	Members []RecipientGroupMemberCmd `json:"members" binding:"required"`
+}
+
+// RemoveRecipientGroupMemberCommand 移除接收者组成员命令
+type RemoveRecipientGroupMemberCommand struct {
+	GroupID  string `json:"-"`
+	MemberID string `json:"-"`
+	OwnerID  string `json:"owner_id"`
 }
 
 // CreateNotificationFromTemplateCommand 从模板创建通知命令
@@ -38,10 +95,13 @@ type CreateNotificationFromTemplateCommand struct {
 	Channel     domain.NotificationChannel    `json:"channel" binding:"required"`
 	Priority    domain.NotificationPriority   `json:"priority,omitempty"`
 	Variables   map[string]string             `json:"variables,omitempty"`
-	Recipients  []CreateRecipientCommand      `json:"recipients" binding:"required"`
+	Recipients  []CreateRecipientCommand      `json:"recipients,omitempty"`
+	RecipientGroups []string                  `json:"recipient_groups,omitempty"` // 创建者的接收者组名称
 	Metadata    *domain.NotificationMetadata  `json:"metadata,omitempty"`
 	ScheduledAt *time.Time                    `json:"scheduled_at,omitempty"`
 	MaxRetries  int                           `json:"max_retries,omitempty"`
+	SLASeconds  int                           `json:"sla_seconds,omitempty"` // 0表示使用通知类型的默认SLA
+	DryRun      bool                          `json:"dry_run,omitempty"`     // 模拟发送，不联系服务商
 	CreatedBy   string                        `json:"created_by" binding:"required"`
 }
 
@@ -63,6 +123,15 @@ type ListNotificationsCommand struct {
 	CreatedBy string `json:"created_by,omitempty"`
 	Offset    int    `json:"offset"`
 	Limit     int    `json:"limit"`
+	Cursor    string `json:"cursor,omitempty"` // 游标分页时上一页返回的next_cursor，为空时从第一页开始
+}
+
+// UpcomingScheduleFilter 计划发送查询条件
+type UpcomingScheduleFilter struct {
+	CreatedBy string `json:"created_by,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Limit     int    `json:"limit"` // 最多返回的发送数，默认500
 }
 
 // GetNotificationCommand 获取通知命令
@@ -148,6 +217,32 @@ type RenderTemplateCommand struct {
 	TemplateID string                     `json:"template_id" binding:"required"`
 	Channel    domain.NotificationChannel `json:"channel" binding:"required"`
 	Variables  map[string]string          `json:"variables,omitempty"`
+	Recipient  *CreateRecipientCommand    `json:"recipient,omitempty"` // 按接收者资料补充name、email等变量
+}
+
+// PreviewTemplateCommand 预览模板命令
+type PreviewTemplateCommand struct {
+	Channel   domain.NotificationChannel `json:"channel" binding:"required"`
+	Variables map[string]string          `json:"variables,omitempty"` // 示例变量，未提供的变量使用默认值
+}
+
+// SetTemplateChannelCommand 设置模板渠道配置命令
+type SetTemplateChannelCommand struct {
+	TemplateID string                     `json:"-"`
+	Channel    domain.NotificationChannel `json:"-"`
+	Subject    string                     `json:"subject,omitempty"`
+	Content    string                     `json:"content,omitempty"`
+	Config     map[string]string          `json:"config,omitempty"`
+	IsEnabled  *bool                      `json:"is_enabled,omitempty"`
+}
+
+// SetTemplateLocalizationCommand 设置模板本地化内容命令
+type SetTemplateLocalizationCommand struct {
+	TemplateID string                     `json:"-"`
+	Locale     string                     `json:"-"`                 // BCP-47语言标签，如pt-BR
+	Channel    domain.NotificationChannel `json:"channel,omitempty"` // 为空时适用于所有渠道
+	Subject    string                     `json:"subject,omitempty"`
+	Content    string                     `json:"content,omitempty"`
 }
 
 // ListTemplatesCommand 列出模板命令
@@ -158,6 +253,7 @@ type ListTemplatesCommand struct {
 	CreatedBy string `json:"created_by,omitempty"`
 	Offset    int    `json:"offset"`
 	Limit     int    `json:"limit"`
+	Cursor    string `json:"cursor,omitempty"` // 游标分页时上一页返回的next_cursor，为空时从第一页开始
 }
 
 // SearchTemplatesCommand 搜索模板命令
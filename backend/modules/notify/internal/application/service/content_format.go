@@ -0,0 +1,626 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/textformat"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ConfigContentFormat 渠道配置中覆盖目标内容格式的键，取值为text、html、markdown，raw表示不转换
+const ConfigContentFormat = "content_format"
+
+// contentFormatRaw content_format取该值时原样发送内容
+const contentFormatRaw = "raw"
+
+// contentFormatSlack Slack的mrkdwn格式，与标准Markdown的加粗、链接语法不同
+const contentFormatSlack domain.TemplateType = "mrkdwn"
+
+// channelFormat 渠道的内容格式要求
+type channelFormat struct {
+	target  domain.TemplateType   // 内容需要转换时的目标格式
+	accepts []domain.TemplateType // 可以原样发送的格式
+}
+
+// channelContentFormats 各渠道的内容格式要求
+// 未列出的渠道原样发送：Webhook交给接收方处理，Telegram按内容格式选择parse_mode
+var channelContentFormats = map[domain.NotificationChannel]channelFormat{
+	domain.ChannelEmail:      {target: domain.TemplateTypeHTML, accepts: []domain.TemplateType{domain.TemplateTypeText, domain.TemplateTypeHTML}},
+	domain.ChannelSMS:        {target: domain.TemplateTypeText, accepts: []domain.TemplateType{domain.TemplateTypeText}},
+	domain.ChannelPush:       {target: domain.TemplateTypeText, accepts: []domain.TemplateType{domain.TemplateTypeText}},
+	domain.ChannelBark:       {target: domain.TemplateTypeText, accepts: []domain.TemplateType{domain.TemplateTypeText}},
+	domain.ChannelSlack:      {target: contentFormatSlack},
+	domain.ChannelDingTalk:   {target: domain.TemplateTypeMarkdown, accepts: []domain.TemplateType{domain.TemplateTypeText, domain.TemplateTypeMarkdown}},
+	domain.ChannelServerChan: {target: domain.TemplateTypeMarkdown, accepts: []domain.TemplateType{domain.TemplateTypeText, domain.TemplateTypeMarkdown}},
+	domain.ChannelFeishu:     {target: domain.TemplateTypeMarkdown, accepts: []domain.TemplateType{domain.TemplateTypeText, domain.TemplateTypeMarkdown}},
+}
+
+// formatForChannel 将通知内容转换为渠道期望的格式
+// 返回内容和格式已替换的通知副本，无需转换时返回原通知；渠道配置的content_format优先于默认要求
+func formatForChannel(notification *domain.Notification, config *domain.ChannelConfig) *domain.Notification {
+	format, exists := channelContentFormats[config.Channel]
+	if override, ok := config.GetConfig(ConfigContentFormat); ok && override != "" {
+		if override == contentFormatRaw {
+			return notification
+		}
+		target := domain.TemplateType(override)
+		format, exists = channelFormat{target: target, accepts: []domain.TemplateType{target}}, true
+	}
+	if !exists {
+		return notification
+	}
+
+	from := notification.ContentFormat()
+	if from == domain.TemplateTypeJSON {
+		return notification
+	}
+	for _, accepted := range format.accepts {
+		if from == accepted {
+			return notification
+		}
+	}
+
+	content, converted := convertContent(notification.Content, from, format.target)
+	if !converted {
+		return notification
+	}
+
+	custom := make(map[string]string, len(notification.Metadata.Custom)+1)
+	for key, value := range notification.Metadata.Custom {
+		custom[key] = value
+	}
+	custom[domain.MetadataTemplateType] = string(format.target)
+
+	formatted := *notification
+	formatted.Content = content
+	formatted.Metadata.Custom = custom
+	return &formatted
+}
+
+// convertContent 在格式之间转换内容，不支持的组合返回false
+func convertContent(content string, from, to domain.TemplateType) (string, bool) {
+	switch to {
+	case domain.TemplateTypeText:
+		switch from {
+		case domain.TemplateTypeHTML:
+			return htmlToText(content), true
+		case domain.TemplateTypeMarkdown:
+			return markdownToText(content), true
+		}
+	case domain.TemplateTypeHTML:
+		switch from {
+		case domain.TemplateTypeMarkdown:
+			return markdownToHTML(content), true
+		case domain.TemplateTypeText:
+			return textToHTML(content), true
+		}
+	case domain.TemplateTypeMarkdown:
+		if from == domain.TemplateTypeHTML {
+			return htmlToMarkdown(content), true
+		}
+	case contentFormatSlack:
+		switch from {
+		case domain.TemplateTypeHTML:
+			return markdownToSlack(htmlToMarkdown(content)), true
+		case domain.TemplateTypeMarkdown:
+			return markdownToSlack(content), true
+		case domain.TemplateTypeText:
+			return escapeSlack(content), true
+		}
+	}
+	return content, false
+}
+
+// htmlRenderer 将HTML渲染为纯文本或Markdown
+type htmlRenderer struct {
+	markdown bool
+}
+
+// htmlToText 移除HTML标签，保留段落、列表和链接地址
+func htmlToText(content string) string {
+	return renderHTML(content, htmlRenderer{})
+}
+
+// htmlToMarkdown 将HTML转换为Markdown，不支持的元素只保留文字
+func htmlToMarkdown(content string) string {
+	return renderHTML(content, htmlRenderer{markdown: true})
+}
+
+// renderHTML 解析并渲染HTML，解析失败时退回原内容
+func renderHTML(content string, r htmlRenderer) string {
+	root, err := nethtml.Parse(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	b := &textformat.Builder{}
+	r.walk(b, root)
+	return strings.TrimSpace(b.String())
+}
+
+// walk 深度优先遍历HTML节点
+func (r htmlRenderer) walk(b *textformat.Builder, n *nethtml.Node) {
+	switch n.Type {
+	case nethtml.TextNode:
+		b.WriteInline(n.Data)
+		return
+	case nethtml.CommentNode, nethtml.DoctypeNode:
+		return
+	case nethtml.ElementNode:
+		if textformat.SkippedHTMLElements[n.DataAtom] {
+			return
+		}
+		if r.element(b, n) {
+			return
+		}
+	}
+
+	breaks := 0
+	if n.Type == nethtml.ElementNode {
+		breaks = textformat.HTMLBlockBreaks[n.DataAtom]
+	}
+	if breaks > 0 {
+		b.LineBreak(breaks)
+	}
+	r.walkChildren(b, n)
+	if breaks > 0 {
+		b.LineBreak(breaks)
+	}
+
+	if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+		b.Separate()
+	}
+}
+
+// element 渲染需要特殊处理的元素，已处理时返回true
+func (r htmlRenderer) element(b *textformat.Builder, n *nethtml.Node) bool {
+	if level, isHeading := textformat.HTMLHeadingLevels[n.DataAtom]; isHeading {
+		b.LineBreak(2)
+		text := r.inline(n)
+		if r.markdown {
+			text = strings.Repeat("#", level) + " " + text
+		}
+		b.Write(text)
+		b.LineBreak(2)
+		return true
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.LineBreak(1)
+	case atom.Hr:
+		b.LineBreak(2)
+		if r.markdown {
+			b.Write("---")
+			b.LineBreak(2)
+		}
+	case atom.Img:
+		alt := textformat.HTMLAttr(n, "alt")
+		if r.markdown && textformat.HTMLAttr(n, "src") != "" {
+			b.WriteInline(fmt.Sprintf("![%s](%s)", alt, textformat.HTMLAttr(n, "src")))
+		} else {
+			b.WriteInline(alt)
+		}
+	case atom.Pre:
+		b.LineBreak(2)
+		text := strings.Trim(htmlText(n), "\n")
+		if r.markdown {
+			text = "```\n" + text + "\n```"
+		}
+		b.Write(text)
+		b.LineBreak(2)
+	case atom.Li:
+		b.LineBreak(1)
+		b.Write(htmlListMarker(n))
+		b.Separate()
+		r.walkChildren(b, n)
+		b.LineBreak(1)
+	case atom.A:
+		r.wrapInline(b, n, func(text string) string {
+			href := textformat.HTMLAttr(n, "href")
+			if !textformat.IsSafeURL(href) || href == text {
+				return text
+			}
+			if r.markdown {
+				return fmt.Sprintf("[%s](%s)", text, href)
+			}
+			return fmt.Sprintf("%s (%s)", text, strings.TrimPrefix(href, "mailto:"))
+		})
+	case atom.Strong, atom.B:
+		r.wrapInline(b, n, r.marker("**"))
+	case atom.Em, atom.I:
+		r.wrapInline(b, n, r.marker("_"))
+	case atom.Del, atom.S, atom.Strike:
+		r.wrapInline(b, n, r.marker("~~"))
+	case atom.Code:
+		r.wrapInline(b, n, r.marker("`"))
+	default:
+		return false
+	}
+	return true
+}
+
+// wrapInline 渲染行内元素的文字后加上格式标记，保留元素两侧的空白
+func (r htmlRenderer) wrapInline(b *textformat.Builder, n *nethtml.Node, wrap func(string) string) {
+	raw := htmlText(n)
+	text := r.inline(n)
+	if text == "" {
+		b.WriteInline(raw)
+		return
+	}
+	if first, _ := utf8.DecodeRuneInString(raw); unicode.IsSpace(first) {
+		b.Separate()
+	}
+	b.Write(wrap(text))
+	if last, _ := utf8.DecodeLastRuneInString(raw); unicode.IsSpace(last) {
+		b.Separate()
+	}
+}
+
+// inline 将元素的子节点渲染为单行文本
+func (r htmlRenderer) inline(n *nethtml.Node) string {
+	b := &textformat.Builder{}
+	r.walkChildren(b, n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// marker 返回在Markdown模式下给文字加上格式标记的函数，纯文本模式下原样返回
+func (r htmlRenderer) marker(mark string) func(string) string {
+	return func(text string) string {
+		if !r.markdown {
+			return text
+		}
+		return mark + text + mark
+	}
+}
+
+// walkChildren 遍历子节点
+func (r htmlRenderer) walkChildren(b *textformat.Builder, n *nethtml.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		r.walk(b, child)
+	}
+}
+
+// htmlListMarker 列表项标记，有序列表按位置编号
+func htmlListMarker(n *nethtml.Node) string {
+	if n.Parent == nil || n.Parent.DataAtom != atom.Ol {
+		return "-"
+	}
+	index := 1
+	for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == nethtml.ElementNode && sibling.DataAtom == atom.Li {
+			index++
+		}
+	}
+	return strconv.Itoa(index) + "."
+}
+
+// htmlText 获取元素内的全部文字
+func htmlText(n *nethtml.Node) string {
+	var sb strings.Builder
+	var collect func(*nethtml.Node)
+	collect = func(node *nethtml.Node) {
+		if node.Type == nethtml.TextNode {
+			sb.WriteString(node.Data)
+			return
+		}
+		if node.Type == nethtml.ElementNode && node.DataAtom == atom.Br {
+			sb.WriteString("\n")
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+	return sb.String()
+}
+
+var (
+	markdownBlockquote  = regexp.MustCompile(`^\s{0,3}>\s?`)
+	markdownBulletItem  = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	markdownOrderedItem = regexp.MustCompile(`^(\s*)(\d{1,9})[.)]\s+`)
+)
+
+// markdownLines 按行遍历Markdown，代码块内的行交给code处理，其他行交给text处理
+func markdownLines(content string, code func(line string, fence bool), text func(line string)) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				code(line, true)
+				continue
+			}
+			code(line, false)
+			continue
+		}
+		if match := textformat.MarkdownFence.FindStringSubmatch(line); match != nil {
+			fence = match[1]
+			code(line, true)
+			continue
+		}
+		text(line)
+	}
+}
+
+// markdownToText 移除Markdown格式标记，链接保留文字和地址，代码块内容原样保留
+func markdownToText(content string) string {
+	lines := make([]string, 0)
+	markdownLines(content, func(line string, fence bool) {
+		if !fence {
+			lines = append(lines, line)
+		}
+	}, func(line string) {
+		switch {
+		case textformat.MarkdownThematicBreak.MatchString(line):
+			line = ""
+		case textformat.MarkdownATXHeading.MatchString(line):
+			line = textformat.MarkdownATXHeading.FindStringSubmatch(line)[2]
+		default:
+			line = markdownBlockquote.ReplaceAllString(line, "")
+			line = markdownBulletItem.ReplaceAllString(line, "$1- ")
+		}
+		lines = append(lines, textformat.StripInlineMarkdown(line, textformat.StripOptions{KeepLinkURLs: true}))
+	})
+	return collapseBlankLines(lines)
+}
+
+// collapseBlankLines 合并连续空行并去掉首尾空行
+func collapseBlankLines(lines []string) string {
+	result := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank = len(result) > 0
+			continue
+		}
+		if blank {
+			result = append(result, "")
+			blank = false
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// markdownToHTML 将Markdown转换为HTML
+// 支持标题、段落、列表、引用、分隔线、代码块和常用行内格式，原文中的HTML会被转义
+func markdownToHTML(content string) string {
+	var sb strings.Builder
+	var paragraph []string
+	list, quote, code := "", false, false
+
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		sb.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+		paragraph = nil
+	}
+	closeList := func() {
+		if list != "" {
+			sb.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	closeQuote := func() {
+		if quote {
+			flushParagraph()
+			sb.WriteString("</blockquote>\n")
+			quote = false
+		}
+	}
+	closeBlocks := func() {
+		flushParagraph()
+		closeList()
+		closeQuote()
+	}
+
+	markdownLines(content, func(line string, fence bool) {
+		if fence {
+			if code {
+				sb.WriteString("</code></pre>\n")
+			} else {
+				closeBlocks()
+				sb.WriteString("<pre><code>")
+			}
+			code = !code
+			return
+		}
+		sb.WriteString(html.EscapeString(line) + "\n")
+	}, func(line string) {
+		if strings.TrimSpace(line) == "" {
+			closeBlocks()
+			return
+		}
+
+		if markdownBlockquote.MatchString(line) {
+			if !quote {
+				flushParagraph()
+				closeList()
+				sb.WriteString("<blockquote>\n")
+				quote = true
+			}
+			line = markdownBlockquote.ReplaceAllString(line, "")
+		} else if quote && len(paragraph) == 0 {
+			closeQuote()
+		}
+
+		switch {
+		case textformat.MarkdownThematicBreak.MatchString(line):
+			closeBlocks()
+			sb.WriteString("<hr>\n")
+		case textformat.MarkdownATXHeading.MatchString(line):
+			flushParagraph()
+			closeList()
+			match := textformat.MarkdownATXHeading.FindStringSubmatch(line)
+			level := len(match[1])
+			sb.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, markdownInlineToHTML(match[2]), level))
+		case markdownBulletItem.MatchString(line) || markdownOrderedItem.MatchString(line):
+			flushParagraph()
+			tag, item := "ul", markdownBulletItem.FindString(line)
+			if item == "" {
+				tag, item = "ol", markdownOrderedItem.FindString(line)
+			}
+			if list != tag {
+				closeList()
+				sb.WriteString("<" + tag + ">\n")
+				list = tag
+			}
+			sb.WriteString("<li>" + markdownInlineToHTML(line[len(item):]) + "</li>\n")
+		default:
+			if len(paragraph) == 0 {
+				closeList()
+			}
+			paragraph = append(paragraph, markdownInlineToHTML(strings.TrimSpace(line)))
+		}
+	})
+	closeBlocks()
+
+	return strings.TrimSpace(sb.String())
+}
+
+// markdownInlineToHTML 转换行内格式，链接只保留安全地址
+func markdownInlineToHTML(text string) string {
+	p := &textformat.Protector{}
+	text = textformat.MarkdownEscape.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect(html.EscapeString(match[1:]))
+	})
+	text = textformat.MarkdownCodeSpan.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect("<code>" + html.EscapeString(match[1:len(match)-1]) + "</code>")
+	})
+	text = textformat.MarkdownImage.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textformat.MarkdownImage.FindStringSubmatch(match)
+		if !textformat.IsSafeURL(parts[2]) {
+			return p.Protect(html.EscapeString(parts[1]))
+		}
+		return p.Protect(fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(parts[2]), html.EscapeString(parts[1])))
+	})
+	text = textformat.MarkdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textformat.MarkdownLink.FindStringSubmatch(match)
+		if !textformat.IsSafeURL(parts[2]) {
+			return parts[1]
+		}
+		return p.Protect(fmt.Sprintf(`<a href="%s">`, html.EscapeString(parts[2]))) + parts[1] + p.Protect("</a>")
+	})
+	text = textformat.MarkdownAutolink.ReplaceAllStringFunc(text, func(match string) string {
+		url := html.EscapeString(match[1 : len(match)-1])
+		return p.Protect(fmt.Sprintf(`<a href="%s">%s</a>`, url, url))
+	})
+
+	text = html.EscapeString(text)
+	text = textformat.MarkdownStrong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = textformat.MarkdownEmphasisStar.ReplaceAllString(text, "<em>$1</em>")
+	text = textformat.MarkdownEmphasisUnder.ReplaceAllString(text, "$1<em>$2</em>$3")
+	text = textformat.MarkdownStrikethrough.ReplaceAllString(text, "<del>$1</del>")
+	return p.Restore(text)
+}
+
+// textToHTML 将纯文本转义为HTML，空行分段，换行转为<br>
+func textToHTML(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	paragraphs := strings.Split(content, "\n\n")
+	result := make([]string, 0, len(paragraphs))
+	for _, paragraph := range paragraphs {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		lines := strings.Split(html.EscapeString(paragraph), "\n")
+		result = append(result, "<p>"+strings.Join(lines, "<br>\n")+"</p>")
+	}
+	return strings.Join(result, "\n")
+}
+
+// slackEscaper 转义Slack消息中的控制字符
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escapeSlack 转义Slack消息中的&、<和>
+func escapeSlack(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+// markdownToSlack 将标准Markdown转换为Slack的mrkdwn
+// 加粗**a**转为*a*，斜体转为_a_，删除线转为~a~，链接转为<url|text>，标题转为加粗行，其余文字转义&、<和>
+func markdownToSlack(content string) string {
+	lines := make([]string, 0)
+	markdownLines(content, func(line string, fence bool) {
+		if fence {
+			lines = append(lines, "```")
+			return
+		}
+		lines = append(lines, escapeSlack(line))
+	}, func(line string) {
+		heading, quote := false, ""
+		if markdownBlockquote.MatchString(line) {
+			quote = "> "
+			line = markdownBlockquote.ReplaceAllString(line, "")
+		}
+		switch {
+		case textformat.MarkdownThematicBreak.MatchString(line):
+			lines = append(lines, "")
+			return
+		case textformat.MarkdownATXHeading.MatchString(line):
+			line = textformat.MarkdownATXHeading.FindStringSubmatch(line)[2]
+			heading = true
+		default:
+			line = markdownBulletItem.ReplaceAllString(line, "$1• ")
+		}
+
+		line = markdownInlineToSlack(line)
+		if heading && line != "" {
+			line = "*" + line + "*"
+		}
+		lines = append(lines, quote+line)
+	})
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// markdownInlineToSlack 转换行内格式为mrkdwn
+func markdownInlineToSlack(text string) string {
+	p := &textformat.Protector{}
+	text = textformat.MarkdownEscape.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect(escapeSlack(match[1:]))
+	})
+	text = textformat.MarkdownCodeSpan.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect("`" + escapeSlack(match[1:len(match)-1]) + "`")
+	})
+	slackLink := func(label, url string) string {
+		if !textformat.IsSafeURL(url) {
+			return p.Protect(escapeSlack(label))
+		}
+		if label == "" || label == url {
+			return p.Protect("<" + url + ">")
+		}
+		return p.Protect("<" + url + "|" + escapeSlack(label) + ">")
+	}
+	text = textformat.MarkdownImage.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textformat.MarkdownImage.FindStringSubmatch(match)
+		return slackLink(parts[1], parts[2])
+	})
+	text = textformat.MarkdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textformat.MarkdownLink.FindStringSubmatch(match)
+		return slackLink(textformat.StripInlineMarkdown(parts[1], textformat.StripOptions{KeepLinkURLs: true}), parts[2])
+	})
+	text = textformat.MarkdownAutolink.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect(match)
+	})
+
+	text = escapeSlack(text)
+	// 加粗先转为占位，避免被斜体规则再次匹配
+	text = textformat.MarkdownStrong.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect("*" + match[2:len(match)-2] + "*")
+	})
+	text = textformat.MarkdownEmphasisStar.ReplaceAllString(text, "_${1}_")
+	text = textformat.MarkdownStrikethrough.ReplaceAllString(text, "~$1~")
+	return p.Restore(p.Restore(text))
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// dryRunProviderName 模拟发送提供商名称
+const dryRunProviderName = "dry-run"
+
+// dryRunRequest 模拟发送时记录的一次服务商请求
+type dryRunRequest struct {
+	Channel domain.NotificationChannel `json:"channel"`
+	Payload interface{}                `json:"payload"`
+}
+
+// dryRunProvider 模拟发送提供商，替换所有渠道的服务商，只记录并输出本应发送的请求
+// 每次发送给接收者时创建，记录的请求写入接收者的DryRunPayload
+type dryRunProvider struct {
+	base           *ChannelService
+	notificationID string
+	recipientID    string
+	requests       []dryRunRequest
+	logger         infrastructure.Logger
+}
+
+// newDryRunProvider 创建发送给指定接收者的模拟发送提供商
+func newDryRunProvider(base *ChannelService, notification *domain.Notification, recipient *domain.Recipient) *dryRunProvider {
+	return &dryRunProvider{
+		base:           base,
+		notificationID: notification.ID,
+		recipientID:    recipient.ID,
+		logger:         base.logger,
+	}
+}
+
+// channelService 返回所有服务商都替换为模拟发送的渠道服务
+func (p *dryRunProvider) channelService() *ChannelService {
+	s := *p.base
+	s.emailProvider = p
+	s.smsProvider = p
+	s.pushProvider = p
+	s.webhookProvider = p
+	s.slackProvider = p
+	s.telegramProvider = p
+	return &s
+}
+
+// record 记录本应发送的请求，日志只输出标识、渠道和请求大小，不输出请求内容
+func (p *dryRunProvider) record(channel domain.NotificationChannel, payload interface{}) {
+	p.requests = append(p.requests, dryRunRequest{Channel: channel, Payload: payload})
+
+	size := 0
+	if data, err := json.Marshal(payload); err == nil {
+		size = len(data)
+	}
+	p.logger.Debug("Dry run: notification not sent to provider",
+		zap.String("notification_id", p.notificationID),
+		zap.String("recipient_id", p.recipientID),
+		zap.String("channel", string(channel)),
+		zap.Int("payload_size", size))
+}
+
+// payload 已记录请求的JSON
+func (p *dryRunProvider) payload() string {
+	data, err := json.Marshal(p.requests)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// SendEmail 记录邮件
+func (p *dryRunProvider) SendEmail(ctx context.Context, data *EmailData, config *domain.ChannelConfig) error {
+	p.record(config.Channel, data)
+	return nil
+}
+
+// SendSMS 记录短信，消息ID以dry-run-开头
+func (p *dryRunProvider) SendSMS(ctx context.Context, data *SMSData, config *domain.ChannelConfig) error {
+	p.record(config.Channel, data)
+	data.MessageID = fmt.Sprintf("%s-%s-%d", dryRunProviderName, p.recipientID, len(p.requests))
+	return nil
+}
+
+// SendPush 记录推送
+func (p *dryRunProvider) SendPush(ctx context.Context, data *PushData, config *domain.ChannelConfig) error {
+	p.record(config.Channel, data)
+	return nil
+}
+
+// SendWebhook 记录Webhook请求
+func (p *dryRunProvider) SendWebhook(ctx context.Context, data *WebhookData, config *domain.ChannelConfig) error {
+	p.record(config.Channel, data)
+	return nil
+}
+
+// SendSlack 记录Slack消息
+func (p *dryRunProvider) SendSlack(ctx context.Context, data *SlackData, config *domain.ChannelConfig) error {
+	p.record(config.Channel, data)
+	return nil
+}
+
+// SendTelegram 记录Telegram消息
+func (p *dryRunProvider) SendTelegram(ctx context.Context, data *TelegramData, config *domain.ChannelConfig) error {
+	p.record(config.Channel, data)
+	return nil
+}
+
+// ValidateConfig 模拟发送不校验服务商配置
+func (p *dryRunProvider) ValidateConfig(config *domain.ChannelConfig) error {
+	return nil
+}
+
+// GetProviderName 获取提供商名称
+func (p *dryRunProvider) GetProviderName() string {
+	return dryRunProviderName
+}
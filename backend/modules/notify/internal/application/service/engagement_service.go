@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// EngagementService 通知互动追踪服务，记录邮件打开和链接点击并统计打开率、点击率
+type EngagementService struct {
+	notificationRepo repository.NotificationRepository
+	recipientRepo    repository.RecipientRepository
+	engagementRepo   repository.EngagementRepository
+	tracker          *EngagementTracker
+	logger           infrastructure.Logger
+}
+
+// NewEngagementService 创建互动追踪服务
+func NewEngagementService(
+	notificationRepo repository.NotificationRepository,
+	recipientRepo repository.RecipientRepository,
+	engagementRepo repository.EngagementRepository,
+	tracker *EngagementTracker,
+	logger infrastructure.Logger,
+) *EngagementService {
+	return &EngagementService{
+		notificationRepo: notificationRepo,
+		recipientRepo:    recipientRepo,
+		engagementRepo:   engagementRepo,
+		tracker:          tracker,
+		logger:           logger,
+	}
+}
+
+// RecordOpen 记录打开事件，令牌无效时返回ErrInvalidTrackingToken
+func (s *EngagementService) RecordOpen(ctx context.Context, cmd *RecordEngagementCommand) error {
+	token, err := s.tracker.ParseToken(cmd.Token)
+	if err != nil {
+		return err
+	}
+	return s.record(ctx, token, domain.EngagementOpen, cmd)
+}
+
+// RecordClick 记录点击事件，返回跳转的原始链接
+// 令牌无效或不是点击令牌时返回ErrInvalidTrackingToken；记录失败不影响跳转
+func (s *EngagementService) RecordClick(ctx context.Context, cmd *RecordEngagementCommand) (string, error) {
+	token, err := s.tracker.ParseToken(cmd.Token)
+	if err != nil {
+		return "", err
+	}
+	if token.URL == "" {
+		return "", domain.NewDomainError(domain.ErrInvalidTrackingToken, "not a click tracking token")
+	}
+
+	if err := s.record(ctx, token, domain.EngagementClick, cmd); err != nil {
+		s.logger.Warn("Failed to record click", zap.String("recipient_id", token.RecipientID), zap.Error(err))
+	}
+	return token.URL, nil
+}
+
+// record 保存互动事件，接收者不存在或已退出追踪时不记录
+func (s *EngagementService) record(ctx context.Context, token *TrackingToken, eventType domain.EngagementType, cmd *RecordEngagementCommand) error {
+	recipient, err := s.recipientRepo.FindByID(ctx, token.RecipientID)
+	if err != nil {
+		return err
+	}
+	if recipient == nil || recipient.NotificationID != token.NotificationID {
+		return domain.ErrRecipientNotFoundf(token.RecipientID)
+	}
+	// 发送后才退出追踪的接收者同样不再记录
+	if recipient.TrackingOptOut {
+		return nil
+	}
+
+	event, err := domain.NewEngagementEvent(token.NotificationID, token.RecipientID, eventType, token.URL)
+	if err != nil {
+		return err
+	}
+	event.UserAgent = cmd.UserAgent
+	event.IPAddress = cmd.IPAddress
+
+	return s.engagementRepo.Save(ctx, event)
+}
+
+// GetNotificationEngagement 获取通知的打开率、点击率和各接收者的互动统计
+func (s *EngagementService) GetNotificationEngagement(ctx context.Context, notificationID string) (*domain.NotificationEngagement, error) {
+	notification, err := s.notificationRepo.FindByID(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if notification == nil {
+		return nil, domain.ErrNotificationNotFoundf(notificationID)
+	}
+
+	recipients, err := s.recipientRepo.FindByNotificationID(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.engagementRepo.CountByNotificationID(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.SummarizeEngagement(notificationID, recipients, counts), nil
+}
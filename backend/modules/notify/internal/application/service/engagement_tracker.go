@@ -0,0 +1,154 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+)
+
+// TrackingConfig 邮件打开和点击追踪配置
+type TrackingConfig struct {
+	BaseURL string // 追踪接口对外的访问地址，如https://notify.example.com，为空时不追踪
+	Secret  string // 追踪链接的签名密钥，为空时不追踪
+}
+
+// 追踪接口路径，与HTTP路由一致
+const (
+	TrackingOpenPath  = "/api/v1/track/open/"
+	TrackingClickPath = "/api/v1/track/click/"
+)
+
+// TrackingToken 追踪链接中签名的内容
+type TrackingToken struct {
+	NotificationID string
+	RecipientID    string
+	URL            string // 点击链接的原始地址，打开追踪为空
+}
+
+// EngagementTracker 邮件互动追踪器：在HTML邮件中插入追踪像素、将链接改写为经过追踪跳转接口的地址
+// 追踪链接使用HMAC签名，防止伪造互动事件和利用跳转接口跳转到任意地址
+type EngagementTracker struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewEngagementTracker 创建邮件互动追踪器
+func NewEngagementTracker(config TrackingConfig) *EngagementTracker {
+	return &EngagementTracker{
+		baseURL: strings.TrimRight(config.BaseURL, "/"),
+		secret:  []byte(config.Secret),
+	}
+}
+
+// Enabled 是否已配置访问地址和签名密钥
+func (t *EngagementTracker) Enabled() bool {
+	return t != nil && t.baseURL != "" && len(t.secret) > 0
+}
+
+// ShouldTrack 是否追踪发送给接收者的消息：渠道开启了追踪且接收者未退出追踪
+func (t *EngagementTracker) ShouldTrack(recipient *domain.Recipient, config *domain.ChannelConfig) bool {
+	return t.Enabled() && config.TrackingEnabled() && !recipient.TrackingOptOut
+}
+
+// hrefPattern 匹配<a>标签的href属性
+var hrefPattern = regexp.MustCompile(`(?i)(<a\b[^>]*?\bhref\s*=\s*)("[^"]*"|'[^']*')`)
+
+// bodyEndPattern 匹配</body>
+var bodyEndPattern = regexp.MustCompile(`(?i)</body\s*>`)
+
+// Instrument 改写HTML中的http(s)链接并插入追踪像素
+func (t *EngagementTracker) Instrument(content, notificationID, recipientID string) string {
+	content = hrefPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := hrefPattern.FindStringSubmatch(match)
+		quoted := parts[2]
+		target := html.UnescapeString(strings.TrimSpace(quoted[1 : len(quoted)-1]))
+		if !isTrackableLink(target) || strings.HasPrefix(target, t.baseURL+TrackingClickPath) {
+			return match
+		}
+		clickURL := t.ClickURL(notificationID, recipientID, target)
+		return parts[1] + `"` + html.EscapeString(clickURL) + `"`
+	})
+
+	pixel := fmt.Sprintf(`<img src="%s" width="1" height="1" alt="" style="display:none" />`,
+		html.EscapeString(t.OpenURL(notificationID, recipientID)))
+
+	// 像素放在</body>之前，没有body标签时追加到末尾
+	locations := bodyEndPattern.FindAllStringIndex(content, -1)
+	if len(locations) == 0 {
+		return content + pixel
+	}
+	at := locations[len(locations)-1][0]
+	return content[:at] + pixel + content[at:]
+}
+
+// isTrackableLink 只改写http和https链接，mailto、tel和页内锚点保持不变
+func isTrackableLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// OpenURL 打开追踪像素地址
+func (t *EngagementTracker) OpenURL(notificationID, recipientID string) string {
+	return t.baseURL + TrackingOpenPath + t.sign(TrackingToken{NotificationID: notificationID, RecipientID: recipientID}) + ".gif"
+}
+
+// ClickURL 点击追踪跳转地址
+func (t *EngagementTracker) ClickURL(notificationID, recipientID, target string) string {
+	return t.baseURL + TrackingClickPath + t.sign(TrackingToken{NotificationID: notificationID, RecipientID: recipientID, URL: target})
+}
+
+// sign 生成签名的追踪令牌：base64url(通知ID\n接收者ID\n链接).base64url(HMAC-SHA256)
+func (t *EngagementTracker) sign(token TrackingToken) string {
+	payload := token.NotificationID + "\n" + token.RecipientID + "\n" + token.URL
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseToken 校验签名并解析追踪令牌，打开追踪的像素地址可以带.gif后缀
+func (t *EngagementTracker) ParseToken(token string) (*TrackingToken, error) {
+	if !t.Enabled() {
+		return nil, domain.NewDomainError(domain.ErrInvalidTrackingToken, "engagement tracking is not configured")
+	}
+
+	token = strings.TrimSuffix(token, ".gif")
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, domain.NewDomainError(domain.ErrInvalidTrackingToken, "malformed tracking token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, domain.NewDomainError(domain.ErrInvalidTrackingToken, "malformed tracking token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, domain.NewDomainError(domain.ErrInvalidTrackingToken, "malformed tracking token")
+	}
+
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, domain.NewDomainError(domain.ErrInvalidTrackingToken, "invalid tracking token signature")
+	}
+
+	fields := strings.SplitN(string(payload), "\n", 3)
+	if len(fields) != 3 || fields[0] == "" || fields[1] == "" {
+		return nil, domain.NewDomainError(domain.ErrInvalidTrackingToken, "malformed tracking token")
+	}
+	return &TrackingToken{
+		NotificationID: fields[0],
+		RecipientID:    fields[1],
+		URL:            fields[2],
+	}, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"go.uber.org/zap"
+)
+
+// newInstance 创建共享同一组仓储的另一个服务实例，模拟多实例部署
+func (e *sendTestEnv) newInstance(config SendConfig) *NotificationService {
+	logger := zap.NewNop()
+	channelService := NewChannelService(e.channels, nil, nil, nil, e.webhook, nil, nil, nil, nil, logger)
+	return NewNotificationService(e.notifications, e.recipients, nil, e.channels, channelService,
+		nil, nil, nil, nil, config, nil, logger)
+}
+
+// waitForAllSent 等待所有通知发送完成，定时通知在后台goroutine中发送
+func (e *sendTestEnv) waitForAllSent(t *testing.T, ids []string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range ids {
+		for e.notifications.get(id).Status != domain.NotificationStatusSent {
+			if time.Now().After(deadline) {
+				t.Fatalf("notification %s status = %s, want sent", id, e.notifications.get(id).Status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestConcurrentProcessorsSendScheduledNotificationsOnce(t *testing.T) {
+	env := newSendTestEnv(t, DefaultSendConfig(), nil)
+	env.webhook.delay = time.Millisecond
+	other := env.newInstance(DefaultSendConfig())
+
+	past := time.Now().Add(-time.Minute)
+	ids := make([]string, 20)
+	for i := range ids {
+		notification := env.addNotification(t, 3)
+		notification.ScheduledAt = &past
+		env.notifications.notifications[notification.ID] = *notification
+		ids[i] = notification.ID
+	}
+
+	// 两个实例的定时任务重叠执行，同时即时发送路径也在竞争
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, service := range []*NotificationService{env.service, other, env.service, other} {
+		wg.Add(1)
+		go func(service *NotificationService) {
+			defer wg.Done()
+			if err := service.ProcessScheduledNotifications(ctx); err != nil {
+				t.Error(err)
+			}
+		}(service)
+	}
+	for _, id := range ids[:5] {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			env.service.SendNotification(ctx, id)
+		}(id)
+	}
+	wg.Wait()
+	env.waitForAllSent(t, ids)
+
+	env.webhook.mu.Lock()
+	defer env.webhook.mu.Unlock()
+	for _, identifier := range []string{"u000", "u001", "u002"} {
+		if sent := env.webhook.sent[identifier]; sent != len(ids) {
+			t.Fatalf("%s sent %d times across %d notifications, want once per notification", identifier, sent, len(ids))
+		}
+	}
+}
+
+func TestProcessScheduledNotificationsSkipsFutureAndClaimed(t *testing.T) {
+	env := newSendTestEnv(t, DefaultSendConfig(), nil)
+	future := time.Now().Add(time.Hour)
+	scheduled := env.addNotification(t, 1)
+	scheduled.ScheduledAt = &future
+	env.notifications.notifications[scheduled.ID] = *scheduled
+
+	claimed := env.addNotification(t, 1)
+	past := time.Now().Add(-time.Minute)
+	claimed.ScheduledAt = &past
+	claimed.Status = domain.NotificationStatusSending
+	env.notifications.notifications[claimed.ID] = *claimed
+
+	if err := env.service.ProcessScheduledNotifications(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if status := env.notifications.get(scheduled.ID).Status; status != domain.NotificationStatusPending {
+		t.Fatalf("future notification status = %s, want pending", status)
+	}
+	if len(env.webhook.sent) != 0 {
+		t.Fatalf("sent %v, want nothing", env.webhook.sent)
+	}
+}
+
+func TestSendNotificationRenewsClaimWhileSending(t *testing.T) {
+	// 发送耗时为认领超时的两倍，期间至少刷新一次
+	config := DefaultSendConfig()
+	config.ClaimTimeout = 30 * time.Millisecond
+	env := newSendTestEnv(t, config, nil)
+	env.webhook.delay = 60 * time.Millisecond
+	notification := env.addNotification(t, 1)
+
+	if err := env.service.SendNotification(context.Background(), notification.ID); err != nil {
+		t.Fatal(err)
+	}
+	renewals := env.notifications.renewalCount()
+	if renewals == 0 {
+		t.Fatal("claim was not renewed while sending")
+	}
+
+	// 发送结束后停止刷新
+	time.Sleep(50 * time.Millisecond)
+	if got := env.notifications.renewalCount(); got != renewals {
+		t.Fatalf("claim renewed %d times after sending finished", got-renewals)
+	}
+	if status := env.notifications.get(notification.ID).Status; status != domain.NotificationStatusSent {
+		t.Fatalf("status = %s, want sent", status)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// withTestMetrics 为服务注入注册到独立注册表的通知指标
+func (e *sendTestEnv) withTestMetrics(t *testing.T) *prometheus.Registry {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	notificationMetrics, err := metrics.NewNotificationMetrics(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.service.metrics = notificationMetrics
+	return registry
+}
+
+// metricValue 返回指定标签的计数器值，直方图返回样本数
+func metricValue(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	samples:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if want, ok := labels[label.GetName()]; ok && want != label.GetValue() {
+					continue samples
+				}
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				return float64(histogram.GetSampleCount())
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestCreateNotificationRecordsMetrics(t *testing.T) {
+	env := newSendTestEnv(t, DefaultSendConfig(), nil)
+	registry := env.withTestMetrics(t)
+
+	// 定时通知创建后不立即发送
+	scheduledAt := time.Now().Add(time.Hour)
+	_, err := env.service.CreateNotification(context.Background(), &CreateNotificationCommand{
+		Title:       "title",
+		Content:     "content",
+		Type:        domain.NotificationTypeSystem,
+		Channel:     domain.ChannelWebhook,
+		CreatedBy:   "owner",
+		ScheduledAt: &scheduledAt,
+		Recipients: []CreateRecipientCommand{
+			{Type: domain.RecipientTypeUser, Identifier: "u001"},
+			{Type: domain.RecipientTypeUser, Identifier: "u002"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := map[string]string{"channel": string(domain.ChannelWebhook), "type": string(domain.NotificationTypeSystem)}
+	if got := metricValue(t, registry, "noah_loop_notifications_created_total", labels); got != 1 {
+		t.Fatalf("created = %v, want 1", got)
+	}
+	if got := metricValue(t, registry, "noah_loop_notification_sends_total", nil); got != 0 {
+		t.Fatalf("sends = %v before the scheduled time", got)
+	}
+}
+
+func TestSendNotificationRecordsMetrics(t *testing.T) {
+	env := newSendTestEnv(t, DefaultSendConfig(), failEveryTenth)
+	registry := env.withTestMetrics(t)
+	notification := env.addNotification(t, 20)
+
+	if err := env.service.SendNotification(context.Background(), notification.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for status, want := range map[string]float64{
+		metrics.NotificationSendSuccess: 18,
+		metrics.NotificationSendFailed:  2,
+	} {
+		labels := map[string]string{"channel": string(domain.ChannelWebhook), "status": status}
+		if got := metricValue(t, registry, "noah_loop_notification_sends_total", labels); got != want {
+			t.Errorf("%s sends = %v, want %v", status, got, want)
+		}
+		if got := metricValue(t, registry, "noah_loop_notification_send_duration_seconds", labels); got != want {
+			t.Errorf("%s send durations = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -2,15 +2,38 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
 	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/pagination"
 	"go.uber.org/zap"
 )
 
+// SendConfig 通知发送配置
+type SendConfig struct {
+	Concurrency int // 单条通知并发发送给接收者的worker数
+	// ClaimTimeout 发送中的通知超过该时间没有刷新认领时间时视为处理实例已退出，由定时任务重新认领；
+	// 发送期间每隔ClaimTimeout的三分之一刷新一次。创建后超过该时间仍未发送的即时通知同样由定时任务认领
+	ClaimTimeout       time.Duration
+	ScheduledBatchSize int // 定时任务每次最多认领的通知数
+}
+
+// DefaultSendConfig 默认通知发送配置
+func DefaultSendConfig() SendConfig {
+	return SendConfig{
+		Concurrency:        10,
+		ClaimTimeout:       10 * time.Minute,
+		ScheduledBatchSize: 100,
+	}
+}
+
 // NotificationService 通知应用服务
 type NotificationService struct {
 	notificationRepo repository.NotificationRepository
@@ -19,6 +42,11 @@ type NotificationService struct {
 	channelRepo      repository.ChannelRepository
 	channelService   *ChannelService
 	templateService  *TemplateService
+	groupService     *RecipientGroupService
+	slaAlerter       SLAAlerter
+	eventPublisher   DeliveryEventPublisher
+	sendConfig       SendConfig
+	metrics          *metrics.NotificationMetrics
 	logger           infrastructure.Logger
 }
 
@@ -30,6 +58,11 @@ func NewNotificationService(
 	channelRepo repository.ChannelRepository,
 	channelService *ChannelService,
 	templateService *TemplateService,
+	groupService *RecipientGroupService,
+	slaAlerter SLAAlerter,
+	eventPublisher DeliveryEventPublisher,
+	sendConfig SendConfig,
+	notificationMetrics *metrics.NotificationMetrics,
 	logger infrastructure.Logger,
 ) *NotificationService {
 	return &NotificationService{
@@ -39,7 +72,12 @@ func NewNotificationService(
 		channelRepo:      channelRepo,
 		channelService:   channelService,
 		templateService:  templateService,
-		logger:          logger,
+		groupService:     groupService,
+		slaAlerter:       slaAlerter,
+		eventPublisher:   eventPublisher,
+		sendConfig:       sendConfig,
+		metrics:          notificationMetrics,
+		logger:           logger,
 	}
 }
 
@@ -50,6 +88,20 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 		zap.String("channel", string(cmd.Channel)),
 		zap.String("created_by", cmd.CreatedBy))
 
+	// 幂等键已存在时直接返回已创建的通知
+	if cmd.IdempotencyKey != "" {
+		existing, err := s.notificationRepo.FindByIdempotencyKey(ctx, cmd.CreatedBy, cmd.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			s.logger.Info("Notification already created for idempotency key",
+				zap.String("id", existing.ID),
+				zap.String("idempotency_key", cmd.IdempotencyKey))
+			return existing, nil
+		}
+	}
+
 	// 创建通知
 	notification, err := domain.NewNotification(
 		cmd.Title,
@@ -61,6 +113,8 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 	if err != nil {
 		return nil, err
 	}
+	notification.IdempotencyKey = cmd.IdempotencyKey
+	notification.DryRun = cmd.DryRun
 
 	// 设置可选属性
 	if cmd.Priority != "" {
@@ -81,9 +135,14 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 	if cmd.MaxRetries > 0 {
 		notification.MaxRetries = cmd.MaxRetries
 	}
+	notification.SetSLA(time.Duration(cmd.SLASeconds) * time.Second)
 
 	// 添加接收者
-	for _, recipientCmd := range cmd.Recipients {
+	recipientCmds, err := s.resolveRecipients(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	for _, recipientCmd := range recipientCmds {
 		recipient, err := domain.NewRecipient(
 			notification.ID,
 			recipientCmd.Type,
@@ -96,9 +155,17 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 		
 		recipient.Name = recipientCmd.Name
 		recipient.Address = recipientCmd.Address
+		recipient.Locale = recipientCmd.Locale
+		recipient.TrackingOptOut = recipientCmd.TrackingOptOut
 		if recipientCmd.Variables != nil {
 			recipient.Variables = recipientCmd.Variables
 		}
+		if recipientCmd.QuietHours != nil {
+			if err := recipientCmd.QuietHours.Validate(); err != nil {
+				return nil, err
+			}
+			recipient.QuietHours = recipientCmd.QuietHours
+		}
 		
 		notification.AddRecipient(*recipient)
 	}
@@ -106,6 +173,10 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 	// 保存通知
 	err = s.notificationRepo.Save(ctx, notification)
 	if err != nil {
+		// 并发请求携带相同幂等键时，由唯一索引保证只创建一条，其余请求返回已创建的通知
+		if cmd.IdempotencyKey != "" && errors.Is(err, repository.ErrDuplicateKey) {
+			return s.findByIdempotencyKey(ctx, cmd.CreatedBy, cmd.IdempotencyKey)
+		}
 		s.logger.Error("Failed to save notification", zap.Error(err))
 		return nil, err
 	}
@@ -117,6 +188,10 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 		return nil, err
 	}
 
+	if s.metrics != nil {
+		s.metrics.ObserveCreated(string(notification.Channel), string(notification.Type))
+	}
+
 	// 如果不是定时通知，立即发送
 	if !notification.IsScheduled() {
 		go s.processNotificationAsync(context.Background(), notification.ID)
@@ -126,27 +201,100 @@ func (s *NotificationService) CreateNotification(ctx context.Context, cmd *Creat
 	return notification, nil
 }
 
+// resolveRecipients 合并显式指定的接收者和接收者组展开后的成员
+// 接收者组按创建者查找，展开为创建时的成员；与显式指定的接收者重复时以显式指定的为准
+func (s *NotificationService) resolveRecipients(ctx context.Context, cmd *CreateNotificationCommand) ([]CreateRecipientCommand, error) {
+	recipients := cmd.Recipients
+	if len(cmd.RecipientGroups) > 0 {
+		members, err := s.groupService.ExpandGroups(ctx, cmd.CreatedBy, cmd.RecipientGroups, cmd.Channel)
+		if err != nil {
+			return nil, err
+		}
+
+		explicit := make(map[string]bool, len(cmd.Recipients))
+		for _, recipient := range cmd.Recipients {
+			explicit[recipientKey(recipient.Type, recipient.Identifier)] = true
+		}
+		recipients = make([]CreateRecipientCommand, 0, len(cmd.Recipients)+len(members))
+		recipients = append(recipients, cmd.Recipients...)
+		for _, member := range members {
+			if !explicit[recipientKey(member.Type, member.Identifier)] {
+				recipients = append(recipients, member)
+			}
+		}
+	}
+
+	if len(recipients) == 0 {
+		return nil, domain.NewDomainError(domain.ErrNoRecipients, "notification must have at least one recipient")
+	}
+	return recipients, nil
+}
+
+// findByIdempotencyKey 插入冲突后查找已创建的通知
+func (s *NotificationService) findByIdempotencyKey(ctx context.Context, createdBy, idempotencyKey string) (*domain.Notification, error) {
+	existing, err := s.notificationRepo.FindByIdempotencyKey(ctx, createdBy, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, domain.NewDomainErrorWithDetails(domain.ErrNotificationAlreadyExists, "Notification already exists",
+			fmt.Sprintf("idempotency_key: %s", idempotencyKey))
+	}
+
+	s.logger.Info("Notification created concurrently for idempotency key",
+		zap.String("id", existing.ID),
+		zap.String("idempotency_key", idempotencyKey))
+	return existing, nil
+}
+
 // CreateNotificationFromTemplate 从模板创建通知
 func (s *NotificationService) CreateNotificationFromTemplate(ctx context.Context, cmd *CreateNotificationFromTemplateCommand) (*domain.Notification, error) {
 	s.logger.Info("Creating notification from template",
 		zap.String("template_id", cmd.TemplateID),
 		zap.String("channel", string(cmd.Channel)))
 
-	// 获取模板
-	template, err := s.templateRepo.FindByID(ctx, cmd.TemplateID)
+	// 获取模板，包括渲染所需的版本、渠道模板和本地化内容
+	template, err := s.templateService.GetTemplate(ctx, cmd.TemplateID)
 	if err != nil {
 		return nil, err
 	}
-	if template == nil {
-		return nil, domain.ErrTemplateNotFoundf(cmd.TemplateID)
-	}
 
-	// 渲染模板
-	subject, content, err := template.RenderTemplate(cmd.Channel, cmd.Variables)
+	// 展开接收者组，按每个接收者的资料渲染模板，确保所有接收者都能渲染成功
+	recipients, err := s.resolveRecipients(ctx, &CreateNotificationCommand{
+		Channel:         cmd.Channel,
+		Recipients:      cmd.Recipients,
+		RecipientGroups: cmd.RecipientGroups,
+		CreatedBy:       cmd.CreatedBy,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to render template: %w", err)
+		return nil, err
+	}
+
+	// 通知保存第一个接收者的渲染结果，发送时再按各接收者重新渲染
+	var subject, content string
+	for i := range recipients {
+		variables := mergeVariables(cmd.Variables, recipients[i].Variables)
+		renderedSubject, renderedContent, err := template.RenderTemplateWithProfile(cmd.Channel, variables, profileRecipient(&recipients[i]).ProfileVariables())
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template for recipient %s: %w", recipients[i].Identifier, err)
+		}
+		if i == 0 {
+			subject, content = renderedSubject, renderedContent
+		}
 	}
 
+	// 记录模板类型，供渠道选择消息格式（如Telegram的parse_mode）
+	metadata := domain.NotificationMetadata{}
+	if cmd.Metadata != nil {
+		metadata = *cmd.Metadata
+	}
+	custom := make(map[string]string, len(metadata.Custom)+1)
+	for k, v := range metadata.Custom {
+		custom[k] = v
+	}
+	custom[domain.MetadataTemplateType] = string(template.Type)
+	metadata.Custom = custom
+
 	// 创建通知命令
 	createCmd := &CreateNotificationCommand{
 		Title:       subject,
@@ -156,10 +304,12 @@ func (s *NotificationService) CreateNotificationFromTemplate(ctx context.Context
 		Priority:    cmd.Priority,
 		TemplateID:  cmd.TemplateID,
 		Variables:   cmd.Variables,
-		Recipients:  cmd.Recipients,
-		Metadata:    cmd.Metadata,
+		Recipients:  recipients,
+		Metadata:    &metadata,
 		ScheduledAt: cmd.ScheduledAt,
 		MaxRetries:  cmd.MaxRetries,
+		SLASeconds:  cmd.SLASeconds,
+		DryRun:      cmd.DryRun,
 		CreatedBy:   cmd.CreatedBy,
 	}
 
@@ -184,16 +334,26 @@ func (s *NotificationService) SendNotification(ctx context.Context, notification
 		return domain.NewDomainError("NOTIFICATION_NOT_READY", "notification is not ready to send")
 	}
 
-	// 更新状态为发送中
-	err = notification.UpdateStatus(domain.NotificationStatusSending)
+	// 认领通知，状态原子地改为发送中，定时任务或其他实例已认领时不再发送
+	claimed, err := s.notificationRepo.ClaimNotification(ctx, notificationID, time.Now())
 	if err != nil {
 		return err
 	}
-	err = s.notificationRepo.Update(ctx, notification)
-	if err != nil {
+	if !claimed {
+		s.logger.Info("Notification already claimed by another worker", zap.String("notification_id", notificationID))
+		return nil
+	}
+	if err := notification.UpdateStatus(domain.NotificationStatusSending); err != nil {
 		return err
 	}
 
+	return s.deliverNotification(ctx, notification)
+}
+
+// deliverNotification 发送已认领（状态为发送中）的通知给待发送的接收者，并汇总通知状态
+func (s *NotificationService) deliverNotification(ctx context.Context, notification *domain.Notification) error {
+	notificationID := notification.ID
+
 	// 获取接收者
 	recipients, err := s.recipientRepo.FindByNotificationID(ctx, notificationID)
 	if err != nil {
@@ -215,45 +375,76 @@ func (s *NotificationService) SendNotification(ctx context.Context, notification
 		return err
 	}
 
-	// 发送给每个接收者
-	var sendErrors []string
-	successCount := 0
-
+	// 并发发送给待发送的接收者
+	pending := make([]*domain.Recipient, 0, len(recipients))
 	for _, recipient := range recipients {
-		if recipient.Status != domain.RecipientStatusPending {
-			continue
+		if recipient.Status == domain.RecipientStatusPending {
+			pending = append(pending, recipient)
 		}
+	}
 
-		// 更新接收者状态为发送中
-		recipient.UpdateStatus(domain.RecipientStatusSending)
-		s.recipientRepo.Update(ctx, recipient)
+	var sendErrors []string
+	successCount := 0
+	retryableCount := 0
+	deferredCount := 0
+	var deferredUntil time.Time
 
-		// 发送通知
-		err = s.channelService.SendToRecipient(ctx, notification, recipient, channelConfig)
-		if err != nil {
-			recipient.SetError(err)
-			sendErrors = append(sendErrors, err.Error())
-			s.logger.Error("Failed to send to recipient",
-				zap.String("recipient_id", recipient.ID),
-				zap.Error(err))
-		} else {
-			recipient.UpdateStatus(domain.RecipientStatusSent)
+	template := s.findRenderTemplate(ctx, notification)
+	stopRenewing := s.renewClaim(ctx, notificationID)
+	results := s.sendToRecipients(ctx, notification, template, pending, channelConfig)
+	stopRenewing()
+	now := time.Now()
+	for _, result := range results {
+		if result.err == nil {
 			successCount++
+			continue
 		}
-
-		// 更新接收者状态
-		s.recipientRepo.Update(ctx, recipient)
+		if until, deferred := domain.DeferredUntil(result.err, now); deferred {
+			// 处于免打扰时段或被渠道限流的接收者延后到最早可以发送的时间
+			deferredCount++
+			if deferredUntil.IsZero() || until.Before(deferredUntil) {
+				deferredUntil = until
+			}
+			continue
+		}
+		if domain.IsRetryableError(result.err) {
+			retryableCount++
+		}
+		sendErrors = append(sendErrors, result.err.Error())
 	}
 
+	// 按所有接收者的状态汇总通知状态，之前已发送的接收者同样计入
+	summary := domain.SummarizeRecipients(recipients)
+
 	// 更新通知状态
-	if successCount == 0 {
+	if retryableCount > 0 {
+		// 存在可重试的接收者时标记为失败，ProcessRetryNotifications只会重新发送失败的接收者
+		notification.SetError(fmt.Errorf("retryable failures for %d recipients: %v", retryableCount, sendErrors))
+	} else if deferredCount > 0 {
+		if err := notification.Defer(deferredUntil); err != nil {
+			return err
+		}
+		s.logger.Info("Notification deferred for quiet hours or rate limit",
+			zap.String("notification_id", notificationID),
+			zap.Int("deferred_count", deferredCount),
+			zap.Time("scheduled_at", deferredUntil))
+		if len(sendErrors) > 0 {
+			notification.ErrorMessage = fmt.Sprintf("failed to send to %d recipients: %v", len(sendErrors), sendErrors)
+		}
+	} else if summary.Sent == 0 {
 		notification.SetError(fmt.Errorf("failed to send to all recipients: %v", sendErrors))
-	} else if successCount == len(recipients) {
+	} else if summary.Failed == 0 {
 		notification.UpdateStatus(domain.NotificationStatusSent)
+		notification.ErrorMessage = ""
 	} else {
-		// 部分成功，状态保持为已发送但记录错误
+		// 部分成功，状态保持为已发送但记录错误，可通过RetryNotification只重试失败的接收者
 		notification.UpdateStatus(domain.NotificationStatusSent)
-		notification.ErrorMessage = fmt.Sprintf("partial success: %d/%d sent", successCount, len(recipients))
+		notification.ErrorMessage = fmt.Sprintf("partial success: %d/%d sent", summary.Sent, summary.Total)
+	}
+
+	// 发送失败时按渠道的退避策略安排下次自动重试
+	if notification.Status == domain.NotificationStatusFailed {
+		notification.ScheduleRetry(channelConfig.RetryBackoff(), time.Now())
 	}
 
 	err = s.notificationRepo.Update(ctx, notification)
@@ -264,11 +455,200 @@ func (s *NotificationService) SendNotification(ctx context.Context, notification
 	s.logger.Info("Notification sending completed",
 		zap.String("notification_id", notificationID),
 		zap.Int("success_count", successCount),
-		zap.Int("total_count", len(recipients)))
+		zap.Int("sent_count", summary.Sent),
+		zap.Int("failed_count", summary.Failed),
+		zap.Int("total_count", summary.Total))
 
 	return nil
 }
 
+// renewClaim 发送期间每隔ClaimTimeout的三分之一刷新认领时间，避免发送耗时超过ClaimTimeout时被其他实例重新认领
+// 返回的函数停止刷新，并等待正在进行的刷新结束
+func (s *NotificationService) renewClaim(ctx context.Context, notificationID string) func() {
+	interval := s.sendConfig.ClaimTimeout / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := s.notificationRepo.RenewClaim(ctx, notificationID, now); err != nil {
+					s.logger.Warn("Failed to renew notification claim",
+						zap.String("notification_id", notificationID),
+						zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// recipientSendResult 单个接收者的发送结果
+type recipientSendResult struct {
+	recipient *domain.Recipient
+	err       error
+}
+
+// sendToRecipients 使用有界worker池并发发送给接收者，返回结果的顺序与接收者顺序一致
+func (s *NotificationService) sendToRecipients(ctx context.Context, notification *domain.Notification, template *domain.NotificationTemplate, recipients []*domain.Recipient, channelConfig *domain.ChannelConfig) []recipientSendResult {
+	results := make([]recipientSendResult, len(recipients))
+	if len(recipients) == 0 {
+		return results
+	}
+
+	concurrency := s.sendConfig.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(recipients) {
+		concurrency = len(recipients)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个worker只写入自己负责的下标，无需加锁
+			for i := range jobs {
+				results[i] = s.sendToRecipient(ctx, s.personalize(notification, template, recipients[i]), recipients[i], channelConfig)
+			}
+		}()
+	}
+
+	for i := range recipients {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// findRenderTemplate 获取从模板创建的通知所使用的模板，用于按接收者重新渲染
+// 模板已删除或查询失败时返回nil，发送创建时保存的内容
+func (s *NotificationService) findRenderTemplate(ctx context.Context, notification *domain.Notification) *domain.NotificationTemplate {
+	if notification.TemplateID == "" {
+		return nil
+	}
+
+	template, err := s.templateService.GetTemplate(ctx, notification.TemplateID)
+	if err != nil {
+		s.logger.Warn("Failed to load template for personalization, sending stored content",
+			zap.String("notification_id", notification.ID),
+			zap.String("template_id", notification.TemplateID),
+			zap.Error(err))
+		return nil
+	}
+	return template
+}
+
+// personalize 按接收者资料和个性化变量重新渲染通知的标题和内容
+// 没有模板或渲染失败时返回原通知
+func (s *NotificationService) personalize(notification *domain.Notification, template *domain.NotificationTemplate, recipient *domain.Recipient) *domain.Notification {
+	if template == nil {
+		return notification
+	}
+
+	variables := mergeVariables(notification.Variables, recipient.Variables)
+	subject, content, err := template.RenderTemplateWithProfile(notification.Channel, variables, recipient.ProfileVariables())
+	if err != nil {
+		s.logger.Warn("Failed to render template for recipient, sending stored content",
+			zap.String("notification_id", notification.ID),
+			zap.String("recipient_id", recipient.ID),
+			zap.Error(err))
+		return notification
+	}
+
+	personalized := *notification
+	personalized.Title = subject
+	personalized.Content = content
+	return &personalized
+}
+
+// profileRecipient 根据接收者命令构造接收者资料，用于生成模板变量
+func profileRecipient(cmd *CreateRecipientCommand) *domain.Recipient {
+	return &domain.Recipient{
+		Type:       cmd.Type,
+		Identifier: cmd.Identifier,
+		Name:       cmd.Name,
+		Address:    cmd.Address,
+		Locale:     cmd.Locale,
+	}
+}
+
+// mergeVariables 合并变量，overrides中的值优先
+func mergeVariables(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
+// sendToRecipient 发送给单个接收者并更新接收者状态
+func (s *NotificationService) sendToRecipient(ctx context.Context, notification *domain.Notification, recipient *domain.Recipient, channelConfig *domain.ChannelConfig) recipientSendResult {
+	// 更新接收者状态为发送中
+	recipient.UpdateStatus(domain.RecipientStatusSending)
+	s.recipientRepo.Update(ctx, recipient)
+
+	// 发送通知
+	start := time.Now()
+	err := s.channelService.SendToRecipient(ctx, notification, recipient, channelConfig)
+	duration := time.Since(start)
+	status := metrics.NotificationSendSuccess
+	_, deferred := domain.DeferredUntil(err, start)
+	switch {
+	case err == nil:
+		recipient.UpdateStatus(domain.RecipientStatusSent)
+	case deferred:
+		// 免打扰时段内或被渠道限流时保持待发送状态，不计入重试次数
+		recipient.UpdateStatus(domain.RecipientStatusPending)
+		status = metrics.NotificationSendDeferred
+	default:
+		// 服务商限流等临时错误保持待发送状态，由重试任务再次投递
+		if domain.IsRetryableError(err) {
+			recipient.MarkForRetry(err)
+			status = metrics.NotificationSendRetryable
+		} else {
+			recipient.SetError(err)
+			status = metrics.NotificationSendFailed
+		}
+		s.logger.Error("Failed to send to recipient",
+			zap.String("recipient_id", recipient.ID),
+			zap.Bool("retryable", domain.IsRetryableError(err)),
+			zap.Error(err))
+	}
+
+	// 更新接收者状态
+	s.recipientRepo.Update(ctx, recipient)
+
+	if s.metrics != nil {
+		s.metrics.ObserveSend(string(notification.Channel), status, duration)
+	}
+
+	return recipientSendResult{recipient: recipient, err: err}
+}
+
 // GetNotification 获取通知
 func (s *NotificationService) GetNotification(ctx context.Context, notificationID string) (*domain.Notification, error) {
 	notification, err := s.notificationRepo.FindByID(ctx, notificationID)
@@ -307,6 +687,106 @@ func (s *NotificationService) ListNotifications(ctx context.Context, cmd *ListNo
 	return notifications, total, err
 }
 
+// 游标分页的每页数量限制
+const (
+	defaultCursorPageLimit = 20
+	maxCursorPageLimit     = 100
+)
+
+// ListNotificationsAfterCursor 游标分页列出通知，按创建时间从新到旧排列
+// 返回下一页的游标，没有更多通知时为空字符串；深翻页不会变慢，翻页期间新创建的通知不会导致重复或遗漏
+func (s *NotificationService) ListNotificationsAfterCursor(ctx context.Context, cmd *ListNotificationsCommand) ([]*domain.Notification, string, error) {
+	cursor, err := pagination.DecodeCursor(cmd.Cursor)
+	if err != nil {
+		return nil, "", domain.NewDomainErrorWithDetails(domain.ErrInvalidCursor, "Invalid pagination cursor", cmd.Cursor)
+	}
+
+	limit := cmd.Limit
+	if limit <= 0 {
+		limit = defaultCursorPageLimit
+	}
+	if limit > maxCursorPageLimit {
+		limit = maxCursorPageLimit
+	}
+
+	var notifications []*domain.Notification
+	var next *pagination.Cursor
+	if cmd.Status != "" {
+		status := domain.NotificationStatus(cmd.Status)
+		notifications, next, err = s.notificationRepo.FindByStatusAfterCursor(ctx, status, cursor, limit)
+	} else if cmd.CreatedBy != "" {
+		notifications, next, err = s.notificationRepo.FindByCreatedByAfterCursor(ctx, cmd.CreatedBy, cursor, limit)
+	} else {
+		notifications, next, err = s.notificationRepo.FindAfterCursor(ctx, cursor, limit)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return notifications, next.Encode(), nil
+}
+
+// 计划发送查询的限制
+const (
+	defaultUpcomingScheduleLimit = 500
+	maxUpcomingScheduleLimit     = 2000
+	maxUpcomingScheduleWindow    = 90 * 24 * time.Hour
+)
+
+// GetUpcomingSchedule 列出从现在起window内的计划发送，用于日历视图
+// 包括待发送的定时通知（含因免打扰时段延后的通知）和待自动重试的失败通知，每个通知给出下一次发送时间
+func (s *NotificationService) GetUpcomingSchedule(ctx context.Context, filter *UpcomingScheduleFilter, window time.Duration) (*domain.UpcomingSchedule, error) {
+	if window <= 0 || window > maxUpcomingScheduleWindow {
+		return nil, domain.NewDomainErrorWithDetails(domain.ErrInvalidScheduleWindow, "Invalid schedule window",
+			fmt.Sprintf("window must be between 0 and %s, got %s", maxUpcomingScheduleWindow, window))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultUpcomingScheduleLimit
+	}
+	if limit > maxUpcomingScheduleLimit {
+		limit = maxUpcomingScheduleLimit
+	}
+
+	from := time.Now()
+	to := from.Add(window)
+
+	// 多取一条用于判断是否截断
+	notifications, err := s.notificationRepo.FindUpcomingNotifications(ctx, to, repository.ScheduleFilter{
+		CreatedBy: filter.CreatedBy,
+		Channel:   domain.NotificationChannel(filter.Channel),
+		Type:      domain.NotificationType(filter.Type),
+	}, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &domain.UpcomingSchedule{
+		From:  from,
+		To:    to,
+		Sends: make([]domain.UpcomingSend, 0, len(notifications)),
+	}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		schedule.Truncated = true
+	}
+
+	for _, notification := range notifications {
+		sendAt, kind, ok := notification.NextSendTime(from, to)
+		if !ok {
+			continue
+		}
+		schedule.Sends = append(schedule.Sends, domain.NewUpcomingSend(notification, kind, sendAt))
+	}
+
+	sort.SliceStable(schedule.Sends, func(i, j int) bool {
+		return schedule.Sends[i].SendAt.Before(schedule.Sends[j].SendAt)
+	})
+
+	return schedule, nil
+}
+
 // CancelNotification 取消通知
 func (s *NotificationService) CancelNotification(ctx context.Context, notificationID string) error {
 	notification, err := s.notificationRepo.FindByID(ctx, notificationID)
@@ -342,7 +822,8 @@ func (s *NotificationService) CancelNotification(ctx context.Context, notificati
 	return s.notificationRepo.Update(ctx, notification)
 }
 
-// RetryNotification 重试通知
+// RetryNotification 重试通知，只重新发送失败的接收者，已发送的接收者不会再次发送
+// 失败的通知和部分发送成功的通知都可以重试，每个接收者最多重试通知的max_retries次
 func (s *NotificationService) RetryNotification(ctx context.Context, notificationID string) error {
 	notification, err := s.notificationRepo.FindByID(ctx, notificationID)
 	if err != nil {
@@ -352,22 +833,42 @@ func (s *NotificationService) RetryNotification(ctx context.Context, notificatio
 		return domain.ErrNotificationNotFoundf(notificationID)
 	}
 
-	// 检查是否可以重试
-	if !notification.CanRetry() {
-		return domain.NewDomainError("CANNOT_RETRY", "notification cannot be retried")
+	recipients, err := s.recipientRepo.FindByNotificationID(ctx, notificationID)
+	if err != nil {
+		return err
 	}
 
-	// 重置状态为待发送
-	err = notification.UpdateStatus(domain.NotificationStatusPending)
+	reset, err := notification.PrepareRetry(recipients)
 	if err != nil {
+		// 没有可重试的接收者时保存通知，停止自动重试
+		if notification.Status == domain.NotificationStatusFailed && notification.NextRetryAt == nil {
+			if updateErr := s.notificationRepo.Update(ctx, notification); updateErr != nil {
+				s.logger.Warn("Failed to stop retrying notification",
+					zap.String("notification_id", notificationID),
+					zap.Error(updateErr))
+			}
+		}
 		return err
 	}
 
+	for _, recipient := range reset {
+		if err := s.recipientRepo.Update(ctx, recipient); err != nil {
+			return err
+		}
+	}
+
 	err = s.notificationRepo.Update(ctx, notification)
 	if err != nil {
 		return err
 	}
 
+	s.logger.Info("Retrying notification",
+		zap.String("notification_id", notificationID),
+		zap.Int("reset_recipients", len(reset)))
+	if s.metrics != nil {
+		s.metrics.ObserveRetries(string(notification.Channel), len(reset))
+	}
+
 	// 异步发送
 	go s.processNotificationAsync(context.Background(), notificationID)
 
@@ -375,16 +876,18 @@ func (s *NotificationService) RetryNotification(ctx context.Context, notificatio
 }
 
 // ProcessScheduledNotifications 处理定时通知
+// 先认领到期的通知再发送，定时任务重叠或多个实例同时运行时每个通知只会被发送一次；
+// 认领超时的发送中通知（处理实例已退出）会被重新认领，已发送的接收者不会再次发送
 func (s *NotificationService) ProcessScheduledNotifications(ctx context.Context) error {
-	// 获取应该发送的定时通知
-	notifications, err := s.notificationRepo.FindScheduledNotifications(ctx, time.Now().Unix())
+	now := time.Now()
+	notifications, err := s.notificationRepo.ClaimScheduledNotifications(ctx, now, now.Add(-s.sendConfig.ClaimTimeout), s.sendConfig.ScheduledBatchSize)
 	if err != nil {
 		return err
 	}
 
 	for _, notification := range notifications {
 		// 异步处理每个通知
-		go s.processNotificationAsync(context.Background(), notification.ID)
+		go s.deliverNotificationAsync(context.Background(), notification)
 	}
 
 	return nil
@@ -398,7 +901,135 @@ func (s *NotificationService) ProcessRetryNotifications(ctx context.Context) err
 	}
 
 	for _, notification := range notifications {
-		go s.processNotificationAsync(context.Background(), notification.ID)
+		// 失败的通知需先重置为待发送状态才能再次发送
+		if err := s.RetryNotification(ctx, notification.ID); err != nil {
+			s.logger.Error("Failed to retry notification",
+				zap.String("notification_id", notification.ID),
+				zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// ProcessSLABreaches 检查超过SLA仍未发送的通知并发出告警
+func (s *NotificationService) ProcessSLABreaches(ctx context.Context) error {
+	now := time.Now()
+	notifications, err := s.notificationRepo.FindSLABreachedNotifications(ctx, now, 100)
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		if !notification.IsSLABreached(now) {
+			continue
+		}
+
+		deadline, _ := notification.SLADeadline()
+		event := &SLABreachEvent{
+			EventType:      domain.EventNotificationSLABreached,
+			NotificationID: notification.ID,
+			Channel:        notification.Channel,
+			Type:           notification.Type,
+			Priority:       notification.Priority,
+			Status:         notification.Status,
+			SLA:            time.Duration(notification.SLASeconds) * time.Second,
+			Deadline:       deadline,
+			Overdue:        now.Sub(deadline),
+			CreatedBy:      notification.CreatedBy,
+		}
+
+		if s.slaAlerter != nil {
+			if err := s.slaAlerter.AlertSLABreach(ctx, event); err != nil {
+				// 告警失败时不标记，下一轮继续尝试
+				s.logger.Error("Failed to alert SLA breach",
+					zap.String("notification_id", notification.ID),
+					zap.Error(err))
+				continue
+			}
+		}
+
+		notification.MarkSLABreached(now)
+		if err := s.notificationRepo.Update(ctx, notification); err != nil {
+			s.logger.Error("Failed to mark SLA breach",
+				zap.String("notification_id", notification.ID),
+				zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// RecordDeliveryReceipt 记录服务商的投递回执，更新接收者状态并汇总通知状态
+// 重复或乱序到达的回执不会覆盖已有的最终状态
+func (s *NotificationService) RecordDeliveryReceipt(ctx context.Context, cmd *RecordDeliveryReceiptCommand) error {
+	recipient, err := s.recipientRepo.FindByID(ctx, cmd.RecipientID)
+	if err != nil {
+		return err
+	}
+	if recipient == nil || recipient.NotificationID != cmd.NotificationID {
+		return domain.ErrRecipientNotFoundf(cmd.RecipientID)
+	}
+
+	now := time.Now()
+	changed, err := recipient.ApplyReceipt(cmd.Status, cmd.ProviderMessageID, now)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		s.logger.Info("Ignoring delivery receipt for recipient with final status",
+			zap.String("recipient_id", recipient.ID),
+			zap.String("recipient_status", string(recipient.Status)),
+			zap.String("receipt_status", string(cmd.Status)))
+		return nil
+	}
+	if err := s.recipientRepo.Update(ctx, recipient); err != nil {
+		return err
+	}
+
+	// 汇总通知状态
+	notification, err := s.notificationRepo.FindByID(ctx, cmd.NotificationID)
+	if err != nil {
+		return err
+	}
+	if notification == nil {
+		return domain.ErrNotificationNotFoundf(cmd.NotificationID)
+	}
+
+	recipients, err := s.recipientRepo.FindByNotificationID(ctx, cmd.NotificationID)
+	if err != nil {
+		return err
+	}
+	if !notification.ApplyDeliveryReceipts(recipients, now) {
+		return nil
+	}
+	if err := s.notificationRepo.Update(ctx, notification); err != nil {
+		return err
+	}
+
+	if notification.Status == domain.NotificationStatusDelivered && s.eventPublisher != nil {
+		delivered := 0
+		for _, r := range recipients {
+			if r.Status == domain.RecipientStatusDelivered {
+				delivered++
+			}
+		}
+
+		event := &DeliveryEvent{
+			EventType:      domain.EventNotificationDelivered,
+			NotificationID: notification.ID,
+			Channel:        notification.Channel,
+			Status:         notification.Status,
+			Delivered:      delivered,
+			Total:          len(recipients),
+			DeliveredAt:    now,
+			CreatedBy:      notification.CreatedBy,
+		}
+		if err := s.eventPublisher.PublishDeliveryEvent(ctx, event); err != nil {
+			s.logger.Error("Failed to publish delivery event",
+				zap.String("notification_id", notification.ID),
+				zap.Error(err))
+		}
 	}
 
 	return nil
@@ -419,6 +1050,15 @@ func (s *NotificationService) processNotificationAsync(ctx context.Context, noti
 	}
 }
 
+// deliverNotificationAsync 异步发送已认领的通知
+func (s *NotificationService) deliverNotificationAsync(ctx context.Context, notification *domain.Notification) {
+	if err := s.deliverNotification(ctx, notification); err != nil {
+		s.logger.Error("Failed to deliver claimed notification",
+			zap.String("notification_id", notification.ID),
+			zap.Error(err))
+	}
+}
+
 // 辅助函数
 func convertRecipientsToPointers(recipients []domain.Recipient) []*domain.Recipient {
 	result := make([]*domain.Recipient, len(recipients))
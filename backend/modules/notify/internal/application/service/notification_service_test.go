@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// memNotificationRepository 内存通知仓储，保存副本以模拟数据库读写
+type memNotificationRepository struct {
+	repository.NotificationRepository
+	mu            sync.Mutex
+	notifications map[string]domain.Notification
+	renewals      int
+}
+
+func newMemNotificationRepository() *memNotificationRepository {
+	return &memNotificationRepository{notifications: make(map[string]domain.Notification)}
+}
+
+func (r *memNotificationRepository) renewalCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.renewals
+}
+
+func (r *memNotificationRepository) get(id string) domain.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.notifications[id]
+}
+
+func (r *memNotificationRepository) FindByID(ctx context.Context, id string) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notification, exists := r.notifications[id]
+	if !exists {
+		return nil, nil
+	}
+	return &notification, nil
+}
+
+func (r *memNotificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (r *memNotificationRepository) Save(ctx context.Context, notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (r *memNotificationRepository) ClaimNotification(ctx context.Context, id string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notification := r.notifications[id]
+	if notification.Status != domain.NotificationStatusPending || (notification.ScheduledAt != nil && notification.ScheduledAt.After(now)) {
+		return false, nil
+	}
+	notification.Status = domain.NotificationStatusSending
+	r.notifications[id] = notification
+	return true, nil
+}
+
+// ClaimScheduledNotifications 在同一把锁内认领到期的定时通知，模拟数据库的行锁
+func (r *memNotificationRepository) ClaimScheduledNotifications(ctx context.Context, now, staleBefore time.Time, limit int) ([]*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []*domain.Notification
+	for id, notification := range r.notifications {
+		if len(claimed) >= limit {
+			break
+		}
+		if notification.Status != domain.NotificationStatusPending || notification.ScheduledAt == nil || notification.ScheduledAt.After(now) {
+			continue
+		}
+		notification.Status = domain.NotificationStatusSending
+		r.notifications[id] = notification
+		copied := notification
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (r *memNotificationRepository) RenewClaim(ctx context.Context, id string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renewals++
+	return r.notifications[id].Status == domain.NotificationStatusSending, nil
+}
+
+// memRecipientRepository 内存接收者仓储
+type memRecipientRepository struct {
+	repository.RecipientRepository
+	mu         sync.Mutex
+	recipients map[string]domain.Recipient
+}
+
+func newMemRecipientRepository() *memRecipientRepository {
+	return &memRecipientRepository{recipients: make(map[string]domain.Recipient)}
+}
+
+func (r *memRecipientRepository) get(id string) domain.Recipient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recipients[id]
+}
+
+func (r *memRecipientRepository) FindByID(ctx context.Context, id string) (*domain.Recipient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recipient, exists := r.recipients[id]
+	if !exists {
+		return nil, nil
+	}
+	return &recipient, nil
+}
+
+func (r *memRecipientRepository) FindByNotificationID(ctx context.Context, notificationID string) ([]*domain.Recipient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var recipients []*domain.Recipient
+	for _, recipient := range r.recipients {
+		if recipient.NotificationID == notificationID {
+			copied := recipient
+			recipients = append(recipients, &copied)
+		}
+	}
+	return recipients, nil
+}
+
+func (r *memRecipientRepository) Update(ctx context.Context, recipient *domain.Recipient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recipients[recipient.ID] = *recipient
+	return nil
+}
+
+func (r *memRecipientRepository) SaveBatch(ctx context.Context, recipients []*domain.Recipient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, recipient := range recipients {
+		r.recipients[recipient.ID] = *recipient
+	}
+	return nil
+}
+
+// staticChannelRepository 始终返回同一个渠道配置
+type staticChannelRepository struct {
+	repository.ChannelRepository
+	config *domain.ChannelConfig
+}
+
+func (r *staticChannelRepository) FindByChannelAndOwner(ctx context.Context, channel domain.NotificationChannel, ownerID string) (*domain.ChannelConfig, error) {
+	return r.config, nil
+}
+
+// failingWebhookProvider 对指定标识的接收者返回错误，并记录最大并发数
+type failingWebhookProvider struct {
+	fail     func(identifier string) bool
+	delay    time.Duration
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	sent     map[string]int
+}
+
+func (p *failingWebhookProvider) SendWebhook(ctx context.Context, data *WebhookData, config *domain.ChannelConfig) error {
+	recipient := data.Data["recipient"].(*domain.Recipient)
+
+	p.mu.Lock()
+	p.inFlight++
+	if p.inFlight > p.peak {
+		p.peak = p.inFlight
+	}
+	p.sent[recipient.Identifier]++
+	p.mu.Unlock()
+
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+
+	if p.fail != nil && p.fail(recipient.Identifier) {
+		return errors.New("invalid recipient")
+	}
+	return nil
+}
+
+func (p *failingWebhookProvider) ValidateConfig(config *domain.ChannelConfig) error { return nil }
+
+func (p *failingWebhookProvider) GetProviderName() string { return "failing" }
+
+// sendTestEnv 通过Webhook渠道发送通知的测试环境
+type sendTestEnv struct {
+	notifications *memNotificationRepository
+	recipients    *memRecipientRepository
+	channels      *staticChannelRepository
+	webhook       *failingWebhookProvider
+	service       *NotificationService
+}
+
+func newSendTestEnv(t testing.TB, config SendConfig, fail func(identifier string) bool) *sendTestEnv {
+	t.Helper()
+	channelConfig, err := domain.NewChannelConfig(domain.ChannelWebhook, "webhook", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	channelConfig.IsEnabled = true
+	channelConfig.Config = map[string]string{"url": "https://example.com/hook"}
+
+	env := &sendTestEnv{
+		notifications: newMemNotificationRepository(),
+		recipients:    newMemRecipientRepository(),
+		channels:      &staticChannelRepository{config: channelConfig},
+		webhook:       &failingWebhookProvider{fail: fail, sent: make(map[string]int)},
+	}
+	logger := zap.NewNop()
+	channelService := NewChannelService(env.channels, nil, nil, nil, env.webhook, nil, nil, nil, nil, logger)
+	env.service = NewNotificationService(env.notifications, env.recipients, nil, env.channels, channelService,
+		nil, nil, nil, nil, config, nil, logger)
+	return env
+}
+
+// addNotification 创建带count个接收者的待发送通知，接收者标识为u000、u001……
+func (e *sendTestEnv) addNotification(t testing.TB, count int) *domain.Notification {
+	t.Helper()
+	notification, err := domain.NewNotification("title", "content", domain.NotificationTypeSystem, domain.ChannelWebhook, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.notifications.notifications[notification.ID] = *notification
+
+	for i := 0; i < count; i++ {
+		recipient, err := domain.NewRecipient(notification.ID, domain.RecipientTypeUser, fmt.Sprintf("u%03d", i), domain.ChannelWebhook)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.recipients.recipients[recipient.ID] = *recipient
+	}
+	return notification
+}
+
+// failEveryTenth 标识序号为10的倍数的接收者发送失败
+func failEveryTenth(identifier string) bool {
+	var index int
+	fmt.Sscanf(identifier, "u%d", &index)
+	return index%10 == 0
+}
+
+func TestSendNotificationParallelPartialFailure(t *testing.T) {
+	config := DefaultSendConfig()
+	config.Concurrency = 8
+	env := newSendTestEnv(t, config, failEveryTenth)
+	env.webhook.delay = time.Millisecond
+	notification := env.addNotification(t, 100)
+
+	if err := env.service.SendNotification(context.Background(), notification.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got := env.notifications.get(notification.ID)
+	if got.Status != domain.NotificationStatusSent || got.ErrorMessage != "partial success: 90/100 sent" {
+		t.Fatalf("status = %s, error = %q", got.Status, got.ErrorMessage)
+	}
+
+	recipients, _ := env.recipients.FindByNotificationID(context.Background(), notification.ID)
+	failed := 0
+	for _, recipient := range recipients {
+		if env.webhook.sent[recipient.Identifier] != 1 {
+			t.Fatalf("recipient %s sent %d times", recipient.Identifier, env.webhook.sent[recipient.Identifier])
+		}
+		want := domain.RecipientStatusSent
+		if failEveryTenth(recipient.Identifier) {
+			want = domain.RecipientStatusFailed
+			failed++
+		}
+		if recipient.Status != want {
+			t.Fatalf("recipient %s status = %s, want %s", recipient.Identifier, recipient.Status, want)
+		}
+	}
+	if failed != 10 {
+		t.Fatalf("failed recipients = %d, want 10", failed)
+	}
+
+	if env.webhook.peak > config.Concurrency {
+		t.Fatalf("peak concurrency %d exceeds %d", env.webhook.peak, config.Concurrency)
+	}
+	if env.webhook.peak < 2 {
+		t.Fatalf("recipients were not sent in parallel, peak concurrency %d", env.webhook.peak)
+	}
+}
+
+func TestSendNotificationAggregateStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		fail   func(identifier string) bool
+		status domain.NotificationStatus
+	}{
+		{"all sent", nil, domain.NotificationStatusSent},
+		{"all failed", func(string) bool { return true }, domain.NotificationStatusFailed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env := newSendTestEnv(t, DefaultSendConfig(), c.fail)
+			notification := env.addNotification(t, 100)
+
+			if err := env.service.SendNotification(context.Background(), notification.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			got := env.notifications.get(notification.ID)
+			if got.Status != c.status {
+				t.Fatalf("status = %s, want %s", got.Status, c.status)
+			}
+			if c.status == domain.NotificationStatusSent && got.ErrorMessage != "" {
+				t.Fatalf("unexpected error message %q", got.ErrorMessage)
+			}
+			if c.status == domain.NotificationStatusFailed && !strings.HasPrefix(got.ErrorMessage, "failed to send to all recipients") {
+				t.Fatalf("unexpected error message %q", got.ErrorMessage)
+			}
+		})
+	}
+}
+
+// recordingEventPublisher 记录发布的投递事件
+type recordingEventPublisher struct {
+	events []*DeliveryEvent
+}
+
+func (p *recordingEventPublisher) PublishDeliveryEvent(ctx context.Context, event *DeliveryEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestRecordDeliveryReceiptOutOfOrder(t *testing.T) {
+	env := newSendTestEnv(t, DefaultSendConfig(), nil)
+	publisher := &recordingEventPublisher{}
+	env.service.eventPublisher = publisher
+	notification := env.addNotification(t, 2)
+	ctx := context.Background()
+
+	if err := env.service.SendNotification(ctx, notification.ID); err != nil {
+		t.Fatal(err)
+	}
+	recipients, _ := env.recipients.FindByNotificationID(ctx, notification.ID)
+	first, second := recipients[0].ID, recipients[1].ID
+
+	receipt := func(recipientID string, status domain.RecipientStatus) {
+		t.Helper()
+		err := env.service.RecordDeliveryReceipt(ctx, &RecordDeliveryReceiptCommand{
+			NotificationID: notification.ID,
+			RecipientID:    recipientID,
+			Status:         status,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	receipt(first, domain.RecipientStatusBounced)
+	if got := env.notifications.get(notification.ID); got.Status != domain.NotificationStatusSent || len(publisher.events) != 0 {
+		t.Fatalf("notification should wait for all receipts, status = %s", got.Status)
+	}
+
+	receipt(second, domain.RecipientStatusDelivered)
+	// 重复和迟到的回执被忽略
+	receipt(second, domain.RecipientStatusFailed)
+	receipt(first, domain.RecipientStatusDelivered)
+
+	got := env.notifications.get(notification.ID)
+	if got.Status != domain.NotificationStatusDelivered || got.ErrorMessage != "partial delivery: 1/2 delivered" {
+		t.Fatalf("status = %s, error = %q", got.Status, got.ErrorMessage)
+	}
+	if env.recipients.get(first).Status != domain.RecipientStatusBounced || env.recipients.get(second).Status != domain.RecipientStatusDelivered {
+		t.Fatal("late receipts changed recipient status")
+	}
+	if len(publisher.events) != 1 {
+		t.Fatalf("published %d events, want 1", len(publisher.events))
+	}
+	event := publisher.events[0]
+	if event.EventType != domain.EventNotificationDelivered || event.Delivered != 1 || event.Total != 2 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	err := env.service.RecordDeliveryReceipt(ctx, &RecordDeliveryReceiptCommand{NotificationID: "other", RecipientID: first, Status: domain.RecipientStatusDelivered})
+	var domainErr *domain.DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != domain.ErrRecipientNotFound {
+		t.Fatalf("expected recipient not found, got %v", err)
+	}
+}
+
+func BenchmarkSendNotification(b *testing.B) {
+	for _, concurrency := range []int{1, 10} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			config := DefaultSendConfig()
+			config.Concurrency = concurrency
+			env := newSendTestEnv(b, config, failEveryTenth)
+			env.webhook.delay = 100 * time.Microsecond
+
+			ids := make([]string, b.N)
+			for i := range ids {
+				ids[i] = env.addNotification(b, 100).ID
+			}
+
+			b.ResetTimer()
+			for _, id := range ids {
+				if err := env.service.SendNotification(context.Background(), id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
 )
@@ -48,6 +49,7 @@ type SMSData struct {
 	TemplateID  string            `json:"template_id,omitempty"`
 	Variables   map[string]string `json:"variables,omitempty"`
 	SignName    string            `json:"sign_name,omitempty"`
+	MessageID   string            `json:"-"` // 发送成功后由提供商填写服务商消息ID，用于关联投递回执
 }
 
 // PushProvider 推送提供商接口（包括Bark等）
@@ -163,6 +165,7 @@ type TelegramData struct {
 	Text        string `json:"text"`
 	ParseMode   string `json:"parse_mode,omitempty"` // Markdown, HTML
 	ReplyMarkup string `json:"reply_markup,omitempty"`
+	SentParts   int    `json:"-"` // 拆分发送时已成功发送的消息数，提供商跳过这些消息，每成功发送一条加一
 }
 
 // DiscordProvider Discord提供商接口
@@ -237,3 +240,46 @@ func (r *ProviderRegistry) GetPushProvider(name string) PushProvider {
 func (r *ProviderRegistry) GetWebhookProvider(name string) WebhookProvider {
 	return r.webhookProviders[name]
 }
+
+// ChannelRateLimiter 渠道限流器接口
+// 默认实现为进程内令牌桶，多实例部署时可替换为基于etcd等共享存储的实现
+type ChannelRateLimiter interface {
+	// Allow 尝试获取一次发送配额，被拒绝时返回建议的等待时间
+	Allow(ctx context.Context, key string, limitPerMinute int) (bool, time.Duration, error)
+}
+
+// DeliveryEventPublisher 投递事件发布接口
+type DeliveryEventPublisher interface {
+	PublishDeliveryEvent(ctx context.Context, event *DeliveryEvent) error
+}
+
+// DeliveryEvent 通知投递事件
+type DeliveryEvent struct {
+	EventType      string                     `json:"event_type"`
+	NotificationID string                     `json:"notification_id"`
+	Channel        domain.NotificationChannel `json:"channel"`
+	Status         domain.NotificationStatus  `json:"status"`
+	Delivered      int                        `json:"delivered"` // 已送达的接收者数
+	Total          int                        `json:"total"`
+	DeliveredAt    time.Time                  `json:"delivered_at"`
+	CreatedBy      string                     `json:"created_by"`
+}
+
+// SLAAlerter SLA告警接口
+type SLAAlerter interface {
+	AlertSLABreach(ctx context.Context, event *SLABreachEvent) error
+}
+
+// SLABreachEvent 通知SLA违约事件
+type SLABreachEvent struct {
+	EventType      string                      `json:"event_type"`
+	NotificationID string                      `json:"notification_id"`
+	Channel        domain.NotificationChannel  `json:"channel"`
+	Type           domain.NotificationType     `json:"type"`
+	Priority       domain.NotificationPriority `json:"priority"`
+	Status         domain.NotificationStatus   `json:"status"`
+	SLA            time.Duration               `json:"sla"`
+	Deadline       time.Time                   `json:"deadline"`
+	Overdue        time.Duration               `json:"overdue"`
+	CreatedBy      string                      `json:"created_by"`
+}
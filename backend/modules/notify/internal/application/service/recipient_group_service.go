@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// RecipientGroupService 接收者组服务
+// 接收者组按所有者隔离，访问其他用户的接收者组时按不存在处理
+type RecipientGroupService struct {
+	groupRepo repository.RecipientGroupRepository
+	logger    infrastructure.Logger
+}
+
+// NewRecipientGroupService 创建接收者组服务
+func NewRecipientGroupService(
+	groupRepo repository.RecipientGroupRepository,
+	logger infrastructure.Logger,
+) *RecipientGroupService {
+	return &RecipientGroupService{
+		groupRepo: groupRepo,
+		logger:    logger,
+	}
+}
+
+// CreateGroup 创建接收者组
+func (s *RecipientGroupService) CreateGroup(ctx context.Context, cmd *CreateRecipientGroupCommand) (*domain.RecipientGroup, error) {
+	s.logger.Info("Creating recipient group",
+		zap.String("name", cmd.Name),
+		zap.String("owner_id", cmd.OwnerID))
+
+	group, err := domain.NewRecipientGroup(cmd.Name, cmd.Description, cmd.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, memberCmd := range cmd.Members {
+		member, err := newGroupMember(group.ID, memberCmd)
+		if err != nil {
+			return nil, err
+		}
+		if err := group.AddMember(*member); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.groupRepo.Save(ctx, group); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, domain.ErrRecipientGroupExistsf(group.Name)
+		}
+		s.logger.Error("Failed to save recipient group", zap.Error(err))
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// GetGroup 获取所有者的接收者组
+func (s *RecipientGroupService) GetGroup(ctx context.Context, groupID, ownerID string) (*domain.RecipientGroup, error) {
+	group, err := s.groupRepo.FindByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || !group.IsOwnedBy(ownerID) {
+		return nil, domain.ErrRecipientGroupNotFoundf(groupID)
+	}
+	return group, nil
+}
+
+// ListGroups 列出所有者的接收者组
+func (s *RecipientGroupService) ListGroups(ctx context.Context, ownerID string) ([]*domain.RecipientGroup, error) {
+	return s.groupRepo.FindByOwner(ctx, ownerID)
+}
+
+// AddMembers 添加接收者组成员
+func (s *RecipientGroupService) AddMembers(ctx context.Context, cmd *AddRecipientGroupMembersCommand) (*domain.RecipientGroup, error) {
+	group, err := s.GetGroup(ctx, cmd.GroupID, cmd.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	added := make([]*domain.RecipientGroupMember, 0, len(cmd.Members))
+	for _, memberCmd := range cmd.Members {
+		member, err := newGroupMember(group.ID, memberCmd)
+		if err != nil {
+			return nil, err
+		}
+		if err := group.AddMember(*member); err != nil {
+			return nil, err
+		}
+		added = append(added, member)
+	}
+
+	if err := s.groupRepo.SaveMembers(ctx, added); err != nil {
+		// 并发添加了相同成员
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, domain.NewDomainErrorWithDetails(domain.ErrRecipientGroupMemberExists,
+				"Recipient group member already exists", "group: "+group.Name)
+		}
+		s.logger.Error("Failed to save recipient group members", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Recipient group members added",
+		zap.String("group_id", group.ID),
+		zap.Int("count", len(added)))
+	return group, nil
+}
+
+// RemoveMember 移除接收者组成员
+func (s *RecipientGroupService) RemoveMember(ctx context.Context, cmd *RemoveRecipientGroupMemberCommand) error {
+	group, err := s.GetGroup(ctx, cmd.GroupID, cmd.OwnerID)
+	if err != nil {
+		return err
+	}
+	if err := group.RemoveMember(cmd.MemberID); err != nil {
+		return err
+	}
+	return s.groupRepo.DeleteMember(ctx, group.ID, cmd.MemberID)
+}
+
+// DeleteGroup 删除接收者组，已创建的通知不受影响
+func (s *RecipientGroupService) DeleteGroup(ctx context.Context, groupID, ownerID string) error {
+	group, err := s.GetGroup(ctx, groupID, ownerID)
+	if err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(ctx, group.ID)
+}
+
+// ExpandGroups 将所有者的接收者组展开为接收指定渠道的成员
+// 多个组中类型和标识相同的成员只保留第一个
+func (s *RecipientGroupService) ExpandGroups(ctx context.Context, ownerID string, names []string, channel domain.NotificationChannel) ([]CreateRecipientCommand, error) {
+	recipients := make([]CreateRecipientCommand, 0)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		group, err := s.groupRepo.FindByOwnerAndName(ctx, ownerID, name)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			return nil, domain.ErrRecipientGroupNotFoundf(name)
+		}
+
+		for _, member := range group.MembersForChannel(channel) {
+			key := recipientKey(member.Type, member.Identifier)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			recipients = append(recipients, CreateRecipientCommand{
+				Type:       member.Type,
+				Identifier: member.Identifier,
+				Name:       member.Name,
+				Address:    member.Address,
+				Locale:     member.Locale,
+				Variables:  member.Variables,
+				QuietHours: member.QuietHours,
+				TrackingOptOut: member.TrackingOptOut,
+			})
+		}
+	}
+	return recipients, nil
+}
+
+// newGroupMember 根据命令创建接收者组成员
+func newGroupMember(groupID string, cmd RecipientGroupMemberCmd) (*domain.RecipientGroupMember, error) {
+	member, err := domain.NewRecipientGroupMember(groupID, cmd.Type, cmd.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	member.Name = cmd.Name
+	member.Address = cmd.Address
+	member.Locale = cmd.Locale
+	member.Channels = cmd.Channels
+	if cmd.Variables != nil {
+		member.Variables = cmd.Variables
+	}
+	member.QuietHours = cmd.QuietHours
+	member.TrackingOptOut = cmd.TrackingOptOut
+	return member, nil
+}
+
+// recipientKey 接收者去重键
+func recipientKey(recipientType domain.RecipientType, identifier string) string {
+	return string(recipientType) + ":" + identifier
+}
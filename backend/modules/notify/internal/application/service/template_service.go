@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
 	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
@@ -33,12 +34,6 @@ func (s *TemplateService) CreateTemplate(ctx context.Context, cmd *CreateTemplat
 		zap.String("code", cmd.Code),
 		zap.String("created_by", cmd.CreatedBy))
 
-	// 检查模板代码是否已存在
-	existing, err := s.templateRepo.FindByCode(ctx, cmd.Code)
-	if err == nil && existing != nil {
-		return nil, domain.NewDomainError("TEMPLATE_CODE_EXISTS", "template code already exists")
-	}
-
 	// 创建模板
 	template, err := domain.NewNotificationTemplate(cmd.Name, cmd.Code, cmd.Type, cmd.CreatedBy)
 	if err != nil {
@@ -87,9 +82,13 @@ func (s *TemplateService) CreateTemplate(ctx context.Context, cmd *CreateTemplat
 		return nil, err
 	}
 
-	// 保存模板
+	// 保存模板，模板代码的唯一性由数据库唯一索引保证，避免先查后写的并发竞争
 	err = s.templateRepo.Save(ctx, template)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			s.logger.Warn("Template code already exists", zap.String("code", cmd.Code))
+			return nil, domain.ErrTemplateCodeExistsf(cmd.Code)
+		}
 		s.logger.Error("Failed to save template", zap.Error(err))
 		return nil, err
 	}
@@ -139,6 +138,12 @@ func (s *TemplateService) GetTemplate(ctx context.Context, templateID string) (*
 		template.Channels = convertPointersToChannels(channels)
 	}
 
+	// 加载本地化内容
+	localizations, err := s.templateRepo.FindLocalizations(ctx, templateID)
+	if err == nil {
+		template.Localizations = convertPointersToLocalizations(localizations)
+	}
+
 	return template, nil
 }
 
@@ -263,9 +268,26 @@ func (s *TemplateService) RenderTemplate(ctx context.Context, cmd *RenderTemplat
 		return "", "", domain.NewDomainError("TEMPLATE_NOT_USABLE", "template is not usable")
 	}
 
+	// 指定接收者时按接收者资料和个性化变量渲染
+	if cmd.Recipient != nil {
+		variables := mergeVariables(cmd.Variables, cmd.Recipient.Variables)
+		return template.RenderTemplateWithProfile(cmd.Channel, variables, profileRecipient(cmd.Recipient).ProfileVariables())
+	}
+
 	return template.RenderTemplate(cmd.Channel, cmd.Variables)
 }
 
+// PreviewRender 使用示例变量预览模板渲染结果，不创建通知
+// 使用活跃版本和渠道模板渲染，草稿模板也可以预览；缺少必需变量等验证错误记录在预览结果中
+func (s *TemplateService) PreviewRender(ctx context.Context, templateID string, channel domain.NotificationChannel, sampleVariables map[string]string) (*domain.TemplatePreview, error) {
+	template, err := s.GetTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.Preview(channel, sampleVariables)
+}
+
 // ListTemplates 列出模板
 func (s *TemplateService) ListTemplates(ctx context.Context, cmd *ListTemplatesCommand) ([]*domain.NotificationTemplate, int64, error) {
 	var templates []*domain.NotificationTemplate
@@ -331,37 +353,234 @@ func (s *TemplateService) ArchiveTemplate(ctx context.Context, templateID string
 	return s.templateRepo.Update(ctx, template)
 }
 
-// DeleteTemplate 删除模板
+// DeleteTemplate 软删除模板，变量等关联数据保留，误删时可以恢复；永久删除由TemplateRepository.PurgeDeleted完成
 func (s *TemplateService) DeleteTemplate(ctx context.Context, templateID string) error {
-	// 先删除相关数据
-	s.templateRepo.DeleteVariablesByTemplateID(ctx, templateID)
-	
-	// 删除模板
 	return s.templateRepo.Delete(ctx, templateID)
 }
 
 // SetChannelTemplate 设置渠道模板
 func (s *TemplateService) SetChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel, subject, content string, config map[string]string) error {
+	_, err := s.UpdateChannelTemplate(ctx, &SetTemplateChannelCommand{
+		TemplateID: templateID,
+		Channel:    channel,
+		Subject:    subject,
+		Content:    content,
+		Config:     config,
+	})
+	return err
+}
+
+// ListChannelTemplates 列出模板的渠道配置
+func (s *TemplateService) ListChannelTemplates(ctx context.Context, templateID string) ([]*domain.TemplateChannel, error) {
 	template, err := s.templateRepo.FindByID(ctx, templateID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if template == nil {
-		return domain.ErrTemplateNotFoundf(templateID)
+		return nil, domain.ErrTemplateNotFoundf(templateID)
+	}
+
+	return s.templateRepo.FindChannelTemplates(ctx, templateID)
+}
+
+// GetChannelTemplate 获取模板的单个渠道配置
+func (s *TemplateService) GetChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel) (*domain.TemplateChannel, error) {
+	template, err := s.loadTemplateWithChannels(ctx, templateID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 设置渠道模板
-	template.SetChannelTemplate(channel, subject, content, config)
+	channelTemplate := template.FindChannelTemplate(channel)
+	if channelTemplate == nil {
+		return nil, domain.ErrTemplateChannelNotFoundf(templateID, string(channel))
+	}
+
+	return channelTemplate, nil
+}
+
+// UpdateChannelTemplate 创建或更新模板的渠道配置
+func (s *TemplateService) UpdateChannelTemplate(ctx context.Context, cmd *SetTemplateChannelCommand) (*domain.TemplateChannel, error) {
+	s.logger.Info("Setting template channel",
+		zap.String("template_id", cmd.TemplateID),
+		zap.String("channel", string(cmd.Channel)))
+
+	template, err := s.loadTemplateWithChannels(ctx, cmd.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证渠道内容模板语法
+	if cmd.Content != "" {
+		variables, err := s.templateRepo.FindVariablesByTemplateID(ctx, cmd.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = domain.ValidateTemplate(cmd.Content, convertPointersToVariables(variables))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	template.SetChannelTemplate(cmd.Channel, cmd.Subject, cmd.Content, cmd.Config)
+
+	if cmd.IsEnabled != nil {
+		err = template.SetChannelTemplateEnabled(cmd.Channel, *cmd.IsEnabled)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// 保存渠道模板
-	channelTemplate := template.GetChannelTemplate(channel)
-	if channelTemplate != nil {
-		return s.templateRepo.SaveChannelTemplate(ctx, channelTemplate)
+	channelTemplate := template.FindChannelTemplate(cmd.Channel)
+	err = s.templateRepo.SaveChannelTemplate(ctx, channelTemplate)
+	if err != nil {
+		s.logger.Error("Failed to save template channel", zap.Error(err))
+		return nil, err
+	}
+
+	return channelTemplate, nil
+}
+
+// DeleteChannelTemplate 删除模板的渠道配置
+func (s *TemplateService) DeleteChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel) error {
+	template, err := s.loadTemplateWithChannels(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	if !template.RemoveChannelTemplate(channel) {
+		return domain.ErrTemplateChannelNotFoundf(templateID, string(channel))
+	}
+
+	err = s.templateRepo.DeleteChannelTemplate(ctx, templateID, channel)
+	if err != nil {
+		s.logger.Error("Failed to delete template channel", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// loadTemplateWithChannels 加载模板及其渠道配置
+func (s *TemplateService) loadTemplateWithChannels(ctx context.Context, templateID string) (*domain.NotificationTemplate, error) {
+	template, err := s.templateRepo.FindByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, domain.ErrTemplateNotFoundf(templateID)
+	}
+
+	channels, err := s.templateRepo.FindChannelTemplates(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	template.Channels = convertPointersToChannels(channels)
+
+	return template, nil
+}
+
+// ListTemplateLocalizations 列出模板的本地化内容
+func (s *TemplateService) ListTemplateLocalizations(ctx context.Context, templateID string) ([]*domain.TemplateLocalization, error) {
+	template, err := s.templateRepo.FindByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, domain.ErrTemplateNotFoundf(templateID)
+	}
+
+	return s.templateRepo.FindLocalizations(ctx, templateID)
+}
+
+// SetTemplateLocalization 创建或更新模板的本地化内容
+func (s *TemplateService) SetTemplateLocalization(ctx context.Context, cmd *SetTemplateLocalizationCommand) (*domain.TemplateLocalization, error) {
+	locale, err := domain.NormalizeLocale(cmd.Locale)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Subject == "" && cmd.Content == "" {
+		return nil, domain.NewDomainError(domain.ErrInvalidTemplate, "localization subject and content cannot both be empty")
+	}
+
+	s.logger.Info("Setting template localization",
+		zap.String("template_id", cmd.TemplateID),
+		zap.String("locale", locale),
+		zap.String("channel", string(cmd.Channel)))
+
+	template, err := s.loadTemplateWithLocalizations(ctx, cmd.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证本地化内容模板语法
+	if cmd.Content != "" {
+		variables, err := s.templateRepo.FindVariablesByTemplateID(ctx, cmd.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = domain.ValidateTemplate(cmd.Content, convertPointersToVariables(variables))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	localization := template.SetLocalization(locale, cmd.Channel, cmd.Subject, cmd.Content)
+	err = s.templateRepo.SaveLocalization(ctx, localization)
+	if err != nil {
+		s.logger.Error("Failed to save template localization", zap.Error(err))
+		return nil, err
+	}
+
+	return localization, nil
+}
+
+// DeleteTemplateLocalization 删除模板的本地化内容，channel为空时删除适用于所有渠道的本地化
+func (s *TemplateService) DeleteTemplateLocalization(ctx context.Context, templateID, locale string, channel domain.NotificationChannel) error {
+	normalized, err := domain.NormalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+
+	template, err := s.loadTemplateWithLocalizations(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	if !template.RemoveLocalization(normalized, channel) {
+		return domain.ErrTemplateLocaleNotFoundf(templateID, normalized)
+	}
+
+	err = s.templateRepo.DeleteLocalization(ctx, templateID, normalized, channel)
+	if err != nil {
+		s.logger.Error("Failed to delete template localization", zap.Error(err))
+		return err
 	}
 
 	return nil
 }
 
+// loadTemplateWithLocalizations 加载模板及其本地化内容
+func (s *TemplateService) loadTemplateWithLocalizations(ctx context.Context, templateID string) (*domain.NotificationTemplate, error) {
+	template, err := s.templateRepo.FindByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, domain.ErrTemplateNotFoundf(templateID)
+	}
+
+	localizations, err := s.templateRepo.FindLocalizations(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	template.Localizations = convertPointersToLocalizations(localizations)
+
+	return template, nil
+}
+
 // GetTemplateUsageStats 获取模板使用统计
 func (s *TemplateService) GetTemplateUsageStats(ctx context.Context, templateID string) (*repository.TemplateUsageStats, error) {
 	return s.templateRepo.GetUsageStats(ctx, templateID)
@@ -391,3 +610,11 @@ func convertPointersToChannels(channels []*domain.TemplateChannel) []domain.Temp
 	}
 	return result
 }
+
+func convertPointersToLocalizations(localizations []*domain.TemplateLocalization) []domain.TemplateLocalization {
+	result := make([]domain.TemplateLocalization, len(localizations))
+	for i, l := range localizations {
+		result[i] = *l
+	}
+	return result
+}
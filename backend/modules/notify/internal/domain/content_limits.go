@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 内容限制相关的渠道配置项
+const (
+	ConfigSMSMaxSegments   = "sms_max_segments"  // 单条短信允许的最大分段数
+	ConfigSMSAutoSplit     = "sms_auto_split"    // 超出分段数时是否拆分为多条短信发送
+	ConfigMaxPayloadBytes  = "max_payload_bytes" // 推送/Webhook请求体的最大字节数
+	DefaultSMSMaxSegments  = 5
+	DefaultPushPayloadSize = 4096 // APNs/Bark 推送负载上限
+)
+
+// SMSEncoding 短信编码
+type SMSEncoding string
+
+const (
+	SMSEncodingGSM7 SMSEncoding = "gsm7" // GSM 7位编码，单条160字符
+	SMSEncodingUCS2 SMSEncoding = "ucs2" // UCS-2编码（含中文等字符），单条70字符
+)
+
+// 单条与长短信每段可容纳的字符数（长短信需预留拼接头）
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// gsm7Basic GSM 03.38 基本字符集
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension GSM 03.38 扩展字符集，每个字符占两个字符位
+const gsm7Extension = "^{}\\[~]|€\f"
+
+// SMSInfo 短信分段信息
+type SMSInfo struct {
+	Encoding SMSEncoding `json:"encoding"`
+	Length   int         `json:"length"`   // 按编码计算的字符位数
+	Segments int         `json:"segments"` // 分段数
+}
+
+// AnalyzeSMS 计算短信的编码和分段数
+func AnalyzeSMS(content string) SMSInfo {
+	if content == "" {
+		return SMSInfo{Encoding: SMSEncodingGSM7}
+	}
+
+	length := 0
+	gsm7 := true
+	for _, r := range content {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			length++
+		case strings.ContainsRune(gsm7Extension, r):
+			length += 2
+		default:
+			gsm7 = false
+		}
+		if !gsm7 {
+			break
+		}
+	}
+
+	if gsm7 {
+		return SMSInfo{Encoding: SMSEncodingGSM7, Length: length, Segments: smsSegments(length, gsm7SingleSegment, gsm7MultiSegment)}
+	}
+
+	// UCS-2按UTF-16码元计数，BMP以外的字符占两个
+	length = 0
+	for _, r := range content {
+		if r > 0xFFFF {
+			length += 2
+		} else {
+			length++
+		}
+	}
+	return SMSInfo{Encoding: SMSEncodingUCS2, Length: length, Segments: smsSegments(length, ucs2SingleSegment, ucs2MultiSegment)}
+}
+
+// smsSegments 计算分段数
+func smsSegments(length, single, multi int) int {
+	if length <= single {
+		return 1
+	}
+	return (length + multi - 1) / multi
+}
+
+// SplitSMS 将短信拆分为多条，每条不超过maxSegments段
+// 优先在换行或空格处拆分，避免截断单词
+func SplitSMS(content string, maxSegments int) []string {
+	if maxSegments <= 0 || AnalyzeSMS(content).Segments <= maxSegments {
+		return []string{content}
+	}
+
+	var parts []string
+	runes := []rune(content)
+	for len(runes) > 0 {
+		// 二分查找能放入maxSegments段的最长前缀
+		low, high := 1, len(runes)
+		for low < high {
+			mid := (low + high + 1) / 2
+			if AnalyzeSMS(string(runes[:mid])).Segments <= maxSegments {
+				low = mid
+			} else {
+				high = mid - 1
+			}
+		}
+
+		cut := low
+		if cut < len(runes) {
+			for i := cut; i > cut/2; i-- {
+				if runes[i-1] == '\n' || runes[i-1] == ' ' {
+					cut = i
+					break
+				}
+			}
+		}
+
+		parts = append(parts, strings.TrimRight(string(runes[:cut]), " \n"))
+		runes = runes[cut:]
+	}
+
+	return parts
+}
+
+// SMSMaxSegments 获取单条短信允许的最大分段数
+func (c *ChannelConfig) SMSMaxSegments() int {
+	if value, exists := c.GetConfig(ConfigSMSMaxSegments); exists {
+		if segments, err := strconv.Atoi(value); err == nil && segments > 0 {
+			return segments
+		}
+	}
+	return DefaultSMSMaxSegments
+}
+
+// SMSAutoSplit 超出分段数时是否自动拆分发送
+func (c *ChannelConfig) SMSAutoSplit() bool {
+	value, _ := c.GetConfig(ConfigSMSAutoSplit)
+	autoSplit, _ := strconv.ParseBool(value)
+	return autoSplit
+}
+
+// MaxPayloadBytes 获取请求体的最大字节数，未配置时返回defaultSize
+func (c *ChannelConfig) MaxPayloadBytes(defaultSize int) int {
+	if value, exists := c.GetConfig(ConfigMaxPayloadBytes); exists {
+		if size, err := strconv.Atoi(value); err == nil && size > 0 {
+			return size
+		}
+	}
+	return defaultSize
+}
+
+// ValidateSMSContent 检查短信分段数是否超出限制
+func ValidateSMSContent(content string, maxSegments int) error {
+	info := AnalyzeSMS(content)
+	if maxSegments > 0 && info.Segments > maxSegments {
+		return ErrContentTooLargef(string(ChannelSMS), fmt.Sprintf(
+			"content requires %d segments (%s, %d chars), max %d; shorten the content or enable %s",
+			info.Segments, info.Encoding, info.Length, maxSegments, ConfigSMSAutoSplit))
+	}
+	return nil
+}
+
+// ValidatePayloadSize 检查请求体大小是否超出限制
+func ValidatePayloadSize(channel NotificationChannel, size, maxBytes int) error {
+	if maxBytes > 0 && size > maxBytes {
+		return ErrContentTooLargef(string(channel), fmt.Sprintf(
+			"payload is %d bytes, max %d; shorten the title or content", size, maxBytes))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventNotificationDelivered 通知的所有接收者回执已到达且至少一个送达的事件
+const EventNotificationDelivered = "notification.delivered"
+
+// IsValidReceiptStatus 回执只能报告送达、退信或失败
+func IsValidReceiptStatus(status RecipientStatus) bool {
+	switch status {
+	case RecipientStatusDelivered, RecipientStatusBounced, RecipientStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// ApplyReceipt 根据服务商回执更新接收者状态，返回状态是否发生变化
+// 回执可能早于发送结果落库到达，因此发送中的接收者同样接受回执；
+// 已有最终回执时忽略后续回执，重复或乱序到达的回执不会改变结果
+func (r *Recipient) ApplyReceipt(status RecipientStatus, providerMessageID string, at time.Time) (bool, error) {
+	if !IsValidReceiptStatus(status) {
+		return false, NewDomainErrorWithDetails("INVALID_RECEIPT_STATUS", "Invalid receipt status", fmt.Sprintf("status: %s", status))
+	}
+	if providerMessageID != "" && r.ProviderMessageID != "" && providerMessageID != r.ProviderMessageID {
+		return false, NewDomainErrorWithDetails(ErrRecipientNotFound, "Provider message ID does not match recipient",
+			fmt.Sprintf("recipient_id: %s, provider_message_id: %s", r.ID, providerMessageID))
+	}
+
+	if r.Status != RecipientStatusSending && r.Status != RecipientStatusSent {
+		return false, nil
+	}
+
+	if r.ProviderMessageID == "" {
+		r.ProviderMessageID = providerMessageID
+	}
+	if r.SentAt == nil {
+		r.SentAt = &at
+	}
+
+	r.Status = status
+	r.UpdatedAt = at
+	switch status {
+	case RecipientStatusDelivered:
+		r.DeliveredAt = &at
+	default:
+		r.FailedAt = &at
+		r.ErrorMessage = fmt.Sprintf("provider receipt: %s", status)
+	}
+
+	return true, nil
+}
+
+// ApplyDeliveryReceipts 所有接收者都有最终结果后汇总通知状态，返回状态是否发生变化
+// 至少一个接收者送达时通知为已送达；全部退信或失败时通知为失败，且不再自动重试
+func (n *Notification) ApplyDeliveryReceipts(recipients []*Recipient, now time.Time) bool {
+	if n.Status != NotificationStatusSent || len(recipients) == 0 {
+		return false
+	}
+
+	delivered, undeliverable := 0, 0
+	for _, recipient := range recipients {
+		switch recipient.Status {
+		case RecipientStatusDelivered:
+			delivered++
+		case RecipientStatusBounced, RecipientStatusFailed, RecipientStatusSkipped:
+			undeliverable++
+		default:
+			// 仍有接收者等待回执
+			return false
+		}
+	}
+
+	if delivered > 0 {
+		n.UpdateStatus(NotificationStatusDelivered)
+		if undeliverable > 0 {
+			n.ErrorMessage = fmt.Sprintf("partial delivery: %d/%d delivered", delivered, len(recipients))
+		}
+		return true
+	}
+
+	n.ErrorMessage = fmt.Sprintf("undeliverable: %d recipients bounced or failed", undeliverable)
+	n.UpdateStatus(NotificationStatusFailed)
+	// 退信无法通过重发恢复
+	n.RetryCount = n.MaxRetries
+	n.NextRetryAt = nil
+	n.UpdatedAt = now
+	return true
+}
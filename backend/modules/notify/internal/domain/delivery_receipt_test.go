@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyReceiptOutOfOrder(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// 回执早于发送结果到达
+	recipient := &Recipient{Status: RecipientStatusSending}
+	changed, err := recipient.ApplyReceipt(RecipientStatusDelivered, "msg-1", at)
+	if err != nil || !changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+	if recipient.Status != RecipientStatusDelivered || recipient.ProviderMessageID != "msg-1" {
+		t.Fatalf("status = %s, provider message id = %q", recipient.Status, recipient.ProviderMessageID)
+	}
+	if recipient.SentAt == nil || recipient.DeliveredAt == nil {
+		t.Fatal("sent_at and delivered_at should be set")
+	}
+
+	// 之后到达的退信和重复回执不改变最终结果
+	for _, status := range []RecipientStatus{RecipientStatusBounced, RecipientStatusDelivered} {
+		changed, err = recipient.ApplyReceipt(status, "msg-1", at.Add(time.Minute))
+		if err != nil || changed {
+			t.Fatalf("%s: changed = %v, err = %v", status, changed, err)
+		}
+	}
+	if recipient.Status != RecipientStatusDelivered || !recipient.DeliveredAt.Equal(at) {
+		t.Fatalf("final receipt was overwritten: %s %v", recipient.Status, recipient.DeliveredAt)
+	}
+}
+
+func TestApplyReceiptBounce(t *testing.T) {
+	sentAt := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)
+	recipient := &Recipient{Status: RecipientStatusSent, SentAt: &sentAt, ProviderMessageID: "msg-1"}
+
+	changed, err := recipient.ApplyReceipt(RecipientStatusBounced, "", sentAt.Add(time.Hour))
+	if err != nil || !changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+	if recipient.Status != RecipientStatusBounced || recipient.FailedAt == nil || recipient.ErrorMessage == "" {
+		t.Fatalf("unexpected recipient: %+v", recipient)
+	}
+	if !recipient.SentAt.Equal(sentAt) {
+		t.Fatal("sent_at should not change")
+	}
+}
+
+func TestApplyReceiptRejectsInvalidReceipts(t *testing.T) {
+	var domainErr *DomainError
+
+	recipient := &Recipient{Status: RecipientStatusSent}
+	if _, err := recipient.ApplyReceipt(RecipientStatusSent, "", time.Now()); !errors.As(err, &domainErr) || domainErr.Code != "INVALID_RECEIPT_STATUS" {
+		t.Fatalf("expected invalid receipt status error, got %v", err)
+	}
+
+	recipient.ProviderMessageID = "msg-1"
+	if _, err := recipient.ApplyReceipt(RecipientStatusDelivered, "msg-2", time.Now()); !errors.As(err, &domainErr) || domainErr.Code != ErrRecipientNotFound {
+		t.Fatalf("expected message id mismatch error, got %v", err)
+	}
+	if recipient.Status != RecipientStatusSent {
+		t.Fatalf("status changed to %s", recipient.Status)
+	}
+
+	// 未发送的接收者忽略回执
+	pending := &Recipient{Status: RecipientStatusPending}
+	if changed, err := pending.ApplyReceipt(RecipientStatusDelivered, "", time.Now()); err != nil || changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+}
+
+func TestApplyDeliveryReceipts(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	newSent := func() *Notification {
+		return &Notification{Status: NotificationStatusSent, MaxRetries: 3}
+	}
+
+	// 仍有接收者等待回执时不汇总
+	notification := newSent()
+	if notification.ApplyDeliveryReceipts([]*Recipient{{Status: RecipientStatusDelivered}, {Status: RecipientStatusSent}}, now) {
+		t.Fatal("should wait for all receipts")
+	}
+
+	notification = newSent()
+	if !notification.ApplyDeliveryReceipts([]*Recipient{{Status: RecipientStatusDelivered}, {Status: RecipientStatusBounced}}, now) {
+		t.Fatal("expected status change")
+	}
+	if notification.Status != NotificationStatusDelivered || notification.ErrorMessage != "partial delivery: 1/2 delivered" {
+		t.Fatalf("status = %s, error = %q", notification.Status, notification.ErrorMessage)
+	}
+
+	notification = newSent()
+	if !notification.ApplyDeliveryReceipts([]*Recipient{{Status: RecipientStatusBounced}, {Status: RecipientStatusFailed}}, now) {
+		t.Fatal("expected status change")
+	}
+	if notification.Status != NotificationStatusFailed || notification.CanRetry() || notification.NextRetryAt != nil {
+		t.Fatalf("undeliverable notification should fail without retry: %+v", notification)
+	}
+
+	// 已送达的通知不再汇总
+	if notification.ApplyDeliveryReceipts([]*Recipient{{Status: RecipientStatusDelivered}}, now) {
+		t.Fatal("only sent notifications aggregate receipts")
+	}
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/domain"
+)
+
+// EngagementType 互动类型
+type EngagementType string
+
+const (
+	EngagementOpen  EngagementType = "open"  // 打开邮件（加载追踪像素）
+	EngagementClick EngagementType = "click" // 点击邮件中的链接
+)
+
+// EngagementEvent 接收者的互动事件
+type EngagementEvent struct {
+	domain.Entity
+	NotificationID string         `gorm:"not null;index" json:"notification_id"`
+	RecipientID    string         `gorm:"not null;index" json:"recipient_id"`
+	Type           EngagementType `gorm:"not null" json:"type"`
+	URL            string         `gorm:"type:text" json:"url,omitempty"` // 点击的原始链接
+	UserAgent      string         `json:"user_agent,omitempty"`
+	IPAddress      string         `json:"ip_address,omitempty"`
+	CreatedAt      time.Time      `gorm:"index" json:"created_at"`
+}
+
+// NewEngagementEvent 创建互动事件
+func NewEngagementEvent(notificationID, recipientID string, eventType EngagementType, url string) (*EngagementEvent, error) {
+	if notificationID == "" || recipientID == "" {
+		return nil, NewDomainError("INVALID_ENGAGEMENT", "notification ID and recipient ID are required")
+	}
+	if eventType != EngagementOpen && eventType != EngagementClick {
+		return nil, NewDomainError("INVALID_ENGAGEMENT", "engagement type must be open or click")
+	}
+
+	return &EngagementEvent{
+		Entity:         domain.NewEntity(),
+		NotificationID: notificationID,
+		RecipientID:    recipientID,
+		Type:           eventType,
+		URL:            url,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// EngagementCount 接收者某类互动的聚合结果
+type EngagementCount struct {
+	RecipientID string
+	Type        EngagementType
+	Count       int
+	FirstAt     time.Time
+	LastAt      time.Time
+}
+
+// RecipientEngagement 接收者的互动统计
+type RecipientEngagement struct {
+	RecipientID    string          `json:"recipient_id"`
+	Identifier     string          `json:"identifier"`
+	Status         RecipientStatus `json:"status"`
+	TrackingOptOut bool            `json:"tracking_opt_out,omitempty"`
+	Opens          int             `json:"opens"`
+	Clicks         int             `json:"clicks"`
+	FirstOpenedAt  *time.Time      `json:"first_opened_at,omitempty"`
+	LastOpenedAt   *time.Time      `json:"last_opened_at,omitempty"`
+	FirstClickedAt *time.Time      `json:"first_clicked_at,omitempty"`
+	LastClickedAt  *time.Time      `json:"last_clicked_at,omitempty"`
+}
+
+// Opened 是否打开过，点击过链接也视为打开（邮件客户端可能屏蔽了追踪像素）
+func (r *RecipientEngagement) Opened() bool {
+	return r.Opens > 0 || r.Clicks > 0
+}
+
+// NotificationEngagement 通知的互动统计
+type NotificationEngagement struct {
+	NotificationID string                 `json:"notification_id"`
+	Tracked        int                    `json:"tracked"`       // 已发送且未退出追踪的接收者数，作为比率的分母
+	UniqueOpens    int                    `json:"unique_opens"`  // 打开过的接收者数
+	UniqueClicks   int                    `json:"unique_clicks"` // 点击过的接收者数
+	TotalOpens     int                    `json:"total_opens"`
+	TotalClicks    int                    `json:"total_clicks"`
+	OpenRate       float64                `json:"open_rate"`
+	ClickRate      float64                `json:"click_rate"`
+	Recipients     []*RecipientEngagement `json:"recipients"`
+}
+
+// SummarizeEngagement 汇总通知的互动统计
+// 只有已发送或已送达、且未退出追踪的接收者计入比率的分母
+func SummarizeEngagement(notificationID string, recipients []*Recipient, counts []EngagementCount) *NotificationEngagement {
+	summary := &NotificationEngagement{
+		NotificationID: notificationID,
+		Recipients:     make([]*RecipientEngagement, 0, len(recipients)),
+	}
+
+	byRecipient := make(map[string]*RecipientEngagement, len(recipients))
+	for _, recipient := range recipients {
+		engagement := &RecipientEngagement{
+			RecipientID:    recipient.ID,
+			Identifier:     recipient.Identifier,
+			Status:         recipient.Status,
+			TrackingOptOut: recipient.TrackingOptOut,
+		}
+		byRecipient[recipient.ID] = engagement
+		summary.Recipients = append(summary.Recipients, engagement)
+	}
+
+	for _, count := range counts {
+		engagement, exists := byRecipient[count.RecipientID]
+		if !exists {
+			continue
+		}
+		first, last := count.FirstAt, count.LastAt
+		switch count.Type {
+		case EngagementOpen:
+			engagement.Opens += count.Count
+			engagement.FirstOpenedAt, engagement.LastOpenedAt = &first, &last
+		case EngagementClick:
+			engagement.Clicks += count.Count
+			engagement.FirstClickedAt, engagement.LastClickedAt = &first, &last
+		}
+	}
+
+	for _, engagement := range summary.Recipients {
+		summary.TotalOpens += engagement.Opens
+		summary.TotalClicks += engagement.Clicks
+		if engagement.TrackingOptOut {
+			continue
+		}
+		if engagement.Status != RecipientStatusSent && engagement.Status != RecipientStatusDelivered {
+			continue
+		}
+		summary.Tracked++
+		if engagement.Opened() {
+			summary.UniqueOpens++
+		}
+		if engagement.Clicks > 0 {
+			summary.UniqueClicks++
+		}
+	}
+
+	if summary.Tracked > 0 {
+		summary.OpenRate = float64(summary.UniqueOpens) / float64(summary.Tracked)
+		summary.ClickRate = float64(summary.UniqueClicks) / float64(summary.Tracked)
+	}
+
+	return summary
+}
@@ -1,6 +1,10 @@
 package domain
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // DomainError 通知领域错误
 type DomainError struct {
@@ -46,9 +50,13 @@ const (
 	// 模板相关错误
 	ErrTemplateNotFound            = "TEMPLATE_NOT_FOUND"
 	ErrTemplateAlreadyExists       = "TEMPLATE_ALREADY_EXISTS"
+	ErrTemplateCodeExists          = "TEMPLATE_CODE_EXISTS"
 	ErrTemplateInvalidFormat       = "TEMPLATE_INVALID_FORMAT"
 	ErrTemplateRenderFailed        = "TEMPLATE_RENDER_FAILED"
 	ErrTemplateMissingVariable     = "TEMPLATE_MISSING_VARIABLE"
+	ErrTemplateInvalidVariable     = "TEMPLATE_INVALID_VARIABLE"
+	ErrTemplateChannelNotFound     = "TEMPLATE_CHANNEL_NOT_FOUND"
+	ErrTemplateLocaleNotFound      = "TEMPLATE_LOCALE_NOT_FOUND"
 
 	// 渠道相关错误
 	ErrChannelNotFound             = "CHANNEL_NOT_FOUND"
@@ -56,11 +64,19 @@ const (
 	ErrChannelConfigInvalid        = "CHANNEL_CONFIG_INVALID"
 	ErrChannelRateLimitExceeded    = "CHANNEL_RATE_LIMIT_EXCEEDED"
 	ErrChannelConnectionFailed     = "CHANNEL_CONNECTION_FAILED"
+	ErrContentTooLarge             = "CONTENT_TOO_LARGE"
 
 	// 接收者相关错误
 	ErrRecipientNotFound           = "RECIPIENT_NOT_FOUND"
 	ErrRecipientInvalidAddress     = "RECIPIENT_INVALID_ADDRESS"
 	ErrRecipientDeliveryFailed     = "RECIPIENT_DELIVERY_FAILED"
+	ErrNoRecipients                = "NO_RECIPIENTS"
+
+	// 接收者组相关错误
+	ErrRecipientGroupNotFound       = "RECIPIENT_GROUP_NOT_FOUND"
+	ErrRecipientGroupExists         = "RECIPIENT_GROUP_EXISTS"
+	ErrRecipientGroupMemberExists   = "RECIPIENT_GROUP_MEMBER_EXISTS"
+	ErrRecipientGroupMemberNotFound = "RECIPIENT_GROUP_MEMBER_NOT_FOUND"
 
 	// 验证相关错误
 	ErrInvalidEmail                = "INVALID_EMAIL"
@@ -68,6 +84,12 @@ const (
 	ErrInvalidTemplate             = "INVALID_TEMPLATE"
 	ErrInvalidChannel              = "INVALID_CHANNEL"
 	ErrInvalidPriority             = "INVALID_PRIORITY"
+	ErrInvalidScheduleWindow       = "INVALID_SCHEDULE_WINDOW"
+	ErrInvalidLocale               = "INVALID_LOCALE"
+	ErrInvalidCursor               = "INVALID_CURSOR"
+
+	// 互动追踪相关错误
+	ErrInvalidTrackingToken        = "INVALID_TRACKING_TOKEN"
 
 	// 权限相关错误
 	ErrPermissionDenied            = "PERMISSION_DENIED"
@@ -95,6 +117,22 @@ func ErrTemplateNotFoundf(templateID string) *DomainError {
 	return NewDomainErrorWithDetails(ErrTemplateNotFound, "Template not found", fmt.Sprintf("template_id: %s", templateID))
 }
 
+func ErrTemplateCodeExistsf(code string) *DomainError {
+	return NewDomainErrorWithDetails(ErrTemplateCodeExists, "Template code already exists", fmt.Sprintf("code: %s", code))
+}
+
+func ErrTemplateChannelNotFoundf(templateID, channel string) *DomainError {
+	return NewDomainErrorWithDetails(ErrTemplateChannelNotFound, "Template channel not found", fmt.Sprintf("template_id: %s, channel: %s", templateID, channel))
+}
+
+func ErrTemplateLocaleNotFoundf(templateID, locale string) *DomainError {
+	return NewDomainErrorWithDetails(ErrTemplateLocaleNotFound, "Template localization not found", fmt.Sprintf("template_id: %s, locale: %s", templateID, locale))
+}
+
+func ErrInvalidLocalef(locale string) *DomainError {
+	return NewDomainErrorWithDetails(ErrInvalidLocale, "Invalid BCP-47 locale", fmt.Sprintf("locale: %s", locale))
+}
+
 func ErrChannelNotFoundf(channel string) *DomainError {
 	return NewDomainErrorWithDetails(ErrChannelNotFound, "Channel not found", fmt.Sprintf("channel: %s", channel))
 }
@@ -122,3 +160,59 @@ func ErrMissingConfigf(field string) *DomainError {
 func ErrRateLimitExceededf(channel string, limit string) *DomainError {
 	return NewDomainErrorWithDetails(ErrChannelRateLimitExceeded, "Rate limit exceeded", fmt.Sprintf("channel: %s, limit: %s", channel, limit))
 }
+
+func ErrChannelRateLimitedf(channel string, retryAfter time.Duration) *DomainError {
+	return NewDomainErrorWithDetails(ErrChannelRateLimitExceeded, "Channel provider rate limited", fmt.Sprintf("channel: %s, retry_after: %s", channel, retryAfter))
+}
+
+func ErrContentTooLargef(channel, details string) *DomainError {
+	return NewDomainErrorWithDetails(ErrContentTooLarge, "Content exceeds channel limit", fmt.Sprintf("channel: %s, %s", channel, details))
+}
+
+// ErrRateLimited 渠道发送被限流器拒绝，接收者保持待发送状态，通知延后到RetryAfter之后发送，不计为失败
+type ErrRateLimited struct {
+	Channel    string
+	OwnerID    string
+	Limit      int // 每分钟最大发送次数
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("%s: channel %s rate limited (limit: %d/min, retry_after: %s)",
+		ErrChannelRateLimitExceeded, e.Channel, e.Limit, e.RetryAfter)
+}
+
+// DeferredUntil 判断错误是否表示延后发送：接收者处于免打扰时段或发送被渠道限流器拒绝
+// 返回最早可以再次发送的时间；延后发送的接收者保持待发送状态，不计入重试次数
+func DeferredUntil(err error, now time.Time) (time.Time, bool) {
+	var quietHoursErr *ErrQuietHours
+	if errors.As(err, &quietHoursErr) {
+		return quietHoursErr.Until, true
+	}
+
+	var rateLimitedErr *ErrRateLimited
+	if errors.As(err, &rateLimitedErr) {
+		retryAfter := rateLimitedErr.RetryAfter
+		if retryAfter < time.Second {
+			retryAfter = time.Second
+		}
+		return now.Add(retryAfter), true
+	}
+	return time.Time{}, false
+}
+
+// IsRetryableError 判断错误是否为可重试的临时错误（服务商限流、连接失败、服务不可用）
+// 渠道限流器拒绝的发送延后进行，见DeferredUntil
+func IsRetryableError(err error) bool {
+	var domainErr *DomainError
+	if !errors.As(err, &domainErr) {
+		return false
+	}
+
+	switch domainErr.Code {
+	case ErrChannelRateLimitExceeded, ErrChannelConnectionFailed, ErrServiceUnavailable:
+		return true
+	default:
+		return false
+	}
+}
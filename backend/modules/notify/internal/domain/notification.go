@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/noah-loop/backend/shared/pkg/domain"
+	"gorm.io/gorm"
 )
 
 // NotificationStatus 通知状态
@@ -60,11 +61,30 @@ type Notification struct {
 	ErrorMessage     string               `json:"error_message,omitempty"`
 	RetryCount       int                  `json:"retry_count"`
 	MaxRetries       int                  `gorm:"default:3" json:"max_retries"`
-	CreatedBy        string               `gorm:"index" json:"created_by"`
+	NextRetryAt      *time.Time           `gorm:"index" json:"next_retry_at,omitempty"`   // 下次自动重试时间
+	SLASeconds       int                  `gorm:"default:0" json:"sla_seconds,omitempty"` // 最长投递时间，0表示不监控
+	SLABreachedAt    *time.Time           `json:"sla_breached_at,omitempty"`
+	DryRun           bool                 `gorm:"default:false" json:"dry_run,omitempty"` // 模拟发送：完整执行发送流程，但不联系服务商
+	IdempotencyKey   string               `gorm:"uniqueIndex:idx_notification_idempotency,priority:2,where:idempotency_key <> '' AND deleted_at IS NULL" json:"idempotency_key,omitempty"` // 同一创建者的未删除通知内唯一，用于客户端重试去重
+	CreatedBy        string               `gorm:"index;uniqueIndex:idx_notification_idempotency,priority:1" json:"created_by"`
 	CreatedAt        time.Time            `json:"created_at"`
 	UpdatedAt        time.Time            `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt       `gorm:"index" json:"-"` // 软删除时间，查询自动排除已删除的通知
 }
 
+// EventNotificationSLABreached 通知超过SLA仍未发送的事件
+const EventNotificationSLABreached = "notification.sla_breached"
+
+// DefaultSLAByType 各通知类型的默认SLA，未列出的类型默认不监控
+var DefaultSLAByType = map[NotificationType]time.Duration{
+	NotificationTypeAlert:  5 * time.Minute,
+	NotificationTypeVerify: 5 * time.Minute,
+	NotificationTypeSystem: 15 * time.Minute,
+}
+
+// MetadataTemplateType 自定义元数据中记录模板类型的键
+const MetadataTemplateType = "template_type"
+
 // NotificationMetadata 通知元数据
 type NotificationMetadata struct {
 	Source          string            `json:"source,omitempty"`          // 来源系统
@@ -101,6 +121,58 @@ func (n *Notification) UpdateStatus(status NotificationStatus) error {
 	return nil
 }
 
+// ContentFormat 获取内容格式，从模板创建的通知沿用模板类型，否则视为纯文本
+func (n *Notification) ContentFormat() TemplateType {
+	if n.Metadata.Custom != nil {
+		if templateType, exists := n.Metadata.Custom[MetadataTemplateType]; exists && templateType != "" {
+			return TemplateType(templateType)
+		}
+	}
+	
+	return TemplateTypeText
+}
+
+// SetSLA 设置SLA，传入0时使用通知类型的默认值
+func (n *Notification) SetSLA(sla time.Duration) {
+	if sla <= 0 {
+		sla = DefaultSLAByType[n.Type]
+	}
+	n.SLASeconds = int(sla / time.Second)
+}
+
+// SLADeadline 获取SLA截止时间，定时通知从计划发送时间开始计算
+func (n *Notification) SLADeadline() (time.Time, bool) {
+	if n.SLASeconds <= 0 {
+		return time.Time{}, false
+	}
+	
+	start := n.CreatedAt
+	if n.ScheduledAt != nil && n.ScheduledAt.After(start) {
+		start = *n.ScheduledAt
+	}
+	
+	return start.Add(time.Duration(n.SLASeconds) * time.Second), true
+}
+
+// IsSLABreached 是否已超过SLA仍处于待发送或发送中，每个通知只报告一次
+func (n *Notification) IsSLABreached(now time.Time) bool {
+	if n.SLABreachedAt != nil {
+		return false
+	}
+	if n.Status != NotificationStatusPending && n.Status != NotificationStatusSending {
+		return false
+	}
+	
+	deadline, ok := n.SLADeadline()
+	return ok && now.After(deadline)
+}
+
+// MarkSLABreached 记录SLA违约时间
+func (n *Notification) MarkSLABreached(now time.Time) {
+	n.SLABreachedAt = &now
+	n.UpdatedAt = now
+}
+
 // CanRetry 是否可以重试
 func (n *Notification) CanRetry() bool {
 	return n.Status == NotificationStatusFailed && n.RetryCount < n.MaxRetries
@@ -136,13 +208,35 @@ func (n *Notification) SetError(err error) {
 	n.UpdateStatus(NotificationStatusFailed)
 }
 
+// ScheduleRetry 按退避策略计算下次自动重试时间，已达到最大重试次数时清空
+func (n *Notification) ScheduleRetry(backoff RetryBackoff, now time.Time) {
+	if !n.CanRetry() {
+		n.NextRetryAt = nil
+		return
+	}
+	
+	nextRetryAt := now.Add(backoff.NextDelay(n.RetryCount))
+	n.NextRetryAt = &nextRetryAt
+	n.UpdatedAt = now
+}
+
+// Defer 延后到指定时间发送，由定时任务在到期后重新发送待发送的接收者
+func (n *Notification) Defer(until time.Time) error {
+	if err := n.UpdateStatus(NotificationStatusPending); err != nil {
+		return err
+	}
+	
+	n.ScheduledAt = &until
+	return nil
+}
+
 // isValidStatusTransition 检查状态转换是否有效
 func (n *Notification) isValidStatusTransition(from, to NotificationStatus) bool {
 	validTransitions := map[NotificationStatus][]NotificationStatus{
 		NotificationStatusPending: {NotificationStatusSending, NotificationStatusCancelled},
-		NotificationStatusSending: {NotificationStatusSent, NotificationStatusFailed},
-		NotificationStatusSent:    {NotificationStatusDelivered, NotificationStatusFailed},
-		NotificationStatusFailed:  {NotificationStatusSending}, // 可以重试
+		NotificationStatusSending: {NotificationStatusSent, NotificationStatusFailed, NotificationStatusPending}, // 免打扰时段延后发送
+		NotificationStatusSent:    {NotificationStatusDelivered, NotificationStatusFailed, NotificationStatusPending}, // 部分接收者失败时重试
+		NotificationStatusFailed:  {NotificationStatusPending, NotificationStatusSending}, // 可以重试
 		NotificationStatusDelivered: {}, // 终态
 		NotificationStatusCancelled: {}, // 终态
 	}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfigQuietHoursBypassPriority 不受免打扰时段限制的最低优先级，默认只有紧急通知不受限制
+const ConfigQuietHoursBypassPriority = "quiet_hours_bypass_priority"
+
+// quietHoursLayout 免打扰时段的时间格式
+const quietHoursLayout = "15:04"
+
+// priorityRanks 通知优先级的高低顺序
+var priorityRanks = map[NotificationPriority]int{
+	NotificationPriorityLow:    1,
+	NotificationPriorityNormal: 2,
+	NotificationPriorityHigh:   3,
+	NotificationPriorityUrgent: 4,
+}
+
+// Rank 优先级高低，未知优先级按普通处理
+func (p NotificationPriority) Rank() int {
+	if rank, exists := priorityRanks[p]; exists {
+		return rank
+	}
+	return priorityRanks[NotificationPriorityNormal]
+}
+
+// QuietHours 接收者的免打扰时段，按接收者时区的本地时间计算
+// Start晚于End时表示跨越午夜，如 22:00-08:00
+type QuietHours struct {
+	Start    string `json:"start"`              // 开始时间，HH:MM
+	End      string `json:"end"`                // 结束时间，HH:MM
+	Timezone string `json:"timezone,omitempty"` // IANA时区，如 Asia/Shanghai，默认UTC
+}
+
+// Validate 验证免打扰时段配置
+func (q *QuietHours) Validate() error {
+	if _, err := time.Parse(quietHoursLayout, q.Start); err != nil {
+		return NewDomainErrorWithDetails(ErrInvalidConfig, "Invalid quiet hours start", fmt.Sprintf("start: %s", q.Start))
+	}
+	if _, err := time.Parse(quietHoursLayout, q.End); err != nil {
+		return NewDomainErrorWithDetails(ErrInvalidConfig, "Invalid quiet hours end", fmt.Sprintf("end: %s", q.End))
+	}
+	if _, err := q.location(); err != nil {
+		return NewDomainErrorWithDetails(ErrInvalidConfig, "Invalid quiet hours timezone", fmt.Sprintf("timezone: %s", q.Timezone))
+	}
+	return nil
+}
+
+// NextAllowedTime 返回now之后允许发送的最早时间，不在免打扰时段内时返回now
+func (q *QuietHours) NextAllowedTime(now time.Time) time.Time {
+	if q == nil || q.Validate() != nil || q.Start == q.End {
+		return now
+	}
+
+	loc, _ := q.location()
+	local := now.In(loc)
+	start, _ := time.Parse(quietHoursLayout, q.Start)
+	end, _ := time.Parse(quietHoursLayout, q.End)
+
+	// 从前一天开始检查，覆盖跨越午夜且开始于前一天的时段
+	for offset := -1; offset <= 0; offset++ {
+		day := local.AddDate(0, 0, offset)
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		windowEnd := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+		if !windowEnd.After(windowStart) {
+			windowEnd = time.Date(day.Year(), day.Month(), day.Day()+1, end.Hour(), end.Minute(), 0, 0, loc)
+		}
+
+		if !local.Before(windowStart) && local.Before(windowEnd) {
+			return windowEnd.In(now.Location())
+		}
+	}
+
+	return now
+}
+
+// location 解析时区
+func (q *QuietHours) location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(q.Timezone)
+}
+
+// QuietHoursBypassPriority 获取不受免打扰时段限制的最低优先级
+func (c *ChannelConfig) QuietHoursBypassPriority() NotificationPriority {
+	if value, exists := c.GetConfig(ConfigQuietHoursBypassPriority); exists {
+		if _, valid := priorityRanks[NotificationPriority(value)]; valid {
+			return NotificationPriority(value)
+		}
+	}
+	return NotificationPriorityUrgent
+}
+
+// QuietHoursDeferral 计算通知是否需要因接收者的免打扰时段延后发送
+// 优先级不低于bypass的通知以及紧急通知不受限制
+func QuietHoursDeferral(notification *Notification, recipient *Recipient, bypass NotificationPriority, now time.Time) (time.Time, bool) {
+	if recipient.QuietHours == nil ||
+		notification.Priority == NotificationPriorityUrgent ||
+		notification.Priority.Rank() >= bypass.Rank() {
+		return now, false
+	}
+
+	next := recipient.QuietHours.NextAllowedTime(now)
+	return next, next.After(now)
+}
+
+// ErrQuietHours 接收者处于免打扰时段，接收者保持待发送状态，通知延后到时段结束后发送
+type ErrQuietHours struct {
+	RecipientID string
+	Until       time.Time
+}
+
+func (e *ErrQuietHours) Error() string {
+	return fmt.Sprintf("recipient %s is in quiet hours until %s", e.RecipientID, e.Until.Format(time.RFC3339))
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestQuietHoursSpanningMidnight(t *testing.T) {
+	quietHours := &QuietHours{Start: "22:00", End: "08:00"}
+
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		// 开始前不延后
+		{time.Date(2024, 3, 1, 21, 59, 0, 0, time.UTC), time.Date(2024, 3, 1, 21, 59, 0, 0, time.UTC)},
+		// 午夜前延后到次日结束时间
+		{time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC), time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)},
+		// 午夜后延后到当天结束时间
+		{time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)},
+		// 结束时间不在时段内
+		{time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := quietHours.NextAllowedTime(c.now); !got.Equal(c.want) {
+			t.Fatalf("now %s: next allowed = %s, want %s", c.now, got, c.want)
+		}
+	}
+}
+
+func TestQuietHoursSameDayWindow(t *testing.T) {
+	quietHours := &QuietHours{Start: "12:00", End: "14:00"}
+
+	now := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+	if got := quietHours.NextAllowedTime(now); !got.Equal(time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("next allowed = %s", got)
+	}
+	now = time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	if got := quietHours.NextAllowedTime(now); !got.Equal(now) {
+		t.Fatalf("outside window should not defer, got %s", got)
+	}
+}
+
+func TestQuietHoursTimezones(t *testing.T) {
+	// 15:00 UTC 为上海时间 23:00、纽约时间 10:00（夏令时）
+	now := time.Date(2024, 7, 1, 15, 0, 0, 0, time.UTC)
+
+	shanghai := &QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Shanghai"}
+	if got := shanghai.NextAllowedTime(now); !got.Equal(time.Date(2024, 7, 1, 23, 0, 0, 0, time.UTC)) {
+		t.Fatalf("shanghai next allowed = %s, want 2024-07-01 23:00 UTC", got)
+	}
+	if got := shanghai.NextAllowedTime(now); got.Location() != time.UTC {
+		t.Fatalf("result should use the location of now, got %s", got.Location())
+	}
+
+	newYork := &QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"}
+	if got := newYork.NextAllowedTime(now); !got.Equal(now) {
+		t.Fatalf("new york should not defer, got %s", got)
+	}
+}
+
+func TestQuietHoursValidate(t *testing.T) {
+	valid := &QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, quietHours := range []*QuietHours{
+		{Start: "25:00", End: "07:00"},
+		{Start: "22:00", End: "7am"},
+		{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"},
+	} {
+		err := quietHours.Validate()
+		var domainErr *DomainError
+		if !errors.As(err, &domainErr) || domainErr.Code != ErrInvalidConfig {
+			t.Fatalf("%+v: expected invalid config error, got %v", quietHours, err)
+		}
+		// 无效配置不延后发送
+		now := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+		if got := quietHours.NextAllowedTime(now); !got.Equal(now) {
+			t.Fatalf("%+v: invalid quiet hours should not defer", quietHours)
+		}
+	}
+}
+
+func TestQuietHoursDeferralPriority(t *testing.T) {
+	now := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	recipient := &Recipient{QuietHours: &QuietHours{Start: "22:00", End: "08:00"}}
+	morning := time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		priority NotificationPriority
+		bypass   NotificationPriority
+		deferred bool
+	}{
+		{NotificationPriorityNormal, NotificationPriorityUrgent, true},
+		{NotificationPriorityHigh, NotificationPriorityUrgent, true},
+		{NotificationPriorityUrgent, NotificationPriorityUrgent, false},
+		{NotificationPriorityHigh, NotificationPriorityHigh, false},
+		// 紧急通知始终不受限制
+		{NotificationPriorityUrgent, NotificationPriority("unknown"), false},
+	}
+	for _, c := range cases {
+		until, deferred := QuietHoursDeferral(&Notification{Priority: c.priority}, recipient, c.bypass, now)
+		if deferred != c.deferred {
+			t.Fatalf("priority %s, bypass %s: deferred = %v, want %v", c.priority, c.bypass, deferred, c.deferred)
+		}
+		if deferred && !until.Equal(morning) {
+			t.Fatalf("deferred until %s, want %s", until, morning)
+		}
+	}
+
+	if _, deferred := QuietHoursDeferral(&Notification{Priority: NotificationPriorityLow}, &Recipient{}, NotificationPriorityUrgent, now); deferred {
+		t.Fatal("recipient without quiet hours should not be deferred")
+	}
+}
+
+func TestChannelConfigQuietHoursBypassPriority(t *testing.T) {
+	config := &ChannelConfig{Config: map[string]string{ConfigQuietHoursBypassPriority: "high"}}
+	if priority := config.QuietHoursBypassPriority(); priority != NotificationPriorityHigh {
+		t.Fatalf("bypass priority = %s", priority)
+	}
+	config.Config[ConfigQuietHoursBypassPriority] = "critical"
+	if priority := config.QuietHoursBypassPriority(); priority != NotificationPriorityUrgent {
+		t.Fatalf("invalid bypass priority should default to urgent, got %s", priority)
+	}
+}
@@ -29,7 +29,13 @@ type Recipient struct {
 	Name           string            `json:"name"`                       // 接收者名称
 	Channel        NotificationChannel `gorm:"not null" json:"channel"`
 	Address        string            `json:"address"`                    // 接收地址（邮箱、手机号等）
+	Locale         string            `json:"locale,omitempty"`           // 语言区域，如zh-CN
 	Variables      map[string]string `gorm:"serializer:json" json:"variables,omitempty"` // 个性化变量
+	QuietHours     *QuietHours       `gorm:"serializer:json" json:"quiet_hours,omitempty"` // 免打扰时段
+	TrackingOptOut bool              `gorm:"default:false" json:"tracking_opt_out,omitempty"` // 不追踪邮件打开和链接点击
+	ProviderMessageID string         `gorm:"index" json:"provider_message_id,omitempty"`  // 服务商消息ID，用于关联投递回执
+	DryRunPayload  string            `gorm:"type:text" json:"dry_run_payload,omitempty"` // 模拟发送时本应发给服务商的请求（JSON）
+	SentParts      int               `gorm:"default:0" json:"sent_parts,omitempty"` // 拆分发送的消息中已成功发送的条数，重试时跳过这些消息
 	Status         RecipientStatus   `gorm:"not null;default:'pending'" json:"status"`
 	SentAt         *time.Time        `json:"sent_at,omitempty"`
 	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
@@ -50,6 +56,7 @@ const (
 	RecipientStatusDelivered RecipientStatus = "delivered" // 已送达
 	RecipientStatusFailed    RecipientStatus = "failed"    // 发送失败
 	RecipientStatusSkipped   RecipientStatus = "skipped"   // 跳过
+	RecipientStatusBounced   RecipientStatus = "bounced"   // 服务商回执报告退信
 )
 
 // UpdateStatus 更新接收者状态
@@ -79,6 +86,13 @@ func (r *Recipient) SetError(err error) {
 	r.UpdateStatus(RecipientStatusFailed)
 }
 
+// MarkForRetry 记录临时错误并重置为待发送，等待下一次重试
+func (r *Recipient) MarkForRetry(err error) {
+	r.ErrorMessage = err.Error()
+	r.RetryCount++
+	r.UpdateStatus(RecipientStatusPending)
+}
+
 // IsValid 验证接收者信息是否有效
 func (r *Recipient) IsValid() error {
 	if r.Identifier == "" {
@@ -96,9 +110,54 @@ func (r *Recipient) IsValid() error {
 		}
 	}
 	
+	if r.QuietHours != nil {
+		if err := r.QuietHours.Validate(); err != nil {
+			return err
+		}
+	}
+	
 	return nil
 }
 
+// 接收者资料对应的模板变量名
+const (
+	ProfileVariableName   = "name"
+	ProfileVariableEmail  = "email"
+	ProfileVariablePhone  = "phone"
+	ProfileVariableLocale = "locale"
+)
+
+// IsProfileVariable 判断变量是否由接收者资料提供
+func IsProfileVariable(name string) bool {
+	switch name {
+	case ProfileVariableName, ProfileVariableEmail, ProfileVariablePhone, ProfileVariableLocale:
+		return true
+	}
+	return false
+}
+
+// ProfileVariables 从接收者资料生成模板变量，只包含有值的字段
+// 渲染模板时优先级低于调用方传入的变量，模板中可直接使用{{name}}等变量
+func (r *Recipient) ProfileVariables() map[string]string {
+	profile := make(map[string]string, 4)
+	if r.Name != "" {
+		profile[ProfileVariableName] = r.Name
+	}
+	if isValidEmail(r.Address) {
+		profile[ProfileVariableEmail] = r.Address
+	} else if isValidEmail(r.Identifier) {
+		profile[ProfileVariableEmail] = r.Identifier
+	}
+	// 手机号格式较宽松，只取手机号类型接收者的地址
+	if r.Type == RecipientTypePhone {
+		profile[ProfileVariablePhone] = r.GetEffectiveAddress()
+	}
+	if r.Locale != "" {
+		profile[ProfileVariableLocale] = r.Locale
+	}
+	return profile
+}
+
 // GetEffectiveAddress 获取有效的接收地址
 func (r *Recipient) GetEffectiveAddress() string {
 	if r.Address != "" {
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/domain"
+)
+
+// RecipientGroup 接收者组（通讯组），同一所有者内名称唯一
+type RecipientGroup struct {
+	domain.Entity
+	Name        string                 `gorm:"not null;uniqueIndex:idx_recipient_group_owner_name,priority:2" json:"name"`
+	Description string                 `json:"description,omitempty"`
+	OwnerID     string                 `gorm:"not null;uniqueIndex:idx_recipient_group_owner_name,priority:1" json:"owner_id"`
+	Members     []RecipientGroupMember `gorm:"foreignKey:GroupID" json:"members"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// RecipientGroupMember 接收者组成员
+type RecipientGroupMember struct {
+	domain.Entity
+	GroupID    string                `gorm:"not null;uniqueIndex:idx_recipient_group_member,priority:1" json:"group_id"`
+	Type       RecipientType         `gorm:"not null;uniqueIndex:idx_recipient_group_member,priority:2" json:"type"`
+	Identifier string                `gorm:"not null;uniqueIndex:idx_recipient_group_member,priority:3" json:"identifier"`
+	Name       string                `json:"name,omitempty"`
+	Address    string                `json:"address,omitempty"`
+	Locale     string                `json:"locale,omitempty"`
+	Channels   []NotificationChannel `gorm:"serializer:json" json:"channels,omitempty"` // 接收的渠道，为空表示接收所有渠道
+	Variables  map[string]string     `gorm:"serializer:json" json:"variables,omitempty"`
+	QuietHours *QuietHours           `gorm:"serializer:json" json:"quiet_hours,omitempty"`
+	TrackingOptOut bool              `gorm:"default:false" json:"tracking_opt_out,omitempty"` // 不追踪邮件打开和链接点击
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+// NewRecipientGroup 创建接收者组
+func NewRecipientGroup(name, description, ownerID string) (*RecipientGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, NewDomainError("INVALID_GROUP_NAME", "recipient group name cannot be empty")
+	}
+	if ownerID == "" {
+		return nil, NewDomainError("INVALID_OWNER", "recipient group owner cannot be empty")
+	}
+
+	return &RecipientGroup{
+		Entity:      domain.NewEntity(),
+		Name:        name,
+		Description: description,
+		OwnerID:     ownerID,
+		Members:     make([]RecipientGroupMember, 0),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+// NewRecipientGroupMember 创建接收者组成员
+func NewRecipientGroupMember(groupID string, recipientType RecipientType, identifier string) (*RecipientGroupMember, error) {
+	if identifier == "" {
+		return nil, NewDomainError("INVALID_IDENTIFIER", "recipient identifier cannot be empty")
+	}
+
+	return &RecipientGroupMember{
+		Entity:     domain.NewEntity(),
+		GroupID:    groupID,
+		Type:       recipientType,
+		Identifier: identifier,
+		Variables:  make(map[string]string),
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// IsOwnedBy 判断接收者组是否属于指定用户
+func (g *RecipientGroup) IsOwnedBy(ownerID string) bool {
+	return ownerID != "" && g.OwnerID == ownerID
+}
+
+// AddMember 添加成员，类型和标识相同的成员已存在时返回错误
+func (g *RecipientGroup) AddMember(member RecipientGroupMember) error {
+	if g.FindMember(member.Type, member.Identifier) != nil {
+		return ErrRecipientGroupMemberExistsf(g.Name, member.Identifier)
+	}
+	if member.QuietHours != nil {
+		if err := member.QuietHours.Validate(); err != nil {
+			return err
+		}
+	}
+
+	member.GroupID = g.ID
+	g.Members = append(g.Members, member)
+	g.UpdatedAt = time.Now()
+	return nil
+}
+
+// RemoveMember 移除成员
+func (g *RecipientGroup) RemoveMember(memberID string) error {
+	for i, member := range g.Members {
+		if member.ID == memberID {
+			g.Members = append(g.Members[:i], g.Members[i+1:]...)
+			g.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return ErrRecipientGroupMemberNotFoundf(g.Name, memberID)
+}
+
+// FindMember 按类型和标识查找成员
+func (g *RecipientGroup) FindMember(recipientType RecipientType, identifier string) *RecipientGroupMember {
+	for i := range g.Members {
+		if g.Members[i].Type == recipientType && g.Members[i].Identifier == identifier {
+			return &g.Members[i]
+		}
+	}
+	return nil
+}
+
+// MembersForChannel 获取接收指定渠道的成员
+func (g *RecipientGroup) MembersForChannel(channel NotificationChannel) []RecipientGroupMember {
+	members := make([]RecipientGroupMember, 0, len(g.Members))
+	for _, member := range g.Members {
+		if member.AcceptsChannel(channel) {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// AcceptsChannel 判断成员是否接收指定渠道
+func (m *RecipientGroupMember) AcceptsChannel(channel NotificationChannel) bool {
+	if len(m.Channels) == 0 {
+		return true
+	}
+	for _, c := range m.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrRecipientGroupNotFoundf 接收者组不存在
+func ErrRecipientGroupNotFoundf(group string) *DomainError {
+	return NewDomainErrorWithDetails(ErrRecipientGroupNotFound, "Recipient group not found", fmt.Sprintf("group: %s", group))
+}
+
+// ErrRecipientGroupExistsf 接收者组名称已存在
+func ErrRecipientGroupExistsf(name string) *DomainError {
+	return NewDomainErrorWithDetails(ErrRecipientGroupExists, "Recipient group already exists", fmt.Sprintf("name: %s", name))
+}
+
+// ErrRecipientGroupMemberExistsf 成员已在接收者组中
+func ErrRecipientGroupMemberExistsf(group, identifier string) *DomainError {
+	return NewDomainErrorWithDetails(ErrRecipientGroupMemberExists, "Recipient group member already exists", fmt.Sprintf("group: %s, identifier: %s", group, identifier))
+}
+
+// ErrRecipientGroupMemberNotFoundf 成员不在接收者组中
+func ErrRecipientGroupMemberNotFoundf(group, memberID string) *DomainError {
+	return NewDomainErrorWithDetails(ErrRecipientGroupMemberNotFound, "Recipient group member not found", fmt.Sprintf("group: %s, member_id: %s", group, memberID))
+}
@@ -0,0 +1,94 @@
+package domain
+
+import "time"
+
+// RecipientSummary 通知接收者的发送状态汇总，用于计算通知的整体状态
+type RecipientSummary struct {
+	Total   int
+	Sent    int // 已发送（含已送达和退信），重试时不会再次发送
+	Pending int // 待发送或发送中，包括临时错误后等待重试的接收者
+	Failed  int // 发送失败
+	Skipped int // 已跳过
+}
+
+// SummarizeRecipients 按接收者状态汇总
+func SummarizeRecipients(recipients []*Recipient) RecipientSummary {
+	summary := RecipientSummary{Total: len(recipients)}
+	for _, recipient := range recipients {
+		switch recipient.Status {
+		case RecipientStatusSent, RecipientStatusDelivered, RecipientStatusBounced:
+			summary.Sent++
+		case RecipientStatusPending, RecipientStatusSending:
+			summary.Pending++
+		case RecipientStatusFailed:
+			summary.Failed++
+		case RecipientStatusSkipped:
+			summary.Skipped++
+		}
+	}
+	return summary
+}
+
+// CanRetry 发送失败且重试次数未达到上限的接收者可以重试
+func (r *Recipient) CanRetry(maxRetries int) bool {
+	return r.Status == RecipientStatusFailed && r.RetryCount < maxRetries
+}
+
+// ResetForRetry 将失败的接收者重置为待发送，保留重试次数和最近一次错误
+func (r *Recipient) ResetForRetry() error {
+	if r.Status != RecipientStatusFailed {
+		return NewDomainError("INVALID_STATUS_TRANSITION", "only failed recipients can be retried")
+	}
+
+	r.Status = RecipientStatusPending
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// PrepareRetry 准备重试：将可以重试的失败接收者重置为待发送并返回，通知重置为待发送
+// 已发送的接收者保持不变，重试时不会再次发送；失败的通知和部分发送成功的通知都可以重试
+// 没有可以重试的接收者时通知不再自动重试
+func (n *Notification) PrepareRetry(recipients []*Recipient) ([]*Recipient, error) {
+	switch n.Status {
+	case NotificationStatusFailed:
+		if n.RetryCount >= n.MaxRetries {
+			return nil, NewDomainError("CANNOT_RETRY", "notification cannot be retried")
+		}
+	case NotificationStatusSent:
+	default:
+		return nil, NewDomainError("CANNOT_RETRY", "notification cannot be retried")
+	}
+
+	var reset []*Recipient
+	pending := 0
+	for _, recipient := range recipients {
+		switch {
+		case recipient.CanRetry(n.MaxRetries):
+			reset = append(reset, recipient)
+		case recipient.Status == RecipientStatusPending:
+			pending++
+		}
+	}
+
+	if len(reset) == 0 && pending == 0 {
+		if n.Status == NotificationStatusFailed {
+			n.RetryCount = n.MaxRetries
+			n.NextRetryAt = nil
+			n.UpdatedAt = time.Now()
+		}
+		return nil, NewDomainError("CANNOT_RETRY", "no failed recipients to retry")
+	}
+
+	if err := n.UpdateStatus(NotificationStatusPending); err != nil {
+		return nil, err
+	}
+	n.NextRetryAt = nil
+
+	for _, recipient := range reset {
+		if err := recipient.ResetForRetry(); err != nil {
+			return nil, err
+		}
+	}
+
+	return reset, nil
+}
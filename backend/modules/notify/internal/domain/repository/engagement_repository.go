@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+)
+
+// EngagementRepository 互动事件仓储接口
+type EngagementRepository interface {
+	Save(ctx context.Context, event *domain.EngagementEvent) error
+	// CountByNotificationID 按接收者和互动类型聚合通知的互动事件
+	CountByNotificationID(ctx context.Context, notificationID string) ([]domain.EngagementCount, error)
+}
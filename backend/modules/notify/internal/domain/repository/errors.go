@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// ErrDuplicateKey 违反唯一约束
+// 仓储实现需要将数据库的唯一约束冲突转换为该错误（可包装），由应用层转换为具体的领域错误
+var ErrDuplicateKey = errors.New("duplicate key")
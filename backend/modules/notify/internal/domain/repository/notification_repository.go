@@ -2,15 +2,19 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/pagination"
 )
 
 // NotificationRepository 通知仓储接口
 type NotificationRepository interface {
 	// 基本CRUD操作
+	// Save 幂等键重复时返回ErrDuplicateKey
 	Save(ctx context.Context, notification *domain.Notification) error
 	FindByID(ctx context.Context, id string) (*domain.Notification, error)
+	FindByIdempotencyKey(ctx context.Context, createdBy, idempotencyKey string) (*domain.Notification, error)
 	Update(ctx context.Context, notification *domain.Notification) error
 	Delete(ctx context.Context, id string) error
 
@@ -26,11 +30,29 @@ type NotificationRepository interface {
 	FindByStatusWithPagination(ctx context.Context, status domain.NotificationStatus, offset, limit int) ([]*domain.Notification, int64, error)
 	FindByCreatedByWithPagination(ctx context.Context, createdBy string, offset, limit int) ([]*domain.Notification, int64, error)
 
+	// 游标分页查询，按(created_at, id)降序返回cursor之后的最多limit条通知，cursor为nil时从第一条开始
+	// 还有更多记录时返回指向本页最后一条的游标，否则返回nil
+	FindAfterCursor(ctx context.Context, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error)
+	FindByStatusAfterCursor(ctx context.Context, status domain.NotificationStatus, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error)
+	FindByCreatedByAfterCursor(ctx context.Context, createdBy string, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error)
+
 	// 定时任务相关
+	// FindScheduledNotifications 查找到期且尚未被认领的定时通知
 	FindScheduledNotifications(ctx context.Context, beforeTime int64) ([]*domain.Notification, error)
+	// ClaimNotification 认领待发送且已到发送时间的通知：原子地将状态从待发送改为发送中
+	// 通知已被其他worker认领或状态已改变时返回false
+	ClaimNotification(ctx context.Context, id string, now time.Time) (bool, error)
+	// ClaimScheduledNotifications 批量认领到期的定时通知、创建后超过staleBefore仍未发送的通知和认领超时的发送中通知，
+	// 返回的通知状态已改为发送中；使用行锁并跳过其他实例正在认领的行，同一通知只会被一个worker认领
+	ClaimScheduledNotifications(ctx context.Context, now, staleBefore time.Time, limit int) ([]*domain.Notification, error)
+	// RenewClaim 刷新发送中通知的认领时间，表示处理实例仍在发送；通知已不是发送中时返回false
+	RenewClaim(ctx context.Context, id string, now time.Time) (bool, error)
 	FindPendingNotifications(ctx context.Context, limit int) ([]*domain.Notification, error)
 	FindFailedNotifications(ctx context.Context, limit int) ([]*domain.Notification, error)
 	FindRetryableNotifications(ctx context.Context, limit int) ([]*domain.Notification, error)
+	FindSLABreachedNotifications(ctx context.Context, now time.Time, limit int) ([]*domain.Notification, error)
+	// FindUpcomingNotifications 查找before之前计划发送的定时通知和待自动重试的通知，按计划发送时间升序
+	FindUpcomingNotifications(ctx context.Context, before time.Time, filter ScheduleFilter, limit int) ([]*domain.Notification, error)
 
 	// 搜索操作
 	SearchByContent(ctx context.Context, query string, limit int) ([]*domain.Notification, error)
@@ -48,9 +70,15 @@ type NotificationRepository interface {
 	GetStatsByDateRange(ctx context.Context, startDate, endDate string) (*NotificationStats, error)
 	GetChannelStats(ctx context.Context) ([]ChannelStats, error)
 
-	// 清理操作
+	// 清理操作，删除均为软删除
 	DeleteOldNotifications(ctx context.Context, beforeTime int64) (int64, error)
 	DeleteCancelledNotifications(ctx context.Context, beforeTime int64) (int64, error)
+
+	// 软删除管理，供审计和误删恢复使用
+	// FindIncludingDeleted 根据ID查找通知，包括已软删除的通知
+	FindIncludingDeleted(ctx context.Context, id string) (*domain.Notification, error)
+	// PurgeDeleted 永久删除before之前软删除的通知及其接收者和互动事件，返回删除的通知数
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 // NotificationStats 通知统计信息
@@ -66,6 +94,13 @@ type NotificationStats struct {
 	LastDayCount     int64                                      `json:"last_day_count"`
 }
 
+// ScheduleFilter 计划发送查询条件，空值表示不过滤
+type ScheduleFilter struct {
+	CreatedBy string
+	Channel   domain.NotificationChannel
+	Type      domain.NotificationType
+}
+
 // ChannelStats 渠道统计信息
 type ChannelStats struct {
 	Channel      domain.NotificationChannel `json:"channel"`
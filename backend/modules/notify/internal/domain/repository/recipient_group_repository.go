@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+)
+
+// RecipientGroupRepository 接收者组仓储接口
+type RecipientGroupRepository interface {
+	// Save 保存接收者组及其成员，同一所有者内名称重复时返回ErrDuplicateKey
+	Save(ctx context.Context, group *domain.RecipientGroup) error
+	FindByID(ctx context.Context, id string) (*domain.RecipientGroup, error)
+	FindByOwnerAndName(ctx context.Context, ownerID, name string) (*domain.RecipientGroup, error)
+	FindByOwner(ctx context.Context, ownerID string) ([]*domain.RecipientGroup, error)
+	Update(ctx context.Context, group *domain.RecipientGroup) error
+	// Delete 删除接收者组及其成员
+	Delete(ctx context.Context, id string) error
+
+	// 成员管理
+	// SaveMembers 添加成员，成员已在组中时返回ErrDuplicateKey
+	SaveMembers(ctx context.Context, members []*domain.RecipientGroupMember) error
+	DeleteMember(ctx context.Context, groupID, memberID string) error
+}
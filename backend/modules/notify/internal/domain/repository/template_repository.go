@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
 )
@@ -9,6 +10,7 @@ import (
 // TemplateRepository 模板仓储接口
 type TemplateRepository interface {
 	// 基本CRUD操作
+	// Save 模板代码重复时返回ErrDuplicateKey
 	Save(ctx context.Context, template *domain.NotificationTemplate) error
 	FindByID(ctx context.Context, id string) (*domain.NotificationTemplate, error)
 	FindByCode(ctx context.Context, code string) (*domain.NotificationTemplate, error)
@@ -41,6 +43,12 @@ type TemplateRepository interface {
 	SaveChannelTemplate(ctx context.Context, channelTemplate *domain.TemplateChannel) error
 	FindChannelTemplates(ctx context.Context, templateID string) ([]*domain.TemplateChannel, error)
 	FindChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel) (*domain.TemplateChannel, error)
+	DeleteChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel) error
+
+	// 本地化内容
+	SaveLocalization(ctx context.Context, localization *domain.TemplateLocalization) error
+	FindLocalizations(ctx context.Context, templateID string) ([]*domain.TemplateLocalization, error)
+	DeleteLocalization(ctx context.Context, templateID, locale string, channel domain.NotificationChannel) error
 
 	// 变量管理
 	SaveVariables(ctx context.Context, variables []*domain.TemplateVariable) error
@@ -61,6 +69,12 @@ type TemplateRepository interface {
 	// 清理操作
 	DeleteArchivedTemplates(ctx context.Context, beforeTime int64) (int64, error)
 	CleanupOrphanedVersions(ctx context.Context) (int64, error)
+
+	// 软删除管理，Delete只软删除模板，变量、版本、渠道模板和本地化内容保留到永久删除
+	// FindIncludingDeleted 根据ID查找模板，包括已软删除的模板
+	FindIncludingDeleted(ctx context.Context, id string) (*domain.NotificationTemplate, error)
+	// PurgeDeleted 永久删除before之前软删除的模板及其关联数据，返回删除的模板数
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 // TemplateUsageStats 模板使用统计
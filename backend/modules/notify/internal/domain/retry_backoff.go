@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// 重试退避相关的渠道配置项
+const (
+	ConfigRetryBackoffBase       = "retry_backoff_base"       // 首次重试延迟，如 "30s"
+	ConfigRetryBackoffMultiplier = "retry_backoff_multiplier" // 每次重试的延迟倍数
+	ConfigRetryBackoffJitter     = "retry_backoff_jitter"     // 随机抖动比例，0~1
+	ConfigRetryBackoffMax        = "retry_backoff_max"        // 最大延迟，如 "1h"
+)
+
+// RetryBackoff 通知重试的指数退避策略
+type RetryBackoff struct {
+	Base       time.Duration // 首次重试延迟
+	Multiplier float64       // 延迟倍数
+	Jitter     float64       // 随机抖动比例，延迟在 ±Jitter 范围内浮动
+	Max        time.Duration // 最大延迟
+}
+
+// DefaultRetryBackoff 默认退避策略：30秒起，每次翻倍，±20%抖动，最长1小时
+func DefaultRetryBackoff() RetryBackoff {
+	return RetryBackoff{
+		Base:       30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+		Max:        time.Hour,
+	}
+}
+
+// Delay 计算第retryCount次失败后的重试延迟
+// random为[0,1)的随机数，用于计算抖动；抖动后的延迟仍不超过Max
+func (b RetryBackoff) Delay(retryCount int, random float64) time.Duration {
+	if retryCount < 1 {
+		retryCount = 1
+	}
+
+	delay := float64(b.Base) * math.Pow(b.Multiplier, float64(retryCount-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		delay *= 1 + b.Jitter*(2*random-1)
+	}
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
+
+// NextDelay 计算带随机抖动的重试延迟
+func (b RetryBackoff) NextDelay(retryCount int) time.Duration {
+	return b.Delay(retryCount, rand.Float64())
+}
+
+// RetryBackoff 获取渠道的重试退避策略，未配置或配置无效的参数使用默认值
+func (c *ChannelConfig) RetryBackoff() RetryBackoff {
+	backoff := DefaultRetryBackoff()
+
+	if value, exists := c.GetConfig(ConfigRetryBackoffBase); exists {
+		if base, err := time.ParseDuration(value); err == nil && base > 0 {
+			backoff.Base = base
+		}
+	}
+	if value, exists := c.GetConfig(ConfigRetryBackoffMultiplier); exists {
+		if multiplier, err := strconv.ParseFloat(value, 64); err == nil && multiplier >= 1 {
+			backoff.Multiplier = multiplier
+		}
+	}
+	if value, exists := c.GetConfig(ConfigRetryBackoffJitter); exists {
+		if jitter, err := strconv.ParseFloat(value, 64); err == nil && jitter >= 0 && jitter <= 1 {
+			backoff.Jitter = jitter
+		}
+	}
+	if value, exists := c.GetConfig(ConfigRetryBackoffMax); exists {
+		if max, err := time.ParseDuration(value); err == nil && max > 0 {
+			backoff.Max = max
+		}
+	}
+
+	return backoff
+}
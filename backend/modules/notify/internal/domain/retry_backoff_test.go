@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoffDelayGrowsAndRespectsCap(t *testing.T) {
+	backoff := RetryBackoff{Base: 30 * time.Second, Multiplier: 2, Max: 5 * time.Minute}
+
+	want := []time.Duration{
+		30 * time.Second,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		5 * time.Minute,
+		5 * time.Minute,
+	}
+	for i, expected := range want {
+		if delay := backoff.Delay(i+1, 0.5); delay != expected {
+			t.Fatalf("retry %d: delay = %s, want %s", i+1, delay, expected)
+		}
+	}
+
+	if delay := backoff.Delay(0, 0.5); delay != 30*time.Second {
+		t.Fatalf("retry 0 should use base delay, got %s", delay)
+	}
+}
+
+func TestRetryBackoffJitter(t *testing.T) {
+	backoff := RetryBackoff{Base: 100 * time.Second, Multiplier: 2, Jitter: 0.2, Max: time.Hour}
+
+	if delay := backoff.Delay(1, 0); delay != 80*time.Second {
+		t.Fatalf("min jitter delay = %s, want 80s", delay)
+	}
+	if delay := backoff.Delay(1, 0.75); delay != 110*time.Second {
+		t.Fatalf("jitter delay = %s, want 110s", delay)
+	}
+	// 抖动后仍不超过上限
+	if delay := backoff.Delay(10, 0.99); delay != time.Hour {
+		t.Fatalf("capped delay = %s, want 1h", delay)
+	}
+
+	for i := 0; i < 100; i++ {
+		if delay := backoff.NextDelay(2); delay < 160*time.Second || delay > 240*time.Second {
+			t.Fatalf("next delay %s outside jitter range", delay)
+		}
+	}
+}
+
+func TestChannelConfigRetryBackoff(t *testing.T) {
+	config := &ChannelConfig{Config: map[string]string{
+		ConfigRetryBackoffBase:       "10s",
+		ConfigRetryBackoffMultiplier: "3",
+		ConfigRetryBackoffJitter:     "0",
+		ConfigRetryBackoffMax:        "2m",
+	}}
+	backoff := config.RetryBackoff()
+	if backoff != (RetryBackoff{Base: 10 * time.Second, Multiplier: 3, Max: 2 * time.Minute}) {
+		t.Fatalf("unexpected backoff: %+v", backoff)
+	}
+
+	invalid := &ChannelConfig{Config: map[string]string{
+		ConfigRetryBackoffBase:       "soon",
+		ConfigRetryBackoffMultiplier: "0.5",
+		ConfigRetryBackoffJitter:     "2",
+		ConfigRetryBackoffMax:        "-1h",
+	}}
+	if backoff := invalid.RetryBackoff(); backoff != DefaultRetryBackoff() {
+		t.Fatalf("invalid values should fall back to defaults: %+v", backoff)
+	}
+}
+
+func TestScheduleRetry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	backoff := RetryBackoff{Base: time.Minute, Multiplier: 2, Max: time.Hour}
+
+	n, err := NewNotification("t", "c", NotificationTypeSystem, ChannelEmail, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.MaxRetries = 3
+	// 两次发送失败
+	for i := 0; i < 2; i++ {
+		n.UpdateStatus(NotificationStatusSending)
+		n.SetError(errors.New("smtp unavailable"))
+	}
+	n.ScheduleRetry(backoff, now)
+	if n.NextRetryAt == nil || !n.NextRetryAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("next retry at = %v, want %v", n.NextRetryAt, now.Add(2*time.Minute))
+	}
+
+	n.RetryCount = 3
+	n.ScheduleRetry(backoff, now)
+	if n.NextRetryAt != nil {
+		t.Fatalf("exhausted notification should not be scheduled, got %v", n.NextRetryAt)
+	}
+}
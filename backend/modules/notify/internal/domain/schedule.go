@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// ScheduleKind 计划发送的来源
+type ScheduleKind string
+
+const (
+	ScheduleKindScheduled ScheduleKind = "scheduled" // 定时发送，包括因免打扰时段延后的发送
+	ScheduleKindRetry     ScheduleKind = "retry"     // 失败后的自动重试
+)
+
+// NextSendTime 获取通知在[from, to)内的下一次计划发送时间
+// 待发送的定时通知取计划时间；可重试的失败通知取下次重试时间，未设置时视为立即重试；
+// 已到期但尚未被定时任务处理的发送视为在from时发送
+func (n *Notification) NextSendTime(from, to time.Time) (time.Time, ScheduleKind, bool) {
+	var sendAt time.Time
+	var kind ScheduleKind
+
+	switch {
+	case n.Status == NotificationStatusPending && n.ScheduledAt != nil:
+		sendAt, kind = *n.ScheduledAt, ScheduleKindScheduled
+	case n.CanRetry():
+		sendAt, kind = from, ScheduleKindRetry
+		if n.NextRetryAt != nil {
+			sendAt = *n.NextRetryAt
+		}
+	default:
+		return time.Time{}, "", false
+	}
+
+	if sendAt.Before(from) {
+		sendAt = from
+	}
+	if !sendAt.Before(to) {
+		return time.Time{}, "", false
+	}
+	return sendAt, kind, true
+}
+
+// UpcomingSend 日历视图中的一次计划发送
+type UpcomingSend struct {
+	NotificationID string               `json:"notification_id"`
+	Title          string               `json:"title"`
+	Type           NotificationType     `json:"type"`
+	Channel        NotificationChannel  `json:"channel"`
+	Priority       NotificationPriority `json:"priority"`
+	CreatedBy      string               `json:"created_by"`
+	Kind           ScheduleKind         `json:"kind"`
+	SendAt         time.Time            `json:"send_at"`
+	Attempt        int                  `json:"attempt"` // 第几次发送，首次发送为1
+}
+
+// UpcomingSchedule 时间窗口内的计划发送，按发送时间升序
+type UpcomingSchedule struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Sends     []UpcomingSend `json:"sends"`
+	Truncated bool           `json:"truncated"` // 超过数量上限，窗口末尾的发送未列出
+}
+
+// NewUpcomingSend 根据通知创建计划发送
+func NewUpcomingSend(n *Notification, kind ScheduleKind, sendAt time.Time) UpcomingSend {
+	attempt := 1
+	if kind == ScheduleKindRetry {
+		attempt = n.RetryCount + 1
+	}
+
+	return UpcomingSend{
+		NotificationID: n.ID,
+		Title:          n.Title,
+		Type:           n.Type,
+		Channel:        n.Channel,
+		Priority:       n.Priority,
+		CreatedBy:      n.CreatedBy,
+		Kind:           kind,
+		SendAt:         sendAt,
+		Attempt:        attempt,
+	}
+}
@@ -1,12 +1,17 @@
 package domain
 
 import (
+	"bytes"
 	"fmt"
+	htmltemplate "html/template"
 	"regexp"
 	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
 	"time"
 
 	"github.com/noah-loop/backend/shared/pkg/domain"
+	"gorm.io/gorm"
 )
 
 // TemplateType 模板类型
@@ -33,19 +38,21 @@ const (
 type NotificationTemplate struct {
 	domain.Entity
 	Name        string                         `gorm:"not null" json:"name"`
-	Code        string                         `gorm:"not null;uniqueIndex:idx_template_code" json:"code"` // 模板代码
+	Code        string                         `gorm:"not null;uniqueIndex:idx_template_code,where:deleted_at IS NULL" json:"code"` // 模板代码，未删除的模板内唯一
 	Type        TemplateType                   `gorm:"not null" json:"type"`
 	Status      TemplateStatus                 `gorm:"not null;default:'draft'" json:"status"`
 	Category    string                         `json:"category"`    // 分类
 	Description string                         `json:"description"` // 描述
-	Variables   []TemplateVariable             `json:"variables"`   // 模板变量
-	Versions    []TemplateVersion              `json:"versions"`    // 版本历史
-	Channels    []TemplateChannel              `json:"channels"`    // 渠道配置
+	Variables   []TemplateVariable             `gorm:"foreignKey:TemplateID" json:"variables"` // 模板变量
+	Versions    []TemplateVersion              `gorm:"foreignKey:TemplateID" json:"versions"`  // 版本历史
+	Channels    []TemplateChannel              `gorm:"foreignKey:TemplateID" json:"channels"`  // 渠道配置
+	Localizations []TemplateLocalization       `gorm:"foreignKey:TemplateID" json:"localizations,omitempty"` // 本地化内容，按接收者语言区域选择
 	Tags        []string                       `gorm:"serializer:json" json:"tags,omitempty"`
 	CreatedBy   string                         `gorm:"not null;index" json:"created_by"`
 	UpdatedBy   string                         `gorm:"index" json:"updated_by"`
 	CreatedAt   time.Time                      `json:"created_at"`
 	UpdatedAt   time.Time                      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt                 `gorm:"index" json:"-"` // 软删除时间，查询自动排除已删除的模板
 }
 
 // TemplateVariable 模板变量
@@ -85,7 +92,7 @@ type TemplateChannel struct {
 	IsEnabled  bool                `gorm:"default:true" json:"is_enabled"`
 }
 
-// AddVariable 添加模板变量
+// AddVariable 添加模板变量，验证规则不合法时返回错误
 func (t *NotificationTemplate) AddVariable(variable TemplateVariable) error {
 	// 检查变量名是否已存在
 	for _, v := range t.Variables {
@@ -94,6 +101,12 @@ func (t *NotificationTemplate) AddVariable(variable TemplateVariable) error {
 		}
 	}
 	
+	if variable.Validation != "" {
+		if err := variable.ValidateRule(); err != nil {
+			return err
+		}
+	}
+	
 	variable.TemplateID = t.ID
 	t.Variables = append(t.Variables, variable)
 	t.UpdatedAt = time.Now()
@@ -191,12 +204,90 @@ func (t *NotificationTemplate) GetChannelTemplate(channel NotificationChannel) *
 	return nil
 }
 
+// FindChannelTemplate 查找渠道模板（包括已禁用的）
+func (t *NotificationTemplate) FindChannelTemplate(channel NotificationChannel) *TemplateChannel {
+	for i, tc := range t.Channels {
+		if tc.Channel == channel {
+			return &t.Channels[i]
+		}
+	}
+
+	return nil
+}
+
+// SetChannelTemplateEnabled 启用或禁用渠道模板
+func (t *NotificationTemplate) SetChannelTemplateEnabled(channel NotificationChannel, enabled bool) error {
+	channelTemplate := t.FindChannelTemplate(channel)
+	if channelTemplate == nil {
+		return ErrTemplateChannelNotFoundf(t.ID, string(channel))
+	}
+
+	channelTemplate.IsEnabled = enabled
+	t.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// RemoveChannelTemplate 移除渠道模板
+func (t *NotificationTemplate) RemoveChannelTemplate(channel NotificationChannel) bool {
+	for i, tc := range t.Channels {
+		if tc.Channel == channel {
+			t.Channels = append(t.Channels[:i], t.Channels[i+1:]...)
+			t.UpdatedAt = time.Now()
+			return true
+		}
+	}
+
+	return false
+}
+
 // RenderTemplate 渲染模板
 func (t *NotificationTemplate) RenderTemplate(channel NotificationChannel, variables map[string]string) (string, string, error) {
+	return t.RenderTemplateWithProfile(channel, variables, nil)
+}
+
+// RenderTemplateWithProfile 使用接收者资料渲染模板
+// 变量优先级从低到高为：模板变量默认值、接收者资料（见Recipient.ProfileVariables）、传入的变量；
+// 传入的变量按声明的类型和验证规则检查，不符合时返回列出全部验证失败项的ErrInvalidTemplateVariables；
+// 接收者资料中有locale时使用协商出的本地化内容（见FindLocalization）
+func (t *NotificationTemplate) RenderTemplateWithProfile(channel NotificationChannel, variables, profile map[string]string) (string, string, error) {
+	_, subject, content, err := t.renderSource(channel, profile[ProfileVariableLocale])
+	if err != nil {
+		return "", "", err
+	}
+	
+	allVariables := t.renderVariables(variables, profile)
+	
+	// 验证必需变量
+	if missing := t.missingRequiredVariables(allVariables); len(missing) > 0 {
+		return "", "", NewDomainError("MISSING_REQUIRED_VARIABLE", "missing required variable: "+missing[0])
+	}
+	
+	// 替换前按声明的类型和验证规则检查传入的变量
+	if violations := t.ValidateVariables(variables); len(violations) > 0 {
+		return "", "", &ErrInvalidTemplateVariables{Violations: violations}
+	}
+	
+	renderedSubject, err := renderString(subject, allVariables)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	
+	renderedContent, err := t.renderContent(content, allVariables)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render content: %w", err)
+	}
+	
+	return renderedSubject, renderedContent, nil
+}
+
+// renderSource 选择渲染使用的标题和内容模板，返回活跃版本
+// 渠道模板优先，渠道模板为空的部分使用活跃版本；locale非空时按协商出的本地化内容覆盖
+func (t *NotificationTemplate) renderSource(channel NotificationChannel, locale string) (*TemplateVersion, string, string, error) {
 	// 获取活跃版本
 	version := t.GetActiveVersion()
 	if version == nil {
-		return "", "", NewDomainError("NO_ACTIVE_VERSION", "no active version found")
+		return nil, "", "", NewDomainError("NO_ACTIVE_VERSION", "no active version found")
 	}
 	
 	// 获取渠道模板，如果没有则使用默认模板
@@ -220,7 +311,21 @@ func (t *NotificationTemplate) RenderTemplate(channel NotificationChannel, varia
 		content = version.Content
 	}
 	
-	// 合并变量（默认值 + 传入值）
+	// 按接收者语言区域协商本地化内容，本地化的标题或内容为空时沿用上面的默认内容
+	if localization := t.FindLocalization(locale, channel); localization != nil {
+		if localization.Subject != "" {
+			subject = localization.Subject
+		}
+		if localization.Content != "" {
+			content = localization.Content
+		}
+	}
+	
+	return version, subject, content, nil
+}
+
+// renderVariables 合并渲染变量（默认值 + 接收者资料 + 传入值）
+func (t *NotificationTemplate) renderVariables(variables, profile map[string]string) map[string]string {
 	allVariables := make(map[string]string)
 	
 	// 先设置默认值
@@ -230,32 +335,38 @@ func (t *NotificationTemplate) RenderTemplate(channel NotificationChannel, varia
 		}
 	}
 	
+	// 接收者资料覆盖默认值
+	for key, value := range profile {
+		allVariables[key] = value
+	}
+	
 	// 再设置传入的值
 	for key, value := range variables {
 		allVariables[key] = value
 	}
 	
-	// 验证必需变量
+	return allVariables
+}
+
+// missingRequiredVariables 返回合并后仍缺少的必需变量，按声明顺序排列
+func (t *NotificationTemplate) missingRequiredVariables(allVariables map[string]string) []string {
+	var missing []string
 	for _, variable := range t.Variables {
 		if variable.Required {
 			if _, exists := allVariables[variable.Name]; !exists {
-				return "", "", NewDomainError("MISSING_REQUIRED_VARIABLE", "missing required variable: "+variable.Name)
+				missing = append(missing, variable.Name)
 			}
 		}
 	}
-	
-	// 渲染模板
-	renderedSubject, err := renderString(subject, allVariables)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to render subject: %w", err)
-	}
-	
-	renderedContent, err := renderString(content, allVariables)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to render content: %w", err)
+	return missing
+}
+
+// renderContent 渲染内容模板，HTML模板使用html/template自动转义变量，标题始终按纯文本渲染
+func (t *NotificationTemplate) renderContent(content string, variables map[string]string) (string, error) {
+	if t.Type == TemplateTypeHTML {
+		return renderHTMLString(content, variables)
 	}
-	
-	return renderedSubject, renderedContent, nil
+	return renderString(content, variables)
 }
 
 // UpdateStatus 更新模板状态
@@ -319,40 +430,125 @@ func NewNotificationTemplate(name, code string, templateType TemplateType, creat
 	return template, nil
 }
 
-// renderString 渲染字符串模板
+// legacyVariablePattern 旧版 {{name}} 变量语法
+var legacyVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_]\w*)\s*\}\}`)
+
+// templateKeywords 模板关键字，不按旧版变量语法改写
+var templateKeywords = map[string]bool{
+	"end":      true,
+	"else":     true,
+	"break":    true,
+	"continue": true,
+	"nil":      true,
+	"true":     true,
+	"false":    true,
+}
+
+// templateFuncs 模板辅助函数
+var templateFuncs = texttemplate.FuncMap{
+	// default 变量为空时使用默认值：{{default "访客" .username}}
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	// split 按分隔符拆分变量，用于遍历列表：{{range split .items ","}}{{.}}{{end}}，空值不产生元素
+	"split": func(value, sep string) []string {
+		if value == "" {
+			return nil
+		}
+		return strings.Split(value, sep)
+	},
+}
+
+// normalizeTemplate 将旧版 {{name}} 改写为 {{.name}}，兼容已有模板
+func normalizeTemplate(template string) string {
+	return legacyVariablePattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := legacyVariablePattern.FindStringSubmatch(match)[1]
+		if templateKeywords[name] {
+			return match
+		}
+		return "{{." + name + "}}"
+	})
+}
+
+// parseTextTemplate 解析文本模板
+func parseTextTemplate(template string) (*texttemplate.Template, error) {
+	tmpl, err := texttemplate.New("template").
+		Option("missingkey=zero").
+		Funcs(templateFuncs).
+		Parse(normalizeTemplate(template))
+	if err != nil {
+		return nil, NewDomainErrorWithDetails(ErrTemplateInvalidFormat, "Template syntax error", err.Error())
+	}
+	return tmpl, nil
+}
+
+// renderString 使用text/template渲染字符串模板，缺失的变量渲染为空
 func renderString(template string, variables map[string]string) (string, error) {
-	result := template
+	tmpl, err := parseTextTemplate(template)
+	if err != nil {
+		return "", err
+	}
 	
-	// 简单的变量替换 {{variable_name}}
-	re := regexp.MustCompile(`\{\{(\w+)\}\}`)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", NewDomainErrorWithDetails(ErrTemplateRenderFailed, "Template render failed", err.Error())
+	}
 	
-	result = re.ReplaceAllStringFunc(result, func(match string) string {
-		// 提取变量名
-		varName := strings.Trim(match, "{}")
-		varName = strings.TrimSpace(varName)
-		
-		if value, exists := variables[varName]; exists {
-			return value
-		}
-		
-		// 如果变量不存在，保留原样
-		return match
-	})
+	return buf.String(), nil
+}
+
+// renderHTMLString 使用html/template渲染字符串模板，变量值会按上下文自动转义
+func renderHTMLString(template string, variables map[string]string) (string, error) {
+	tmpl, err := htmltemplate.New("template").
+		Option("missingkey=zero").
+		Funcs(htmltemplate.FuncMap(templateFuncs)).
+		Parse(normalizeTemplate(template))
+	if err != nil {
+		return "", NewDomainErrorWithDetails(ErrTemplateInvalidFormat, "Template syntax error", err.Error())
+	}
+	
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", NewDomainErrorWithDetails(ErrTemplateRenderFailed, "Template render failed", err.Error())
+	}
 	
-	return result, nil
+	return buf.String(), nil
 }
 
 // ValidateTemplate 验证模板语法
+// 检查模板能否解析、引用的变量是否已定义，以及必需变量是否都在模板中使用
 func ValidateTemplate(template string, variables []TemplateVariable) error {
-	re := regexp.MustCompile(`\{\{(\w+)\}\}`)
-	matches := re.FindAllStringSubmatch(template, -1)
+	tmpl, err := parseTextTemplate(template)
+	if err != nil {
+		return err
+	}
+	
+	// 变量都是字符串，不能直接遍历
+	if name := rangedVariable(tmpl.Tree.Root, true); name != "" {
+		return NewDomainErrorWithDetails(ErrTemplateInvalidFormat, "cannot range over string variable: "+name,
+			fmt.Sprintf(`use {{range split .%s ","}} to iterate over a delimited list`, name))
+	}
 	
 	// 收集模板中使用的变量
 	usedVars := make(map[string]bool)
-	for _, match := range matches {
-		if len(match) > 1 {
-			varName := strings.TrimSpace(match[1])
-			usedVars[varName] = true
+	collectTemplateVariables(tmpl.Tree.Root, true, usedVars)
+	
+	// 声明了变量时，模板引用的变量必须已定义；接收者资料变量无需声明
+	if len(variables) > 0 {
+		declared := make(map[string]bool, len(variables))
+		for _, variable := range variables {
+			declared[variable.Name] = true
+		}
+		for name := range usedVars {
+			if !declared[name] && !IsProfileVariable(name) {
+				return NewDomainError("UNDEFINED_VARIABLE", "template references undefined variable: "+name)
+			}
 		}
 	}
 	
@@ -365,3 +561,109 @@ func ValidateTemplate(template string, variables []TemplateVariable) error {
 	
 	return nil
 }
+
+// rangedVariable 查找被range直接遍历的变量，返回第一个变量名，没有时返回空字符串
+// rootDot含义同collectTemplateVariables
+func rangedVariable(node parse.Node, rootDot bool) string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return ""
+		}
+		for _, child := range n.Nodes {
+			if name := rangedVariable(child, rootDot); name != "" {
+				return name
+			}
+		}
+	case *parse.IfNode:
+		if name := rangedVariable(n.List, rootDot); name != "" {
+			return name
+		}
+		return rangedVariable(n.ElseList, rootDot)
+	case *parse.RangeNode:
+		if name := pipeVariable(n.Pipe, rootDot); name != "" {
+			return name
+		}
+		if name := rangedVariable(n.List, false); name != "" {
+			return name
+		}
+		return rangedVariable(n.ElseList, rootDot)
+	case *parse.WithNode:
+		if name := rangedVariable(n.List, false); name != "" {
+			return name
+		}
+		return rangedVariable(n.ElseList, rootDot)
+	}
+	return ""
+}
+
+// pipeVariable 管道只引用单个变量（如 .items、$.items）时返回变量名
+func pipeVariable(pipe *parse.PipeNode, rootDot bool) string {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return ""
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		if rootDot {
+			return arg.Ident[0]
+		}
+	case *parse.VariableNode:
+		if len(arg.Ident) > 1 && arg.Ident[0] == "$" {
+			return arg.Ident[1]
+		}
+	}
+	return ""
+}
+
+// collectTemplateVariables 遍历模板语法树收集引用的变量名
+// rootDot表示当前"."是否指向变量集合，range/with内部"."被重新绑定，只能通过"$."引用变量
+func collectTemplateVariables(node parse.Node, rootDot bool, used map[string]bool) {
+	switch n := node.(type) {
+	case nil:
+		return
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectTemplateVariables(child, rootDot, used)
+		}
+	case *parse.ActionNode:
+		collectTemplateVariables(n.Pipe, rootDot, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectTemplateVariables(cmd, rootDot, used)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectTemplateVariables(arg, rootDot, used)
+		}
+	case *parse.FieldNode:
+		if rootDot && len(n.Ident) > 0 {
+			used[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			used[n.Ident[1]] = true
+		}
+	case *parse.ChainNode:
+		collectTemplateVariables(n.Node, rootDot, used)
+	case *parse.IfNode:
+		collectTemplateVariables(n.Pipe, rootDot, used)
+		collectTemplateVariables(n.List, rootDot, used)
+		collectTemplateVariables(n.ElseList, rootDot, used)
+	case *parse.RangeNode:
+		collectTemplateVariables(n.Pipe, rootDot, used)
+		collectTemplateVariables(n.List, false, used)
+		collectTemplateVariables(n.ElseList, rootDot, used)
+	case *parse.WithNode:
+		collectTemplateVariables(n.Pipe, rootDot, used)
+		collectTemplateVariables(n.List, false, used)
+		collectTemplateVariables(n.ElseList, rootDot, used)
+	case *parse.TemplateNode:
+		collectTemplateVariables(n.Pipe, rootDot, used)
+	}
+}
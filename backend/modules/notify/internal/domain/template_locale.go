@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/domain"
+)
+
+// TemplateLocalization 模板的本地化内容
+// 按接收者的语言区域选择，标题或内容为空时沿用默认内容（渠道模板或活跃版本）
+type TemplateLocalization struct {
+	domain.Entity
+	TemplateID string              `gorm:"not null;uniqueIndex:idx_template_localization" json:"template_id"`
+	Locale     string              `gorm:"not null;uniqueIndex:idx_template_localization" json:"locale"`   // BCP-47语言标签，如pt-BR
+	Channel    NotificationChannel `gorm:"uniqueIndex:idx_template_localization" json:"channel,omitempty"` // 为空时适用于所有渠道
+	Subject    string              `json:"subject"`                                                        // 本地化标题模板
+	Content    string              `gorm:"type:text" json:"content"`                                       // 本地化内容模板
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// NormalizeLocale 校验BCP-47语言标签并转换为规范大小写，下划线视为连字符：
+// 语言小写（pt）、文字首字母大写（Hant）、地区大写（BR），如pt_br转换为pt-BR
+func NormalizeLocale(locale string) (string, error) {
+	subtags := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if !isAlpha(subtags[0]) || len(subtags[0]) < 2 || len(subtags[0]) > 8 {
+		return "", ErrInvalidLocalef(locale)
+	}
+
+	for i, subtag := range subtags {
+		if subtag == "" || len(subtag) > 8 || !isAlphanumeric(subtag) {
+			return "", ErrInvalidLocalef(locale)
+		}
+
+		subtag = strings.ToLower(subtag)
+		switch {
+		case i == 0:
+		case len(subtag) == 4 && isAlpha(subtag):
+			subtag = strings.ToUpper(subtag[:1]) + subtag[1:]
+		case len(subtag) == 2 && isAlpha(subtag), len(subtag) == 3 && isDigits(subtag):
+			subtag = strings.ToUpper(subtag)
+		}
+		subtags[i] = subtag
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// localeFallbacks 语言标签的回退链，按RFC 4647 Lookup依次去掉最后一个子标签：
+// zh-Hant-TW -> zh-Hant -> zh；去掉后末尾为单字符扩展标记时一并去掉
+func localeFallbacks(locale string) []string {
+	subtags := strings.Split(locale, "-")
+	fallbacks := make([]string, 0, len(subtags))
+	for n := len(subtags); n > 0; n-- {
+		if len(subtags[n-1]) == 1 {
+			continue
+		}
+		fallbacks = append(fallbacks, strings.Join(subtags[:n], "-"))
+	}
+	return fallbacks
+}
+
+// localeLanguage 语言标签的主语言子标签
+func localeLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return language
+}
+
+// FindLocalization 为接收者语言区域协商最合适的本地化内容，没有匹配时返回nil，使用默认内容
+// 依次尝试完整标签及其回退链（pt-BR -> pt），每一级优先当前渠道专用的本地化；
+// 回退链都不存在时使用同一语言的其他地区（pt-BR接收者使用pt-PT），多个时按标签排序取第一个
+func (t *NotificationTemplate) FindLocalization(locale string, channel NotificationChannel) *TemplateLocalization {
+	if locale == "" || len(t.Localizations) == 0 {
+		return nil
+	}
+	normalized, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil
+	}
+
+	for _, candidate := range localeFallbacks(normalized) {
+		if localization := t.findLocalization(candidate, channel); localization != nil {
+			return localization
+		}
+		if localization := t.findLocalization(candidate, ""); localization != nil {
+			return localization
+		}
+	}
+
+	language := localeLanguage(normalized)
+	var best *TemplateLocalization
+	for i := range t.Localizations {
+		localization := &t.Localizations[i]
+		if localeLanguage(localization.Locale) != language {
+			continue
+		}
+		if localization.Channel != "" && localization.Channel != channel {
+			continue
+		}
+		if best == nil || localizationLess(localization, best, channel) {
+			best = localization
+		}
+	}
+	return best
+}
+
+// localizationLess 同一语言的候选中，渠道专用的优先，其次按标签排序
+func localizationLess(a, b *TemplateLocalization, channel NotificationChannel) bool {
+	aChannel, bChannel := a.Channel == channel, b.Channel == channel
+	if aChannel != bChannel {
+		return aChannel
+	}
+	return a.Locale < b.Locale
+}
+
+// findLocalization 按规范化的语言标签和渠道精确查找本地化内容
+func (t *NotificationTemplate) findLocalization(locale string, channel NotificationChannel) *TemplateLocalization {
+	for i, localization := range t.Localizations {
+		if localization.Locale == locale && localization.Channel == channel {
+			return &t.Localizations[i]
+		}
+	}
+	return nil
+}
+
+// SetLocalization 创建或更新本地化内容，locale需已规范化
+func (t *NotificationTemplate) SetLocalization(locale string, channel NotificationChannel, subject, content string) *TemplateLocalization {
+	now := time.Now()
+	t.UpdatedAt = now
+
+	if localization := t.findLocalization(locale, channel); localization != nil {
+		localization.Subject = subject
+		localization.Content = content
+		localization.UpdatedAt = now
+		return localization
+	}
+
+	t.Localizations = append(t.Localizations, TemplateLocalization{
+		Entity:     domain.NewEntity(),
+		TemplateID: t.ID,
+		Locale:     locale,
+		Channel:    channel,
+		Subject:    subject,
+		Content:    content,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	sort.SliceStable(t.Localizations, func(i, j int) bool {
+		return t.Localizations[i].Locale < t.Localizations[j].Locale
+	})
+	return t.findLocalization(locale, channel)
+}
+
+// RemoveLocalization 移除本地化内容，locale需已规范化
+func (t *NotificationTemplate) RemoveLocalization(locale string, channel NotificationChannel) bool {
+	for i, localization := range t.Localizations {
+		if localization.Locale == locale && localization.Channel == channel {
+			t.Localizations = append(t.Localizations[:i], t.Localizations[i+1:]...)
+			t.UpdatedAt = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package domain
+
+// TemplatePreview 模板预览结果
+// 预览不要求模板已激活，缺少必需变量、变量验证失败或渲染失败时不返回错误，而是记录在Errors中，其余部分照常渲染
+type TemplatePreview struct {
+	TemplateID       string              `json:"template_id"`
+	Channel          NotificationChannel `json:"channel"`
+	Version          string              `json:"version"`                     // 使用的活跃版本
+	Subject          string              `json:"subject"`                     // 渲染后的标题
+	Content          string              `json:"content"`                     // 渲染后的内容
+	Variables        map[string]string   `json:"variables"`                   // 渲染使用的变量，包括默认值
+	MissingVariables []string            `json:"missing_variables,omitempty"` // 缺少的必需变量
+	Violations       []VariableViolation `json:"violations,omitempty"`        // 类型或验证规则不符合的变量
+	Errors           []string            `json:"errors,omitempty"`            // 验证和渲染错误
+	Valid            bool                `json:"valid"`                       // 没有任何错误，可以直接用于发送
+}
+
+// Preview 使用示例变量预览模板在指定渠道的渲染结果
+// 内容来源与变量合并规则与RenderTemplate相同；缺少的必需变量按空值渲染并报告，
+// 只有没有活跃版本时返回错误
+func (t *NotificationTemplate) Preview(channel NotificationChannel, sampleVariables map[string]string) (*TemplatePreview, error) {
+	version, subject, content, err := t.renderSource(channel, "")
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &TemplatePreview{
+		TemplateID: t.ID,
+		Channel:    channel,
+		Version:    version.Version,
+		Variables:  t.renderVariables(sampleVariables, nil),
+	}
+
+	preview.MissingVariables = t.missingRequiredVariables(preview.Variables)
+	for _, name := range preview.MissingVariables {
+		preview.Errors = append(preview.Errors, "missing required variable: "+name)
+	}
+	preview.Violations = t.ValidateVariables(sampleVariables)
+	for _, violation := range preview.Violations {
+		preview.Errors = append(preview.Errors, violation.Variable+": "+violation.Reason)
+	}
+
+	if preview.Subject, err = renderString(subject, preview.Variables); err != nil {
+		preview.Errors = append(preview.Errors, "failed to render subject: "+err.Error())
+	}
+	if preview.Content, err = t.renderContent(content, preview.Variables); err != nil {
+		preview.Errors = append(preview.Errors, "failed to render content: "+err.Error())
+	}
+
+	preview.Valid = len(preview.Errors) == 0
+	return preview, nil
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newPreviewTestTemplate 创建带默认值变量和必需变量的模板，短信渠道有单独的内容模板
+func newPreviewTestTemplate(t *testing.T) *NotificationTemplate {
+	t.Helper()
+	template := newRenderTestTemplate(t, TemplateTypeText, "Order {{.order_id}}", "Hi {{.name}}, order {{.order_id}} has shipped.")
+	template.SetChannelTemplate(ChannelSMS, "", "{{.name}}: order {{.order_id}} shipped", nil)
+	for _, variable := range []TemplateVariable{
+		{Name: "name", DefaultValue: "customer"},
+		{Name: "order_id", Required: true},
+	} {
+		if err := template.AddVariable(variable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return template
+}
+
+func TestPreviewUsesDefaultValues(t *testing.T) {
+	template := newPreviewTestTemplate(t)
+
+	preview, err := template.Preview(ChannelEmail, map[string]string{"order_id": "A-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preview.Valid || len(preview.Errors) != 0 {
+		t.Fatalf("preview should be valid, errors %v", preview.Errors)
+	}
+	if preview.Subject != "Order A-1" || preview.Content != "Hi customer, order A-1 has shipped." {
+		t.Fatalf("subject = %q, content = %q", preview.Subject, preview.Content)
+	}
+	if want := map[string]string{"name": "customer", "order_id": "A-1"}; !reflect.DeepEqual(preview.Variables, want) {
+		t.Fatalf("variables = %v, want %v", preview.Variables, want)
+	}
+	if preview.Version != "v1" || preview.TemplateID != template.ID {
+		t.Fatalf("preview of template %s version %s", preview.TemplateID, preview.Version)
+	}
+
+	// 渠道模板覆盖内容，空标题沿用活跃版本
+	preview, err = template.Preview(ChannelSMS, map[string]string{"order_id": "A-1", "name": "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Subject != "Order A-1" || preview.Content != "Ann: order A-1 shipped" {
+		t.Fatalf("sms subject = %q, content = %q", preview.Subject, preview.Content)
+	}
+}
+
+func TestPreviewReportsMissingRequiredVariables(t *testing.T) {
+	template := newPreviewTestTemplate(t)
+
+	// 渲染直接失败，预览照常渲染并报告缺少的变量
+	if _, _, err := template.RenderTemplate(ChannelEmail, nil); err == nil {
+		t.Fatal("render without the required variable should fail")
+	}
+	preview, err := template.Preview(ChannelEmail, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Valid {
+		t.Fatal("preview missing a required variable should not be valid")
+	}
+	if !reflect.DeepEqual(preview.MissingVariables, []string{"order_id"}) {
+		t.Fatalf("missing variables = %v, want [order_id]", preview.MissingVariables)
+	}
+	if !reflect.DeepEqual(preview.Errors, []string{"missing required variable: order_id"}) {
+		t.Fatalf("errors = %v", preview.Errors)
+	}
+	if preview.Content != "Hi customer, order  has shipped." {
+		t.Fatalf("content = %q, missing variables should render empty", preview.Content)
+	}
+}
+
+func TestPreviewWithoutVersion(t *testing.T) {
+	template, err := NewNotificationTemplate("Order", "order", TemplateTypeText, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = template.Preview(ChannelEmail, nil)
+	var domainErr *DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != "NO_ACTIVE_VERSION" {
+		t.Fatalf("expected NO_ACTIVE_VERSION, got %v", err)
+	}
+}
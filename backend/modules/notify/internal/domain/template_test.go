@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// newRenderTestTemplate 创建带活跃版本的模板
+func newRenderTestTemplate(t *testing.T, templateType TemplateType, subject, content string) *NotificationTemplate {
+	t.Helper()
+	template, err := NewNotificationTemplate("Order", "order", templateType, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := template.AddVersion(TemplateVersion{Version: "v1", Subject: subject, Content: content, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	return template
+}
+
+func TestRenderTemplateIfAndRange(t *testing.T) {
+	template := newRenderTestTemplate(t, TemplateTypeText,
+		"Hi {{default \"guest\" .name}}",
+		`{{if .vip}}VIP {{end}}items:{{range split .items ","}} {{.}}{{else}} none{{end}}`)
+
+	subject, content, err := template.RenderTemplate(ChannelEmail, map[string]string{"vip": "1", "items": "a,b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Hi guest" || content != "VIP items: a b" {
+		t.Fatalf("subject = %q, content = %q", subject, content)
+	}
+
+	_, content, err = template.RenderTemplate(ChannelEmail, map[string]string{"name": "Ann"})
+	if err != nil || content != "items: none" {
+		t.Fatalf("content = %q, %v", content, err)
+	}
+}
+
+func TestRenderTemplateLegacySyntax(t *testing.T) {
+	template := newRenderTestTemplate(t, TemplateTypeText, "{{ name }}", "Hello {{name}}, code {{code}}{{end_note}}")
+
+	subject, content, err := template.RenderTemplate(ChannelEmail, map[string]string{"name": "Ann", "code": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Ann" || content != "Hello Ann, code 42" {
+		t.Fatalf("subject = %q, content = %q", subject, content)
+	}
+}
+
+func TestRenderTemplateHTMLEscaping(t *testing.T) {
+	template := newRenderTestTemplate(t, TemplateTypeHTML, "{{.name}}", `<p>{{.name}}</p><a href="/u?n={{.name}}">x</a>`)
+
+	subject, content, err := template.RenderTemplate(ChannelEmail, map[string]string{"name": `<b>"A&B"</b>`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != `<b>"A&B"</b>` {
+		t.Fatalf("subject should render as plain text, got %q", subject)
+	}
+	want := `<p>&lt;b&gt;&#34;A&amp;B&#34;&lt;/b&gt;</p><a href="/u?n=%3cb%3e%22A%26B%22%3c%2fb%3e">x</a>`
+	if content != want {
+		t.Fatalf("content = %q, want %q", content, want)
+	}
+
+	text := newRenderTestTemplate(t, TemplateTypeText, "s", "{{.name}}")
+	if _, content, _ := text.RenderTemplate(ChannelEmail, map[string]string{"name": "<b>"}); content != "<b>" {
+		t.Fatalf("text templates should not escape, got %q", content)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	variables := []TemplateVariable{{Name: "name", Required: true}, {Name: "note"}}
+
+	if err := ValidateTemplate(`{{if .note}}{{.note}}{{end}} {{.name}} {{.locale}}`, variables); err != nil {
+		t.Fatalf("valid template rejected: %v", err)
+	}
+
+	var domainErr *DomainError
+	if err := ValidateTemplate(`{{if .name}}`, variables); !errors.As(err, &domainErr) || domainErr.Code != ErrTemplateInvalidFormat {
+		t.Fatalf("expected syntax error, got %v", err)
+	}
+	if err := ValidateTemplate(`{{.name}} {{.other}}`, variables); !errors.As(err, &domainErr) || domainErr.Code != "UNDEFINED_VARIABLE" {
+		t.Fatalf("expected undefined variable error, got %v", err)
+	}
+	if err := ValidateTemplate(`{{.note}}`, variables); !errors.As(err, &domainErr) || domainErr.Code != "UNUSED_REQUIRED_VARIABLE" {
+		t.Fatalf("expected unused required variable error, got %v", err)
+	}
+}
+
+func TestValidateTemplateRejectsRangeOverVariable(t *testing.T) {
+	variables := []TemplateVariable{{Name: "items"}}
+	for _, content := range []string{
+		`{{range .items}}{{.}}{{end}}`,
+		`{{if .items}}{{range $i, $v := .items}}{{$v}}{{end}}{{end}}`,
+		`{{range split .items ","}}{{range $.items}}{{end}}{{end}}`,
+	} {
+		err := ValidateTemplate(content, variables)
+		var domainErr *DomainError
+		if !errors.As(err, &domainErr) || domainErr.Code != ErrTemplateInvalidFormat {
+			t.Fatalf("%s: expected invalid format error, got %v", content, err)
+		}
+	}
+
+	if err := ValidateTemplate(`{{range split .items ","}}{{.}}{{end}}`, variables); err != nil {
+		t.Fatalf("split range rejected: %v", err)
+	}
+}
+
+func TestRenderRangeOverSplitVariable(t *testing.T) {
+	out, err := renderString(`{{range split .items ","}}[{{.}}]{{else}}none{{end}}`, map[string]string{"items": "a,b"})
+	if err != nil || out != "[a][b]" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+	out, err = renderHTMLString(`{{range split .items ","}}<li>{{.}}</li>{{else}}none{{end}}`, map[string]string{"items": ""})
+	if err != nil || out != "none" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+}
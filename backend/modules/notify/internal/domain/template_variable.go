@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 模板变量类型，空类型按string处理
+const (
+	VariableTypeString = "string"
+	VariableTypeInt    = "int"
+	VariableTypeFloat  = "float"
+	VariableTypeBool   = "bool"
+	VariableTypeEmail  = "email"
+	VariableTypeURL    = "url"
+	VariableTypeDate   = "date"
+)
+
+// variableDateLayouts date类型变量接受的格式
+var variableDateLayouts = []string{"2006-01-02", time.RFC3339}
+
+// variableRangePattern 数值变量的范围规则，如 1..100、..100、0..
+var variableRangePattern = regexp.MustCompile(`^\s*(-?[0-9]+(?:\.[0-9]+)?)?\s*\.\.\s*(-?[0-9]+(?:\.[0-9]+)?)?\s*$`)
+
+// variablePatterns 已编译的变量验证正则，按表达式缓存，避免每次渲染重复编译
+var variablePatterns sync.Map
+
+// VariableViolation 验证失败的模板变量
+type VariableViolation struct {
+	Variable string `json:"variable"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Reason   string `json:"reason"`
+}
+
+// ErrInvalidTemplateVariables 模板变量验证错误，列出全部验证失败的变量
+type ErrInvalidTemplateVariables struct {
+	Violations []VariableViolation `json:"violations"`
+}
+
+func (e *ErrInvalidTemplateVariables) Error() string {
+	reasons := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		reasons[i] = violation.Variable + ": " + violation.Reason
+	}
+	return fmt.Sprintf("%s: invalid template variables (%s)", ErrTemplateInvalidVariable, strings.Join(reasons, "; "))
+}
+
+// ValidateVariables 按声明的类型和验证规则检查传入的变量值，返回全部验证失败项
+// 只检查已声明的变量；可选变量的空值视为未提供，不做检查
+func (t *NotificationTemplate) ValidateVariables(variables map[string]string) []VariableViolation {
+	var violations []VariableViolation
+	for _, variable := range t.Variables {
+		value, exists := variables[variable.Name]
+		if !exists || (value == "" && !variable.Required) {
+			continue
+		}
+		if reason := variable.validate(value); reason != "" {
+			violations = append(violations, VariableViolation{
+				Variable: variable.Name,
+				Type:     variable.valueType(),
+				Value:    value,
+				Reason:   reason,
+			})
+		}
+	}
+	return violations
+}
+
+// valueType 变量类型，空类型按string处理
+func (v *TemplateVariable) valueType() string {
+	if v.Type == "" {
+		return VariableTypeString
+	}
+	return strings.ToLower(v.Type)
+}
+
+// ValidateRule 检查变量的验证规则，int和float的范围规则之外必须是合法的正则表达式
+func (v *TemplateVariable) ValidateRule() error {
+	if v.isRangeRule() {
+		return nil
+	}
+	if _, err := compileVariablePattern(v.Validation); err != nil {
+		return NewDomainErrorWithDetails(ErrTemplateInvalidVariable, "invalid validation pattern for variable "+v.Name, err.Error())
+	}
+	return nil
+}
+
+// isRangeRule 验证规则是否为数值变量的范围规则
+func (v *TemplateVariable) isRangeRule() bool {
+	valueType := v.valueType()
+	return (valueType == VariableTypeInt || valueType == VariableTypeFloat) && variableRangePattern.MatchString(v.Validation)
+}
+
+// compileVariablePattern 编译变量验证正则，已编译的表达式直接从缓存返回
+func compileVariablePattern(expr string) (*regexp.Regexp, error) {
+	if cached, ok := variablePatterns.Load(expr); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	variablePatterns.Store(expr, pattern)
+	return pattern, nil
+}
+
+// validate 检查变量值，通过时返回空字符串，否则返回失败原因
+// Validation对int和float可以是范围规则（min..max，任一端可省略），否则按正则表达式匹配
+func (v *TemplateVariable) validate(value string) string {
+	valueType := v.valueType()
+
+	var number float64
+	switch valueType {
+	case VariableTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return "integer out of range"
+		}
+		if err != nil {
+			return "must be an integer"
+		}
+		number = float64(n)
+	case VariableTypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "must be a finite number"
+		}
+		number = f
+	case VariableTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be a boolean"
+		}
+	case VariableTypeEmail:
+		if !isValidEmail(value) {
+			return "must be a valid email address"
+		}
+	case VariableTypeURL:
+		u, err := url.ParseRequestURI(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URL"
+		}
+	case VariableTypeDate:
+		if !isValidDate(value) {
+			return "must be a date (YYYY-MM-DD or RFC 3339)"
+		}
+	}
+
+	if v.Validation == "" {
+		return ""
+	}
+	if valueType == VariableTypeInt || valueType == VariableTypeFloat {
+		if match := variableRangePattern.FindStringSubmatch(v.Validation); match != nil {
+			return checkRange(number, match[1], match[2])
+		}
+	}
+
+	pattern, err := compileVariablePattern(v.Validation)
+	if err != nil {
+		return "invalid validation pattern: " + err.Error()
+	}
+	if !pattern.MatchString(value) {
+		return "does not match pattern " + v.Validation
+	}
+	return ""
+}
+
+// checkRange 检查数值是否在范围内，空的边界表示不限制
+func checkRange(number float64, min, max string) string {
+	if min != "" {
+		if bound, _ := strconv.ParseFloat(min, 64); number < bound {
+			return "must be at least " + min
+		}
+	}
+	if max != "" {
+		if bound, _ := strconv.ParseFloat(max, 64); number > bound {
+			return "must be at most " + max
+		}
+	}
+	return ""
+}
+
+// isValidDate 检查日期是否为支持的格式
+func isValidDate(value string) bool {
+	for _, layout := range variableDateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newTypedTestTemplate 创建声明了类型和验证规则的模板
+func newTypedTestTemplate(t *testing.T) *NotificationTemplate {
+	t.Helper()
+	template := newRenderTestTemplate(t, TemplateTypeText, "Order {{.order_id}}", "{{.email}} ordered {{.quantity}} on {{.date}}, gift {{.gift}}, {{.link}}")
+	for _, variable := range []TemplateVariable{
+		{Name: "order_id", Validation: `^A-[0-9]+$`, Required: true},
+		{Name: "email", Type: VariableTypeEmail},
+		{Name: "quantity", Type: VariableTypeInt, Validation: "1..100"},
+		{Name: "price", Type: VariableTypeFloat, Validation: "0.."},
+		{Name: "gift", Type: VariableTypeBool},
+		{Name: "link", Type: VariableTypeURL},
+		{Name: "date", Type: VariableTypeDate},
+	} {
+		if err := template.AddVariable(variable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return template
+}
+
+func TestRenderTemplateValidVariables(t *testing.T) {
+	template := newTypedTestTemplate(t)
+
+	subject, content, err := template.RenderTemplate(ChannelEmail, map[string]string{
+		"order_id": "A-42",
+		"email":    "ann@example.com",
+		"quantity": "3",
+		"price":    "9.5",
+		"gift":     "true",
+		"link":     "https://example.com/orders/42",
+		"date":     "2024-05-01",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Order A-42" || content != "ann@example.com ordered 3 on 2024-05-01, gift true, https://example.com/orders/42" {
+		t.Fatalf("subject = %q, content = %q", subject, content)
+	}
+
+	// 未提供或为空的可选变量不检查
+	if _, _, err := template.RenderTemplate(ChannelEmail, map[string]string{"order_id": "A-1", "email": ""}); err != nil {
+		t.Fatalf("optional variables should not be validated when empty: %v", err)
+	}
+}
+
+func TestRenderTemplateRejectsInvalidVariables(t *testing.T) {
+	template := newTypedTestTemplate(t)
+
+	_, _, err := template.RenderTemplate(ChannelEmail, map[string]string{
+		"order_id": "A-42",
+		"email":    "ann@",
+		"quantity": "500",
+		"gift":     "maybe",
+		"date":     "01/05/2024",
+	})
+	var invalid *ErrInvalidTemplateVariables
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected ErrInvalidTemplateVariables, got %v", err)
+	}
+
+	// 列出全部验证失败项，按变量声明顺序排列
+	want := []VariableViolation{
+		{Variable: "email", Type: VariableTypeEmail, Value: "ann@", Reason: "must be a valid email address"},
+		{Variable: "quantity", Type: VariableTypeInt, Value: "500", Reason: "must be at most 100"},
+		{Variable: "gift", Type: VariableTypeBool, Value: "maybe", Reason: "must be a boolean"},
+		{Variable: "date", Type: VariableTypeDate, Value: "01/05/2024", Reason: "must be a date (YYYY-MM-DD or RFC 3339)"},
+	}
+	if !reflect.DeepEqual(invalid.Violations, want) {
+		t.Fatalf("violations = %+v, want %+v", invalid.Violations, want)
+	}
+}
+
+func TestValidateVariablesRules(t *testing.T) {
+	template := newTypedTestTemplate(t)
+
+	tests := []struct {
+		name   string
+		value  string
+		reason string
+	}{
+		{name: "order_id", value: "B-1", reason: "does not match pattern ^A-[0-9]+$"},
+		{name: "quantity", value: "0", reason: "must be at least 1"},
+		{name: "quantity", value: "2.5", reason: "must be an integer"},
+		{name: "quantity", value: "99999999999999999999", reason: "integer out of range"},
+		{name: "price", value: "-1", reason: "must be at least 0"},
+		{name: "price", value: "NaN", reason: "must be a finite number"},
+		{name: "link", value: "/orders/42", reason: "must be an absolute URL"},
+		{name: "date", value: "2024-05-01T10:00:00Z"},
+	}
+	for _, tt := range tests {
+		violations := template.ValidateVariables(map[string]string{tt.name: tt.value})
+		reason := ""
+		if len(violations) > 0 {
+			reason = violations[0].Reason
+		}
+		if len(violations) > 1 || reason != tt.reason {
+			t.Errorf("%s=%q: violations %+v, want reason %q", tt.name, tt.value, violations, tt.reason)
+		}
+	}
+}
+
+func TestAddVariableRejectsInvalidPattern(t *testing.T) {
+	template := newRenderTestTemplate(t, TemplateTypeText, "", "{{.code}}")
+
+	err := template.AddVariable(TemplateVariable{Name: "code", Validation: "[a-z"})
+	var domainErr *DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != ErrTemplateInvalidVariable {
+		t.Fatalf("expected %s, got %v", ErrTemplateInvalidVariable, err)
+	}
+
+	// 数值变量的范围规则不是正则表达式
+	if err := template.AddVariable(TemplateVariable{Name: "count", Type: VariableTypeInt, Validation: "..10"}); err != nil {
+		t.Fatal(err)
+	}
+}
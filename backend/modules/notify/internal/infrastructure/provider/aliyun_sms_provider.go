@@ -110,6 +110,9 @@ func (p *AliyunSMSProvider) SendSMS(ctx context.Context, data *service.SMSData,
 		zap.String("phone", data.Phone),
 		zap.String("biz_id", result.BizId))
 
+	// 回执通过BizId关联
+	data.MessageID = result.BizId
+
 	return nil
 }
 
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// LogDeliveryEventPublisher 基于日志的投递事件发布，以Info级别输出结构化事件供日志管道采集
+type LogDeliveryEventPublisher struct {
+	logger infrastructure.Logger
+}
+
+// NewLogDeliveryEventPublisher 创建日志投递事件发布
+func NewLogDeliveryEventPublisher(logger infrastructure.Logger) *LogDeliveryEventPublisher {
+	return &LogDeliveryEventPublisher{logger: logger}
+}
+
+// PublishDeliveryEvent 输出投递事件
+func (p *LogDeliveryEventPublisher) PublishDeliveryEvent(ctx context.Context, event *service.DeliveryEvent) error {
+	p.logger.Info("Notification delivery event",
+		zap.String("event", event.EventType),
+		zap.String("notification_id", event.NotificationID),
+		zap.String("channel", string(event.Channel)),
+		zap.String("status", string(event.Status)),
+		zap.Int("delivered", event.Delivered),
+		zap.Int("total", event.Total),
+		zap.Time("delivered_at", event.DeliveredAt),
+		zap.String("created_by", event.CreatedBy))
+	return nil
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// LogSLAAlerter 基于日志的SLA告警，以Error级别输出结构化事件供日志告警规则采集
+type LogSLAAlerter struct {
+	logger infrastructure.Logger
+}
+
+// NewLogSLAAlerter 创建日志SLA告警
+func NewLogSLAAlerter(logger infrastructure.Logger) *LogSLAAlerter {
+	return &LogSLAAlerter{logger: logger}
+}
+
+// AlertSLABreach 输出SLA违约事件
+func (a *LogSLAAlerter) AlertSLABreach(ctx context.Context, event *service.SLABreachEvent) error {
+	a.logger.Error("Notification SLA breached",
+		zap.String("event", event.EventType),
+		zap.String("notification_id", event.NotificationID),
+		zap.String("channel", string(event.Channel)),
+		zap.String("type", string(event.Type)),
+		zap.String("priority", string(event.Priority)),
+		zap.String("status", string(event.Status)),
+		zap.Duration("sla", event.SLA),
+		zap.Time("deadline", event.Deadline),
+		zap.Duration("overdue", event.Overdue),
+		zap.String("created_by", event.CreatedBy))
+	return nil
+}
@@ -38,6 +38,9 @@ func (p *ServerChanWebhookProvider) SendWebhook(ctx context.Context, data *servi
 	if p.isServerChanURL(data.URL) {
 		return p.sendServerChanMessage(ctx, data, config)
 	}
+	if p.isRobotURL(data.URL) {
+		return p.sendRobotMessage(ctx, data)
+	}
 
 	// 通用Webhook处理
 	return p.sendGenericWebhook(ctx, data, config)
@@ -48,6 +51,75 @@ func (p *ServerChanWebhookProvider) isServerChanURL(url string) bool {
 	return strings.Contains(url, "sctapi.ftqq.com") || strings.Contains(url, "sc.ftqq.com")
 }
 
+// isRobotURL 判断是否是钉钉/飞书群机器人URL
+func (p *ServerChanWebhookProvider) isRobotURL(url string) bool {
+	return strings.Contains(url, "oapi.dingtalk.com/robot") ||
+		strings.Contains(url, "open.feishu.cn/open-apis/bot") ||
+		strings.Contains(url, "open.larksuite.com/open-apis/bot")
+}
+
+// sendRobotMessage 发送钉钉/飞书群机器人消息
+// 机器人接口失败时仍返回HTTP 200，需要检查响应体中的错误码
+func (p *ServerChanWebhookProvider) sendRobotMessage(ctx context.Context, data *service.WebhookData) error {
+	payload, err := json.Marshal(data.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal robot message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", data.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range data.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Error("Failed to send robot webhook", zap.Error(err))
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return domain.ErrChannelRateLimitedf(robotChannel(data.URL), 0)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook failed with status %d", resp.StatusCode)
+	}
+
+	var response RobotResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to parse robot response: %w", err)
+	}
+
+	// 钉钉返回errcode/errmsg，飞书返回code/msg
+	code, message := response.ErrCode, response.ErrMsg
+	if code == 0 {
+		code, message = response.Code, response.Msg
+	}
+
+	switch code {
+	case 0:
+		p.logger.Info("Robot webhook sent successfully")
+		return nil
+	case dingTalkRateLimitCode, feishuRateLimitCode:
+		return domain.ErrChannelRateLimitedf(robotChannel(data.URL), time.Minute)
+	default:
+		return fmt.Errorf("robot webhook failed: code=%d, message=%s", code, message)
+	}
+}
+
+// robotChannel 根据机器人URL获取渠道名称
+func robotChannel(url string) string {
+	if strings.Contains(url, "dingtalk.com") {
+		return string(domain.ChannelDingTalk)
+	}
+	return string(domain.ChannelFeishu)
+}
+
 // sendServerChanMessage 发送Server酱消息
 func (p *ServerChanWebhookProvider) sendServerChanMessage(ctx context.Context, data *service.WebhookData, config *domain.ChannelConfig) error {
 	// Server酱消息格式
@@ -204,6 +276,21 @@ func (p *ServerChanWebhookProvider) GetProviderName() string {
 	return "webhook"
 }
 
+const (
+	// dingTalkRateLimitCode 钉钉机器人发送过于频繁的错误码（每分钟最多20条）
+	dingTalkRateLimitCode = 130101
+	// feishuRateLimitCode 飞书机器人请求频率超限的错误码
+	feishuRateLimitCode = 11232
+)
+
+// RobotResponse 钉钉/飞书群机器人响应结构
+type RobotResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+}
+
 // ServerChanMessage Server酱消息结构
 type ServerChanMessage struct {
 	Title   string `json:"title"`
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// slackPostMessageURL Slack chat.postMessage接口地址
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// SlackProvider Slack提供商，支持Incoming Webhook和Bot Token两种方式
+type SlackProvider struct {
+	logger infrastructure.Logger
+	client *http.Client
+}
+
+// NewSlackProvider 创建Slack提供商
+func NewSlackProvider(logger infrastructure.Logger) *SlackProvider {
+	return &SlackProvider{
+		logger: logger,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SendSlack 发送Slack消息
+// 配置了webhook_url时通过Incoming Webhook发送，否则使用bot_token调用chat.postMessage
+func (p *SlackProvider) SendSlack(ctx context.Context, data *service.SlackData, config *domain.ChannelConfig) error {
+	if webhookURL, exists := config.GetConfig("webhook_url"); exists {
+		p.logger.Info("Sending Slack message via incoming webhook")
+		return p.sendViaWebhook(ctx, webhookURL, data)
+	}
+
+	botToken, exists := config.GetConfig("bot_token")
+	if !exists {
+		return domain.ErrMissingConfigf("webhook_url or bot_token")
+	}
+
+	if data.Channel == "" {
+		data.Channel, _ = config.GetConfig("channel_id")
+	}
+	if data.Channel == "" {
+		return domain.ErrMissingConfigf("channel_id")
+	}
+
+	p.logger.Info("Sending Slack message via chat.postMessage",
+		zap.String("channel", data.Channel))
+	return p.sendViaAPI(ctx, botToken, data)
+}
+
+// sendViaWebhook 通过Incoming Webhook发送
+func (p *SlackProvider) sendViaWebhook(ctx context.Context, webhookURL string, data *service.SlackData) error {
+	// Incoming Webhook绑定了固定频道，不接受channel参数
+	payload := *data
+	payload.Channel = ""
+
+	body, err := p.post(ctx, webhookURL, "", &payload)
+	if err != nil {
+		return err
+	}
+
+	// Webhook成功时返回纯文本 "ok"
+	if strings.TrimSpace(string(body)) != "ok" {
+		return fmt.Errorf("Slack webhook failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	p.logger.Info("Slack message sent successfully via webhook")
+	return nil
+}
+
+// sendViaAPI 通过chat.postMessage发送
+func (p *SlackProvider) sendViaAPI(ctx context.Context, botToken string, data *service.SlackData) error {
+	body, err := p.post(ctx, slackPostMessageURL, botToken, data)
+	if err != nil {
+		return err
+	}
+
+	var response SlackAPIResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse Slack response: %w", err)
+	}
+	if !response.OK {
+		return fmt.Errorf("Slack chat.postMessage failed: %s", response.Error)
+	}
+
+	p.logger.Info("Slack message sent successfully",
+		zap.String("channel", response.Channel),
+		zap.String("ts", response.TS))
+	return nil
+}
+
+// post 发送JSON请求并返回响应体
+func (p *SlackProvider) post(ctx context.Context, url, botToken string, data *service.SlackData) ([]byte, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if botToken != "" {
+		req.Header.Set("Authorization", "Bearer "+botToken)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Error("Failed to send Slack request", zap.Error(err))
+		return nil, fmt.Errorf("failed to send Slack request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Slack response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Slack request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+// ValidateConfig 验证配置
+func (p *SlackProvider) ValidateConfig(config *domain.ChannelConfig) error {
+	if _, exists := config.GetConfig("webhook_url"); exists {
+		return nil
+	}
+
+	if _, exists := config.GetConfig("bot_token"); !exists {
+		return domain.NewDomainError("MISSING_CONFIG", "missing required Slack config: webhook_url or bot_token")
+	}
+	if _, exists := config.GetConfig("channel_id"); !exists {
+		return domain.NewDomainError("MISSING_CONFIG", "missing required Slack config: channel_id")
+	}
+
+	return nil
+}
+
+// GetProviderName 获取提供商名称
+func (p *SlackProvider) GetProviderName() string {
+	return "slack"
+}
+
+// SlackAPIResponse Slack Web API响应结构
+type SlackAPIResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	TS      string `json:"ts,omitempty"`
+}
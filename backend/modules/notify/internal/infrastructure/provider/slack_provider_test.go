@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"go.uber.org/zap"
+)
+
+// rewriteTransport 把发往服务商固定地址的请求转发到测试服务器
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestServer 启动测试服务器，返回转发到该服务器的HTTP客户端
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *http.Client) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+	return server, &http.Client{Transport: &rewriteTransport{target: target}}
+}
+
+func TestSlackWebhookOmitsChannel(t *testing.T) {
+	var received map[string]interface{}
+	server, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte("ok"))
+	})
+
+	p := NewSlackProvider(zap.NewNop())
+	config := &domain.ChannelConfig{Channel: domain.ChannelSlack, Config: map[string]string{"webhook_url": server.URL}}
+	data := &service.SlackData{Channel: "#ops", Text: "deploy finished"}
+
+	if err := p.SendSlack(context.Background(), data, config); err != nil {
+		t.Fatal(err)
+	}
+	if received["text"] != "deploy finished" || received["channel"] != "" {
+		t.Fatalf("unexpected webhook payload: %v", received)
+	}
+	if data.Channel != "#ops" {
+		t.Fatalf("caller data should not be modified, channel = %q", data.Channel)
+	}
+}
+
+func TestSlackWebhookRejectsNonOKBody(t *testing.T) {
+	server, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("invalid_payload"))
+	})
+
+	p := NewSlackProvider(zap.NewNop())
+	config := &domain.ChannelConfig{Channel: domain.ChannelSlack, Config: map[string]string{"webhook_url": server.URL}}
+	if err := p.SendSlack(context.Background(), &service.SlackData{Text: "x"}, config); err == nil {
+		t.Fatal("expected webhook error")
+	}
+}
+
+func TestSlackAPIUsesBotTokenAndConfiguredChannel(t *testing.T) {
+	var auth string
+	var received service.SlackData
+	_, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1.2"}`))
+	})
+
+	p := NewSlackProvider(zap.NewNop())
+	p.client = client
+	config := &domain.ChannelConfig{Channel: domain.ChannelSlack, Config: map[string]string{"bot_token": "xoxb-1", "channel_id": "C123"}}
+
+	if err := p.SendSlack(context.Background(), &service.SlackData{Text: "hello"}, config); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer xoxb-1" || received.Channel != "C123" {
+		t.Fatalf("auth = %q, channel = %q", auth, received.Channel)
+	}
+}
+
+func TestSlackAPIErrorResponse(t *testing.T) {
+	_, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	})
+
+	p := NewSlackProvider(zap.NewNop())
+	p.client = client
+	config := &domain.ChannelConfig{Channel: domain.ChannelSlack, Config: map[string]string{"bot_token": "xoxb-1", "channel_id": "C404"}}
+
+	err := p.SendSlack(context.Background(), &service.SlackData{Text: "hello"}, config)
+	if err == nil || err.Error() != "Slack chat.postMessage failed: channel_not_found" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSlackValidateConfig(t *testing.T) {
+	p := NewSlackProvider(zap.NewNop())
+	cases := []struct {
+		config map[string]string
+		valid  bool
+	}{
+		{map[string]string{"webhook_url": "https://hooks.slack.com/x"}, true},
+		{map[string]string{"bot_token": "xoxb-1", "channel_id": "C1"}, true},
+		{map[string]string{"bot_token": "xoxb-1"}, false},
+		{map[string]string{}, false},
+	}
+	for _, c := range cases {
+		err := p.ValidateConfig(&domain.ChannelConfig{Channel: domain.ChannelSlack, Config: c.config})
+		if (err == nil) != c.valid {
+			t.Fatalf("config %v: err = %v", c.config, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+const (
+	// telegramAPIBaseURL Telegram Bot API地址
+	telegramAPIBaseURL = "https://api.telegram.org"
+	// TelegramMaxMessageLength Telegram单条消息的最大字符数
+	TelegramMaxMessageLength = 4096
+)
+
+// TelegramProvider Telegram提供商，通过Bot API的sendMessage发送消息
+type TelegramProvider struct {
+	logger infrastructure.Logger
+	client *http.Client
+}
+
+// NewTelegramProvider 创建Telegram提供商
+func NewTelegramProvider(logger infrastructure.Logger) *TelegramProvider {
+	return &TelegramProvider{
+		logger: logger,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SendTelegram 发送Telegram消息
+// 超过4096字符的内容会拆分为多条消息依次发送，reply_markup只附加在最后一条；
+// 跳过data.SentParts条已发送的消息，每成功发送一条更新data.SentParts，重试时不会重复发送
+func (p *TelegramProvider) SendTelegram(ctx context.Context, data *service.TelegramData, config *domain.ChannelConfig) error {
+	botToken, exists := config.GetConfig("bot_token")
+	if !exists {
+		return domain.ErrMissingConfigf("bot_token")
+	}
+
+	if data.ChatID == "" {
+		data.ChatID, _ = config.GetConfig("chat_id")
+	}
+	if data.ChatID == "" {
+		return domain.ErrMissingConfigf("chat_id")
+	}
+
+	parts := SplitTelegramMessage(data.Text, TelegramMaxMessageLength)
+
+	p.logger.Info("Sending Telegram message",
+		zap.String("chat_id", data.ChatID),
+		zap.Int("parts", len(parts)),
+		zap.Int("sent_parts", data.SentParts))
+
+	for i := data.SentParts; i < len(parts); i++ {
+		text := parts[i]
+		message := service.TelegramData{
+			ChatID:    data.ChatID,
+			Text:      text,
+			ParseMode: data.ParseMode,
+		}
+		if i == len(parts)-1 {
+			message.ReplyMarkup = data.ReplyMarkup
+		}
+
+		if err := p.sendMessage(ctx, botToken, &message); err != nil {
+			return err
+		}
+		data.SentParts = i + 1
+	}
+
+	p.logger.Info("Telegram message sent successfully",
+		zap.String("chat_id", data.ChatID))
+	return nil
+}
+
+// sendMessage 调用sendMessage接口发送单条消息
+func (p *TelegramProvider) sendMessage(ctx context.Context, botToken string, data *service.TelegramData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIBaseURL, botToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Error("Failed to send Telegram request", zap.Error(err))
+		return fmt.Errorf("failed to send Telegram request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Telegram response: %w", err)
+	}
+
+	var response TelegramAPIResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse Telegram response (status %d): %w", resp.StatusCode, err)
+	}
+
+	// 429限流返回可重试错误，由重试任务在稍后重新投递
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := time.Duration(response.Parameters.RetryAfter) * time.Second
+		p.logger.Warn("Telegram rate limit exceeded",
+			zap.String("chat_id", data.ChatID),
+			zap.Duration("retry_after", retryAfter))
+		return domain.ErrChannelRateLimitedf(string(domain.ChannelTelegram), retryAfter)
+	}
+
+	if !response.OK {
+		return fmt.Errorf("Telegram sendMessage failed with status %d: %s", resp.StatusCode, response.Description)
+	}
+
+	return nil
+}
+
+// SplitTelegramMessage 按字符数拆分消息
+// 优先在换行处拆分，其次在空格处，都没有时按limit硬拆分
+func SplitTelegramMessage(text string, limit int) []string {
+	runes := []rune(text)
+	if limit <= 0 || len(runes) <= limit {
+		return []string{text}
+	}
+
+	var parts []string
+	for len(runes) > limit {
+		cut := lastIndexRune(runes[:limit], '\n')
+		if cut <= 0 {
+			cut = lastIndexRune(runes[:limit], ' ')
+		}
+
+		if cut <= 0 {
+			parts = append(parts, string(runes[:limit]))
+			runes = runes[limit:]
+			continue
+		}
+
+		parts = append(parts, strings.TrimRight(string(runes[:cut]), " \n"))
+		// 丢弃作为分隔符的换行或空格
+		runes = runes[cut+1:]
+	}
+
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+
+	return parts
+}
+
+// lastIndexRune 查找字符最后出现的位置
+func lastIndexRune(runes []rune, r rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateConfig 验证配置
+func (p *TelegramProvider) ValidateConfig(config *domain.ChannelConfig) error {
+	requiredFields := []string{"bot_token", "chat_id"}
+
+	for _, field := range requiredFields {
+		if _, exists := config.GetConfig(field); !exists {
+			return domain.NewDomainError("MISSING_CONFIG", "missing required Telegram config: "+field)
+		}
+	}
+
+	return nil
+}
+
+// GetProviderName 获取提供商名称
+func (p *TelegramProvider) GetProviderName() string {
+	return "telegram"
+}
+
+// TelegramAPIResponse Telegram Bot API响应结构
+type TelegramAPIResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description,omitempty"`
+	ErrorCode   int    `json:"error_code,omitempty"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after,omitempty"`
+	} `json:"parameters,omitempty"`
+}
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"go.uber.org/zap"
+)
+
+func telegramConfig() *domain.ChannelConfig {
+	return &domain.ChannelConfig{Channel: domain.ChannelTelegram, Config: map[string]string{"bot_token": "token", "chat_id": "42"}}
+}
+
+func TestSplitTelegramMessage(t *testing.T) {
+	if parts := SplitTelegramMessage("short", 10); len(parts) != 1 || parts[0] != "short" {
+		t.Fatalf("unexpected parts: %q", parts)
+	}
+
+	parts := SplitTelegramMessage("line one\nline two\nline three", 12)
+	if strings.Join(parts, "|") != "line one|line two|line three" {
+		t.Fatalf("should split at newlines: %q", parts)
+	}
+
+	parts = SplitTelegramMessage("alpha beta gamma", 11)
+	if strings.Join(parts, "|") != "alpha beta|gamma" {
+		t.Fatalf("should split at spaces: %q", parts)
+	}
+
+	text := strings.Repeat("中", 25)
+	parts = SplitTelegramMessage(text, 10)
+	if len(parts) != 3 || strings.Join(parts, "") != text {
+		t.Fatalf("should hard split by characters: %q", parts)
+	}
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) > 10 {
+			t.Fatalf("part exceeds limit: %q", part)
+		}
+	}
+}
+
+func TestSendTelegramAttachesReplyMarkupToLastPart(t *testing.T) {
+	var messages []service.TelegramData
+	_, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/bottoken/sendMessage") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var message service.TelegramData
+		json.NewDecoder(r.Body).Decode(&message)
+		messages = append(messages, message)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	p := NewTelegramProvider(zap.NewNop())
+	p.client = client
+	text := strings.Repeat("a", 3000) + "\n" + strings.Repeat("b", 3000)
+	data := &service.TelegramData{Text: text, ParseMode: "HTML", ReplyMarkup: `{"inline_keyboard":[]}`}
+
+	if err := p.SendTelegram(context.Background(), data, telegramConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(messages))
+	}
+	if messages[0].ReplyMarkup != "" || messages[1].ReplyMarkup == "" {
+		t.Fatalf("reply markup should only be on the last part: %+v", messages)
+	}
+	if messages[0].ChatID != "42" || messages[1].ParseMode != "HTML" {
+		t.Fatalf("unexpected message fields: %+v", messages[1])
+	}
+}
+
+func TestSendTelegramRetrySkipsSentParts(t *testing.T) {
+	var texts []string
+	failAt := 2
+	_, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var message service.TelegramData
+		json.NewDecoder(r.Body).Decode(&message)
+		if len(texts) == failAt {
+			failAt = -1
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"description":"Too Many Requests","parameters":{"retry_after":1}}`))
+			return
+		}
+		texts = append(texts, message.Text)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	p := NewTelegramProvider(zap.NewNop())
+	p.client = client
+	text := strings.Repeat("a", 3000) + "\n" + strings.Repeat("b", 3000) + "\n" + strings.Repeat("c", 3000)
+	data := &service.TelegramData{Text: text}
+
+	if err := p.SendTelegram(context.Background(), data, telegramConfig()); err == nil {
+		t.Fatal("expected rate limit error")
+	}
+	if data.SentParts != 2 {
+		t.Fatalf("sent parts = %d, want 2", data.SentParts)
+	}
+
+	retry := &service.TelegramData{Text: text, SentParts: data.SentParts}
+	if err := p.SendTelegram(context.Background(), retry, telegramConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 3 || texts[2] != strings.Repeat("c", 3000) {
+		t.Fatalf("retry should only send the remaining part, sent %d messages", len(texts))
+	}
+	if retry.SentParts != 3 {
+		t.Fatalf("sent parts = %d, want 3", retry.SentParts)
+	}
+}
+
+func TestSendTelegramRequiresChatID(t *testing.T) {
+	p := NewTelegramProvider(zap.NewNop())
+	config := &domain.ChannelConfig{Channel: domain.ChannelTelegram, Config: map[string]string{"bot_token": "token"}}
+	if err := p.SendTelegram(context.Background(), &service.TelegramData{Text: "x"}, config); err == nil {
+		t.Fatal("expected missing chat_id error")
+	}
+}
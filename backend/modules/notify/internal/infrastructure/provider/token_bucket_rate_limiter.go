@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucketRateLimiter 进程内令牌桶限流器
+// 每个key一个令牌桶，容量为每分钟限额，令牌按 限额/分钟 的速率匀速补充
+type TokenBucketRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+// tokenBucket 令牌桶状态
+type tokenBucket struct {
+	limit      int
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewTokenBucketRateLimiter 创建令牌桶限流器
+func NewTokenBucketRateLimiter() *TokenBucketRateLimiter {
+	return &TokenBucketRateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow 尝试获取一次发送配额，limitPerMinute小于等于0时不限流
+func (l *TokenBucketRateLimiter) Allow(ctx context.Context, key string, limitPerMinute int) (bool, time.Duration, error) {
+	if limitPerMinute <= 0 {
+		return true, 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucket, exists := l.buckets[key]
+	// 限额变更后按新限额重新开始计算
+	if !exists || bucket.limit != limitPerMinute {
+		bucket = &tokenBucket{
+			limit:      limitPerMinute,
+			tokens:     float64(limitPerMinute),
+			lastRefill: now,
+		}
+		l.buckets[key] = bucket
+	}
+
+	ratePerSecond := float64(limitPerMinute) / time.Minute.Seconds()
+	if elapsed := now.Sub(bucket.lastRefill).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * ratePerSecond
+		if bucket.tokens > float64(limitPerMinute) {
+			bucket.tokens = float64(limitPerMinute)
+		}
+		bucket.lastRefill = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+
+	retryAfter := time.Duration((1 - bucket.tokens) / ratePerSecond * float64(time.Second))
+	return false, retryAfter, nil
+}
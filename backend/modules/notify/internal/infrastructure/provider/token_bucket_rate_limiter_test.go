@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestRateLimiter 创建使用可控时钟的限流器
+func newTestRateLimiter() (*TokenBucketRateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewTokenBucketRateLimiter()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+// countAllowed 连续请求n次，返回允许的次数
+func countAllowed(t *testing.T, limiter *TokenBucketRateLimiter, key string, limit, n int) int {
+	t.Helper()
+	allowed := 0
+	for i := 0; i < n; i++ {
+		ok, _, err := limiter.Allow(context.Background(), key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestTokenBucketAllowedAndBlockedCounts(t *testing.T) {
+	limiter, now := newTestRateLimiter()
+
+	if allowed := countAllowed(t, limiter, "telegram:u1", 10, 15); allowed != 10 {
+		t.Fatalf("burst allowed %d, want 10", allowed)
+	}
+
+	ok, retryAfter, _ := limiter.Allow(context.Background(), "telegram:u1", 10)
+	if ok || retryAfter != 6*time.Second {
+		t.Fatalf("allowed = %v, retry_after = %s, want blocked for 6s", ok, retryAfter)
+	}
+
+	// 半分钟补充5个令牌
+	*now = now.Add(30 * time.Second)
+	if allowed := countAllowed(t, limiter, "telegram:u1", 10, 10); allowed != 5 {
+		t.Fatalf("after 30s allowed %d, want 5", allowed)
+	}
+
+	// 令牌不超过桶容量
+	*now = now.Add(10 * time.Minute)
+	if allowed := countAllowed(t, limiter, "telegram:u1", 10, 20); allowed != 10 {
+		t.Fatalf("after idle allowed %d, want 10", allowed)
+	}
+}
+
+func TestTokenBucketKeysAreIndependent(t *testing.T) {
+	limiter, _ := newTestRateLimiter()
+
+	if allowed := countAllowed(t, limiter, "email:u1", 2, 5); allowed != 2 {
+		t.Fatalf("email:u1 allowed %d, want 2", allowed)
+	}
+	if allowed := countAllowed(t, limiter, "email:u2", 2, 5); allowed != 2 {
+		t.Fatalf("email:u2 allowed %d, want 2", allowed)
+	}
+}
+
+func TestTokenBucketUnlimitedAndLimitChange(t *testing.T) {
+	limiter, _ := newTestRateLimiter()
+
+	if allowed := countAllowed(t, limiter, "sms:u1", 0, 100); allowed != 100 {
+		t.Fatalf("unlimited allowed %d, want 100", allowed)
+	}
+
+	countAllowed(t, limiter, "sms:u1", 1, 2)
+	// 限额变更后按新限额重新计算
+	if allowed := countAllowed(t, limiter, "sms:u1", 3, 5); allowed != 3 {
+		t.Fatalf("after limit change allowed %d, want 3", allowed)
+	}
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/dberror"
+)
+
+// translateError 将数据库错误转换为仓储错误，唯一约束冲突转换为repository.ErrDuplicateKey
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if dberror.IsDuplicateKey(err) {
+		return fmt.Errorf("%w: %v", repository.ErrDuplicateKey, err)
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// GormEngagementRepository GORM互动事件仓储实现
+type GormEngagementRepository struct {
+	db *gorm.DB
+}
+
+// NewGormEngagementRepository 创建GORM互动事件仓储
+func NewGormEngagementRepository(db *gorm.DB) repository.EngagementRepository {
+	return &GormEngagementRepository{
+		db: db,
+	}
+}
+
+// Save 保存互动事件
+func (r *GormEngagementRepository) Save(ctx context.Context, event *domain.EngagementEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// CountByNotificationID 按接收者和互动类型聚合通知的互动事件
+func (r *GormEngagementRepository) CountByNotificationID(ctx context.Context, notificationID string) ([]domain.EngagementCount, error) {
+	var counts []domain.EngagementCount
+	err := r.db.WithContext(ctx).
+		Model(&domain.EngagementEvent{}).
+		Select("recipient_id, type, COUNT(*) AS count, MIN(created_at) AS first_at, MAX(created_at) AS last_at").
+		Where("notification_id = ?", notificationID).
+		Group("recipient_id, type").
+		Scan(&counts).Error
+
+	return counts, err
+}
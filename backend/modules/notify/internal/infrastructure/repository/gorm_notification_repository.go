@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/notify/internal/domain"
 	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormNotificationRepository GORM通知仓储实现
@@ -22,7 +25,7 @@ func NewGormNotificationRepository(db *gorm.DB) repository.NotificationRepositor
 
 // Save 保存通知
 func (r *GormNotificationRepository) Save(ctx context.Context, notification *domain.Notification) error {
-	return r.db.WithContext(ctx).Create(notification).Error
+	return translateError(r.db.WithContext(ctx).Create(notification).Error)
 }
 
 // FindByID 根据ID查找通知
@@ -42,12 +45,29 @@ func (r *GormNotificationRepository) FindByID(ctx context.Context, id string) (*
 	return &notification, nil
 }
 
+// FindByIdempotencyKey 根据创建者和幂等键查找通知
+func (r *GormNotificationRepository) FindByIdempotencyKey(ctx context.Context, createdBy, idempotencyKey string) (*domain.Notification, error) {
+	var notification domain.Notification
+	err := r.db.WithContext(ctx).
+		Preload("Recipients").
+		First(&notification, "created_by = ? AND idempotency_key = ?", createdBy, idempotencyKey).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
 // Update 更新通知
 func (r *GormNotificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
 	return r.db.WithContext(ctx).Save(notification).Error
 }
 
-// Delete 删除通知
+// Delete 软删除通知，接收者保留到永久删除
 func (r *GormNotificationRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(&domain.Notification{}, "id = ?", id).Error
 }
@@ -174,18 +194,114 @@ func (r *GormNotificationRepository) FindByCreatedByWithPagination(ctx context.C
 	return notifications, total, err
 }
 
-// FindScheduledNotifications 查找定时通知
+// FindAfterCursor 游标分页查找通知
+func (r *GormNotificationRepository) FindAfterCursor(ctx context.Context, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error) {
+	return r.findAfterCursor(r.db.WithContext(ctx), cursor, limit)
+}
+
+// FindByStatusAfterCursor 根据状态游标分页查找通知
+func (r *GormNotificationRepository) FindByStatusAfterCursor(ctx context.Context, status domain.NotificationStatus, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error) {
+	return r.findAfterCursor(r.db.WithContext(ctx).Where("status = ?", status), cursor, limit)
+}
+
+// FindByCreatedByAfterCursor 根据创建者游标分页查找通知
+func (r *GormNotificationRepository) FindByCreatedByAfterCursor(ctx context.Context, createdBy string, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error) {
+	return r.findAfterCursor(r.db.WithContext(ctx).Where("created_by = ?", createdBy), cursor, limit)
+}
+
+// findAfterCursor 查询cursor之后的一页通知
+func (r *GormNotificationRepository) findAfterCursor(query *gorm.DB, cursor *pagination.Cursor, limit int) ([]*domain.Notification, *pagination.Cursor, error) {
+	return pagination.FindAfterCursor(query, cursor, limit, func(n *domain.Notification) (time.Time, string) {
+		return n.CreatedAt, n.ID
+	})
+}
+
+// FindScheduledNotifications 查找到期且尚未被认领的定时通知，认领后状态为发送中，不再返回
 func (r *GormNotificationRepository) FindScheduledNotifications(ctx context.Context, beforeTime int64) ([]*domain.Notification, error) {
 	var notifications []*domain.Notification
 	err := r.db.WithContext(ctx).
-		Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= FROM_UNIXTIME(?)", 
-			domain.NotificationStatusPending, beforeTime).
+		Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", 
+			domain.NotificationStatusPending, time.Unix(beforeTime, 0)).
 		Order("scheduled_at ASC").
 		Find(&notifications).Error
 	
 	return notifications, err
 }
 
+// ClaimNotification 认领通知，条件更新保证并发认领时只有一个成功
+func (r *GormNotificationRepository) ClaimNotification(ctx context.Context, id string, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Notification{}).
+		Where("id = ? AND status = ?", id, domain.NotificationStatusPending).
+		Where("scheduled_at IS NULL OR scheduled_at <= ?", now).
+		Updates(map[string]interface{}{
+			"status":     domain.NotificationStatusSending,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ClaimScheduledNotifications 在事务中锁定并认领到期的通知，SKIP LOCKED跳过其他实例已锁定的行
+func (r *GormNotificationRepository) ClaimScheduledNotifications(ctx context.Context, now, staleBefore time.Time, limit int) ([]*domain.Notification, error) {
+	var notifications []*domain.Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?) OR "+
+				"(status = ? AND scheduled_at IS NULL AND created_at < ?) OR "+
+				"(status = ? AND updated_at < ?)",
+				domain.NotificationStatusPending, now,
+				domain.NotificationStatusPending, staleBefore,
+				domain.NotificationStatusSending, staleBefore).
+			Order("COALESCE(scheduled_at, created_at) ASC").
+			Limit(limit).
+			Find(&notifications).Error
+		if err != nil || len(notifications) == 0 {
+			return err
+		}
+		
+		ids := make([]string, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID
+		}
+		
+		err = tx.Model(&domain.Notification{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":     domain.NotificationStatusSending,
+				"updated_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		
+		for _, notification := range notifications {
+			notification.Status = domain.NotificationStatusSending
+			notification.UpdatedAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return notifications, nil
+}
+
+// RenewClaim 以发送中状态为条件刷新updated_at，认领超时按updated_at判断
+func (r *GormNotificationRepository) RenewClaim(ctx context.Context, id string, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Notification{}).
+		Where("id = ? AND status = ?", id, domain.NotificationStatusSending).
+		Update("updated_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // FindPendingNotifications 查找待发送通知
 func (r *GormNotificationRepository) FindPendingNotifications(ctx context.Context, limit int) ([]*domain.Notification, error) {
 	var notifications []*domain.Notification
@@ -211,13 +327,54 @@ func (r *GormNotificationRepository) FindFailedNotifications(ctx context.Context
 	return notifications, err
 }
 
-// FindRetryableNotifications 查找可重试的通知
+// FindRetryableNotifications 查找可重试且已到重试时间的通知
 func (r *GormNotificationRepository) FindRetryableNotifications(ctx context.Context, limit int) ([]*domain.Notification, error) {
 	var notifications []*domain.Notification
 	err := r.db.WithContext(ctx).
 		Where("status = ? AND retry_count < max_retries", domain.NotificationStatusFailed).
+		Where("next_retry_at IS NULL OR next_retry_at <= NOW()").
+		Limit(limit).
+		Order("next_retry_at ASC").
+		Find(&notifications).Error
+	
+	return notifications, err
+}
+
+// FindSLABreachedNotifications 查找超过SLA仍处于待发送或发送中、且尚未报告的通知
+func (r *GormNotificationRepository) FindSLABreachedNotifications(ctx context.Context, now time.Time, limit int) ([]*domain.Notification, error) {
+	var notifications []*domain.Notification
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []domain.NotificationStatus{domain.NotificationStatusPending, domain.NotificationStatusSending}).
+		Where("sla_seconds > 0 AND sla_breached_at IS NULL").
+		Where("GREATEST(created_at, COALESCE(scheduled_at, created_at)) + sla_seconds * INTERVAL '1 second' < ?", now).
+		Limit(limit).
+		Order("created_at ASC").
+		Find(&notifications).Error
+	
+	return notifications, err
+}
+
+// FindUpcomingNotifications 查找计划发送的通知
+func (r *GormNotificationRepository) FindUpcomingNotifications(ctx context.Context, before time.Time, filter repository.ScheduleFilter, limit int) ([]*domain.Notification, error) {
+	query := r.db.WithContext(ctx).
+		Where("((status = ? AND scheduled_at IS NOT NULL AND scheduled_at < ?) OR "+
+			"(status = ? AND retry_count < max_retries AND (next_retry_at IS NULL OR next_retry_at < ?)))",
+			domain.NotificationStatusPending, before, domain.NotificationStatusFailed, before)
+	
+	if filter.CreatedBy != "" {
+		query = query.Where("created_by = ?", filter.CreatedBy)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	
+	var notifications []*domain.Notification
+	err := query.
+		Order("CASE WHEN status = 'pending' THEN scheduled_at ELSE next_retry_at END ASC NULLS FIRST").
 		Limit(limit).
-		Order("failed_at ASC").
 		Find(&notifications).Error
 	
 	return notifications, err
@@ -249,7 +406,7 @@ func (r *GormNotificationRepository) SearchByTitle(ctx context.Context, query st
 
 // SaveBatch 批量保存通知
 func (r *GormNotificationRepository) SaveBatch(ctx context.Context, notifications []*domain.Notification) error {
-	return r.db.WithContext(ctx).CreateInBatches(notifications, 100).Error
+	return translateError(r.db.WithContext(ctx).CreateInBatches(notifications, 100).Error)
 }
 
 // UpdateBatch 批量更新通知
@@ -362,6 +519,7 @@ func (r *GormNotificationRepository) GetChannelStats(ctx context.Context) ([]rep
 			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as failed_count,
 			MAX(sent_at) as last_sent_at
 		FROM notifications 
+		WHERE deleted_at IS NULL
 		GROUP BY channel
 	`, domain.NotificationStatusSent, domain.NotificationStatusDelivered, domain.NotificationStatusFailed).Rows()
 	
@@ -417,3 +575,47 @@ func (r *GormNotificationRepository) DeleteCancelledNotifications(ctx context.Co
 	
 	return result.RowsAffected, result.Error
 }
+
+// FindIncludingDeleted 根据ID查找通知，包括已软删除的通知
+func (r *GormNotificationRepository) FindIncludingDeleted(ctx context.Context, id string) (*domain.Notification, error) {
+	var notification domain.Notification
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("Recipients").
+		First(&notification, "id = ?", id).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// PurgeDeleted 永久删除before之前软删除的通知，同一事务中删除其接收者和互动事件
+func (r *GormNotificationRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Unscoped().
+			Model(&domain.Notification{}).
+			Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+
+		if err := tx.Where("notification_id IN (?)", deleted).Delete(&domain.Recipient{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("notification_id IN (?)", deleted).Delete(&domain.EngagementEvent{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Delete(&domain.Notification{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+
+	return purged, err
+}
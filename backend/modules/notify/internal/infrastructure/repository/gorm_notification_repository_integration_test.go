@@ -0,0 +1,117 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// newIntegrationDB 连接POSTGRES_DSN指定的数据库，在临时schema中建表，未设置时跳过测试
+func newIntegrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN is not set")
+	}
+	schemaName := fmt.Sprintf("it_notify_%d", time.Now().UnixNano())
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{TablePrefix: schemaName + "."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec("CREATE SCHEMA " + schemaName).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DROP SCHEMA " + schemaName + " CASCADE") })
+	if err := db.AutoMigrate(&domain.Notification{}, &domain.Recipient{}, &domain.EngagementEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newIntegrationNotification(t *testing.T, title string) *domain.Notification {
+	t.Helper()
+	notification, err := domain.NewNotification(title, "content", domain.NotificationTypeSystem, domain.ChannelEmail, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := domain.NewRecipient(notification.ID, domain.RecipientTypeEmail, "ann@example.com", domain.ChannelEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notification.AddRecipient(*recipient)
+	return notification
+}
+
+func TestSoftDeletedNotificationLifecycle(t *testing.T) {
+	repo := NewGormNotificationRepository(newIntegrationDB(t))
+	ctx := context.Background()
+
+	kept := newIntegrationNotification(t, "kept")
+	deleted := newIntegrationNotification(t, "deleted")
+	for _, notification := range []*domain.Notification{kept, deleted} {
+		if err := repo.Save(ctx, notification); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 软删除的通知从普通查询中消失
+	pending, err := repo.FindByStatus(ctx, domain.NotificationStatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != kept.ID {
+		t.Fatalf("FindByStatus returned %d notifications, want only %s", len(pending), kept.ID)
+	}
+	if found, err := repo.FindByID(ctx, deleted.ID); err != nil || found != nil {
+		t.Fatalf("FindByID returned %v, %v for a deleted notification", found, err)
+	}
+
+	// 清除前仍可连同接收者一起找回
+	found, err := repo.FindIncludingDeleted(ctx, deleted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || !found.DeletedAt.Valid || len(found.Recipients) != 1 {
+		t.Fatalf("FindIncludingDeleted returned %+v", found)
+	}
+
+	// 只清除截止时间之前删除的通知
+	if purged, err := repo.PurgeDeleted(ctx, found.DeletedAt.Time.Add(-time.Second)); err != nil || purged != 0 {
+		t.Fatalf("PurgeDeleted before the deletion purged %d, %v", purged, err)
+	}
+	purged, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %d notifications, want 1", purged)
+	}
+	if found, err := repo.FindIncludingDeleted(ctx, deleted.ID); err != nil || found != nil {
+		t.Fatalf("purged notification still found: %v, %v", found, err)
+	}
+
+	// 未删除的通知及其接收者不受影响
+	found, err = repo.FindByID(ctx, kept.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || len(found.Recipients) != 1 {
+		t.Fatalf("kept notification after purge: %+v", found)
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder 记录生成的SQL
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface { return r }
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// last 返回最后一条SQL并清空记录
+func (r *sqlRecorder) last(t *testing.T) string {
+	t.Helper()
+	if len(r.statements) == 0 {
+		t.Fatal("no statement was generated")
+	}
+	sql := r.statements[len(r.statements)-1]
+	r.statements = nil
+	return sql
+}
+
+// newDryRunNotificationRepository 创建只生成SQL、不连接数据库的通知仓储
+func newDryRunNotificationRepository(t *testing.T) (*GormNotificationRepository, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &GormNotificationRepository{db: db}, recorder
+}
+
+func TestNotificationDeleteIsSoft(t *testing.T) {
+	repo, recorder := newDryRunNotificationRepository(t)
+	ctx := context.Background()
+
+	if err := repo.Delete(ctx, "n-1"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := recorder.last(t); !strings.HasPrefix(sql, `UPDATE "notifications" SET "deleted_at"=`) {
+		t.Fatalf("delete should set deleted_at, got %s", sql)
+	}
+
+	// 普通查询排除已删除的通知，包含已删除的查找不加条件
+	if _, err := repo.FindByStatus(ctx, "pending"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := recorder.last(t); !strings.Contains(sql, `"notifications"."deleted_at" IS NULL`) {
+		t.Fatalf("FindByStatus should exclude deleted notifications, got %s", sql)
+	}
+	if _, err := repo.FindIncludingDeleted(ctx, "n-1"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := recorder.last(t); strings.Contains(sql, "deleted_at") {
+		t.Fatalf("FindIncludingDeleted should not filter deleted notifications, got %s", sql)
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// GormRecipientGroupRepository GORM接收者组仓储实现
+type GormRecipientGroupRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRecipientGroupRepository 创建GORM接收者组仓储
+func NewGormRecipientGroupRepository(db *gorm.DB) repository.RecipientGroupRepository {
+	return &GormRecipientGroupRepository{
+		db: db,
+	}
+}
+
+// Save 保存接收者组及其成员
+func (r *GormRecipientGroupRepository) Save(ctx context.Context, group *domain.RecipientGroup) error {
+	return translateError(r.db.WithContext(ctx).Create(group).Error)
+}
+
+// FindByID 根据ID查找接收者组
+func (r *GormRecipientGroupRepository) FindByID(ctx context.Context, id string) (*domain.RecipientGroup, error) {
+	return r.findOne(ctx, "id = ?", id)
+}
+
+// FindByOwnerAndName 根据所有者和名称查找接收者组
+func (r *GormRecipientGroupRepository) FindByOwnerAndName(ctx context.Context, ownerID, name string) (*domain.RecipientGroup, error) {
+	return r.findOne(ctx, "owner_id = ? AND name = ?", ownerID, name)
+}
+
+// FindByOwner 查找所有者的接收者组
+func (r *GormRecipientGroupRepository) FindByOwner(ctx context.Context, ownerID string) ([]*domain.RecipientGroup, error) {
+	var groups []*domain.RecipientGroup
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		Where("owner_id = ?", ownerID).
+		Order("name ASC").
+		Find(&groups).Error
+
+	return groups, err
+}
+
+// Update 更新接收者组属性，成员通过SaveMembers和DeleteMember维护
+func (r *GormRecipientGroupRepository) Update(ctx context.Context, group *domain.RecipientGroup) error {
+	return translateError(r.db.WithContext(ctx).Omit("Members").Save(group).Error)
+}
+
+// Delete 删除接收者组及其成员
+func (r *GormRecipientGroupRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domain.RecipientGroupMember{}, "group_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.RecipientGroup{}, "id = ?", id).Error
+	})
+}
+
+// SaveMembers 添加成员
+func (r *GormRecipientGroupRepository) SaveMembers(ctx context.Context, members []*domain.RecipientGroupMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return translateError(r.db.WithContext(ctx).Create(&members).Error)
+}
+
+// DeleteMember 删除成员
+func (r *GormRecipientGroupRepository) DeleteMember(ctx context.Context, groupID, memberID string) error {
+	return r.db.WithContext(ctx).
+		Delete(&domain.RecipientGroupMember{}, "group_id = ? AND id = ?", groupID, memberID).Error
+}
+
+// findOne 按条件查找单个接收者组，不存在时返回nil
+func (r *GormRecipientGroupRepository) findOne(ctx context.Context, query string, args ...interface{}) (*domain.RecipientGroup, error) {
+	var group domain.RecipientGroup
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		Where(query, args...).
+		First(&group).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &group, nil
+}
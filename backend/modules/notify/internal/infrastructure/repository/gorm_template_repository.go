@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
+	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormTemplateRepository GORM模板仓储实现
+type GormTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTemplateRepository 创建GORM模板仓储
+func NewGormTemplateRepository(db *gorm.DB) repository.TemplateRepository {
+	return &GormTemplateRepository{
+		db: db,
+	}
+}
+
+// Save 保存模板及其版本，变量通过SaveVariables保存；模板代码与未删除的模板重复时返回repository.ErrDuplicateKey
+func (r *GormTemplateRepository) Save(ctx context.Context, template *domain.NotificationTemplate) error {
+	return translateError(r.db.WithContext(ctx).Omit("Variables").Create(template).Error)
+}
+
+// FindByID 根据ID查找模板，不存在时返回nil
+func (r *GormTemplateRepository) FindByID(ctx context.Context, id string) (*domain.NotificationTemplate, error) {
+	return r.findOne(r.db.WithContext(ctx), "id = ?", id)
+}
+
+// FindByCode 根据代码查找模板，不存在时返回nil
+func (r *GormTemplateRepository) FindByCode(ctx context.Context, code string) (*domain.NotificationTemplate, error) {
+	return r.findOne(r.db.WithContext(ctx), "code = ?", code)
+}
+
+// Update 更新模板属性，变量、版本、渠道模板和本地化内容通过各自的方法维护
+func (r *GormTemplateRepository) Update(ctx context.Context, template *domain.NotificationTemplate) error {
+	return translateError(r.db.WithContext(ctx).Omit(clause.Associations).Save(template).Error)
+}
+
+// Delete 软删除模板，关联数据保留到永久删除
+func (r *GormTemplateRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.NotificationTemplate{}, "id = ?", id).Error
+}
+
+// FindByStatus 根据状态查找模板
+func (r *GormTemplateRepository) FindByStatus(ctx context.Context, status domain.TemplateStatus) ([]*domain.NotificationTemplate, error) {
+	return r.findAll(r.db.WithContext(ctx).Where("status = ?", status))
+}
+
+// FindByType 根据类型查找模板
+func (r *GormTemplateRepository) FindByType(ctx context.Context, templateType domain.TemplateType) ([]*domain.NotificationTemplate, error) {
+	return r.findAll(r.db.WithContext(ctx).Where("type = ?", templateType))
+}
+
+// FindByCreatedBy 根据创建者查找模板
+func (r *GormTemplateRepository) FindByCreatedBy(ctx context.Context, createdBy string) ([]*domain.NotificationTemplate, error) {
+	return r.findAll(r.db.WithContext(ctx).Where("created_by = ?", createdBy))
+}
+
+// FindByCategory 根据分类查找模板
+func (r *GormTemplateRepository) FindByCategory(ctx context.Context, category string) ([]*domain.NotificationTemplate, error) {
+	return r.findAll(r.db.WithContext(ctx).Where("category = ?", category))
+}
+
+// FindByTags 查找包含任一标签的模板
+func (r *GormTemplateRepository) FindByTags(ctx context.Context, tags []string) ([]*domain.NotificationTemplate, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	condition := r.db.WithContext(ctx)
+	for _, tag := range tags {
+		encoded, err := json.Marshal([]string{tag})
+		if err != nil {
+			return nil, err
+		}
+		condition = condition.Or("tags::jsonb @> ?", string(encoded))
+	}
+
+	return r.findAll(r.db.WithContext(ctx).Where(condition))
+}
+
+// FindWithPagination 分页查找模板
+func (r *GormTemplateRepository) FindWithPagination(ctx context.Context, offset, limit int) ([]*domain.NotificationTemplate, int64, error) {
+	return r.findPage(r.db.WithContext(ctx), offset, limit)
+}
+
+// FindByStatusWithPagination 根据状态分页查找模板
+func (r *GormTemplateRepository) FindByStatusWithPagination(ctx context.Context, status domain.TemplateStatus, offset, limit int) ([]*domain.NotificationTemplate, int64, error) {
+	return r.findPage(r.db.WithContext(ctx).Where("status = ?", status), offset, limit)
+}
+
+// FindByCreatedByWithPagination 根据创建者分页查找模板
+func (r *GormTemplateRepository) FindByCreatedByWithPagination(ctx context.Context, createdBy string, offset, limit int) ([]*domain.NotificationTemplate, int64, error) {
+	return r.findPage(r.db.WithContext(ctx).Where("created_by = ?", createdBy), offset, limit)
+}
+
+// SearchByName 根据名称搜索模板
+func (r *GormTemplateRepository) SearchByName(ctx context.Context, query string, limit int) ([]*domain.NotificationTemplate, error) {
+	return r.findAll(r.db.WithContext(ctx).Where("name ILIKE ?", "%"+query+"%").Limit(limit))
+}
+
+// SearchByContent 根据版本内容搜索模板
+func (r *GormTemplateRepository) SearchByContent(ctx context.Context, query string, limit int) ([]*domain.NotificationTemplate, error) {
+	matched := r.db.Model(&domain.TemplateVersion{}).
+		Select("template_id").
+		Where("content ILIKE ?", "%"+query+"%")
+
+	return r.findAll(r.db.WithContext(ctx).Where("id IN (?)", matched).Limit(limit))
+}
+
+// SaveVersion 保存模板版本
+func (r *GormTemplateRepository) SaveVersion(ctx context.Context, version *domain.TemplateVersion) error {
+	return translateError(r.db.WithContext(ctx).Create(version).Error)
+}
+
+// FindVersionsByTemplateID 查找模板的版本，按创建时间从旧到新排列
+func (r *GormTemplateRepository) FindVersionsByTemplateID(ctx context.Context, templateID string) ([]*domain.TemplateVersion, error) {
+	var versions []*domain.TemplateVersion
+	err := r.db.WithContext(ctx).
+		Where("template_id = ?", templateID).
+		Order("created_at ASC").
+		Find(&versions).Error
+
+	return versions, err
+}
+
+// FindActiveVersion 查找模板的活跃版本，不存在时返回nil
+func (r *GormTemplateRepository) FindActiveVersion(ctx context.Context, templateID string) (*domain.TemplateVersion, error) {
+	var version domain.TemplateVersion
+	err := r.db.WithContext(ctx).
+		Where("template_id = ? AND is_active = ?", templateID, true).
+		Order("created_at DESC").
+		First(&version).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// UpdateVersionStatus 更新版本的活跃状态，激活时在同一事务中停用模板的其他版本
+func (r *GormTemplateRepository) UpdateVersionStatus(ctx context.Context, templateID, version string, isActive bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if isActive {
+			err := tx.Model(&domain.TemplateVersion{}).
+				Where("template_id = ? AND version <> ?", templateID, version).
+				Update("is_active", false).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&domain.TemplateVersion{}).
+			Where("template_id = ? AND version = ?", templateID, version).
+			Update("is_active", isActive).Error
+	})
+}
+
+// SaveChannelTemplate 创建或更新渠道模板
+func (r *GormTemplateRepository) SaveChannelTemplate(ctx context.Context, channelTemplate *domain.TemplateChannel) error {
+	return translateError(r.db.WithContext(ctx).Save(channelTemplate).Error)
+}
+
+// FindChannelTemplates 查找模板的渠道模板
+func (r *GormTemplateRepository) FindChannelTemplates(ctx context.Context, templateID string) ([]*domain.TemplateChannel, error) {
+	var channels []*domain.TemplateChannel
+	err := r.db.WithContext(ctx).
+		Where("template_id = ?", templateID).
+		Order("channel ASC").
+		Find(&channels).Error
+
+	return channels, err
+}
+
+// FindChannelTemplate 查找模板的单个渠道模板，不存在时返回nil
+func (r *GormTemplateRepository) FindChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel) (*domain.TemplateChannel, error) {
+	var channelTemplate domain.TemplateChannel
+	err := r.db.WithContext(ctx).
+		Where("template_id = ? AND channel = ?", templateID, channel).
+		First(&channelTemplate).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &channelTemplate, nil
+}
+
+// DeleteChannelTemplate 删除模板的渠道模板
+func (r *GormTemplateRepository) DeleteChannelTemplate(ctx context.Context, templateID string, channel domain.NotificationChannel) error {
+	return r.db.WithContext(ctx).
+		Delete(&domain.TemplateChannel{}, "template_id = ? AND channel = ?", templateID, channel).Error
+}
+
+// SaveLocalization 创建或更新本地化内容，同一模板、语言区域和渠道重复时返回repository.ErrDuplicateKey
+func (r *GormTemplateRepository) SaveLocalization(ctx context.Context, localization *domain.TemplateLocalization) error {
+	return translateError(r.db.WithContext(ctx).Save(localization).Error)
+}
+
+// FindLocalizations 查找模板的本地化内容
+func (r *GormTemplateRepository) FindLocalizations(ctx context.Context, templateID string) ([]*domain.TemplateLocalization, error) {
+	var localizations []*domain.TemplateLocalization
+	err := r.db.WithContext(ctx).
+		Where("template_id = ?", templateID).
+		Order("locale ASC").
+		Order("channel ASC").
+		Find(&localizations).Error
+
+	return localizations, err
+}
+
+// DeleteLocalization 删除模板的本地化内容，channel为空时删除适用于所有渠道的本地化
+func (r *GormTemplateRepository) DeleteLocalization(ctx context.Context, templateID, locale string, channel domain.NotificationChannel) error {
+	return r.db.WithContext(ctx).
+		Delete(&domain.TemplateLocalization{}, "template_id = ? AND locale = ? AND channel = ?", templateID, locale, channel).Error
+}
+
+// SaveVariables 保存模板变量
+func (r *GormTemplateRepository) SaveVariables(ctx context.Context, variables []*domain.TemplateVariable) error {
+	if len(variables) == 0 {
+		return nil
+	}
+	return translateError(r.db.WithContext(ctx).Create(&variables).Error)
+}
+
+// FindVariablesByTemplateID 查找模板的变量
+func (r *GormTemplateRepository) FindVariablesByTemplateID(ctx context.Context, templateID string) ([]*domain.TemplateVariable, error) {
+	var variables []*domain.TemplateVariable
+	err := r.db.WithContext(ctx).
+		Where("template_id = ?", templateID).
+		Order("name ASC").
+		Find(&variables).Error
+
+	return variables, err
+}
+
+// DeleteVariablesByTemplateID 删除模板的所有变量
+func (r *GormTemplateRepository) DeleteVariablesByTemplateID(ctx context.Context, templateID string) error {
+	return r.db.WithContext(ctx).Delete(&domain.TemplateVariable{}, "template_id = ?", templateID).Error
+}
+
+// SaveBatch 批量保存模板
+func (r *GormTemplateRepository) SaveBatch(ctx context.Context, templates []*domain.NotificationTemplate) error {
+	return translateError(r.db.WithContext(ctx).Omit("Variables").CreateInBatches(templates, 100).Error)
+}
+
+// UpdateBatch 批量更新模板属性
+func (r *GormTemplateRepository) UpdateBatch(ctx context.Context, templates []*domain.NotificationTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, template := range templates {
+			if err := tx.Omit(clause.Associations).Save(template).Error; err != nil {
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateStatusBatch 批量更新状态
+func (r *GormTemplateRepository) UpdateStatusBatch(ctx context.Context, ids []string, status domain.TemplateStatus) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.NotificationTemplate{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// CountByStatus 根据状态统计数量
+func (r *GormTemplateRepository) CountByStatus(ctx context.Context, status domain.TemplateStatus) (int64, error) {
+	return r.count(r.db.WithContext(ctx).Where("status = ?", status))
+}
+
+// CountByType 根据类型统计数量
+func (r *GormTemplateRepository) CountByType(ctx context.Context, templateType domain.TemplateType) (int64, error) {
+	return r.count(r.db.WithContext(ctx).Where("type = ?", templateType))
+}
+
+// CountByCreatedBy 根据创建者统计数量
+func (r *GormTemplateRepository) CountByCreatedBy(ctx context.Context, createdBy string) (int64, error) {
+	return r.count(r.db.WithContext(ctx).Where("created_by = ?", createdBy))
+}
+
+// GetUsageStats 统计使用模板创建的通知，不记录渲染耗时
+func (r *GormTemplateRepository) GetUsageStats(ctx context.Context, templateID string) (*repository.TemplateUsageStats, error) {
+	stats := &repository.TemplateUsageStats{
+		TemplateID:   templateID,
+		ChannelUsage: make(map[domain.NotificationChannel]int64),
+	}
+
+	var channelCounts []struct {
+		Channel domain.NotificationChannel
+		Count   int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&domain.Notification{}).
+		Select("channel, COUNT(*) as count").
+		Where("template_id = ?", templateID).
+		Group("channel").
+		Scan(&channelCounts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range channelCounts {
+		stats.ChannelUsage[item.Channel] = item.Count
+		stats.TotalUsage += item.Count
+	}
+
+	var statusCounts struct {
+		SuccessCount int64
+		FailedCount  int64
+	}
+	err = r.db.WithContext(ctx).
+		Model(&domain.Notification{}).
+		Select("SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END) as success_count, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as failed_count",
+			[]domain.NotificationStatus{domain.NotificationStatusSent, domain.NotificationStatusDelivered},
+			domain.NotificationStatusFailed).
+		Where("template_id = ?", templateID).
+		Scan(&statusCounts).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.SuccessCount = statusCounts.SuccessCount
+	stats.FailedCount = statusCounts.FailedCount
+
+	var last domain.Notification
+	result := r.db.WithContext(ctx).
+		Select("created_at").
+		Where("template_id = ?", templateID).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&last)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		stats.LastUsedAt = last.CreatedAt.Format(time.RFC3339)
+	}
+
+	return stats, nil
+}
+
+// DeleteArchivedTemplates 软删除beforeTime之前归档的模板
+func (r *GormTemplateRepository) DeleteArchivedTemplates(ctx context.Context, beforeTime int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", domain.TemplateStatusArchived, time.Unix(beforeTime, 0)).
+		Delete(&domain.NotificationTemplate{})
+
+	return result.RowsAffected, result.Error
+}
+
+// CleanupOrphanedVersions 删除模板已不存在的版本，软删除模板的版本保留到永久删除
+func (r *GormTemplateRepository) CleanupOrphanedVersions(ctx context.Context) (int64, error) {
+	templates := r.db.Unscoped().Model(&domain.NotificationTemplate{}).Select("id")
+	result := r.db.WithContext(ctx).
+		Where("template_id NOT IN (?)", templates).
+		Delete(&domain.TemplateVersion{})
+
+	return result.RowsAffected, result.Error
+}
+
+// FindIncludingDeleted 根据ID查找模板，包括已软删除的模板
+func (r *GormTemplateRepository) FindIncludingDeleted(ctx context.Context, id string) (*domain.NotificationTemplate, error) {
+	return r.findOne(r.db.WithContext(ctx).Unscoped(), "id = ?", id)
+}
+
+// PurgeDeleted 永久删除before之前软删除的模板，同一事务中删除其变量、版本、渠道模板和本地化内容
+func (r *GormTemplateRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Unscoped().
+			Model(&domain.NotificationTemplate{}).
+			Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+
+		for _, model := range []interface{}{
+			&domain.TemplateVariable{},
+			&domain.TemplateVersion{},
+			&domain.TemplateChannel{},
+			&domain.TemplateLocalization{},
+		} {
+			if err := tx.Where("template_id IN (?)", deleted).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Delete(&domain.NotificationTemplate{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+
+	return purged, err
+}
+
+// findOne 按条件查找单个模板，不存在时返回nil
+func (r *GormTemplateRepository) findOne(db *gorm.DB, query string, args ...interface{}) (*domain.NotificationTemplate, error) {
+	var template domain.NotificationTemplate
+	err := db.Where(query, args...).First(&template).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// findAll 按条件查找模板，按创建时间从新到旧排列
+func (r *GormTemplateRepository) findAll(query *gorm.DB) ([]*domain.NotificationTemplate, error) {
+	var templates []*domain.NotificationTemplate
+	err := query.Order("created_at DESC").Find(&templates).Error
+
+	return templates, err
+}
+
+// findPage 按条件分页查找模板，返回总数
+func (r *GormTemplateRepository) findPage(query *gorm.DB, offset, limit int) ([]*domain.NotificationTemplate, int64, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(&domain.NotificationTemplate{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var templates []*domain.NotificationTemplate
+	err := query.
+		Offset(offset).
+		Limit(limit).
+		Order("created_at DESC").
+		Find(&templates).Error
+
+	return templates, total, err
+}
+
+// count 按条件统计模板数量
+func (r *GormTemplateRepository) count(query *gorm.DB) (int64, error) {
+	var count int64
+	err := query.Model(&domain.NotificationTemplate{}).Count(&count).Error
+
+	return count, err
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/noah-loop/backend/modules/notify/internal/application/service"
+	"github.com/noah-loop/backend/modules/notify/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"go.uber.org/zap"
 )
@@ -15,6 +18,8 @@ type NotifyHandler struct {
 	notificationService *service.NotificationService
 	templateService     *service.TemplateService
 	channelService      *service.ChannelService
+	groupService        *service.RecipientGroupService
+	engagementService   *service.EngagementService
 	logger             infrastructure.Logger
 }
 
@@ -23,12 +28,16 @@ func NewNotifyHandler(
 	notificationService *service.NotificationService,
 	templateService *service.TemplateService,
 	channelService *service.ChannelService,
+	groupService *service.RecipientGroupService,
+	engagementService *service.EngagementService,
 	logger infrastructure.Logger,
 ) *NotifyHandler {
 	return &NotifyHandler{
 		notificationService: notificationService,
 		templateService:     templateService,
 		channelService:      channelService,
+		groupService:        groupService,
+		engagementService:   engagementService,
 		logger:             logger,
 	}
 }
@@ -43,6 +52,10 @@ func (h *NotifyHandler) CreateNotification(c *gin.Context) {
 
 	notification, err := h.notificationService.CreateNotification(c.Request.Context(), &cmd)
 	if err != nil {
+		if status, code, ok := recipientErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error(), "code": code})
+			return
+		}
 		h.logger.Error("Failed to create notification", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -64,6 +77,19 @@ func (h *NotifyHandler) CreateNotificationFromTemplate(c *gin.Context) {
 
 	notification, err := h.notificationService.CreateNotificationFromTemplate(c.Request.Context(), &cmd)
 	if err != nil {
+		if status, code, ok := recipientErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error(), "code": code})
+			return
+		}
+		var variablesErr *domain.ErrInvalidTemplateVariables
+		if errors.As(err, &variablesErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      err.Error(),
+				"code":       domain.ErrTemplateInvalidVariable,
+				"violations": variablesErr.Violations,
+			})
+			return
+		}
 		h.logger.Error("Failed to create notification from template", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -88,6 +114,7 @@ func (h *NotifyHandler) GetNotification(c *gin.Context) {
 }
 
 // ListNotifications 列出通知
+// 带cursor参数（第一页传空值）时使用游标分页，否则按offset分页
 func (h *NotifyHandler) ListNotifications(c *gin.Context) {
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -99,6 +126,12 @@ func (h *NotifyHandler) ListNotifications(c *gin.Context) {
 		Limit:     limit,
 	}
 
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		cmd.Cursor = cursor
+		h.listNotificationsAfterCursor(c, cmd)
+		return
+	}
+
 	notifications, total, err := h.notificationService.ListNotifications(c.Request.Context(), cmd)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -113,6 +146,56 @@ func (h *NotifyHandler) ListNotifications(c *gin.Context) {
 	})
 }
 
+// listNotificationsAfterCursor 游标分页列出通知，next_cursor为空表示没有更多通知
+func (h *NotifyHandler) listNotificationsAfterCursor(c *gin.Context, cmd *service.ListNotificationsCommand) {
+	notifications, nextCursor, err := h.notificationService.ListNotificationsAfterCursor(c.Request.Context(), cmd)
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Code == domain.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"next_cursor":   nextCursor,
+		"has_more":      nextCursor != "",
+	})
+}
+
+// GetUpcomingSchedule 获取计划发送日历
+func (h *NotifyHandler) GetUpcomingSchedule(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "168h"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window: " + err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	filter := &service.UpcomingScheduleFilter{
+		CreatedBy: c.Query("created_by"),
+		Channel:   c.Query("channel"),
+		Type:      c.Query("type"),
+		Limit:     limit,
+	}
+
+	schedule, err := h.notificationService.GetUpcomingSchedule(c.Request.Context(), filter, window)
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Code == domain.ErrInvalidScheduleWindow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
 // SendNotification 发送通知
 func (h *NotifyHandler) SendNotification(c *gin.Context) {
 	id := c.Param("id")
@@ -125,6 +208,93 @@ func (h *NotifyHandler) SendNotification(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Notification sent successfully"})
 }
 
+// RecordDeliveryReceipt 接收服务商的投递回执
+func (h *NotifyHandler) RecordDeliveryReceipt(c *gin.Context) {
+	var cmd service.RecordDeliveryReceiptCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !domain.IsValidReceiptStatus(cmd.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of delivered, bounced, failed"})
+		return
+	}
+	cmd.NotificationID = c.Param("id")
+
+	err := h.notificationService.RecordDeliveryReceipt(c.Request.Context(), &cmd)
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && (domainErr.Code == domain.ErrRecipientNotFound || domainErr.Code == domain.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery receipt recorded"})
+}
+
+// GetNotificationEngagement 获取通知的打开率、点击率和各接收者的互动统计
+func (h *NotifyHandler) GetNotificationEngagement(c *gin.Context) {
+	engagement, err := h.engagementService.GetNotificationEngagement(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Code == domain.ErrNotificationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"engagement": engagement})
+}
+
+// trackingPixel 1x1透明GIF
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// TrackOpen 邮件打开追踪像素，记录失败或令牌无效时同样返回像素
+func (h *NotifyHandler) TrackOpen(c *gin.Context) {
+	cmd := &service.RecordEngagementCommand{
+		Token:     c.Param("token"),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+	if err := h.engagementService.RecordOpen(c.Request.Context(), cmd); err != nil {
+		h.logger.Debug("Open tracking not recorded", zap.Error(err))
+	}
+
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate, private")
+	c.Data(http.StatusOK, "image/gif", trackingPixel)
+}
+
+// TrackClick 链接点击追踪，记录后跳转到原始链接，令牌无效时返回404
+func (h *NotifyHandler) TrackClick(c *gin.Context) {
+	cmd := &service.RecordEngagementCommand{
+		Token:     c.Param("token"),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+	target, err := h.engagementService.RecordClick(c.Request.Context(), cmd)
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, target)
+}
+
 // CreateTemplate 创建模板
 func (h *NotifyHandler) CreateTemplate(c *gin.Context) {
 	var cmd service.CreateTemplateCommand
@@ -135,6 +305,15 @@ func (h *NotifyHandler) CreateTemplate(c *gin.Context) {
 
 	template, err := h.templateService.CreateTemplate(c.Request.Context(), &cmd)
 	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Code == domain.ErrTemplateCodeExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		if errors.As(err, &domainErr) && domainErr.Code == domain.ErrTemplateInvalidVariable {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -145,6 +324,166 @@ func (h *NotifyHandler) CreateTemplate(c *gin.Context) {
 	})
 }
 
+// PreviewTemplate 使用示例变量预览模板渲染结果，不创建通知
+// 缺少必需变量等验证错误在响应的errors中返回，模板不存在返回404，没有活跃版本返回400
+func (h *NotifyHandler) PreviewTemplate(c *gin.Context) {
+	var cmd service.PreviewTemplateCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.templateService.PreviewRender(c.Request.Context(), c.Param("id"), cmd.Channel, cmd.Variables)
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) {
+			status := http.StatusBadRequest
+			if domainErr.Code == domain.ErrTemplateNotFound {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": err.Error(), "code": domainErr.Code})
+			return
+		}
+		h.logger.Error("Failed to preview template", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preview": preview})
+}
+
+// ListTemplateChannels 列出模板渠道配置
+func (h *NotifyHandler) ListTemplateChannels(c *gin.Context) {
+	templateID := c.Param("id")
+	channels, err := h.templateService.ListChannelTemplates(c.Request.Context(), templateID)
+	if err != nil {
+		h.respondTemplateChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channels": channels})
+}
+
+// GetTemplateChannel 获取模板渠道配置
+func (h *NotifyHandler) GetTemplateChannel(c *gin.Context) {
+	templateID := c.Param("id")
+	channel := domain.NotificationChannel(c.Param("channel"))
+
+	channelTemplate, err := h.templateService.GetChannelTemplate(c.Request.Context(), templateID, channel)
+	if err != nil {
+		h.respondTemplateChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channel": channelTemplate})
+}
+
+// SetTemplateChannel 设置模板渠道配置
+func (h *NotifyHandler) SetTemplateChannel(c *gin.Context) {
+	var cmd service.SetTemplateChannelCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cmd.TemplateID = c.Param("id")
+	cmd.Channel = domain.NotificationChannel(c.Param("channel"))
+
+	channelTemplate, err := h.templateService.UpdateChannelTemplate(c.Request.Context(), &cmd)
+	if err != nil {
+		h.respondTemplateChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel": channelTemplate,
+		"message": "Template channel updated successfully",
+	})
+}
+
+// DeleteTemplateChannel 删除模板渠道配置
+func (h *NotifyHandler) DeleteTemplateChannel(c *gin.Context) {
+	templateID := c.Param("id")
+	channel := domain.NotificationChannel(c.Param("channel"))
+
+	err := h.templateService.DeleteChannelTemplate(c.Request.Context(), templateID, channel)
+	if err != nil {
+		h.respondTemplateChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template channel deleted successfully"})
+}
+
+// respondTemplateChannelError 返回模板渠道配置相关错误
+// 模板或渠道配置不存在返回404，其他领域错误（如模板语法、变量检查失败）返回400
+func (h *NotifyHandler) respondTemplateChannelError(c *gin.Context, err error) {
+	var domainErr *domain.DomainError
+	if errors.As(err, &domainErr) {
+		status := http.StatusBadRequest
+		if domainErr.Code == domain.ErrTemplateNotFound || domainErr.Code == domain.ErrTemplateChannelNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "code": domainErr.Code})
+		return
+	}
+	h.logger.Error("Template channel operation failed", zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ListTemplateLocalizations 列出模板本地化内容
+func (h *NotifyHandler) ListTemplateLocalizations(c *gin.Context) {
+	templateID := c.Param("id")
+	localizations, err := h.templateService.ListTemplateLocalizations(c.Request.Context(), templateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"localizations": localizations})
+}
+
+// SetTemplateLocalization 设置模板本地化内容
+func (h *NotifyHandler) SetTemplateLocalization(c *gin.Context) {
+	var cmd service.SetTemplateLocalizationCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cmd.TemplateID = c.Param("id")
+	cmd.Locale = c.Param("locale")
+
+	localization, err := h.templateService.SetTemplateLocalization(c.Request.Context(), &cmd)
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && (domainErr.Code == domain.ErrInvalidLocale || domainErr.Code == domain.ErrInvalidTemplate || domainErr.Code == domain.ErrTemplateInvalidFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Failed to set template localization", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"localization": localization,
+		"message":      "Template localization updated successfully",
+	})
+}
+
+// DeleteTemplateLocalization 删除模板本地化内容，channel查询参数指定渠道专用的本地化
+func (h *NotifyHandler) DeleteTemplateLocalization(c *gin.Context) {
+	templateID := c.Param("id")
+	channel := domain.NotificationChannel(c.Query("channel"))
+
+	err := h.templateService.DeleteTemplateLocalization(c.Request.Context(), templateID, c.Param("locale"), channel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template localization deleted successfully"})
+}
+
 // CreateChannelConfig 创建渠道配置
 func (h *NotifyHandler) CreateChannelConfig(c *gin.Context) {
 	var cmd service.CreateChannelConfigCommand
@@ -182,6 +521,138 @@ func (h *NotifyHandler) TestChannel(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Channel test successful"})
 }
 
+// CreateRecipientGroup 创建接收者组
+func (h *NotifyHandler) CreateRecipientGroup(c *gin.Context) {
+	var cmd service.CreateRecipientGroupCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(c.Request.Context(), &cmd)
+	if err != nil {
+		h.respondRecipientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"group":   group,
+		"message": "Recipient group created successfully",
+	})
+}
+
+// ListRecipientGroups 列出所有者的接收者组
+func (h *NotifyHandler) ListRecipientGroups(c *gin.Context) {
+	ownerID := c.Query("owner_id")
+	if ownerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id is required"})
+		return
+	}
+
+	groups, err := h.groupService.ListGroups(c.Request.Context(), ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups": groups,
+		"total":  len(groups),
+	})
+}
+
+// GetRecipientGroup 获取接收者组
+func (h *NotifyHandler) GetRecipientGroup(c *gin.Context) {
+	group, err := h.groupService.GetGroup(c.Request.Context(), c.Param("id"), c.Query("owner_id"))
+	if err != nil {
+		h.respondRecipientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group": group})
+}
+
+// DeleteRecipientGroup 删除接收者组
+func (h *NotifyHandler) DeleteRecipientGroup(c *gin.Context) {
+	if err := h.groupService.DeleteGroup(c.Request.Context(), c.Param("id"), c.Query("owner_id")); err != nil {
+		h.respondRecipientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recipient group deleted successfully"})
+}
+
+// AddRecipientGroupMembers 添加接收者组成员
+func (h *NotifyHandler) AddRecipientGroupMembers(c *gin.Context) {
+	var cmd service.AddRecipientGroupMembersCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cmd.GroupID = c.Param("id")
+
+	group, err := h.groupService.AddMembers(c.Request.Context(), &cmd)
+	if err != nil {
+		h.respondRecipientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":   group,
+		"message": "Recipient group members added successfully",
+	})
+}
+
+// RemoveRecipientGroupMember 移除接收者组成员
+func (h *NotifyHandler) RemoveRecipientGroupMember(c *gin.Context) {
+	cmd := &service.RemoveRecipientGroupMemberCommand{
+		GroupID:  c.Param("id"),
+		MemberID: c.Param("member_id"),
+		OwnerID:  c.Query("owner_id"),
+	}
+
+	if err := h.groupService.RemoveMember(c.Request.Context(), cmd); err != nil {
+		h.respondRecipientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recipient group member removed successfully"})
+}
+
+// respondRecipientError 返回接收者组相关错误
+func (h *NotifyHandler) respondRecipientError(c *gin.Context, err error) {
+	if status, code, ok := recipientErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error(), "code": code})
+		return
+	}
+	var domainErr *domain.DomainError
+	if errors.As(err, &domainErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": domainErr.Code})
+		return
+	}
+	h.logger.Error("Recipient group operation failed", zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// recipientErrorStatus 接收者及接收者组领域错误对应的HTTP状态码
+func recipientErrorStatus(err error) (int, string, bool) {
+	var domainErr *domain.DomainError
+	if !errors.As(err, &domainErr) {
+		return 0, "", false
+	}
+
+	switch domainErr.Code {
+	case domain.ErrRecipientGroupNotFound, domain.ErrRecipientGroupMemberNotFound:
+		return http.StatusNotFound, domainErr.Code, true
+	case domain.ErrRecipientGroupExists, domain.ErrRecipientGroupMemberExists:
+		return http.StatusConflict, domainErr.Code, true
+	case domain.ErrNoRecipients:
+		return http.StatusBadRequest, domainErr.Code, true
+	default:
+		return 0, "", false
+	}
+}
+
 // Health 健康检查
 func (h *NotifyHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -5,6 +5,7 @@ import (
 	"github.com/noah-loop/backend/modules/notify/internal/interface/http/handler"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/middleware"
 )
 
 // Router HTTP路由器
@@ -19,6 +20,7 @@ func NewRouter(
 	notifyHandler *handler.NotifyHandler,
 	metrics *infrastructure.MetricsRegistry,
 	tracingWrapper *tracing.TracingWrapper,
+	bodyLimit middleware.BodyLimitConfig,
 ) *Router {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
@@ -37,6 +39,9 @@ func NewRouter(
 		engine.Use(metrics.PrometheusMiddleware())
 	}
 
+	// 请求体大小限制，超限的请求在绑定前返回413
+	engine.Use(middleware.BodyLimit(bodyLimit))
+
 	router := &Router{
 		engine:        engine,
 		notifyHandler: notifyHandler,
@@ -49,9 +54,8 @@ func NewRouter(
 
 // setupRoutes 设置路由
 func (r *Router) setupRoutes() {
-	// 健康检查
+	// 存活检查，就绪探针/ready和/readyz由cmd中的就绪闸门处理
 	r.engine.GET("/health", r.notifyHandler.Health)
-	r.engine.GET("/ready", r.notifyHandler.Health)
 
 	// API版本
 	v1 := r.engine.Group("/api/v1")
@@ -62,8 +66,18 @@ func (r *Router) setupRoutes() {
 		notifications.POST("", r.notifyHandler.CreateNotification)
 		notifications.POST("/template", r.notifyHandler.CreateNotificationFromTemplate)
 		notifications.GET("", r.notifyHandler.ListNotifications)
+		notifications.GET("/schedule", r.notifyHandler.GetUpcomingSchedule)
 		notifications.GET("/:id", r.notifyHandler.GetNotification)
 		notifications.POST("/:id/send", r.notifyHandler.SendNotification)
+		notifications.POST("/:id/receipts", r.notifyHandler.RecordDeliveryReceipt)
+		notifications.GET("/:id/engagement", r.notifyHandler.GetNotificationEngagement)
+	}
+
+	// 邮件打开和点击追踪，由邮件客户端直接访问，令牌经过签名
+	track := v1.Group("/track")
+	{
+		track.GET("/open/:token", r.notifyHandler.TrackOpen)
+		track.GET("/click/:token", r.notifyHandler.TrackClick)
 	}
 
 	// 模板相关路由
@@ -73,6 +87,25 @@ func (r *Router) setupRoutes() {
 		// templates.GET("", r.notifyHandler.ListTemplates)
 		// templates.GET("/:id", r.notifyHandler.GetTemplate)
 		// templates.PUT("/:id", r.notifyHandler.UpdateTemplate)
+		templates.POST("/:id/preview", r.notifyHandler.PreviewTemplate)
+		templates.GET("/:id/channels", r.notifyHandler.ListTemplateChannels)
+		templates.GET("/:id/channels/:channel", r.notifyHandler.GetTemplateChannel)
+		templates.PUT("/:id/channels/:channel", r.notifyHandler.SetTemplateChannel)
+		templates.DELETE("/:id/channels/:channel", r.notifyHandler.DeleteTemplateChannel)
+		templates.GET("/:id/locales", r.notifyHandler.ListTemplateLocalizations)
+		templates.PUT("/:id/locales/:locale", r.notifyHandler.SetTemplateLocalization)
+		templates.DELETE("/:id/locales/:locale", r.notifyHandler.DeleteTemplateLocalization)
+	}
+
+	// 接收者组相关路由
+	groups := v1.Group("/recipient-groups")
+	{
+		groups.POST("", r.notifyHandler.CreateRecipientGroup)
+		groups.GET("", r.notifyHandler.ListRecipientGroups)
+		groups.GET("/:id", r.notifyHandler.GetRecipientGroup)
+		groups.DELETE("/:id", r.notifyHandler.DeleteRecipientGroup)
+		groups.POST("/:id/members", r.notifyHandler.AddRecipientGroupMembers)
+		groups.DELETE("/:id/members/:member_id", r.notifyHandler.RemoveRecipientGroupMember)
 	}
 
 	// 渠道配置相关路由
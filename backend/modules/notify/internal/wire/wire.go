@@ -1,6 +1,10 @@
 package wire
 
 import (
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/google/wire"
 	"github.com/noah-loop/backend/modules/notify/internal/application/service"
 	"github.com/noah-loop/backend/modules/notify/internal/domain/repository"
@@ -11,20 +15,24 @@ import (
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/middleware"
 	"gorm.io/gorm"
 )
 
 // NotifyApp 通知应用结构
 type NotifyApp struct {
-	NotificationService *service.NotificationService
-	TemplateService     *service.TemplateService
-	ChannelService      *service.ChannelService
-	Handler             *handler.NotifyHandler
-	Router              *http.Router
-	Config              *infrastructure.Config
-	Logger              infrastructure.Logger
-	Metrics             *infrastructure.MetricsRegistry
-	Database            *gorm.DB
+	NotificationService   *service.NotificationService
+	TemplateService       *service.TemplateService
+	ChannelService        *service.ChannelService
+	RecipientGroupService *service.RecipientGroupService
+	EngagementService     *service.EngagementService
+	Handler               *handler.NotifyHandler
+	Router                *http.Router
+	Config                *infrastructure.Config
+	Logger                infrastructure.Logger
+	Metrics               *infrastructure.MetricsRegistry
+	Database              *gorm.DB
 
 	// etcd相关组件
 	EtcdClient       *etcd.Client
@@ -38,10 +46,15 @@ type NotifyApp struct {
 	TracingWrapper *tracing.TracingWrapper
 
 	// 通知提供商
-	EmailProvider   service.EmailProvider
-	SMSProvider     service.SMSProvider
-	PushProvider    service.PushProvider
-	WebhookProvider service.WebhookProvider
+	EmailProvider    service.EmailProvider
+	SMSProvider      service.SMSProvider
+	PushProvider     service.PushProvider
+	WebhookProvider  service.WebhookProvider
+	SlackProvider    service.SlackProvider
+	TelegramProvider service.TelegramProvider
+	SLAAlerter       service.SLAAlerter
+	EventPublisher   service.DeliveryEventPublisher
+	RateLimiter      service.ChannelRateLimiter
 }
 
 // NotifyRepositoryProviderSet 通知仓储提供者集合
@@ -49,6 +62,11 @@ var NotifyRepositoryProviderSet = wire.NewSet(
 	infraRepo.NewGormNotificationRepository,
 	// TODO: 添加其他仓储实现
 	wire.Bind(new(repository.NotificationRepository), new(*infraRepo.GormNotificationRepository)),
+	infraRepo.NewGormRecipientGroupRepository,
+	wire.Bind(new(repository.RecipientGroupRepository), new(*infraRepo.GormRecipientGroupRepository)),
+	infraRepo.NewGormTemplateRepository,
+	wire.Bind(new(repository.TemplateRepository), new(*infraRepo.GormTemplateRepository)),
+	infraRepo.NewGormEngagementRepository,
 )
 
 // NotifyProviderSet 通知提供商集合
@@ -57,10 +75,20 @@ var NotifyProviderSet = wire.NewSet(
 	provider.NewAliyunSMSProvider,
 	provider.NewBarkPushProvider,
 	provider.NewServerChanWebhookProvider,
+	provider.NewSlackProvider,
+	provider.NewTelegramProvider,
+	provider.NewLogSLAAlerter,
+	provider.NewLogDeliveryEventPublisher,
+	provider.NewTokenBucketRateLimiter,
 	wire.Bind(new(service.EmailProvider), new(*provider.SMTPEmailProvider)),
 	wire.Bind(new(service.SMSProvider), new(*provider.AliyunSMSProvider)),
 	wire.Bind(new(service.PushProvider), new(*provider.BarkPushProvider)),
 	wire.Bind(new(service.WebhookProvider), new(*provider.ServerChanWebhookProvider)),
+	wire.Bind(new(service.SlackProvider), new(*provider.SlackProvider)),
+	wire.Bind(new(service.TelegramProvider), new(*provider.TelegramProvider)),
+	wire.Bind(new(service.SLAAlerter), new(*provider.LogSLAAlerter)),
+	wire.Bind(new(service.DeliveryEventPublisher), new(*provider.LogDeliveryEventPublisher)),
+	wire.Bind(new(service.ChannelRateLimiter), new(*provider.TokenBucketRateLimiter)),
 )
 
 // NotifyServiceProviderSet 通知服务提供者集合
@@ -68,14 +96,60 @@ var NotifyServiceProviderSet = wire.NewSet(
 	service.NewNotificationService,
 	service.NewTemplateService,
 	service.NewChannelService,
+	service.NewRecipientGroupService,
+	service.NewEngagementService,
+	service.NewEngagementTracker,
+	NewSendConfig,
+	NewTrackingConfig,
+	NewNotificationMetrics,
 )
 
+// NewNotificationMetrics 创建通知领域指标，注册到默认注册表，由现有的 /metrics 端点暴露
+func NewNotificationMetrics() (*metrics.NotificationMetrics, error) {
+	return metrics.NewNotificationMetrics(nil)
+}
+
+// NewSendConfig 创建通知发送配置，支持通过环境变量覆盖
+func NewSendConfig() service.SendConfig {
+	sendConfig := service.DefaultSendConfig()
+
+	if concurrency, err := strconv.Atoi(os.Getenv("NOTIFY_SEND_CONCURRENCY")); err == nil && concurrency > 0 {
+		sendConfig.Concurrency = concurrency
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("NOTIFY_CLAIM_TIMEOUT")); err == nil && timeout > 0 {
+		sendConfig.ClaimTimeout = timeout
+	}
+
+	return sendConfig
+}
+
+// NewTrackingConfig 创建邮件互动追踪配置
+// NOTIFY_TRACKING_BASE_URL为追踪接口对外的访问地址，NOTIFY_TRACKING_SECRET为追踪链接的签名密钥，两者都配置时才追踪
+func NewTrackingConfig(logger infrastructure.Logger) service.TrackingConfig {
+	trackingConfig := service.TrackingConfig{
+		BaseURL: os.Getenv("NOTIFY_TRACKING_BASE_URL"),
+		Secret:  os.Getenv("NOTIFY_TRACKING_SECRET"),
+	}
+
+	if trackingConfig.BaseURL != "" && trackingConfig.Secret == "" {
+		logger.Warn("NOTIFY_TRACKING_SECRET is not set, engagement tracking is disabled")
+	}
+
+	return trackingConfig
+}
+
 // NotifyHandlerProviderSet 通知处理器提供者集合
 var NotifyHandlerProviderSet = wire.NewSet(
 	handler.NewNotifyHandler,
 	http.NewRouter,
+	NewBodyLimitConfig,
 )
 
+// NewBodyLimitConfig 读取请求体大小限制，环境变量为NOTIFY_MAX_BODY_SIZE和NOTIFY_MAX_BODY_SIZE_ROUTES
+func NewBodyLimitConfig(logger infrastructure.Logger) middleware.BodyLimitConfig {
+	return middleware.LoadBodyLimitConfig("NOTIFY", logger)
+}
+
 // InitializeNotifyApp 初始化通知应用
 func InitializeNotifyApp() (*NotifyApp, func(), error) {
 	wire.Build(
@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
@@ -19,6 +21,7 @@ type CreateWorkflowCommand struct {
 	Variables   map[string]interface{}    `json:"variables"`
 	Tags        []string                  `json:"tags"`
 	IsTemplate  bool                      `json:"is_template"`
+	MaxExecutionTime time.Duration        `json:"max_execution_time"` // 单次执行的最长时间，0表示不限制
 }
 
 func NewCreateWorkflowCommand() *CreateWorkflowCommand {
@@ -43,6 +46,15 @@ func (c *CreateWorkflowCommand) Validate() error {
 		return errors.New("owner ID is required")
 	}
 	
+	if c.MaxExecutionTime < 0 {
+		return errors.New("max execution time cannot be negative")
+	}
+	
+	// 定义中的步骤依赖必须构成有向无环图
+	if err := validateDefinitionDAG(c.Definition); err != nil {
+		return err
+	}
+	
 	return nil
 }
 
@@ -74,6 +86,31 @@ func (c *ExecuteWorkflowCommand) Validate() error {
 	return nil
 }
 
+// RetryExecutionCommand 重新执行命令
+type RetryExecutionCommand struct {
+	application.BaseCommand
+	ExecutionID uuid.UUID `json:"execution_id"`
+	FromStepID  uuid.UUID `json:"from_step_id"` // 为空时从首个失败步骤开始
+}
+
+func NewRetryExecutionCommand(executionID uuid.UUID) *RetryExecutionCommand {
+	return &RetryExecutionCommand{
+		BaseCommand: application.BaseCommand{
+			CommandID:   uuid.New(),
+			CommandType: "retry_execution",
+		},
+		ExecutionID: executionID,
+	}
+}
+
+func (c *RetryExecutionCommand) Validate() error {
+	if c.ExecutionID == uuid.Nil {
+		return errors.New("execution ID is required")
+	}
+	
+	return nil
+}
+
 // AddStepCommand 添加步骤命令
 type AddStepCommand struct {
 	application.BaseCommand
@@ -130,6 +167,39 @@ func (c *AddStepCommand) Validate() error {
 		return errors.New("max retries must be non-negative")
 	}
 	
+	// 条件步骤需要合法的表达式
+	if c.Type == domain.StepTypeCondition {
+		expression, _ := c.Config[ConditionConfigExpression].(string)
+		if strings.TrimSpace(expression) == "" {
+			return errors.New("expression is required for condition steps")
+		}
+		if err := ValidateExpression(expression); err != nil {
+			return fmt.Errorf("invalid condition expression: %w", err)
+		}
+	}
+	
+	// 子工作流步骤需要合法的目标工作流ID
+	if c.Type == domain.StepTypeSubworkflow {
+		if _, err := subworkflowID(c.Config); err != nil {
+			return err
+		}
+	}
+	
+	// 派生变量需要合法的变量名和表达式
+	if err := validateStepVariables(c.Config); err != nil {
+		return err
+	}
+	
+	// 缓存开关和过期时间需要合法
+	if err := validateStepCache(c.Config); err != nil {
+		return err
+	}
+	
+	// 输入中的引用需要合法的表达式
+	if err := validateStepInput(c.Input); err != nil {
+		return fmt.Errorf("invalid step input: %w", err)
+	}
+	
 	return nil
 }
 
@@ -144,6 +214,7 @@ type AddTriggerCommand struct {
 	Schedule    string                    `json:"schedule"` // for schedule triggers
 	Timezone    string                    `json:"timezone"` // for schedule triggers
 	Conditions  []TriggerCondition        `json:"conditions"` // for condition triggers
+	Secret      string                    `json:"secret"`     // for webhook triggers, generated when empty
 }
 
 type TriggerCondition struct {
@@ -182,14 +253,23 @@ func (c *AddTriggerCommand) Validate() error {
 	}
 	
 	// 验证特定类型的配置
-	if c.Type == domain.TriggerTypeSchedule && c.Schedule == "" {
-		return errors.New("schedule is required for schedule triggers")
+	if c.Type == domain.TriggerTypeSchedule {
+		if c.Schedule == "" {
+			return errors.New("schedule is required for schedule triggers")
+		}
+		if _, err := ParseTriggerSchedule(c.Schedule, c.Timezone); err != nil {
+			return err
+		}
 	}
 	
 	if c.Type == domain.TriggerTypeCondition && len(c.Conditions) == 0 {
 		return errors.New("conditions are required for condition triggers")
 	}
 	
+	if c.Type == domain.TriggerTypeWebhook && c.Secret != "" && len(c.Secret) < domain.MinWebhookSecretLength {
+		return fmt.Errorf("webhook secret must be at least %d characters", domain.MinWebhookSecretLength)
+	}
+	
 	return nil
 }
 
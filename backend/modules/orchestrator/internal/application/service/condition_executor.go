@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// ConditionConfigExpression 条件步骤配置中的表达式键
+	ConditionConfigExpression = "expression"
+	// ConditionConfigElseSteps 条件步骤配置中的否定分支键，值为步骤ID或名称列表
+	ConditionConfigElseSteps = "else_steps"
+	// ConditionOutputResult 条件步骤输出中的求值结果键
+	ConditionOutputResult = "result"
+)
+
+// ConditionStepExecutor 条件步骤执行器
+// 对步骤配置中的表达式求值，结果写入输出的result字段，由编排服务据此跳过未命中的分支
+type ConditionStepExecutor struct{}
+
+// NewConditionStepExecutor 创建条件步骤执行器
+func NewConditionStepExecutor() *ConditionStepExecutor {
+	return &ConditionStepExecutor{}
+}
+
+// Execute 求值条件表达式，可引用的变量：
+// input（执行输入）、context（执行上下文）、vars（工作流变量）、steps（已完成步骤的输出，如steps.fetch.output.count）
+func (e *ConditionStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	expression, _ := request.Step.Config[ConditionConfigExpression].(string)
+	if strings.TrimSpace(expression) == "" {
+		return nil, errors.New("condition step requires an expression")
+	}
+
+	result, err := EvaluateCondition(expression, expressionVariables(request))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate condition %q: %w", expression, err)
+	}
+
+	return &StepExecutionResult{
+		Output: map[string]interface{}{
+			ConditionOutputResult: result,
+			"expression":          expression,
+		},
+	}, nil
+}
+
+// GetSupportedType 获取支持的步骤类型
+func (e *ConditionStepExecutor) GetSupportedType() domain.StepType {
+	return domain.StepTypeCondition
+}
+
+// expressionVariables 构造表达式可引用的变量
+func expressionVariables(request *StepExecutionRequest) map[string]interface{} {
+	variables := map[string]interface{}{
+		"context": request.Context,
+		"vars":    request.Variables,
+		"steps":   request.Steps,
+	}
+	if request.Execution != nil {
+		variables["input"] = request.Execution.Input
+	}
+	return variables
+}
+
+// buildStepScope 构造已完成步骤的输出视图，按步骤名称和ID索引
+func buildStepScope(steps []*domain.Step, outputs map[uuid.UUID]map[string]interface{}) map[string]interface{} {
+	scope := make(map[string]interface{}, len(outputs)*2)
+	for _, step := range steps {
+		output, exists := outputs[step.ID]
+		if !exists {
+			continue
+		}
+		entry := map[string]interface{}{"output": output}
+		scope[step.ID.String()] = entry
+		if step.Name != "" {
+			scope[step.Name] = entry
+		}
+	}
+	return scope
+}
+
+// findStep 按ID查找步骤
+func findStep(steps []*domain.Step, stepID uuid.UUID) *domain.Step {
+	for _, step := range steps {
+		if step.ID == stepID {
+			return step
+		}
+	}
+	return nil
+}
+
+// skipConditionBranch 按条件步骤的结果跳过未命中的分支，返回被跳过的步骤
+// 直接依赖条件步骤的步骤中，else_steps列出的属于否定分支，其余属于肯定分支
+func (s *OrchestratorService) skipConditionBranch(ctx context.Context, execution *domain.Execution, steps []*domain.Step, condition *domain.Step, output map[string]interface{}) []uuid.UUID {
+	result, _ := output[ConditionOutputResult].(bool)
+	elseSteps := conditionElseSteps(condition)
+
+	var skipped []uuid.UUID
+	for _, step := range steps {
+		if step.Status != domain.StepStatusPending || !containsStepID(step.Dependencies, condition.ID) {
+			continue
+		}
+		inElse := elseSteps[step.ID.String()] || elseSteps[step.Name]
+		if result != inElse {
+			continue
+		}
+		s.skipStep(ctx, execution, step, fmt.Sprintf("condition %s evaluated to %t", condition.Name, result))
+		skipped = append(skipped, step.ID)
+	}
+	return skipped
+}
+
+// propagateSkips 跳过所有依赖都已被跳过的待执行步骤，直到没有新的步骤被跳过
+// 只要有一个依赖已完成，步骤就会执行，用于汇合多个分支
+func (s *OrchestratorService) propagateSkips(ctx context.Context, execution *domain.Execution, steps []*domain.Step) []uuid.UUID {
+	statuses := make(map[uuid.UUID]domain.StepStatus, len(steps))
+	for _, step := range steps {
+		statuses[step.ID] = step.Status
+	}
+
+	var skipped []uuid.UUID
+	for changed := true; changed; {
+		changed = false
+		for _, step := range steps {
+			if step.Status != domain.StepStatusPending || len(step.Dependencies) == 0 {
+				continue
+			}
+			allSkipped := true
+			for _, depID := range step.Dependencies {
+				if statuses[depID] != domain.StepStatusSkipped {
+					allSkipped = false
+					break
+				}
+			}
+			if !allSkipped {
+				continue
+			}
+			s.skipStep(ctx, execution, step, "all dependencies were skipped")
+			statuses[step.ID] = domain.StepStatusSkipped
+			skipped = append(skipped, step.ID)
+			changed = true
+		}
+	}
+	return skipped
+}
+
+// skipStep 跳过步骤并记录步骤执行
+func (s *OrchestratorService) skipStep(ctx context.Context, execution *domain.Execution, step *domain.Step, reason string) {
+	step.Skip(reason)
+	s.stepRepo.Save(ctx, step)
+
+	stepExecution := domain.NewStepExecution(execution.ID, step.ID, step.Input)
+	stepExecution.Skip(reason)
+	execution.AddStepExecution(stepExecution)
+	s.stepExecutionRepo.Save(ctx, stepExecution)
+
+	s.logger.Info("Step skipped",
+		zap.String("execution_id", execution.ID.String()),
+		zap.String("step_id", step.ID.String()),
+		zap.String("reason", reason))
+}
+
+// conditionElseSteps 读取条件步骤配置的否定分支步骤
+func conditionElseSteps(condition *domain.Step) map[string]bool {
+	elseSteps := make(map[string]bool)
+	switch value := condition.Config[ConditionConfigElseSteps].(type) {
+	case []interface{}:
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				elseSteps[name] = true
+			}
+		}
+	case []string:
+		for _, name := range value {
+			elseSteps[name] = true
+		}
+	}
+	return elseSteps
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+)
+
+// dagNode 依赖图中的节点
+type dagNode struct {
+	key  string   // 唯一标识：步骤ID或工作流定义中的id
+	name string   // 错误信息中展示的名称
+	deps []string // 依赖节点的key
+}
+
+// ValidateDAG 检查步骤的依赖关系构成有向无环图
+// 存在依赖不存在的步骤或循环依赖时返回错误，错误信息列出涉及的步骤
+func ValidateDAG(steps []*domain.Step) error {
+	nodes := make([]dagNode, len(steps))
+	for i, step := range steps {
+		deps := make([]string, len(step.Dependencies))
+		for j, depID := range step.Dependencies {
+			deps[j] = depID.String()
+		}
+		name := step.Name
+		if name == "" {
+			name = step.ID.String()
+		}
+		nodes[i] = dagNode{key: step.ID.String(), name: name, deps: deps}
+	}
+	return validateDAG(nodes)
+}
+
+// validateDefinitionDAG 检查工作流定义中steps的依赖关系，步骤以id标识、以depends_on声明依赖
+func validateDefinitionDAG(definition map[string]interface{}) error {
+	items, exists := definition["steps"]
+	if !exists || items == nil {
+		return nil
+	}
+	list, ok := items.([]interface{})
+	if !ok {
+		return domain.NewWorkflowError("definition steps must be a list")
+	}
+
+	nodes := make([]dagNode, 0, len(list))
+	for i, item := range list {
+		step, ok := item.(map[string]interface{})
+		if !ok {
+			return domain.NewWorkflowError(fmt.Sprintf("definition step %d must be an object", i+1))
+		}
+		id, _ := step["id"].(string)
+		if id == "" {
+			return domain.NewWorkflowError(fmt.Sprintf("definition step %d has no id", i+1))
+		}
+
+		var deps []string
+		switch value := step["depends_on"].(type) {
+		case nil:
+		case []interface{}:
+			for _, dep := range value {
+				depID, ok := dep.(string)
+				if !ok {
+					return domain.NewWorkflowError(fmt.Sprintf("depends_on of step %q must contain step ids", id))
+				}
+				deps = append(deps, depID)
+			}
+		default:
+			return domain.NewWorkflowError(fmt.Sprintf("depends_on of step %q must be a list", id))
+		}
+		nodes = append(nodes, dagNode{key: id, name: id, deps: deps})
+	}
+	return validateDAG(nodes)
+}
+
+// validateDAG 先检查重复和悬空的依赖，再用深度优先搜索查找环，返回找到的第一个环
+func validateDAG(nodes []dagNode) error {
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		if _, exists := index[node.key]; exists {
+			return domain.NewWorkflowError(fmt.Sprintf("duplicate step %q", node.name))
+		}
+		index[node.key] = i
+	}
+
+	var dangling []string
+	for _, node := range nodes {
+		for _, dep := range node.deps {
+			if _, exists := index[dep]; !exists {
+				dangling = append(dangling, fmt.Sprintf("step %q depends on non-existent step %q", node.name, dep))
+			}
+		}
+	}
+	if len(dangling) > 0 {
+		return domain.NewWorkflowError(strings.Join(dangling, "; "))
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(nodes))
+	var stack []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, dep := range nodes[i].deps {
+			j := index[dep]
+			switch state[j] {
+			case visiting:
+				// 栈中从j到当前节点的部分构成环
+				for k, n := range stack {
+					if n == j {
+						return append(append([]int(nil), stack[k:]...), j)
+					}
+				}
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range nodes {
+		if state[i] != unvisited {
+			continue
+		}
+		if cycle := visit(i); cycle != nil {
+			names := make([]string, len(cycle))
+			for k, n := range cycle {
+				names[k] = fmt.Sprintf("%q", nodes[n].name)
+			}
+			// 依赖边由步骤指向其依赖，反转后按执行顺序展示
+			for l, r := 0, len(names)-1; l < r; l, r = l+1, r-1 {
+				names[l], names[r] = names[r], names[l]
+			}
+			return domain.NewWorkflowError("dependency cycle: " + strings.Join(names, " -> "))
+		}
+	}
+	return nil
+}
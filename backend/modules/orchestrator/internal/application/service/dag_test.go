@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+)
+
+// newDAGSteps 按名称创建步骤，deps中的名称作为依赖
+func newDAGSteps(deps map[string][]string, names ...string) []*domain.Step {
+	workflowID := uuid.New()
+	byName := make(map[string]*domain.Step, len(names))
+	steps := make([]*domain.Step, len(names))
+	for i, name := range names {
+		steps[i] = domain.NewStep(workflowID, name, domain.StepTypeAction, i+1)
+		byName[name] = steps[i]
+	}
+	for _, step := range steps {
+		for _, dep := range deps[step.Name] {
+			step.Dependencies = append(step.Dependencies, byName[dep].ID)
+		}
+	}
+	return steps
+}
+
+func TestValidateDAG(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []*domain.Step
+		want  string
+	}{
+		{
+			name:  "valid",
+			steps: newDAGSteps(map[string][]string{"b": {"a"}, "c": {"a", "b"}}, "a", "b", "c"),
+		},
+		{
+			name:  "self cycle",
+			steps: newDAGSteps(map[string][]string{"a": {"a"}}, "a"),
+			want:  `dependency cycle: "a" -> "a"`,
+		},
+		{
+			name:  "two node cycle",
+			steps: newDAGSteps(map[string][]string{"a": {"b"}, "b": {"a"}}, "a", "b"),
+			want:  `dependency cycle: "a" -> "b" -> "a"`,
+		},
+		{
+			name:  "three node cycle",
+			steps: newDAGSteps(map[string][]string{"a": {"c"}, "b": {"a"}, "c": {"b"}}, "a", "b", "c"),
+			want:  `dependency cycle: "a" -> "b" -> "c" -> "a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDAG(tt.steps)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDAGDanglingDependency(t *testing.T) {
+	steps := newDAGSteps(nil, "a", "b")
+	missing := uuid.New()
+	steps[0].Dependencies = append(steps[0].Dependencies, missing)
+
+	err := ValidateDAG(steps)
+	want := fmt.Sprintf("step %q depends on non-existent step %q", "a", missing.String())
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+}
+
+func TestValidateDefinitionDAG(t *testing.T) {
+	step := func(id string, deps ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"id": id, "depends_on": deps}
+	}
+	tests := []struct {
+		name  string
+		steps []interface{}
+		want  string
+	}{
+		{
+			name:  "valid",
+			steps: []interface{}{step("x"), step("y", "x")},
+		},
+		{
+			name:  "cycle",
+			steps: []interface{}{step("x"), step("y", "x", "z"), step("z", "y")},
+			want:  `dependency cycle: "y" -> "z" -> "y"`,
+		},
+		{
+			name:  "dangling dependency",
+			steps: []interface{}{step("x"), step("y", "q")},
+			want:  `step "y" depends on non-existent step "q"`,
+		},
+		{
+			name:  "duplicate step",
+			steps: []interface{}{step("x"), step("x")},
+			want:  `duplicate step "x"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDefinitionDAG(map[string]interface{}{"steps": tt.steps})
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddStepRejectsDanglingDependency(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	workflow := env.addWorkflow(t)
+	env.addStep(t, workflow, "first", domain.StepTypeAction)
+
+	cmd := NewAddStepCommand()
+	cmd.WorkflowID = workflow.ID
+	cmd.Name = "second"
+	cmd.Type = domain.StepTypeAction
+	cmd.Order = 2
+	cmd.Dependencies = []uuid.UUID{uuid.New()}
+	if _, err := env.service.AddStep(context.Background(), cmd); err == nil {
+		t.Fatal("step with a dangling dependency should be rejected")
+	}
+
+	steps, _ := env.steps.FindByWorkflowID(context.Background(), workflow.ID)
+	if len(steps) != 1 {
+		t.Fatalf("rejected step was saved: %d steps", len(steps))
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// ErrExecutionCancelled 执行被取消，作为执行上下文的取消原因，与执行超时区分
+var ErrExecutionCancelled = errors.New("execution cancelled")
+
+// DefaultCancelPollInterval 默认检查运行中的执行是否在其他实例被取消的间隔
+const DefaultCancelPollInterval = 5 * time.Second
+
+// runningExecution 本实例中正在运行的执行
+type runningExecution struct {
+	execution *domain.Execution
+	cancel    context.CancelCauseFunc
+	done      chan struct{} // 执行结束后关闭
+}
+
+// runningExecutions 运行中执行的取消句柄，按执行ID索引，可并发访问
+type runningExecutions struct {
+	mu         sync.Mutex
+	executions map[uuid.UUID]*runningExecution
+}
+
+// add 登记运行中的执行
+func (r *runningExecutions) add(id uuid.UUID, running *runningExecution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.executions == nil {
+		r.executions = make(map[uuid.UUID]*runningExecution)
+	}
+	r.executions[id] = running
+}
+
+// remove 移除已结束的执行
+func (r *runningExecutions) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.executions, id)
+}
+
+// get 获取运行中的执行，不在本实例运行时返回nil
+func (r *runningExecutions) get(id uuid.UUID) *runningExecution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.executions[id]
+}
+
+// launchExecution 登记执行的取消句柄并异步执行工作流，执行结束后移除句柄
+// 执行不随发起请求结束而取消，只能通过CancelExecution取消
+func (s *OrchestratorService) launchExecution(ctx context.Context, workflow *domain.Workflow, execution *domain.Execution, reusedSteps map[uuid.UUID]bool) {
+	runCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	running := &runningExecution{
+		execution: execution,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	s.runningExecutions.add(execution.ID, running)
+
+	go func() {
+		defer close(running.done)
+		defer s.runningExecutions.remove(execution.ID)
+		defer cancel(nil)
+		s.executeWorkflowAsync(ctx, runCtx, workflow, execution, reusedSteps)
+	}()
+	go s.watchCancellation(context.WithoutCancel(ctx), execution.ID, running)
+}
+
+// watchCancellation 定期检查执行是否已在其他实例被取消（库中状态为已取消），是则取消本实例中的执行，执行结束后返回
+func (s *OrchestratorService) watchCancellation(ctx context.Context, executionID uuid.UUID, running *runningExecution) {
+	if s.cancelPollInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-running.done:
+			return
+		case <-ticker.C:
+		}
+
+		stored, err := s.executionRepo.FindByID(ctx, executionID)
+		if err != nil {
+			s.logger.Warn("Failed to check execution cancellation",
+				zap.String("execution_id", executionID.String()),
+				zap.Error(err))
+			continue
+		}
+		if stored.Status == domain.ExecutionStatusCancelled {
+			s.logger.Info("Execution cancelled on another instance",
+				zap.String("execution_id", executionID.String()))
+			running.cancel(ErrExecutionCancelled)
+			return
+		}
+	}
+}
+
+// saveRunningExecution 保存运行中执行的状态，执行已被其他实例取消时不覆盖取消状态，并取消本实例中的执行
+func (s *OrchestratorService) saveRunningExecution(ctx context.Context, execution *domain.Execution) {
+	saved, err := s.executionRepo.SaveUnlessCancelled(ctx, execution)
+	if err != nil {
+		s.logger.Error("Failed to save execution",
+			zap.String("execution_id", execution.ID.String()),
+			zap.Error(err))
+		return
+	}
+	if !saved {
+		s.logger.Info("Execution cancelled on another instance, keeping cancelled status",
+			zap.String("execution_id", execution.ID.String()))
+		if running := s.runningExecutions.get(execution.ID); running != nil {
+			running.cancel(ErrExecutionCancelled)
+		}
+	}
+}
+
+// CancelExecution 取消执行
+// 本实例中运行的执行：取消进行中的步骤并不再启动后续步骤，等待执行结束后返回已取消的执行；
+// 不在本实例运行的未结束执行在库中标记为已取消，运行它的实例通过watchCancellation或保存状态时发现后停止执行，
+// 服务重启后遗留的执行同样直接标记为已取消
+func (s *OrchestratorService) CancelExecution(ctx context.Context, executionID uuid.UUID) (*application.Result, error) {
+	if running := s.runningExecutions.get(executionID); running != nil {
+		running.cancel(ErrExecutionCancelled)
+		select {
+		case <-running.done:
+		case <-ctx.Done():
+			return &application.Result{Success: false, Error: ctx.Err().Error()}, ctx.Err()
+		}
+
+		// 取消前执行已经结束
+		if running.execution.Status != domain.ExecutionStatusCancelled {
+			err := domain.NewExecutionError(fmt.Sprintf("execution is already %s", running.execution.Status))
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+		return &application.Result{Success: true, Data: running.execution}, nil
+	}
+
+	execution, err := s.executionRepo.FindByID(ctx, executionID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "execution not found"}, err
+	}
+	if execution.Status != domain.ExecutionStatusPending && execution.Status != domain.ExecutionStatusRunning {
+		err := domain.NewExecutionError(fmt.Sprintf("execution is already %s", execution.Status))
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	execution.Cancel()
+	saved, err := s.executionRepo.SaveIfActive(ctx, execution)
+	if err != nil {
+		s.logger.Error("Failed to save cancelled execution", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to save execution"}, err
+	}
+	// 读取后执行已在其他实例结束
+	if !saved {
+		err := domain.NewExecutionError("execution has already finished")
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	s.logger.Info("Cancelled execution not running on this instance",
+		zap.String("execution_id", execution.ID.String()))
+	return &application.Result{Success: true, Data: execution}, nil
+}
+
+// stopExecution 执行上下文结束后，按原因将执行标记为已取消或超时
+func (s *OrchestratorService) stopExecution(ctx, runCtx context.Context, workflow *domain.Workflow, execution *domain.Execution) {
+	if errors.Is(context.Cause(runCtx), ErrExecutionCancelled) {
+		s.cancelExecution(ctx, workflow, execution)
+		return
+	}
+	s.timeoutExecution(ctx, workflow, execution)
+}
+
+// cancelExecution 将被取消的执行标记为已取消，未启动的步骤保持待执行
+func (s *OrchestratorService) cancelExecution(ctx context.Context, workflow *domain.Workflow, execution *domain.Execution) {
+	s.logger.Info("Workflow execution cancelled",
+		zap.String("execution_id", execution.ID.String()),
+		zap.String("workflow_id", workflow.ID.String()))
+
+	execution.Cancel()
+	s.executionRepo.Save(ctx, execution)
+
+	// 记录工作流执行取消指标
+	if s.metrics != nil {
+		s.metrics.RecordWorkflowExecution(workflow.ID.String(), "cancelled", execution.Duration)
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+)
+
+// blockingStepExecutor 开始执行后阻塞，release关闭时成功完成，上下文取消时返回取消原因
+type blockingStepExecutor struct {
+	calls   int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingStepExecutor() *blockingStepExecutor {
+	return &blockingStepExecutor{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (e *blockingStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	atomic.AddInt32(&e.calls, 1)
+	e.started <- struct{}{}
+	select {
+	case <-e.release:
+		return &StepExecutionResult{Output: map[string]interface{}{}}, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+func (e *blockingStepExecutor) GetSupportedType() domain.StepType { return domain.StepTypeHuman }
+
+// waitStarted 等待步骤开始执行
+func (e *blockingStepExecutor) waitStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case <-e.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("blocking step never started")
+	}
+}
+
+// countingStepExecutor 记录调用次数
+type countingStepExecutor struct {
+	calls int32
+}
+
+func (e *countingStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	atomic.AddInt32(&e.calls, 1)
+	return &StepExecutionResult{Output: map[string]interface{}{}}, nil
+}
+
+func (e *countingStepExecutor) GetSupportedType() domain.StepType { return domain.StepTypeWait }
+
+// startExecution 执行工作流，返回执行及其运行句柄
+func (env *orchestratorTestEnv) startExecution(t *testing.T, workflow *domain.Workflow, blocking *blockingStepExecutor) (*domain.Execution, *runningExecution) {
+	t.Helper()
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = workflow.ID
+	execution := env.execute(t, cmd)
+	blocking.waitStarted(t)
+	running := env.service.runningExecutions.get(execution.ID)
+	if running == nil {
+		t.Fatal("execution is not registered as running")
+	}
+	return execution, running
+}
+
+func TestCancelExecutionStopsRemainingSteps(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	blocking := newBlockingStepExecutor()
+	counting := &countingStepExecutor{}
+	env.service.RegisterStepExecutor(domain.StepTypeHuman, blocking)
+	env.service.RegisterStepExecutor(domain.StepTypeWait, counting)
+
+	workflow := env.addWorkflow(t)
+	first := env.addStep(t, workflow, "first", domain.StepTypeWait)
+	second := env.addStep(t, workflow, "second", domain.StepTypeHuman, first)
+	second.MaxRetries = 3
+	env.saveStep(t, second)
+	third := env.addStep(t, workflow, "third", domain.StepTypeWait, second)
+	fourth := env.addStep(t, workflow, "fourth", domain.StepTypeWait, third)
+
+	execution, _ := env.startExecution(t, workflow, blocking)
+	result, err := env.service.CancelExecution(context.Background(), execution.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := result.Data.(*domain.Execution).Status; status != domain.ExecutionStatusCancelled {
+		t.Fatalf("returned status = %s", status)
+	}
+	if stored, _ := env.executions.FindByID(context.Background(), execution.ID); stored.Status != domain.ExecutionStatusCancelled {
+		t.Fatalf("stored status = %s", stored.Status)
+	}
+
+	// 取消的步骤不重试，后续步骤不执行
+	if calls := atomic.LoadInt32(&counting.calls); calls != 1 {
+		t.Fatalf("wait executor called %d times, want only the first step", calls)
+	}
+	if calls := atomic.LoadInt32(&blocking.calls); calls != 1 {
+		t.Fatalf("cancelled step ran %d times", calls)
+	}
+	// 执行结束时不等待未响应取消的步骤，步骤状态随后保存
+	env.waitForStepStatus(t, second.ID, domain.StepStatusCancelled)
+	if third, fourth := env.stepStatus(t, third.ID), env.stepStatus(t, fourth.ID); third != domain.StepStatusPending || fourth != domain.StepStatusPending {
+		t.Fatalf("third step = %s, fourth step = %s", third, fourth)
+	}
+	if env.service.runningExecutions.get(execution.ID) != nil {
+		t.Fatal("cancel handle was not removed")
+	}
+
+	if _, err := env.service.CancelExecution(context.Background(), execution.ID); err == nil {
+		t.Fatal("finished execution should not be cancelled again")
+	}
+}
+
+func TestCancelOrphanedExecution(t *testing.T) {
+	// 服务重启后遗留的运行中执行直接在库中标记为已取消
+	env := newOrchestratorTestEnv()
+	execution := domain.NewExecution(uuid.New(), uuid.Nil, nil)
+	execution.Start()
+	if err := env.executions.Save(context.Background(), execution); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.service.CancelExecution(context.Background(), execution.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := env.executions.FindByID(context.Background(), execution.ID); stored.Status != domain.ExecutionStatusCancelled {
+		t.Fatalf("stored status = %s", stored.Status)
+	}
+}
+
+func TestCancelExecutionFromAnotherInstance(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	env.service.SetCancelPollInterval(10 * time.Millisecond)
+	blocking := newBlockingStepExecutor()
+	env.service.RegisterStepExecutor(domain.StepTypeHuman, blocking)
+	workflow := env.addWorkflow(t)
+	step := env.addStep(t, workflow, "approve", domain.StepTypeHuman)
+
+	execution, running := env.startExecution(t, workflow, blocking)
+
+	// 另一个实例没有取消句柄，只在库中标记为已取消
+	other := newOrchestratorTestEnv()
+	other.service.executionRepo = env.executions
+	if _, err := other.service.CancelExecution(context.Background(), execution.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-running.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("running instance did not stop")
+	}
+	env.waitForStepStatus(t, step.ID, domain.StepStatusCancelled)
+	if stored, _ := env.executions.FindByID(context.Background(), execution.ID); stored.Status != domain.ExecutionStatusCancelled {
+		t.Fatalf("stored status = %s", stored.Status)
+	}
+}
+
+func TestCancelledExecutionNotOverwrittenOnFinish(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	env.service.SetCancelPollInterval(0)
+	blocking := newBlockingStepExecutor()
+	env.service.RegisterStepExecutor(domain.StepTypeHuman, blocking)
+	workflow := env.addWorkflow(t)
+	env.addStep(t, workflow, "approve", domain.StepTypeHuman)
+
+	execution, running := env.startExecution(t, workflow, blocking)
+
+	// 其他实例取消后本实例的步骤才完成
+	stored, _ := env.executions.FindByID(context.Background(), execution.ID)
+	stored.Cancel()
+	if err := env.executions.Save(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	close(blocking.release)
+	<-running.done
+
+	if stored, _ := env.executions.FindByID(context.Background(), execution.ID); stored.Status != domain.ExecutionStatusCancelled {
+		t.Fatalf("stored status = %s, cancellation was overwritten", stored.Status)
+	}
+}
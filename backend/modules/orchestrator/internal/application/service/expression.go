@@ -0,0 +1,664 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxExpressionLength 表达式的最大长度（字符数）
+	MaxExpressionLength = 4096
+	// maxExpressionDepth 表达式的最大嵌套深度，防止构造的表达式耗尽栈空间
+	maxExpressionDepth = 64
+)
+
+// EvaluateCondition 对变量求值条件表达式，返回表达式结果的真假
+// 支持的语法：
+//   - 字面量：数字、'字符串'或"字符串"、true、false、null、列表[1, 'a']
+//   - 变量路径：input.user.age、steps.fetch.output.count、steps["步骤 名称"].output.ok、items[0]
+//   - 算术：+ - * / %，+ 的任一侧为字符串时拼接字符串
+//   - 比较：== != > >= < <=，数字按数值比较，字符串按字典序比较；x in 列表/对象/字符串
+//   - 逻辑：&& || !，以及括号分组
+//   - 函数：只能调用expressionFunctions中的内置函数，如len(items) > 0、lower(input.name) == 'bob'
+//
+// 求值器只读取传入的变量，不能访问Go对象的方法，也没有任何IO
+// 不存在的路径求值为null；逻辑运算中null、false、0和空字符串为假，其余为真
+func EvaluateCondition(expression string, variables map[string]interface{}) (bool, error) {
+	value, err := EvaluateExpression(expression, variables)
+	if err != nil {
+		return false, err
+	}
+	return truthy(value), nil
+}
+
+// EvaluateExpression 对变量求值表达式，返回表达式的值
+func EvaluateExpression(expression string, variables map[string]interface{}) (interface{}, error) {
+	return evaluateExpression(expression, variables, 0)
+}
+
+// ValidateExpression 检查表达式语法和函数调用，不对变量求值
+func ValidateExpression(expression string) error {
+	_, err := evaluateExpression(expression, nil, 1)
+	return err
+}
+
+// evaluateExpression 解析并求值表达式，skipping大于0时只检查语法
+func evaluateExpression(expression string, variables map[string]interface{}, skipping int) (interface{}, error) {
+	if len([]rune(expression)) > MaxExpressionLength {
+		return nil, fmt.Errorf("expression exceeds %d characters", MaxExpressionLength)
+	}
+
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &expressionParser{tokens: tokens, variables: variables, skipping: skipping}
+	value, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+	}
+	return value, nil
+}
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+// expressionToken 词法单元
+type expressionToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// expressionOperators 运算符和标点，双字符运算符需排在单字符之前
+var expressionOperators = []string{
+	"&&", "||", "==", "!=", ">=", "<=", ">", "<", "!",
+	"+", "-", "*", "/", "%",
+	"(", ")", "[", "]", ".", ",",
+}
+
+// tokenizeExpression 将表达式拆分为词法单元
+func tokenizeExpression(expression string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			var builder strings.Builder
+			start := i
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				builder.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, expressionToken{kind: tokenString, text: builder.String(), pos: start})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+			for _, op := range expressionOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, expressionToken{kind: tokenOperator, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+
+	tokens = append(tokens, expressionToken{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// expressionParser 递归下降求值器，解析的同时计算结果
+type expressionParser struct {
+	tokens    []expressionToken
+	pos       int
+	variables map[string]interface{}
+	skipping  int // 大于0时处于被短路的分支，只解析不报告求值错误
+	depth     int // 当前嵌套深度
+}
+
+func (p *expressionParser) peek() expressionToken {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() expressionToken {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// accept 下一个词法单元为指定运算符时消费它
+func (p *expressionParser) accept(op string) bool {
+	if token := p.peek(); token.kind == tokenOperator && token.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect 消费指定运算符，否则返回错误
+func (p *expressionParser) expect(op string) error {
+	if !p.accept(op) {
+		token := p.peek()
+		return fmt.Errorf("expected %q at position %d", op, token.pos)
+	}
+	return nil
+}
+
+// evalError 报告求值错误，被短路的分支中忽略错误并求值为null
+func (p *expressionParser) evalError(err error) (interface{}, error) {
+	if p.skipping > 0 {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// parseOr or := and ('||' and)*
+func (p *expressionParser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		short := truthy(left)
+		right, err := p.shortCircuit(short, p.parseAnd)
+		if err != nil {
+			return nil, err
+		}
+		left = short || truthy(right)
+	}
+	return left, nil
+}
+
+// parseAnd and := comparison ('&&' comparison)*
+func (p *expressionParser) parseAnd() (interface{}, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		short := !truthy(left)
+		right, err := p.shortCircuit(short, p.parseComparison)
+		if err != nil {
+			return nil, err
+		}
+		left = !short && truthy(right)
+	}
+	return left, nil
+}
+
+// shortCircuit 解析右操作数，short为true时结果已确定，右操作数的求值错误被忽略
+// 使 value != null && value > 10 这类写法在value为null时不会报错
+func (p *expressionParser) shortCircuit(short bool, parse func() (interface{}, error)) (interface{}, error) {
+	if short {
+		p.skipping++
+		defer func() { p.skipping-- }()
+	}
+	return parse()
+}
+
+// parseComparison comparison := additive (op additive)?
+func (p *expressionParser) parseComparison() (interface{}, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	switch {
+	case token.kind == tokenIdent && token.text == "in":
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		result, err := containsValue(right, left)
+		if err != nil {
+			return p.evalError(err)
+		}
+		return result, nil
+	case token.kind != tokenOperator:
+		return left, nil
+	}
+
+	switch token.text {
+	case "==", "!=", ">", ">=", "<", "<=":
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		result, err := compareValues(token.text, left, right)
+		if err != nil && p.skipping > 0 {
+			return false, nil
+		}
+		return result, err
+	default:
+		return left, nil
+	}
+}
+
+// parseAdditive additive := multiplicative (('+' | '-') multiplicative)*
+func (p *expressionParser) parseAdditive() (interface{}, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		if token.kind != tokenOperator || token.text != "+" && token.text != "-" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(token.text, left, right); err != nil {
+			if left, err = p.evalError(err); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// parseMultiplicative multiplicative := unary (('*' | '/' | '%') unary)*
+func (p *expressionParser) parseMultiplicative() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		if token.kind != tokenOperator || token.text != "*" && token.text != "/" && token.text != "%" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(token.text, left, right); err != nil {
+			if left, err = p.evalError(err); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// parseUnary unary := '!' unary | '-' unary | primary
+func (p *expressionParser) parseUnary() (interface{}, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return nil, fmt.Errorf("expression nesting exceeds %d levels", maxExpressionDepth)
+	}
+
+	switch {
+	case p.accept("!"):
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(value), nil
+	case p.accept("-"):
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		number, ok := toNumber(value)
+		if !ok {
+			return p.evalError(fmt.Errorf("cannot negate %T", value))
+		}
+		return -number, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary primary := (number | string | true | false | null | call | path | list | '(' or ')') 路径访问*
+func (p *expressionParser) parsePrimary() (interface{}, error) {
+	token := p.next()
+	switch token.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", token.text, token.pos)
+		}
+		return value, nil
+	case tokenString:
+		return token.text, nil
+	case tokenIdent:
+		switch token.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null", "nil":
+			return nil, nil
+		}
+		if p.accept("(") {
+			value, err := p.parseCall(token)
+			if err != nil {
+				return nil, err
+			}
+			return p.parsePath(value)
+		}
+		return p.parsePath(p.variables[token.text])
+	case tokenOperator:
+		switch token.text {
+		case "(":
+			value, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return p.parsePath(value)
+		case "[":
+			items, err := p.parseArguments("]")
+			if err != nil {
+				return nil, err
+			}
+			return p.parsePath(items)
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+}
+
+// parseCall 解析并调用内置函数，函数名未知或参数个数不符属于语法错误
+func (p *expressionParser) parseCall(name expressionToken) (interface{}, error) {
+	function, exists := expressionFunctions[name.text]
+	if !exists {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+
+	args, err := p.parseArguments(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < function.minArgs || function.maxArgs >= 0 && len(args) > function.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments for %s at position %d", name.text, name.pos)
+	}
+	if p.skipping > 0 {
+		return nil, nil
+	}
+
+	value, err := function.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name.text, err)
+	}
+	return value, nil
+}
+
+// parseArguments 解析以逗号分隔、以closing结束的表达式列表
+func (p *expressionParser) parseArguments(closing string) ([]interface{}, error) {
+	items := make([]interface{}, 0)
+	if p.accept(closing) {
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept(closing) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parsePath 解析值后续的 .字段 和 [下标] 访问
+func (p *expressionParser) parsePath(value interface{}) (interface{}, error) {
+	for {
+		switch {
+		case p.accept("."):
+			token := p.next()
+			if token.kind != tokenIdent && token.kind != tokenNumber {
+				return nil, fmt.Errorf("expected field name at position %d", token.pos)
+			}
+			value = lookupField(value, token.text)
+		case p.accept("["):
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			value = lookupField(value, formatValue(index))
+		default:
+			return value, nil
+		}
+	}
+}
+
+// lookupField 访问对象字段或数组下标，不存在时返回nil
+func lookupField(value interface{}, field string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v[field]
+	case []interface{}:
+		index, err := strconv.Atoi(field)
+		if err != nil || index < 0 || index >= len(v) {
+			return nil
+		}
+		return v[index]
+	default:
+		return nil
+	}
+}
+
+// arithmetic 计算算术运算，+ 的任一侧为字符串时拼接字符串
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if op == "+" {
+		_, lok := left.(string)
+		_, rok := right.(string)
+		if lok || rok {
+			return formatValue(left) + formatValue(right), nil
+		}
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %T and %T", op, left, right)
+	}
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default:
+		// 取模按整数计算，(-1, 1)之间的除数截断后为0
+		if int64(r) == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return float64(int64(l) % int64(r)), nil
+	}
+}
+
+// containsValue 判断元素是否在列表中、键是否在对象中、子串是否在字符串中
+func containsValue(container, item interface{}) (bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, element := range c {
+			if equal, _ := compareValues("==", element, item); equal {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := item.(string)
+		if !ok {
+			return false, nil
+		}
+		_, exists := c[key]
+		return exists, nil
+	case string:
+		sub, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot search %T in string", item)
+		}
+		return strings.Contains(c, sub), nil
+	default:
+		return false, fmt.Errorf("cannot search in %T", container)
+	}
+}
+
+// compareValues 比较两个值
+func compareValues(op string, left, right interface{}) (bool, error) {
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			switch op {
+			case "==":
+				return l == r, nil
+			case "!=":
+				return l != r, nil
+			case ">":
+				return l > r, nil
+			case ">=":
+				return l >= r, nil
+			case "<":
+				return l < r, nil
+			case "<=":
+				return l <= r, nil
+			}
+		}
+	}
+
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	}
+
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return false, fmt.Errorf("cannot compare %T and %T with %s", left, right, op)
+	}
+	switch op {
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "<":
+		return l < r, nil
+	default:
+		return l <= r, nil
+	}
+}
+
+// toNumber 将数值类型转换为float64
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// formatValue 将值格式化为字符串，整数值的数字不带小数点
+func formatValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	if number, ok := toNumber(value); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// truthy 判断值在逻辑运算中的真假
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	default:
+		if number, ok := toNumber(v); ok {
+			return number != 0
+		}
+		return true
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// expressionFunction 表达式可调用的内置函数
+type expressionFunction struct {
+	minArgs int
+	maxArgs int // -1表示不限
+	call    func(args []interface{}) (interface{}, error)
+}
+
+// expressionFunctions 表达式可调用的函数白名单，均为无副作用的纯函数
+var expressionFunctions = map[string]expressionFunction{
+	"len":        {1, 1, fnLen},
+	"lower":      {1, 1, stringFunction(strings.ToLower)},
+	"upper":      {1, 1, stringFunction(strings.ToUpper)},
+	"trim":       {1, 1, stringFunction(strings.TrimSpace)},
+	"contains":   {2, 2, fnContains},
+	"startsWith": {2, 2, stringPredicate(strings.HasPrefix)},
+	"endsWith":   {2, 2, stringPredicate(strings.HasSuffix)},
+	"split":      {2, 2, fnSplit},
+	"join":       {1, 2, fnJoin},
+	"abs":        {1, 1, numberFunction(math.Abs)},
+	"floor":      {1, 1, numberFunction(math.Floor)},
+	"ceil":       {1, 1, numberFunction(math.Ceil)},
+	"round":      {1, 1, numberFunction(math.Round)},
+	"min":        {1, -1, numberReducer(math.Min)},
+	"max":        {1, -1, numberReducer(math.Max)},
+	"int":        {1, 1, fnInt},
+	"float":      {1, 1, fnFloat},
+	"string":     {1, 1, fnString},
+	"default":    {2, 2, fnDefault},
+}
+
+// fnLen 字符串的字符数、列表或对象的元素数，null为0
+func fnLen(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(len([]rune(v))), nil
+	}
+	value := reflect.ValueOf(args[0])
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), nil
+	}
+	return nil, fmt.Errorf("cannot get length of %T", args[0])
+}
+
+// fnContains 列表是否包含元素、对象是否包含键、字符串是否包含子串
+func fnContains(args []interface{}) (interface{}, error) {
+	return containsValue(args[0], args[1])
+}
+
+// fnSplit 按分隔符拆分字符串
+func fnSplit(args []interface{}) (interface{}, error) {
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := stringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, sep)
+	items := make([]interface{}, len(parts))
+	for i, part := range parts {
+		items[i] = part
+	}
+	return items, nil
+}
+
+// fnJoin 用分隔符连接列表元素，默认分隔符为逗号
+func fnJoin(args []interface{}) (interface{}, error) {
+	items, ok := args[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected list, got %T", args[0])
+	}
+	sep := ","
+	if len(args) > 1 {
+		var err error
+		if sep, err = stringArg(args, 1); err != nil {
+			return nil, err
+		}
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = formatValue(item)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// fnInt 转换为整数，小数部分被截断
+func fnInt(args []interface{}) (interface{}, error) {
+	number, err := parseNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return math.Trunc(number), nil
+}
+
+// fnFloat 转换为数字
+func fnFloat(args []interface{}) (interface{}, error) {
+	return parseNumber(args[0])
+}
+
+// fnString 转换为字符串
+func fnString(args []interface{}) (interface{}, error) {
+	return formatValue(args[0]), nil
+}
+
+// fnDefault 第一个参数为null时返回第二个参数
+func fnDefault(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+// stringFunction 包装单个字符串参数的函数
+func stringFunction(fn func(string) string) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(s), nil
+	}
+}
+
+// stringPredicate 包装两个字符串参数的判断函数
+func stringPredicate(fn func(string, string) bool) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		affix, err := stringArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(s, affix), nil
+	}
+}
+
+// numberFunction 包装单个数字参数的函数
+func numberFunction(fn func(float64) float64) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		number, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("expected number, got %T", args[0])
+		}
+		return fn(number), nil
+	}
+}
+
+// numberReducer 包装min、max这类函数，参数可以是多个数字或一个数字列表
+func numberReducer(fn func(float64, float64) float64) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) == 1 {
+			if items, ok := args[0].([]interface{}); ok {
+				args = items
+			}
+		}
+		if len(args) == 0 {
+			return nil, nil
+		}
+		var result float64
+		for i, arg := range args {
+			number, ok := toNumber(arg)
+			if !ok {
+				return nil, fmt.Errorf("expected number, got %T", arg)
+			}
+			if i == 0 {
+				result = number
+			} else {
+				result = fn(result, number)
+			}
+		}
+		return result, nil
+	}
+}
+
+// stringArg 读取字符串参数
+func stringArg(args []interface{}, index int) (string, error) {
+	s, ok := args[index].(string)
+	if !ok {
+		return "", fmt.Errorf("argument %d: expected string, got %T", index+1, args[index])
+	}
+	return s, nil
+}
+
+// parseNumber 将数字、数字字符串或布尔值转换为数字
+func parseNumber(value interface{}) (float64, error) {
+	if number, ok := toNumber(value); ok {
+		return number, nil
+	}
+	switch v := value.(type) {
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to number", v)
+		}
+		return number, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to number", value)
+}
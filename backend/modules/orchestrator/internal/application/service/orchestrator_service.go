@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -15,15 +16,19 @@ import (
 
 // OrchestratorService 编排服务
 type OrchestratorService struct {
-	workflowRepo      domain.WorkflowRepository
-	stepRepo          domain.StepRepository
-	triggerRepo       domain.TriggerRepository
-	executionRepo     domain.ExecutionRepository
-	stepExecutionRepo domain.StepExecutionRepository
-	eventBus          application.EventBus
-	logger            infrastructure.Logger
-	metrics           *infrastructure.MetricsRegistry
-	stepExecutors     map[domain.StepType]StepExecutor
+	workflowRepo       domain.WorkflowRepository
+	stepRepo           domain.StepRepository
+	triggerRepo        domain.TriggerRepository
+	executionRepo      domain.ExecutionRepository
+	stepExecutionRepo  domain.StepExecutionRepository
+	eventBus           application.EventBus
+	logger             infrastructure.Logger
+	metrics            *infrastructure.MetricsRegistry
+	stepExecutors      map[domain.StepType]StepExecutor
+	triggerObservers   []TriggerObserver
+	runningExecutions  runningExecutions // 本实例中运行的执行，用于取消
+	cancelPollInterval time.Duration     // 检查运行中的执行是否在其他实例被取消的间隔
+	stepCache          StepCache         // 可缓存步骤的输出缓存
 }
 
 // NewOrchestratorService 创建编排服务
@@ -37,7 +42,7 @@ func NewOrchestratorService(
 	logger infrastructure.Logger,
 	metrics *infrastructure.MetricsRegistry,
 ) *OrchestratorService {
-	return &OrchestratorService{
+	s := &OrchestratorService{
 		workflowRepo:      workflowRepo,
 		stepRepo:          stepRepo,
 		triggerRepo:       triggerRepo,
@@ -46,8 +51,14 @@ func NewOrchestratorService(
 		eventBus:          eventBus,
 		logger:            logger,
 		metrics:           metrics,
-		stepExecutors:     make(map[domain.StepType]StepExecutor),
+		stepExecutors: map[domain.StepType]StepExecutor{
+			domain.StepTypeCondition: NewConditionStepExecutor(),
+		},
+		cancelPollInterval: DefaultCancelPollInterval,
+		stepCache:          noopStepCache{},
 	}
+	s.stepExecutors[domain.StepTypeSubworkflow] = NewSubworkflowStepExecutor(s, DefaultMaxSubworkflowDepth)
+	return s
 }
 
 // RegisterStepExecutor 注册步骤执行器
@@ -55,6 +66,21 @@ func (s *OrchestratorService) RegisterStepExecutor(stepType domain.StepType, exe
 	s.stepExecutors[stepType] = executor
 }
 
+// SetStepCache 设置步骤输出缓存，未设置时不缓存
+func (s *OrchestratorService) SetStepCache(stepCache StepCache) {
+	s.stepCache = stepCache
+}
+
+// SetCancelPollInterval 设置检查运行中的执行是否在其他实例被取消的间隔，为0时不检查
+func (s *OrchestratorService) SetCancelPollInterval(interval time.Duration) {
+	s.cancelPollInterval = interval
+}
+
+// RegisterTriggerObserver 注册触发器观察者，触发器添加或删除后会通知观察者
+func (s *OrchestratorService) RegisterTriggerObserver(observer TriggerObserver) {
+	s.triggerObservers = append(s.triggerObservers, observer)
+}
+
 // CreateWorkflow 创建工作流
 func (s *OrchestratorService) CreateWorkflow(ctx context.Context, cmd *CreateWorkflowCommand) (*application.Result, error) {
 	if err := cmd.Validate(); err != nil {
@@ -67,6 +93,7 @@ func (s *OrchestratorService) CreateWorkflow(ctx context.Context, cmd *CreateWor
 	workflow.Variables = cmd.Variables
 	workflow.Tags = cmd.Tags
 	workflow.IsTemplate = cmd.IsTemplate
+	workflow.MaxExecutionTime = cmd.MaxExecutionTime
 	
 	// 保存工作流
 	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
@@ -113,7 +140,7 @@ func (s *OrchestratorService) ExecuteWorkflow(ctx context.Context, cmd *ExecuteW
 	}
 	
 	// 异步执行工作流
-	go s.executeWorkflowAsync(ctx, workflow, execution)
+	s.launchExecution(ctx, workflow, execution, nil)
 	
 	// 记录工作流执行
 	workflow.RecordExecution(true) // 先记录为成功，失败时会更新
@@ -124,13 +151,175 @@ func (s *OrchestratorService) ExecuteWorkflow(ctx context.Context, cmd *ExecuteW
 	return &application.Result{Success: true, Data: execution}, nil
 }
 
+// RetryExecution 从失败步骤重新执行
+// 创建新的执行并复用原执行中成功步骤的输出，fromStep及其下游步骤会重新执行；fromStep为空时从原执行中首个未完成的步骤开始
+func (s *OrchestratorService) RetryExecution(ctx context.Context, executionID, fromStep uuid.UUID) (*application.Result, error) {
+	// 获取原执行
+	source, err := s.executionRepo.FindByID(ctx, executionID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "execution not found"}, err
+	}
+	
+	if !source.CanRetry() {
+		err := domain.NewExecutionError("only failed, cancelled or timed out executions can be retried")
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	// 获取工作流
+	workflow, err := s.workflowRepo.FindByID(ctx, source.WorkflowID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "workflow not found"}, err
+	}
+	
+	if workflow.Status != domain.WorkflowStatusActive {
+		return &application.Result{Success: false, Error: "workflow is not active"}, fmt.Errorf("workflow is not active")
+	}
+	
+	steps, err := s.stepRepo.FindByWorkflowID(ctx, workflow.ID)
+	if err != nil {
+		s.logger.Error("Failed to get workflow steps", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to get workflow steps"}, err
+	}
+	
+	stepExecutions, err := s.stepExecutionRepo.FindByExecutionID(ctx, source.ID)
+	if err != nil {
+		s.logger.Error("Failed to get step executions", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to get step executions"}, err
+	}
+	
+	// 确定恢复执行的起始步骤
+	if fromStep == uuid.Nil {
+		fromStep = findFirstUnfinishedStep(steps, stepExecutions)
+		if fromStep == uuid.Nil {
+			err := domain.NewExecutionError("no failed step found in execution")
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+	} else if !containsStep(steps, fromStep) {
+		err := domain.NewExecutionError("step does not belong to workflow")
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	// 起始步骤及其下游步骤需要重新执行，其余已成功的步骤复用原输出
+	rerunSteps := findDownstreamSteps(steps, fromStep)
+	execution := domain.NewRetryExecution(source, fromStep)
+	reusedSteps := make(map[uuid.UUID]bool)
+	
+	for _, stepExecution := range stepExecutions {
+		if stepExecution.Status != domain.StepStatusCompleted || rerunSteps[stepExecution.StepID] || reusedSteps[stepExecution.StepID] {
+			continue
+		}
+		execution.AddStepExecution(domain.NewReusedStepExecution(execution.ID, stepExecution))
+		reusedSteps[stepExecution.StepID] = true
+	}
+	
+	// 保存执行
+	if err := s.executionRepo.Save(ctx, execution); err != nil {
+		s.logger.Error("Failed to save execution", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to save execution"}, err
+	}
+	for _, stepExecution := range execution.StepExecutions {
+		if err := s.stepExecutionRepo.Save(ctx, stepExecution); err != nil {
+			s.logger.Warn("Failed to save reused step execution", zap.Error(err))
+		}
+	}
+	
+	s.logger.Info("Retrying execution",
+		zap.String("execution_id", execution.ID.String()),
+		zap.String("retry_of", source.ID.String()),
+		zap.String("from_step", fromStep.String()),
+		zap.Int("reused_steps", len(reusedSteps)))
+	
+	// 异步执行工作流
+	s.launchExecution(ctx, workflow, execution, reusedSteps)
+	
+	// 记录工作流执行
+	workflow.RecordExecution(true)
+	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+		s.logger.Warn("Failed to update workflow execution stats", zap.Error(err))
+	}
+	
+	return &application.Result{Success: true, Data: execution}, nil
+}
+
+// findFirstUnfinishedStep 找到执行中顺序最靠前的未成功完成且未被跳过的步骤
+func findFirstUnfinishedStep(steps []*domain.Step, stepExecutions []*domain.StepExecution) uuid.UUID {
+	order := make(map[uuid.UUID]int, len(steps))
+	for _, step := range steps {
+		order[step.ID] = step.Order
+	}
+	
+	completed := make(map[uuid.UUID]bool)
+	for _, stepExecution := range stepExecutions {
+		if stepExecution.Status == domain.StepStatusCompleted || stepExecution.Status == domain.StepStatusSkipped {
+			completed[stepExecution.StepID] = true
+		}
+	}
+	
+	result := uuid.Nil
+	for _, stepExecution := range stepExecutions {
+		stepOrder, exists := order[stepExecution.StepID]
+		if !exists || completed[stepExecution.StepID] {
+			continue
+		}
+		if result == uuid.Nil || stepOrder < order[result] {
+			result = stepExecution.StepID
+		}
+	}
+	
+	return result
+}
+
+// findDownstreamSteps 找到起始步骤及所有直接或间接依赖它的步骤
+func findDownstreamSteps(steps []*domain.Step, fromStep uuid.UUID) map[uuid.UUID]bool {
+	downstream := map[uuid.UUID]bool{fromStep: true}
+	
+	for changed := true; changed; {
+		changed = false
+		for _, step := range steps {
+			if downstream[step.ID] {
+				continue
+			}
+			for _, depID := range step.Dependencies {
+				if downstream[depID] {
+					downstream[step.ID] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	
+	return downstream
+}
+
+// containsStep 检查步骤是否存在
+func containsStep(steps []*domain.Step, stepID uuid.UUID) bool {
+	for _, step := range steps {
+		if step.ID == stepID {
+			return true
+		}
+	}
+	return false
+}
+
 // executeWorkflowAsync 异步执行工作流
-func (s *OrchestratorService) executeWorkflowAsync(ctx context.Context, workflow *domain.Workflow, execution *domain.Execution) {
+// reusedSteps为重新执行时复用原输出的步骤，这些步骤视为已完成，不再执行；
+// runCtx被取消时取消进行中的步骤、不再启动后续步骤并将执行标记为已取消；
+// 工作流设置了最长执行时间时，超时后同样取消进行中的步骤并将执行标记为超时
+func (s *OrchestratorService) executeWorkflowAsync(ctx, runCtx context.Context, workflow *domain.Workflow, execution *domain.Execution, reusedSteps map[uuid.UUID]bool) {
+	// 执行不随发起请求结束而取消，ctx只用于持久化，runCtx用于执行步骤
+	ctx = context.WithoutCancel(ctx)
+	if workflow.MaxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, workflow.MaxExecutionTime)
+		defer cancel()
+	}
+	
 	defer func() {
 		if r := recover(); r != nil {
 			s.logger.Error("Panic in executeWorkflowAsync", zap.Any("panic", r))
 			execution.Fail(fmt.Sprintf("internal error: %v", r))
-			s.executionRepo.Save(ctx, execution)
+			s.saveRunningExecution(ctx, execution)
 		}
 	}()
 	
@@ -139,14 +328,14 @@ func (s *OrchestratorService) executeWorkflowAsync(ctx context.Context, workflow
 		s.logger.Error("Failed to start execution", zap.Error(err))
 		return
 	}
-	s.executionRepo.Save(ctx, execution)
+	s.saveRunningExecution(ctx, execution)
 	
 	// 获取工作流步骤
 	steps, err := s.stepRepo.FindByWorkflowID(ctx, workflow.ID)
 	if err != nil {
 		s.logger.Error("Failed to get workflow steps", zap.Error(err))
 		execution.Fail("failed to get workflow steps")
-		s.executionRepo.Save(ctx, execution)
+		s.saveRunningExecution(ctx, execution)
 		return
 	}
 	
@@ -155,43 +344,134 @@ func (s *OrchestratorService) executeWorkflowAsync(ctx context.Context, workflow
 		return steps[i].Order < steps[j].Order
 	})
 	
+	// 依赖关系不合法时直接失败，不进入调度
+	if err := ValidateDAG(steps); err != nil {
+		execution.Fail(err.Error())
+		s.saveRunningExecution(ctx, execution)
+		return
+	}
+	
 	// 执行步骤
 	completedSteps := make([]uuid.UUID, 0)
+	skippedSteps := make([]uuid.UUID, 0)
+	stepOutputs := make(map[uuid.UUID]map[string]interface{})
+	// 执行变量，步骤派生的变量合并后生成新的副本，执行中的步骤持有的快照不受影响
+	variables := mergeVariables(workflow.Variables, nil)
+	
+	// 步骤状态保存的是上一次执行的结果，每次执行前重置为待执行；
+	// 重新执行时，复用的步骤直接视为完成
+	for _, step := range steps {
+		if reusedSteps[step.ID] {
+			completedSteps = append(completedSteps, step.ID)
+			continue
+		}
+		step.Reset()
+		s.stepRepo.Save(ctx, step)
+	}
+	if execution.IsRetry() {
+		for _, stepExecution := range execution.StepExecutions {
+			if stepExecution.Reused {
+				stepOutputs[stepExecution.StepID] = stepExecution.Output
+			}
+		}
+		// 复用的条件步骤按原结果重新选择分支，复用的步骤按原输出重新派生变量
+		for _, step := range steps {
+			if !reusedSteps[step.ID] {
+				continue
+			}
+			if step.Type == domain.StepTypeCondition {
+				skippedSteps = append(skippedSteps, s.skipConditionBranch(ctx, execution, steps, step, stepOutputs[step.ID])...)
+			}
+			derived, err := deriveStepVariables(&StepExecutionRequest{
+				Step:      step,
+				Execution: execution,
+				Input:     step.Input,
+				Context:   execution.Context,
+				Variables: variables,
+				Steps:     buildStepScope(steps, stepOutputs),
+			}, stepOutputs[step.ID])
+			if err != nil {
+				s.logger.Warn("Failed to derive variables from reused step",
+					zap.String("step_id", step.ID.String()),
+					zap.Error(err))
+				continue
+			}
+			variables = mergeVariables(variables, derived)
+		}
+	}
+	
+	// 执行上下文中只保留本次执行已完成步骤的输出
+	execution.Context = withStepOutputs(execution.Context, stepOutputs)
 	
 	for {
+		// 每批步骤启动前检查执行是否已取消或超时
+		if runCtx.Err() != nil {
+			s.stopExecution(ctx, runCtx, workflow, execution)
+			return
+		}
+		
+		// 所有依赖都被跳过的步骤同样跳过
+		skippedSteps = append(skippedSteps, s.propagateSkips(ctx, execution, steps)...)
+		
 		// 找到可执行的步骤
 		executableSteps := s.findExecutableSteps(steps, completedSteps)
 		if len(executableSteps) == 0 {
 			break // 没有可执行的步骤，结束执行
 		}
 		
-		// 并行执行可执行的步骤
+		// 并行执行可执行的步骤，同一批步骤看到的前序输出相同
 		stepResults := make(chan *stepExecutionResult, len(executableSteps))
+		stepScope := buildStepScope(steps, stepOutputs)
 		
 		for _, step := range executableSteps {
-			go s.executeStepAsync(ctx, execution, step, stepResults)
+			go s.executeStepAsync(ctx, runCtx, execution, step, variables, stepScope, stepResults)
 		}
 		
-		// 等待步骤执行完成
+		// 等待步骤执行完成，取消或超时后不再等待未响应取消的步骤
 		for i := 0; i < len(executableSteps); i++ {
-			result := <-stepResults
+			var result *stepExecutionResult
+			select {
+			case result = <-stepResults:
+			case <-runCtx.Done():
+				s.stopExecution(ctx, runCtx, workflow, execution)
+				return
+			}
+			
 			if result.Success {
 				completedSteps = append(completedSteps, result.StepID)
+				stepOutputs[result.StepID] = result.Output
+				if len(result.Variables) > 0 {
+					variables = mergeVariables(variables, result.Variables)
+				}
+				if step := findStep(steps, result.StepID); step != nil && step.Type == domain.StepTypeCondition {
+					skippedSteps = append(skippedSteps, s.skipConditionBranch(ctx, execution, steps, step, result.Output)...)
+				}
+			} else if runCtx.Err() != nil {
+				// 步骤因执行取消或超时被取消
+				s.stopExecution(ctx, runCtx, workflow, execution)
+				return
 			} else {
 				// 有步骤失败，整个工作流失败
 				execution.Fail(fmt.Sprintf("step %s failed: %s", result.StepID, result.Error))
-				s.executionRepo.Save(ctx, execution)
+				s.saveRunningExecution(ctx, execution)
 				return
 			}
 		}
+		
+		// 本批步骤都已结束，将输出写入执行上下文，后续步骤通过StepExecutionRequest.Context获取
+		execution.Context = withStepOutputs(execution.Context, stepOutputs)
+		s.saveRunningExecution(ctx, execution)
 	}
 	
-	// 检查是否所有步骤都执行完成
-	if len(completedSteps) == len(steps) {
+	// 检查是否所有步骤都执行完成或被跳过
+	if len(completedSteps)+len(skippedSteps) == len(steps) {
 		// 所有步骤完成，工作流成功
 		execution.Complete(map[string]interface{}{
 			"completed_steps": completedSteps,
+			"skipped_steps":   skippedSteps,
 			"total_steps":     len(steps),
+			"reused_steps":    len(reusedSteps),
+			"variables":       variables,
 		})
 		
 		// 记录工作流执行成功指标
@@ -210,19 +490,41 @@ func (s *OrchestratorService) executeWorkflowAsync(ctx context.Context, workflow
 		}
 	}
 	
-	s.executionRepo.Save(ctx, execution)
+	s.saveRunningExecution(ctx, execution)
+}
+
+// timeoutExecution 将超过最长执行时间的执行标记为超时
+func (s *OrchestratorService) timeoutExecution(ctx context.Context, workflow *domain.Workflow, execution *domain.Execution) {
+	s.logger.Warn("Workflow execution timed out",
+		zap.String("execution_id", execution.ID.String()),
+		zap.String("workflow_id", workflow.ID.String()),
+		zap.Duration("max_execution_time", workflow.MaxExecutionTime))
+	
+	if err := execution.Timeout(workflow.MaxExecutionTime); err != nil {
+		s.logger.Error("Failed to mark execution as timed out", zap.Error(err))
+		return
+	}
+	s.saveRunningExecution(ctx, execution)
+	
+	// 记录工作流执行超时指标
+	if s.metrics != nil {
+		s.metrics.RecordWorkflowExecution(workflow.ID.String(), "timeout", execution.Duration)
+	}
 }
 
 // stepExecutionResult 步骤执行结果
 type stepExecutionResult struct {
-	StepID  uuid.UUID
-	Success bool
-	Error   string
-	Output  map[string]interface{}
+	StepID    uuid.UUID
+	Success   bool
+	Error     string
+	Output    map[string]interface{}
+	Variables map[string]interface{} // 步骤派生的变量
 }
 
 // executeStepAsync 异步执行步骤
-func (s *OrchestratorService) executeStepAsync(ctx context.Context, execution *domain.Execution, step *domain.Step, result chan<- *stepExecutionResult) {
+// ctx用于持久化，runCtx传给步骤执行器，执行被取消或超时后被取消；
+// variables为工作流变量，stepScope为已完成步骤的输出，供条件表达式和输入中的引用使用
+func (s *OrchestratorService) executeStepAsync(ctx, runCtx context.Context, execution *domain.Execution, step *domain.Step, variables, stepScope map[string]interface{}, result chan<- *stepExecutionResult) {
 	defer func() {
 		if r := recover(); r != nil {
 			result <- &stepExecutionResult{
@@ -233,9 +535,19 @@ func (s *OrchestratorService) executeStepAsync(ctx context.Context, execution *d
 		}
 	}()
 	
+	// 同一批的步骤启动前执行已被取消或超时时不再启动
+	if runCtx.Err() != nil {
+		result <- &stepExecutionResult{
+			StepID:  step.ID,
+			Success: false,
+			Error:   context.Cause(runCtx).Error(),
+		}
+		return
+	}
+	
 	// 设置当前步骤
 	execution.SetCurrentStep(step.ID)
-	s.executionRepo.Save(ctx, execution)
+	s.saveRunningExecution(ctx, execution)
 	
 	// 开始执行步骤
 	if err := step.Start(); err != nil {
@@ -249,16 +561,47 @@ func (s *OrchestratorService) executeStepAsync(ctx context.Context, execution *d
 	}
 	s.stepRepo.Save(ctx, step)
 	
-	// 创建步骤执行记录
-	stepExecution := domain.NewStepExecution(execution.ID, step.ID, step.Input)
+	// 解析输入中对前序步骤输出和变量的引用，如${steps.fetch.output.id}
+	request := &StepExecutionRequest{
+		Step:      step,
+		Execution: execution,
+		Input:     step.Input,
+		Context:   execution.Context,
+		Variables: variables,
+		Steps:     stepScope,
+	}
+	input, resolveErr := ResolveStepInput(step.Input, expressionVariables(request))
+	if resolveErr == nil {
+		request.Input = input
+	}
+	
+	// 创建步骤执行记录，记录解析后的输入
+	stepExecution := domain.NewStepExecution(execution.ID, step.ID, request.Input)
+	stepExecution.Start()
 	execution.AddStepExecution(stepExecution)
 	s.stepExecutionRepo.Save(ctx, stepExecution)
 	
+	if resolveErr != nil {
+		message := fmt.Sprintf("failed to resolve step input: %v", resolveErr)
+		step.Fail(message)
+		s.stepRepo.Save(ctx, step)
+		stepExecution.Fail(message)
+		s.stepExecutionRepo.Save(ctx, stepExecution)
+		result <- &stepExecutionResult{
+			StepID:  step.ID,
+			Success: false,
+			Error:   message,
+		}
+		return
+	}
+	
 	// 获取步骤执行器
 	executor, exists := s.stepExecutors[step.Type]
 	if !exists {
 		step.Fail("no executor found for step type")
 		s.stepRepo.Save(ctx, step)
+		stepExecution.Fail("no executor found for step type")
+		s.stepExecutionRepo.Save(ctx, stepExecution)
 		result <- &stepExecutionResult{
 			StepID:  step.ID,
 			Success: false,
@@ -267,17 +610,30 @@ func (s *OrchestratorService) executeStepAsync(ctx context.Context, execution *d
 		return
 	}
 	
-	// 执行步骤
-	stepResult, err := executor.Execute(ctx, &StepExecutionRequest{
-		Step:      step,
-		Execution: execution,
-		Input:     step.Input,
-		Context:   execution.Context,
-	})
+	// 可缓存的步骤命中缓存时复用输出，否则执行步骤，超时或失败时按步骤的重试次数重试
+	stepResult, err := s.executeWithCache(ctx, runCtx, executor, step, stepExecution, request)
+	
+	// 按步骤输出计算派生变量，求值失败时步骤失败
+	var derived map[string]interface{}
+	if err == nil {
+		derived, err = deriveStepVariables(request, stepResult.Output)
+	}
 	
 	if err != nil {
-		step.Fail(err.Error())
+		if errors.Is(context.Cause(runCtx), ErrExecutionCancelled) {
+			// 执行被取消
+			step.Cancel()
+			stepExecution.Cancel(err.Error())
+		} else if runCtx.Err() != nil || errors.Is(err, ErrStepTimeout) {
+			// 步骤超时，或工作流执行超时导致步骤被取消
+			step.MarkTimeout()
+			stepExecution.Timeout(err.Error())
+		} else {
+			step.Fail(err.Error())
+			stepExecution.Fail(err.Error())
+		}
 		s.stepRepo.Save(ctx, step)
+		s.stepExecutionRepo.Save(ctx, stepExecution)
 		result <- &stepExecutionResult{
 			StepID:  step.ID,
 			Success: false,
@@ -289,20 +645,34 @@ func (s *OrchestratorService) executeStepAsync(ctx context.Context, execution *d
 	// 步骤执行成功
 	step.Complete(stepResult.Output)
 	s.stepRepo.Save(ctx, step)
+	stepExecution.Complete(stepResult.Output)
+	s.stepExecutionRepo.Save(ctx, stepExecution)
 	
 	result <- &stepExecutionResult{
-		StepID:  step.ID,
-		Success: true,
-		Output:  stepResult.Output,
+		StepID:    step.ID,
+		Success:   true,
+		Output:    stepResult.Output,
+		Variables: derived,
 	}
 }
 
 // findExecutableSteps 找到可执行的步骤
+// 已跳过的步骤与已完成的步骤一样满足依赖，使条件分支之后的汇合步骤可以继续执行
 func (s *OrchestratorService) findExecutableSteps(allSteps []*domain.Step, completedSteps []uuid.UUID) []*domain.Step {
 	var executableSteps []*domain.Step
 	
+	satisfiedSteps := append([]uuid.UUID{}, completedSteps...)
+	for _, step := range allSteps {
+		if step.Status == domain.StepStatusSkipped {
+			satisfiedSteps = append(satisfiedSteps, step.ID)
+		}
+	}
+	
 	for _, step := range allSteps {
-		if step.CanExecute(completedSteps) {
+		if containsStepID(satisfiedSteps, step.ID) {
+			continue
+		}
+		if step.CanExecute(satisfiedSteps) {
 			executableSteps = append(executableSteps, step)
 		}
 	}
@@ -310,6 +680,16 @@ func (s *OrchestratorService) findExecutableSteps(allSteps []*domain.Step, compl
 	return executableSteps
 }
 
+// containsStepID 检查步骤ID是否在列表中
+func containsStepID(stepIDs []uuid.UUID, stepID uuid.UUID) bool {
+	for _, id := range stepIDs {
+		if id == stepID {
+			return true
+		}
+	}
+	return false
+}
+
 // AddStep 添加步骤
 func (s *OrchestratorService) AddStep(ctx context.Context, cmd *AddStepCommand) (*application.Result, error) {
 	if err := cmd.Validate(); err != nil {
@@ -331,6 +711,16 @@ func (s *OrchestratorService) AddStep(ctx context.Context, cmd *AddStepCommand)
 	step.MaxRetries = cmd.MaxRetries
 	step.Dependencies = cmd.Dependencies
 	
+	// 新步骤的依赖必须存在且不构成环
+	existingSteps, err := s.stepRepo.FindByWorkflowID(ctx, workflow.ID)
+	if err != nil {
+		s.logger.Error("Failed to get workflow steps", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to get workflow steps"}, err
+	}
+	if err := ValidateDAG(append(existingSteps, step)); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
 	// 添加到工作流
 	if err := workflow.AddStep(step); err != nil {
 		return &application.Result{Success: false, Error: err.Error()}, err
@@ -365,7 +755,105 @@ func (s *OrchestratorService) AddStep(ctx context.Context, cmd *AddStepCommand)
 	return &application.Result{Success: true, Data: step}, nil
 }
 
+// AddTrigger 添加触发器
+// 定时触发器会计算下次运行时间，保存后通知调度器注册
+func (s *OrchestratorService) AddTrigger(ctx context.Context, cmd *AddTriggerCommand) (*application.Result, error) {
+	if err := cmd.Validate(); err != nil {
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+	
+	// 获取工作流
+	workflow, err := s.workflowRepo.FindByID(ctx, cmd.WorkflowID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "workflow not found"}, err
+	}
+	
+	// 创建触发器
+	trigger := domain.NewTrigger(workflow.ID, cmd.Type, cmd.Name)
+	trigger.Description = cmd.Description
+	if cmd.Config != nil {
+		trigger.Config = cmd.Config
+	}
+	for _, condition := range cmd.Conditions {
+		trigger.Conditions = append(trigger.Conditions, domain.TriggerCondition{
+			Field:    condition.Field,
+			Operator: condition.Operator,
+			Value:    condition.Value,
+		})
+	}
+	
+	if cmd.Type == domain.TriggerTypeSchedule {
+		schedule, err := ParseTriggerSchedule(cmd.Schedule, cmd.Timezone)
+		if err != nil {
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+		trigger.Schedule = cmd.Schedule
+		trigger.Timezone = cmd.Timezone
+		if next := schedule.Next(time.Now()); !next.IsZero() {
+			trigger.SetNextRun(&next)
+		}
+	}
+	
+	// Webhook触发器需要签名密钥，未指定时生成
+	if cmd.Type == domain.TriggerTypeWebhook {
+		trigger.Secret = cmd.Secret
+		if trigger.Secret == "" {
+			secret, err := domain.GenerateWebhookSecret()
+			if err != nil {
+				return &application.Result{Success: false, Error: "failed to generate webhook secret"}, err
+			}
+			trigger.Secret = secret
+		}
+	}
+	
+	// 保存触发器
+	if err := s.triggerRepo.Save(ctx, trigger); err != nil {
+		s.logger.Error("Failed to save trigger", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to save trigger"}, err
+	}
+	
+	// 发布事件
+	for _, event := range trigger.GetDomainEvents() {
+		if err := s.eventBus.Publish(ctx, event); err != nil {
+			s.logger.Warn("Failed to publish event", zap.Error(err))
+		}
+	}
+	trigger.ClearDomainEvents()
+	
+	for _, observer := range s.triggerObservers {
+		observer.OnTriggerSaved(trigger)
+	}
+	
+	return &application.Result{Success: true, Data: trigger}, nil
+}
+
+// RemoveTrigger 删除触发器，删除后通知调度器取消调度
+func (s *OrchestratorService) RemoveTrigger(ctx context.Context, triggerID uuid.UUID) (*application.Result, error) {
+	trigger, err := s.triggerRepo.FindByID(ctx, triggerID)
+	if err != nil {
+		return &application.Result{Success: false, Error: "trigger not found"}, err
+	}
+	
+	if err := s.triggerRepo.Delete(ctx, trigger.ID); err != nil {
+		s.logger.Error("Failed to delete trigger", zap.Error(err))
+		return &application.Result{Success: false, Error: "failed to delete trigger"}, err
+	}
+	
+	for _, observer := range s.triggerObservers {
+		observer.OnTriggerRemoved(trigger.ID)
+	}
+	
+	return &application.Result{Success: true, Data: trigger}, nil
+}
+
+// TriggerObserver 触发器变更观察者接口
+type TriggerObserver interface {
+	OnTriggerSaved(trigger *domain.Trigger)
+	OnTriggerRemoved(triggerID uuid.UUID)
+}
+
 // StepExecutor 步骤执行器接口
+// Execute收到的ctx在步骤超时或工作流执行超时后被取消，实现需要响应取消并尽快返回
 type StepExecutor interface {
 	Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error)
 	GetSupportedType() domain.StepType
@@ -375,8 +863,10 @@ type StepExecutor interface {
 type StepExecutionRequest struct {
 	Step      *domain.Step
 	Execution *domain.Execution
-	Input     map[string]interface{}
-	Context   map[string]interface{}
+	Input     map[string]interface{} // 解析引用后的步骤输入
+	Context   map[string]interface{} // 执行上下文，ContextStepOutputs下为已完成步骤的输出
+	Variables map[string]interface{} // 工作流变量
+	Steps     map[string]interface{} // 已完成步骤的输出，按步骤名称和ID索引，值为{"output": ...}
 }
 
 // StepExecutionResult 步骤执行结果
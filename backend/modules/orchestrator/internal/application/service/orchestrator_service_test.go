@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	shareddomain "github.com/noah-loop/backend/shared/pkg/domain"
+	"go.uber.org/zap"
+)
+
+// errRecordNotFound 内存仓储中记录不存在
+var errRecordNotFound = errors.New("record not found")
+
+// memStore 按ID保存实体的内存存储
+type memStore[T any] struct {
+	id    func(T) uuid.UUID
+	mu    sync.Mutex
+	items map[uuid.UUID]T
+}
+
+func newMemStore[T any](id func(T) uuid.UUID) *memStore[T] {
+	return &memStore[T]{id: id, items: make(map[uuid.UUID]T)}
+}
+
+func (s *memStore[T]) Save(ctx context.Context, entity T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[s.id(entity)] = entity
+	return nil
+}
+
+func (s *memStore[T]) FindByID(ctx context.Context, id uuid.UUID) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entity, ok := s.items[id]
+	if !ok {
+		var zero T
+		return zero, errRecordNotFound
+	}
+	return entity, nil
+}
+
+func (s *memStore[T]) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+	return nil
+}
+
+// filter 返回满足条件的实体
+func (s *memStore[T]) filter(match func(T) bool) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entities []T
+	for _, entity := range s.items {
+		if match(entity) {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// memWorkflowRepository 内存工作流仓储
+type memWorkflowRepository struct {
+	domain.WorkflowRepository
+	*memStore[*domain.Workflow]
+}
+
+func (r memWorkflowRepository) Save(ctx context.Context, workflow *domain.Workflow) error {
+	return r.memStore.Save(ctx, workflow)
+}
+
+func (r memWorkflowRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Workflow, error) {
+	return r.memStore.FindByID(ctx, id)
+}
+
+func (r memWorkflowRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.memStore.Delete(ctx, id)
+}
+
+// memStepRepository 内存步骤仓储，保存和读取步骤的副本，执行中的步骤状态只能通过仓储读取
+type memStepRepository struct {
+	domain.StepRepository
+	*memStore[*domain.Step]
+}
+
+func (r memStepRepository) Save(ctx context.Context, step *domain.Step) error {
+	copied := *step
+	return r.memStore.Save(ctx, &copied)
+}
+
+func (r memStepRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Step, error) {
+	step, err := r.memStore.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *step
+	return &copied, nil
+}
+
+func (r memStepRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.memStore.Delete(ctx, id)
+}
+
+func (r memStepRepository) FindByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.Step, error) {
+	steps := r.filter(func(step *domain.Step) bool { return step.WorkflowID == workflowID })
+	for i, step := range steps {
+		copied := *step
+		steps[i] = &copied
+	}
+	return steps, nil
+}
+
+// memExecutionRepository 内存执行仓储，保存和读取执行的副本，模拟数据库中的记录
+type memExecutionRepository struct {
+	domain.ExecutionRepository
+	*memStore[*domain.Execution]
+}
+
+func (r memExecutionRepository) Save(ctx context.Context, execution *domain.Execution) error {
+	copied := *execution
+	return r.memStore.Save(ctx, &copied)
+}
+
+func (r memExecutionRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Execution, error) {
+	execution, err := r.memStore.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *execution
+	return &copied, nil
+}
+
+func (r memExecutionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.memStore.Delete(ctx, id)
+}
+
+// saveIf 当前记录满足条件或不存在时保存
+func (r memExecutionRepository) saveIf(execution *domain.Execution, allowed func(current *domain.Execution) bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, exists := r.items[execution.ID]; exists && !allowed(current) {
+		return false, nil
+	}
+	copied := *execution
+	r.items[execution.ID] = &copied
+	return true, nil
+}
+
+func (r memExecutionRepository) SaveUnlessCancelled(ctx context.Context, execution *domain.Execution) (bool, error) {
+	return r.saveIf(execution, func(current *domain.Execution) bool {
+		return current.Status != domain.ExecutionStatusCancelled
+	})
+}
+
+func (r memExecutionRepository) SaveIfActive(ctx context.Context, execution *domain.Execution) (bool, error) {
+	return r.saveIf(execution, func(current *domain.Execution) bool {
+		return current.Status == domain.ExecutionStatusPending || current.Status == domain.ExecutionStatusRunning
+	})
+}
+
+// memStepExecutionRepository 内存步骤执行仓储
+type memStepExecutionRepository struct {
+	domain.StepExecutionRepository
+	*memStore[*domain.StepExecution]
+}
+
+func (r memStepExecutionRepository) Save(ctx context.Context, stepExecution *domain.StepExecution) error {
+	return r.memStore.Save(ctx, stepExecution)
+}
+
+func (r memStepExecutionRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.StepExecution, error) {
+	return r.memStore.FindByID(ctx, id)
+}
+
+func (r memStepExecutionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.memStore.Delete(ctx, id)
+}
+
+func (r memStepExecutionRepository) FindByExecutionID(ctx context.Context, executionID uuid.UUID) ([]*domain.StepExecution, error) {
+	return r.filter(func(stepExecution *domain.StepExecution) bool { return stepExecution.ExecutionID == executionID }), nil
+}
+
+// nopEventBus 丢弃所有事件
+type nopEventBus struct{}
+
+func (nopEventBus) Publish(ctx context.Context, event shareddomain.DomainEvent) error { return nil }
+
+// echoStepExecutor 以步骤名作为输出的动作执行器
+type echoStepExecutor struct{}
+
+func (echoStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	return &StepExecutionResult{Output: map[string]interface{}{"step": request.Step.Name}}, nil
+}
+
+func (echoStepExecutor) GetSupportedType() domain.StepType { return domain.StepTypeAction }
+
+// orchestratorTestEnv 使用内存仓储的编排服务
+type orchestratorTestEnv struct {
+	service        *OrchestratorService
+	workflows      memWorkflowRepository
+	steps          memStepRepository
+	executions     memExecutionRepository
+	stepExecutions memStepExecutionRepository
+}
+
+func newOrchestratorTestEnv() *orchestratorTestEnv {
+	env := &orchestratorTestEnv{
+		workflows:      memWorkflowRepository{memStore: newMemStore(func(w *domain.Workflow) uuid.UUID { return w.ID })},
+		steps:          memStepRepository{memStore: newMemStore(func(s *domain.Step) uuid.UUID { return s.ID })},
+		executions:     memExecutionRepository{memStore: newMemStore(func(e *domain.Execution) uuid.UUID { return e.ID })},
+		stepExecutions: memStepExecutionRepository{memStore: newMemStore(func(e *domain.StepExecution) uuid.UUID { return e.ID })},
+	}
+	env.service = NewOrchestratorService(env.workflows, env.steps, nil, env.executions, env.stepExecutions, nopEventBus{}, zap.NewNop(), nil)
+	env.service.RegisterStepExecutor(domain.StepTypeAction, echoStepExecutor{})
+	return env
+}
+
+// addWorkflow 保存一个已激活的工作流
+func (env *orchestratorTestEnv) addWorkflow(t *testing.T) *domain.Workflow {
+	t.Helper()
+	workflow := domain.NewWorkflow("workflow", "", uuid.New())
+	workflow.Status = domain.WorkflowStatusActive
+	if err := env.workflows.Save(context.Background(), workflow); err != nil {
+		t.Fatal(err)
+	}
+	return workflow
+}
+
+// addStep 保存工作流的步骤，依赖dependencies中的步骤；仓储保存的是副本，修改返回的步骤后需要用saveStep重新保存
+func (env *orchestratorTestEnv) addStep(t *testing.T, workflow *domain.Workflow, name string, stepType domain.StepType, dependencies ...*domain.Step) *domain.Step {
+	t.Helper()
+	step := domain.NewStep(workflow.ID, name, stepType, len(env.steps.filter(func(s *domain.Step) bool { return s.WorkflowID == workflow.ID }))+1)
+	for _, dependency := range dependencies {
+		step.Dependencies = append(step.Dependencies, dependency.ID)
+	}
+	env.saveStep(t, step)
+	return step
+}
+
+// saveStep 保存步骤
+func (env *orchestratorTestEnv) saveStep(t *testing.T, step *domain.Step) {
+	t.Helper()
+	if err := env.steps.Save(context.Background(), step); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForStepStatus 等待仓储中的步骤进入指定状态
+func (env *orchestratorTestEnv) waitForStepStatus(t *testing.T, stepID uuid.UUID, status domain.StepStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		step, err := env.steps.FindByID(context.Background(), stepID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.Status == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("step %s is %s, want %s", step.Name, step.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// stepStatus 返回仓储中步骤的状态
+func (env *orchestratorTestEnv) stepStatus(t *testing.T, stepID uuid.UUID) domain.StepStatus {
+	t.Helper()
+	step, err := env.steps.FindByID(context.Background(), stepID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return step.Status
+}
+
+// execute 启动工作流，返回执行
+func (env *orchestratorTestEnv) execute(t *testing.T, cmd *ExecuteWorkflowCommand) *domain.Execution {
+	t.Helper()
+	result, err := env.service.ExecuteWorkflow(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Data.(*domain.Execution)
+}
+
+// waitForExecution 等待执行结束，返回最终状态的执行
+func (env *orchestratorTestEnv) waitForExecution(t *testing.T, executionID uuid.UUID) *domain.Execution {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		execution, err := env.executions.FindByID(context.Background(), executionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if execution.Status != domain.ExecutionStatusPending && execution.Status != domain.ExecutionStatusRunning {
+			return execution
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("execution still %s", execution.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// stepExecutionOf 返回执行中步骤的执行记录
+func (env *orchestratorTestEnv) stepExecutionOf(t *testing.T, executionID, stepID uuid.UUID) *domain.StepExecution {
+	t.Helper()
+	stepExecutions := env.stepExecutions.filter(func(e *domain.StepExecution) bool {
+		return e.ExecutionID == executionID && e.StepID == stepID
+	})
+	if len(stepExecutions) != 1 {
+		t.Fatalf("found %d step executions for step %s", len(stepExecutions), stepID)
+	}
+	return stepExecutions[0]
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/cache"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+const (
+	// StepConfigCache 步骤配置中的缓存开关，值为true时步骤输出按配置和输入缓存
+	// 只应对确定性的步骤开启：相同的配置和输入总是产生相同的输出，且没有需要重复执行的副作用
+	StepConfigCache = "cache"
+	// StepConfigCacheTTL 步骤配置中的缓存过期时间键，值为时长字符串，如"1h"，未配置时使用全局默认值
+	StepConfigCacheTTL = "cache_ttl"
+)
+
+// stepCacheIgnoredConfig 不影响步骤输出的配置键，不参与缓存键的计算
+var stepCacheIgnoredConfig = map[string]bool{
+	StepConfigCache:        true,
+	StepConfigCacheTTL:     true,
+	StepConfigRetryBackoff: true,
+	StepConfigSetVariables: true,
+}
+
+// StepCache 步骤输出缓存，相同配置和输入的可缓存步骤跨执行复用输出
+// 缓存读写失败只影响命中率，不影响步骤执行
+type StepCache interface {
+	// Get 获取缓存的步骤输出
+	Get(ctx context.Context, key string) (map[string]interface{}, bool)
+
+	// Set 缓存步骤输出，ttl小于等于0时使用默认过期时间
+	Set(ctx context.Context, key string, output map[string]interface{}, ttl time.Duration)
+}
+
+// StepCacheConfig 步骤缓存配置
+type StepCacheConfig struct {
+	Enabled bool
+	TTL     time.Duration // 默认缓存过期时间，0表示使用缓存后端的默认值
+}
+
+// DefaultStepCacheConfig 默认步骤缓存配置：启用，缓存24小时
+func DefaultStepCacheConfig() StepCacheConfig {
+	return StepCacheConfig{
+		Enabled: true,
+		TTL:     24 * time.Hour,
+	}
+}
+
+// StepCacheKey 生成步骤缓存键：步骤类型 + 配置（去除不影响输出的键）+ 解析后输入的sha256
+// 键不包含工作流和步骤ID，不同工作流中配置和输入相同的步骤也复用输出
+func StepCacheKey(step *domain.Step, input map[string]interface{}) (string, error) {
+	config := make(map[string]interface{}, len(step.Config))
+	for key, value := range step.Config {
+		if !stepCacheIgnoredConfig[key] {
+			config[key] = value
+		}
+	}
+
+	// json.Marshal按键排序输出map，相同内容的配置和输入得到相同的编码
+	data, err := json.Marshal(map[string]interface{}{
+		"type":   step.Type,
+		"config": config,
+		"input":  input,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode step cache key: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return "step:" + hex.EncodeToString(sum[:]), nil
+}
+
+// stepCacheable 步骤是否开启了输出缓存
+func stepCacheable(step *domain.Step) bool {
+	cacheable, _ := step.Config[StepConfigCache].(bool)
+	return cacheable
+}
+
+// stepCacheTTL 读取步骤配置的缓存过期时间，未配置或无效时返回0，使用全局默认值
+func stepCacheTTL(step *domain.Step) time.Duration {
+	if value, ok := step.Config[StepConfigCacheTTL].(string); ok {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+			return ttl
+		}
+	}
+	return 0
+}
+
+// validateStepCache 检查步骤的缓存配置
+func validateStepCache(config map[string]interface{}) error {
+	switch config[StepConfigCache].(type) {
+	case nil, bool:
+	default:
+		return fmt.Errorf("%s must be a boolean", StepConfigCache)
+	}
+
+	switch value := config[StepConfigCacheTTL].(type) {
+	case nil:
+	case string:
+		if ttl, err := time.ParseDuration(value); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid %s %q", StepConfigCacheTTL, value)
+		}
+	default:
+		return fmt.Errorf("%s must be a duration string", StepConfigCacheTTL)
+	}
+	return nil
+}
+
+// executeWithCache 执行步骤，可缓存的步骤先按配置和解析后的输入查找缓存
+// 命中时不调用执行器，直接复用缓存的输出并标记步骤执行的CacheHit；未命中时执行成功后写入缓存
+func (s *OrchestratorService) executeWithCache(ctx, runCtx context.Context, executor StepExecutor, step *domain.Step, stepExecution *domain.StepExecution, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	if !stepCacheable(step) {
+		return s.executeWithRetry(ctx, runCtx, executor, step, stepExecution, request)
+	}
+
+	key, err := StepCacheKey(step, request.Input)
+	if err != nil {
+		s.logger.Warn("Step is not cacheable", zap.String("step_id", step.ID.String()), zap.Error(err))
+		return s.executeWithRetry(ctx, runCtx, executor, step, stepExecution, request)
+	}
+
+	if output, hit := s.stepCache.Get(ctx, key); hit {
+		s.logger.Info("Reusing cached step output",
+			zap.String("execution_id", stepExecution.ExecutionID.String()),
+			zap.String("step_id", step.ID.String()))
+		stepExecution.CacheHit = true
+		return &StepExecutionResult{Output: output}, nil
+	}
+
+	result, err := s.executeWithRetry(ctx, runCtx, executor, step, stepExecution, request)
+	if err == nil {
+		s.stepCache.Set(ctx, key, result.Output, stepCacheTTL(step))
+	}
+	return result, err
+}
+
+// sharedStepCache 基于共享缓存（进程内LRU或Redis）的步骤缓存，输出以JSON保存
+type sharedStepCache struct {
+	cache  cache.Cache
+	ttl    time.Duration
+	logger infrastructure.Logger
+}
+
+// NewStepCache 创建步骤缓存，c为nil时返回不缓存的实现
+func NewStepCache(c cache.Cache, config StepCacheConfig, logger infrastructure.Logger) StepCache {
+	if c == nil || !config.Enabled {
+		return noopStepCache{}
+	}
+	return &sharedStepCache{
+		cache:  c,
+		ttl:    config.TTL,
+		logger: logger,
+	}
+}
+
+// Get 获取缓存的步骤输出
+func (c *sharedStepCache) Get(ctx context.Context, key string) (map[string]interface{}, bool) {
+	data, err := c.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrNotFound) {
+			c.logger.Warn("Failed to read step cache", zap.String("key", key), zap.Error(err))
+		}
+		return nil, false
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		c.logger.Warn("Discarding corrupted step cache entry", zap.String("key", key), zap.Error(err))
+		return nil, false
+	}
+	return output, true
+}
+
+// Set 缓存步骤输出，无法编码为JSON的输出不缓存
+func (c *sharedStepCache) Set(ctx context.Context, key string, output map[string]interface{}, ttl time.Duration) {
+	data, err := json.Marshal(output)
+	if err != nil {
+		c.logger.Warn("Step output is not cacheable", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	if err := c.cache.Set(ctx, key, data, ttl); err != nil {
+		c.logger.Warn("Failed to write step cache", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// noopStepCache 不缓存的实现，用于禁用步骤缓存
+type noopStepCache struct{}
+
+func (noopStepCache) Get(ctx context.Context, key string) (map[string]interface{}, bool) {
+	return nil, false
+}
+
+func (noopStepCache) Set(ctx context.Context, key string, output map[string]interface{}, ttl time.Duration) {
+}
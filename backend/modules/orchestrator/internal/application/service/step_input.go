@@ -0,0 +1,195 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ContextStepOutputs 执行上下文中保存已完成步骤输出的键，值为 步骤ID -> {"output": 输出}
+const ContextStepOutputs = "steps"
+
+// stepInputReference 一处输入引用${表达式}在字符串中的位置
+type stepInputReference struct {
+	start, end int    // ${ 的起始位置和 } 之后的位置
+	expression string // ${}中的表达式
+}
+
+// findStepInputReferences 查找字符串中的${表达式}引用，$${ 转义为字面的 ${
+// 表达式中引号内的 } 不结束引用
+func findStepInputReferences(text string) ([]stepInputReference, error) {
+	var references []stepInputReference
+	for i := 0; i < len(text); i++ {
+		if strings.HasPrefix(text[i:], "$${") {
+			i += 2
+			continue
+		}
+		if !strings.HasPrefix(text[i:], "${") {
+			continue
+		}
+
+		end := -1
+		var quote byte
+		for j := i + 2; j < len(text) && end < 0; j++ {
+			switch c := text[j]; {
+			case quote != 0:
+				if c == '\\' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '}':
+				end = j
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated reference at position %d", i)
+		}
+		expression := strings.TrimSpace(text[i+2 : end])
+		if expression == "" {
+			return nil, fmt.Errorf("empty reference at position %d", i)
+		}
+		references = append(references, stepInputReference{start: i, end: end + 1, expression: expression})
+		i = end
+	}
+	return references, nil
+}
+
+// ResolveStepInput 解析步骤输入中的引用，返回解析后的副本，不修改原输入
+// 字符串中的${表达式}按表达式求值，如${steps.fetch.output.user.id}、${vars.region}、${input.order_id}；
+// 整个字符串只有一处引用时保留求值结果的类型，否则将结果格式化后拼接到字符串中，对象和列表格式化为JSON；
+// 不存在的路径求值为null，拼接时为空字符串
+func ResolveStepInput(input map[string]interface{}, scope map[string]interface{}) (map[string]interface{}, error) {
+	if input == nil {
+		return nil, nil
+	}
+	resolved, err := resolveStepInputValue(input, scope)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+// resolveStepInputValue 递归解析对象、列表和字符串中的引用
+func resolveStepInputValue(value interface{}, scope map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			item, err := resolveStepInputValue(item, scope)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			resolved[key] = item
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := resolveStepInputValue(item, scope)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	case string:
+		return resolveStepInputString(v, scope)
+	default:
+		return value, nil
+	}
+}
+
+// resolveStepInputString 解析字符串中的引用
+func resolveStepInputString(text string, scope map[string]interface{}) (interface{}, error) {
+	references, err := findStepInputReferences(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(references) == 0 {
+		return strings.ReplaceAll(text, "$${", "${"), nil
+	}
+
+	// 整个字符串就是一处引用时保留值的类型
+	if len(references) == 1 && references[0].start == 0 && references[0].end == len(text) {
+		value, err := EvaluateExpression(references[0].expression, scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve ${%s}: %w", references[0].expression, err)
+		}
+		return value, nil
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, reference := range references {
+		value, err := EvaluateExpression(reference.expression, scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve ${%s}: %w", reference.expression, err)
+		}
+		builder.WriteString(strings.ReplaceAll(text[last:reference.start], "$${", "${"))
+		builder.WriteString(formatInputValue(value))
+		last = reference.end
+	}
+	builder.WriteString(strings.ReplaceAll(text[last:], "$${", "${"))
+	return builder.String(), nil
+}
+
+// formatInputValue 将引用的值拼接到字符串中，对象和列表格式化为JSON
+func formatInputValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return formatValue(value)
+}
+
+// validateStepInput 检查步骤输入中引用的语法，不对引用求值
+func validateStepInput(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if err := validateStepInput(item); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := validateStepInput(item); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case string:
+		references, err := findStepInputReferences(v)
+		if err != nil {
+			return err
+		}
+		for _, reference := range references {
+			if err := ValidateExpression(reference.expression); err != nil {
+				return fmt.Errorf("invalid reference ${%s}: %w", reference.expression, err)
+			}
+		}
+	}
+	return nil
+}
+
+// withStepOutputs 复制执行上下文并写入已完成步骤的输出，按步骤ID索引，不修改原上下文
+// 执行中的步骤持有的上下文不受影响
+func withStepOutputs(context map[string]interface{}, outputs map[uuid.UUID]map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(context)+1)
+	for key, value := range context {
+		merged[key] = value
+	}
+	steps := make(map[string]interface{}, len(outputs))
+	for stepID, output := range outputs {
+		steps[stepID.String()] = map[string]interface{}{"output": output}
+	}
+	merged[ContextStepOutputs] = steps
+	return merged
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+)
+
+// requestRecordingExecutor 按步骤名记录执行请求，stepA输出固定的结果，其他步骤输出解析后的value
+type requestRecordingExecutor struct {
+	mu       sync.Mutex
+	requests map[string]*StepExecutionRequest
+}
+
+func (e *requestRecordingExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	e.mu.Lock()
+	e.requests[request.Step.Name] = request
+	e.mu.Unlock()
+	if request.Step.Name == "stepA" {
+		return &StepExecutionResult{Output: map[string]interface{}{
+			"foo":  float64(42),
+			"user": map[string]interface{}{"name": "ann"},
+		}}, nil
+	}
+	return &StepExecutionResult{Output: map[string]interface{}{"got": request.Input["value"]}}, nil
+}
+
+func (e *requestRecordingExecutor) GetSupportedType() domain.StepType { return domain.StepTypeAction }
+
+func TestDependentStepReadsUpstreamOutput(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	recorder := &requestRecordingExecutor{requests: map[string]*StepExecutionRequest{}}
+	env.service.RegisterStepExecutor(domain.StepTypeAction, recorder)
+
+	workflow := env.addWorkflow(t)
+	stepA := env.addStep(t, workflow, "stepA", domain.StepTypeAction)
+	stepB := env.addStep(t, workflow, "stepB", domain.StepTypeAction, stepA)
+	stepB.Input = map[string]interface{}{
+		"value":  "${steps.stepA.output.foo}",
+		"text":   "hi ${steps.stepA.output.user.name}, n=${steps.stepA.output.foo + 1} lit=$${x}",
+		"user":   "${steps.stepA.output.user}",
+		"nested": []interface{}{"${input.k}", map[string]interface{}{"q": "${steps['stepA'].output.foo}"}},
+	}
+	env.saveStep(t, stepB)
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = workflow.ID
+	cmd.Input = map[string]interface{}{"k": "v"}
+	execution := env.waitForExecution(t, env.execute(t, cmd).ID)
+	if execution.Status != domain.ExecutionStatusCompleted {
+		t.Fatalf("execution status = %s: %s", execution.Status, execution.ErrorMessage)
+	}
+
+	input := recorder.requests["stepB"].Input
+	if input["value"] != float64(42) {
+		t.Fatalf("value = %#v", input["value"])
+	}
+	if input["text"] != "hi ann, n=43 lit=${x}" {
+		t.Fatalf("text = %q", input["text"])
+	}
+	if user, ok := input["user"].(map[string]interface{}); !ok || user["name"] != "ann" {
+		t.Fatalf("user = %#v", input["user"])
+	}
+	nested := input["nested"].([]interface{})
+	if nested[0] != "v" || nested[1].(map[string]interface{})["q"] != float64(42) {
+		t.Fatalf("nested = %#v", nested)
+	}
+
+	// 下游步骤的上下文中带有上游步骤的输出，上游步骤看不到自己的输出
+	outputs := recorder.requests["stepB"].Context[ContextStepOutputs].(map[string]interface{})
+	if outputs[stepA.ID.String()].(map[string]interface{})["output"].(map[string]interface{})["foo"] != float64(42) {
+		t.Fatalf("stepB context = %#v", recorder.requests["stepB"].Context)
+	}
+	if outputs, ok := recorder.requests["stepA"].Context[ContextStepOutputs].(map[string]interface{}); ok && outputs[stepA.ID.String()] != nil {
+		t.Fatal("stepA should not see its own output")
+	}
+
+	// 解析不修改步骤定义
+	stored, _ := env.steps.FindByID(context.Background(), stepB.ID)
+	if stored.Input["value"] != "${steps.stepA.output.foo}" {
+		t.Fatalf("step input was modified: %#v", stored.Input)
+	}
+	if outputs := execution.Context[ContextStepOutputs].(map[string]interface{}); len(outputs) != 2 {
+		t.Fatalf("execution context outputs = %#v", outputs)
+	}
+}
+
+func TestStepInputResolveErrorFailsExecution(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	workflow := env.addWorkflow(t)
+	step := env.addStep(t, workflow, "stepA", domain.StepTypeAction)
+	step.Input = map[string]interface{}{"v": "${len(1, 2, 3)}"}
+	env.saveStep(t, step)
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = workflow.ID
+	if execution := env.waitForExecution(t, env.execute(t, cmd).ID); execution.Status != domain.ExecutionStatusFailed {
+		t.Fatalf("execution status = %s", execution.Status)
+	}
+}
+
+func TestAddStepCommandValidatesInputReferences(t *testing.T) {
+	cmd := NewAddStepCommand()
+	cmd.WorkflowID = uuid.New()
+	cmd.Name = "step"
+	cmd.Type = domain.StepTypeAction
+	cmd.Input = map[string]interface{}{"a": "${steps.stepA.output.foo}", "b": []interface{}{"plain $${x}"}}
+	if err := cmd.Validate(); err != nil {
+		t.Fatalf("valid references rejected: %v", err)
+	}
+
+	for _, value := range []string{"${steps.}", "${oops"} {
+		cmd.Input = map[string]interface{}{"a": value}
+		if err := cmd.Validate(); err == nil {
+			t.Fatalf("invalid reference %q accepted", value)
+		}
+	}
+}
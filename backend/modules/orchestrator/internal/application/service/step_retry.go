@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// StepConfigRetryBackoff 步骤配置中的首次重试间隔键，值为时长字符串，如"500ms"
+	StepConfigRetryBackoff = "retry_backoff"
+	// DefaultStepRetryBackoff 默认首次重试间隔，之后每次翻倍
+	DefaultStepRetryBackoff = time.Second
+	// MaxStepRetryBackoff 重试间隔上限
+	MaxStepRetryBackoff = 30 * time.Second
+)
+
+// ErrStepTimeout 单次尝试超过步骤的超时时间
+var ErrStepTimeout = errors.New("step timed out")
+
+// executeWithRetry 按步骤的超时时间和最大重试次数调用执行器，每次尝试都记录到步骤执行
+// 尝试失败或超时后按退避间隔重试，最多重试MaxRetries次；runCtx结束（工作流超时）后不再重试
+func (s *OrchestratorService) executeWithRetry(ctx, runCtx context.Context, executor StepExecutor, step *domain.Step, stepExecution *domain.StepExecution, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	backoff := stepRetryBackoff(step)
+
+	for attempt := 1; ; attempt++ {
+		startedAt := time.Now()
+		result, err := executeAttempt(runCtx, executor, step.Timeout, request)
+
+		record := domain.StepAttempt{
+			Attempt:   attempt,
+			StartedAt: startedAt,
+			Duration:  time.Since(startedAt),
+		}
+		if err != nil {
+			record.Error = err.Error()
+			record.TimedOut = errors.Is(err, ErrStepTimeout)
+		}
+		stepExecution.RecordAttempt(record)
+		s.stepExecutionRepo.Save(ctx, stepExecution)
+
+		if err == nil || runCtx.Err() != nil || attempt > step.MaxRetries {
+			return result, err
+		}
+
+		s.logger.Warn("Step attempt failed, retrying",
+			zap.String("execution_id", stepExecution.ExecutionID.String()),
+			zap.String("step_id", step.ID.String()),
+			zap.Int("attempt", attempt),
+			zap.Int("max_retries", step.MaxRetries),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		select {
+		case <-runCtx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > MaxStepRetryBackoff {
+			backoff = MaxStepRetryBackoff
+		}
+	}
+}
+
+// executeAttempt 在步骤超时时间内调用一次执行器，timeout为0时只受runCtx限制
+// 执行器在独立的goroutine中运行，超时后立即返回，不等待未响应取消的执行器；
+// 结果通道带缓冲，执行器最终返回时goroutine随即退出
+func executeAttempt(runCtx context.Context, executor StepExecutor, timeout time.Duration, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	attemptCtx, cancel := context.WithCancel(runCtx)
+	if timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(runCtx, timeout)
+	}
+	defer cancel()
+
+	type attemptOutcome struct {
+		result *StepExecutionResult
+		err    error
+	}
+	done := make(chan attemptOutcome, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- attemptOutcome{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		result, err := executor.Execute(attemptCtx, request)
+		if err == nil && result == nil {
+			err = errors.New("executor returned no result")
+		}
+		done <- attemptOutcome{result: result, err: err}
+	}()
+
+	select {
+	case outcome := <-done:
+		if outcome.err != nil && runCtx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s: %v", ErrStepTimeout, timeout, outcome.err)
+		}
+		return outcome.result, outcome.err
+	case <-attemptCtx.Done():
+		if err := runCtx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w after %s", ErrStepTimeout, timeout)
+	}
+}
+
+// stepRetryBackoff 读取步骤配置的首次重试间隔，未配置或无效时使用默认值
+func stepRetryBackoff(step *domain.Step) time.Duration {
+	if value, ok := step.Config[StepConfigRetryBackoff].(string); ok {
+		if backoff, err := time.ParseDuration(value); err == nil && backoff > 0 {
+			return backoff
+		}
+	}
+	return DefaultStepRetryBackoff
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+)
+
+// flakyStepExecutor 前failures次调用失败，之后成功
+type flakyStepExecutor struct {
+	calls    int32
+	failures int32
+}
+
+func (e *flakyStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	call := atomic.AddInt32(&e.calls, 1)
+	if call <= e.failures {
+		return nil, errors.New("temporary failure")
+	}
+	return &StepExecutionResult{Output: map[string]interface{}{"call": call}}, nil
+}
+
+func (e *flakyStepExecutor) GetSupportedType() domain.StepType { return domain.StepTypeWait }
+
+// hangingStepExecutor 一直阻塞到上下文取消
+type hangingStepExecutor struct {
+	calls int32
+}
+
+func (e *hangingStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	atomic.AddInt32(&e.calls, 1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (e *hangingStepExecutor) GetSupportedType() domain.StepType { return domain.StepTypeWait }
+
+// runRetryWorkflow 执行包含一个待测步骤和一个后续步骤的工作流
+func runRetryWorkflow(t *testing.T, executor StepExecutor, timeout time.Duration, maxRetries int) (*orchestratorTestEnv, *domain.Execution, *domain.Step, *domain.Step) {
+	t.Helper()
+	env := newOrchestratorTestEnv()
+	env.service.RegisterStepExecutor(domain.StepTypeWait, executor)
+
+	workflow := env.addWorkflow(t)
+	step := env.addStep(t, workflow, "call api", domain.StepTypeWait)
+	step.Timeout = timeout
+	step.MaxRetries = maxRetries
+	step.Config[StepConfigRetryBackoff] = "10ms"
+	env.saveStep(t, step)
+	next := env.addStep(t, workflow, "notify", domain.StepTypeAction, step)
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = workflow.ID
+	execution := env.execute(t, cmd)
+	return env, env.waitForExecution(t, execution.ID), step, next
+}
+
+func TestStepSucceedsOnSecondAttempt(t *testing.T) {
+	executor := &flakyStepExecutor{failures: 1}
+	env, execution, step, next := runRetryWorkflow(t, executor, time.Second, 2)
+
+	if execution.Status != domain.ExecutionStatusCompleted {
+		t.Fatalf("execution status = %s: %s", execution.Status, execution.ErrorMessage)
+	}
+	if calls := atomic.LoadInt32(&executor.calls); calls != 2 {
+		t.Fatalf("executor called %d times, want 2", calls)
+	}
+
+	stepExecution := env.stepExecutionOf(t, execution.ID, step.ID)
+	if len(stepExecution.Attempts) != 2 || stepExecution.RetryCount != 1 {
+		t.Fatalf("attempts = %+v, retry count = %d", stepExecution.Attempts, stepExecution.RetryCount)
+	}
+	if first := stepExecution.Attempts[0]; first.Attempt != 1 || first.Error == "" || first.TimedOut {
+		t.Fatalf("first attempt = %+v", first)
+	}
+	if second := stepExecution.Attempts[1]; second.Attempt != 2 || second.Error != "" {
+		t.Fatalf("second attempt = %+v", second)
+	}
+	if env.stepExecutionOf(t, execution.ID, next.ID).Status != domain.StepStatusCompleted {
+		t.Fatal("dependent step should run after the retried step succeeds")
+	}
+}
+
+func TestStepFailsAfterRetriesExhausted(t *testing.T) {
+	executor := &flakyStepExecutor{failures: 5}
+	env, execution, step, _ := runRetryWorkflow(t, executor, time.Second, 2)
+
+	if execution.Status != domain.ExecutionStatusFailed {
+		t.Fatalf("execution status = %s", execution.Status)
+	}
+	if calls := atomic.LoadInt32(&executor.calls); calls != 3 {
+		t.Fatalf("executor called %d times, want 3", calls)
+	}
+	if attempts := env.stepExecutionOf(t, execution.ID, step.ID).Attempts; len(attempts) != 3 {
+		t.Fatalf("recorded %d attempts, want 3", len(attempts))
+	}
+}
+
+func TestStepTimesOut(t *testing.T) {
+	executor := &hangingStepExecutor{}
+	env, execution, step, _ := runRetryWorkflow(t, executor, 50*time.Millisecond, 1)
+
+	if execution.Status != domain.ExecutionStatusFailed {
+		t.Fatalf("execution status = %s", execution.Status)
+	}
+	if calls := atomic.LoadInt32(&executor.calls); calls != 2 {
+		t.Fatalf("executor called %d times, want 2", calls)
+	}
+	for _, attempt := range env.stepExecutionOf(t, execution.ID, step.ID).Attempts {
+		if !attempt.TimedOut {
+			t.Fatalf("attempt %d should time out: %+v", attempt.Attempt, attempt)
+		}
+	}
+}
+
+func TestExecuteAttemptDoesNotWaitForUnresponsiveExecutor(t *testing.T) {
+	// 执行器不响应取消时，超时后立即返回
+	release := make(chan struct{})
+	defer close(release)
+	executor := stepExecutorFunc(func(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+		<-release
+		return &StepExecutionResult{}, nil
+	})
+
+	start := time.Now()
+	_, err := executeAttempt(context.Background(), executor, 20*time.Millisecond, &StepExecutionRequest{})
+	if !errors.Is(err, ErrStepTimeout) {
+		t.Fatalf("expected step timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("attempt returned after %s", elapsed)
+	}
+}
+
+// stepExecutorFunc 以函数实现的执行器
+type stepExecutorFunc func(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error)
+
+func (f stepExecutorFunc) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	return f(ctx, request)
+}
+
+func (f stepExecutorFunc) GetSupportedType() domain.StepType { return domain.StepTypeAction }
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// StepConfigSetVariables 步骤配置中的派生变量键，值为 变量名 -> 表达式
+// 步骤成功后对表达式求值，结果写入执行变量，后续步骤通过vars.变量名引用
+const StepConfigSetVariables = "set_variables"
+
+// stepVariableExpressions 读取步骤配置的派生变量表达式
+func stepVariableExpressions(config map[string]interface{}) (map[string]string, error) {
+	expressions := make(map[string]string)
+	switch value := config[StepConfigSetVariables].(type) {
+	case nil:
+	case map[string]interface{}:
+		for name, item := range value {
+			expression, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expression for variable %q must be a string", name)
+			}
+			expressions[name] = expression
+		}
+	case map[string]string:
+		for name, expression := range value {
+			expressions[name] = expression
+		}
+	default:
+		return nil, fmt.Errorf("%s must map variable names to expressions", StepConfigSetVariables)
+	}
+	return expressions, nil
+}
+
+// validateStepVariables 检查派生变量的名称和表达式语法
+func validateStepVariables(config map[string]interface{}) error {
+	expressions, err := stepVariableExpressions(config)
+	if err != nil {
+		return err
+	}
+	for name, expression := range expressions {
+		if !isVariableName(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+		if err := ValidateExpression(expression); err != nil {
+			return fmt.Errorf("invalid expression for variable %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// deriveStepVariables 对步骤的派生变量表达式求值
+// 表达式除input、context、vars、steps外还可引用当前步骤的输出output；
+// 同一步骤的派生变量都基于步骤开始时的变量求值，彼此不可见
+func deriveStepVariables(request *StepExecutionRequest, output map[string]interface{}) (map[string]interface{}, error) {
+	expressions, err := stepVariableExpressions(request.Step.Config)
+	if err != nil || len(expressions) == 0 {
+		return nil, err
+	}
+
+	scope := expressionVariables(request)
+	scope["output"] = output
+
+	derived := make(map[string]interface{}, len(expressions))
+	for name, expression := range expressions {
+		value, err := EvaluateExpression(expression, scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate variable %q: %w", name, err)
+		}
+		derived[name] = value
+	}
+	return derived, nil
+}
+
+// mergeVariables 复制变量并合并派生变量，不修改原变量
+func mergeVariables(variables, derived map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(variables)+len(derived))
+	for name, value := range variables {
+		merged[name] = value
+	}
+	for name, value := range derived {
+		merged[name] = value
+	}
+	return merged
+}
+
+// isVariableName 变量名需为标识符，才能在表达式中以vars.变量名引用
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// SubworkflowConfigWorkflowID 子工作流步骤配置中的目标工作流ID键
+	SubworkflowConfigWorkflowID = "workflow_id"
+	// ContextCallDepth 执行上下文中的子工作流调用深度键，顶层执行为0
+	ContextCallDepth = "call_depth"
+	// ContextParentExecutionID 执行上下文中发起子工作流的父执行ID键
+	ContextParentExecutionID = "parent_execution_id"
+	// ContextParentStepID 执行上下文中发起子工作流的父步骤ID键
+	ContextParentStepID = "parent_step_id"
+	// SubworkflowOutputExecutionID 子工作流步骤输出中的子执行ID键
+	SubworkflowOutputExecutionID = "execution_id"
+	// DefaultMaxSubworkflowDepth 默认最大子工作流调用深度
+	DefaultMaxSubworkflowDepth = 5
+)
+
+// SubworkflowStepExecutor 子工作流步骤执行器
+// 以步骤输入作为子工作流的执行输入执行目标工作流，等待子执行结束，子执行的输出和子执行ID作为步骤输出；
+// 调用深度记录在子执行的上下文中，超过最大深度时拒绝执行，防止工作流互相调用导致无限递归
+type SubworkflowStepExecutor struct {
+	service  *OrchestratorService
+	maxDepth int
+}
+
+// NewSubworkflowStepExecutor 创建子工作流步骤执行器，maxDepth为最大调用深度
+func NewSubworkflowStepExecutor(service *OrchestratorService, maxDepth int) *SubworkflowStepExecutor {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxSubworkflowDepth
+	}
+	return &SubworkflowStepExecutor{service: service, maxDepth: maxDepth}
+}
+
+// Execute 执行子工作流并等待结束
+// 步骤超时、执行被取消或超时时取消子执行
+func (e *SubworkflowStepExecutor) Execute(ctx context.Context, request *StepExecutionRequest) (*StepExecutionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	workflowID, err := subworkflowID(request.Step.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := callDepth(request.Context) + 1
+	if depth > e.maxDepth {
+		return nil, fmt.Errorf("sub-workflow call depth %d exceeds the maximum of %d", depth, e.maxDepth)
+	}
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = workflowID
+	if request.Input != nil {
+		cmd.Input = request.Input
+	}
+	cmd.Context = map[string]interface{}{
+		ContextCallDepth:         depth,
+		ContextParentExecutionID: request.Execution.ID.String(),
+		ContextParentStepID:      request.Step.ID.String(),
+	}
+
+	result, err := e.service.ExecuteWorkflow(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sub-workflow %s: %w", workflowID, err)
+	}
+	child := result.Data.(*domain.Execution)
+
+	e.service.logger.Info("Sub-workflow started",
+		zap.String("execution_id", request.Execution.ID.String()),
+		zap.String("step_id", request.Step.ID.String()),
+		zap.String("child_execution_id", child.ID.String()),
+		zap.Int("call_depth", depth))
+
+	// 子执行在本实例中运行，结束后句柄被移除
+	if running := e.service.runningExecutions.get(child.ID); running != nil {
+		select {
+		case <-running.done:
+		case <-ctx.Done():
+			running.cancel(ErrExecutionCancelled)
+			<-running.done
+			return nil, fmt.Errorf("sub-workflow execution %s cancelled: %w", child.ID, context.Cause(ctx))
+		}
+	}
+
+	if child.Status != domain.ExecutionStatusCompleted {
+		return nil, fmt.Errorf("sub-workflow execution %s %s: %s", child.ID, child.Status, child.ErrorMessage)
+	}
+
+	// 子执行的输出包含结束时的执行变量variables，子工作流通过派生变量返回结果
+	output := make(map[string]interface{}, len(child.Output)+1)
+	for key, value := range child.Output {
+		output[key] = value
+	}
+	output[SubworkflowOutputExecutionID] = child.ID.String()
+	return &StepExecutionResult{
+		Output: output,
+		Metadata: map[string]interface{}{
+			"call_depth": depth,
+		},
+	}, nil
+}
+
+// GetSupportedType 获取支持的步骤类型
+func (e *SubworkflowStepExecutor) GetSupportedType() domain.StepType {
+	return domain.StepTypeSubworkflow
+}
+
+// subworkflowID 读取步骤配置中的目标工作流ID
+func subworkflowID(config map[string]interface{}) (uuid.UUID, error) {
+	value, _ := config[SubworkflowConfigWorkflowID].(string)
+	if value == "" {
+		return uuid.Nil, errors.New("sub-workflow step requires a workflow_id")
+	}
+	workflowID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid sub-workflow workflow_id %q", value)
+	}
+	return workflowID, nil
+}
+
+// callDepth 读取执行上下文中的调用深度，上下文来自存储时数字为float64
+func callDepth(context map[string]interface{}) int {
+	if depth, ok := toNumber(context[ContextCallDepth]); ok && depth > 0 {
+		return int(depth)
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+)
+
+// addSubworkflowStep 保存调用target工作流的子工作流步骤
+func (env *orchestratorTestEnv) addSubworkflowStep(t *testing.T, workflow *domain.Workflow, target *domain.Workflow, dependencies ...*domain.Step) *domain.Step {
+	t.Helper()
+	step := env.addStep(t, workflow, "call", domain.StepTypeSubworkflow, dependencies...)
+	step.Config[SubworkflowConfigWorkflowID] = target.ID.String()
+	step.MaxRetries = 0
+	env.saveStep(t, step)
+	return step
+}
+
+// childExecutionOf 等待并返回工作流的唯一一次执行
+func (env *orchestratorTestEnv) childExecutionOf(t *testing.T, workflow *domain.Workflow) *domain.Execution {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		executions := env.executions.filter(func(e *domain.Execution) bool { return e.WorkflowID == workflow.ID })
+		if len(executions) == 1 {
+			return env.waitForExecution(t, executions[0].ID)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("found %d executions of workflow %s", len(executions), workflow.ID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSubworkflowStepReturnsChildOutput(t *testing.T) {
+	env := newOrchestratorTestEnv()
+
+	// 子工作流把输入翻倍后作为变量返回
+	child := env.addWorkflow(t)
+	double := env.addStep(t, child, "double", domain.StepTypeAction)
+	double.Config["set_variables"] = map[string]interface{}{"result": "input.n * 2"}
+	env.saveStep(t, double)
+
+	parent := env.addWorkflow(t)
+	call := env.addSubworkflowStep(t, parent, child)
+	call.Input = map[string]interface{}{"n": "${input.n}"}
+	env.saveStep(t, call)
+	after := env.addStep(t, parent, "after", domain.StepTypeAction, call)
+	after.Config["set_variables"] = map[string]interface{}{"final": "steps.call.output.variables.result + 1"}
+	env.saveStep(t, after)
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = parent.ID
+	cmd.Input = map[string]interface{}{"n": float64(21)}
+	execution := env.waitForExecution(t, env.execute(t, cmd).ID)
+	if execution.Status != domain.ExecutionStatusCompleted {
+		t.Fatalf("execution status = %s: %s", execution.Status, execution.ErrorMessage)
+	}
+	if final := execution.Output["variables"].(map[string]interface{})["final"]; final != float64(43) {
+		t.Fatalf("final = %v, want 43", final)
+	}
+
+	// 子执行记录调用深度和父执行
+	childExecution := env.childExecutionOf(t, child)
+	output := env.stepExecutionOf(t, execution.ID, call.ID).Output
+	if output[SubworkflowOutputExecutionID] != childExecution.ID.String() {
+		t.Fatalf("step output = %v, child execution = %s", output, childExecution.ID)
+	}
+	if callDepth(childExecution.Context) != 1 || childExecution.Context[ContextParentExecutionID] != execution.ID.String() {
+		t.Fatalf("child context = %v", childExecution.Context)
+	}
+}
+
+func TestSubworkflowRecursionLimit(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	env.service.RegisterStepExecutor(domain.StepTypeSubworkflow, NewSubworkflowStepExecutor(env.service, 2))
+
+	// 三层嵌套调用超过最大深度2
+	leaf := env.addWorkflow(t)
+	env.addStep(t, leaf, "leaf", domain.StepTypeAction)
+	top := leaf
+	for i := 0; i < 3; i++ {
+		caller := env.addWorkflow(t)
+		env.addSubworkflowStep(t, caller, top)
+		top = caller
+	}
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = top.ID
+	execution := env.waitForExecution(t, env.execute(t, cmd).ID)
+	if execution.Status != domain.ExecutionStatusFailed || !strings.Contains(execution.ErrorMessage, "exceeds the maximum of 2") {
+		t.Fatalf("execution status = %s: %s", execution.Status, execution.ErrorMessage)
+	}
+	if executions := env.executions.filter(func(e *domain.Execution) bool { return e.WorkflowID == leaf.ID }); len(executions) != 0 {
+		t.Fatal("leaf workflow should not run beyond the maximum depth")
+	}
+}
+
+func TestSubworkflowCancelPropagatesToChild(t *testing.T) {
+	env := newOrchestratorTestEnv()
+	blocking := newBlockingStepExecutor()
+	env.service.RegisterStepExecutor(domain.StepTypeHuman, blocking)
+
+	child := env.addWorkflow(t)
+	env.addStep(t, child, "approve", domain.StepTypeHuman)
+	parent := env.addWorkflow(t)
+	env.addSubworkflowStep(t, parent, child)
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = parent.ID
+	execution := env.execute(t, cmd)
+	blocking.waitStarted(t)
+
+	if _, err := env.service.CancelExecution(context.Background(), execution.ID); err != nil {
+		t.Fatal(err)
+	}
+	if childExecution := env.childExecutionOf(t, child); childExecution.Status != domain.ExecutionStatusCancelled {
+		t.Fatalf("child execution status = %s", childExecution.Status)
+	}
+}
+
+func TestSubworkflowIDFromConfig(t *testing.T) {
+	id := uuid.New()
+	if got, err := subworkflowID(map[string]interface{}{SubworkflowConfigWorkflowID: id.String()}); err != nil || got != id {
+		t.Fatalf("workflow id = %s, %v", got, err)
+	}
+	for _, config := range []map[string]interface{}{{}, {SubworkflowConfigWorkflowID: "not-a-uuid"}} {
+		if _, err := subworkflowID(config); err == nil {
+			t.Fatalf("config %v should be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// scheduleParser 标准5字段cron表达式解析器，同时支持@daily、@every 1h等描述符
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// TriggerSchedule 解析后的定时触发计划
+type TriggerSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// ParseTriggerSchedule 解析cron表达式和时区，时区为空时使用UTC
+func ParseTriggerSchedule(spec, timezone string) (*TriggerSchedule, error) {
+	location := time.UTC
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		location = loc
+	}
+
+	schedule, err := scheduleParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return &TriggerSchedule{schedule: schedule, location: location}, nil
+}
+
+// Next 返回after之后的下一次运行时间，按触发器时区计算；表达式永远不会命中时返回零值
+func (s *TriggerSchedule) Next(after time.Time) time.Time {
+	return s.schedule.Next(after.In(s.location))
+}
+
+// Clock 时钟接口，调度器通过它获取当前时间和等待，测试时可替换为可控时钟
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WorkflowExecutor 工作流执行接口，由OrchestratorService实现
+type WorkflowExecutor interface {
+	ExecuteWorkflow(ctx context.Context, cmd *ExecuteWorkflowCommand) (*application.Result, error)
+}
+
+// TriggerSchedulerConfig 定时触发调度器配置
+type TriggerSchedulerConfig struct {
+	Enabled        bool          // 是否启用调度，多实例部署时可只在部分实例上启用
+	ReloadInterval time.Duration // 定期从仓储重新加载触发器的间隔，用于感知其他实例的修改，为0时不定期加载
+}
+
+// DefaultTriggerSchedulerConfig 默认定时触发调度器配置
+func DefaultTriggerSchedulerConfig() TriggerSchedulerConfig {
+	return TriggerSchedulerConfig{
+		Enabled:        true,
+		ReloadInterval: time.Minute,
+	}
+}
+
+// TriggerScheduler 定时触发调度器
+// 加载已启用的定时触发器，按cron表达式和时区计算运行时间，到期时调用ExecuteWorkflow启动工作流。
+// 每次触发前先持久化计划时间（LastTriggered），重启后不会重复触发已记录的计划
+type TriggerScheduler struct {
+	triggerRepo domain.TriggerRepository
+	executor    WorkflowExecutor
+	config      TriggerSchedulerConfig
+	clock       Clock
+	logger      infrastructure.Logger
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]*scheduleEntry
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// scheduleEntry 已注册的定时触发器
+type scheduleEntry struct {
+	spec     string
+	timezone string
+	schedule *TriggerSchedule
+	next     time.Time
+}
+
+// NewTriggerScheduler 创建定时触发调度器
+func NewTriggerScheduler(
+	triggerRepo domain.TriggerRepository,
+	executor WorkflowExecutor,
+	config TriggerSchedulerConfig,
+	logger infrastructure.Logger,
+) *TriggerScheduler {
+	return &TriggerScheduler{
+		triggerRepo: triggerRepo,
+		executor:    executor,
+		config:      config,
+		clock:       realClock{},
+		logger:      logger,
+		entries:     make(map[uuid.UUID]*scheduleEntry),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// SetClock 替换时钟，需在Start之前调用
+func (s *TriggerScheduler) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// Start 加载定时触发器并启动调度
+func (s *TriggerScheduler) Start(ctx context.Context) error {
+	if !s.config.Enabled {
+		s.logger.Info("Trigger scheduler disabled")
+		return nil
+	}
+	if s.triggerRepo == nil {
+		return errors.New("trigger repository is not configured")
+	}
+
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return errors.New("trigger scheduler already started")
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	if err := s.Reload(ctx); err != nil {
+		s.logger.Warn("Failed to load schedule triggers, will retry on next reload", zap.Error(err))
+	}
+
+	go s.run()
+	s.logger.Info("Trigger scheduler started", zap.Duration("reload_interval", s.config.ReloadInterval))
+	return nil
+}
+
+// Stop 停止调度并等待进行中的触发完成，未启动时直接返回
+func (s *TriggerScheduler) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop = nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+	s.logger.Info("Trigger scheduler stopped")
+}
+
+// Reload 从仓储重新加载所有定时触发器
+// 表达式和时区未变化的触发器保留已计算的运行时间，已删除或禁用的触发器被移除
+func (s *TriggerScheduler) Reload(ctx context.Context) error {
+	triggers, err := s.triggerRepo.FindByType(ctx, domain.TriggerTypeSchedule)
+	if err != nil {
+		return fmt.Errorf("failed to load schedule triggers: %w", err)
+	}
+
+	now := s.clock.Now()
+	s.mu.Lock()
+	entries := make(map[uuid.UUID]*scheduleEntry, len(triggers))
+	for _, trigger := range triggers {
+		if !trigger.IsScheduled() {
+			continue
+		}
+		if entry, exists := s.entries[trigger.ID]; exists && entry.spec == trigger.Schedule && entry.timezone == trigger.Timezone {
+			entries[trigger.ID] = entry
+			continue
+		}
+		if entry := s.newEntry(trigger, now); entry != nil {
+			entries[trigger.ID] = entry
+		}
+	}
+	s.entries = entries
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// OnTriggerSaved 触发器保存后重新注册，非定时或已禁用的触发器被移除
+func (s *TriggerScheduler) OnTriggerSaved(trigger *domain.Trigger) {
+	if !trigger.IsScheduled() {
+		s.OnTriggerRemoved(trigger.ID)
+		return
+	}
+
+	s.mu.Lock()
+	if entry := s.newEntry(trigger, s.clock.Now()); entry != nil {
+		s.entries[trigger.ID] = entry
+	} else {
+		delete(s.entries, trigger.ID)
+	}
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// OnTriggerRemoved 触发器删除后取消调度
+func (s *TriggerScheduler) OnTriggerRemoved(triggerID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.entries, triggerID)
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// NextRun 返回触发器在调度器中的下次运行时间，未注册时返回false
+func (s *TriggerScheduler) NextRun(triggerID uuid.UUID) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[triggerID]
+	if !exists {
+		return time.Time{}, false
+	}
+	return entry.next, true
+}
+
+// newEntry 计算触发器的下次运行时间，从当前时间和上次触发的计划时间中较晚者开始计算，
+// 避免重启后重复触发已记录的计划；表达式无效或永远不会命中时返回nil
+func (s *TriggerScheduler) newEntry(trigger *domain.Trigger, now time.Time) *scheduleEntry {
+	schedule, err := ParseTriggerSchedule(trigger.Schedule, trigger.Timezone)
+	if err != nil {
+		s.logger.Warn("Skipping trigger with invalid schedule",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.Error(err))
+		return nil
+	}
+
+	after := now
+	if trigger.LastTriggered != nil && trigger.LastTriggered.After(after) {
+		after = *trigger.LastTriggered
+	}
+	next := schedule.Next(after)
+	if next.IsZero() {
+		s.logger.Warn("Skipping trigger whose schedule never fires",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.String("schedule", trigger.Schedule))
+		return nil
+	}
+
+	return &scheduleEntry{
+		spec:     trigger.Schedule,
+		timezone: trigger.Timezone,
+		schedule: schedule,
+		next:     next,
+	}
+}
+
+// notify 唤醒调度循环重新计算等待时间
+func (s *TriggerScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 调度循环，等待到最近的运行时间或重新加载时间
+func (s *TriggerScheduler) run() {
+	defer close(s.done)
+
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+
+	ctx := context.Background()
+	reloadAt := s.clock.Now().Add(s.config.ReloadInterval)
+
+	for {
+		now := s.clock.Now()
+		if s.config.ReloadInterval > 0 && !now.Before(reloadAt) {
+			if err := s.Reload(ctx); err != nil {
+				s.logger.Warn("Failed to reload schedule triggers", zap.Error(err))
+			}
+			reloadAt = now.Add(s.config.ReloadInterval)
+		}
+
+		s.fireDue(ctx, now)
+
+		var timer <-chan time.Time
+		if wait, ok := s.nextWait(now, reloadAt); ok {
+			timer = s.clock.After(wait)
+		}
+
+		select {
+		case <-timer:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// nextWait 计算距最近一次运行或重新加载的等待时间，没有需要等待的事件时返回false
+func (s *TriggerScheduler) nextWait(now, reloadAt time.Time) (time.Duration, bool) {
+	var earliest time.Time
+	if s.config.ReloadInterval > 0 {
+		earliest = reloadAt
+	}
+
+	s.mu.Lock()
+	for _, entry := range s.entries {
+		if earliest.IsZero() || entry.next.Before(earliest) {
+			earliest = entry.next
+		}
+	}
+	s.mu.Unlock()
+
+	if earliest.IsZero() {
+		return 0, false
+	}
+	wait := earliest.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// dueTrigger 到期的触发
+type dueTrigger struct {
+	triggerID   uuid.UUID
+	scheduledAt time.Time
+	next        time.Time
+}
+
+// fireDue 触发所有到期的触发器
+// 停机期间错过的多次计划只补触发最近的一次，下次运行时间从当前时间开始计算
+func (s *TriggerScheduler) fireDue(ctx context.Context, now time.Time) {
+	var due []dueTrigger
+
+	s.mu.Lock()
+	for triggerID, entry := range s.entries {
+		if entry.next.After(now) {
+			continue
+		}
+		scheduledAt := entry.next
+		entry.next = entry.schedule.Next(now)
+		if entry.next.IsZero() {
+			delete(s.entries, triggerID)
+		}
+		due = append(due, dueTrigger{triggerID: triggerID, scheduledAt: scheduledAt, next: entry.next})
+	}
+	s.mu.Unlock()
+
+	for _, item := range due {
+		s.fire(ctx, item)
+	}
+}
+
+// fire 记录并执行一次触发
+// 先重新读取触发器，确认仍然启用且该计划尚未被记录（其他实例或重启前可能已触发），
+// 再通过条件更新原子地记录计划时间，只有记录成功的实例才启动工作流；
+// 记录失败时放弃本次触发以免重启后重复执行
+func (s *TriggerScheduler) fire(ctx context.Context, item dueTrigger) {
+	trigger, err := s.triggerRepo.FindByID(ctx, item.triggerID)
+	if err != nil || trigger == nil {
+		s.logger.Warn("Schedule trigger not found, unscheduling",
+			zap.String("trigger_id", item.triggerID.String()),
+			zap.Error(err))
+		s.OnTriggerRemoved(item.triggerID)
+		return
+	}
+	if !trigger.IsScheduled() {
+		s.OnTriggerRemoved(trigger.ID)
+		return
+	}
+	if trigger.HasFired(item.scheduledAt) {
+		s.logger.Debug("Schedule already fired, skipping",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.Time("scheduled_at", item.scheduledAt))
+		return
+	}
+
+	var next *time.Time
+	if !item.next.IsZero() {
+		next = &item.next
+	}
+	recorded, err := s.triggerRepo.RecordFire(ctx, trigger.ID, item.scheduledAt, next)
+	if err != nil {
+		s.logger.Error("Failed to record trigger fire, skipping execution",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.Time("scheduled_at", item.scheduledAt),
+			zap.Error(err))
+		return
+	}
+	if !recorded {
+		s.logger.Debug("Schedule fired by another instance, skipping",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.Time("scheduled_at", item.scheduledAt))
+		return
+	}
+	trigger.FireAt(item.scheduledAt, next)
+	trigger.ClearDomainEvents()
+
+	cmd := NewExecuteWorkflowCommand()
+	cmd.WorkflowID = trigger.WorkflowID
+	cmd.TriggerID = trigger.ID
+	if input, ok := trigger.Config["input"].(map[string]interface{}); ok {
+		cmd.Input = input
+	}
+	cmd.Context["trigger_type"] = string(trigger.Type)
+	cmd.Context["scheduled_at"] = item.scheduledAt.Format(time.RFC3339)
+
+	if _, err := s.executor.ExecuteWorkflow(ctx, cmd); err != nil {
+		s.logger.Error("Failed to execute scheduled workflow",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.String("workflow_id", trigger.WorkflowID.String()),
+			zap.Error(err))
+		return
+	}
+
+	s.logger.Info("Schedule trigger fired",
+		zap.String("trigger_id", trigger.ID.String()),
+		zap.String("workflow_id", trigger.WorkflowID.String()),
+		zap.Time("scheduled_at", item.scheduledAt),
+		zap.Int("trigger_count", trigger.TriggerCount))
+}
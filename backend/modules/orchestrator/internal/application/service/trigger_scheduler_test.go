@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// fakeClock 可控时钟，Advance推进时间并唤醒到期的等待
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeClockWaiter
+	added   chan struct{}
+}
+
+type fakeClockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, added: make(chan struct{}, 16)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeClockWaiter{at: c.now.Add(d), ch: ch})
+	select {
+	case c.added <- struct{}{}:
+	default:
+	}
+	return ch
+}
+
+// Advance 推进时间
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(c.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// waitForTimers 等待调度循环注册n次等待
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.added:
+		case <-time.After(time.Second):
+			t.Fatal("scheduler did not start waiting")
+		}
+	}
+}
+
+// scheduleTriggerRepository 内存定时触发器仓储，返回触发器副本，模拟多个实例各自从数据库读取
+type scheduleTriggerRepository struct {
+	domain.TriggerRepository
+	mu      sync.Mutex
+	trigger domain.Trigger
+}
+
+func (r *scheduleTriggerRepository) FindByType(ctx context.Context, triggerType domain.TriggerType) ([]*domain.Trigger, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.trigger.Type != triggerType {
+		return nil, nil
+	}
+	trigger := r.trigger
+	return []*domain.Trigger{&trigger}, nil
+}
+
+func (r *scheduleTriggerRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Trigger, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trigger := r.trigger
+	return &trigger, nil
+}
+
+func (r *scheduleTriggerRepository) RecordFire(ctx context.Context, id uuid.UUID, scheduledAt time.Time, next *time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.trigger.HasFired(scheduledAt) {
+		return false, nil
+	}
+	r.trigger.LastTriggered = &scheduledAt
+	r.trigger.NextRun = next
+	r.trigger.TriggerCount++
+	return true, nil
+}
+
+func (r *scheduleTriggerRepository) snapshot() domain.Trigger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.trigger
+}
+
+// recordingWorkflowExecutor 记录启动工作流的命令
+type recordingWorkflowExecutor struct {
+	calls    int32
+	commands chan *ExecuteWorkflowCommand
+}
+
+func newRecordingWorkflowExecutor() *recordingWorkflowExecutor {
+	return &recordingWorkflowExecutor{commands: make(chan *ExecuteWorkflowCommand, 16)}
+}
+
+func (e *recordingWorkflowExecutor) ExecuteWorkflow(ctx context.Context, cmd *ExecuteWorkflowCommand) (*application.Result, error) {
+	atomic.AddInt32(&e.calls, 1)
+	e.commands <- cmd
+	return &application.Result{Success: true}, nil
+}
+
+func newScheduleTrigger(schedule, timezone string) *domain.Trigger {
+	trigger := domain.NewTrigger(uuid.New(), domain.TriggerTypeSchedule, "daily report")
+	trigger.Schedule = schedule
+	trigger.Timezone = timezone
+	return trigger
+}
+
+func TestTriggerSchedulerFiresAtScheduledTime(t *testing.T) {
+	// 上海时间每天9点，即UTC 1点
+	trigger := newScheduleTrigger("0 9 * * *", "Asia/Shanghai")
+	repo := &scheduleTriggerRepository{trigger: *trigger}
+	executor := newRecordingWorkflowExecutor()
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC))
+
+	scheduler := NewTriggerScheduler(repo, executor, TriggerSchedulerConfig{Enabled: true}, zap.NewNop())
+	scheduler.SetClock(clock)
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer scheduler.Stop()
+
+	firstRun := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	if next, ok := scheduler.NextRun(trigger.ID); !ok || !next.Equal(firstRun) {
+		t.Fatalf("next run = %s, want %s", next, firstRun)
+	}
+
+	// Start加载触发器时唤醒过一次调度循环，第二次等待注册后循环才会阻塞
+	clock.waitForTimers(t, 2)
+
+	// 到达运行时间前不触发
+	clock.Advance(29 * time.Minute)
+	if calls := atomic.LoadInt32(&executor.calls); calls != 0 {
+		t.Fatalf("fired %d times before the scheduled time", calls)
+	}
+
+	clock.Advance(time.Minute)
+	select {
+	case cmd := <-executor.commands:
+		if cmd.WorkflowID != trigger.WorkflowID || cmd.TriggerID != trigger.ID {
+			t.Fatalf("unexpected command: %+v", cmd)
+		}
+		if cmd.Context["scheduled_at"] != firstRun.In(mustLoadLocation(t, "Asia/Shanghai")).Format(time.RFC3339) {
+			t.Fatalf("scheduled_at = %v", cmd.Context["scheduled_at"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("trigger did not fire at the scheduled time")
+	}
+
+	stored := repo.snapshot()
+	if stored.TriggerCount != 1 || !stored.LastTriggered.Equal(firstRun) {
+		t.Fatalf("trigger count = %d, last triggered = %v", stored.TriggerCount, stored.LastTriggered)
+	}
+	secondRun := firstRun.AddDate(0, 0, 1)
+	if stored.NextRun == nil || !stored.NextRun.Equal(secondRun) {
+		t.Fatalf("persisted next run = %v, want %s", stored.NextRun, secondRun)
+	}
+}
+
+func TestTriggerSchedulerDoesNotRefireAfterRestart(t *testing.T) {
+	trigger := newScheduleTrigger("0 * * * *", "")
+	lastFired := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	trigger.LastTriggered = &lastFired
+	repo := &scheduleTriggerRepository{trigger: *trigger}
+
+	// 重启时时钟略早于已记录的计划时间，下次运行从已记录的计划之后计算
+	scheduler := NewTriggerScheduler(repo, newRecordingWorkflowExecutor(), TriggerSchedulerConfig{Enabled: true}, zap.NewNop())
+	scheduler.SetClock(newFakeClock(lastFired.Add(-time.Second)))
+	if err := scheduler.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if next, ok := scheduler.NextRun(trigger.ID); !ok || !next.Equal(lastFired.Add(time.Hour)) {
+		t.Fatalf("next run = %s, want %s", next, lastFired.Add(time.Hour))
+	}
+}
+
+func TestTriggerSchedulerFiresOnceAcrossInstances(t *testing.T) {
+	trigger := newScheduleTrigger("* * * * *", "")
+	repo := &scheduleTriggerRepository{trigger: *trigger}
+	executor := newRecordingWorkflowExecutor()
+
+	scheduledAt := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	item := dueTrigger{triggerID: trigger.ID, scheduledAt: scheduledAt, next: scheduledAt.Add(time.Minute)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		scheduler := NewTriggerScheduler(repo, executor, DefaultTriggerSchedulerConfig(), zap.NewNop())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.fire(context.Background(), item)
+		}()
+	}
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&executor.calls); calls != 1 {
+		t.Fatalf("expected one execution, got %d", calls)
+	}
+	if stored := repo.snapshot(); stored.TriggerCount != 1 || !stored.LastTriggered.Equal(scheduledAt) {
+		t.Fatalf("trigger count = %d, last triggered = %v", stored.TriggerCount, stored.LastTriggered)
+	}
+
+	// 下一个计划正常触发
+	scheduler := NewTriggerScheduler(repo, executor, DefaultTriggerSchedulerConfig(), zap.NewNop())
+	scheduler.fire(context.Background(), dueTrigger{triggerID: trigger.ID, scheduledAt: item.next})
+	if calls := atomic.LoadInt32(&executor.calls); calls != 2 {
+		t.Fatalf("next schedule should fire, got %d executions", calls)
+	}
+}
+
+func TestParseTriggerSchedule(t *testing.T) {
+	schedule, err := ParseTriggerSchedule("@daily", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 纽约时间午夜，冬令时为UTC 5点
+	after := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if next := schedule.Next(after); !next.Equal(time.Date(2024, 1, 2, 5, 0, 0, 0, time.UTC)) {
+		t.Fatalf("next = %s", next)
+	}
+
+	if _, err := ParseTriggerSchedule("* * *", ""); err == nil {
+		t.Fatal("invalid cron expression should be rejected")
+	}
+	if _, err := ParseTriggerSchedule("* * * * *", "Mars/Olympus"); err == nil {
+		t.Fatal("invalid timezone should be rejected")
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/application"
+	"go.uber.org/zap"
+)
+
+// FireWebhookTriggerCommand Webhook触发命令
+type FireWebhookTriggerCommand struct {
+	TriggerID uuid.UUID
+	Signature string // X-Noah-Signature请求头
+	Timestamp string // X-Noah-Timestamp请求头
+	Body      []byte // 原始请求体，签名按原始字节计算
+}
+
+// FireWebhookTrigger 校验签名后由Webhook触发器启动工作流
+// 触发器不存在、不是Webhook触发器或签名无效时返回ErrInvalidWebhookSignature，不区分具体原因，避免探测触发器ID；
+// 请求体为JSON对象时与触发器config.input合并作为执行输入，请求体中的键优先
+func (s *OrchestratorService) FireWebhookTrigger(ctx context.Context, cmd *FireWebhookTriggerCommand) (*application.Result, error) {
+	trigger, err := s.triggerRepo.FindByID(ctx, cmd.TriggerID)
+	if err != nil || trigger == nil {
+		return &application.Result{Success: false, Error: domain.ErrInvalidWebhookSignature.Error()}, domain.ErrInvalidWebhookSignature
+	}
+
+	if err := trigger.VerifyWebhookSignature(cmd.Signature, cmd.Timestamp, cmd.Body, time.Now()); err != nil {
+		s.logger.Warn("Rejected webhook trigger request",
+			zap.String("trigger_id", cmd.TriggerID.String()),
+			zap.Error(err))
+		return &application.Result{Success: false, Error: domain.ErrInvalidWebhookSignature.Error()}, err
+	}
+
+	if !trigger.IsEnabled {
+		err := domain.NewTriggerError("trigger is disabled")
+		return &application.Result{Success: false, Error: err.Error()}, err
+	}
+
+	input := make(map[string]interface{})
+	if defaults, ok := trigger.Config["input"].(map[string]interface{}); ok {
+		for key, value := range defaults {
+			input[key] = value
+		}
+	}
+	if len(cmd.Body) > 0 {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(cmd.Body, &payload); err != nil {
+			err := domain.NewTriggerError(fmt.Sprintf("webhook payload must be a JSON object: %v", err))
+			return &application.Result{Success: false, Error: err.Error()}, err
+		}
+		for key, value := range payload {
+			input[key] = value
+		}
+	}
+
+	// 记录触发统计，保存失败不影响本次执行
+	trigger.Fire()
+	if err := s.triggerRepo.Save(ctx, trigger); err != nil {
+		s.logger.Warn("Failed to record webhook trigger fire",
+			zap.String("trigger_id", trigger.ID.String()),
+			zap.Error(err))
+	}
+	trigger.ClearDomainEvents()
+
+	execCmd := NewExecuteWorkflowCommand()
+	execCmd.WorkflowID = trigger.WorkflowID
+	execCmd.TriggerID = trigger.ID
+	execCmd.Input = input
+	execCmd.Context["trigger_type"] = string(trigger.Type)
+
+	result, err := s.ExecuteWorkflow(ctx, execCmd)
+	if err != nil {
+		return result, err
+	}
+
+	s.logger.Info("Webhook trigger fired",
+		zap.String("trigger_id", trigger.ID.String()),
+		zap.String("workflow_id", trigger.WorkflowID.String()))
+	return result, nil
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 	
 	"github.com/google/uuid"
@@ -39,6 +40,10 @@ type Execution struct {
 	StepExecutions []*StepExecution `json:"step_executions" gorm:"foreignKey:ExecutionID"`
 	CurrentStep    *uuid.UUID       `json:"current_step" gorm:"type:uuid"`
 	
+	// 重新执行
+	RetryOf     *uuid.UUID `json:"retry_of,omitempty" gorm:"type:uuid;index"` // 原执行ID
+	ResumedFrom *uuid.UUID `json:"resumed_from,omitempty" gorm:"type:uuid"`   // 恢复执行的起始步骤ID
+	
 	// 关联
 	Workflow *Workflow `json:"workflow,omitempty" gorm:"foreignKey:WorkflowID"`
 	Trigger  *Trigger  `json:"trigger,omitempty" gorm:"foreignKey:TriggerID"`
@@ -84,6 +89,36 @@ func NewExecution(workflowID, triggerID uuid.UUID, input map[string]interface{})
 	return execution
 }
 
+// NewRetryExecution 基于失败的执行创建重新执行，沿用原执行的输入和上下文
+func NewRetryExecution(source *Execution, fromStepID uuid.UUID) *Execution {
+	execution := NewExecution(source.WorkflowID, source.TriggerID, source.Input)
+	execution.Context = source.Context
+	execution.RetryOf = &source.ID
+	execution.ResumedFrom = &fromStepID
+	
+	event := domain.NewDomainEvent("execution.retried", execution.ID, map[string]interface{}{
+		"execution_id": execution.ID,
+		"workflow_id":  execution.WorkflowID,
+		"retry_of":     source.ID,
+		"from_step":    fromStepID,
+	})
+	execution.domainEvents = append(execution.domainEvents, event)
+	
+	return execution
+}
+
+// CanRetry 检查是否可以重新执行
+func (e *Execution) CanRetry() bool {
+	return e.Status == ExecutionStatusFailed ||
+		e.Status == ExecutionStatusCancelled ||
+		e.Status == ExecutionStatusTimeout
+}
+
+// IsRetry 是否为重新执行
+func (e *Execution) IsRetry() bool {
+	return e.RetryOf != nil
+}
+
 // Start 开始执行
 func (e *Execution) Start() error {
 	if e.Status != ExecutionStatusPending {
@@ -163,9 +198,38 @@ func (e *Execution) Fail(errorMessage string) error {
 	return nil
 }
 
+// Timeout 执行超过工作流的最长执行时间
+func (e *Execution) Timeout(maxExecutionTime time.Duration) error {
+	if e.Status != ExecutionStatusRunning {
+		return NewExecutionError("execution is not in running status")
+	}
+	
+	e.Status = ExecutionStatusTimeout
+	e.ErrorMessage = fmt.Sprintf("execution exceeded max execution time of %s", maxExecutionTime)
+	now := time.Now()
+	e.CompletedAt = &now
+	
+	if e.StartedAt != nil {
+		e.Duration = now.Sub(*e.StartedAt)
+	}
+	
+	e.MarkAsModified()
+	
+	event := domain.NewDomainEvent("execution.timeout", e.ID, map[string]interface{}{
+		"execution_id":       e.ID,
+		"workflow_id":        e.WorkflowID,
+		"max_execution_time": maxExecutionTime,
+		"completed_at":       e.CompletedAt,
+		"duration":           e.Duration,
+	})
+	e.domainEvents = append(e.domainEvents, event)
+	
+	return nil
+}
+
 // Cancel 取消执行
 func (e *Execution) Cancel() {
-	if e.Status == ExecutionStatusCompleted || e.Status == ExecutionStatusFailed {
+	if e.Status == ExecutionStatusCompleted || e.Status == ExecutionStatusFailed || e.Status == ExecutionStatusTimeout {
 		return
 	}
 	
@@ -228,12 +292,24 @@ type StepExecution struct {
 	CompletedAt  *time.Time             `json:"completed_at"`
 	Duration     time.Duration          `json:"duration"`
 	RetryCount   int                    `json:"retry_count" gorm:"default:0"`
+	Attempts     []StepAttempt          `json:"attempts,omitempty" gorm:"type:jsonb;serializer:json"` // 每次尝试的记录
+	Reused       bool                   `json:"reused" gorm:"default:false"` // 是否复用了原执行的输出
+	CacheHit     bool                   `json:"cache_hit" gorm:"default:false"` // 是否复用了步骤缓存中的输出
 	
 	// 关联
 	Execution *Execution `json:"execution,omitempty" gorm:"foreignKey:ExecutionID"`
 	Step      *Step      `json:"step,omitempty" gorm:"foreignKey:StepID"`
 }
 
+// StepAttempt 步骤的一次执行尝试
+type StepAttempt struct {
+	Attempt   int           `json:"attempt"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+	TimedOut  bool          `json:"timed_out,omitempty"`
+}
+
 // NewStepExecution 创建新步骤执行
 func NewStepExecution(executionID, stepID uuid.UUID, input map[string]interface{}) *StepExecution {
 	return &StepExecution{
@@ -251,6 +327,78 @@ func NewStepExecution(executionID, stepID uuid.UUID, input map[string]interface{
 	}
 }
 
+// NewReusedStepExecution 复用原执行中已成功的步骤执行记录
+func NewReusedStepExecution(executionID uuid.UUID, source *StepExecution) *StepExecution {
+	stepExecution := NewStepExecution(executionID, source.StepID, source.Input)
+	stepExecution.Status = StepStatusCompleted
+	stepExecution.Output = source.Output
+	stepExecution.StartedAt = source.StartedAt
+	stepExecution.CompletedAt = source.CompletedAt
+	stepExecution.Duration = source.Duration
+	stepExecution.Reused = true
+	return stepExecution
+}
+
+// Start 开始步骤执行
+func (se *StepExecution) Start() {
+	se.Status = StepStatusRunning
+	now := time.Now()
+	se.StartedAt = &now
+	se.UpdatedAt = now
+}
+
+// RecordAttempt 记录一次执行尝试，重试次数为尝试次数减一
+func (se *StepExecution) RecordAttempt(attempt StepAttempt) {
+	se.Attempts = append(se.Attempts, attempt)
+	se.RetryCount = len(se.Attempts) - 1
+	se.UpdatedAt = time.Now()
+}
+
+// Complete 完成步骤执行
+func (se *StepExecution) Complete(output map[string]interface{}) {
+	se.Status = StepStatusCompleted
+	se.Output = output
+	se.finish()
+}
+
+// Fail 步骤执行失败
+func (se *StepExecution) Fail(errorMessage string) {
+	se.Status = StepStatusFailed
+	se.ErrorMessage = errorMessage
+	se.finish()
+}
+
+// Timeout 步骤因执行超时被取消
+func (se *StepExecution) Timeout(errorMessage string) {
+	se.Status = StepStatusTimeout
+	se.ErrorMessage = errorMessage
+	se.finish()
+}
+
+// Cancel 步骤因执行被取消而中止
+func (se *StepExecution) Cancel(errorMessage string) {
+	se.Status = StepStatusCancelled
+	se.ErrorMessage = errorMessage
+	se.finish()
+}
+
+// Skip 步骤因条件分支未命中被跳过
+func (se *StepExecution) Skip(reason string) {
+	se.Status = StepStatusSkipped
+	se.ErrorMessage = reason
+	se.finish()
+}
+
+// finish 记录结束时间和耗时
+func (se *StepExecution) finish() {
+	now := time.Now()
+	se.CompletedAt = &now
+	if se.StartedAt != nil {
+		se.Duration = now.Sub(*se.StartedAt)
+	}
+	se.UpdatedAt = now
+}
+
 // ExecutionError 执行错误
 type ExecutionError struct {
 	message string
@@ -271,6 +419,10 @@ type ExecutionRepository interface {
 	FindByStatus(ctx context.Context, status ExecutionStatus) ([]*Execution, error)
 	FindRunningExecutions(ctx context.Context) ([]*Execution, error)
 	FindByTriggerID(ctx context.Context, triggerID uuid.UUID) ([]*Execution, error)
+	// SaveUnlessCancelled 仅当库中的执行未被取消时保存，返回是否保存；执行已被其他实例取消时不覆盖取消状态
+	SaveUnlessCancelled(ctx context.Context, execution *Execution) (bool, error)
+	// SaveIfActive 仅当库中的执行仍为待执行或运行中时保存，返回是否保存；用于取消不在本实例运行的执行，不覆盖已结束的状态
+	SaveIfActive(ctx context.Context, execution *Execution) (bool, error)
 }
 
 // StepExecutionRepository 步骤执行仓储接口
@@ -203,8 +203,8 @@ func (s *Step) Skip(reason string) {
 	s.domainEvents = append(s.domainEvents, event)
 }
 
-// Timeout 步骤超时
-func (s *Step) Timeout() {
+// MarkTimeout 步骤超时，方法名避免与Timeout字段冲突
+func (s *Step) MarkTimeout() {
 	s.Status = StepStatusTimeout
 	now := time.Now()
 	s.CompletedAt = &now
@@ -270,6 +270,18 @@ func (s *Step) Retry() error {
 	return nil
 }
 
+// Reset 重置步骤为待执行状态，用于重新执行工作流
+func (s *Step) Reset() {
+	s.Status = StepStatusPending
+	s.ErrorMessage = ""
+	s.Output = make(map[string]interface{})
+	s.RetryCount = 0
+	s.StartedAt = nil
+	s.CompletedAt = nil
+	s.Duration = 0
+	s.MarkAsModified()
+}
+
 // CanExecute 检查是否可以执行
 func (s *Step) CanExecute(completedSteps []uuid.UUID) bool {
 	if s.Status != StepStatusPending {
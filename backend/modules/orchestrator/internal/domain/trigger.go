@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 	
 	"github.com/google/uuid"
@@ -25,6 +26,9 @@ type Trigger struct {
 	// 条件配置（针对条件触发）
 	Conditions   []TriggerCondition `json:"conditions" gorm:"type:jsonb"`
 	
+	// 签名配置（针对Webhook触发），密钥不随触发器返回
+	Secret       string `json:"-" gorm:"column:secret"` // 请求签名密钥
+	
 	// 统计信息
 	TriggerCount int       `json:"trigger_count" gorm:"default:0"`
 	LastTriggered *time.Time `json:"last_triggered"`
@@ -104,20 +108,42 @@ func (t *Trigger) Disable() {
 
 // Trigger 执行触发
 func (t *Trigger) Fire() {
+	t.FireAt(time.Now(), nil)
+}
+
+// FireAt 按计划时间记录一次触发，next为下次运行时间
+// 定时触发器以计划时间而非实际执行时间作为LastTriggered，用于重启或多实例时判断该次计划是否已触发
+func (t *Trigger) FireAt(at time.Time, next *time.Time) {
 	t.TriggerCount++
-	now := time.Now()
-	t.LastTriggered = &now
+	t.LastTriggered = &at
+	t.NextRun = next
 	t.MarkAsModified()
 	
 	event := domain.NewDomainEvent("trigger.fired", t.ID, map[string]interface{}{
 		"trigger_id":     t.ID,
 		"workflow_id":    t.WorkflowID,
 		"trigger_count":  t.TriggerCount,
-		"triggered_at":   now,
+		"triggered_at":   at,
 	})
 	t.domainEvents = append(t.domainEvents, event)
 }
 
+// HasFired 检查计划时间的触发是否已经记录过
+func (t *Trigger) HasFired(at time.Time) bool {
+	return t.LastTriggered != nil && !t.LastTriggered.Before(at)
+}
+
+// IsScheduled 检查是否为需要调度的已启用定时触发器
+func (t *Trigger) IsScheduled() bool {
+	return t.Type == TriggerTypeSchedule && t.IsEnabled && t.Schedule != ""
+}
+
+// SetNextRun 设置下次运行时间
+func (t *Trigger) SetNextRun(next *time.Time) {
+	t.NextRun = next
+	t.MarkAsModified()
+}
+
 // UpdateSchedule 更新调度配置
 func (t *Trigger) UpdateSchedule(schedule, timezone string) error {
 	if t.Type != TriggerTypeSchedule {
@@ -126,7 +152,8 @@ func (t *Trigger) UpdateSchedule(schedule, timezone string) error {
 	
 	t.Schedule = schedule
 	t.Timezone = timezone
-	// TODO: 计算下次运行时间
+	// 下次运行时间由调度器根据新的表达式重新计算
+	t.NextRun = nil
 	t.MarkAsModified()
 	
 	event := domain.NewDomainEvent("trigger.schedule.updated", t.ID, map[string]interface{}{
@@ -220,4 +247,7 @@ type TriggerRepository interface {
 	FindByType(ctx context.Context, triggerType TriggerType) ([]*Trigger, error)
 	FindEnabledTriggers(ctx context.Context) ([]*Trigger, error)
 	FindScheduledTriggers(ctx context.Context, before time.Time) ([]*Trigger, error)
+	// RecordFire 条件更新触发记录：仅当LastTriggered为空或早于scheduledAt时写入计划时间、下次运行时间并累加触发次数，
+	// 返回是否写入成功；多个实例同时触发同一计划时只有一个实例写入成功
+	RecordFire(ctx context.Context, triggerID uuid.UUID, scheduledAt time.Time, next *time.Time) (bool, error)
 }
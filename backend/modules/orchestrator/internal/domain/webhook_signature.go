@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader 签名请求头，值为 sha256=<十六进制签名>
+	WebhookSignatureHeader = "X-Noah-Signature"
+	// WebhookTimestampHeader 签名时间戳请求头，值为Unix秒
+	WebhookTimestampHeader = "X-Noah-Timestamp"
+	// WebhookSignaturePrefix 签名算法前缀
+	WebhookSignaturePrefix = "sha256="
+	// WebhookSignatureTolerance 时间戳与当前时间的最大偏差，超出时拒绝，限制签名请求被重放的时间窗口
+	WebhookSignatureTolerance = 5 * time.Minute
+	// MinWebhookSecretLength 自定义签名密钥的最小长度
+	MinWebhookSecretLength = 16
+)
+
+// ErrInvalidWebhookSignature Webhook请求未签名、签名错误或时间戳过期
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// GenerateWebhookSecret 生成随机签名密钥
+func GenerateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SignWebhookPayload 计算Webhook请求签名
+// 以secret为密钥对"timestamp.body"做HMAC-SHA256后十六进制编码，body为原始请求体
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 校验Webhook请求签名，signature和timestamp为对应请求头的值
+// 非Webhook触发器、未配置密钥、缺少签名、时间戳偏差超过WebhookSignatureTolerance或签名不匹配时返回ErrInvalidWebhookSignature
+func (t *Trigger) VerifyWebhookSignature(signature, timestamp string, body []byte, now time.Time) error {
+	if t.Type != TriggerTypeWebhook || t.Secret == "" {
+		return fmt.Errorf("%w: trigger does not accept webhooks", ErrInvalidWebhookSignature)
+	}
+	if signature == "" || timestamp == "" {
+		return fmt.Errorf("%w: missing %s or %s header", ErrInvalidWebhookSignature, WebhookSignatureHeader, WebhookTimestampHeader)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidWebhookSignature)
+	}
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > WebhookSignatureTolerance || skew < -WebhookSignatureTolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidWebhookSignature)
+	}
+
+	encoded, found := strings.CutPrefix(signature, WebhookSignaturePrefix)
+	if !found {
+		return fmt.Errorf("%w: unsupported signature scheme", ErrInvalidWebhookSignature)
+	}
+	given, err := hex.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidWebhookSignature)
+	}
+	expected, _ := hex.DecodeString(SignWebhookPayload(t.Secret, timestamp, body))
+	if !hmac.Equal(given, expected) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidWebhookSignature)
+	}
+	return nil
+}
@@ -44,6 +44,9 @@ type Workflow struct {
 	OwnerID     uuid.UUID             `json:"owner_id" gorm:"type:uuid;not null;index"`
 	IsTemplate  bool                  `json:"is_template" gorm:"default:false"`
 	
+	// 执行限制
+	MaxExecutionTime time.Duration `json:"max_execution_time" gorm:"default:0"` // 单次执行的最长时间，0表示不限制
+	
 	// 统计信息
 	ExecutionCount int       `json:"execution_count" gorm:"default:0"`
 	LastExecuted   time.Time `json:"last_executed"`
@@ -1,9 +1,14 @@
 package http
 
 import (
+	"errors"
+	"io"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/noah-loop/backend/modules/orchestrator/internal/application/service"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/utils"
 	"go.uber.org/zap"
@@ -32,6 +37,11 @@ func (h *OrchestratorHandler) CreateWorkflow(c *gin.Context) {
 		return
 	}
 
+	if err := cmd.Validate(); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+
 	result, err := h.orchestratorService.CreateWorkflow(c.Request.Context(), cmd)
 	if err != nil {
 		h.logger.Error("Failed to create workflow", zap.Error(err))
@@ -131,14 +141,98 @@ func (h *OrchestratorHandler) ExecuteWorkflow(c *gin.Context) {
 
 // CreateTrigger 创建触发器
 func (h *OrchestratorHandler) CreateTrigger(c *gin.Context) {
-	cmd := service.NewCreateTriggerCommand()
+	cmd := service.NewAddTriggerCommand()
 	if err := c.ShouldBindJSON(cmd); err != nil {
 		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
 		return
 	}
+	if err := cmd.Validate(); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+
+	result, err := h.orchestratorService.AddTrigger(c.Request.Context(), cmd)
+	if err != nil {
+		h.logger.Error("Failed to create trigger", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	// Webhook触发器的签名密钥只在创建时返回
+	if trigger, ok := result.Data.(*domain.Trigger); ok && trigger.Type == domain.TriggerTypeWebhook {
+		utils.CreatedResponse(c, webhookTriggerResponse{Trigger: trigger, Secret: trigger.Secret}, "Trigger created successfully")
+		return
+	}
+
+	utils.CreatedResponse(c, result.Data, "Trigger created successfully")
+}
+
+// webhookTriggerResponse Webhook触发器创建响应，附带签名密钥
+type webhookTriggerResponse struct {
+	*domain.Trigger
+	Secret string `json:"secret"`
+}
+
+// FireWebhookTrigger 由外部系统通过Webhook触发工作流，请求需携带触发器密钥的HMAC签名
+func (h *OrchestratorHandler) FireWebhookTrigger(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
 
-	// TODO: 实现创建触发器逻辑
-	utils.CreatedResponse(c, nil, "Trigger created successfully")
+	// 签名按原始请求体计算，不能先解析JSON
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("body", err.Error()))
+		return
+	}
+
+	result, err := h.orchestratorService.FireWebhookTrigger(c.Request.Context(), &service.FireWebhookTriggerCommand{
+		TriggerID: id,
+		Signature: c.GetHeader(domain.WebhookSignatureHeader),
+		Timestamp: c.GetHeader(domain.WebhookTimestampHeader),
+		Body:      body,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidWebhookSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Invalid webhook signature",
+				"error":   "invalid_signature",
+			})
+			return
+		}
+		var triggerErr *domain.TriggerError
+		if errors.As(err, &triggerErr) {
+			utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("trigger", err.Error()))
+			return
+		}
+		h.logger.Error("Failed to fire webhook trigger", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, result.Data, "Workflow triggered successfully")
+}
+
+// DeleteTrigger 删除触发器
+func (h *OrchestratorHandler) DeleteTrigger(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+
+	if _, err := h.orchestratorService.RemoveTrigger(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete trigger", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, nil, "Trigger deleted successfully")
 }
 
 // GetTriggers 获取触发器列表
@@ -172,3 +266,61 @@ func (h *OrchestratorHandler) GetExecution(c *gin.Context) {
 	_ = id
 	utils.SuccessResponse(c, nil, "Execution retrieved successfully")
 }
+
+// RetryExecution 从失败步骤重新执行
+func (h *OrchestratorHandler) RetryExecution(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+
+	cmd := service.NewRetryExecutionCommand(id)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(cmd); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+			return
+		}
+	}
+	cmd.ExecutionID = id
+
+	if err := cmd.Validate(); err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("validation", err.Error()))
+		return
+	}
+
+	result, err := h.orchestratorService.RetryExecution(c.Request.Context(), cmd.ExecutionID, cmd.FromStepID)
+	if err != nil {
+		h.logger.Error("Failed to retry execution", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, result.Data, "Execution retried successfully")
+}
+
+// CancelExecution 取消执行，进行中的步骤被取消，后续步骤不再执行
+func (h *OrchestratorHandler) CancelExecution(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("id", "invalid UUID format"))
+		return
+	}
+
+	result, err := h.orchestratorService.CancelExecution(c.Request.Context(), id)
+	if err != nil {
+		// 执行已经结束
+		var executionErr *domain.ExecutionError
+		if errors.As(err, &executionErr) {
+			utils.ErrorResponse(c, utils.ErrInvalidInput.WithDetail("execution", err.Error()))
+			return
+		}
+		h.logger.Error("Failed to cancel execution", zap.Error(err))
+		utils.ErrorResponse(c, utils.ErrInternalServer.WithCause(err))
+		return
+	}
+
+	utils.SuccessResponse(c, result.Data, "Execution cancelled successfully")
+}
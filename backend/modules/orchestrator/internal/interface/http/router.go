@@ -10,15 +10,17 @@ import (
 
 // Router 路由结构
 type Router struct {
-	handler *OrchestratorHandler
-	metrics *infrastructure.MetricsRegistry
+	handler   *OrchestratorHandler
+	metrics   *infrastructure.MetricsRegistry
+	bodyLimit middleware.BodyLimitConfig
 }
 
 // NewRouter 创建路由实例
-func NewRouter(handler *OrchestratorHandler, metrics *infrastructure.MetricsRegistry) *Router {
+func NewRouter(handler *OrchestratorHandler, metrics *infrastructure.MetricsRegistry, bodyLimit middleware.BodyLimitConfig) *Router {
 	return &Router{
-		handler: handler,
-		metrics: metrics,
+		handler:   handler,
+		metrics:   metrics,
+		bodyLimit: bodyLimit,
 	}
 }
 
@@ -53,6 +55,7 @@ func (r *Router) setupMiddlewares(router *gin.Engine) {
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS(middleware.DefaultCORSConfig()))
 	router.Use(middleware.MetricsMiddleware(r.metrics))
+	router.Use(middleware.BodyLimit(r.bodyLimit))
 }
 
 // setupHealthRoutes 设置健康检查路由
@@ -97,6 +100,8 @@ func (r *Router) registerOrchestratorRoutes(api *gin.RouterGroup) {
 	{
 		triggers.POST("", r.handler.CreateTrigger)
 		triggers.GET("", r.handler.GetTriggers)
+		triggers.DELETE("/:id", r.handler.DeleteTrigger)
+		triggers.POST("/:id/webhook", r.handler.FireWebhookTrigger)
 	}
 
 	// 执行历史路由
@@ -104,5 +109,7 @@ func (r *Router) registerOrchestratorRoutes(api *gin.RouterGroup) {
 	{
 		executions.GET("", r.handler.GetExecutions)
 		executions.GET("/:id", r.handler.GetExecution)
+		executions.POST("/:id/retry", r.handler.RetryExecution)
+		executions.POST("/:id/cancel", r.handler.CancelExecution)
 	}
 }
@@ -0,0 +1,51 @@
+package wire
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/orchestrator/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/cache"
+)
+
+// NewStepCacheConfig 从环境变量读取步骤缓存配置
+func NewStepCacheConfig() service.StepCacheConfig {
+	config := service.DefaultStepCacheConfig()
+	if enabled, err := strconv.ParseBool(os.Getenv("ORCHESTRATOR_STEP_CACHE_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("ORCHESTRATOR_STEP_CACHE_TTL")); err == nil && ttl > 0 {
+		config.TTL = ttl
+	}
+	return config
+}
+
+// NewStepCacheStore 创建步骤缓存的存储后端
+// ORCHESTRATOR_STEP_CACHE选择memory（默认，进程内LRU）或redis（多实例共享，连接参数来自REDIS_*环境变量），禁用时返回nil
+func NewStepCacheStore(config service.StepCacheConfig) (cache.Cache, func(), error) {
+	if !config.Enabled {
+		return nil, func() {}, nil
+	}
+
+	storeConfig := cache.DefaultConfig()
+	storeConfig.Prefix = "orchestrator:"
+	if backend := strings.ToLower(os.Getenv("ORCHESTRATOR_STEP_CACHE")); backend != "" {
+		storeConfig.Backend = backend
+	}
+	if maxEntries, err := strconv.Atoi(os.Getenv("ORCHESTRATOR_STEP_CACHE_MAX_ENTRIES")); err == nil && maxEntries > 0 {
+		storeConfig.MaxEntries = maxEntries
+	}
+
+	redisConfig := cache.RedisConfig{Addr: "localhost:6379"}
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		redisConfig.Addr = addr
+	}
+	redisConfig.Password = os.Getenv("REDIS_PASSWORD")
+	if db, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil && db >= 0 {
+		redisConfig.DB = db
+	}
+
+	return cache.New(storeConfig, redisConfig)
+}
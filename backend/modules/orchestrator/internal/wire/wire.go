@@ -3,8 +3,13 @@
 package wire
 
 import (
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/google/wire"
 	"github.com/noah-loop/backend/modules/orchestrator/internal/application/service"
+	"github.com/noah-loop/backend/modules/orchestrator/internal/domain"
 	httpHandler "github.com/noah-loop/backend/modules/orchestrator/internal/interface/http"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 )
@@ -16,6 +21,7 @@ type OrchestratorApp struct {
 	Router              *httpHandler.Router
 	Metrics             *infrastructure.MetricsRegistry
 	Database            *infrastructure.Database
+	TriggerScheduler    *service.TriggerScheduler
 }
 
 // InitializeOrchestratorApp 初始化编排器应用
@@ -54,25 +60,32 @@ func InitializeOrchestratorApp() (*OrchestratorApp, func(), error) {
 
 // OrchestratorServiceProviderSet 应用服务提供者集合
 var OrchestratorServiceProviderSet = wire.NewSet(
+	NewStepCacheConfig,
+	NewStepCacheStore,
+	service.NewStepCache,
 	NewOrchestratorServiceStub,
+	NewTriggerSchedulerConfig,
+	NewTriggerScheduler,
 )
 
 // OrchestratorHandlerProviderSet HTTP处理器提供者集合
 var OrchestratorHandlerProviderSet = wire.NewSet(
 	httpHandler.NewOrchestratorHandler,
 	httpHandler.NewRouter,
+	NewBodyLimitConfig,
 )
 
 // NewOrchestratorServiceStub 创建编排器服务存根（临时实现，直到完整实现完成）
 func NewOrchestratorServiceStub(
 	logger infrastructure.Logger,
 	metrics *infrastructure.MetricsRegistry,
+	stepCache service.StepCache,
 ) *service.OrchestratorService {
 	// TODO: 当仓储实现完成后，使用真实的仓储创建服务
 	// return service.NewOrchestratorService(workflowRepo, stepRepo, triggerRepo, executionRepo, stepExecutionRepo, eventBus, logger, metrics)
 	
 	// 目前创建一个带有nil仓储的服务实例用于基本功能
-	return service.NewOrchestratorService(
+	orchestratorService := service.NewOrchestratorService(
 		nil, // workflowRepo
 		nil, // stepRepo  
 		nil, // triggerRepo
@@ -82,4 +95,41 @@ func NewOrchestratorServiceStub(
 		logger,
 		metrics,
 	)
+	
+	orchestratorService.SetStepCache(stepCache)
+	
+	// 检查运行中的执行是否在其他实例被取消的间隔
+	if interval, err := time.ParseDuration(os.Getenv("ORCHESTRATOR_CANCEL_POLL_INTERVAL")); err == nil && interval >= 0 {
+		orchestratorService.SetCancelPollInterval(interval)
+	}
+	
+	// 子工作流最大调用深度
+	if depth, err := strconv.Atoi(os.Getenv("ORCHESTRATOR_MAX_SUBWORKFLOW_DEPTH")); err == nil && depth > 0 {
+		orchestratorService.RegisterStepExecutor(domain.StepTypeSubworkflow, service.NewSubworkflowStepExecutor(orchestratorService, depth))
+	}
+	return orchestratorService
+}
+
+// NewTriggerSchedulerConfig 从环境变量读取定时触发调度器配置
+func NewTriggerSchedulerConfig() service.TriggerSchedulerConfig {
+	config := service.DefaultTriggerSchedulerConfig()
+	if enabled, err := strconv.ParseBool(os.Getenv("ORCHESTRATOR_SCHEDULER_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if interval, err := time.ParseDuration(os.Getenv("ORCHESTRATOR_TRIGGER_RELOAD_INTERVAL")); err == nil && interval >= 0 {
+		config.ReloadInterval = interval
+	}
+	return config
+}
+
+// NewTriggerScheduler 创建定时触发调度器，并注册为编排服务的触发器观察者
+func NewTriggerScheduler(
+	orchestratorService *service.OrchestratorService,
+	config service.TriggerSchedulerConfig,
+	logger infrastructure.Logger,
+) *service.TriggerScheduler {
+	// TODO: 当仓储实现完成后，注入真实的触发器仓储；仓储为nil时调度器不会启动
+	scheduler := service.NewTriggerScheduler(nil, orchestratorService, config, logger)
+	orchestratorService.RegisterTriggerObserver(scheduler)
+	return scheduler
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/noah-loop/backend/modules/orchestrator/internal/application/service"
 	httpHandler "github.com/noah-loop/backend/modules/orchestrator/internal/interface/http"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/logging"
 )
 
 // Injectors from wire.go:
@@ -18,7 +19,7 @@ func InitializeOrchestratorApp() (*OrchestratorApp, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	logger, err := infrastructure.ProvideLogger(config)
+	logger, err := logging.ProvideLogger(config)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -27,16 +28,27 @@ func InitializeOrchestratorApp() (*OrchestratorApp, func(), error) {
 		return nil, nil, err
 	}
 	metricsRegistry := infrastructure.ProvideMetrics("orchestrator", logger)
-	orchestratorService := NewOrchestratorServiceStub(logger, metricsRegistry)
+	stepCacheConfig := NewStepCacheConfig()
+	cacheCache, cleanup, err := NewStepCacheStore(stepCacheConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	stepCache := service.NewStepCache(cacheCache, stepCacheConfig, logger)
+	orchestratorService := NewOrchestratorServiceStub(logger, metricsRegistry, stepCache)
+	triggerSchedulerConfig := NewTriggerSchedulerConfig()
+	triggerScheduler := NewTriggerScheduler(orchestratorService, triggerSchedulerConfig, logger)
 	orchestratorHandler := httpHandler.NewOrchestratorHandler(orchestratorService, logger)
-	router := httpHandler.NewRouter(orchestratorHandler, metricsRegistry)
+	bodyLimitConfig := NewBodyLimitConfig(logger)
+	router := httpHandler.NewRouter(orchestratorHandler, metricsRegistry, bodyLimitConfig)
 	orchestratorApp := &OrchestratorApp{
 		OrchestratorService: orchestratorService,
 		Handler:             orchestratorHandler,
 		Router:              router,
 		Metrics:             metricsRegistry,
 		Database:            database,
+		TriggerScheduler:    triggerScheduler,
 	}
 	return orchestratorApp, func() {
+		cleanup()
 	}, nil
 }
@@ -4,22 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
 	"github.com/noah-loop/backend/modules/rag/internal/wire"
-	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/readiness"
+	"github.com/noah-loop/backend/shared/pkg/server"
 	"go.uber.org/zap"
 )
 
@@ -34,7 +30,7 @@ func main() {
 	defer cleanup()
 
 	// 初始化基础设施组件
-	infraApp, infraCleanup, err := initializeInfrastructure()
+	infra, infraCleanup, err := server.NewInfrastructure(serviceName, "../../configs")
 	if err != nil {
 		log.Fatalf("Failed to initialize infrastructure: %v", err)
 	}
@@ -44,242 +40,110 @@ func main() {
 		zap.String("service", serviceName),
 		zap.String("version", app.Config.App.Version))
 
-	// 注册服务到etcd
-	if err := registerService(infraApp.ServiceRegistry, infraApp.Config); err != nil {
-		app.Logger.Fatal("Failed to register service", zap.Error(err))
-	}
-	defer deregisterService(infraApp.ServiceRegistry)
-
-	// 设置HTTP服务器
-	httpServer := setupHTTPServer(app, infraApp)
-
-	// 设置gRPC服务器
-	grpcServer := setupGRPCServer(app, infraApp)
-
-	// 启动服务器
-	go startHTTPServer(httpServer, infraApp.Config, app.Logger)
-	go startGRPCServer(grpcServer, infraApp.Config, app.Logger)
-
-	// 启动健康检查更新
-	go startHealthUpdater(infraApp.ServiceRegistry, app.Logger)
-
-	// 等待中断信号
-	waitForShutdown(httpServer, grpcServer, infraApp.TracerManager, app.Logger)
-}
-
-// InfrastructureApp 基础设施应用组件
-type InfrastructureApp struct {
-	Config           *infrastructure.Config
-	Logger           infrastructure.Logger
-	TracerManager    *tracing.TracerManager
-	EtcdClient       *etcd.Client
-	ServiceRegistry  *etcd.ServiceRegistry
-	ServiceDiscovery *etcd.ServiceDiscovery
-	ConfigManager    *etcd.ConfigManager
-	SecretManager    *etcd.SecretManager
-}
-
-// initializeInfrastructure 初始化基础设施组件
-func initializeInfrastructure() (*InfrastructureApp, func(), error) {
-	// 加载配置
-	config, err := infrastructure.LoadConfig("../../configs")
+	// 创建就绪闸门，启动步骤完成且依赖检查通过前不接收业务流量
+	gate, err := setupReadiness(app, infra)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+		app.Logger.Fatal("Failed to setup readiness gate", zap.Error(err))
 	}
 
-	// 初始化日志
-	logger := infrastructure.NewZapLogger(config)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// 初始化链路追踪
-	tracingConfig := tracing.NewTracingConfigFromInfrastructure(config, serviceName)
-	tracerManager, err := tracing.NewTracerManager(tracingConfig, logger)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize tracer: %w", err)
-	}
-
-	// 初始化etcd客户端
-	etcdConfig := etcd.NewConfigFromInfrastructure(config)
-	etcdClient, err := etcd.NewClient(etcdConfig, logger)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize etcd client: %w", err)
-	}
+	// 监听etcd中的服务配置，运行时调整日志级别等可变配置
+	infra.WatchConfig(ctx, serviceName)
 
-	// 初始化etcd组件
-	serviceRegistry := etcd.NewServiceRegistry(etcdClient, serviceName, logger)
-	serviceDiscovery := etcd.NewServiceDiscovery(etcdClient, logger)
-	configManager := etcd.NewConfigManager(etcdClient, logger)
-	secretManager := etcd.NewSecretManager(etcdClient, logger)
-
-	app := &InfrastructureApp{
-		Config:           config,
-		Logger:           logger,
-		TracerManager:    tracerManager,
-		EtcdClient:       etcdClient,
-		ServiceRegistry:  serviceRegistry,
-		ServiceDiscovery: serviceDiscovery,
-		ConfigManager:    configManager,
-		SecretManager:    secretManager,
-	}
-
-	cleanup := func() {
-		tracerManager.Close(context.Background())
-		etcdClient.Close()
-	}
-
-	return app, cleanup, nil
-}
-
-// registerService 注册服务到etcd
-func registerService(serviceRegistry *etcd.ServiceRegistry, config *infrastructure.Config) error {
-	serviceInfo := etcd.ServiceInfo{
-		Name:    serviceName,
-		Version: config.App.Version,
-		HTTP: etcd.EndpointInfo{
-			Host: "localhost",
-			Port: config.Services.RAG.Port,
-		},
-		GRPC: etcd.EndpointInfo{
-			Host: "localhost",
-			Port: config.Services.RAG.GRPCPort,
-		},
+	rt := server.New(server.Options{
+		Name:         serviceName,
+		Version:      infra.Config.App.Version,
+		Environment:  infra.Config.App.Environment,
+		HTTPPort:     infra.Config.Services.RAG.Port,
+		GRPCPort:     infra.Config.Services.RAG.GRPCPort,
+		Handler:      app.Router.GetEngine(),
+		ReadTimeout:  time.Duration(infra.Config.HTTP.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(infra.Config.HTTP.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(infra.Config.HTTP.IdleTimeout) * time.Second,
+		GRPCOptions:  grpcServerOptions(infra.TracerManager),
+		// TODO: 注册RAG gRPC服务
+		// RegisterGRPC: func(s *grpc.Server) { ragpb.RegisterRAGServiceServer(s, grpcHandler) },
+		Gate:           gate,
+		Registry:       infra.ServiceRegistry,
+		Logger:         app.Logger,
+		HealthInterval: 15 * time.Second,
 		Metadata: map[string]string{
-			"environment": config.App.Environment,
+			"environment": infra.Config.App.Environment,
 			"region":      "local",
 		},
-	}
-
-	return serviceRegistry.Register(context.Background(), serviceInfo, 30*time.Second)
-}
-
-// deregisterService 注销服务
-func deregisterService(serviceRegistry *etcd.ServiceRegistry) {
-	if err := serviceRegistry.Deregister(context.Background()); err != nil {
-		log.Printf("Failed to deregister service: %v", err)
-	}
-}
-
-// setupHTTPServer 设置HTTP服务器
-func setupHTTPServer(app *wire.RAGApp, infraApp *InfrastructureApp) *http.Server {
-	return &http.Server{
-		Addr:         fmt.Sprintf(":%d", infraApp.Config.Services.RAG.Port),
-		Handler:      app.Router.GetEngine(),
-		ReadTimeout:  time.Duration(infraApp.Config.HTTP.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(infraApp.Config.HTTP.WriteTimeout) * time.Second,
-		IdleTimeout:  time.Duration(infraApp.Config.HTTP.IdleTimeout) * time.Second,
+	})
+
+	// 就绪后启动向量同步任务，重试写入失败的分块向量
+	rt.Go("vector-sync", app.VectorSyncWorker.Run)
+
+	// 数据库迁移、预热向量存储，就绪后注册到etcd，收到关闭信号后摘除流量并关闭服务器
+	err = rt.Run(ctx,
+		readiness.Step{Name: "migrate", Run: func(ctx context.Context) error {
+			return migrateDatabase(ctx, app)
+		}},
+		readiness.Step{Name: "warmup-vector-store", Run: app.VectorRepository.Warmup},
+	)
+	if err != nil {
+		app.Logger.Fatal("RAG service stopped with error", zap.Error(err))
 	}
 }
 
-// setupGRPCServer 设置gRPC服务器
-func setupGRPCServer(app *wire.RAGApp, infraApp *InfrastructureApp) *grpc.Server {
-	// gRPC拦截器选项
+// grpcServerOptions gRPC链路追踪拦截器
+func grpcServerOptions(tracerManager *tracing.TracerManager) []grpc.ServerOption {
 	var opts []grpc.ServerOption
-
-	// 添加链路追踪拦截器
-	if infraApp.TracerManager != nil {
-		if unaryInterceptor := infraApp.TracerManager.UnaryServerInterceptor(); unaryInterceptor != nil {
+	if tracerManager != nil {
+		if unaryInterceptor := tracerManager.UnaryServerInterceptor(); unaryInterceptor != nil {
 			opts = append(opts, grpc.UnaryInterceptor(unaryInterceptor))
 		}
-		if streamInterceptor := infraApp.TracerManager.StreamServerInterceptor(); streamInterceptor != nil {
+		if streamInterceptor := tracerManager.StreamServerInterceptor(); streamInterceptor != nil {
 			opts = append(opts, grpc.StreamInterceptor(streamInterceptor))
 		}
 	}
-
-	server := grpc.NewServer(opts...)
-
-	// 注册健康检查服务
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(server, healthServer)
-	healthServer.SetServingStatus(serviceName, grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// TODO: 注册RAG gRPC服务
-	// ragpb.RegisterRAGServiceServer(server, grpcHandler)
-
-	// 启用反射（开发环境）
-	if infraApp.Config.App.Environment == "development" {
-		reflection.Register(server)
-	}
-
-	return server
+	return opts
 }
 
-// startHTTPServer 启动HTTP服务器
-func startHTTPServer(server *http.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	logger.Info("Starting HTTP server", 
-		zap.String("addr", server.Addr),
-		zap.String("service", serviceName))
-
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatal("Failed to start HTTP server", zap.Error(err))
-	}
-}
-
-// startGRPCServer 启动gRPC服务器
-func startGRPCServer(server *grpc.Server, config *infrastructure.Config, logger infrastructure.Logger) {
-	addr := fmt.Sprintf(":%d", config.Services.RAG.GRPCPort)
-	lis, err := net.Listen("tcp", addr)
+// setupReadiness 创建就绪闸门并添加数据库、etcd和向量存储检查
+func setupReadiness(app *wire.RAGApp, infra *server.Infrastructure) (*readiness.Gate, error) {
+	sqlDB, err := app.Database.DB()
 	if err != nil {
-		logger.Fatal("Failed to listen on gRPC port", zap.Error(err), zap.String("addr", addr))
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
 	}
 
-	logger.Info("Starting gRPC server",
-		zap.String("addr", addr),
-		zap.String("service", serviceName))
-
-	if err := server.Serve(lis); err != nil {
-		logger.Fatal("Failed to start gRPC server", zap.Error(err))
-	}
+	gate := readiness.NewGate(readiness.DefaultConfig())
+	gate.AddCheck("database", readiness.PingCheck(sqlDB))
+	gate.AddCheck("etcd", etcd.HealthCheck(infra.EtcdClient))
+	gate.AddCheck("vector_store", app.VectorRepository.Health)
+	return gate, nil
 }
 
-// startHealthUpdater 启动健康状态更新器
-func startHealthUpdater(serviceRegistry *etcd.ServiceRegistry, logger infrastructure.Logger) {
-	ticker := time.NewTicker(15 * time.Second)
-	defer ticker.Stop()
+// migrateDatabase 执行数据库迁移
+func migrateDatabase(ctx context.Context, app *wire.RAGApp) error {
+	db := app.Database.WithContext(ctx)
 
-	for range ticker.C {
-		if err := serviceRegistry.UpdateHealth(context.Background(), etcd.HealthStatusHealthy, ""); err != nil {
-			logger.Error("Failed to update health status", zap.Error(err))
+	// 内容哈希由全局唯一改为知识库内唯一：删除旧约束，并为使用占位哈希的旧文档补算SHA-256
+	if db.Migrator().HasTable(&domain.Document{}) {
+		if err := db.Exec(`ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_hash_key`).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(`UPDATE documents SET hash = encode(sha256(convert_to(content, 'UTF8')), 'hex') WHERE hash = 'hash_placeholder'`).Error; err != nil {
+			return err
 		}
-	}
-}
-
-// waitForShutdown 等待关闭信号
-func waitForShutdown(httpServer *http.Server, grpcServer *grpc.Server, tracerManager *tracing.TracerManager, logger infrastructure.Logger) {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	
-	<-quit
-	logger.Info("Shutting down RAG service...")
-
-	// 创建超时上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// 优雅关闭HTTP服务器
-	if err := httpServer.Shutdown(ctx); err != nil {
-		logger.Error("HTTP server forced to shutdown", zap.Error(err))
-	}
-
-	// 优雅关闭gRPC服务器
-	done := make(chan bool, 1)
-	go func() {
-		grpcServer.GracefulStop()
-		done <- true
-	}()
-
-	select {
-	case <-done:
-		logger.Info("gRPC server stopped gracefully")
-	case <-ctx.Done():
-		logger.Warn("gRPC server forced to stop due to timeout")
-		grpcServer.Stop()
 	}
 
-	// 关闭链路追踪
-	if tracerManager != nil {
-		if err := tracerManager.Close(ctx); err != nil {
-			logger.Error("Failed to close tracer", zap.Error(err))
+	// 引入软删除后内容哈希只需在未删除的文档中唯一：还没有deleted_at列时删除旧的唯一索引，由AutoMigrate按新条件重建
+	if migrator := db.Migrator(); migrator.HasTable(&domain.Document{}) && !migrator.HasColumn(&domain.Document{}, "DeletedAt") && migrator.HasIndex(&domain.Document{}, "idx_document_kb_hash") {
+		if err := migrator.DropIndex(&domain.Document{}, "idx_document_kb_hash"); err != nil {
+			return err
 		}
 	}
 
-	logger.Info("RAG service stopped")
+	return db.AutoMigrate(
+		&domain.KnowledgeBase{},
+		&domain.Document{},
+		&domain.Chunk{},
+		&domain.Tag{},
+		&domain.KnowledgeBasePermission{},
+	)
 }
@@ -9,7 +9,9 @@ require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/google/wire v0.5.0
 	github.com/google/uuid v1.4.0
+	github.com/milvus-io/milvus-sdk-go/v2 v2.3.6
 	go.uber.org/zap v1.26.0
+	golang.org/x/net v0.18.0
 	gorm.io/gorm v1.25.5
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.31.0
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// AccessControlConfig 知识库访问控制配置
+type AccessControlConfig struct {
+	// AllowAnonymous 为true时不检查匿名请求的知识库权限，仅用于网关未开启认证的本地开发；默认拒绝没有用户身份的请求
+	AllowAnonymous bool
+}
+
+type userIDContextKey struct{}
+
+// WithUserID 将请求用户写入上下文，添加、删除文档和搜索时据此检查知识库权限
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserIDFromContext 从上下文获取请求用户，没有时返回空字符串
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey{}).(string)
+	return userID
+}
+
+// checkKnowledgeBaseAccess 检查用户是否拥有知识库的指定权限，没有时返回PERMISSION_DENIED领域错误
+// 所有者拥有全部权限；没有用户身份时返回UNAUTHENTICATED，AllowAnonymous为true时放行
+func (s *RAGService) checkKnowledgeBaseAccess(ctx context.Context, kb *domain.KnowledgeBase, userID string, permission repository.Permission) error {
+	if userID == "" {
+		if !s.accessConfig.AllowAnonymous {
+			return errUserRequired()
+		}
+		return nil
+	}
+	if userID == kb.OwnerID {
+		return nil
+	}
+
+	allowed, err := s.kbRepo.CheckAccess(ctx, kb.ID, userID, permission)
+	if err != nil {
+		s.logger.Error("Failed to check knowledge base access",
+			zap.String("knowledge_base_id", kb.ID),
+			zap.String("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+	if !allowed {
+		return domain.ErrPermissionDeniedf(kb.ID, string(permission))
+	}
+	return nil
+}
+
+// errUserRequired 请求没有用户身份
+func errUserRequired() error {
+	return domain.NewDomainError(domain.ErrUnauthenticated, "user identity is required")
+}
+
+// findAccessibleDocument 查找文档并检查请求用户的权限：需要所在知识库的指定权限，且文档对用户可见
+// 知识库所有者可以访问所有文档；用户看不到的文档按不存在处理，避免泄露受限文档
+func (s *RAGService) findAccessibleDocument(ctx context.Context, documentID string, permission repository.Permission) (*domain.Document, *domain.KnowledgeBase, error) {
+	doc, err := s.findDocument(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	kb, err := s.findKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userID := UserIDFromContext(ctx)
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, userID, permission); err != nil {
+		return nil, nil, err
+	}
+	if userID != kb.OwnerID && !doc.CanBeAccessedBy(userID) {
+		return nil, nil, domain.ErrDocumentNotFoundf(documentID)
+	}
+	return doc, kb, nil
+}
+
+// setDocumentOwner 将请求用户设为新文档的所有者，并设置访问级别，未指定时保持公开
+func setDocumentOwner(ctx context.Context, doc *domain.Document, level domain.DocumentAccessLevel, allowedUsers []string) error {
+	if level == "" {
+		level = domain.DocumentAccessPublic
+	}
+	return doc.SetAccess(level, UserIDFromContext(ctx), allowedUsers)
+}
+
+// GrantKnowledgeBaseAccess 授予用户知识库权限，已授予时覆盖原权限
+// 需要管理权限；只能授予read、write或admin，所有者不需要授权
+func (s *RAGService) GrantKnowledgeBaseAccess(ctx context.Context, cmd *GrantKnowledgeBaseAccessCommand) error {
+	if cmd.UserID == "" {
+		return domain.ErrInvalidInputf("user_id", "user_id is required")
+	}
+	if !cmd.Permission.IsValid() || cmd.Permission == repository.PermissionOwner {
+		return domain.ErrInvalidInputf("permission", "permission must be read, write or admin")
+	}
+
+	kb, err := s.findKnowledgeBase(ctx, cmd.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionAdmin); err != nil {
+		return err
+	}
+	if cmd.UserID == kb.OwnerID {
+		return domain.ErrInvalidInputf("user_id", "user is the owner of the knowledge base")
+	}
+
+	if err := s.kbRepo.GrantAccess(ctx, kb.ID, cmd.UserID, cmd.Permission); err != nil {
+		s.logger.Error("Failed to grant knowledge base access", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Knowledge base access granted",
+		zap.String("knowledge_base_id", kb.ID),
+		zap.String("user_id", cmd.UserID),
+		zap.String("permission", string(cmd.Permission)))
+	return nil
+}
+
+// RevokeKnowledgeBaseAccess 撤销用户的知识库权限，需要管理权限；未授予时返回repository.ErrNotFound
+func (s *RAGService) RevokeKnowledgeBaseAccess(ctx context.Context, knowledgeBaseID, userID string) error {
+	kb, err := s.findKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionAdmin); err != nil {
+		return err
+	}
+
+	if err := s.kbRepo.RevokeAccess(ctx, kb.ID, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Knowledge base access revoked",
+		zap.String("knowledge_base_id", kb.ID),
+		zap.String("user_id", userID))
+	return nil
+}
+
+// ListKnowledgeBaseAccess 列出有知识库访问权限的用户，需要管理权限
+func (s *RAGService) ListKnowledgeBaseAccess(ctx context.Context, knowledgeBaseID string) ([]repository.UserPermission, error) {
+	kb, err := s.findKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionAdmin); err != nil {
+		return nil, err
+	}
+	return s.kbRepo.ListAccessUsers(ctx, kb.ID)
+}
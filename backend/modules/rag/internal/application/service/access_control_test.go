@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// accessKnowledgeBaseRepository 在内存知识库仓储上按授予的权限检查访问
+type accessKnowledgeBaseRepository struct {
+	memKnowledgeBaseRepository
+	grants map[string]repository.Permission
+}
+
+func (r *accessKnowledgeBaseRepository) CheckAccess(ctx context.Context, knowledgeBaseID, userID string, permission repository.Permission) (bool, error) {
+	kb, ok := r.knowledgeBases[knowledgeBaseID]
+	if !ok {
+		return false, repository.ErrNotFound
+	}
+	if kb.OwnerID == userID {
+		return true, nil
+	}
+	granted, ok := r.grants[userID]
+	return ok && granted.Includes(permission), nil
+}
+
+func (r *accessKnowledgeBaseRepository) GrantAccess(ctx context.Context, knowledgeBaseID, userID string, permission repository.Permission) error {
+	r.grants[userID] = permission
+	return nil
+}
+
+// accessDocumentRepository 只能按ID找到预先保存的文档，使AddDocument触发的后台索引直接结束
+type accessDocumentRepository struct {
+	hashDocumentRepository
+	existing *domain.Document
+	deleted  bool
+}
+
+func (r *accessDocumentRepository) FindByID(ctx context.Context, id string) (*domain.Document, error) {
+	if id != r.existing.ID || r.deleted {
+		return nil, repository.ErrNotFound
+	}
+	copied := *r.existing
+	return &copied, nil
+}
+
+func (r *accessDocumentRepository) Delete(ctx context.Context, id string) error {
+	if id != r.existing.ID {
+		return repository.ErrNotFound
+	}
+	r.deleted = true
+	return nil
+}
+
+// newAccessTestService 创建所有者为owner的知识库，授予reader读权限、writer写权限，文档仓储中预先保存一个文档
+func newAccessTestService(t *testing.T) (*RAGService, *accessDocumentRepository, string) {
+	t.Helper()
+	kb, err := domain.NewKnowledgeBase("kb", "", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kb.Statistics.IndexedCount = 1
+	kbRepo := &accessKnowledgeBaseRepository{
+		memKnowledgeBaseRepository: memKnowledgeBaseRepository{knowledgeBases: map[string]*domain.KnowledgeBase{kb.ID: kb}},
+		grants:                     map[string]repository.Permission{},
+	}
+	doc, err := domain.NewDocument("手册", "已有的部署手册。", domain.DocumentTypeText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.KnowledgeBaseID = kb.ID
+	docRepo := &accessDocumentRepository{existing: doc}
+	chunkRepo := &memChunkRepository{chunks: map[string]*domain.Chunk{}}
+	vectorRepo := &memVectorRepository{indexes: map[string]map[string]bool{}}
+	service := NewRAGService(kbRepo, docRepo, chunkRepo, vectorRepo, &recordingEmbedder{}, noopEmbeddingCache{},
+		NewDefaultChunkingService(nil), nil, nil, ImageExtractionConfig{}, nil, DefaultSearchConfig(),
+		StreamIngestionConfig{}, EmbeddingBatchConfig{}, AccessControlConfig{}, nil, zap.NewNop())
+
+	ownerCtx := WithUserID(context.Background(), "owner")
+	for userID, permission := range map[string]repository.Permission{"reader": repository.PermissionRead, "writer": repository.PermissionWrite} {
+		cmd := &GrantKnowledgeBaseAccessCommand{KnowledgeBaseID: kb.ID, UserID: userID, Permission: permission}
+		if err := service.GrantKnowledgeBaseAccess(ownerCtx, cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return service, docRepo, kb.ID
+}
+
+// errorCode 返回领域错误码，其他错误返回空字符串
+func errorCode(err error) string {
+	var domainErr *domain.DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+	return ""
+}
+
+func TestKnowledgeBaseAccessControl(t *testing.T) {
+	tests := []struct {
+		userID     string
+		searchCode string
+		writeCode  string
+	}{
+		{userID: "owner"},
+		{userID: "reader", writeCode: domain.ErrPermissionDenied},
+		{userID: "writer"},
+		{userID: "stranger", searchCode: domain.ErrPermissionDenied, writeCode: domain.ErrPermissionDenied},
+		{userID: "", searchCode: domain.ErrUnauthenticated, writeCode: domain.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		name := tt.userID
+		if name == "" {
+			name = "anonymous"
+		}
+		t.Run(name, func(t *testing.T) {
+			service, docRepo, kbID := newAccessTestService(t)
+			ctx := context.Background()
+			if tt.userID != "" {
+				ctx = WithUserID(ctx, tt.userID)
+			}
+
+			_, err := service.Search(ctx, domain.NewSearchQuery("部署", kbID))
+			if code := errorCode(err); code != tt.searchCode || (code == "" && err != nil) {
+				t.Fatalf("search: %v, want code %q", err, tt.searchCode)
+			}
+
+			_, err = service.AddDocument(ctx, newAddDocumentCommand(kbID, "新的部署手册。"))
+			if code := errorCode(err); code != tt.writeCode || (code == "" && err != nil) {
+				t.Fatalf("add document: %v, want code %q", err, tt.writeCode)
+			}
+			if added := docRepo.count() == 1; added != (tt.writeCode == "") {
+				t.Fatalf("document added = %v with error code %q", added, tt.writeCode)
+			}
+
+			err = service.DeleteDocument(ctx, docRepo.existing.ID)
+			if code := errorCode(err); code != tt.writeCode || (code == "" && err != nil) {
+				t.Fatalf("delete document: %v, want code %q", err, tt.writeCode)
+			}
+			if docRepo.deleted != (tt.writeCode == "") {
+				t.Fatalf("document deleted = %v with error code %q", docRepo.deleted, tt.writeCode)
+			}
+		})
+	}
+}
+
+func TestGrantKnowledgeBaseAccessRequiresAdmin(t *testing.T) {
+	service, _, kbID := newAccessTestService(t)
+
+	// 写权限不能授予他人权限
+	cmd := &GrantKnowledgeBaseAccessCommand{KnowledgeBaseID: kbID, UserID: "stranger", Permission: repository.PermissionRead}
+	if err := service.GrantKnowledgeBaseAccess(WithUserID(context.Background(), "writer"), cmd); errorCode(err) != domain.ErrPermissionDenied {
+		t.Fatalf("grant by writer: %v, want %s", err, domain.ErrPermissionDenied)
+	}
+	if _, err := service.Search(WithUserID(context.Background(), "stranger"), domain.NewSearchQuery("部署", kbID)); errorCode(err) != domain.ErrPermissionDenied {
+		t.Fatalf("search by stranger after rejected grant: %v", err)
+	}
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 )
@@ -16,6 +19,12 @@ type ChunkingService interface {
 	// ChunkText 对文本进行分块
 	ChunkText(ctx context.Context, text string, chunkType domain.ChunkType) ([]*domain.Chunk, error)
 	
+	// ChunkStream 边读边分块，不在内存中保留全文，每个分块交给emit处理
+	ChunkStream(ctx context.Context, document *domain.Document, r io.Reader, emit func(*domain.Chunk) error) error
+	
+	// ChunkImage 对从文档图片中识别的文字进行分块，分块从position开始编号并记录来源图片
+	ChunkImage(ctx context.Context, document *domain.Document, image *domain.ChunkImage, text string, position int) ([]*domain.Chunk, error)
+	
 	// GetOptimalChunkSize 获取最佳分块大小
 	GetOptimalChunkSize(text string, maxTokens int) int
 	
@@ -42,6 +51,37 @@ type ChunkingConfig struct {
 	MaxChunkSize  int             `json:"max_chunk_size"` // 最大分块大小
 	Separators    []string        `json:"separators"`     // 分隔符
 	KeepSeparator bool            `json:"keep_separator"` // 保留分隔符
+	Languages     map[string]LanguageProfile `json:"languages"` // 主语言代码 -> 分块参数，未配置的语言使用Separators
+}
+
+// LanguageProfile 语言相关的分块参数
+type LanguageProfile struct {
+	Separators    []string `json:"separators"`     // 分隔符，按优先级排列
+	KeepSeparator bool     `json:"keep_separator"` // 分隔符保留在前一个分块末尾，使句末标点留在所属句子中
+}
+
+// defaultLanguageProfiles 默认的语言分块参数
+// 中文和日文的句末标点后没有空格，按全角标点断句；拉丁字母语言按句末标点加空格断句，避免在缩写和小数点处断开
+func defaultLanguageProfiles() map[string]LanguageProfile {
+	cjk := LanguageProfile{
+		Separators:    []string{"\n\n", "\n", "。", "！", "？", "；", "!", "?", "…", "，", "、"},
+		KeepSeparator: true,
+	}
+	latin := LanguageProfile{
+		Separators:    []string{"\n\n", "\n", ". ", "! ", "? ", "; ", ", ", " "},
+		KeepSeparator: true,
+	}
+
+	return map[string]LanguageProfile{
+		"zh": cjk,
+		"ja": cjk,
+		"en": latin,
+		"fr": latin,
+		"de": latin,
+		"es": latin,
+		"pt": latin,
+		"ru": latin,
+	}
 }
 
 // DefaultChunkingConfig 默认分块配置
@@ -54,6 +94,7 @@ func DefaultChunkingConfig() *ChunkingConfig {
 		MaxChunkSize:  2000,
 		Separators:    []string{"\n\n", "\n", "。", "！", "？", ".", "!", "?"},
 		KeepSeparator: false,
+		Languages:     defaultLanguageProfiles(),
 	}
 }
 
@@ -111,11 +152,23 @@ func (s *DefaultChunkingService) ChunkDocument(ctx context.Context, document *do
 	// 根据文档类型选择分块策略
 	chunkType := s.getChunkTypeForDocument(document.Type)
 	
-	// 预处理文档内容
-	content := s.preprocessContent(document.Content, document.Type)
+	// 预处理文档内容，HTML和Markdown提取纯文本和标题结构
+	processed := s.preprocessContent(document.Content, document.Type)
+	if strings.TrimSpace(processed.Text) == "" {
+		return nil, fmt.Errorf("document has no readable content")
+	}
 	
-	// 执行分块
-	textChunks := s.splitText(content)
+	// 按文档语言分块，未设置语言时自动检测；有标题结构时按章节分块
+	language := document.Language
+	if language == "" {
+		language = domain.DetectLanguage(processed.Text)
+	}
+	var textChunks []TextChunk
+	if len(processed.Sections) > 0 {
+		textChunks = s.splitSections(processed, language)
+	} else {
+		textChunks = s.splitText(processed.Text, language)
+	}
 	
 	// 创建分块对象
 	chunks := make([]*domain.Chunk, 0, len(textChunks))
@@ -131,6 +184,7 @@ func (s *DefaultChunkingService) ChunkDocument(ctx context.Context, document *do
 		
 		// 设置元数据
 		chunk.Metadata.Title = document.Title
+		chunk.Metadata.Section = textChunk.Section
 		if document.Metadata.Author != "" {
 			chunk.Metadata.Custom["author"] = document.Metadata.Author
 		}
@@ -150,7 +204,7 @@ func (s *DefaultChunkingService) ChunkText(ctx context.Context, text string, chu
 		return nil, fmt.Errorf("text cannot be empty")
 	}
 	
-	textChunks := s.splitText(text)
+	textChunks := s.splitText(text, domain.DetectLanguage(text))
 	
 	chunks := make([]*domain.Chunk, 0, len(textChunks))
 	for i, textChunk := range textChunks {
@@ -168,10 +222,54 @@ func (s *DefaultChunkingService) ChunkText(ctx context.Context, text string, chu
 	return chunks, nil
 }
 
+// ChunkImage 对图片文字进行分块
+// 分块位置为在图片文字中的偏移，类型为图片分块，元数据与文档正文分块相同
+func (s *DefaultChunkingService) ChunkImage(ctx context.Context, document *domain.Document, image *domain.ChunkImage, text string, position int) ([]*domain.Chunk, error) {
+	if document == nil {
+		return nil, fmt.Errorf("document cannot be nil")
+	}
+	
+	text = s.preprocessText(text)
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("image %d has no readable text", image.Index)
+	}
+	
+	language := document.Language
+	if language == "" {
+		language = domain.DetectLanguage(text)
+	}
+	textChunks := s.splitText(text, language)
+	
+	chunks := make([]*domain.Chunk, 0, len(textChunks))
+	for i, textChunk := range textChunks {
+		chunk, err := domain.NewChunk(document.ID, textChunk.Content, domain.ChunkTypeImage, position+i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create chunk %d of image %d: %w", i, image.Index, err)
+		}
+		
+		chunk.StartIndex = textChunk.StartIndex
+		chunk.EndIndex = textChunk.EndIndex
+		source := *image
+		chunk.Image = &source
+		
+		chunk.Metadata.Title = document.Title
+		if document.Metadata.Author != "" {
+			chunk.Metadata.Custom["author"] = document.Metadata.Author
+		}
+		if document.Source != "" {
+			chunk.Metadata.Custom["source"] = document.Source
+		}
+		
+		chunks = append(chunks, chunk)
+	}
+	
+	return chunks, nil
+}
+
 // GetOptimalChunkSize 获取最佳分块大小
 func (s *DefaultChunkingService) GetOptimalChunkSize(text string, maxTokens int) int {
 	// 简单实现：基于文本长度和最大令牌数计算
-	textLength := len(text)
+	textLength := utf8.RuneCountInString(text)
 	estimatedTokens := textLength / 4 // 简单估算
 	
 	if estimatedTokens <= maxTokens {
@@ -191,7 +289,7 @@ func (s *DefaultChunkingService) ValidateChunk(chunk *domain.Chunk) error {
 		return fmt.Errorf("chunk content cannot be empty")
 	}
 	
-	contentLength := len(chunk.Content)
+	contentLength := utf8.RuneCountInString(chunk.Content)
 	if contentLength < s.config.MinChunkSize {
 		return fmt.Errorf("chunk size %d is below minimum %d", contentLength, s.config.MinChunkSize)
 	}
@@ -203,31 +301,45 @@ func (s *DefaultChunkingService) ValidateChunk(chunk *domain.Chunk) error {
 	return nil
 }
 
-// TextChunk 文本分块结构
+// TextChunk 文本分块结构，位置为字符（rune）偏移
 type TextChunk struct {
 	Content    string
 	StartIndex int
 	EndIndex   int
+	Section    string // 所属章节标题
 }
 
 // splitText 分割文本
-func (s *DefaultChunkingService) splitText(text string) []TextChunk {
+func (s *DefaultChunkingService) splitText(text, language string) []TextChunk {
 	switch s.config.Strategy {
 	case ChunkingStrategyFixedSize:
-		return s.fixedSizeSplit(text)
+		return s.fixedSizeSplit(text, s.languageProfile(language))
 	case ChunkingStrategySemantic:
 		return s.semanticSplit(text)
 	case ChunkingStrategyStructural:
 		return s.structuralSplit(text)
 	default:
-		return s.fixedSizeSplit(text)
+		return s.fixedSizeSplit(text, s.languageProfile(language))
+	}
+}
+
+// languageProfile 获取语言的分块参数，未配置的语言使用通用分隔符
+func (s *DefaultChunkingService) languageProfile(language string) LanguageProfile {
+	if profile, exists := s.config.Languages[domain.LanguageBase(language)]; exists && len(profile.Separators) > 0 {
+		return profile
+	}
+	return LanguageProfile{
+		Separators:    s.config.Separators,
+		KeepSeparator: s.config.KeepSeparator,
 	}
 }
 
 // fixedSizeSplit 固定大小分割
-func (s *DefaultChunkingService) fixedSizeSplit(text string) []TextChunk {
+// 按字符（rune）而非字节计算大小和位置，避免在多字节字符中间切断
+func (s *DefaultChunkingService) fixedSizeSplit(text string, profile LanguageProfile) []TextChunk {
 	var chunks []TextChunk
-	textLen := len(text)
+	runes := []rune(text)
+	textLen := len(runes)
 	
 	if textLen <= s.config.ChunkSize {
 		return []TextChunk{{
@@ -237,6 +349,11 @@ func (s *DefaultChunkingService) fixedSizeSplit(text string) []TextChunk {
 		}}
 	}
 	
+	separators := make([][]rune, len(profile.Separators))
+	for i, separator := range profile.Separators {
+		separators[i] = []rune(separator)
+	}
+	
 	start := 0
 	for start < textLen {
 		end := start + s.config.ChunkSize
@@ -245,14 +362,17 @@ func (s *DefaultChunkingService) fixedSizeSplit(text string) []TextChunk {
 		}
 		
 		// 尝试在分隔符处分割
-		actualEnd := s.findBestSplitPoint(text, start, end)
+		actualEnd := s.findBestSplitPoint(runes, start, end, separators, profile.KeepSeparator)
 		
 		chunk := TextChunk{
-			Content:    text[start:actualEnd],
+			Content:    string(runes[start:actualEnd]),
 			StartIndex: start,
 			EndIndex:   actualEnd,
 		}
 		chunks = append(chunks, chunk)
+		if actualEnd >= textLen {
+			break
+		}
 		
 		// 计算下一个开始位置（考虑重叠）
 		start = actualEnd - s.config.ChunkOverlap
@@ -261,7 +381,7 @@ func (s *DefaultChunkingService) fixedSizeSplit(text string) []TextChunk {
 		}
 		
 		// 如果没有进展，强制移动
-		if start == chunk.StartIndex {
+		if start <= chunk.StartIndex {
 			start = actualEnd
 		}
 	}
@@ -271,38 +391,47 @@ func (s *DefaultChunkingService) fixedSizeSplit(text string) []TextChunk {
 
 // semanticSplit 语义分割（简单实现）
 func (s *DefaultChunkingService) semanticSplit(text string) []TextChunk {
-	// 简单实现：按段落分割
-	paragraphs := strings.Split(text, "\n\n")
+	// 简单实现：按段落分割，位置按字符计算
+	const paragraphSeparator = "\n\n"
+	separatorLen := utf8.RuneCountInString(paragraphSeparator)
+	
+	paragraphs := strings.Split(text, paragraphSeparator)
 	var chunks []TextChunk
-	currentChunk := ""
+	var current []string
+	currentLen := 0
 	startIndex := 0
+	offset := 0
 	
 	for _, paragraph := range paragraphs {
-		if len(currentChunk)+len(paragraph) > s.config.ChunkSize && currentChunk != "" {
+		paragraphLen := utf8.RuneCountInString(paragraph)
+		if len(current) > 0 && currentLen+paragraphLen > s.config.ChunkSize {
 			// 创建当前分块
 			chunks = append(chunks, TextChunk{
-				Content:    strings.TrimSpace(currentChunk),
+				Content:    strings.TrimSpace(strings.Join(current, paragraphSeparator)),
 				StartIndex: startIndex,
-				EndIndex:   startIndex + len(currentChunk),
+				EndIndex:   startIndex + currentLen,
 			})
 			
 			// 开始新分块
-			startIndex += len(currentChunk)
-			currentChunk = paragraph
-		} else {
-			if currentChunk != "" {
-				currentChunk += "\n\n"
-			}
-			currentChunk += paragraph
+			current = nil
+			currentLen = 0
+			startIndex = offset
+		}
+		
+		if len(current) > 0 {
+			currentLen += separatorLen
 		}
+		current = append(current, paragraph)
+		currentLen += paragraphLen
+		offset += paragraphLen + separatorLen
 	}
 	
 	// 添加最后一个分块
-	if currentChunk != "" {
+	if len(current) > 0 {
 		chunks = append(chunks, TextChunk{
-			Content:    strings.TrimSpace(currentChunk),
+			Content:    strings.TrimSpace(strings.Join(current, paragraphSeparator)),
 			StartIndex: startIndex,
-			EndIndex:   startIndex + len(currentChunk),
+			EndIndex:   startIndex + currentLen,
 		})
 	}
 	
@@ -316,10 +445,46 @@ func (s *DefaultChunkingService) structuralSplit(text string) []TextChunk {
 	return s.semanticSplit(text)
 }
 
-// findBestSplitPoint 找到最佳分割点
-func (s *DefaultChunkingService) findBestSplitPoint(text string, start, maxEnd int) int {
-	if maxEnd >= len(text) {
-		return len(text)
+// splitSections 按章节分块，章节超过分块大小时在章节内按分块策略继续切分
+// 只有标题没有正文的章节并入下一个章节，避免产生只含标题的分块
+func (s *DefaultChunkingService) splitSections(content preprocessedContent, language string) []TextChunk {
+	runes := []rune(content.Text)
+	var chunks []TextChunk
+	start := -1
+	
+	for i, section := range content.Sections {
+		if start < 0 {
+			start = section.StartIndex
+		}
+		
+		own := strings.TrimSpace(string(runes[section.StartIndex:section.EndIndex]))
+		title := ""
+		if section.Level > 0 {
+			title, _, _ = strings.Cut(own, "\n")
+			if !strings.Contains(own, "\n") && i+1 < len(content.Sections) {
+				continue
+			}
+		}
+		
+		text := strings.TrimRightFunc(string(runes[start:section.EndIndex]), unicode.IsSpace)
+		if text != "" {
+			for _, chunk := range s.splitText(text, language) {
+				chunk.StartIndex += start
+				chunk.EndIndex += start
+				chunk.Section = title
+				chunks = append(chunks, chunk)
+			}
+		}
+		start = -1
+	}
+	
+	return chunks
+}
+
+// findBestSplitPoint 找到最佳分割点，位置为字符偏移
+func (s *DefaultChunkingService) findBestSplitPoint(runes []rune, start, maxEnd int, separators [][]rune, keepSeparator bool) int {
+	if maxEnd >= len(runes) {
+		return len(runes)
 	}
 	
 	// 在分隔符附近寻找最佳分割点
@@ -328,13 +493,21 @@ func (s *DefaultChunkingService) findBestSplitPoint(text string, start, maxEnd i
 		searchStart = start
 	}
 	
-	for _, separator := range s.config.Separators {
+	for _, separator := range separators {
+		if len(separator) == 0 {
+			continue
+		}
 		for i := maxEnd - 1; i >= searchStart; i-- {
-			if i+len(separator) <= len(text) && text[i:i+len(separator)] == separator {
-				if s.config.KeepSeparator {
-					return i + len(separator)
-				}
-				return i
+			if !hasRunesAt(runes, i, separator) {
+				continue
+			}
+			end := i
+			if keepSeparator {
+				end = i + len(separator)
+			}
+			// 分割点不能超过分块大小，也不能产生空分块
+			if end > start && end <= maxEnd {
+				return end
 			}
 		}
 	}
@@ -343,8 +516,21 @@ func (s *DefaultChunkingService) findBestSplitPoint(text string, start, maxEnd i
 	return maxEnd
 }
 
+// hasRunesAt 判断runes在位置i处是否以separator开头
+func hasRunesAt(runes []rune, i int, separator []rune) bool {
+	if i+len(separator) > len(runes) {
+		return false
+	}
+	for j, r := range separator {
+		if runes[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
 // preprocessContent 预处理内容
-func (s *DefaultChunkingService) preprocessContent(content string, docType domain.DocumentType) string {
+func (s *DefaultChunkingService) preprocessContent(content string, docType domain.DocumentType) preprocessedContent {
 	// 根据文档类型进行预处理
 	switch docType {
 	case domain.DocumentTypeHTML:
@@ -352,20 +538,23 @@ func (s *DefaultChunkingService) preprocessContent(content string, docType domai
 	case domain.DocumentTypeMarkdown:
 		return s.preprocessMarkdown(content)
 	default:
-		return s.preprocessText(content)
+		return preprocessedContent{Text: s.preprocessText(content)}
 	}
 }
 
-// preprocessHTML 预处理HTML内容
-func (s *DefaultChunkingService) preprocessHTML(content string) string {
-	// TODO: 实现HTML预处理（移除标签、提取纯文本等）
-	return content
+// preprocessHTML 预处理HTML内容：移除标签、脚本和样式，提取纯文本和标题结构
+func (s *DefaultChunkingService) preprocessHTML(content string) preprocessedContent {
+	extracted, err := extractHTML(content)
+	if err != nil {
+		// 无法解析时按纯文本处理
+		return preprocessedContent{Text: s.preprocessText(content)}
+	}
+	return extracted
 }
 
-// preprocessMarkdown 预处理Markdown内容
-func (s *DefaultChunkingService) preprocessMarkdown(content string) string {
-	// TODO: 实现Markdown预处理（保留结构信息等）
-	return content
+// preprocessMarkdown 预处理Markdown内容：移除格式标记，保留段落、列表和标题结构
+func (s *DefaultChunkingService) preprocessMarkdown(content string) preprocessedContent {
+	return extractMarkdown(content)
 }
 
 // preprocessText 预处理纯文本内容
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+)
+
+// checkRuneOffsets 检查分块是合法的UTF-8，并且位置是原文中的字符偏移
+func checkRuneOffsets(t *testing.T, text string, chunks []TextChunk, chunkSize int) {
+	t.Helper()
+	runes := []rune(text)
+	for i, chunk := range chunks {
+		if !utf8.ValidString(chunk.Content) {
+			t.Fatalf("chunk %d is not valid UTF-8: %q", i, chunk.Content)
+		}
+		if chunk.StartIndex < 0 || chunk.EndIndex > len(runes) || chunk.StartIndex >= chunk.EndIndex {
+			t.Fatalf("chunk %d has invalid offsets [%d, %d)", i, chunk.StartIndex, chunk.EndIndex)
+		}
+		if got := string(runes[chunk.StartIndex:chunk.EndIndex]); got != chunk.Content {
+			t.Fatalf("chunk %d offsets [%d, %d) select %q, content %q", i, chunk.StartIndex, chunk.EndIndex, got, chunk.Content)
+		}
+		if n := utf8.RuneCountInString(chunk.Content); n > chunkSize {
+			t.Fatalf("chunk %d has %d runes, larger than %d", i, n, chunkSize)
+		}
+	}
+	if last := chunks[len(chunks)-1]; last.EndIndex != len(runes) {
+		t.Fatalf("last chunk ends at %d, text has %d runes", last.EndIndex, len(runes))
+	}
+}
+
+func TestFixedSizeSplitEmojiAndCJK(t *testing.T) {
+	// 没有分隔符时按大小硬切分，切分点落在多字节字符之间
+	service := NewDefaultChunkingService(&ChunkingConfig{
+		Strategy:     ChunkingStrategyFixedSize,
+		ChunkSize:    7,
+		ChunkOverlap: 2,
+	})
+	text := strings.Repeat("表情😀👍🏽汉字🇨🇳", 10)
+
+	chunks := service.fixedSizeSplit(text, LanguageProfile{})
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	checkRuneOffsets(t, text, chunks, 7)
+	for i := 1; i < len(chunks); i++ {
+		if chunks[i].StartIndex != chunks[i-1].EndIndex-2 {
+			t.Fatalf("chunk %d starts at %d, want overlap of 2 runes with [%d, %d)",
+				i, chunks[i].StartIndex, chunks[i-1].StartIndex, chunks[i-1].EndIndex)
+		}
+	}
+}
+
+func TestChunkDocumentMixedEnglishAndChinese(t *testing.T) {
+	config := DefaultChunkingConfig()
+	config.ChunkSize = 40
+	config.ChunkOverlap = 0
+	service := NewDefaultChunkingService(config)
+
+	sentences := []string{
+		"RAG服务支持mixed English和中文内容😀。",
+		"分块按照rune偏移计算，不会切断emoji🎉。",
+		"每个句子以全角句号结束，便于断句测试。",
+		"Unicode文本包括韩文한국어和日文ひらがな。",
+	}
+	document := &domain.Document{
+		Content:  strings.Repeat(strings.Join(sentences, ""), 3),
+		Type:     domain.DocumentTypeText,
+		Language: "zh",
+	}
+	document.ID = "doc-1"
+
+	chunks, err := service.ChunkDocument(context.Background(), document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+
+	textChunks := make([]TextChunk, len(chunks))
+	for i, chunk := range chunks {
+		textChunks[i] = TextChunk{Content: chunk.Content, StartIndex: chunk.StartIndex, EndIndex: chunk.EndIndex}
+		// 中文按全角标点断句，句号保留在所属句子末尾
+		if !strings.HasSuffix(chunk.Content, "。") {
+			t.Fatalf("chunk %d does not end at a sentence boundary: %q", i, chunk.Content)
+		}
+	}
+	checkRuneOffsets(t, document.Content, textChunks, 40)
+}
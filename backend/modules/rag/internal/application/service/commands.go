@@ -1,12 +1,14 @@
 package service
 
-import "github.com/noah-loop/backend/modules/rag/internal/domain"
+import (
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+)
 
 // CreateKnowledgeBaseCommand 创建知识库命令
 type CreateKnowledgeBaseCommand struct {
 	Name        string                            `json:"name" binding:"required"`
 	Description string                            `json:"description"`
-	OwnerID     string                            `json:"owner_id" binding:"required"`
 	Settings    *domain.KnowledgeBaseSettings     `json:"settings,omitempty"`
 	Tags        []string                          `json:"tags,omitempty"`
 }
@@ -36,18 +38,36 @@ type AddDocumentCommand struct {
 	KnowledgeBaseID string                    `json:"knowledge_base_id" binding:"required"`
 	Metadata        *domain.DocumentMetadata  `json:"metadata,omitempty"`
 	Tags            []string                  `json:"tags,omitempty"`
+	AccessLevel     domain.DocumentAccessLevel `json:"access_level,omitempty"` // 文档所有者为请求用户
+	AllowedUsers    []string                  `json:"allowed_users,omitempty"`
+	SkipDuplicates  bool                      `json:"skip_duplicates,omitempty"` // 内容重复时返回已有文档而不是报错
 }
 
 // UpdateDocumentCommand 更新文档命令
 type UpdateDocumentCommand struct {
-	ID          string                    `json:"id" binding:"required"`
+	ID          string                    `json:"id"`
 	Title       string                    `json:"title,omitempty"`
 	Content     string                    `json:"content,omitempty"`
+	Language    string                    `json:"language,omitempty"`
 	Status      domain.DocumentStatus     `json:"status,omitempty"`
 	Metadata    *domain.DocumentMetadata  `json:"metadata,omitempty"`
 	Tags        []string                  `json:"tags,omitempty"`
 }
 
+// UpdateDocumentAccessCommand 更新文档访问控制命令
+type UpdateDocumentAccessCommand struct {
+	ID           string                     `json:"id"`
+	AccessLevel  domain.DocumentAccessLevel `json:"access_level" binding:"required"` // 文档所有者不变
+	AllowedUsers []string                   `json:"allowed_users,omitempty"`
+}
+
+// GrantKnowledgeBaseAccessCommand 授予知识库权限命令
+type GrantKnowledgeBaseAccessCommand struct {
+	KnowledgeBaseID string                `json:"knowledge_base_id"`
+	UserID          string                `json:"-"` // 被授权的用户，取自路径参数
+	Permission      repository.Permission `json:"permission" binding:"required"`
+}
+
 // DeleteDocumentCommand 删除文档命令
 type DeleteDocumentCommand struct {
 	ID string `json:"id" binding:"required"`
@@ -66,9 +86,13 @@ type SearchCommand struct {
 	TopK            int                   `json:"top_k"`
 	ScoreThreshold  float32               `json:"score_threshold"`
 	SearchType      domain.SearchType     `json:"search_type"`
+	SearchMode      domain.SearchMode     `json:"search_mode,omitempty"`
+	Level           domain.SearchLevel    `json:"level,omitempty"` // 检索粒度，summary先检索文档摘要，detail只检索正文
 	Filters         *domain.SearchFilters `json:"filters,omitempty"`
 	Rerank          bool                  `json:"rerank"`
 	IncludeMetadata bool                  `json:"include_metadata"`
+	Fields          []string              `json:"fields,omitempty"`
+	ContextExpansion *domain.ContextExpansion `json:"context_expansion,omitempty"` // 命中分块的上下文扩展
 }
 
 // ToSearchQuery 转换为搜索查询
@@ -87,12 +111,19 @@ func (cmd *SearchCommand) ToSearchQuery() *domain.SearchQuery {
 		query.WithSearchType(cmd.SearchType)
 	}
 	
+	if cmd.SearchMode != "" {
+		query.WithSearchMode(cmd.SearchMode)
+	}
+	
 	if cmd.Filters != nil {
 		query.WithFilters(*cmd.Filters)
 	}
 	
+	query.Level = cmd.Level
 	query.Rerank = cmd.Rerank
 	query.IncludeMetadata = cmd.IncludeMetadata
+	query.Fields = cmd.Fields
+	query.ContextExpansion = cmd.ContextExpansion
 	
 	return query
 }
@@ -134,6 +165,7 @@ type ListDocumentsCommand struct {
 	Type            string `json:"type,omitempty"`
 	Offset          int    `json:"offset"`
 	Limit           int    `json:"limit"`
+	Cursor          string `json:"cursor,omitempty"` // 游标分页时上一页返回的next_cursor，为空时从第一页开始
 }
 
 // GetDocumentCommand 获取文档命令
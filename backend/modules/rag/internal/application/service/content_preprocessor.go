@@ -0,0 +1,285 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/noah-loop/backend/shared/pkg/textformat"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// textSection 预处理后文本中的章节，位置为字符（rune）偏移
+// 章节从标题所在行开始，到下一个章节开始为止；首个标题之前的内容为无标题章节
+type textSection struct {
+	Level      int // 标题级别，1-6，0表示无标题
+	StartIndex int
+	EndIndex   int
+}
+
+// preprocessedContent 预处理结果
+type preprocessedContent struct {
+	Text     string
+	Sections []textSection // 按标题划分的章节，没有标题时为空
+}
+
+// plainTextBuilder 纯文本构建器，在textformat.Builder的基础上记录标题的起始位置
+type plainTextBuilder struct {
+	textformat.Builder
+	sections []textSection
+	heading  int // 待记录的标题级别，下一段文本即标题的开始
+}
+
+// newPlainTextBuilder 创建纯文本构建器
+func newPlainTextBuilder() *plainTextBuilder {
+	b := &plainTextBuilder{}
+	b.OnWrite = b.recordSection
+	return b
+}
+
+// startSection 开始一个标题，标题前后分段
+func (b *plainTextBuilder) startSection(level int) {
+	b.LineBreak(2)
+	b.heading = level
+}
+
+// recordSection 标题的第一段文本写入时记录章节起始位置
+func (b *plainTextBuilder) recordSection(offset int) {
+	if b.heading > 0 {
+		b.sections = append(b.sections, textSection{Level: b.heading, StartIndex: offset})
+		b.heading = 0
+	}
+}
+
+// result 返回文本和章节，首个标题之前有内容时补充无标题章节
+func (b *plainTextBuilder) result() preprocessedContent {
+	content := preprocessedContent{Text: b.String()}
+	if len(b.sections) == 0 {
+		return content
+	}
+
+	sections := b.sections
+	if sections[0].StartIndex > 0 {
+		sections = append([]textSection{{StartIndex: 0}}, sections...)
+	}
+	for i := range sections {
+		if i+1 < len(sections) {
+			sections[i].EndIndex = sections[i+1].StartIndex
+		} else {
+			sections[i].EndIndex = b.Len()
+		}
+	}
+	content.Sections = sections
+	return content
+}
+
+// skippedDocumentElements 文档中除textformat.SkippedHTMLElements外还需忽略的交互和导航元素
+var skippedDocumentElements = map[atom.Atom]bool{
+	atom.Canvas: true,
+	atom.Iframe: true,
+	atom.Object: true,
+	atom.Nav:    true,
+	atom.Button: true,
+	atom.Select: true,
+}
+
+// isSkippedHTMLElement 判断元素是否不包含正文，连同子节点一起忽略
+func isSkippedHTMLElement(a atom.Atom) bool {
+	return textformat.SkippedHTMLElements[a] || skippedDocumentElements[a]
+}
+
+// extractHTML 从HTML中提取可读文本
+// 移除标签、脚本、样式和导航，块级元素之间换行或分段，标题作为章节边界
+func extractHTML(content string) (preprocessedContent, error) {
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return preprocessedContent{}, err
+	}
+
+	builder := newPlainTextBuilder()
+	walkHTML(builder, root, false)
+	return builder.result(), nil
+}
+
+// walkHTML 深度优先遍历HTML节点，pre表示是否在预格式化元素内
+func walkHTML(b *plainTextBuilder, n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			b.WriteRaw(n.Data)
+		} else {
+			b.WriteInline(n.Data)
+		}
+		return
+	case html.CommentNode, html.DoctypeNode:
+		return
+	case html.ElementNode:
+		if isSkippedHTMLElement(n.DataAtom) {
+			return
+		}
+		switch n.DataAtom {
+		case atom.Br:
+			b.LineBreak(1)
+			return
+		case atom.Img:
+			b.WriteInline(textformat.HTMLAttr(n, "alt"))
+			return
+		case atom.Pre:
+			pre = true
+		}
+		if level, isHeading := textformat.HTMLHeadingLevels[n.DataAtom]; isHeading {
+			b.startSection(level)
+			walkHTMLChildren(b, n, pre)
+			b.LineBreak(2)
+			return
+		}
+	}
+
+	breaks := 0
+	if n.Type == html.ElementNode {
+		breaks = textformat.HTMLBlockBreaks[n.DataAtom]
+	}
+	if breaks > 0 {
+		b.LineBreak(breaks)
+	}
+	walkHTMLChildren(b, n, pre)
+	if breaks > 0 {
+		b.LineBreak(breaks)
+	}
+
+	// 表格单元格之间以空格分隔
+	if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+		b.Separate()
+	}
+}
+
+// walkHTMLChildren 遍历子节点
+func walkHTMLChildren(b *plainTextBuilder, n *html.Node, pre bool) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walkHTML(b, child, pre)
+	}
+}
+
+var (
+	markdownSetextH1       = regexp.MustCompile(`^\s{0,3}=+\s*$`)
+	markdownSetextH2       = regexp.MustCompile(`^\s{0,3}-+\s*$`)
+	markdownBlockquote     = regexp.MustCompile(`^\s{0,3}(>\s?)+`)
+	markdownListItem       = regexp.MustCompile(`^\s*([-*+]|\d{1,9}[.)])\s+(\[[ xX]\]\s+)?`)
+	markdownTableDelimiter = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	markdownLinkDefinition = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*\S+`)
+)
+
+// extractMarkdown 移除Markdown格式标记，保留标题、段落和列表结构
+// 标题作为章节边界，代码块内容原样保留，链接和图片保留文字
+func extractMarkdown(content string) preprocessedContent {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	lines := strings.Split(stripFrontMatter(content), "\n")
+
+	builder := newPlainTextBuilder()
+	fence := ""
+	inTable := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// 代码块
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				builder.LineBreak(2)
+				continue
+			}
+			builder.WriteRaw(line)
+			builder.LineBreak(1)
+			continue
+		}
+		if match := textformat.MarkdownFence.FindStringSubmatch(line); match != nil {
+			fence = match[1]
+			builder.LineBreak(2)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			inTable = false
+			builder.LineBreak(2)
+			continue
+		}
+		if isMarkdownTableDelimiter(line) {
+			inTable = true
+			builder.LineBreak(1)
+			continue
+		}
+		if inTable || (i+1 < len(lines) && isMarkdownTableDelimiter(lines[i+1])) {
+			writeMarkdownTableRow(builder, line)
+			builder.LineBreak(1)
+			continue
+		}
+
+		switch {
+		case textformat.MarkdownATXHeading.MatchString(line):
+			match := textformat.MarkdownATXHeading.FindStringSubmatch(line)
+			builder.startSection(len(match[1]))
+			builder.WriteInline(textformat.StripInlineMarkdown(match[2], textformat.StripOptions{StripHTMLTags: true}))
+			builder.LineBreak(2)
+		case i+1 < len(lines) && markdownSetextH1.MatchString(lines[i+1]):
+			builder.startSection(1)
+			builder.WriteInline(textformat.StripInlineMarkdown(line, textformat.StripOptions{StripHTMLTags: true}))
+			builder.LineBreak(2)
+			i++
+		case i+1 < len(lines) && markdownSetextH2.MatchString(lines[i+1]) && !markdownListItem.MatchString(line):
+			builder.startSection(2)
+			builder.WriteInline(textformat.StripInlineMarkdown(line, textformat.StripOptions{StripHTMLTags: true}))
+			builder.LineBreak(2)
+			i++
+		case textformat.MarkdownThematicBreak.MatchString(line), markdownLinkDefinition.MatchString(line):
+			builder.LineBreak(2)
+		default:
+			line = markdownBlockquote.ReplaceAllString(line, "")
+			if item := markdownListItem.FindString(line); item != "" {
+				builder.LineBreak(1)
+				line = line[len(item):]
+			}
+			builder.WriteInline(textformat.StripInlineMarkdown(line, textformat.StripOptions{StripHTMLTags: true}))
+			builder.LineBreak(1)
+		}
+	}
+
+	return builder.result()
+}
+
+// stripFrontMatter 移除文档开头的YAML元数据块
+func stripFrontMatter(content string) string {
+	if !strings.HasPrefix(content, "---\n") {
+		return content
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return content
+	}
+	rest := content[4+end+4:]
+	if newline := strings.IndexByte(rest, '\n'); newline >= 0 {
+		if strings.TrimSpace(rest[:newline]) != "" {
+			return content
+		}
+		return rest[newline+1:]
+	}
+	if strings.TrimSpace(rest) != "" {
+		return content
+	}
+	return ""
+}
+
+// isMarkdownTableDelimiter 判断是否为表头与表体之间的分隔行，如 |---|:--:|
+func isMarkdownTableDelimiter(line string) bool {
+	return strings.Contains(line, "|") && markdownTableDelimiter.MatchString(line)
+}
+
+// writeMarkdownTableRow 写入表格行，单元格之间以空格分隔
+func writeMarkdownTableRow(b *plainTextBuilder, line string) {
+	trimmed := strings.TrimSpace(line)
+	trimmed = strings.TrimSuffix(strings.TrimPrefix(trimmed, "|"), "|")
+	for _, cell := range strings.Split(trimmed, "|") {
+		b.WriteInline(textformat.StripInlineMarkdown(cell, textformat.StripOptions{StripHTMLTags: true}))
+		b.Separate()
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+)
+
+// sectionTexts 返回每个章节的文本
+func sectionTexts(content preprocessedContent) []string {
+	runes := []rune(content.Text)
+	texts := make([]string, len(content.Sections))
+	for i, section := range content.Sections {
+		texts[i] = strings.TrimSpace(string(runes[section.StartIndex:section.EndIndex]))
+	}
+	return texts
+}
+
+func TestExtractHTML(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head><title>页面标题</title><style>body { color: red; }</style></head>
+<body>
+<nav><a href="/">首页</a> | <a href="/docs">文档</a></nav>
+<p>开头的<b>介绍</b>段落。</p>
+<h1>安装</h1>
+<p>第一段&amp;说明，<a href="https://example.com">链接文字</a>。</p>
+<p>第二段<br>换行内容。</p>
+<script>alert("x")</script>
+<h2>配置</h2>
+<ul><li>选项一</li><li>选项二</li></ul>
+</body>
+</html>`
+
+	content, err := extractHTML(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "开头的介绍段落。\n\n安装\n\n第一段&说明，链接文字。\n\n第二段\n换行内容。\n\n配置\n\n选项一\n选项二"
+	if content.Text != want {
+		t.Fatalf("text = %q\nwant   %q", content.Text, want)
+	}
+	for _, fragment := range []string{"<", ">", "alert", "color", "首页", "页面标题"} {
+		if strings.Contains(content.Text, fragment) {
+			t.Fatalf("text contains %q: %q", fragment, content.Text)
+		}
+	}
+
+	wantSections := []string{"开头的介绍段落。", "安装\n\n第一段&说明，链接文字。\n\n第二段\n换行内容。", "配置\n\n选项一\n选项二"}
+	if got := sectionTexts(content); strings.Join(got, "|") != strings.Join(wantSections, "|") {
+		t.Fatalf("sections = %q\nwant       %q", got, wantSections)
+	}
+	if content.Sections[0].Level != 0 || content.Sections[1].Level != 1 || content.Sections[2].Level != 2 {
+		t.Fatalf("section levels = %+v", content.Sections)
+	}
+}
+
+func TestExtractMarkdown(t *testing.T) {
+	doc := `---
+title: 示例
+---
+# 快速开始
+
+这是**加粗**和*斜体*文字，包含[链接](https://example.com)与` + "`代码`" + `。
+同一段落的第二行。
+
+> 引用内容
+
+## 安装步骤
+
+1. 下载安装包
+2. 运行 ![图标](icon.png) 安装程序
+
+` + "```sh\nmake install # **不处理**\n```" + `
+
+| 参数 | 说明 |
+|------|------|
+| port | 端口 |
+`
+
+	content := extractMarkdown(doc)
+	want := "快速开始\n\n这是加粗和斜体文字，包含链接与代码。\n同一段落的第二行。\n\n引用内容\n\n" +
+		"安装步骤\n\n下载安装包\n运行 图标 安装程序\n\nmake install # **不处理**\n\n参数 说明\nport 端口"
+	if content.Text != want {
+		t.Fatalf("text = %q\nwant   %q", content.Text, want)
+	}
+	if strings.Contains(content.Text, "title:") || strings.Contains(content.Text, "](") || strings.Contains(content.Text, "|") {
+		t.Fatalf("markdown syntax remains: %q", content.Text)
+	}
+
+	if len(content.Sections) != 2 || content.Sections[0].Level != 1 || content.Sections[1].Level != 2 {
+		t.Fatalf("sections = %+v", content.Sections)
+	}
+	if got := sectionTexts(content); !strings.HasPrefix(got[0], "快速开始") || !strings.HasPrefix(got[1], "安装步骤") {
+		t.Fatalf("sections = %q", got)
+	}
+}
+
+func TestChunkDocumentUsesMarkdownHeadingsAsSections(t *testing.T) {
+	service := NewDefaultChunkingService(nil)
+	document := &domain.Document{
+		Title:   "手册",
+		Type:    domain.DocumentTypeMarkdown,
+		Content: "# 简介\n\n产品**概述**。\n\n# 使用\n\n## 登录\n\n输入`用户名`和密码。\n",
+	}
+	document.ID = "doc-1"
+
+	chunks, err := service.ChunkDocument(context.Background(), document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有标题的“使用”章节并入下一个章节，分块归属于有正文的章节
+	want := []struct {
+		section string
+		content string
+	}{
+		{"简介", "简介\n\n产品概述。"},
+		{"登录", "使用\n\n登录\n\n输入用户名和密码。"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks: %+v", len(chunks), chunks)
+	}
+	for i, w := range want {
+		chunk := chunks[i]
+		if chunk.Type != domain.ChunkTypeSection || chunk.Metadata.Section != w.section || chunk.Content != w.content {
+			t.Fatalf("chunk %d: type = %s, section = %q, content = %q", i, chunk.Type, chunk.Metadata.Section, chunk.Content)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"go.uber.org/zap"
+)
+
+// expandResultContexts 为截断后的搜索结果加载命中分块前后的分块文本，同一文档的分块只加载一次
+// merged为合并后的命中，按结果ID（最高分分块ID）对应；文档分块加载失败时该结果不返回前后文
+func (s *RAGService) expandResultContexts(ctx context.Context, results *domain.SearchResults, merged []mergedHit, expansion *domain.ContextExpansion) {
+	hits := make(map[string]*mergedHit, len(merged))
+	for i := range merged {
+		hits[merged[i].best.chunk.ID] = &merged[i]
+	}
+
+	documentChunks := make(map[string][]*domain.Chunk)
+	for i := range results.Results {
+		result := &results.Results[i]
+		hit := hits[result.ID]
+		if hit == nil {
+			continue
+		}
+
+		documentID := hit.best.chunk.DocumentID
+		chunks, loaded := documentChunks[documentID]
+		if !loaded {
+			var err error
+			chunks, err = s.chunkRepo.FindByDocumentID(ctx, documentID)
+			if err != nil {
+				s.logger.Warn("Failed to load document chunks for result context",
+					zap.String("document_id", documentID),
+					zap.Error(err))
+				chunks = nil
+			}
+			sort.SliceStable(chunks, func(a, b int) bool {
+				return chunks[a].Position < chunks[b].Position
+			})
+			documentChunks[documentID] = chunks
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+
+		result.Context = expandHitContext(hit, chunks, expansion)
+	}
+}
+
+// expandHitContext 从命中范围两侧交替向外取分块，直到达到每侧分块数（neighbors范围）或章节边界（section范围），
+// 或加入下一个分块会超出令牌预算。chunks为文档中按位置排序的全部分块，只使用与命中位于同一文本（正文或同一图片）的分块
+func expandHitContext(hit *mergedHit, chunks []*domain.Chunk, expansion *domain.ContextExpansion) *domain.ResultContext {
+	key := mergeKey(hit.best.chunk)
+	first, last := hit.chunks[0].Position, hit.chunks[0].Position
+	for _, chunk := range hit.chunks {
+		if chunk.Position < first {
+			first = chunk.Position
+		}
+		if chunk.Position > last {
+			last = chunk.Position
+		}
+	}
+
+	var before, after []*domain.Chunk
+	for i := len(chunks) - 1; i >= 0; i-- {
+		if chunks[i].Position < first && mergeKey(chunks[i]) == key {
+			before = append(before, chunks[i])
+		}
+	}
+	for _, chunk := range chunks {
+		if chunk.Position > last && mergeKey(chunk) == key {
+			after = append(after, chunk)
+		}
+	}
+
+	// 范围内的候选分块，由近及远
+	section := hit.best.chunk.Metadata.Section
+	limit := func(candidates []*domain.Chunk) []*domain.Chunk {
+		if expansion.Scope == domain.ContextScopeSection {
+			for i, chunk := range candidates {
+				if chunk.Metadata.Section != section {
+					return candidates[:i]
+				}
+			}
+			return candidates
+		}
+		if len(candidates) > expansion.Neighbors {
+			return candidates[:expansion.Neighbors]
+		}
+		return candidates
+	}
+	before, after = limit(before), limit(after)
+
+	// 命中范围的边界，用于去除与命中内容重叠的部分；位置与内容不一致时不去重
+	offsets := hasRuneOffsets(hit.chunks[0])
+	start, end := hit.startIndex, hit.endIndex
+
+	result := &domain.ResultContext{}
+	var beforeParts, afterParts []string
+	var beforeIDs, afterIDs []string
+	budget := expansion.MaxTokens
+	for i, j := 0, 0; i < len(before) || j < len(after); {
+		takeBefore := i < len(before) && (j >= len(after) || i <= j)
+		if takeBefore {
+			chunk := before[i]
+			text := chunk.Content
+			if offsets && hasRuneOffsets(chunk) {
+				text = textBefore(chunk, start)
+				if chunk.StartIndex < start {
+					start = chunk.StartIndex
+				}
+			}
+			if text == "" {
+				i++
+				continue
+			}
+			tokens := estimateTokens(text)
+			if tokens > budget {
+				result.Truncated = true
+				before = before[:i]
+				continue
+			}
+			budget -= tokens
+			result.TokenCount += tokens
+			beforeParts = append(beforeParts, text)
+			beforeIDs = append(beforeIDs, chunk.ID)
+			i++
+			continue
+		}
+
+		chunk := after[j]
+		text := chunk.Content
+		if offsets && hasRuneOffsets(chunk) {
+			text = textAfter(chunk, end)
+			if chunk.EndIndex > end {
+				end = chunk.EndIndex
+			}
+		}
+		if text == "" {
+			j++
+			continue
+		}
+		tokens := estimateTokens(text)
+		if tokens > budget {
+			result.Truncated = true
+			after = after[:j]
+			continue
+		}
+		budget -= tokens
+		result.TokenCount += tokens
+		afterParts = append(afterParts, text)
+		afterIDs = append(afterIDs, chunk.ID)
+		j++
+	}
+
+	// 前文由近及远取得，按位置顺序拼接
+	for a, b := 0, len(beforeParts)-1; a < b; a, b = a+1, b-1 {
+		beforeParts[a], beforeParts[b] = beforeParts[b], beforeParts[a]
+		beforeIDs[a], beforeIDs[b] = beforeIDs[b], beforeIDs[a]
+	}
+	separator := "\n"
+	if offsets {
+		separator = ""
+	}
+	result.Before = strings.Join(beforeParts, separator)
+	result.After = strings.Join(afterParts, separator)
+	result.ChunkIDs = append(beforeIDs, afterIDs...)
+	if result.ChunkIDs == nil {
+		result.ChunkIDs = []string{}
+	}
+	return result
+}
+
+// textBefore 分块在start之前的部分，分块完全位于start之后时为空
+func textBefore(chunk *domain.Chunk, start int) string {
+	if chunk.EndIndex <= start {
+		return chunk.Content
+	}
+	if chunk.StartIndex >= start {
+		return ""
+	}
+	return string([]rune(chunk.Content)[:start-chunk.StartIndex])
+}
+
+// textAfter 分块在end之后的部分，分块完全位于end之前时为空
+func textAfter(chunk *domain.Chunk, end int) string {
+	if chunk.StartIndex >= end {
+		return chunk.Content
+	}
+	if chunk.EndIndex <= end {
+		return ""
+	}
+	return string([]rune(chunk.Content)[end-chunk.StartIndex:])
+}
+
+// estimateTokens 与Chunk.CalculateTokenCount相同的估算方式
+func estimateTokens(text string) int {
+	return len(text) / 4
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// memKnowledgeBaseRepository 内存知识库仓储
+type memKnowledgeBaseRepository struct {
+	repository.KnowledgeBaseRepository
+	knowledgeBases map[string]*domain.KnowledgeBase
+}
+
+func (r *memKnowledgeBaseRepository) FindByID(ctx context.Context, id string) (*domain.KnowledgeBase, error) {
+	kb, ok := r.knowledgeBases[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *kb
+	return &copied, nil
+}
+
+// hashDocumentRepository 只支持按哈希去重的文档仓储，与数据库一样保证同一知识库内哈希唯一
+// FindByID始终返回未找到，使AddDocument触发的后台索引直接结束
+type hashDocumentRepository struct {
+	repository.DocumentRepository
+	mu        sync.Mutex
+	documents []*domain.Document
+}
+
+func (r *hashDocumentRepository) Save(ctx context.Context, doc *domain.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.documents {
+		if existing.KnowledgeBaseID == doc.KnowledgeBaseID && existing.Hash == doc.Hash {
+			return repository.ErrDuplicateKey
+		}
+	}
+	r.documents = append(r.documents, doc)
+	return nil
+}
+
+func (r *hashDocumentRepository) FindByHash(ctx context.Context, knowledgeBaseID, hash string) (*domain.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, doc := range r.documents {
+		if doc.KnowledgeBaseID == knowledgeBaseID && doc.Hash == hash {
+			return doc, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *hashDocumentRepository) FindByID(ctx context.Context, id string) (*domain.Document, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *hashDocumentRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.documents)
+}
+
+// newDedupTestService 创建包含两个知识库的服务，返回以所有者身份发起请求的上下文
+func newDedupTestService(t *testing.T) (*RAGService, *hashDocumentRepository, context.Context) {
+	t.Helper()
+	kbRepo := &memKnowledgeBaseRepository{knowledgeBases: map[string]*domain.KnowledgeBase{}}
+	for _, id := range []string{"kb-1", "kb-2"} {
+		kb, err := domain.NewKnowledgeBase(id, "", "owner")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kb.ID = id
+		kbRepo.knowledgeBases[id] = kb
+	}
+	docRepo := &hashDocumentRepository{}
+	service := NewRAGService(kbRepo, docRepo, nil, nil, nil, noopEmbeddingCache{}, NewDefaultChunkingService(nil), nil, nil,
+		ImageExtractionConfig{}, nil, DefaultSearchConfig(), StreamIngestionConfig{}, EmbeddingBatchConfig{},
+		AccessControlConfig{}, nil, zap.NewNop())
+	return service, docRepo, WithUserID(context.Background(), "owner")
+}
+
+func newAddDocumentCommand(knowledgeBaseID, content string) *AddDocumentCommand {
+	return &AddDocumentCommand{
+		Title:           "部署手册",
+		Content:         content,
+		Type:            domain.DocumentTypeText,
+		KnowledgeBaseID: knowledgeBaseID,
+	}
+}
+
+func TestAddDocumentExactDuplicate(t *testing.T) {
+	service, docRepo, ctx := newDedupTestService(t)
+
+	original, err := service.AddDocument(ctx, newAddDocumentCommand("kb-1", "部署步骤：先构建镜像，再更新服务。"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.AddDocument(ctx, newAddDocumentCommand("kb-1", "部署步骤：先构建镜像，再更新服务。"))
+	var domainErr *domain.DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != domain.ErrDocumentAlreadyExists {
+		t.Fatalf("expected duplicate document error, got %v", err)
+	}
+
+	cmd := newAddDocumentCommand("kb-1", "部署步骤：先构建镜像，再更新服务。")
+	cmd.SkipDuplicates = true
+	existing, err := service.AddDocument(ctx, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing.ID != original.ID {
+		t.Fatalf("skip duplicates returned %s, want existing document %s", existing.ID, original.ID)
+	}
+
+	// 去重只在同一知识库内进行
+	if _, err := service.AddDocument(ctx, newAddDocumentCommand("kb-2", "部署步骤：先构建镜像，再更新服务。")); err != nil {
+		t.Fatalf("same content in another knowledge base: %v", err)
+	}
+	if n := docRepo.count(); n != 2 {
+		t.Fatalf("saved %d documents, want 2", n)
+	}
+}
+
+func TestAddDocumentNearDuplicateIsIndexed(t *testing.T) {
+	service, docRepo, ctx := newDedupTestService(t)
+
+	first, err := service.AddDocument(ctx, newAddDocumentCommand("kb-1", "部署步骤：先构建镜像，再更新服务。"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只差一个字符的内容不是重复文档
+	second, err := service.AddDocument(ctx, newAddDocumentCommand("kb-1", "部署步骤：先构建镜像，再更新服务！"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID || first.Hash == second.Hash {
+		t.Fatalf("near duplicate shares id or hash with the original: %s %s", first.Hash, second.Hash)
+	}
+	if n := docRepo.count(); n != 2 {
+		t.Fatalf("saved %d documents, want 2", n)
+	}
+}
+
+func TestAddDocumentConcurrentDuplicates(t *testing.T) {
+	service, docRepo, ctx := newDedupTestService(t)
+
+	const n = 8
+	var wg sync.WaitGroup
+	ids := make([]string, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := newAddDocumentCommand("kb-1", "同时上传的相同内容")
+			cmd.SkipDuplicates = true
+			doc, err := service.AddDocument(ctx, cmd)
+			if doc != nil {
+				ids[i] = doc.ID
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	// 并发添加的相同内容只保存一份，都返回这一份
+	for i := 0; i < n; i++ {
+		if errs[i] != nil || ids[i] != ids[0] {
+			t.Fatalf("request %d: id = %s, err = %v, want %s", i, ids[i], errs[i], ids[0])
+		}
+	}
+	if count := docRepo.count(); count != 1 {
+		t.Fatalf("saved %d documents, want 1", count)
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// memDocumentRepository 在hashDocumentRepository的基础上支持按ID查找和更新
+type memDocumentRepository struct {
+	hashDocumentRepository
+}
+
+func (r *memDocumentRepository) FindByID(ctx context.Context, id string) (*domain.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, doc := range r.documents {
+		if doc.ID == id {
+			copied := *doc
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memDocumentRepository) Update(ctx context.Context, doc *domain.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.documents {
+		if existing.ID == doc.ID {
+			copied := *doc
+			r.documents[i] = &copied
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// memChunkRepository 内存分块仓储
+type memChunkRepository struct {
+	repository.ChunkRepository
+	chunks map[string]*domain.Chunk
+}
+
+func (r *memChunkRepository) FindByDocumentID(ctx context.Context, documentID string) ([]*domain.Chunk, error) {
+	var chunks []*domain.Chunk
+	for _, chunk := range r.chunks {
+		if chunk.DocumentID == documentID {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+func (r *memChunkRepository) SaveBatch(ctx context.Context, chunks []*domain.Chunk) error {
+	for _, chunk := range chunks {
+		r.chunks[chunk.ID] = chunk
+	}
+	return nil
+}
+
+func (r *memChunkRepository) UpdateBatch(ctx context.Context, chunks []*domain.Chunk) error {
+	return r.SaveBatch(ctx, chunks)
+}
+
+func (r *memChunkRepository) DeleteByDocumentID(ctx context.Context, documentID string) error {
+	for id, chunk := range r.chunks {
+		if chunk.DocumentID == documentID {
+			delete(r.chunks, id)
+		}
+	}
+	return nil
+}
+
+// memVectorRepository 内存向量仓储，只记录每个索引中的向量ID
+type memVectorRepository struct {
+	repository.VectorRepository
+	indexes map[string]map[string]bool
+}
+
+func (r *memVectorRepository) Upsert(ctx context.Context, indexName string, records []repository.VectorRecord) error {
+	if r.indexes[indexName] == nil {
+		r.indexes[indexName] = make(map[string]bool)
+	}
+	for _, record := range records {
+		r.indexes[indexName][record.ID] = true
+	}
+	return nil
+}
+
+func (r *memVectorRepository) Delete(ctx context.Context, indexName string, ids []string) error {
+	for _, id := range ids {
+		delete(r.indexes[indexName], id)
+	}
+	return nil
+}
+
+// ids 返回索引中的向量ID
+func (r *memVectorRepository) ids(indexName string) []string {
+	ids := make([]string, 0, len(r.indexes[indexName]))
+	for id := range r.indexes[indexName] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// chunkIDs 返回仓储中文档的分块ID
+func chunkIDs(t *testing.T, chunkRepo *memChunkRepository, documentID string) []string {
+	t.Helper()
+	chunks, _ := chunkRepo.FindByDocumentID(context.Background(), documentID)
+	if len(chunks) == 0 {
+		t.Fatalf("document %s has no chunks", documentID)
+	}
+	ids := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		ids[i] = chunk.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestReindexDocumentReplacesChunksAndVectors(t *testing.T) {
+	kb, err := domain.NewKnowledgeBase("kb", "", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kbRepo := &memKnowledgeBaseRepository{knowledgeBases: map[string]*domain.KnowledgeBase{kb.ID: kb}}
+	docRepo := &memDocumentRepository{}
+	chunkRepo := &memChunkRepository{chunks: map[string]*domain.Chunk{}}
+	vectorRepo := &memVectorRepository{indexes: map[string]map[string]bool{}}
+	config := DefaultChunkingConfig()
+	config.ChunkSize = 20
+	config.ChunkOverlap = 0
+	service := NewRAGService(kbRepo, docRepo, chunkRepo, vectorRepo, &recordingEmbedder{}, noopEmbeddingCache{},
+		NewDefaultChunkingService(config), nil, nil, ImageExtractionConfig{}, nil, DefaultSearchConfig(),
+		StreamIngestionConfig{}, EmbeddingBatchConfig{}, AccessControlConfig{}, nil, zap.NewNop())
+	ctx := WithUserID(context.Background(), "owner")
+	indexName := service.getIndexName(kb.ID)
+
+	doc, err := domain.NewDocument("手册", "The first version of the manual. It has two sentences.", domain.DocumentTypeText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.KnowledgeBaseID = kb.ID
+	if err := docRepo.Save(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ProcessDocument(ctx, doc.ID); err != nil {
+		t.Fatal(err)
+	}
+	oldIDs := chunkIDs(t, chunkRepo, doc.ID)
+	if got := vectorRepo.ids(indexName); !reflect.DeepEqual(got, oldIDs) {
+		t.Fatalf("vectors = %v, want %v", got, oldIDs)
+	}
+
+	// 修改内容后重新索引
+	stored, _ := docRepo.FindByID(ctx, doc.ID)
+	stored.UpdateContent("", "The second version replaces the manual. It is longer than before. Much longer.")
+	if err := docRepo.Update(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReindexDocument(ctx, doc.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	newIDs := chunkIDs(t, chunkRepo, doc.ID)
+	for _, id := range oldIDs {
+		for _, newID := range newIDs {
+			if id == newID {
+				t.Fatalf("old chunk %s was not replaced", id)
+			}
+		}
+	}
+	if got := vectorRepo.ids(indexName); !reflect.DeepEqual(got, newIDs) {
+		t.Fatalf("vectors after reindex = %v, want only the new chunks %v", got, newIDs)
+	}
+
+	reindexed, _ := docRepo.FindByID(ctx, doc.ID)
+	if reindexed.Status != domain.DocumentStatusIndexed || reindexed.IndexedAt == nil {
+		t.Fatalf("status = %s, indexed_at = %v", reindexed.Status, reindexed.IndexedAt)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/retry"
+	"go.uber.org/zap"
+)
+
+// EmbeddingBatchConfig 嵌入请求分批配置
+// 嵌入提供商限制单次请求的文本数和令牌数，超过时整批被拒绝；未命中缓存的文本按此拆分为子批次依次请求
+type EmbeddingBatchConfig struct {
+	MaxInputs  int           // 单次请求的最大文本数
+	MaxTokens  int           // 单次请求的最大令牌数，按与Chunk.CalculateTokenCount相同的方式估算；单个文本超过时单独请求
+	MaxRetries int           // 子批次失败后的最大重试次数，已成功的子批次不重新请求
+	RetryDelay time.Duration // 首次重试等待时间，之后按指数退避
+}
+
+// DefaultEmbeddingBatchConfig 默认分批配置，与OpenAI嵌入接口的单次请求上限一致
+func DefaultEmbeddingBatchConfig() EmbeddingBatchConfig {
+	return EmbeddingBatchConfig{
+		MaxInputs:  2048,
+		MaxTokens:  300000,
+		MaxRetries: 2,
+		RetryDelay: time.Second,
+	}
+}
+
+// embeddingBatch 子批次在文本列表中的范围[start, end)
+type embeddingBatch struct {
+	start int
+	end   int
+}
+
+// splitEmbeddingBatches 按顺序将文本拆分为不超过最大文本数和最大令牌数的子批次，小于等于0的限制不生效
+func splitEmbeddingBatches(texts []string, maxInputs, maxTokens int) []embeddingBatch {
+	var batches []embeddingBatch
+	start, tokens := 0, 0
+	for i, text := range texts {
+		textTokens := estimateTokens(text)
+		full := (maxInputs > 0 && i-start >= maxInputs) || (maxTokens > 0 && tokens+textTokens > maxTokens)
+		if i > start && full {
+			batches = append(batches, embeddingBatch{start: start, end: i})
+			start, tokens = i, 0
+		}
+		tokens += textTokens
+	}
+	if start < len(texts) {
+		batches = append(batches, embeddingBatch{start: start, end: len(texts)})
+	}
+	return batches
+}
+
+// embedBatch 请求一个子批次的嵌入向量，失败时按配置退避重试该子批次
+func (s *RAGService) embedBatch(ctx context.Context, embeddingService EmbeddingService, texts []string, batch embeddingBatch, total int) ([][]float32, error) {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = s.embeddingBatch.MaxRetries + 1
+	if s.embeddingBatch.RetryDelay > 0 {
+		policy.InitialDelay = s.embeddingBatch.RetryDelay
+	}
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		s.logger.Warn("Retrying embedding batch",
+			zap.Int("start", batch.start),
+			zap.Int("end", batch.end),
+			zap.Int("total", total),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+	}
+
+	var embeddings [][]float32
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		start := time.Now()
+		generated, err := embeddingService.GenerateEmbeddings(ctx, texts)
+		if s.metrics != nil {
+			s.metrics.ObserveEmbedding(metrics.EmbeddingOperationDocument, time.Since(start))
+		}
+		if err != nil {
+			return err
+		}
+		if len(generated) != len(texts) {
+			return fmt.Errorf("embedding provider returned %d embeddings for %d texts", len(generated), len(texts))
+		}
+		embeddings = generated
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings for texts %d-%d of %d: %w", batch.start, batch.end, total, err)
+	}
+	return embeddings, nil
+}
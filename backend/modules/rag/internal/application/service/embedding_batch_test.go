@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/cache"
+	"go.uber.org/zap"
+)
+
+// limitedEmbedder 模拟限制单次请求文本数的嵌入提供商，超过限制时整批拒绝
+type limitedEmbedder struct {
+	recordingEmbedder
+	maxInputs int
+	failures  map[string]int // 以该文本开头的请求还要失败的次数
+}
+
+func (e *limitedEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	e.requests = append(e.requests, append([]string(nil), texts...))
+	if len(texts) > e.maxInputs {
+		return nil, fmt.Errorf("batch of %d inputs exceeds limit %d", len(texts), e.maxInputs)
+	}
+	if e.failures[texts[0]] > 0 {
+		e.failures[texts[0]]--
+		return nil, fmt.Errorf("provider unavailable")
+	}
+
+	// 向量首位是文本编号，便于检查结果顺序
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		var id int
+		fmt.Sscanf(text, "text-%d", &id)
+		embeddings[i] = []float32{float32(id), 1, 0}
+	}
+	return embeddings, nil
+}
+
+func newEmbeddingBatchTestService(embedder EmbeddingService, maxInputs, maxRetries int) *RAGService {
+	embeddingCache := NewContentEmbeddingCache(cache.NewMemoryCache(100, 0), DefaultEmbeddingCacheConfig(), zap.NewNop())
+	batchConfig := EmbeddingBatchConfig{MaxInputs: maxInputs, MaxRetries: maxRetries, RetryDelay: time.Millisecond}
+	return NewRAGService(nil, nil, nil, nil, embedder, embeddingCache, NewDefaultChunkingService(nil), nil, nil,
+		ImageExtractionConfig{}, nil, DefaultSearchConfig(), StreamIngestionConfig{}, batchConfig,
+		AccessControlConfig{}, nil, zap.NewNop())
+}
+
+func batchTexts(ids ...int) []string {
+	texts := make([]string, len(ids))
+	for i, id := range ids {
+		texts[i] = fmt.Sprintf("text-%d", id)
+	}
+	return texts
+}
+
+func TestSplitEmbeddingBatches(t *testing.T) {
+	// 估算令牌数分别为1、2、10、1
+	texts := []string{"aaaa", "aaaaaaaa", string(make([]byte, 40)), "aaaa"}
+
+	tests := []struct {
+		name      string
+		maxInputs int
+		maxTokens int
+		want      []embeddingBatch
+	}{
+		{name: "no limits", want: []embeddingBatch{{0, 4}}},
+		{name: "max inputs", maxInputs: 3, want: []embeddingBatch{{0, 3}, {3, 4}}},
+		{name: "max tokens", maxTokens: 3, want: []embeddingBatch{{0, 2}, {2, 3}, {3, 4}}},
+		{name: "both limits", maxInputs: 1, maxTokens: 100, want: []embeddingBatch{{0, 1}, {1, 2}, {2, 3}, {3, 4}}},
+	}
+	for _, tt := range tests {
+		if got := splitEmbeddingBatches(texts, tt.maxInputs, tt.maxTokens); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: batches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := splitEmbeddingBatches(nil, 3, 3); len(got) != 0 {
+		t.Errorf("empty input: batches = %v", got)
+	}
+}
+
+func TestEmbedTextsSplitsAndRetriesFailedBatch(t *testing.T) {
+	embedder := &limitedEmbedder{maxInputs: 3, failures: map[string]int{"text-3": 1}}
+	service := newEmbeddingBatchTestService(embedder, 3, 2)
+
+	texts := batchTexts(0, 1, 2, 3, 4, 5, 6)
+	embeddings, err := service.embedTexts(context.Background(), embedder, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按顺序拆分，只重试失败的子批次
+	want := [][]string{batchTexts(0, 1, 2), batchTexts(3, 4, 5), batchTexts(3, 4, 5), batchTexts(6)}
+	if !reflect.DeepEqual(embedder.requests, want) {
+		t.Fatalf("requests = %v, want %v", embedder.requests, want)
+	}
+	if len(embeddings) != len(texts) {
+		t.Fatalf("got %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	for i, embedding := range embeddings {
+		if embedding[0] != float32(i) {
+			t.Fatalf("embedding %d belongs to text-%v", i, embedding[0])
+		}
+	}
+}
+
+func TestEmbedTextsKeepsSucceededBatchesAfterFailure(t *testing.T) {
+	embedder := &limitedEmbedder{maxInputs: 3, failures: map[string]int{"text-3": 3}}
+	service := newEmbeddingBatchTestService(embedder, 3, 2)
+	ctx := context.Background()
+	texts := batchTexts(0, 1, 2, 3, 4, 5, 6)
+
+	// 重试用尽后返回错误，不再请求后续子批次
+	if _, err := service.embedTexts(ctx, embedder, texts); err == nil {
+		t.Fatal("expected an error after the retries were exhausted")
+	}
+	want := [][]string{batchTexts(0, 1, 2), batchTexts(3, 4, 5), batchTexts(3, 4, 5), batchTexts(3, 4, 5)}
+	if !reflect.DeepEqual(embedder.requests, want) {
+		t.Fatalf("requests = %v, want %v", embedder.requests, want)
+	}
+
+	// 再次处理时已成功的子批次从缓存读取
+	embedder.requests = nil
+	embeddings, err := service.embedTexts(ctx, embedder, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{batchTexts(3, 4, 5), batchTexts(6)}; !reflect.DeepEqual(embedder.requests, want) {
+		t.Fatalf("requests = %v, want %v", embedder.requests, want)
+	}
+	for i, embedding := range embeddings {
+		if embedding[0] != float32(i) {
+			t.Fatalf("embedding %d belongs to text-%v", i, embedding[0])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/cache"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// ContentEmbeddingCache 按内容哈希缓存嵌入向量，避免为相同内容重复调用嵌入提供商
+// 缓存读写失败只影响命中率，不影响文档处理
+type ContentEmbeddingCache interface {
+	// Get 获取缓存的嵌入向量
+	Get(ctx context.Context, key string) ([]float32, bool)
+
+	// Set 缓存嵌入向量
+	Set(ctx context.Context, key string, embedding []float32)
+}
+
+// EmbeddingCacheKey 生成嵌入缓存键：内容的sha256 + 模型 + 维度
+// 同一内容在不同模型或维度下的向量互不复用
+func EmbeddingCacheKey(content, model string, dimension int) string {
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf("embedding:%s:%s:%d", hex.EncodeToString(sum[:]), model, dimension)
+}
+
+// EmbeddingCacheConfig 嵌入缓存配置
+type EmbeddingCacheConfig struct {
+	Enabled bool
+	TTL     time.Duration // 缓存过期时间，0表示使用缓存后端的默认值
+}
+
+// DefaultEmbeddingCacheConfig 默认嵌入缓存配置：启用，缓存7天
+func DefaultEmbeddingCacheConfig() EmbeddingCacheConfig {
+	return EmbeddingCacheConfig{
+		Enabled: true,
+		TTL:     7 * 24 * time.Hour,
+	}
+}
+
+// sharedEmbeddingCache 基于共享缓存（进程内LRU或Redis）的嵌入缓存
+type sharedEmbeddingCache struct {
+	cache  cache.Cache
+	ttl    time.Duration
+	logger infrastructure.Logger
+}
+
+// NewContentEmbeddingCache 创建嵌入缓存，c为nil时返回不缓存的实现
+func NewContentEmbeddingCache(c cache.Cache, config EmbeddingCacheConfig, logger infrastructure.Logger) ContentEmbeddingCache {
+	if c == nil || !config.Enabled {
+		return noopEmbeddingCache{}
+	}
+	return &sharedEmbeddingCache{
+		cache:  c,
+		ttl:    config.TTL,
+		logger: logger,
+	}
+}
+
+// Get 获取缓存的嵌入向量
+func (c *sharedEmbeddingCache) Get(ctx context.Context, key string) ([]float32, bool) {
+	data, err := c.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrNotFound) {
+			c.logger.Warn("Failed to read embedding cache", zap.String("key", key), zap.Error(err))
+		}
+		return nil, false
+	}
+
+	embedding, err := decodeEmbedding(data)
+	if err != nil {
+		c.logger.Warn("Discarding corrupted embedding cache entry", zap.String("key", key), zap.Error(err))
+		return nil, false
+	}
+	return embedding, true
+}
+
+// Set 缓存嵌入向量
+func (c *sharedEmbeddingCache) Set(ctx context.Context, key string, embedding []float32) {
+	if err := c.cache.Set(ctx, key, encodeEmbedding(embedding), c.ttl); err != nil {
+		c.logger.Warn("Failed to write embedding cache", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// encodeEmbedding 将向量编码为小端序的float32字节
+func encodeEmbedding(embedding []float32) []byte {
+	data := make([]byte, 4*len(embedding))
+	for i, value := range embedding {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(value))
+	}
+	return data
+}
+
+// decodeEmbedding 解码encodeEmbedding编码的向量
+func decodeEmbedding(data []byte) ([]float32, error) {
+	if len(data) == 0 || len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding length %d", len(data))
+	}
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return embedding, nil
+}
+
+// noopEmbeddingCache 不缓存的实现，用于禁用嵌入缓存
+type noopEmbeddingCache struct{}
+
+func (noopEmbeddingCache) Get(ctx context.Context, key string) ([]float32, bool) { return nil, false }
+
+func (noopEmbeddingCache) Set(ctx context.Context, key string, embedding []float32) {}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/noah-loop/backend/shared/pkg/cache"
+	"go.uber.org/zap"
+)
+
+// recordingEmbedder 记录发送给提供商的文本，按文本长度生成向量
+type recordingEmbedder struct {
+	EmbeddingService
+	requests [][]string
+}
+
+func (e *recordingEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	e.requests = append(e.requests, append([]string(nil), texts...))
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = []float32{float32(len(text)), 1, 0}
+	}
+	return embeddings, nil
+}
+
+func (e *recordingEmbedder) GetModel() string { return "test-model" }
+
+func (e *recordingEmbedder) GetDimension() int { return 3 }
+
+func (e *recordingEmbedder) ForLanguage(language string) EmbeddingService { return e }
+
+func newEmbeddingCacheTestService(embedder EmbeddingService) *RAGService {
+	embeddingCache := NewContentEmbeddingCache(cache.NewMemoryCache(100, 0), DefaultEmbeddingCacheConfig(), zap.NewNop())
+	return NewRAGService(nil, nil, nil, nil, embedder, embeddingCache, NewDefaultChunkingService(nil), nil, nil,
+		ImageExtractionConfig{}, nil, DefaultSearchConfig(), StreamIngestionConfig{}, EmbeddingBatchConfig{},
+		AccessControlConfig{}, nil, zap.NewNop())
+}
+
+func TestEmbedTextsOnlySendsCacheMisses(t *testing.T) {
+	embedder := &recordingEmbedder{}
+	service := newEmbeddingCacheTestService(embedder)
+	ctx := context.Background()
+
+	// 同一批次中相同的内容只请求一次
+	embeddings, err := service.embedTexts(ctx, embedder, []string{"a", "bb", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embeddings) != 3 || embeddings[0][0] != 1 || embeddings[1][0] != 2 || embeddings[2][0] != 1 {
+		t.Fatalf("embeddings = %v", embeddings)
+	}
+
+	// 重新索引时只请求未缓存的内容
+	embeddings, err = service.embedTexts(ctx, embedder, []string{"bb", "ccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embeddings[0][0] != 2 || embeddings[1][0] != 3 {
+		t.Fatalf("embeddings = %v", embeddings)
+	}
+
+	// 全部命中时不调用提供商
+	if _, err := service.embedTexts(ctx, embedder, []string{"ccc", "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"a", "bb"}, {"ccc"}}
+	if !reflect.DeepEqual(embedder.requests, want) {
+		t.Fatalf("provider requests = %q, want %q", embedder.requests, want)
+	}
+}
+
+func TestEmbeddingCacheKey(t *testing.T) {
+	key := EmbeddingCacheKey("内容", "model-a", 768)
+	if key != EmbeddingCacheKey("内容", "model-a", 768) {
+		t.Fatal("key should be deterministic")
+	}
+	for _, other := range []string{
+		EmbeddingCacheKey("内容 ", "model-a", 768),
+		EmbeddingCacheKey("内容", "model-b", 768),
+		EmbeddingCacheKey("内容", "model-a", 1024),
+	} {
+		if other == key {
+			t.Fatalf("content, model and dimension should all change the key: %s", key)
+		}
+	}
+}
+
+func TestSharedEmbeddingCache(t *testing.T) {
+	ctx := context.Background()
+	backend := cache.NewMemoryCache(10, 0)
+	embeddingCache := NewContentEmbeddingCache(backend, DefaultEmbeddingCacheConfig(), zap.NewNop())
+
+	embeddingCache.Set(ctx, "k", []float32{0.25, -1, 3.5})
+	if got, ok := embeddingCache.Get(ctx, "k"); !ok || !reflect.DeepEqual(got, []float32{0.25, -1, 3.5}) {
+		t.Fatalf("get = %v, %v", got, ok)
+	}
+	if _, ok := embeddingCache.Get(ctx, "missing"); ok {
+		t.Fatal("missing key should not hit")
+	}
+
+	// 损坏的缓存条目视为未命中
+	if err := backend.Set(ctx, "bad", []byte{1, 2, 3}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := embeddingCache.Get(ctx, "bad"); ok {
+		t.Fatal("corrupted entry should not hit")
+	}
+
+	disabled := NewContentEmbeddingCache(backend, EmbeddingCacheConfig{}, zap.NewNop())
+	if _, ok := disabled.Get(ctx, "k"); ok {
+		t.Fatal("disabled cache should never hit")
+	}
+}
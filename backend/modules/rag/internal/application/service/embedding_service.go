@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/retry"
 )
 
 // EmbeddingService 嵌入向量服务接口
@@ -21,6 +25,9 @@ type EmbeddingService interface {
 	
 	// ValidateEmbedding 验证嵌入向量
 	ValidateEmbedding(embedding []float32) error
+	
+	// ForLanguage 获取适用于指定语言的嵌入服务，未配置语言专用模型时返回自身
+	ForLanguage(language string) EmbeddingService
 }
 
 // EmbeddingProvider 嵌入向量提供商
@@ -42,7 +49,25 @@ type EmbeddingConfig struct {
 	Dimension   int              `json:"dimension"`
 	MaxTokens   int              `json:"max_tokens"`
 	BatchSize   int              `json:"batch_size"`
-	Timeout     int              `json:"timeout"` // 秒
+	Timeout     int              `json:"timeout"`     // 单次请求超时，秒
+	MaxRetries  int              `json:"max_retries"` // 临时错误（超时、限流、5xx）的最大重试次数
+	RetryDelay  int              `json:"retry_delay"` // 首次重试等待时间，毫秒，之后按指数退避
+	LanguageModels map[string]LanguageModel `json:"language_models,omitempty"` // 主语言代码 -> 语言专用模型
+}
+
+// LanguageModel 语言专用的嵌入模型，与默认模型使用同一服务地址
+type LanguageModel struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"` // 为0时与默认模型相同
+}
+
+// LanguageModel 获取语言专用模型
+func (c *EmbeddingConfig) LanguageModel(language string) (LanguageModel, bool) {
+	model, exists := c.LanguageModels[domain.LanguageBase(language)]
+	if !exists || model.Model == "" {
+		return LanguageModel{}, false
+	}
+	return model, true
 }
 
 // DefaultEmbeddingConfig 默认配置
@@ -54,6 +79,8 @@ func DefaultEmbeddingConfig() *EmbeddingConfig {
 		MaxTokens:  8191,
 		BatchSize:  100,
 		Timeout:    30,
+		MaxRetries: 3,
+		RetryDelay: 500,
 	}
 }
 
@@ -79,9 +106,24 @@ func (c *EmbeddingConfig) Validate() error {
 		return fmt.Errorf("batch size must be positive")
 	}
 	
+	if c.Timeout < 0 || c.MaxRetries < 0 || c.RetryDelay < 0 {
+		return fmt.Errorf("timeout and retry settings must not be negative")
+	}
+	
 	return nil
 }
 
+// RetryPolicy 根据配置生成重试策略
+func (c *EmbeddingConfig) RetryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = c.MaxRetries + 1
+	policy.AttemptTimeout = time.Duration(c.Timeout) * time.Second
+	if c.RetryDelay > 0 {
+		policy.InitialDelay = time.Duration(c.RetryDelay) * time.Millisecond
+	}
+	return policy
+}
+
 // EmbeddingMetrics 嵌入指标
 type EmbeddingMetrics struct {
 	TotalRequests     int64   `json:"total_requests"`
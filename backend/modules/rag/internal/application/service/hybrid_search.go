@@ -0,0 +1,101 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSearchKeywords 关键词检索最多使用的关键词数
+const maxSearchKeywords = 8
+
+// SearchConfig 搜索配置
+type SearchConfig struct {
+	HybridVectorWeight float64 // 混合检索中向量结果的融合权重，关键词结果的权重为 1-HybridVectorWeight
+	RRFK               int     // 倒数排名融合的平滑常数，越大排名靠后的结果占比越高
+}
+
+// DefaultSearchConfig 默认搜索配置
+func DefaultSearchConfig() SearchConfig {
+	return SearchConfig{
+		HybridVectorWeight: 0.5,
+		RRFK:               60,
+	}
+}
+
+// rankedCandidate 融合排序后的候选分块
+type rankedCandidate struct {
+	chunkID string
+	score   float32
+}
+
+// fuseRankings 使用加权倒数排名融合（RRF）合并向量和关键词检索的排名
+// 同一分块只出现一次；分数按两路均排第一时的最大分数归一化到 (0, 1]
+func fuseRankings(vectorIDs, keywordIDs []string, vectorWeight float64, k int) []rankedCandidate {
+	if k <= 0 {
+		k = DefaultSearchConfig().RRFK
+	}
+	if vectorWeight < 0 {
+		vectorWeight = 0
+	} else if vectorWeight > 1 {
+		vectorWeight = 1
+	}
+
+	scores := make(map[string]float64, len(vectorIDs)+len(keywordIDs))
+	order := make([]string, 0, len(vectorIDs)+len(keywordIDs))
+	accumulate := func(ids []string, weight float64) {
+		for rank, id := range ids {
+			if _, exists := scores[id]; !exists {
+				order = append(order, id)
+			}
+			scores[id] += weight * float64(k+1) / float64(k+rank+1)
+		}
+	}
+	accumulate(vectorIDs, vectorWeight)
+	accumulate(keywordIDs, 1-vectorWeight)
+
+	candidates := make([]rankedCandidate, len(order))
+	for i, id := range order {
+		candidates[i] = rankedCandidate{chunkID: id, score: float32(scores[id])}
+	}
+	// 稳定排序，分数相同时向量结果在前
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	return candidates
+}
+
+// extractKeywords 从查询文本中提取关键词
+// 按空白和标点切分，保留连字符、下划线和点号以匹配产品编号等标识；单个字母或数字被忽略
+func extractKeywords(query string) []string {
+	fields := strings.FieldsFunc(query, func(r rune) bool {
+		if r == '-' || r == '_' || r == '.' {
+			return false
+		}
+		return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+
+	keywords := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		field = strings.Trim(field, "-_.")
+		key := strings.ToLower(field)
+		if seen[key] || (utf8.RuneCountInString(field) < 2 && !isCJKRune(field)) {
+			continue
+		}
+		seen[key] = true
+		keywords = append(keywords, field)
+		if len(keywords) == maxSearchKeywords {
+			break
+		}
+	}
+	return keywords
+}
+
+// isCJKRune 判断文本是否为单个中日韩字符，这类单字也有检索意义
+func isCJKRune(text string) bool {
+	r, size := utf8.DecodeRuneInString(text)
+	return size == len(text) && (unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r))
+}
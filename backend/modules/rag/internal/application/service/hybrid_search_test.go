@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// candidateIDs 返回融合结果的分块ID顺序
+func candidateIDs(candidates []rankedCandidate) []string {
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.chunkID
+	}
+	return ids
+}
+
+func TestFuseRankingsAcrossModes(t *testing.T) {
+	vectorIDs := []string{"a", "b", "c"}
+	keywordIDs := []string{"c", "d", "a"}
+
+	cases := []struct {
+		name   string
+		weight float64
+		want   []string
+	}{
+		// 只看向量排名，仅关键词命中的分块排在最后
+		{"vector only", 1, []string{"a", "b", "c", "d"}},
+		// 只看关键词排名
+		{"keyword only", 0, []string{"c", "d", "a", "b"}},
+		// 两路都命中的分块排在前面，分数相同时向量结果在前
+		{"hybrid", 0.5, []string{"a", "c", "b", "d"}},
+		// 超出范围的权重被截断
+		{"clamped", 2, []string{"a", "b", "c", "d"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			candidates := fuseRankings(vectorIDs, keywordIDs, c.weight, 60)
+			if got := candidateIDs(candidates); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("ranking = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFuseRankingsScores(t *testing.T) {
+	// 两路均排第一的分块得到满分
+	candidates := fuseRankings([]string{"x", "y"}, []string{"x"}, 0.5, 60)
+	if len(candidates) != 2 || candidates[0].chunkID != "x" || candidates[0].score != 1 {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+	if want := float32(0.5 * 61.0 / 62.0); candidates[1].score != want {
+		t.Fatalf("second score = %v, want %v", candidates[1].score, want)
+	}
+
+	// 未配置平滑常数时使用默认值
+	candidates = fuseRankings([]string{"x", "y"}, nil, 1, 0)
+	if want := float32(61.0 / 62.0); candidates[1].score != want {
+		t.Fatalf("default k score = %v, want %v", candidates[1].score, want)
+	}
+
+	if candidates := fuseRankings(nil, nil, 0.5, 60); len(candidates) != 0 {
+		t.Fatalf("empty rankings = %+v", candidates)
+	}
+}
+
+func TestExtractKeywords(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		// 保留产品编号和版本号，忽略单个字母
+		{"AB-123 price for x v2.0?", []string{"AB-123", "price", "for", "v2.0"}},
+		// 中文单字有检索意义
+		{"查询 的 价格，库存", []string{"查询", "的", "价格", "库存"}},
+		// 忽略大小写去重，去掉首尾的连接符
+		{"API api --api_key-- Api", []string{"API", "api_key"}},
+		{"  ,. ", []string{}},
+	}
+	for _, c := range cases {
+		if got := extractKeywords(c.query); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%q: keywords = %q, want %q", c.query, got, c.want)
+		}
+	}
+
+	words := make([]string, 12)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	if got := extractKeywords(strings.Join(words, " ")); !reflect.DeepEqual(got, words[:maxSearchKeywords]) {
+		t.Fatalf("keywords = %q, want the first %d", got, maxSearchKeywords)
+	}
+}
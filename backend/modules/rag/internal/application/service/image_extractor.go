@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/textformat"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ImageReference 待识别的图片
+type ImageReference struct {
+	URL     string // 图片地址或data URI
+	Index   int    // 图片在文档中的序号，从0开始
+	AltText string // 文档中的替代文字
+}
+
+// ImageText 图片识别结果
+type ImageText struct {
+	Text    string // 图片中的文字（OCR）
+	Caption string // 图片内容的简短描述
+}
+
+// ImageTextExtractor 图片文字提取器接口
+// 识别图片中的文字并生成描述，用于为图片生成可检索的分块
+type ImageTextExtractor interface {
+	ExtractText(ctx context.Context, image ImageReference) (*ImageText, error)
+}
+
+// NoopImageTextExtractor 不识别图片内容、只以替代文字作为描述的提取器，未配置视觉模型时使用
+type NoopImageTextExtractor struct{}
+
+// NewNoopImageTextExtractor 创建只使用替代文字的提取器
+func NewNoopImageTextExtractor() *NoopImageTextExtractor {
+	return &NoopImageTextExtractor{}
+}
+
+// ExtractText 返回图片的替代文字
+func (e *NoopImageTextExtractor) ExtractText(ctx context.Context, image ImageReference) (*ImageText, error) {
+	return &ImageText{Caption: strings.TrimSpace(image.AltText)}, nil
+}
+
+// ImageExtractionConfig 图片文字识别配置
+type ImageExtractionConfig struct {
+	APIBase              string        // 视觉模型服务地址（OpenAI兼容），为空时只使用替代文字
+	APIKey               string        // API密钥
+	Model                string        // 视觉模型
+	Timeout              time.Duration // 单张图片的识别超时
+	MaxImagesPerDocument int           // 每个文档识别的内嵌图片上限，0表示不识别内嵌图片
+}
+
+// DefaultImageExtractionConfig 默认图片文字识别配置
+func DefaultImageExtractionConfig() ImageExtractionConfig {
+	return ImageExtractionConfig{
+		Model:                "gpt-4o-mini",
+		Timeout:              60 * time.Second,
+		MaxImagesPerDocument: 20,
+	}
+}
+
+// markdownImageReference Markdown图片，捕获替代文字和地址，地址后可带标题
+var markdownImageReference = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^\s<>()]+)>?(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+
+// findDocumentImages 查找文档引用的图片：图片文档本身，或Markdown和HTML中的内嵌图片
+// 只返回可以获取的图片（http、https地址和data URI），同一地址只返回一次
+func findDocumentImages(doc *domain.Document) []ImageReference {
+	var candidates []ImageReference
+	switch doc.Type {
+	case domain.DocumentTypeImage:
+		candidates = []ImageReference{{URL: strings.TrimSpace(doc.Content), AltText: doc.Title}}
+	case domain.DocumentTypeMarkdown:
+		for _, match := range markdownImageReference.FindAllStringSubmatch(doc.Content, -1) {
+			candidates = append(candidates, ImageReference{URL: match[2], AltText: match[1]})
+		}
+	case domain.DocumentTypeHTML:
+		root, err := html.Parse(strings.NewReader(doc.Content))
+		if err != nil {
+			return nil
+		}
+		candidates = findHTMLImages(root, candidates)
+	}
+
+	images := make([]ImageReference, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, image := range candidates {
+		if !isFetchableImage(image.URL) || seen[image.URL] {
+			continue
+		}
+		seen[image.URL] = true
+		image.Index = len(images)
+		images = append(images, image)
+	}
+	return images
+}
+
+// findHTMLImages 按文档顺序收集<img>元素，跳过脚本、样式等不展示的元素
+func findHTMLImages(n *html.Node, images []ImageReference) []ImageReference {
+	if n.Type == html.ElementNode {
+		if isSkippedHTMLElement(n.DataAtom) {
+			return images
+		}
+		if n.DataAtom == atom.Img {
+			return append(images, ImageReference{URL: strings.TrimSpace(textformat.HTMLAttr(n, "src")), AltText: textformat.HTMLAttr(n, "alt")})
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		images = findHTMLImages(child, images)
+	}
+	return images
+}
+
+// isFetchableImage 判断图片地址是否可以交给提取器获取，相对路径无法获取
+func isFetchableImage(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "http://") ||
+		strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "data:image/")
+}
+
+// imageChunkText 图片分块的文字，描述在前，图片中的文字在后
+func imageChunkText(text *ImageText) string {
+	parts := make([]string, 0, 2)
+	if caption := strings.TrimSpace(text.Caption); caption != "" {
+		parts = append(parts, caption)
+	}
+	if body := strings.TrimSpace(text.Text); body != "" {
+		parts = append(parts, body)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// chunkImageSource 分块中记录的来源图片，data URI不保存在分块中
+func chunkImageSource(image ImageReference, text *ImageText) *domain.ChunkImage {
+	source := &domain.ChunkImage{
+		Index:   image.Index,
+		AltText: strings.TrimSpace(image.AltText),
+		Caption: strings.TrimSpace(text.Caption),
+	}
+	if !strings.HasPrefix(strings.ToLower(image.URL), "data:") {
+		source.URL = image.URL
+	}
+	return source
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// SnapshotFormatVersion 知识库快照格式版本，格式不兼容时递增
+const SnapshotFormatVersion = 1
+
+// KnowledgeBaseSnapshot 知识库快照，包含知识库配置、文档、分块及其嵌入向量
+// 恢复时直接写入嵌入向量，不重新调用嵌入模型
+type KnowledgeBaseSnapshot struct {
+	Version       int                  `json:"version"`
+	CreatedAt     time.Time            `json:"created_at"`
+	KnowledgeBase domain.KnowledgeBase `json:"knowledge_base"`
+	Index         SnapshotIndex        `json:"index"`
+	Documents     []domain.Document    `json:"documents"` // 不含分块，分块单独保存在Chunks中
+	Chunks        []domain.Chunk       `json:"chunks"`
+}
+
+// SnapshotIndex 快照的向量索引配置
+type SnapshotIndex struct {
+	Dimension      int                   `json:"dimension"` // 0表示快照中没有嵌入向量
+	MetricType     repository.MetricType `json:"metric_type"`
+	EmbeddingModel string                `json:"embedding_model"` // 生成嵌入向量的模型，恢复环境须使用同一模型
+}
+
+// KnowledgeBaseRestoreResult 知识库恢复结果
+type KnowledgeBaseRestoreResult struct {
+	KnowledgeBase  *domain.KnowledgeBase `json:"knowledge_base"`
+	DocumentCount  int                   `json:"document_count"`
+	ChunkCount     int                   `json:"chunk_count"`
+	VectorCount    int                   `json:"vector_count"`    // 已写入向量库的向量数
+	PendingVectors int                   `json:"pending_vectors"` // 缺少嵌入或写入失败、由向量同步任务补齐的分块数
+}
+
+// SnapshotKnowledgeBase 导出知识库快照：知识库配置、所有文档、分块和嵌入向量，格式为gzip压缩的JSON
+// 快照包含所有受限文档，需要管理权限
+func (s *RAGService) SnapshotKnowledgeBase(ctx context.Context, knowledgeBaseID string) ([]byte, error) {
+	kb, err := s.findKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionAdmin); err != nil {
+		return nil, err
+	}
+
+	docs, err := s.docRepo.FindByKnowledgeBaseID(ctx, kb.ID)
+	if err != nil {
+		s.logger.Error("Failed to load documents for snapshot", zap.Error(err))
+		return nil, err
+	}
+
+	snapshot := &KnowledgeBaseSnapshot{
+		Version:       SnapshotFormatVersion,
+		CreatedAt:     time.Now(),
+		KnowledgeBase: *kb,
+		Documents:     make([]domain.Document, 0, len(docs)),
+	}
+	snapshot.KnowledgeBase.Documents = nil
+
+	for _, doc := range docs {
+		chunks, err := s.chunkRepo.FindByDocumentID(ctx, doc.ID)
+		if err != nil {
+			s.logger.Error("Failed to load chunks for snapshot",
+				zap.String("document_id", doc.ID),
+				zap.Error(err))
+			return nil, err
+		}
+
+		document := *doc
+		document.Chunks = nil
+		snapshot.Documents = append(snapshot.Documents, document)
+		for _, chunk := range chunks {
+			snapshot.Chunks = append(snapshot.Chunks, *chunk)
+			if snapshot.Index.Dimension == 0 && chunk.HasEmbedding() {
+				snapshot.Index.Dimension = len(chunk.Embedding)
+			}
+		}
+	}
+
+	snapshot.Index.EmbeddingModel = s.embeddingService.ForLanguage(kb.Settings.Language).GetModel()
+	snapshot.Index.MetricType = repository.MetricTypeCosine
+	if info, err := s.vectorRepo.GetIndexInfo(ctx, s.getIndexName(kb.ID)); err == nil && info.MetricType != "" {
+		snapshot.Index.MetricType = info.MetricType
+	}
+
+	blob, err := EncodeKnowledgeBaseSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Knowledge base snapshot created",
+		zap.String("knowledge_base_id", kb.ID),
+		zap.Int("document_count", len(snapshot.Documents)),
+		zap.Int("chunk_count", len(snapshot.Chunks)),
+		zap.Int("size", len(blob)))
+	return blob, nil
+}
+
+// RestoreKnowledgeBase 从快照恢复知识库，保留原有的知识库、文档和分块ID
+// 先创建向量索引，再保存知识库、文档和分块，最后直接写入快照中的嵌入向量；
+// 缺少嵌入或写入失败的分块标记为待同步，由向量同步任务补齐。未完成索引的文档在后台重新索引
+// 目标环境已有同ID或同名知识库、或嵌入模型与快照不一致时拒绝恢复；只有快照中知识库的所有者可以恢复
+func (s *RAGService) RestoreKnowledgeBase(ctx context.Context, blob []byte) (*KnowledgeBaseRestoreResult, error) {
+	snapshot, err := DecodeKnowledgeBaseSnapshot(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	kb := snapshot.KnowledgeBase
+	if err := s.checkKnowledgeBaseAccess(ctx, &kb, UserIDFromContext(ctx), repository.PermissionOwner); err != nil {
+		return nil, err
+	}
+	if err := s.checkRestoreTarget(ctx, &kb, snapshot.Index); err != nil {
+		return nil, err
+	}
+
+	// 按文档分组分块，丢弃快照中的向量同步状态
+	documents := make(map[string]*domain.Document, len(snapshot.Documents))
+	for i := range snapshot.Documents {
+		doc := &snapshot.Documents[i]
+		if doc.KnowledgeBaseID != kb.ID {
+			return nil, domain.ErrInvalidInputf("documents", fmt.Sprintf("document %s belongs to another knowledge base", doc.ID))
+		}
+		doc.Chunks = nil
+		documents[doc.ID] = doc
+	}
+	chunks := make([]*domain.Chunk, len(snapshot.Chunks))
+	byDocument := make(map[string][]*domain.Chunk, len(documents))
+	for i := range snapshot.Chunks {
+		chunk := &snapshot.Chunks[i]
+		if documents[chunk.DocumentID] == nil {
+			return nil, domain.ErrInvalidInputf("chunks", fmt.Sprintf("chunk %s references unknown document %s", chunk.ID, chunk.DocumentID))
+		}
+		if chunk.HasEmbedding() && len(chunk.Embedding) != snapshot.Index.Dimension {
+			return nil, domain.ErrInvalidInputf("chunks", fmt.Sprintf("chunk %s has %d dimensions, expected %d", chunk.ID, len(chunk.Embedding), snapshot.Index.Dimension))
+		}
+		chunk.VectorStatus = domain.VectorSyncPending
+		chunk.VectorSyncAttempts = 0
+		chunk.VectorSyncError = ""
+		chunk.VectorSyncedAt = nil
+		chunks[i] = chunk
+		byDocument[chunk.DocumentID] = append(byDocument[chunk.DocumentID], chunk)
+	}
+
+	// 创建向量索引，快照中没有嵌入向量时由首次写入创建
+	indexName := s.getIndexName(kb.ID)
+	if snapshot.Index.Dimension > 0 {
+		if err := s.vectorRepo.CreateIndex(ctx, indexName, snapshot.Index.Dimension, snapshot.Index.MetricType); err != nil {
+			s.logger.Error("Failed to create vector index for restore", zap.String("index_name", indexName), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	kb.Documents = nil
+	if kb.Status != domain.KnowledgeBaseStatusInactive {
+		kb.Status = domain.KnowledgeBaseStatusActive
+	}
+	kb.Statistics = restoredStatistics(snapshot.Documents, len(chunks))
+	if err := s.saveRestoredKnowledgeBase(ctx, &kb, snapshot.Documents, chunks); err != nil {
+		return nil, err
+	}
+
+	// 按文档写入向量，同ID向量会被替换
+	result := &KnowledgeBaseRestoreResult{
+		KnowledgeBase: &kb,
+		DocumentCount: len(snapshot.Documents),
+		ChunkCount:    len(chunks),
+	}
+	var reindex []string
+	for i := range snapshot.Documents {
+		doc := &snapshot.Documents[i]
+		if doc.Status == domain.DocumentStatusPending || doc.Status == domain.DocumentStatusIndexing {
+			reindex = append(reindex, doc.ID)
+			continue
+		}
+
+		var embedded []*domain.Chunk
+		for _, chunk := range byDocument[doc.ID] {
+			if chunk.HasEmbedding() {
+				embedded = append(embedded, chunk)
+			}
+		}
+		result.PendingVectors += len(byDocument[doc.ID]) - len(embedded)
+		if len(embedded) == 0 {
+			continue
+		}
+
+		if err := s.syncVectors(ctx, doc, embedded); err != nil {
+			s.logger.Warn("Failed to restore document vectors, leaving them to vector sync",
+				zap.String("document_id", doc.ID),
+				zap.Int("chunk_count", len(embedded)),
+				zap.Error(err))
+			result.PendingVectors += len(embedded)
+			continue
+		}
+		result.VectorCount += len(embedded)
+	}
+
+	for _, documentID := range reindex {
+		go s.processDocumentAsync(context.Background(), documentID)
+	}
+
+	s.logger.Info("Knowledge base restored from snapshot",
+		zap.String("knowledge_base_id", kb.ID),
+		zap.Int("document_count", result.DocumentCount),
+		zap.Int("chunk_count", result.ChunkCount),
+		zap.Int("vector_count", result.VectorCount),
+		zap.Int("pending_vectors", result.PendingVectors),
+		zap.Int("reindexing_documents", len(reindex)))
+	return result, nil
+}
+
+// checkRestoreTarget 检查目标环境能否恢复快照：不存在同ID（包括已软删除但未永久删除的）或同名的知识库，且嵌入模型与快照一致
+func (s *RAGService) checkRestoreTarget(ctx context.Context, kb *domain.KnowledgeBase, index SnapshotIndex) error {
+	if existing, err := s.kbRepo.FindIncludingDeleted(ctx, kb.ID); err == nil {
+		if existing.DeletedAt.Valid {
+			return domain.NewDomainErrorWithDetails(domain.ErrKnowledgeBaseExists, "deleted knowledge base has not been purged", fmt.Sprintf("knowledge_base_id: %s", kb.ID))
+		}
+		return domain.NewDomainErrorWithDetails(domain.ErrKnowledgeBaseExists, "knowledge base already exists", fmt.Sprintf("knowledge_base_id: %s", kb.ID))
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if _, err := s.kbRepo.FindByName(ctx, kb.Name, kb.OwnerID); err == nil {
+		return domain.NewDomainError(domain.ErrKnowledgeBaseExists, "knowledge base name already exists")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	// 不同模型的向量不可比较，恢复后的查询向量必须由同一模型生成
+	if index.Dimension == 0 {
+		return nil
+	}
+	embeddingService := s.embeddingService.ForLanguage(kb.Settings.Language)
+	if embeddingService.GetModel() != index.EmbeddingModel || embeddingService.GetDimension() != index.Dimension {
+		return domain.NewDomainErrorWithDetails(domain.ErrVectorDimensionMismatch,
+			"snapshot embeddings are incompatible with the configured embedding model",
+			fmt.Sprintf("snapshot: %s (%d), configured: %s (%d)", index.EmbeddingModel, index.Dimension, embeddingService.GetModel(), embeddingService.GetDimension()))
+	}
+	return nil
+}
+
+// saveRestoredKnowledgeBase 保存恢复的知识库、文档和分块，中途失败时尽量永久删除已保存的数据，以便重试
+func (s *RAGService) saveRestoredKnowledgeBase(ctx context.Context, kb *domain.KnowledgeBase, documents []domain.Document, chunks []*domain.Chunk) error {
+	if err := s.kbRepo.Save(ctx, kb); err != nil {
+		s.logger.Error("Failed to save restored knowledge base", zap.Error(err))
+		return err
+	}
+
+	docs := make([]*domain.Document, len(documents))
+	for i := range documents {
+		docs[i] = &documents[i]
+	}
+	err := s.docRepo.SaveBatch(ctx, docs)
+	if err == nil && len(chunks) > 0 {
+		err = s.chunkRepo.SaveBatch(ctx, chunks)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// 软删除会保留相同ID的记录，回滚需要永久删除，知识库的文档和分块一并删除
+	s.logger.Error("Failed to save restored documents, rolling back", zap.String("knowledge_base_id", kb.ID), zap.Error(err))
+	if cleanupErr := s.kbRepo.Purge(ctx, kb.ID); cleanupErr != nil {
+		s.logger.Warn("Failed to roll back restored knowledge base", zap.Error(cleanupErr))
+	}
+	return err
+}
+
+// restoredStatistics 按恢复的文档重新计算知识库统计，查询统计从零开始
+func restoredStatistics(documents []domain.Document, chunkCount int) domain.KnowledgeBaseStats {
+	stats := domain.KnowledgeBaseStats{
+		DocumentCount: len(documents),
+		ChunkCount:    chunkCount,
+	}
+	for i := range documents {
+		stats.TotalSize += documents[i].Size
+		if documents[i].IsIndexed() {
+			stats.IndexedCount++
+		}
+	}
+	if stats.DocumentCount > 0 {
+		stats.AverageSize = float64(stats.TotalSize) / float64(stats.DocumentCount)
+	}
+	return stats
+}
+
+// EncodeKnowledgeBaseSnapshot 将快照编码为gzip压缩的JSON
+func EncodeKnowledgeBaseSnapshot(snapshot *KnowledgeBaseSnapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeKnowledgeBaseSnapshot 解码快照，格式无效或版本不支持时返回INVALID_INPUT领域错误
+func DecodeKnowledgeBaseSnapshot(blob []byte) (*KnowledgeBaseSnapshot, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, domain.ErrInvalidInputf("snapshot", "not a gzip-compressed snapshot")
+	}
+	defer reader.Close()
+
+	var snapshot KnowledgeBaseSnapshot
+	if err := json.NewDecoder(reader).Decode(&snapshot); err != nil && !errors.Is(err, io.EOF) {
+		return nil, domain.ErrInvalidInputf("snapshot", err.Error())
+	}
+	if snapshot.Version != SnapshotFormatVersion {
+		return nil, domain.ErrInvalidInputf("version", fmt.Sprintf("unsupported snapshot version %d", snapshot.Version))
+	}
+	if snapshot.KnowledgeBase.ID == "" || snapshot.KnowledgeBase.Name == "" {
+		return nil, domain.ErrInvalidInputf("knowledge_base", "knowledge base ID and name are required")
+	}
+	if snapshot.Index.MetricType == "" {
+		snapshot.Index.MetricType = repository.MetricTypeCosine
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+func (r *memKnowledgeBaseRepository) Update(ctx context.Context, kb *domain.KnowledgeBase) error {
+	copied := *kb
+	r.knowledgeBases[kb.ID] = &copied
+	return nil
+}
+
+func (r *memChunkRepository) FindByID(ctx context.Context, id string) (*domain.Chunk, error) {
+	chunk, ok := r.chunks[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return chunk, nil
+}
+
+// Search 返回索引中的全部向量，分数都是searchTestScore
+func (r *memVectorRepository) Search(ctx context.Context, query *repository.VectorQuery) (*repository.VectorSearchResult, error) {
+	result := &repository.VectorSearchResult{Query: query}
+	for _, id := range r.ids(query.IndexName) {
+		result.Results = append(result.Results, repository.VectorSearchMatch{ID: id, Score: searchTestScore})
+	}
+	result.Total = len(result.Results)
+	return result, nil
+}
+
+const searchTestScore = 0.8
+
+func (e *recordingEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// histogramSamples 返回注册表中指定标签的直方图样本数和样本和，计数器返回值和0
+func histogramSamples(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) (float64, float64) {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	samples:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if want, ok := labels[label.GetName()]; ok && want != label.GetValue() {
+					continue samples
+				}
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				return float64(histogram.GetSampleCount()), histogram.GetSampleSum()
+			}
+			return metric.GetCounter().GetValue(), 0
+		}
+	}
+	return 0, 0
+}
+
+func TestSearchRecordsMetrics(t *testing.T) {
+	kb, err := domain.NewKnowledgeBase("kb", "", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kbRepo := &memKnowledgeBaseRepository{knowledgeBases: map[string]*domain.KnowledgeBase{kb.ID: kb}}
+	docRepo := &memDocumentRepository{}
+	chunkRepo := &memChunkRepository{chunks: map[string]*domain.Chunk{}}
+	vectorRepo := &memVectorRepository{indexes: map[string]map[string]bool{}}
+	registry := prometheus.NewRegistry()
+	ragMetrics, err := metrics.NewRAGMetrics(registry, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultChunkingConfig()
+	config.ChunkSize = 20
+	config.ChunkOverlap = 0
+	service := NewRAGService(kbRepo, docRepo, chunkRepo, vectorRepo, &recordingEmbedder{}, noopEmbeddingCache{},
+		NewDefaultChunkingService(config), nil, nil, ImageExtractionConfig{}, nil, DefaultSearchConfig(),
+		StreamIngestionConfig{}, EmbeddingBatchConfig{}, AccessControlConfig{}, ragMetrics, zap.NewNop())
+	ctx := WithUserID(context.Background(), "owner")
+
+	doc, err := domain.NewDocument("手册", "The manual has three sentences. It covers deployment. It covers rollback.", domain.DocumentTypeText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.KnowledgeBaseID = kb.ID
+	if err := docRepo.Save(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ProcessDocument(ctx, doc.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	byKnowledgeBase := map[string]string{"knowledge_base": kb.ID}
+	chunks := float64(len(chunkIDs(t, chunkRepo, doc.ID)))
+	if got, _ := histogramSamples(t, registry, "noah_loop_rag_indexed_chunks_total", byKnowledgeBase); got != chunks {
+		t.Fatalf("indexed chunks = %v, want %v", got, chunks)
+	}
+	for _, name := range []string{"noah_loop_rag_index_duration_seconds", "noah_loop_rag_index_throughput_chunks_per_second"} {
+		if count, _ := histogramSamples(t, registry, name, byKnowledgeBase); count != 1 {
+			t.Fatalf("%s has %v samples, want 1", name, count)
+		}
+	}
+
+	stored := kbRepo.knowledgeBases[kb.ID]
+	stored.Statistics.IndexedCount = 1
+	results, err := service.Search(ctx, domain.NewSearchQuery("deployment", kb.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Results) == 0 {
+		t.Fatal("search returned no results")
+	}
+
+	bySearch := map[string]string{"knowledge_base": kb.ID, "mode": string(domain.SearchModeVector)}
+	if count, _ := histogramSamples(t, registry, "noah_loop_rag_search_duration_seconds", bySearch); count != 1 {
+		t.Fatalf("search duration has %v samples, want 1", count)
+	}
+	if count, sum := histogramSamples(t, registry, "noah_loop_rag_search_results", bySearch); count != 1 || sum != float64(len(results.Results)) {
+		t.Fatalf("search results = %v samples with sum %v, want 1 sample of %d", count, sum, len(results.Results))
+	}
+	if count, sum := histogramSamples(t, registry, "noah_loop_rag_search_average_score", bySearch); count != 1 || math.Abs(sum-searchTestScore) > 1e-6 {
+		t.Fatalf("average score = %v samples with sum %v, want 1 sample of %v", count, sum, searchTestScore)
+	}
+	for _, operation := range []string{metrics.EmbeddingOperationDocument, metrics.EmbeddingOperationQuery} {
+		if count, _ := histogramSamples(t, registry, "noah_loop_rag_embedding_duration_seconds", map[string]string{"operation": operation}); count != 1 {
+			t.Fatalf("%s embedding duration has %v samples, want 1", operation, count)
+		}
+	}
+
+	// 没有结果的检索记录结果数，不记录平均分
+	if _, err := service.Search(ctx, domain.NewSearchQuery("deployment", kb.ID).WithScoreThreshold(0.9)); err != nil {
+		t.Fatal(err)
+	}
+	if count, sum := histogramSamples(t, registry, "noah_loop_rag_search_results", bySearch); count != 2 || sum != float64(len(results.Results)) {
+		t.Fatalf("search results = %v samples with sum %v after an empty search", count, sum)
+	}
+	if count, _ := histogramSamples(t, registry, "noah_loop_rag_search_average_score", bySearch); count != 1 {
+		t.Fatalf("average score has %v samples after an empty search, want 1", count)
+	}
+}
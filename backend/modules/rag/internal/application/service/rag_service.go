@@ -2,14 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/pagination"
 	"go.uber.org/zap"
 )
 
+// accessFilterOverfetch 向量检索的候选倍数，为文档级权限过滤预留余量
+const accessFilterOverfetch = 2
+
+// documentLockStripes 文档索引锁的分段数
+const documentLockStripes = 64
+
 // RAGService RAG应用服务
 type RAGService struct {
 	kbRepo       repository.KnowledgeBaseRepository
@@ -17,7 +31,18 @@ type RAGService struct {
 	chunkRepo    repository.ChunkRepository
 	vectorRepo   repository.VectorRepository
 	embeddingService EmbeddingService
+	embeddingCache   ContentEmbeddingCache
 	chunkingService  ChunkingService
+	reranker         Reranker
+	imageExtractor   ImageTextExtractor
+	imageConfig      ImageExtractionConfig
+	summarizer       DocumentSummarizer
+	searchConfig     SearchConfig
+	streamConfig     StreamIngestionConfig
+	embeddingBatch   EmbeddingBatchConfig
+	accessConfig     AccessControlConfig
+	metrics          *metrics.RAGMetrics
+	documentLocks    [documentLockStripes]sync.Mutex // 按文档ID分段的索引锁，串行化同一文档的索引
 	logger       infrastructure.Logger
 }
 
@@ -28,34 +53,80 @@ func NewRAGService(
 	chunkRepo repository.ChunkRepository,
 	vectorRepo repository.VectorRepository,
 	embeddingService EmbeddingService,
+	embeddingCache ContentEmbeddingCache,
 	chunkingService ChunkingService,
+	reranker Reranker,
+	imageExtractor ImageTextExtractor,
+	imageConfig ImageExtractionConfig,
+	summarizer DocumentSummarizer,
+	searchConfig SearchConfig,
+	streamConfig StreamIngestionConfig,
+	embeddingBatch EmbeddingBatchConfig,
+	accessConfig AccessControlConfig,
+	ragMetrics *metrics.RAGMetrics,
 	logger infrastructure.Logger,
 ) *RAGService {
+	if reranker == nil {
+		reranker = NewNoopReranker()
+	}
+	if imageExtractor == nil {
+		imageExtractor = NewNoopImageTextExtractor()
+	}
+	if summarizer == nil {
+		summarizer = NewNoopDocumentSummarizer()
+	}
+	if streamConfig.BatchSize <= 0 {
+		streamConfig.BatchSize = DefaultStreamIngestionConfig().BatchSize
+	}
+	if embeddingBatch.MaxInputs <= 0 {
+		embeddingBatch.MaxInputs = DefaultEmbeddingBatchConfig().MaxInputs
+	}
+	if embeddingBatch.MaxTokens <= 0 {
+		embeddingBatch.MaxTokens = DefaultEmbeddingBatchConfig().MaxTokens
+	}
 	return &RAGService{
 		kbRepo:           kbRepo,
 		docRepo:          docRepo,
 		chunkRepo:        chunkRepo,
 		vectorRepo:       vectorRepo,
 		embeddingService: embeddingService,
+		embeddingCache:   embeddingCache,
 		chunkingService:  chunkingService,
+		reranker:         reranker,
+		imageExtractor:   imageExtractor,
+		imageConfig:      imageConfig,
+		summarizer:       summarizer,
+		searchConfig:     searchConfig,
+		streamConfig:     streamConfig,
+		embeddingBatch:   embeddingBatch,
+		accessConfig:     accessConfig,
+		metrics:          ragMetrics,
 		logger:          logger,
 	}
 }
 
-// CreateKnowledgeBase 创建知识库
+// CreateKnowledgeBase 创建知识库，所有者为请求用户
 func (s *RAGService) CreateKnowledgeBase(ctx context.Context, cmd *CreateKnowledgeBaseCommand) (*domain.KnowledgeBase, error) {
+	ownerID := UserIDFromContext(ctx)
+	if ownerID == "" {
+		return nil, errUserRequired()
+	}
+
 	s.logger.Info("Creating knowledge base",
 		zap.String("name", cmd.Name),
-		zap.String("owner_id", cmd.OwnerID))
+		zap.String("owner_id", ownerID))
 
 	// 检查知识库名称是否已存在
-	existing, err := s.kbRepo.FindByName(ctx, cmd.Name, cmd.OwnerID)
-	if err == nil && existing != nil {
-		return nil, domain.NewDomainError("KNOWLEDGE_BASE_EXISTS", "knowledge base name already exists")
+	_, err := s.kbRepo.FindByName(ctx, cmd.Name, ownerID)
+	if err == nil {
+		return nil, domain.NewDomainError(domain.ErrKnowledgeBaseExists, "knowledge base name already exists")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
 	}
 
 	// 创建知识库
-	kb, err := domain.NewKnowledgeBase(cmd.Name, cmd.Description, cmd.OwnerID)
+	kb, err := domain.NewKnowledgeBase(cmd.Name, cmd.Description, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -79,15 +150,14 @@ func (s *RAGService) CreateKnowledgeBase(ctx context.Context, cmd *CreateKnowled
 	return kb, nil
 }
 
-// UpdateKnowledgeBase 更新知识库
+// UpdateKnowledgeBase 更新知识库，需要管理权限
 func (s *RAGService) UpdateKnowledgeBase(ctx context.Context, cmd *UpdateKnowledgeBaseCommand) (*domain.KnowledgeBase, error) {
-	kb, err := s.kbRepo.FindByID(ctx, cmd.ID)
+	kb, err := s.findKnowledgeBase(ctx, cmd.ID)
 	if err != nil {
 		return nil, err
 	}
-
-	if kb == nil {
-		return nil, domain.ErrKnowledgeBaseNotFoundf(cmd.ID)
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionAdmin); err != nil {
+		return nil, err
 	}
 
 	// 更新基本信息
@@ -130,13 +200,13 @@ func (s *RAGService) AddDocument(ctx context.Context, cmd *AddDocumentCommand) (
 		zap.String("title", cmd.Title),
 		zap.String("knowledge_base_id", cmd.KnowledgeBaseID))
 
-	// 检查知识库是否存在
-	kb, err := s.kbRepo.FindByID(ctx, cmd.KnowledgeBaseID)
+	// 检查知识库是否存在，请求用户需要写权限
+	kb, err := s.findKnowledgeBase(ctx, cmd.KnowledgeBaseID)
 	if err != nil {
 		return nil, err
 	}
-	if kb == nil {
-		return nil, domain.ErrKnowledgeBaseNotFoundf(cmd.KnowledgeBaseID)
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionWrite); err != nil {
+		return nil, err
 	}
 
 	// 创建文档
@@ -148,6 +218,9 @@ func (s *RAGService) AddDocument(ctx context.Context, cmd *AddDocumentCommand) (
 	doc.KnowledgeBaseID = cmd.KnowledgeBaseID
 	if cmd.Language != "" {
 		doc.Language = cmd.Language
+	} else if doc.Language == "" {
+		// 内容无法识别语言时沿用知识库的语言
+		doc.Language = kb.Settings.Language
 	}
 
 	// 设置元数据
@@ -155,9 +228,30 @@ func (s *RAGService) AddDocument(ctx context.Context, cmd *AddDocumentCommand) (
 		doc.Metadata = *cmd.Metadata
 	}
 
+	// 设置访问控制，文档所有者为请求用户
+	if err := setDocumentOwner(ctx, doc, cmd.AccessLevel, cmd.AllowedUsers); err != nil {
+		return nil, err
+	}
+
+	// 知识库中已有内容相同的文档时不再重复索引
+	existing, err := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, doc.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return s.duplicateDocument(existing, cmd.SkipDuplicates)
+	}
+
 	// 保存文档
 	err = s.docRepo.Save(ctx, doc)
 	if err != nil {
+		// 并发添加了相同内容的文档
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			existing, findErr := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, doc.Hash)
+			if findErr == nil && existing != nil {
+				return s.duplicateDocument(existing, cmd.SkipDuplicates)
+			}
+		}
 		s.logger.Error("Failed to save document", zap.Error(err))
 		return nil, err
 	}
@@ -169,35 +263,335 @@ func (s *RAGService) AddDocument(ctx context.Context, cmd *AddDocumentCommand) (
 	return doc, nil
 }
 
-// ProcessDocument 处理文档（分块和向量化）
+// duplicateDocument 处理重复文档：skip为true时返回已有文档，否则返回重复错误
+func (s *RAGService) duplicateDocument(existing *domain.Document, skip bool) (*domain.Document, error) {
+	s.logger.Info("Document with identical content already exists",
+		zap.String("existing_id", existing.ID),
+		zap.String("knowledge_base_id", existing.KnowledgeBaseID),
+		zap.Bool("skip_duplicates", skip))
+
+	if !skip {
+		return nil, domain.ErrDuplicateDocumentf(existing.ID)
+	}
+	return existing, nil
+}
+
+// GetDocument 获取文档，需要知识库读权限，未要求时不返回内容和分块
+func (s *RAGService) GetDocument(ctx context.Context, cmd *GetDocumentCommand) (*domain.Document, error) {
+	doc, _, err := s.findAccessibleDocument(ctx, cmd.ID, repository.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cmd.IncludeContent {
+		doc.Content = ""
+	}
+	if !cmd.IncludeChunks {
+		doc.Chunks = nil
+	}
+	return doc, nil
+}
+
+// 游标分页的每页数量限制
+const (
+	defaultCursorPageLimit = 20
+	maxCursorPageLimit     = 100
+	maxCursorScanDocuments = 1000 // 过滤不可见文档时单次请求最多扫描的文档数
+)
+
+// ListDocumentsAfterCursor 游标分页列出知识库中的文档，按创建时间从新到旧排列，不返回内容和分块
+// 需要知识库读权限，只返回请求用户可见的文档；返回下一页的游标，没有更多文档时为空字符串。游标分页不支持按状态和类型过滤
+func (s *RAGService) ListDocumentsAfterCursor(ctx context.Context, cmd *ListDocumentsCommand) ([]*domain.Document, string, error) {
+	if cmd.KnowledgeBaseID == "" {
+		return nil, "", domain.ErrInvalidInputf("knowledge_base_id", "knowledge_base_id is required")
+	}
+	if cmd.Status != "" || cmd.Type != "" {
+		return nil, "", domain.ErrInvalidInputf("cursor", "status and type filters are not supported with cursor pagination")
+	}
+	cursor, err := pagination.DecodeCursor(cmd.Cursor)
+	if err != nil {
+		return nil, "", domain.ErrInvalidInputf("cursor", "invalid pagination cursor")
+	}
+
+	kb, err := s.findKnowledgeBase(ctx, cmd.KnowledgeBaseID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionRead); err != nil {
+		return nil, "", err
+	}
+
+	limit := cmd.Limit
+	if limit <= 0 {
+		limit = defaultCursorPageLimit
+	}
+	if limit > maxCursorPageLimit {
+		limit = maxCursorPageLimit
+	}
+
+	// 逐页过滤请求用户看不到的文档，直到填满一页；扫描数量有上限，达到时返回不足一页的结果和继续扫描的游标
+	userID := UserIDFromContext(ctx)
+	docs := make([]*domain.Document, 0, limit)
+	scanned := 0
+	for {
+		batch, next, err := s.docRepo.FindByKnowledgeBaseIDAfterCursor(ctx, kb.ID, cursor, limit)
+		if err != nil {
+			s.logger.Error("Failed to list documents", zap.Error(err))
+			return nil, "", err
+		}
+		for i, doc := range batch {
+			cursor = pagination.NewCursor(doc.CreatedAt, doc.ID)
+			if userID != kb.OwnerID && !doc.CanBeAccessedBy(userID) {
+				continue
+			}
+			doc.Content = ""
+			doc.Chunks = nil
+			docs = append(docs, doc)
+			if len(docs) == limit {
+				if i == len(batch)-1 && next == nil {
+					return docs, "", nil
+				}
+				return docs, cursor.Encode(), nil
+			}
+		}
+		scanned += len(batch)
+		if next == nil {
+			return docs, "", nil
+		}
+		if scanned >= maxCursorScanDocuments {
+			return docs, cursor.Encode(), nil
+		}
+	}
+}
+
+// UpdateDocumentAccess 更新文档访问控制，文档所有者不变
+// 需要知识库写权限，且请求用户是文档所有者或知识库管理员，管理员可以修改自己看不到的文档；没有所有者的文档由请求用户成为所有者
+func (s *RAGService) UpdateDocumentAccess(ctx context.Context, cmd *UpdateDocumentAccessCommand) (*domain.Document, error) {
+	doc, err := s.findDocument(ctx, cmd.ID)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.findKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := UserIDFromContext(ctx)
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, userID, repository.PermissionWrite); err != nil {
+		return nil, err
+	}
+	if userID == "" || userID != doc.Access.OwnerID {
+		if err := s.checkKnowledgeBaseAccess(ctx, kb, userID, repository.PermissionAdmin); err != nil {
+			// 看不到的文档按不存在处理
+			if !doc.CanBeAccessedBy(userID) {
+				return nil, domain.ErrDocumentNotFoundf(doc.ID)
+			}
+			return nil, err
+		}
+	}
+
+	ownerID := doc.Access.OwnerID
+	if ownerID == "" {
+		ownerID = userID
+	}
+	err = doc.SetAccess(cmd.AccessLevel, ownerID, cmd.AllowedUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.docRepo.Update(ctx, doc)
+	if err != nil {
+		s.logger.Error("Failed to update document access", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Document access updated",
+		zap.String("document_id", doc.ID),
+		zap.String("access_level", string(doc.Access.Level)))
+	return doc, nil
+}
+
+// UpdateDocument 更新文档，内容或元数据变化时在后台重新索引，需要知识库写权限
+// 返回的布尔值表示是否已开始重新索引
+func (s *RAGService) UpdateDocument(ctx context.Context, cmd *UpdateDocumentCommand) (*domain.Document, bool, error) {
+	doc, _, err := s.findAccessibleDocument(ctx, cmd.ID, repository.PermissionWrite)
+	if err != nil {
+		return nil, false, err
+	}
+
+	contentChanged := doc.UpdateContent(cmd.Title, cmd.Content)
+	if contentChanged {
+		// 知识库中已有内容相同的其他文档
+		existing, err := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, doc.Hash)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil && existing.ID != doc.ID {
+			return nil, false, domain.ErrDuplicateDocumentf(existing.ID)
+		}
+	}
+	if cmd.Language != "" {
+		contentChanged = contentChanged || cmd.Language != doc.Language
+		doc.Language = cmd.Language
+	}
+
+	// 元数据会写入向量，同样需要重新索引
+	reindex := contentChanged
+	if cmd.Metadata != nil {
+		doc.Metadata = *cmd.Metadata
+		reindex = true
+	}
+	// 流式导入的文档没有保存全文，只修改元数据或语言时无法重新索引，新的元数据在重新上传后生效
+	if doc.Streamed {
+		reindex = false
+	}
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		// 并发更新为相同内容
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			if existing, findErr := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, doc.Hash); findErr == nil && existing != nil {
+				return nil, false, domain.ErrDuplicateDocumentf(existing.ID)
+			}
+		}
+		s.logger.Error("Failed to update document", zap.Error(err))
+		return nil, false, err
+	}
+
+	if reindex {
+		go s.reindexDocumentAsync(context.Background(), doc.ID)
+	}
+
+	s.logger.Info("Document updated",
+		zap.String("document_id", doc.ID),
+		zap.Bool("content_changed", contentChanged),
+		zap.Bool("reindex", reindex))
+	return doc, reindex, nil
+}
+
+// ProcessDocument 处理文档（分块和向量化），需要知识库写权限
 func (s *RAGService) ProcessDocument(ctx context.Context, documentID string) error {
+	if _, _, err := s.findAccessibleDocument(ctx, documentID, repository.PermissionWrite); err != nil {
+		return err
+	}
+	return s.processDocument(ctx, documentID)
+}
+
+// processDocument 处理文档，不检查权限，用于添加文档后的后台处理
+func (s *RAGService) processDocument(ctx context.Context, documentID string) error {
+	unlock := s.lockDocument(documentID)
+	defer unlock()
+
 	s.logger.Info("Processing document", zap.String("document_id", documentID))
 
-	// 获取文档
-	doc, err := s.docRepo.FindByID(ctx, documentID)
+	doc, err := s.findDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.indexDocument(ctx, doc); err != nil {
+		return err
+	}
+
+	s.logger.Info("Document processed successfully", zap.String("document_id", documentID))
+	return nil
+}
+
+// ReindexDocument 按文档当前内容重新分块和向量化，替换已有的分块和向量，需要知识库写权限
+// 同一文档的重新索引串行执行，后执行的一次使用最新内容
+func (s *RAGService) ReindexDocument(ctx context.Context, documentID string) error {
+	if _, _, err := s.findAccessibleDocument(ctx, documentID, repository.PermissionWrite); err != nil {
+		return err
+	}
+	return s.reindexDocument(ctx, documentID)
+}
+
+// reindexDocument 重新索引文档，不检查权限，用于更新文档后的后台重新索引
+func (s *RAGService) reindexDocument(ctx context.Context, documentID string) error {
+	unlock := s.lockDocument(documentID)
+	defer unlock()
+
+	s.logger.Info("Reindexing document", zap.String("document_id", documentID))
+
+	// 获得锁后再加载，保证使用最新内容
+	doc, err := s.findDocument(ctx, documentID)
 	if err != nil {
 		return err
 	}
-	if doc == nil {
-		return domain.ErrDocumentNotFoundf(documentID)
+	staleChunks := len(doc.Chunks)
+
+	if err := s.indexDocument(ctx, doc); err != nil {
+		return err
+	}
+
+	s.logger.Info("Document reindexed successfully",
+		zap.String("document_id", documentID),
+		zap.Int("removed_chunks", staleChunks),
+		zap.Int("chunk_count", len(doc.Chunks)))
+	return nil
+}
+
+// indexDocument 分块、生成嵌入并替换文档已有的分块和向量
+// 先完成分块和嵌入，再删除旧分块和向量并写入新分块：分块或嵌入失败时保留旧索引，
+// 新向量写入失败时分块已保存并标记为待同步，由向量同步任务重试
+func (s *RAGService) indexDocument(ctx context.Context, doc *domain.Document) error {
+	// 流式导入的文档没有保存全文，只能重新上传
+	if doc.Streamed {
+		return domain.NewDomainErrorWithDetails(domain.ErrDocumentInvalidContent, "Streamed document has no stored content to index", "upload the document again to reindex it")
 	}
 
-	// 更新状态为索引中
-	err = doc.UpdateStatus(domain.DocumentStatusIndexing)
+	start := time.Now()
+
+	// 更新状态为索引中，已持有文档锁，遗留的索引中状态说明上次索引被中断
+	if doc.Status != domain.DocumentStatusIndexing {
+		if err := doc.UpdateStatus(domain.DocumentStatusIndexing); err != nil {
+			return err
+		}
+	}
+	if doc.Language == "" && doc.Type != domain.DocumentTypeImage {
+		doc.Language = domain.DetectLanguage(doc.Content)
+	}
+	err := s.docRepo.Update(ctx, doc)
 	if err != nil {
 		return err
 	}
-	err = s.docRepo.Update(ctx, doc)
+
+	kb, err := s.findKnowledgeBase(ctx, doc.KnowledgeBaseID)
 	if err != nil {
+		s.markDocumentFailed(ctx, doc)
 		return err
 	}
 
 	// 分块处理
-	chunks, err := s.chunkingService.ChunkDocument(ctx, doc)
+	chunks, err := s.chunkDocument(ctx, doc)
 	if err != nil {
 		s.logger.Error("Failed to chunk document", zap.Error(err))
-		doc.UpdateStatus(domain.DocumentStatusFailed)
-		s.docRepo.Update(ctx, doc)
+		s.markDocumentFailed(ctx, doc)
+		return err
+	}
+
+	// 生成摘要，开启摘要分块时追加在其他分块之后，与正文分块一起生成向量
+	s.summarizeDocument(ctx, kb, doc)
+	if doc.Summary != "" && kb.Settings.EmbedSummary {
+		summaryChunk, err := newSummaryChunk(doc, len(chunks))
+		if err != nil {
+			s.markDocumentFailed(ctx, doc)
+			return err
+		}
+		chunks = append(chunks, summaryChunk)
+	}
+
+	// 生成向量嵌入
+	err = s.generateEmbeddings(ctx, doc, chunks)
+	if err != nil {
+		s.logger.Error("Failed to generate embeddings", zap.Error(err))
+		s.markDocumentFailed(ctx, doc)
+		return err
+	}
+
+	// 清理旧分块及其向量，避免残留过期向量
+	if err := s.removeDocumentChunks(ctx, doc); err != nil {
+		s.logger.Error("Failed to remove stale chunks", zap.Error(err))
+		s.markDocumentFailed(ctx, doc)
 		return err
 	}
 
@@ -205,17 +599,15 @@ func (s *RAGService) ProcessDocument(ctx context.Context, documentID string) err
 	err = s.chunkRepo.SaveBatch(ctx, chunks)
 	if err != nil {
 		s.logger.Error("Failed to save chunks", zap.Error(err))
-		doc.UpdateStatus(domain.DocumentStatusFailed)
-		s.docRepo.Update(ctx, doc)
+		s.markDocumentFailed(ctx, doc)
 		return err
 	}
 
-	// 生成向量嵌入
-	err = s.generateEmbeddings(ctx, chunks)
+	// 写入向量
+	err = s.syncVectors(ctx, doc, chunks)
 	if err != nil {
-		s.logger.Error("Failed to generate embeddings", zap.Error(err))
-		doc.UpdateStatus(domain.DocumentStatusFailed)
-		s.docRepo.Update(ctx, doc)
+		s.logger.Error("Failed to sync vectors", zap.Error(err))
+		s.markDocumentFailed(ctx, doc)
 		return err
 	}
 
@@ -224,15 +616,90 @@ func (s *RAGService) ProcessDocument(ctx context.Context, documentID string) err
 	if err != nil {
 		return err
 	}
-	err = s.docRepo.Update(ctx, doc)
-	if err != nil {
+	if err := s.docRepo.Update(ctx, doc); err != nil {
 		return err
 	}
 
-	s.logger.Info("Document processed successfully", zap.String("document_id", documentID))
+	if s.metrics != nil {
+		s.metrics.ObserveIndexing(kb.ID, len(chunks), time.Since(start))
+	}
 	return nil
 }
 
+// chunkDocument 对文档正文和引用的图片分块
+// 图片文档的分块全部来自图片识别结果；其他文档中内嵌图片的分块追加在正文分块之后，
+// 单张内嵌图片识别失败只记录警告，不影响文档索引
+func (s *RAGService) chunkDocument(ctx context.Context, doc *domain.Document) ([]*domain.Chunk, error) {
+	images := findDocumentImages(doc)
+	if doc.Type == domain.DocumentTypeImage {
+		if len(images) == 0 {
+			return nil, fmt.Errorf("image document content must be an http(s) URL or data URI")
+		}
+		text, err := s.imageExtractor.ExtractText(ctx, images[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from image: %w", err)
+		}
+		content := imageChunkText(text)
+		if content == "" {
+			return nil, fmt.Errorf("no text found in image")
+		}
+		return s.chunkingService.ChunkImage(ctx, doc, chunkImageSource(images[0], text), content, 0)
+	}
+
+	chunks, err := s.chunkingService.ChunkDocument(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) > s.imageConfig.MaxImagesPerDocument {
+		s.logger.Warn("Document has more images than will be extracted",
+			zap.String("document_id", doc.ID),
+			zap.Int("images", len(images)),
+			zap.Int("limit", s.imageConfig.MaxImagesPerDocument))
+		images = images[:s.imageConfig.MaxImagesPerDocument]
+	}
+	for _, image := range images {
+		text, err := s.imageExtractor.ExtractText(ctx, image)
+		if err != nil {
+			s.logger.Warn("Failed to extract text from image",
+				zap.String("document_id", doc.ID),
+				zap.Int("image_index", image.Index),
+				zap.Error(err))
+			continue
+		}
+		// 替代文字已包含在正文中，识别结果只有替代文字时不单独分块
+		content := imageChunkText(text)
+		if content == "" || content == strings.TrimSpace(image.AltText) {
+			continue
+		}
+		imageChunks, err := s.chunkingService.ChunkImage(ctx, doc, chunkImageSource(image, text), content, len(chunks))
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, imageChunks...)
+	}
+	return chunks, nil
+}
+
+// markDocumentFailed 将文档标记为索引失败
+func (s *RAGService) markDocumentFailed(ctx context.Context, doc *domain.Document) {
+	doc.UpdateStatus(domain.DocumentStatusFailed)
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		s.logger.Error("Failed to mark document as failed",
+			zap.String("document_id", doc.ID),
+			zap.Error(err))
+	}
+}
+
+// lockDocument 获取文档的索引锁，返回释放函数
+// 锁只在当前实例内有效，多实例部署时同一文档的并发更新仍可能交错
+func (s *RAGService) lockDocument(documentID string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(documentID))
+	mu := &s.documentLocks[h.Sum32()%documentLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
 // Search 搜索相关内容
 func (s *RAGService) Search(ctx context.Context, query *domain.SearchQuery) (*domain.SearchResults, error) {
 	s.logger.Info("Searching knowledge base",
@@ -241,75 +708,190 @@ func (s *RAGService) Search(ctx context.Context, query *domain.SearchQuery) (*do
 
 	start := time.Now()
 
-	// 检查知识库
-	kb, err := s.kbRepo.FindByID(ctx, query.KnowledgeBaseID)
+	projection, err := domain.NewResultProjection(query.IncludeMetadata, query.Fields)
 	if err != nil {
 		return nil, err
 	}
-	if kb == nil {
-		return nil, domain.ErrKnowledgeBaseNotFoundf(query.KnowledgeBaseID)
+	if err := query.Level.Validate(); err != nil {
+		return nil, err
 	}
-	if !kb.CanBeQueried() {
-		return nil, domain.NewDomainError("KNOWLEDGE_BASE_NOT_QUERYABLE", "knowledge base cannot be queried")
+	if query.ContextExpansion != nil {
+		if err := query.ContextExpansion.Normalize(); err != nil {
+			return nil, err
+		}
 	}
 
-	// 生成查询向量
-	queryVector, err := s.embeddingService.GenerateEmbedding(ctx, query.Query)
+	// 检查知识库，请求用户需要读权限；文档级权限过滤只使用上下文中的请求用户
+	kb, err := s.findKnowledgeBase(ctx, query.KnowledgeBaseID)
 	if err != nil {
-		s.logger.Error("Failed to generate query embedding", zap.Error(err))
 		return nil, err
 	}
+	query.UserID = UserIDFromContext(ctx)
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, query.UserID, repository.PermissionRead); err != nil {
+		return nil, err
+	}
+	if !kb.CanBeQueried() {
+		return nil, domain.NewDomainError("KNOWLEDGE_BASE_NOT_QUERYABLE", "knowledge base cannot be queried")
+	}
 
-	// 构建向量查询
+	// 构建查询条件，多取一些候选以抵消权限过滤掉的结果；开启重排序时按rerank_top_n获取候选
+	mode := query.ResolveSearchMode()
+	rerankCandidates := kb.Settings.RerankCandidates(query.TopK)
+	candidateLimit := rerankCandidates * accessFilterOverfetch
 	vectorQuery := repository.NewVectorQuery(
 		s.getIndexName(query.KnowledgeBaseID),
-		queryVector,
-		query.TopK,
+		nil,
+		candidateLimit,
 	).WithScoreThreshold(query.ScoreThreshold)
 
 	// 添加过滤条件
-	if len(query.Filters.DocumentTypes) > 0 {
-		vectorQuery.WithFilter("document_type", query.Filters.DocumentTypes[0])
+	applySearchFilters(vectorQuery, query.Filters)
+	applySearchLevel(vectorQuery, query.Level)
+
+	// 并行执行向量检索和关键词检索
+	var (
+		wg            sync.WaitGroup
+		vectorResult  *repository.VectorSearchResult
+		keywordChunks []*domain.Chunk
+		vectorErr     error
+		keywordErr    error
+	)
+	if mode != domain.SearchModeKeyword {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vectorResult, vectorErr = s.searchVectors(ctx, kb, query.Query, vectorQuery)
+		}()
+	}
+	if mode != domain.SearchModeVector {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keywordChunks, keywordErr = s.chunkRepo.SearchByKeywords(ctx, kb.ID, extractKeywords(query.Query), candidateLimit)
+		}()
+	}
+	wg.Wait()
+	if vectorErr != nil {
+		return nil, vectorErr
+	}
+	if keywordErr != nil {
+		s.logger.Error("Failed to search keywords", zap.Error(keywordErr))
+		return nil, keywordErr
 	}
 
-	// 执行向量搜索
-	vectorResult, err := s.vectorRepo.Search(ctx, vectorQuery)
-	if err != nil {
-		s.logger.Error("Failed to search vectors", zap.Error(err))
-		return nil, err
+	// 关键词检索的分块未经向量库过滤，按同样的元数据过滤条件筛选
+	chunks := make(map[string]*domain.Chunk)
+	sources := make(map[string]string)
+	documents := make(map[string]*domain.Document)
+	keywordIDs := make([]string, 0, len(keywordChunks))
+	for _, chunk := range keywordChunks {
+		doc, loaded := documents[chunk.DocumentID]
+		if !loaded {
+			doc, _ = s.docRepo.FindByID(ctx, chunk.DocumentID)
+			documents[chunk.DocumentID] = doc
+		}
+		if doc == nil || !vectorQuery.MatchesFilter(vectorMetadata(doc, chunk)) {
+			continue
+		}
+		chunks[chunk.ID] = chunk
+		sources[chunk.ID] = doc.Source
+		keywordIDs = append(keywordIDs, chunk.ID)
 	}
 
-	// 转换搜索结果
-	results := domain.NewSearchResults(*query)
-	for _, match := range vectorResult.Results {
-		chunk, err := s.chunkRepo.FindByID(ctx, match.ID)
-		if err != nil {
+	// 融合排序，同一分块只保留一个候选
+	var candidates []rankedCandidate
+	if mode == domain.SearchModeVector {
+		candidates = make([]rankedCandidate, len(vectorResult.Results))
+		for i, match := range vectorResult.Results {
+			candidates[i] = rankedCandidate{chunkID: match.ID, score: match.Score}
+			sources[match.ID] = match.Metadata[repository.MetadataSource]
+		}
+	} else {
+		var vectorIDs []string
+		if vectorResult != nil {
+			vectorIDs = make([]string, len(vectorResult.Results))
+			for i, match := range vectorResult.Results {
+				vectorIDs[i] = match.ID
+				sources[match.ID] = match.Metadata[repository.MetadataSource]
+			}
+		}
+		candidates = fuseRankings(vectorIDs, keywordIDs, s.searchConfig.HybridVectorWeight, s.searchConfig.RRFK)
+	}
+
+	// 加载候选分块，关键词检索已加载的分块不再重复查询
+	hits := make([]searchHit, 0, len(candidates))
+	accessible := make(map[string]bool)
+	for _, candidate := range candidates {
+		chunk, loaded := chunks[candidate.chunkID]
+		if !loaded {
+			var err error
+			chunk, err = s.chunkRepo.FindByID(ctx, candidate.chunkID)
+			if err != nil {
+				// 向量库中残留已删除分块的向量时跳过，其他错误记录日志
+				if !errors.Is(err, repository.ErrNotFound) {
+					s.logger.Warn("Failed to load search candidate chunk",
+						zap.String("chunk_id", candidate.chunkID),
+						zap.Error(err))
+				}
+				continue
+			}
+		}
+
+		// 排除请求用户无权访问的文档
+		allowed, checked := accessible[chunk.DocumentID]
+		if !checked {
+			allowed = s.canAccessDocument(ctx, kb, chunk.DocumentID, query.UserID)
+			accessible[chunk.DocumentID] = allowed
+		}
+		if !allowed {
 			continue
 		}
 
-		result := domain.NewSearchResult(
-			chunk.ID,
-			chunk.Content,
-			chunk.Metadata.Title,
-			match.Metadata["source"],
-			match.Score,
-			domain.SearchResultTypeChunk,
-		)
+		hits = append(hits, searchHit{chunk: chunk, source: sources[chunk.ID], score: candidate.score})
+	}
 
-		// 设置分块信息
-		result.SetChunkInfo(&domain.ChunkInfo{
-			Position:   chunk.Position,
-			StartIndex: chunk.StartIndex,
-			EndIndex:   chunk.EndIndex,
-			TokenCount: chunk.TokenCount,
-			ChunkType:  string(chunk.Type),
-		})
+	// 重排序候选分块，重排失败时沿用检索顺序
+	if kb.Settings.EnableReranking {
+		if len(hits) > rerankCandidates {
+			hits = hits[:rerankCandidates]
+		}
+		reranked, err := rerankHits(ctx, s.reranker, query.Query, hits)
+		if err != nil {
+			s.logger.Warn("Failed to rerank search hits, using retrieval order",
+				zap.String("knowledge_base_id", kb.ID),
+				zap.Int("candidates", len(hits)),
+				zap.Error(err))
+		} else {
+			hits = reranked
+		}
+	}
 
-		results.AddResult(*result)
+	// 合并同一文档中重叠或相邻的分块，转换搜索结果
+	merged := mergeOverlappingHits(hits)
+	results := domain.NewSearchResults(*query)
+	for _, hit := range merged {
+		results.AddResult(*newChunkSearchResult(hit))
+	}
+
+	// 过滤低分结果，融合分数与相似度不可比，分数阈值只作用于向量检索；重排后作用于重排分数
+	if mode == domain.SearchModeVector {
+		results.FilterByScore(query.ScoreThreshold)
+	}
+	results.Truncate(query.TopK)
+
+	// 按投影返回元数据，只为截断后的结果加载文档
+	if !projection.IsEmpty() {
+		resultChunks := make(map[string]*domain.Chunk, len(hits))
+		for _, hit := range hits {
+			resultChunks[hit.chunk.ID] = hit.chunk
+		}
+		s.projectResults(ctx, results, projection, resultChunks, documents)
 	}
 
-	// 过滤低分结果
-	results.FilterByScore(query.ScoreThreshold)
+	// 扩展命中分块的前后文，只为截断后的结果加载文档分块
+	if query.ContextExpansion != nil {
+		s.expandResultContexts(ctx, results, merged, query.ContextExpansion)
+	}
 
 	// 记录查询统计
 	avgScore := float32(0)
@@ -324,6 +906,9 @@ func (s *RAGService) Search(ctx context.Context, query *domain.SearchQuery) (*do
 	s.kbRepo.Update(ctx, kb)
 
 	results.Duration = time.Since(start)
+	if s.metrics != nil {
+		s.metrics.ObserveSearch(kb.ID, string(mode), results.Duration, len(results.Results), float64(avgScore))
+	}
 	s.logger.Info("Search completed",
 		zap.Int("result_count", len(results.Results)),
 		zap.Duration("duration", results.Duration))
@@ -331,46 +916,191 @@ func (s *RAGService) Search(ctx context.Context, query *domain.SearchQuery) (*do
 	return results, nil
 }
 
-// DeleteDocument 删除文档
+// newChunkSearchResult 将合并后的分块转换为搜索结果，ID、标题和来源取自分数最高的分块
+func newChunkSearchResult(hit mergedHit) *domain.SearchResult {
+	best := hit.best.chunk
+	result := domain.NewSearchResult(
+		best.ID,
+		hit.content,
+		best.Metadata.Title,
+		hit.best.source,
+		hit.best.score,
+		domain.SearchResultTypeChunk,
+	)
+
+	info := &domain.ChunkInfo{
+		Position:   hit.chunks[0].Position,
+		StartIndex: hit.startIndex,
+		EndIndex:   hit.endIndex,
+		TokenCount: best.TokenCount,
+		ChunkType:  string(best.Type),
+		Image:      best.Image,
+	}
+	if len(hit.chunks) > 1 {
+		// 与Chunk.CalculateTokenCount相同的估算方式
+		info.TokenCount = len(hit.content) / 4
+		info.MergedChunkIDs = hit.chunkIDs()
+	}
+	result.SetChunkInfo(info)
+	return result
+}
+
+// projectResults 按投影将文档和分块元数据写入搜索结果，结果ID为分数最高的分块ID
+// documents为已加载的文档，文档加载失败时只写入分块元数据
+func (s *RAGService) projectResults(ctx context.Context, results *domain.SearchResults, projection *domain.ResultProjection, chunks map[string]*domain.Chunk, documents map[string]*domain.Document) {
+	for i := range results.Results {
+		result := &results.Results[i]
+		chunk := chunks[result.ID]
+		if chunk == nil {
+			continue
+		}
+
+		var doc *domain.Document
+		if projection.NeedsDocument() {
+			var loaded bool
+			doc, loaded = documents[chunk.DocumentID]
+			if !loaded {
+				var err error
+				doc, err = s.docRepo.FindByID(ctx, chunk.DocumentID)
+				if err != nil {
+					s.logger.Warn("Failed to load document for result metadata",
+						zap.String("document_id", chunk.DocumentID),
+						zap.Error(err))
+					doc = nil
+				}
+				documents[chunk.DocumentID] = doc
+			}
+		}
+		projection.Apply(result, doc, chunk)
+	}
+}
+
+// searchVectors 生成查询向量并执行向量检索
+func (s *RAGService) searchVectors(ctx context.Context, kb *domain.KnowledgeBase, queryText string, vectorQuery *repository.VectorQuery) (*repository.VectorSearchResult, error) {
+	start := time.Now()
+	queryVector, err := s.embeddingService.ForLanguage(kb.Settings.Language).GenerateEmbedding(ctx, queryText)
+	if s.metrics != nil {
+		s.metrics.ObserveEmbedding(metrics.EmbeddingOperationQuery, time.Since(start))
+	}
+	if err != nil {
+		s.logger.Error("Failed to generate query embedding", zap.Error(err))
+		return nil, err
+	}
+	vectorQuery.QueryVector = queryVector
+
+	vectorResult, err := s.vectorRepo.Search(ctx, vectorQuery)
+	if err != nil {
+		s.logger.Error("Failed to search vectors", zap.Error(err))
+		return nil, err
+	}
+	return vectorResult, nil
+}
+
+// DeleteDocument 软删除文档并删除其向量，请求用户需要知识库的写权限
 func (s *RAGService) DeleteDocument(ctx context.Context, documentID string) error {
-	doc, err := s.docRepo.FindByID(ctx, documentID)
+	doc, err := s.findDocument(ctx, documentID)
 	if err != nil {
 		return err
 	}
-	if doc == nil {
-		return domain.ErrDocumentNotFoundf(documentID)
+	kb, err := s.findKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionWrite); err != nil {
+		return err
 	}
 
-	// 删除向量索引
+	// 先从向量库删除分块向量，已删除的文档不再出现在检索结果中；删除失败时保留文档以便重试
 	chunks, err := s.chunkRepo.FindByDocumentID(ctx, doc.ID)
-	if err == nil {
-		chunkIDs := make([]string, len(chunks))
-		for i, chunk := range chunks {
-			chunkIDs[i] = chunk.ID
+	if err != nil {
+		return err
+	}
+	if err := s.deleteChunkVectors(ctx, doc, chunks); err != nil {
+		s.logger.Error("Failed to delete document vectors", zap.Error(err))
+		return err
+	}
+
+	// 软删除文档，分块保留到永久删除，恢复时按分块重新写入向量
+	if err := s.docRepo.Delete(ctx, doc.ID); err != nil {
+		s.logger.Error("Failed to delete document", zap.Error(err))
+		if syncErr := s.syncVectors(ctx, doc, chunks); syncErr != nil {
+			s.logger.Error("Failed to restore vectors of undeleted document",
+				zap.String("document_id", doc.ID),
+				zap.Error(syncErr))
 		}
-		indexName := s.getIndexName(doc.KnowledgeBaseID)
-		s.vectorRepo.Delete(ctx, indexName, chunkIDs)
+		return err
 	}
 
-	// 删除分块
-	err = s.chunkRepo.DeleteByDocumentID(ctx, doc.ID)
+	return nil
+}
+
+// RestoreDocument 恢复软删除的文档并按保留的分块重新写入向量，请求用户需要知识库的写权限
+// 知识库中已有内容相同的文档时返回DOCUMENT_ALREADY_EXISTS；向量写入失败的分块由向量同步任务补齐
+func (s *RAGService) RestoreDocument(ctx context.Context, documentID string) (*domain.Document, error) {
+	doc, err := s.docRepo.FindIncludingDeleted(ctx, documentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.ErrDocumentNotFoundf(documentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.findKnowledgeBase(ctx, doc.KnowledgeBaseID)
 	if err != nil {
-		s.logger.Error("Failed to delete chunks", zap.Error(err))
+		return nil, err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionWrite); err != nil {
+		return nil, err
+	}
+	if !doc.DeletedAt.Valid {
+		return doc, nil
+	}
+
+	if err := s.docRepo.Restore(ctx, doc.ID); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			existing, findErr := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, doc.Hash)
+			if findErr == nil && existing != nil {
+				return s.duplicateDocument(existing, false)
+			}
+		}
+		return nil, err
+	}
+	if doc, err = s.findDocument(ctx, doc.ID); err != nil {
+		return nil, err
 	}
 
-	// 删除文档
-	err = s.docRepo.Delete(ctx, documentID)
+	chunks, err := s.chunkRepo.FindByDocumentID(ctx, doc.ID)
 	if err != nil {
-		s.logger.Error("Failed to delete document", zap.Error(err))
-		return err
+		return nil, err
+	}
+	if len(chunks) > 0 {
+		if err := s.resyncDocumentChunks(ctx, doc, chunks); err != nil {
+			s.logger.Warn("Failed to rewrite vectors of restored document, vector sync will retry",
+				zap.String("document_id", doc.ID),
+				zap.Error(err))
+		}
 	}
 
-	return nil
+	s.logger.Info("Document restored",
+		zap.String("document_id", doc.ID),
+		zap.Int("chunk_count", len(chunks)))
+	return doc, nil
+}
+
+// deleteChunkVectors 从向量库删除分块向量
+func (s *RAGService) deleteChunkVectors(ctx context.Context, doc *domain.Document, chunks []*domain.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	chunkIDs := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		chunkIDs[i] = chunk.ID
+	}
+	return s.vectorRepo.Delete(ctx, s.getIndexName(doc.KnowledgeBaseID), chunkIDs)
 }
 
 // processDocumentAsync 异步处理文档
 func (s *RAGService) processDocumentAsync(ctx context.Context, documentID string) {
-	err := s.ProcessDocument(ctx, documentID)
+	err := s.processDocument(ctx, documentID)
 	if err != nil {
 		s.logger.Error("Failed to process document asynchronously",
 			zap.String("document_id", documentID),
@@ -378,55 +1108,397 @@ func (s *RAGService) processDocumentAsync(ctx context.Context, documentID string
 	}
 }
 
-// generateEmbeddings 生成向量嵌入
-func (s *RAGService) generateEmbeddings(ctx context.Context, chunks []*domain.Chunk) error {
-	indexName := ""
-	if len(chunks) > 0 {
-		doc, err := s.docRepo.FindByID(ctx, chunks[0].DocumentID)
-		if err != nil {
-			return err
-		}
-		indexName = s.getIndexName(doc.KnowledgeBaseID)
+// reindexDocumentAsync 异步重新索引文档
+func (s *RAGService) reindexDocumentAsync(ctx context.Context, documentID string) {
+	err := s.reindexDocument(ctx, documentID)
+	if err != nil {
+		s.logger.Error("Failed to reindex document asynchronously",
+			zap.String("document_id", documentID),
+			zap.Error(err))
 	}
+}
 
+// generateEmbeddings 为分块生成向量嵌入，不写入向量库
+func (s *RAGService) generateEmbeddings(ctx context.Context, doc *domain.Document, chunks []*domain.Chunk) error {
 	// 批量生成嵌入
 	texts := make([]string, len(chunks))
 	for i, chunk := range chunks {
 		texts[i] = chunk.Content
 	}
 
-	embeddings, err := s.embeddingService.GenerateEmbeddings(ctx, texts)
+	embeddingService, err := s.knowledgeBaseEmbedding(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	embeddings, err := s.embedTexts(ctx, embeddingService, texts)
 	if err != nil {
 		return err
 	}
 
 	// 更新分块的嵌入向量
-	vectorRecords := make([]repository.VectorRecord, len(chunks))
 	for i, chunk := range chunks {
 		err = chunk.SetEmbedding(embeddings[i])
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
 
+// embedTexts 批量生成嵌入向量，先查嵌入缓存，只将未命中的内容发送给嵌入提供商
+// 同一批次中内容相同的文本只请求一次；未命中的文本按EmbeddingBatchConfig拆分为子批次请求，结果保持原顺序
+func (s *RAGService) embedTexts(ctx context.Context, embeddingService EmbeddingService, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	model, dimension := embeddingService.GetModel(), embeddingService.GetDimension()
+
+	// 未命中的缓存键 -> 对应的文本下标
+	missing := make(map[string][]int)
+	var missKeys, missTexts []string
+	for i, text := range texts {
+		key := EmbeddingCacheKey(text, model, dimension)
+		if embedding, ok := s.embeddingCache.Get(ctx, key); ok && len(embedding) == dimension {
+			embeddings[i] = embedding
+			continue
+		}
+		if _, pending := missing[key]; !pending {
+			missKeys = append(missKeys, key)
+			missTexts = append(missTexts, text)
+		}
+		missing[key] = append(missing[key], i)
+	}
+
+	hits := len(texts) - countIndexes(missing)
+	metrics.ObserveCacheLookups("embedding", hits, len(texts)-hits)
+	if len(missTexts) == 0 {
+		return embeddings, nil
+	}
+
+	batches := splitEmbeddingBatches(missTexts, s.embeddingBatch.MaxInputs, s.embeddingBatch.MaxTokens)
+	for _, batch := range batches {
+		generated, err := s.embedBatch(ctx, embeddingService, missTexts[batch.start:batch.end], batch, len(missTexts))
+		if err != nil {
+			return nil, err
+		}
+
+		// 每个子批次成功后立即写入缓存，后续子批次失败时重新处理可以复用
+		for i, key := range missKeys[batch.start:batch.end] {
+			for _, index := range missing[key] {
+				embeddings[index] = generated[i]
+			}
+			s.embeddingCache.Set(ctx, key, generated[i])
+		}
+	}
+
+	s.logger.Debug("Generated embeddings",
+		zap.Int("total", len(texts)),
+		zap.Int("cache_hits", hits),
+		zap.Int("generated", len(missTexts)),
+		zap.Int("batches", len(batches)))
+	return embeddings, nil
+}
+
+// countIndexes 统计下标总数
+func countIndexes(indexes map[string][]int) int {
+	count := 0
+	for _, list := range indexes {
+		count += len(list)
+	}
+	return count
+}
+
+// knowledgeBaseEmbedding 获取知识库使用的嵌入服务
+// 同一知识库的文档和查询必须使用同一模型，模型按知识库的语言选择
+func (s *RAGService) knowledgeBaseEmbedding(ctx context.Context, knowledgeBaseID string) (EmbeddingService, error) {
+	kb, err := s.findKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	return s.embeddingService.ForLanguage(kb.Settings.Language), nil
+}
+
+// syncVectors 将分块向量写入向量数据库并记录同步状态
+// 写入失败时仍保存分块的嵌入和失败状态，由向量同步任务稍后重试
+func (s *RAGService) syncVectors(ctx context.Context, doc *domain.Document, chunks []*domain.Chunk) error {
+	vectorRecords := make([]repository.VectorRecord, len(chunks))
+	for i, chunk := range chunks {
 		vectorRecords[i] = repository.VectorRecord{
-			ID:     chunk.ID,
-			Vector: embeddings[i],
-			Metadata: map[string]string{
-				"document_id": chunk.DocumentID,
-				"chunk_type":  string(chunk.Type),
-				"position":    string(rune(chunk.Position)),
-			},
+			ID:       chunk.ID,
+			Vector:   chunk.Embedding,
+			Metadata: vectorMetadata(doc, chunk),
 		}
 	}
 
-	// 保存向量到向量数据库
-	err = s.vectorRepo.Insert(ctx, indexName, vectorRecords)
+	// 保存向量到向量数据库，同ID向量会被替换
+	syncErr := s.vectorRepo.Upsert(ctx, s.getIndexName(doc.KnowledgeBaseID), vectorRecords)
+	for _, chunk := range chunks {
+		if syncErr != nil {
+			chunk.MarkVectorSyncFailed(syncErr)
+		} else {
+			chunk.MarkVectorSynced()
+		}
+	}
+
+	// 更新分块
+	if err := s.chunkRepo.UpdateBatch(ctx, chunks); err != nil {
+		return err
+	}
+
+	return syncErr
+}
+
+// vectorMetadata 构建分块向量的元数据，包含搜索过滤使用的文档属性
+func vectorMetadata(doc *domain.Document, chunk *domain.Chunk) map[string]string {
+	metadata := map[string]string{
+		repository.MetadataDocumentID:   chunk.DocumentID,
+		repository.MetadataDocumentType: string(doc.Type),
+		repository.MetadataCreatedAt:    repository.FormatMetadataTime(doc.CreatedAt),
+		repository.MetadataChunkType:    string(chunk.Type),
+		"position":                      strconv.Itoa(chunk.Position),
+	}
+	if chunk.Image != nil {
+		metadata["image_index"] = strconv.Itoa(chunk.Image.Index)
+		if chunk.Image.URL != "" {
+			metadata["image_url"] = chunk.Image.URL
+		}
+	}
+	if doc.Source != "" {
+		metadata[repository.MetadataSource] = doc.Source
+	}
+	if doc.Language != "" {
+		metadata[repository.MetadataLanguage] = doc.Language
+	}
+	for _, tag := range doc.Tags {
+		metadata[repository.PrefixedKey(repository.MetadataTag, tag.Name)] = "true"
+	}
+
+	// 分块的自定义元数据覆盖文档的同名元数据
+	for key, value := range doc.Metadata.Custom {
+		metadata[repository.PrefixedKey(repository.MetadataCustom, key)] = value
+	}
+	for key, value := range chunk.Metadata.Custom {
+		metadata[repository.PrefixedKey(repository.MetadataCustom, key)] = value
+	}
+
+	return metadata
+}
+
+// applySearchFilters 将搜索过滤条件转换为向量查询条件
+// 不同字段之间为AND，同一字段的多个值之间为OR；标签匹配任一即可
+func applySearchFilters(vectorQuery *repository.VectorQuery, filters domain.SearchFilters) {
+	vectorQuery.WithFilterIn(repository.MetadataDocumentID, filters.DocumentIDs...)
+	vectorQuery.WithFilterIn(repository.MetadataDocumentType, filters.DocumentTypes...)
+	vectorQuery.WithFilterIn(repository.MetadataSource, filters.Sources...)
+	vectorQuery.WithFilterIn(repository.MetadataLanguage, filters.Languages...)
+	vectorQuery.WithCondition(repository.MetadataTag, repository.FilterOpHasAny, filters.Tags...)
+	if filters.DateRange != nil {
+		vectorQuery.WithTimeRange(repository.MetadataCreatedAt, filters.DateRange.Start, filters.DateRange.End)
+	}
+	for key, value := range filters.Custom {
+		vectorQuery.WithFilter(repository.PrefixedKey(repository.MetadataCustom, key), value)
+	}
+}
+
+// applySearchLevel 按检索粒度限制分块类型
+func applySearchLevel(vectorQuery *repository.VectorQuery, level domain.SearchLevel) {
+	switch level {
+	case domain.SearchLevelSummary:
+		vectorQuery.WithFilterIn(repository.MetadataChunkType, string(domain.ChunkTypeSummary))
+	case domain.SearchLevelDetail:
+		vectorQuery.WithFilterNotIn(repository.MetadataChunkType, string(domain.ChunkTypeSummary))
+	}
+}
+
+// ReconcileVectors 重新写入写入失败或长时间未写入的分块向量，返回成功同步的分块数
+// 所有分块同步完成后，因向量写入失败而标记为失败的文档会恢复为已索引
+func (s *RAGService) ReconcileVectors(ctx context.Context, config VectorSyncConfig) (int, error) {
+	chunks, err := s.chunkRepo.FindUnsyncedVectors(ctx, config.MaxAttempts, time.Now().Add(-config.StaleAfter), config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(chunks) == 0 {
+		return 0, nil
+	}
+
+	// 按文档分组，同一文档的分块写入同一索引
+	byDocument := make(map[string][]*domain.Chunk)
+	var documentIDs []string
+	for _, chunk := range chunks {
+		if _, exists := byDocument[chunk.DocumentID]; !exists {
+			documentIDs = append(documentIDs, chunk.DocumentID)
+		}
+		byDocument[chunk.DocumentID] = append(byDocument[chunk.DocumentID], chunk)
+	}
+
+	synced := 0
+	for _, documentID := range documentIDs {
+		docChunks := byDocument[documentID]
+
+		doc, err := s.docRepo.FindByID(ctx, documentID)
+		if err != nil {
+			s.logger.Warn("Skipping vector sync for missing document",
+				zap.String("document_id", documentID),
+				zap.Error(err))
+			continue
+		}
+
+		if err := s.resyncDocumentChunks(ctx, doc, docChunks); err != nil {
+			s.logger.Error("Failed to resync document vectors",
+				zap.String("document_id", documentID),
+				zap.Int("chunk_count", len(docChunks)),
+				zap.Error(err))
+			continue
+		}
+		synced += len(docChunks)
+
+		if err := s.restoreDocumentIfSynced(ctx, doc); err != nil {
+			s.logger.Error("Failed to restore document status",
+				zap.String("document_id", documentID),
+				zap.Error(err))
+		}
+	}
+
+	s.logger.Info("Vector reconciliation completed",
+		zap.Int("candidate_count", len(chunks)),
+		zap.Int("synced_count", synced))
+
+	return synced, nil
+}
+
+// resyncDocumentChunks 为缺少嵌入的分块补齐嵌入后重新写入向量库
+func (s *RAGService) resyncDocumentChunks(ctx context.Context, doc *domain.Document, chunks []*domain.Chunk) error {
+	var missing []*domain.Chunk
+	for _, chunk := range chunks {
+		if !chunk.HasEmbedding() {
+			missing = append(missing, chunk)
+		}
+	}
+
+	if len(missing) > 0 {
+		texts := make([]string, len(missing))
+		for i, chunk := range missing {
+			texts[i] = chunk.Content
+		}
+
+		embeddingService, err := s.knowledgeBaseEmbedding(ctx, doc.KnowledgeBaseID)
+		if err != nil {
+			return err
+		}
+		embeddings, err := s.embedTexts(ctx, embeddingService, texts)
+		if err != nil {
+			for _, chunk := range chunks {
+				chunk.MarkVectorSyncFailed(err)
+			}
+			if updateErr := s.chunkRepo.UpdateBatch(ctx, chunks); updateErr != nil {
+				s.logger.Error("Failed to record vector sync failure", zap.Error(updateErr))
+			}
+			return err
+		}
+
+		for i, chunk := range missing {
+			if err := chunk.SetEmbedding(embeddings[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.syncVectors(ctx, doc, chunks)
+}
+
+// restoreDocumentIfSynced 文档的所有分块均已同步时，将失败的文档恢复为已索引
+func (s *RAGService) restoreDocumentIfSynced(ctx context.Context, doc *domain.Document) error {
+	if doc.Status != domain.DocumentStatusFailed {
+		return nil
+	}
+
+	chunks, err := s.chunkRepo.FindByDocumentID(ctx, doc.ID)
 	if err != nil {
 		return err
 	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	for _, chunk := range chunks {
+		if !chunk.IsVectorSynced() {
+			return nil
+		}
+	}
 
-	// 更新分块
-	return s.chunkRepo.UpdateBatch(ctx, chunks)
+	if err := doc.UpdateStatus(domain.DocumentStatusIndexing); err != nil {
+		return err
+	}
+	if err := doc.UpdateStatus(domain.DocumentStatusIndexed); err != nil {
+		return err
+	}
+
+	s.logger.Info("Document recovered after vector sync", zap.String("document_id", doc.ID))
+	return s.docRepo.Update(ctx, doc)
+}
+
+// removeDocumentChunks 删除文档已有的分块和向量
+func (s *RAGService) removeDocumentChunks(ctx context.Context, doc *domain.Document) error {
+	chunks, err := s.chunkRepo.FindByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	if err := s.deleteChunkVectors(ctx, doc, chunks); err != nil {
+		return err
+	}
+
+	s.logger.Info("Removed stale chunks before reindexing",
+		zap.String("document_id", doc.ID),
+		zap.Int("chunk_count", len(chunks)))
+
+	return s.chunkRepo.DeleteByDocumentID(ctx, doc.ID)
+}
+
+// canAccessDocument 检查用户是否可以访问文档，知识库所有者可以访问所有文档
+// 文档加载失败时按无权限处理，避免受限文档泄露
+func (s *RAGService) canAccessDocument(ctx context.Context, kb *domain.KnowledgeBase, documentID, userID string) bool {
+	if userID != "" && userID == kb.OwnerID {
+		return true
+	}
+
+	doc, err := s.docRepo.FindByID(ctx, documentID)
+	if err != nil {
+		s.logger.Warn("Failed to load document for access check",
+			zap.String("document_id", documentID),
+			zap.Error(err))
+		return false
+	}
+
+	return doc.CanBeAccessedBy(userID)
+}
+
+// findKnowledgeBase 查找知识库，不存在时返回KNOWLEDGE_BASE_NOT_FOUND领域错误，数据库错误原样返回
+func (s *RAGService) findKnowledgeBase(ctx context.Context, id string) (*domain.KnowledgeBase, error) {
+	kb, err := s.kbRepo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.ErrKnowledgeBaseNotFoundf(id)
+	}
+	return kb, err
+}
+
+// findDocument 查找文档，不存在时返回DOCUMENT_NOT_FOUND领域错误，数据库错误原样返回
+func (s *RAGService) findDocument(ctx context.Context, id string) (*domain.Document, error) {
+	doc, err := s.docRepo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.ErrDocumentNotFoundf(id)
+	}
+	return doc, err
+}
+
+// findDocumentByHash 查找知识库中内容哈希相同的文档，不存在时返回nil
+func (s *RAGService) findDocumentByHash(ctx context.Context, knowledgeBaseID, hash string) (*domain.Document, error) {
+	doc, err := s.docRepo.FindByHash(ctx, knowledgeBaseID, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return doc, err
 }
 
 // getIndexName 获取索引名称
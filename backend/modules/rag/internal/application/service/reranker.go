@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RerankDocument 待重排的候选文本
+type RerankDocument struct {
+	ID      string
+	Content string
+	Score   float32 // 检索阶段的分数
+}
+
+// RerankResult 重排结果，Index为候选在输入中的下标
+type RerankResult struct {
+	Index int
+	Score float32
+}
+
+// Reranker 重排序器接口
+// 对检索得到的候选按与查询的相关性重新打分（如交叉编码器），返回按分数降序排列的结果，
+// 结果可以只包含部分候选，未返回的候选视为不相关
+type Reranker interface {
+	Rerank(ctx context.Context, query string, documents []RerankDocument) ([]RerankResult, error)
+}
+
+// NoopReranker 不调整顺序的重排序器，未配置重排序服务时使用
+type NoopReranker struct{}
+
+// NewNoopReranker 创建不调整顺序的重排序器
+func NewNoopReranker() *NoopReranker {
+	return &NoopReranker{}
+}
+
+// Rerank 按输入顺序返回候选，保留检索阶段的分数
+func (r *NoopReranker) Rerank(ctx context.Context, query string, documents []RerankDocument) ([]RerankResult, error) {
+	results := make([]RerankResult, len(documents))
+	for i, document := range documents {
+		results[i] = RerankResult{Index: i, Score: document.Score}
+	}
+	return results, nil
+}
+
+// RerankerConfig 重排序服务配置
+type RerankerConfig struct {
+	APIBase string        // 重排序服务地址，为空时不启用重排序服务
+	APIKey  string        // API密钥
+	Model   string        // 重排序模型
+	Timeout time.Duration // 单次请求超时
+}
+
+// DefaultRerankerConfig 默认重排序服务配置
+func DefaultRerankerConfig() RerankerConfig {
+	return RerankerConfig{
+		Model:   "bge-reranker-v2-m3",
+		Timeout: 10 * time.Second,
+	}
+}
+
+// rerankHits 使用重排序器调整候选分块的顺序和分数
+// 返回重排后的候选，重排序器未返回的候选被丢弃
+func rerankHits(ctx context.Context, reranker Reranker, query string, hits []searchHit) ([]searchHit, error) {
+	if len(hits) == 0 {
+		return hits, nil
+	}
+
+	documents := make([]RerankDocument, len(hits))
+	for i, hit := range hits {
+		documents[i] = RerankDocument{ID: hit.chunk.ID, Content: hit.chunk.Content, Score: hit.score}
+	}
+
+	results, err := reranker.Rerank(ctx, query, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	reranked := make([]searchHit, 0, len(results))
+	seen := make(map[int]bool, len(results))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(hits) {
+			return nil, fmt.Errorf("rerank result index %d out of range", result.Index)
+		}
+		if seen[result.Index] {
+			continue
+		}
+		seen[result.Index] = true
+
+		hit := hits[result.Index]
+		hit.score = result.Score
+		reranked = append(reranked, hit)
+	}
+	// 重排序器未保证顺序时按分数降序，分数相同时保持返回顺序
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].score > reranked[j].score
+	})
+	return reranked, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+)
+
+// searchHit 通过权限检查的候选分块，按排名顺序排列
+type searchHit struct {
+	chunk  *domain.Chunk
+	source string
+	score  float32
+}
+
+// mergedHit 同一文档中位置重叠或相邻的分块合并后的结果
+type mergedHit struct {
+	best       searchHit       // 分数最高的分块，作为结果的ID、标题和来源
+	chunks     []*domain.Chunk // 按起始位置排序的分块
+	content    string          // 去除重叠部分后拼接的内容
+	startIndex int
+	endIndex   int
+	rank       int // 最高分分块的原始排名，分数相同时保持原顺序
+}
+
+// mergeOverlappingHits 合并同一文档中位置重叠或相邻的分块
+// 分块重叠时相邻分块包含相同文本，检索同一位置会返回多个近似重复的结果；合并后的结果跨越这些分块，
+// 分数取其中最高分，结果按分数降序排列。位置信息与内容不一致的分块（如旧版本按字节记录位置）不参与合并
+func mergeOverlappingHits(hits []searchHit) []mergedHit {
+	byText := make(map[string][]int)
+	var keys []string
+	for i, hit := range hits {
+		key := mergeKey(hit.chunk)
+		if _, exists := byText[key]; !exists {
+			keys = append(keys, key)
+		}
+		byText[key] = append(byText[key], i)
+	}
+
+	merged := make([]mergedHit, 0, len(hits))
+	for _, key := range keys {
+		indexes := byText[key]
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return hits[indexes[a]].chunk.StartIndex < hits[indexes[b]].chunk.StartIndex
+		})
+
+		var current *mergedHit
+		for _, i := range indexes {
+			hit := hits[i]
+			if !hasRuneOffsets(hit.chunk) {
+				merged = append(merged, *newMergedHit(hit, i))
+				continue
+			}
+			if current != nil && hit.chunk.StartIndex <= current.endIndex {
+				current.extend(hit, i)
+				continue
+			}
+			if current != nil {
+				merged = append(merged, *current)
+			}
+			current = newMergedHit(hit, i)
+		}
+		if current != nil {
+			merged = append(merged, *current)
+		}
+	}
+
+	sort.SliceStable(merged, func(a, b int) bool {
+		if merged[a].best.score != merged[b].best.score {
+			return merged[a].best.score > merged[b].best.score
+		}
+		return merged[a].rank < merged[b].rank
+	})
+	return merged
+}
+
+// mergeKey 分块位置所在的文本：文档正文、文档摘要，或文档中某张图片识别出的文字
+// 图片分块的位置是在图片文字中的偏移，只与同一图片的分块合并；摘要分块不与正文分块合并
+func mergeKey(chunk *domain.Chunk) string {
+	if chunk.Type == domain.ChunkTypeSummary {
+		return chunk.DocumentID + "#summary"
+	}
+	if chunk.Image != nil {
+		return chunk.DocumentID + "#image-" + strconv.Itoa(chunk.Image.Index)
+	}
+	return chunk.DocumentID
+}
+
+// newMergedHit 以单个分块创建合并结果
+func newMergedHit(hit searchHit, rank int) *mergedHit {
+	return &mergedHit{
+		best:       hit,
+		chunks:     []*domain.Chunk{hit.chunk},
+		content:    hit.chunk.Content,
+		startIndex: hit.chunk.StartIndex,
+		endIndex:   hit.chunk.EndIndex,
+		rank:       rank,
+	}
+}
+
+// hasRuneOffsets 判断分块的位置是否与内容的字符数一致
+func hasRuneOffsets(chunk *domain.Chunk) bool {
+	return chunk.EndIndex > chunk.StartIndex &&
+		utf8.RuneCountInString(chunk.Content) == chunk.EndIndex-chunk.StartIndex
+}
+
+// extend 将分块并入合并结果，只追加超出当前范围的内容
+func (m *mergedHit) extend(hit searchHit, rank int) {
+	chunk := hit.chunk
+	m.chunks = append(m.chunks, chunk)
+	if chunk.EndIndex > m.endIndex {
+		runes := []rune(chunk.Content)
+		m.content += string(runes[m.endIndex-chunk.StartIndex:])
+		m.endIndex = chunk.EndIndex
+	}
+	if hit.score > m.best.score || (hit.score == m.best.score && rank < m.rank) {
+		m.best = hit
+		m.rank = rank
+	}
+}
+
+// chunkIDs 合并的分块ID，按位置排序
+func (m *mergedHit) chunkIDs() []string {
+	ids := make([]string, len(m.chunks))
+	for i, chunk := range m.chunks {
+		ids[i] = chunk.ID
+	}
+	return ids
+}
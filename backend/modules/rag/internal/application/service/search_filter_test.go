@@ -0,0 +1,108 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+)
+
+// filterTestChunks 为不同类型、来源和标签的文档各生成一个分块的向量元数据
+func filterTestChunks() map[string]map[string]string {
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	documents := []struct {
+		id       string
+		docType  domain.DocumentType
+		source   string
+		language string
+		tags     []string
+		created  time.Time
+	}{
+		{"pdf-1", domain.DocumentTypePDF, "wiki", "zh", []string{"api"}, created},
+		{"md-1", domain.DocumentTypeMarkdown, "git", "en", []string{"guide"}, created},
+		{"html-1", domain.DocumentTypeHTML, "wiki", "zh", []string{"api"}, created},
+		{"pdf-old", domain.DocumentTypePDF, "wiki", "zh", nil, created.AddDate(-1, 0, 0)},
+	}
+
+	metadata := make(map[string]map[string]string, len(documents))
+	for _, d := range documents {
+		doc := &domain.Document{Type: d.docType, Source: d.source, Language: d.language}
+		doc.ID = d.id
+		doc.CreatedAt = d.created
+		for _, name := range d.tags {
+			doc.Tags = append(doc.Tags, domain.Tag{Name: name})
+		}
+		chunk := &domain.Chunk{DocumentID: d.id, Type: domain.ChunkTypeText}
+		metadata[d.id] = vectorMetadata(doc, chunk)
+	}
+	return metadata
+}
+
+// matchingDocuments 返回满足查询过滤条件的文档ID
+func matchingDocuments(query *repository.VectorQuery, chunks map[string]map[string]string) map[string]bool {
+	matched := make(map[string]bool)
+	for id, metadata := range chunks {
+		if query.MatchesFilter(metadata) {
+			matched[id] = true
+		}
+	}
+	return matched
+}
+
+func TestApplySearchFiltersRestrictsResults(t *testing.T) {
+	chunks := filterTestChunks()
+	cases := []struct {
+		name    string
+		filters domain.SearchFilters
+		want    []string
+	}{
+		{"no filters", domain.SearchFilters{}, []string{"pdf-1", "md-1", "html-1", "pdf-old"}},
+		{"multiple types", domain.SearchFilters{DocumentTypes: []string{"pdf", "markdown"}}, []string{"pdf-1", "md-1", "pdf-old"}},
+		{"types and source", domain.SearchFilters{DocumentTypes: []string{"pdf", "html"}, Sources: []string{"wiki"}, Tags: []string{"api"}}, []string{"pdf-1", "html-1"}},
+		{"language", domain.SearchFilters{Languages: []string{"en"}}, []string{"md-1"}},
+		{"any tag", domain.SearchFilters{Tags: []string{"guide", "missing"}}, []string{"md-1"}},
+		{"date range", domain.SearchFilters{
+			DocumentTypes: []string{"pdf"},
+			DateRange:     &domain.DateRange{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}, []string{"pdf-1"}},
+		{"documents", domain.SearchFilters{DocumentIDs: []string{"md-1", "html-1"}}, []string{"md-1", "html-1"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			query := repository.NewVectorQuery("kb", []float32{1}, 10)
+			applySearchFilters(query, c.filters)
+
+			matched := matchingDocuments(query, chunks)
+			if len(matched) != len(c.want) {
+				t.Fatalf("matched %v, want %v", matched, c.want)
+			}
+			for _, id := range c.want {
+				if !matched[id] {
+					t.Fatalf("matched %v, want %v", matched, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplySearchFiltersCustomMetadata(t *testing.T) {
+	doc := &domain.Document{Type: domain.DocumentTypeText}
+	doc.Metadata.Custom = map[string]string{"team": "infra", "region": "cn"}
+	chunk := &domain.Chunk{DocumentID: "d1"}
+	chunk.Metadata.Custom = map[string]string{"region": "us"}
+	metadata := vectorMetadata(doc, chunk)
+
+	query := repository.NewVectorQuery("kb", []float32{1}, 10)
+	applySearchFilters(query, domain.SearchFilters{Custom: map[string]string{"team": "infra", "region": "us"}})
+	if !query.MatchesFilter(metadata) {
+		t.Fatalf("chunk custom metadata should override document metadata: %v", metadata)
+	}
+
+	query = repository.NewVectorQuery("kb", []float32{1}, 10)
+	applySearchFilters(query, domain.SearchFilters{Custom: map[string]string{"region": "cn"}})
+	if query.MatchesFilter(metadata) {
+		t.Fatal("custom filter should restrict results")
+	}
+}
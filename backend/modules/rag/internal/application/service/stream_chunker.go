@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"unicode"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+)
+
+// streamReadBufferSize 流式分块的读缓冲大小
+const streamReadBufferSize = 64 << 10
+
+// ChunkStream 边读边分块，每切出一个分块即交给emit，emit返回错误时停止读取
+// 文本按纯文本规则预处理，始终按固定大小分块，分块结果与对同一内容调用ChunkDocument的固定大小分块相同；
+// 内存中只保留一个分块窗口（分块大小加重叠部分）。未设置文档语言时按第一个窗口的文本检测语言并写回文档
+func (s *DefaultChunkingService) ChunkStream(ctx context.Context, document *domain.Document, r io.Reader, emit func(*domain.Chunk) error) error {
+	if document == nil {
+		return fmt.Errorf("document cannot be nil")
+	}
+
+	chunker := &streamChunker{
+		service:   s,
+		ctx:       ctx,
+		document:  document,
+		chunkType: s.getChunkTypeForDocument(document.Type),
+		size:      s.streamChunkSize(),
+		emit:      emit,
+	}
+
+	reader := bufio.NewReaderSize(r, streamReadBufferSize)
+	for {
+		ch, _, err := reader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := chunker.write(ch); err != nil {
+			return err
+		}
+	}
+	return chunker.close()
+}
+
+// streamChunkSize 流式分块的分块大小，不超过最大分块大小
+func (s *DefaultChunkingService) streamChunkSize() int {
+	if s.config.MaxChunkSize > 0 && s.config.ChunkSize > s.config.MaxChunkSize {
+		return s.config.MaxChunkSize
+	}
+	return s.config.ChunkSize
+}
+
+// streamChunker 流式分块状态
+// 文本规范化与preprocessText相同：统一换行符、去除每行首尾空白、合并连续空行；
+// 位置为规范化后文本中的字符（rune）偏移
+type streamChunker struct {
+	service   *DefaultChunkingService
+	ctx       context.Context
+	document  *domain.Document
+	chunkType domain.ChunkType
+	size      int
+	emit      func(*domain.Chunk) error
+
+	// 规范化状态
+	lineStarted  bool   // 当前行已有非空白字符
+	pendingSpace []rune // 行内尚未确定是否位于行尾的空白
+	afterCR      bool   // 上一个字符是\r，紧随的\n与其合为一个换行
+	hasLine      bool   // 已写出过行，之后的行前需要换行符
+	prevEmpty    bool   // 上一行是空行
+	hasContent   bool   // 读到过非空白字符
+
+	// 分块窗口，window[0]在规范化文本中的位置为offset
+	window   []rune
+	offset   int
+	start    int // 下一个分块的起始位置
+	position int // 下一个分块的序号
+
+	// 第一个窗口读满后按语言确定的分隔符
+	prepared      bool
+	separators    [][]rune
+	keepSeparator bool
+	lookahead     int // 分块末尾之后需要读到的字符数，为最长分隔符的长度且至少为1
+}
+
+// write 规范化一个字符并写入窗口
+func (c *streamChunker) write(ch rune) error {
+	if c.afterCR {
+		c.afterCR = false
+		if ch == '\n' {
+			return nil
+		}
+	}
+
+	switch {
+	case ch == '\r':
+		c.afterCR = true
+		c.endLine()
+		return nil
+	case ch == '\n':
+		c.endLine()
+		return nil
+	case unicode.IsSpace(ch):
+		if !c.lineStarted {
+			return nil
+		}
+		c.pendingSpace = append(c.pendingSpace, ch)
+		// 行内空白超过分块大小时直接写入窗口，保证内存有界
+		if len(c.pendingSpace) <= c.size {
+			return nil
+		}
+	default:
+		c.hasContent = true
+		if !c.lineStarted {
+			c.beginLine()
+			c.lineStarted = true
+		}
+	}
+
+	c.window = append(c.window, c.pendingSpace...)
+	c.pendingSpace = c.pendingSpace[:0]
+	if !unicode.IsSpace(ch) {
+		c.window = append(c.window, ch)
+	}
+	return c.flush(false)
+}
+
+// beginLine 开始写出一行，行之间以换行符分隔
+func (c *streamChunker) beginLine() {
+	if c.hasLine {
+		c.window = append(c.window, '\n')
+	}
+	c.hasLine = true
+}
+
+// endLine 结束当前行，行尾空白丢弃，连续空行只保留一个
+func (c *streamChunker) endLine() {
+	if c.lineStarted {
+		c.prevEmpty = false
+	} else if !c.prevEmpty {
+		c.beginLine()
+		c.prevEmpty = true
+	}
+	c.lineStarted = false
+	c.pendingSpace = c.pendingSpace[:0]
+}
+
+// close 读取结束，切出剩余的分块
+func (c *streamChunker) close() error {
+	c.endLine()
+	if !c.hasContent {
+		return domain.NewDomainError(domain.ErrDocumentInvalidContent, "document has no readable content")
+	}
+	return c.flush(true)
+}
+
+// prepare 按文档语言确定分隔符，未设置语言时按当前窗口检测
+func (c *streamChunker) prepare() {
+	if c.document.Language == "" {
+		c.document.Language = domain.DetectLanguage(string(c.window))
+	}
+	profile := c.service.languageProfile(c.document.Language)
+
+	c.separators = make([][]rune, len(profile.Separators))
+	c.lookahead = 1
+	for i, separator := range profile.Separators {
+		c.separators[i] = []rune(separator)
+		if len(c.separators[i]) > c.lookahead {
+			c.lookahead = len(c.separators[i])
+		}
+	}
+	c.keepSeparator = profile.KeepSeparator
+	c.prepared = true
+}
+
+// flush 切出窗口中已经可以确定的分块
+// 分块末尾之后还需要读到lookahead个字符，才能与完整文本上的分割点一致；final为true时切出全部剩余文本
+func (c *streamChunker) flush(final bool) error {
+	if !c.prepared {
+		if !final && len(c.window) < c.size {
+			return nil
+		}
+		c.prepare()
+	}
+
+	for {
+		textLen := c.offset + len(c.window)
+		end := c.start + c.size
+		if !final && textLen < end+c.lookahead {
+			return nil
+		}
+		if end > textLen {
+			end = textLen
+		}
+
+		// 窗口从start开始，分割点按窗口内的相对位置计算
+		actualEnd := c.offset + c.service.findBestSplitPoint(c.window, c.start-c.offset, end-c.offset, c.separators, c.keepSeparator)
+		if err := c.emitChunk(c.start, actualEnd); err != nil {
+			return err
+		}
+		if actualEnd >= textLen {
+			c.window = c.window[:0]
+			c.offset, c.start = textLen, textLen
+			return nil
+		}
+
+		// 计算下一个开始位置（考虑重叠），没有进展时强制移动
+		next := actualEnd - c.service.config.ChunkOverlap
+		if next <= c.start {
+			next = actualEnd
+		}
+		c.start = next
+
+		// 丢弃已经不再需要的文本
+		drop := c.start - c.offset
+		c.window = c.window[:copy(c.window, c.window[drop:])]
+		c.offset = c.start
+	}
+}
+
+// emitChunk 创建[start, end)的分块并交给emit，元数据与ChunkDocument相同
+func (c *streamChunker) emitChunk(start, end int) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	chunk, err := domain.NewChunk(c.document.ID, string(c.window[start-c.offset:end-c.offset]), c.chunkType, c.position)
+	if err != nil {
+		return fmt.Errorf("failed to create chunk %d: %w", c.position, err)
+	}
+	chunk.StartIndex = start
+	chunk.EndIndex = end
+	chunk.Metadata.Title = c.document.Title
+	if c.document.Metadata.Author != "" {
+		chunk.Metadata.Custom["author"] = c.document.Metadata.Author
+	}
+	if c.document.Source != "" {
+		chunk.Metadata.Custom["source"] = c.document.Source
+	}
+
+	c.position++
+	return c.emit(chunk)
+}
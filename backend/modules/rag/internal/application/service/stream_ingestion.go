@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// StreamIngestionConfig 流式导入配置
+type StreamIngestionConfig struct {
+	BatchSize        int   // 每批生成嵌入并保存的分块数，内存中最多保留一批分块
+	MaxDocumentBytes int64 // 单个文档的最大字节数，超过时中止导入，小于等于0表示不限制
+	MaxBufferedBytes int64 // 不能流式导入的上传文件（Markdown、HTML）读入内存的最大字节数，小于等于0表示不限制
+}
+
+// DefaultStreamIngestionConfig 默认流式导入配置
+func DefaultStreamIngestionConfig() StreamIngestionConfig {
+	return StreamIngestionConfig{
+		BatchSize:        32,
+		MaxDocumentBytes: 100 << 20,
+		MaxBufferedBytes: 10 << 20,
+	}
+}
+
+// IsStreamableDocumentType 文档类型是否支持流式导入
+// 只支持按纯文本预处理的类型，HTML和Markdown需要完整内容才能解析结构，图片需要识别文字
+func IsStreamableDocumentType(docType domain.DocumentType) bool {
+	switch docType {
+	case domain.DocumentTypeText, domain.DocumentTypePDF, domain.DocumentTypeWord:
+		return true
+	default:
+		return false
+	}
+}
+
+// AddDocumentFromReader 流式导入文档：边读边分块，分块按批生成嵌入、保存并写入向量，不在内存中保留全文
+// 与AddDocument不同，导入在调用中同步完成，返回时文档已索引；文档不保存全文，因此不生成摘要，也不能按内容重新索引。
+// 读取完成后按内容哈希检查重复，重复时删除已导入的分块；导入失败时删除已保存的分块并将文档标记为失败
+func (s *RAGService) AddDocumentFromReader(ctx context.Context, cmd *AddDocumentCommand, r io.Reader) (*domain.Document, error) {
+	start := time.Now()
+	s.logger.Info("Streaming document into knowledge base",
+		zap.String("title", cmd.Title),
+		zap.String("knowledge_base_id", cmd.KnowledgeBaseID))
+
+	if !IsStreamableDocumentType(cmd.Type) {
+		return nil, domain.ErrUnsupportedDocumentTypef(cmd.Type)
+	}
+
+	kb, err := s.findKnowledgeBase(ctx, cmd.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkKnowledgeBaseAccess(ctx, kb, UserIDFromContext(ctx), repository.PermissionWrite); err != nil {
+		return nil, err
+	}
+
+	doc, err := domain.NewStreamedDocument(cmd.Title, cmd.Type, cmd.Source)
+	if err != nil {
+		return nil, err
+	}
+	doc.KnowledgeBaseID = cmd.KnowledgeBaseID
+	doc.Language = cmd.Language
+	if cmd.Metadata != nil {
+		doc.Metadata = *cmd.Metadata
+	}
+	if err := setDocumentOwner(ctx, doc, cmd.AccessLevel, cmd.AllowedUsers); err != nil {
+		return nil, err
+	}
+
+	// 导入过程中文档处于索引中状态
+	if err := doc.UpdateStatus(domain.DocumentStatusIndexing); err != nil {
+		return nil, err
+	}
+	if err := s.docRepo.Save(ctx, doc); err != nil {
+		s.logger.Error("Failed to save document", zap.Error(err))
+		return nil, err
+	}
+
+	reader := &documentSizeReader{reader: r, limit: s.streamConfig.MaxDocumentBytes}
+	hash := sha256.New()
+	batch := &chunkBatch{
+		size: s.streamConfig.BatchSize,
+		flush: func(chunks []*domain.Chunk) error {
+			// 内容无法识别语言时沿用知识库的语言，第一批写入向量前确定
+			if doc.Language == "" {
+				doc.Language = kb.Settings.Language
+			}
+			return s.indexChunkBatch(ctx, doc, chunks)
+		},
+	}
+
+	err = s.chunkingService.ChunkStream(ctx, doc, io.TeeReader(reader, hash), batch.add)
+	if err == nil {
+		err = batch.close()
+	}
+	if err != nil {
+		s.logger.Error("Failed to stream document",
+			zap.String("document_id", doc.ID),
+			zap.Int("indexed_chunks", batch.total),
+			zap.Int64("bytes_read", reader.size),
+			zap.Error(err))
+		// 客户端断开时请求上下文已取消，清理不随请求取消
+		cleanupCtx := context.WithoutCancel(ctx)
+		if removeErr := s.removeDocumentChunks(cleanupCtx, doc); removeErr != nil {
+			s.logger.Error("Failed to remove chunks of failed document",
+				zap.String("document_id", doc.ID),
+				zap.Error(removeErr))
+		}
+		s.markDocumentFailed(cleanupCtx, doc)
+		return nil, err
+	}
+
+	// 知识库中已有内容相同的文档时丢弃本次导入
+	contentHash := hex.EncodeToString(hash.Sum(nil))
+	existing, err := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, contentHash)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		s.discardStreamedDocument(ctx, doc)
+		return s.duplicateDocument(existing, cmd.SkipDuplicates)
+	}
+
+	doc.CompleteStream(contentHash, reader.size)
+	if err := doc.UpdateStatus(domain.DocumentStatusIndexed); err != nil {
+		return nil, err
+	}
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		// 并发导入了相同内容的文档
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			if existing, findErr := s.findDocumentByHash(ctx, doc.KnowledgeBaseID, contentHash); findErr == nil && existing != nil {
+				s.discardStreamedDocument(ctx, doc)
+				return s.duplicateDocument(existing, cmd.SkipDuplicates)
+			}
+		}
+		s.logger.Error("Failed to update document", zap.Error(err))
+		return nil, err
+	}
+
+	if s.metrics != nil {
+		s.metrics.ObserveIndexing(kb.ID, batch.total, time.Since(start))
+	}
+
+	s.logger.Info("Document streamed successfully",
+		zap.String("id", doc.ID),
+		zap.Int64("size", doc.Size),
+		zap.Int("chunk_count", batch.total),
+		zap.Duration("duration", time.Since(start)))
+	return doc, nil
+}
+
+// AddUploadedDocument 添加上传的文件：可流式导入的类型边读边索引，Markdown和HTML需要完整内容解析结构，读入内存后按AddDocument添加
+// 读入内存的文件不超过MaxBufferedBytes，超过时返回DOCUMENT_TOO_LARGE
+func (s *RAGService) AddUploadedDocument(ctx context.Context, cmd *AddDocumentCommand, r io.Reader) (*domain.Document, error) {
+	if !domain.IsUploadableDocumentType(cmd.Type) {
+		return nil, domain.ErrUnsupportedDocumentTypef(cmd.Type)
+	}
+	if IsStreamableDocumentType(cmd.Type) {
+		return s.AddDocumentFromReader(ctx, cmd, r)
+	}
+
+	content, err := io.ReadAll(&documentSizeReader{reader: r, limit: s.streamConfig.MaxBufferedBytes})
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return nil, domain.NewDomainError(domain.ErrDocumentInvalidContent, "document has no readable content")
+	}
+	cmd.Content = string(content)
+	return s.AddDocument(ctx, cmd)
+}
+
+// indexChunkBatch 为一批分块生成嵌入、保存分块并写入向量
+func (s *RAGService) indexChunkBatch(ctx context.Context, doc *domain.Document, chunks []*domain.Chunk) error {
+	if err := s.generateEmbeddings(ctx, doc, chunks); err != nil {
+		return err
+	}
+	if err := s.chunkRepo.SaveBatch(ctx, chunks); err != nil {
+		return err
+	}
+	return s.syncVectors(ctx, doc, chunks)
+}
+
+// discardStreamedDocument 删除重复的流式导入文档及其分块和向量，失败只记录日志
+func (s *RAGService) discardStreamedDocument(ctx context.Context, doc *domain.Document) {
+	if err := s.removeDocumentChunks(ctx, doc); err != nil {
+		s.logger.Error("Failed to remove chunks of duplicate document",
+			zap.String("document_id", doc.ID),
+			zap.Error(err))
+	}
+	if err := s.docRepo.Delete(ctx, doc.ID); err != nil {
+		s.logger.Error("Failed to delete duplicate document",
+			zap.String("document_id", doc.ID),
+			zap.Error(err))
+	}
+}
+
+// chunkBatch 按批处理流式分块，内存中最多保留一批分块
+type chunkBatch struct {
+	size   int
+	flush  func([]*domain.Chunk) error
+	chunks []*domain.Chunk
+	total  int // 已处理的分块数
+}
+
+// add 追加分块，攒满一批时处理
+func (b *chunkBatch) add(chunk *domain.Chunk) error {
+	b.chunks = append(b.chunks, chunk)
+	if len(b.chunks) < b.size {
+		return nil
+	}
+	return b.close()
+}
+
+// close 处理剩余的分块
+func (b *chunkBatch) close() error {
+	if len(b.chunks) == 0 {
+		return nil
+	}
+	if err := b.flush(b.chunks); err != nil {
+		return err
+	}
+	b.total += len(b.chunks)
+
+	// 释放已处理的分块，复用切片
+	for i := range b.chunks {
+		b.chunks[i] = nil
+	}
+	b.chunks = b.chunks[:0]
+	return nil
+}
+
+// documentSizeReader 统计读取的字节数，超过上限时返回DOCUMENT_TOO_LARGE领域错误
+type documentSizeReader struct {
+	reader io.Reader
+	limit  int64
+	size   int64
+}
+
+func (r *documentSizeReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	if r.limit > 0 && r.size > r.limit {
+		return 0, domain.ErrDocumentTooLargef(r.limit)
+	}
+	return n, err
+}
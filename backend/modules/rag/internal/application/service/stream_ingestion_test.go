@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// syntheticReader 按需生成size字节的重复文本，不在内存中保留全文
+type syntheticReader struct {
+	line []byte
+	size int64
+	read int64
+}
+
+func newSyntheticReader(size int64) *syntheticReader {
+	return &syntheticReader{line: []byte("The deployment guide repeats this sentence to build a large document.\n"), size: size}
+}
+
+func (r *syntheticReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && r.read < r.size {
+		p[n] = r.line[r.read%int64(len(r.line))]
+		n++
+		r.read++
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// batchChunkRepository 只记录保存的分块数和批次大小，不保留分块
+type batchChunkRepository struct {
+	repository.ChunkRepository
+	reader      *syntheticReader
+	saved       int
+	maxBatch    int
+	readAtFirst int64 // 第一次保存分块时已读取的字节数
+}
+
+func (r *batchChunkRepository) SaveBatch(ctx context.Context, chunks []*domain.Chunk) error {
+	if r.saved == 0 {
+		r.readAtFirst = r.reader.read
+	}
+	r.saved += len(chunks)
+	if len(chunks) > r.maxBatch {
+		r.maxBatch = len(chunks)
+	}
+	return nil
+}
+
+func (r *batchChunkRepository) UpdateBatch(ctx context.Context, chunks []*domain.Chunk) error {
+	return nil
+}
+
+func newStreamTestService(t *testing.T, chunkRepo repository.ChunkRepository, embedder EmbeddingService, streamConfig StreamIngestionConfig) (*RAGService, *memDocumentRepository, *memVectorRepository, string) {
+	t.Helper()
+	kb, err := domain.NewKnowledgeBase("kb", "", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kbRepo := &memKnowledgeBaseRepository{knowledgeBases: map[string]*domain.KnowledgeBase{kb.ID: kb}}
+	docRepo := &memDocumentRepository{}
+	vectorRepo := &memVectorRepository{indexes: map[string]map[string]bool{}}
+	config := DefaultChunkingConfig()
+	config.ChunkSize = 200
+	config.ChunkOverlap = 0
+	service := NewRAGService(kbRepo, docRepo, chunkRepo, vectorRepo, embedder, noopEmbeddingCache{},
+		NewDefaultChunkingService(config), nil, nil, ImageExtractionConfig{}, nil, DefaultSearchConfig(),
+		streamConfig, EmbeddingBatchConfig{}, AccessControlConfig{}, nil, zap.NewNop())
+	return service, docRepo, vectorRepo, kb.ID
+}
+
+func newStreamCommand(knowledgeBaseID string) *AddDocumentCommand {
+	return &AddDocumentCommand{
+		Title:           "部署手册",
+		Type:            domain.DocumentTypeText,
+		Language:        "en",
+		KnowledgeBaseID: knowledgeBaseID,
+	}
+}
+
+func TestAddDocumentFromReaderFlushesInBatches(t *testing.T) {
+	const size = 4 << 20
+	reader := newSyntheticReader(size)
+	chunkRepo := &batchChunkRepository{reader: reader}
+	embedder := &recordingEmbedder{}
+	streamConfig := StreamIngestionConfig{BatchSize: 16, MaxDocumentBytes: 2 * size}
+	service, _, vectorRepo, kbID := newStreamTestService(t, chunkRepo, embedder, streamConfig)
+	ctx := WithUserID(context.Background(), "owner")
+
+	doc, err := service.AddDocumentFromReader(ctx, newStreamCommand(kbID), reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Status != domain.DocumentStatusIndexed || !doc.Streamed || doc.Size != size {
+		t.Fatalf("document status %s, streamed %v, size %d", doc.Status, doc.Streamed, doc.Size)
+	}
+	if doc.Content != "" {
+		t.Fatal("streamed document kept its content")
+	}
+
+	// 分块按批处理，第一批在读完读缓冲之前写入，内存占用与文档大小无关
+	if chunkRepo.saved < size/200 {
+		t.Fatalf("saved %d chunks, want at least %d", chunkRepo.saved, size/200)
+	}
+	if chunkRepo.maxBatch > streamConfig.BatchSize {
+		t.Fatalf("saved a batch of %d chunks, batch size is %d", chunkRepo.maxBatch, streamConfig.BatchSize)
+	}
+	if chunkRepo.readAtFirst > 2*streamReadBufferSize {
+		t.Fatalf("read %d bytes before the first batch was saved", chunkRepo.readAtFirst)
+	}
+	embedded := 0
+	for _, request := range embedder.requests {
+		if len(request) > streamConfig.BatchSize {
+			t.Fatalf("embedded a batch of %d texts, batch size is %d", len(request), streamConfig.BatchSize)
+		}
+		for _, text := range request {
+			if n := len([]rune(text)); n > 200 {
+				t.Fatalf("chunk has %d runes, chunk size is 200", n)
+			}
+		}
+		embedded += len(request)
+	}
+	if embedded != chunkRepo.saved {
+		t.Fatalf("embedded %d chunks, saved %d", embedded, chunkRepo.saved)
+	}
+	if got := len(vectorRepo.ids(service.getIndexName(kbID))); got != chunkRepo.saved {
+		t.Fatalf("wrote %d vectors, saved %d chunks", got, chunkRepo.saved)
+	}
+}
+
+func TestAddDocumentFromReaderRejectsOversizedDocument(t *testing.T) {
+	const limit = 1 << 20
+	reader := newSyntheticReader(2 * limit)
+	chunkRepo := &memChunkRepository{chunks: map[string]*domain.Chunk{}}
+	service, docRepo, vectorRepo, kbID := newStreamTestService(t, chunkRepo, &recordingEmbedder{},
+		StreamIngestionConfig{BatchSize: 16, MaxDocumentBytes: limit})
+	ctx := WithUserID(context.Background(), "owner")
+
+	_, err := service.AddDocumentFromReader(ctx, newStreamCommand(kbID), reader)
+	var domainErr *domain.DomainError
+	if !errors.As(err, &domainErr) || domainErr.Code != domain.ErrDocumentTooLarge {
+		t.Fatalf("expected document too large error, got %v", err)
+	}
+
+	// 超过上限后停止读取，已导入的分块和向量被删除，文档标记为失败
+	if reader.read > limit+streamReadBufferSize {
+		t.Fatalf("read %d bytes of a document limited to %d", reader.read, limit)
+	}
+	if len(chunkRepo.chunks) != 0 {
+		t.Fatalf("%d chunks left after the failed import", len(chunkRepo.chunks))
+	}
+	if ids := vectorRepo.ids(service.getIndexName(kbID)); len(ids) != 0 {
+		t.Fatalf("%d vectors left after the failed import", len(ids))
+	}
+	if n := docRepo.count(); n != 1 {
+		t.Fatalf("saved %d documents, want 1", n)
+	}
+	if status := docRepo.documents[0].Status; status != domain.DocumentStatusFailed {
+		t.Fatalf("document status = %s, want %s", status, domain.DocumentStatusFailed)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ErrSummarizerNotConfigured 未配置摘要模型
+var ErrSummarizerNotConfigured = errors.New("summarization model is not configured")
+
+// DocumentSummarizer 文档摘要生成器接口
+// 为开启摘要的知识库中的长文档生成摘要，摘要保存在文档上，并可作为摘要分块参与粗粒度检索
+type DocumentSummarizer interface {
+	Summarize(ctx context.Context, doc *domain.Document) (string, error)
+}
+
+// NoopDocumentSummarizer 不生成摘要的生成器，未配置摘要模型时使用
+type NoopDocumentSummarizer struct{}
+
+// NewNoopDocumentSummarizer 创建不生成摘要的生成器
+func NewNoopDocumentSummarizer() *NoopDocumentSummarizer {
+	return &NoopDocumentSummarizer{}
+}
+
+// Summarize 返回ErrSummarizerNotConfigured，知识库开启摘要但服务未配置模型时在日志中提示
+func (s *NoopDocumentSummarizer) Summarize(ctx context.Context, doc *domain.Document) (string, error) {
+	return "", ErrSummarizerNotConfigured
+}
+
+// SummarizationConfig 文档摘要配置
+type SummarizationConfig struct {
+	APIBase       string        // 大模型服务地址（OpenAI兼容），为空时不生成摘要
+	APIKey        string        // API密钥
+	Model         string        // 摘要模型
+	Timeout       time.Duration // 单个文档的摘要超时
+	MaxInputChars int           // 交给模型的正文上限（字符数），超出部分截断
+	MaxTokens     int           // 摘要的最大令牌数
+}
+
+// DefaultSummarizationConfig 默认文档摘要配置
+func DefaultSummarizationConfig() SummarizationConfig {
+	return SummarizationConfig{
+		Model:         "gpt-4o-mini",
+		Timeout:       60 * time.Second,
+		MaxInputChars: 12000,
+		MaxTokens:     400,
+	}
+}
+
+// TruncateForSummary 按字符截断交给模型的正文，不截断时原样返回
+func TruncateForSummary(content string, maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(content) <= maxChars {
+		return content
+	}
+	return string([]rune(content)[:maxChars])
+}
+
+// summarizeDocument 按知识库设置生成文档摘要并保存在文档上
+// 不需要摘要的文档清除旧摘要；生成失败只记录警告并清除旧摘要，旧摘要可能与新内容不符，不影响文档索引
+func (s *RAGService) summarizeDocument(ctx context.Context, kb *domain.KnowledgeBase, doc *domain.Document) {
+	if doc.Type == domain.DocumentTypeImage || !kb.Settings.ShouldSummarize(utf8.RuneCountInString(doc.Content)) {
+		if doc.Summary != "" {
+			doc.SetSummary("")
+		}
+		return
+	}
+
+	summary, err := s.summarizer.Summarize(ctx, doc)
+	if err != nil {
+		s.logger.Warn("Failed to summarize document",
+			zap.String("document_id", doc.ID),
+			zap.String("knowledge_base_id", kb.ID),
+			zap.Error(err))
+		summary = ""
+	}
+	doc.SetSummary(strings.TrimSpace(summary))
+}
+
+// newSummaryChunk 以文档摘要创建摘要分块，位置排在正文和图片分块之后
+func newSummaryChunk(doc *domain.Document, position int) (*domain.Chunk, error) {
+	chunk, err := domain.NewChunk(doc.ID, doc.Summary, domain.ChunkTypeSummary, position)
+	if err != nil {
+		return nil, err
+	}
+	chunk.Metadata.Title = doc.Title
+	if doc.Metadata.Author != "" {
+		chunk.Metadata.Custom["author"] = doc.Metadata.Author
+	}
+	if doc.Source != "" {
+		chunk.Metadata.Custom["source"] = doc.Source
+	}
+	return chunk, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// VectorSyncConfig 向量同步任务配置
+type VectorSyncConfig struct {
+	Interval    time.Duration // 执行间隔
+	BatchSize   int           // 每次处理的分块数
+	MaxAttempts int           // 单个分块的最大重试次数
+	StaleAfter  time.Duration // 待写入状态超过该时长视为卡住
+}
+
+// DefaultVectorSyncConfig 默认向量同步任务配置
+func DefaultVectorSyncConfig() VectorSyncConfig {
+	return VectorSyncConfig{
+		Interval:    time.Minute,
+		BatchSize:   200,
+		MaxAttempts: 10,
+		StaleAfter:  10 * time.Minute,
+	}
+}
+
+// VectorSyncWorker 向量同步任务，定期重试写入失败或卡住的分块向量，使数据库与向量库最终一致
+type VectorSyncWorker struct {
+	ragService *RAGService
+	config     VectorSyncConfig
+	logger     infrastructure.Logger
+}
+
+// NewVectorSyncWorker 创建向量同步任务
+func NewVectorSyncWorker(ragService *RAGService, config VectorSyncConfig, logger infrastructure.Logger) *VectorSyncWorker {
+	defaults := DefaultVectorSyncConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = defaults.StaleAfter
+	}
+
+	return &VectorSyncWorker{
+		ragService: ragService,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// Run 运行同步任务直到ctx取消
+func (w *VectorSyncWorker) Run(ctx context.Context) {
+	w.logger.Info("Vector sync worker started",
+		zap.Duration("interval", w.config.Interval),
+		zap.Int("batch_size", w.config.BatchSize),
+		zap.Int("max_attempts", w.config.MaxAttempts))
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Vector sync worker stopped")
+			return
+		case <-ticker.C:
+			if _, err := w.ragService.ReconcileVectors(ctx, w.config); err != nil {
+				w.logger.Error("Failed to reconcile vectors", zap.Error(err))
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"time"
+	"unicode/utf8"
 
 	"github.com/noah-loop/backend/shared/pkg/domain"
 )
@@ -16,6 +17,17 @@ const (
 	ChunkTypeSection   ChunkType = "section"   // 章节分块
 	ChunkTypeTable     ChunkType = "table"     // 表格分块
 	ChunkTypeCode      ChunkType = "code"      // 代码分块
+	ChunkTypeImage     ChunkType = "image"     // 图片分块，内容为从图片中识别的文字和描述
+	ChunkTypeSummary   ChunkType = "summary"   // 摘要分块，内容为自动生成的文档摘要，用于粗粒度检索
+)
+
+// VectorSyncStatus 分块向量同步状态
+type VectorSyncStatus string
+
+const (
+	VectorSyncPending VectorSyncStatus = "pending" // 待写入向量库
+	VectorSyncSynced  VectorSyncStatus = "synced"  // 已写入向量库
+	VectorSyncFailed  VectorSyncStatus = "failed"  // 写入失败，等待重试
 )
 
 // Chunk 文档分块实体
@@ -25,12 +37,17 @@ type Chunk struct {
 	Content      string             `gorm:"type:text;not null" json:"content"`
 	Type         ChunkType          `gorm:"not null" json:"type"`
 	Position     int                `gorm:"not null" json:"position"`     // 在文档中的位置
-	StartIndex   int                `json:"start_index"`                  // 在原文档中的开始索引
-	EndIndex     int                `json:"end_index"`                    // 在原文档中的结束索引
+	StartIndex   int                `json:"start_index"`                  // 在原文档中的开始位置（字符偏移）
+	EndIndex     int                `json:"end_index"`                    // 在原文档中的结束位置（字符偏移，不含）
 	TokenCount   int                `json:"token_count"`                  // 令牌数量
 	Embedding    []float32          `gorm:"type:jsonb" json:"embedding"`  // 向量嵌入
 	Metadata     ChunkMetadata      `gorm:"embedded" json:"metadata"`
+	Image        *ChunkImage        `gorm:"type:jsonb;serializer:json" json:"image,omitempty"` // 图片分块的来源图片，其他分块为空
 	Similarities []ChunkSimilarity  `json:"similarities,omitempty"`      // 相似度缓存
+	VectorStatus       VectorSyncStatus `gorm:"not null;default:'pending';index" json:"vector_status"`
+	VectorSyncAttempts int              `json:"vector_sync_attempts"`
+	VectorSyncError    string           `json:"vector_sync_error,omitempty"`
+	VectorSyncedAt     *time.Time       `json:"vector_synced_at,omitempty"`
 	CreatedAt    time.Time          `json:"created_at"`
 	UpdatedAt    time.Time          `json:"updated_at"`
 	EmbeddedAt   *time.Time         `json:"embedded_at,omitempty"`
@@ -46,6 +63,14 @@ type ChunkMetadata struct {
 	Custom    map[string]string `gorm:"serializer:json" json:"custom,omitempty"`
 }
 
+// ChunkImage 图片分块的来源图片
+type ChunkImage struct {
+	URL     string `json:"url,omitempty"`      // 图片地址，内嵌的data URI不保存，通过文档和Index定位
+	Index   int    `json:"index"`              // 图片在文档中的序号，从0开始
+	AltText string `json:"alt_text,omitempty"` // 文档中的替代文字
+	Caption string `json:"caption,omitempty"`  // 识别得到的图片描述
+}
+
 // ChunkSimilarity 分块相似度
 type ChunkSimilarity struct {
 	ChunkID    string  `json:"chunk_id"`
@@ -72,6 +97,28 @@ func (c *Chunk) HasEmbedding() bool {
 	return len(c.Embedding) > 0
 }
 
+// MarkVectorSynced 标记向量已写入向量库
+func (c *Chunk) MarkVectorSynced() {
+	now := time.Now()
+	c.VectorStatus = VectorSyncSynced
+	c.VectorSyncError = ""
+	c.VectorSyncedAt = &now
+	c.UpdatedAt = now
+}
+
+// MarkVectorSyncFailed 记录向量写入失败，累计尝试次数
+func (c *Chunk) MarkVectorSyncFailed(err error) {
+	c.VectorStatus = VectorSyncFailed
+	c.VectorSyncAttempts++
+	c.VectorSyncError = err.Error()
+	c.UpdatedAt = time.Now()
+}
+
+// IsVectorSynced 检查向量是否已写入向量库
+func (c *Chunk) IsVectorSynced() bool {
+	return c.VectorStatus == VectorSyncSynced
+}
+
 // UpdateMetadata 更新元数据
 func (c *Chunk) UpdateMetadata(metadata ChunkMetadata) {
 	c.Metadata = metadata
@@ -102,10 +149,7 @@ func (c *Chunk) AddEntity(entity string) {
 
 // GetContentPreview 获取内容预览
 func (c *Chunk) GetContentPreview(length int) string {
-	if length <= 0 || length >= len(c.Content) {
-		return c.Content
-	}
-	return c.Content[:length] + "..."
+	return truncateRunes(c.Content, length)
 }
 
 // CalculateTokenCount 计算令牌数量
@@ -117,6 +161,14 @@ func (c *Chunk) CalculateTokenCount() int {
 	return c.TokenCount
 }
 
+// truncateRunes 按字符截断文本，截断时追加省略号
+func truncateRunes(text string, length int) string {
+	if length <= 0 || length >= utf8.RuneCountInString(text) {
+		return text
+	}
+	return string([]rune(text)[:length]) + "..."
+}
+
 // NewChunk 创建新的文档分块
 func NewChunk(documentID, content string, chunkType ChunkType, position int) (*Chunk, error) {
 	if documentID == "" {
@@ -138,10 +190,11 @@ func NewChunk(documentID, content string, chunkType ChunkType, position int) (*C
 		Type:       chunkType,
 		Position:   position,
 		StartIndex: 0, // 需要在分块时计算
-		EndIndex:   len(content),
+		EndIndex:   utf8.RuneCountInString(content),
 		Metadata: ChunkMetadata{
 			Custom: make(map[string]string),
 		},
+		VectorStatus: VectorSyncPending,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
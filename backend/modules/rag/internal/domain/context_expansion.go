@@ -0,0 +1,63 @@
+package domain
+
+import "fmt"
+
+// ContextScope 上下文扩展范围
+type ContextScope string
+
+const (
+	ContextScopeNeighbors ContextScope = "neighbors" // 前后相邻的分块
+	ContextScopeSection   ContextScope = "section"   // 同一章节内的分块
+)
+
+const (
+	// DefaultContextMaxTokens 默认前后文令牌预算
+	DefaultContextMaxTokens = 512
+	// MaxContextMaxTokens 前后文令牌预算上限
+	MaxContextMaxTokens = 8192
+	// DefaultContextNeighbors neighbors范围默认每侧扩展的分块数
+	DefaultContextNeighbors = 1
+)
+
+// ContextExpansion 命中分块的上下文扩展，搜索结果附带同一文档中命中分块前后的文本，
+// 为下游生成提供更完整的上下文
+type ContextExpansion struct {
+	Scope     ContextScope `json:"scope,omitempty"`      // 扩展范围，默认neighbors
+	Neighbors int          `json:"neighbors,omitempty"`  // neighbors范围每侧最多扩展的分块数，默认1
+	MaxTokens int          `json:"max_tokens,omitempty"` // 前后文合计的令牌预算，默认512
+}
+
+// Normalize 校验扩展参数并填充默认值
+func (e *ContextExpansion) Normalize() error {
+	switch e.Scope {
+	case "":
+		e.Scope = ContextScopeNeighbors
+	case ContextScopeNeighbors, ContextScopeSection:
+	default:
+		return ErrInvalidInputf("context_expansion.scope", fmt.Sprintf("unknown scope %q", e.Scope))
+	}
+
+	if e.Neighbors < 0 {
+		return ErrInvalidInputf("context_expansion.neighbors", "must not be negative")
+	}
+	if e.Neighbors == 0 {
+		e.Neighbors = DefaultContextNeighbors
+	}
+
+	if e.MaxTokens < 0 || e.MaxTokens > MaxContextMaxTokens {
+		return ErrInvalidInputf("context_expansion.max_tokens", fmt.Sprintf("must be between 0 and %d", MaxContextMaxTokens))
+	}
+	if e.MaxTokens == 0 {
+		e.MaxTokens = DefaultContextMaxTokens
+	}
+	return nil
+}
+
+// ResultContext 搜索结果的前后文
+type ResultContext struct {
+	Before     string   `json:"before,omitempty"` // 命中内容之前的文本，已去除与命中分块重叠的部分
+	After      string   `json:"after,omitempty"`  // 命中内容之后的文本，已去除与命中分块重叠的部分
+	ChunkIDs   []string `json:"chunk_ids"`        // 扩展的分块ID，按位置排序
+	TokenCount int      `json:"token_count"`      // 前后文的估算令牌数
+	Truncated  bool     `json:"truncated"`        // 范围内还有分块因令牌预算未包含
+}
@@ -1,9 +1,12 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/noah-loop/backend/shared/pkg/domain"
+	"gorm.io/gorm"
 )
 
 // DocumentStatus 文档状态
@@ -26,6 +29,16 @@ const (
 	DocumentTypeMarkdown DocumentType = "markdown" // Markdown
 	DocumentTypeHTML     DocumentType = "html"     // HTML
 	DocumentTypeWord     DocumentType = "word"     // Word文档
+	DocumentTypeImage    DocumentType = "image"    // 图片，如扫描页，内容为图片地址或data URI
+)
+
+// DocumentAccessLevel 文档访问级别
+type DocumentAccessLevel string
+
+const (
+	DocumentAccessPublic     DocumentAccessLevel = "public"     // 知识库内所有用户可见
+	DocumentAccessRestricted DocumentAccessLevel = "restricted" // 仅文档所有者和授权用户可见
+	DocumentAccessPrivate    DocumentAccessLevel = "private"    // 仅文档所有者可见
 )
 
 // Document 文档聚合根
@@ -36,16 +49,20 @@ type Document struct {
 	Type        DocumentType   `gorm:"not null" json:"type"`
 	Status      DocumentStatus `gorm:"not null;default:'pending'" json:"status"`
 	Source      string         `json:"source"`       // 文档来源
-	Hash        string         `gorm:"unique" json:"hash"` // 内容哈希
+	Hash        string         `gorm:"uniqueIndex:idx_document_kb_hash,priority:2,where:deleted_at IS NULL" json:"hash"` // 内容哈希，同一知识库的未删除文档内唯一
 	Size        int64          `json:"size"`         // 文档大小
 	Language    string         `json:"language"`     // 文档语言
 	Tags        []Tag          `gorm:"many2many:document_tags;" json:"tags"`
 	Chunks      []Chunk        `json:"chunks"`       // 文档分块
+	Summary     string         `gorm:"type:text" json:"summary,omitempty"` // 索引时自动生成的摘要，知识库开启摘要时生成
+	Streamed    bool           `gorm:"not null;default:false" json:"streamed"` // 流式导入的文档，边读边分块，不保存全文
 	Metadata    DocumentMetadata `gorm:"embedded" json:"metadata"`
-	KnowledgeBaseID string `gorm:"index" json:"knowledge_base_id"`
+	Access      DocumentAccess   `gorm:"embedded;embeddedPrefix:access_" json:"access"`
+	KnowledgeBaseID string `gorm:"index;uniqueIndex:idx_document_kb_hash,priority:1" json:"knowledge_base_id"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	IndexedAt   *time.Time     `json:"indexed_at,omitempty"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"` // 软删除时间，查询自动排除已删除的文档
 }
 
 // DocumentMetadata 文档元数据
@@ -58,6 +75,60 @@ type DocumentMetadata struct {
 	Custom      map[string]string `gorm:"serializer:json" json:"custom,omitempty"`
 }
 
+// DocumentAccess 文档访问控制
+type DocumentAccess struct {
+	Level        DocumentAccessLevel `gorm:"default:'public'" json:"level"`
+	OwnerID      string              `gorm:"index" json:"owner_id,omitempty"`
+	AllowedUsers []string            `gorm:"serializer:json" json:"allowed_users,omitempty"`
+}
+
+// SetAccess 设置文档访问控制
+func (d *Document) SetAccess(level DocumentAccessLevel, ownerID string, allowedUsers []string) error {
+	switch level {
+	case DocumentAccessPublic, DocumentAccessRestricted, DocumentAccessPrivate:
+	default:
+		return NewDomainError("INVALID_ACCESS_LEVEL", "invalid document access level: "+string(level))
+	}
+	
+	if level != DocumentAccessPublic && ownerID == "" {
+		return NewDomainError("INVALID_OWNER_ID", "owner ID is required for non-public documents")
+	}
+	
+	d.Access = DocumentAccess{
+		Level:        level,
+		OwnerID:      ownerID,
+		AllowedUsers: allowedUsers,
+	}
+	d.UpdatedAt = time.Now()
+	
+	return nil
+}
+
+// CanBeAccessedBy 检查用户是否可以访问文档，未知用户只能访问公开文档
+func (d *Document) CanBeAccessedBy(userID string) bool {
+	switch d.Access.Level {
+	case "", DocumentAccessPublic:
+		return true
+	case DocumentAccessPrivate:
+		return userID != "" && userID == d.Access.OwnerID
+	case DocumentAccessRestricted:
+		if userID == "" {
+			return false
+		}
+		if userID == d.Access.OwnerID {
+			return true
+		}
+		for _, allowed := range d.Access.AllowedUsers {
+			if allowed == userID {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
 // UpdateStatus 更新文档状态
 func (d *Document) UpdateStatus(status DocumentStatus) error {
 	if !d.isValidStatusTransition(d.Status, status) {
@@ -86,6 +157,34 @@ func (d *Document) MarkAsIndexed(chunks []Chunk) error {
 	return nil
 }
 
+// SetSummary 设置文档摘要，空值表示清除摘要
+func (d *Document) SetSummary(summary string) {
+	d.Summary = summary
+	d.UpdatedAt = time.Now()
+}
+
+// UpdateContent 更新标题和内容，空值表示不修改；返回内容是否变化，内容变化后需要重新索引
+func (d *Document) UpdateContent(title, content string) bool {
+	if title != "" {
+		d.Title = title
+	}
+	d.UpdatedAt = time.Now()
+
+	hash := calculateContentHash(content)
+	if content == "" || hash == d.Hash {
+		return false
+	}
+
+	d.Content = content
+	d.Hash = hash
+	d.Size = int64(len(content))
+	d.Streamed = false
+	if language := DetectLanguage(content); language != "" {
+		d.Language = language
+	}
+	return true
+}
+
 // AddTag 添加标签
 func (d *Document) AddTag(tag Tag) {
 	for _, existingTag := range d.Tags {
@@ -159,6 +258,12 @@ func NewDocument(title, content string, docType DocumentType, source string) (*D
 	
 	hash := calculateContentHash(content)
 	
+	// 图片文档的内容是图片地址，无法据此检测语言
+	language := ""
+	if docType != DocumentTypeImage {
+		language = DetectLanguage(content)
+	}
+	
 	doc := &Document{
 		Entity:   domain.NewEntity(),
 		Title:    title,
@@ -168,12 +273,15 @@ func NewDocument(title, content string, docType DocumentType, source string) (*D
 		Source:   source,
 		Hash:     hash,
 		Size:     int64(len(content)),
-		Language: detectLanguage(content),
+		Language: language,
 		Tags:     make([]Tag, 0),
 		Chunks:   make([]Chunk, 0),
 		Metadata: DocumentMetadata{
 			Custom: make(map[string]string),
 		},
+		Access: DocumentAccess{
+			Level: DocumentAccessPublic,
+		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -181,14 +289,49 @@ func NewDocument(title, content string, docType DocumentType, source string) (*D
 	return doc, nil
 }
 
-// calculateContentHash 计算内容哈希
-func calculateContentHash(content string) string {
-	// TODO: 实现内容哈希算法 (SHA-256)
-	return "hash_placeholder"
+// NewStreamedDocument 创建流式导入的文档，内容边读边分块，不保存在文档中
+// 读取完成前内容哈希未知，先使用按文档ID生成的占位哈希，避免与同一知识库中的其他文档冲突
+func NewStreamedDocument(title string, docType DocumentType, source string) (*Document, error) {
+	if title == "" {
+		return nil, NewDomainError("INVALID_TITLE", "document title cannot be empty")
+	}
+
+	doc := &Document{
+		Entity:   domain.NewEntity(),
+		Title:    title,
+		Type:     docType,
+		Status:   DocumentStatusPending,
+		Source:   source,
+		Streamed: true,
+		Tags:     make([]Tag, 0),
+		Chunks:   make([]Chunk, 0),
+		Metadata: DocumentMetadata{
+			Custom: make(map[string]string),
+		},
+		Access: DocumentAccess{
+			Level: DocumentAccessPublic,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	doc.Hash = streamingHashPrefix + doc.ID
+
+	return doc, nil
+}
+
+// streamingHashPrefix 流式导入中文档的占位哈希前缀
+const streamingHashPrefix = "streaming:"
+
+// CompleteStream 流式读取完成后记录内容哈希和大小，哈希为原始内容的SHA-256十六进制
+func (d *Document) CompleteStream(hash string, size int64) {
+	d.Hash = hash
+	d.Size = size
+	d.UpdatedAt = time.Now()
 }
 
-// detectLanguage 检测语言
-func detectLanguage(content string) string {
-	// TODO: 实现语言检测
-	return "zh-CN" // 默认中文
+// calculateContentHash 计算内容哈希（SHA-256，十六进制）
+func calculateContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
 }
+
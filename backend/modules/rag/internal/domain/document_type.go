@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// uploadExtensionTypes 可上传的文件扩展名对应的文档类型
+// PDF、Word和图片需要先提取文字，不能直接上传原始文件
+var uploadExtensionTypes = map[string]DocumentType{
+	".txt":      DocumentTypeText,
+	".text":     DocumentTypeText,
+	".log":      DocumentTypeText,
+	".md":       DocumentTypeMarkdown,
+	".markdown": DocumentTypeMarkdown,
+	".html":     DocumentTypeHTML,
+	".htm":      DocumentTypeHTML,
+}
+
+// uploadMIMETypes 可上传的MIME类型对应的文档类型
+var uploadMIMETypes = map[string]DocumentType{
+	"text/plain":      DocumentTypeText,
+	"text/markdown":   DocumentTypeMarkdown,
+	"text/x-markdown": DocumentTypeMarkdown,
+	"text/html":       DocumentTypeHTML,
+}
+
+// DetectUploadDocumentType 按文件扩展名识别上传文件的文档类型，扩展名无法识别时按MIME类型识别
+// 客户端常把未知扩展名的文件标为application/octet-stream，因此扩展名优先；不支持上传时返回false
+func DetectUploadDocumentType(filename, contentType string) (DocumentType, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if docType, ok := uploadExtensionTypes[ext]; ok {
+		return docType, true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	docType, ok := uploadMIMETypes[strings.ToLower(mediaType)]
+	return docType, ok
+}
+
+// IsUploadableDocumentType 文档类型是否支持以文件上传
+func IsUploadableDocumentType(docType DocumentType) bool {
+	switch docType {
+	case DocumentTypeText, DocumentTypeMarkdown, DocumentTypeHTML:
+		return true
+	default:
+		return false
+	}
+}
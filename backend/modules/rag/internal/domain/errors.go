@@ -41,12 +41,15 @@ const (
 	ErrDocumentInvalidContent   = "DOCUMENT_INVALID_CONTENT"
 	ErrDocumentIndexingFailed   = "DOCUMENT_INDEXING_FAILED"
 	ErrDocumentProcessingFailed = "DOCUMENT_PROCESSING_FAILED"
+	ErrDocumentTooLarge         = "DOCUMENT_TOO_LARGE"
+	ErrUnsupportedDocumentType  = "UNSUPPORTED_DOCUMENT_TYPE"
 
 	// 知识库相关错误
 	ErrKnowledgeBaseNotFound     = "KNOWLEDGE_BASE_NOT_FOUND"
 	ErrKnowledgeBaseInactive     = "KNOWLEDGE_BASE_INACTIVE"
 	ErrKnowledgeBaseMaxDocuments = "KNOWLEDGE_BASE_MAX_DOCUMENTS"
 	ErrKnowledgeBaseDeleted      = "KNOWLEDGE_BASE_DELETED"
+	ErrKnowledgeBaseExists       = "KNOWLEDGE_BASE_EXISTS"
 
 	// 分块相关错误
 	ErrChunkNotFound       = "CHUNK_NOT_FOUND"
@@ -73,6 +76,7 @@ const (
 	// 通用错误
 	ErrInvalidInput    = "INVALID_INPUT"
 	ErrPermissionDenied = "PERMISSION_DENIED"
+	ErrUnauthenticated  = "UNAUTHENTICATED"
 	ErrResourceLocked  = "RESOURCE_LOCKED"
 	ErrTimeout         = "TIMEOUT"
 	ErrInternalError   = "INTERNAL_ERROR"
@@ -83,6 +87,33 @@ func ErrDocumentNotFoundf(documentID string) *DomainError {
 	return NewDomainErrorWithDetails(ErrDocumentNotFound, "Document not found", fmt.Sprintf("document_id: %s", documentID))
 }
 
+// ErrDuplicateDocumentf 知识库中已有内容相同的文档
+func ErrDuplicateDocumentf(existingID string) *DomainError {
+	return NewDomainErrorWithDetails(ErrDocumentAlreadyExists, "Document with identical content already exists", fmt.Sprintf("document_id: %s", existingID))
+}
+
+// ErrDocumentTooLargef 文档超过允许的最大大小
+func ErrDocumentTooLargef(limit int64) *DomainError {
+	return NewDomainErrorWithDetails(ErrDocumentTooLarge, "Document exceeds the maximum size", fmt.Sprintf("max_bytes: %d", limit))
+}
+
+// ErrUnsupportedDocumentTypef 当前导入方式不支持的文档类型
+func ErrUnsupportedDocumentTypef(docType DocumentType) *DomainError {
+	return NewDomainErrorWithDetails(ErrUnsupportedDocumentType, "Unsupported document type", fmt.Sprintf("type: %s", docType))
+}
+
+// ErrUnsupportedFileTypef 无法从文件名和MIME类型识别为可上传的文档类型
+func ErrUnsupportedFileTypef(filename, contentType string) *DomainError {
+	return NewDomainErrorWithDetails(ErrUnsupportedDocumentType,
+		"Unsupported file type, upload .txt, .md or .html files",
+		fmt.Sprintf("file: %s, content_type: %s", filename, contentType))
+}
+
+// ErrPermissionDeniedf 请求用户没有知识库的指定权限
+func ErrPermissionDeniedf(knowledgeBaseID, permission string) *DomainError {
+	return NewDomainErrorWithDetails(ErrPermissionDenied, "Permission denied", fmt.Sprintf("knowledge_base_id: %s, required: %s", knowledgeBaseID, permission))
+}
+
 func ErrKnowledgeBaseNotFoundf(kbID string) *DomainError {
 	return NewDomainErrorWithDetails(ErrKnowledgeBaseNotFound, "Knowledge base not found", fmt.Sprintf("knowledge_base_id: %s", kbID))
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/noah-loop/backend/shared/pkg/domain"
+	"gorm.io/gorm"
 )
 
 // KnowledgeBaseStatus 知识库状态
@@ -30,6 +31,7 @@ type KnowledgeBase struct {
 	CreatedAt    time.Time              `json:"created_at"`
 	UpdatedAt    time.Time              `json:"updated_at"`
 	LastIndexedAt *time.Time            `json:"last_indexed_at,omitempty"`
+	DeletedAt    gorm.DeletedAt         `gorm:"index" json:"-"` // 软删除时间，查询自动排除已删除的知识库
 }
 
 // KnowledgeBaseSettings 知识库设置
@@ -43,6 +45,45 @@ type KnowledgeBaseSettings struct {
 	SimilarityThreshold float32 `json:"similarity_threshold" gorm:"default:0.7"` // 相似度阈值
 	EnableMetadata  bool    `json:"enable_metadata" gorm:"default:true"`   // 启用元数据
 	EnableVersioning bool   `json:"enable_versioning" gorm:"default:false"` // 启用版本控制
+	EnableReranking bool    `json:"enable_reranking" gorm:"default:false"` // 检索后使用重排序模型调整结果顺序
+	RerankTopN      int     `json:"rerank_top_n" gorm:"default:50"`        // 开启重排序时参与重排的候选数，不少于top_k
+	EnableSummary   bool    `json:"enable_summary" gorm:"default:false"`   // 索引时为长文档自动生成摘要
+	EmbedSummary    bool    `json:"embed_summary" gorm:"default:false"`    // 摘要作为摘要分块写入向量库，用于粗粒度检索
+	SummaryMinLength int    `json:"summary_min_length" gorm:"default:2000"` // 生成摘要的最小文档长度（字符数），0表示使用默认值
+}
+
+// DefaultRerankTopN 未设置rerank_top_n时参与重排的候选数
+const DefaultRerankTopN = 50
+
+// DefaultSummaryMinLength 未设置summary_min_length时生成摘要的最小文档长度
+const DefaultSummaryMinLength = 2000
+
+// ShouldSummarize 判断长度为contentLength（字符数）的文档是否需要生成摘要
+func (s KnowledgeBaseSettings) ShouldSummarize(contentLength int) bool {
+	if !s.EnableSummary {
+		return false
+	}
+	minLength := s.SummaryMinLength
+	if minLength <= 0 {
+		minLength = DefaultSummaryMinLength
+	}
+	return contentLength >= minLength
+}
+
+// RerankCandidates 检索时需要获取的候选数
+// 开启重排序时多取候选交给重排序模型，重排后再截断到topK；未开启时返回topK
+func (s KnowledgeBaseSettings) RerankCandidates(topK int) int {
+	if !s.EnableReranking {
+		return topK
+	}
+	topN := s.RerankTopN
+	if topN <= 0 {
+		topN = DefaultRerankTopN
+	}
+	if topN < topK {
+		return topK
+	}
+	return topN
 }
 
 // KnowledgeBaseStats 知识库统计信息
@@ -112,6 +153,14 @@ func (kb *KnowledgeBase) UpdateSettings(settings KnowledgeBaseSettings) error {
 		return NewDomainError("INVALID_SIMILARITY_THRESHOLD", "similarity threshold must be between 0 and 1")
 	}
 	
+	if settings.RerankTopN < 0 {
+		return NewDomainError("INVALID_RERANK_TOP_N", "rerank top N must be non-negative")
+	}
+	
+	if settings.SummaryMinLength < 0 {
+		return NewDomainError("INVALID_SUMMARY_MIN_LENGTH", "summary min length must be non-negative")
+	}
+	
 	kb.Settings = settings
 	kb.UpdatedAt = time.Now()
 	
@@ -247,6 +296,11 @@ func NewKnowledgeBase(name, description, ownerID string) (*KnowledgeBase, error)
 			SimilarityThreshold: 0.7,
 			EnableMetadata:      true,
 			EnableVersioning:    false,
+			EnableReranking:     false,
+			RerankTopN:          DefaultRerankTopN,
+			EnableSummary:       false,
+			EmbedSummary:        true,
+			SummaryMinLength:    DefaultSummaryMinLength,
 		},
 		Statistics: KnowledgeBaseStats{},
 		Tags:       make([]Tag, 0),
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// KnowledgeBasePermission 知识库共享权限，记录授予所有者以外用户的权限
+// 权限取值为repository.Permission中的read、write或admin
+type KnowledgeBasePermission struct {
+	KnowledgeBaseID string    `gorm:"primaryKey" json:"knowledge_base_id"`
+	UserID          string    `gorm:"primaryKey;index" json:"user_id"`
+	Permission      string    `gorm:"not null" json:"permission"`
+	GrantedAt       time.Time `gorm:"not null" json:"granted_at"`
+}
+
+// TableName 共享权限表名
+func (KnowledgeBasePermission) TableName() string {
+	return "knowledge_base_permissions"
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageSampleRunes 语言检测最多采样的字符数
+const languageSampleRunes = 4096
+
+// latinStopwords 常见拉丁字母语言的高频功能词，用于区分使用相同字母的语言
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "are"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "que", "dans", "pour"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "zu"},
+	"es": {"el", "los", "las", "y", "que", "es", "una", "por", "con", "para"},
+	"pt": {"o", "os", "as", "e", "que", "não", "uma", "com", "para", "em"},
+}
+
+// DetectLanguage 检测文本语言，返回ISO 639-1语言代码，无法判断时返回空字符串
+// 按文字系统判断：含假名为日语，含谚文为韩语，汉字为主为中文；拉丁字母文本按高频功能词区分，无法区分时视为英语
+func DetectLanguage(text string) string {
+	var han, kana, hangul, latin, cyrillic, arabic, total int
+	sampled := 0
+	for _, r := range text {
+		if sampled >= languageSampleRunes {
+			break
+		}
+		sampled++
+
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			continue
+		}
+		total++
+	}
+	if total == 0 {
+		return ""
+	}
+
+	// 日文中汉字常多于假名，只要假名占一定比例即为日语
+	switch {
+	case kana*10 >= total:
+		return "ja"
+	case hangul*5 >= total:
+		return "ko"
+	case (han+kana)*5 >= total:
+		// 中英混排的技术文档中汉字字符数通常少于字母数
+		return "zh"
+	case cyrillic > latin && cyrillic > arabic:
+		return "ru"
+	case arabic > latin:
+		return "ar"
+	default:
+		return detectLatinLanguage(text)
+	}
+}
+
+// detectLatinLanguage 按高频功能词区分拉丁字母语言
+func detectLatinLanguage(text string) string {
+	if len(text) > languageSampleRunes*4 {
+		text = text[:languageSampleRunes*4]
+	}
+
+	counts := make(map[string]int, len(latinStopwords))
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for language, stopwords := range latinStopwords {
+			for _, stopword := range stopwords {
+				if word == stopword {
+					counts[language]++
+				}
+			}
+		}
+	}
+
+	best, bestCount := "en", 0
+	for _, language := range []string{"en", "fr", "de", "es", "pt"} {
+		if counts[language] > bestCount {
+			best, bestCount = language, counts[language]
+		}
+	}
+	return best
+}
+
+// LanguageBase 获取语言标签的主语言代码，如 zh-CN 为 zh
+func LanguageBase(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	return language
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 )
@@ -10,7 +11,7 @@ import (
 type ChunkRepository interface {
 	// 基本CRUD操作
 	Save(ctx context.Context, chunk *domain.Chunk) error
-	FindByID(ctx context.Context, id string) (*domain.Chunk, error)
+	FindByID(ctx context.Context, id string) (*domain.Chunk, error) // 不存在时返回ErrNotFound
 	Update(ctx context.Context, chunk *domain.Chunk) error
 	Delete(ctx context.Context, id string) error
 
@@ -18,11 +19,15 @@ type ChunkRepository interface {
 	FindByDocumentID(ctx context.Context, documentID string) ([]*domain.Chunk, error)
 	FindByDocumentIDWithPagination(ctx context.Context, documentID string, offset, limit int) ([]*domain.Chunk, int64, error)
 	FindByType(ctx context.Context, chunkType domain.ChunkType) ([]*domain.Chunk, error)
+	// SearchByKeywords 在知识库的分块中检索包含任一关键词的分块，按命中的关键词数降序返回
+	SearchByKeywords(ctx context.Context, knowledgeBaseID string, keywords []string, limit int) ([]*domain.Chunk, error)
 
 	// 向量相关操作
 	FindWithoutEmbedding(ctx context.Context, limit int) ([]*domain.Chunk, error)
 	UpdateEmbedding(ctx context.Context, chunkID string, embedding []float32) error
 	UpdateEmbeddingBatch(ctx context.Context, chunkEmbeddings map[string][]float32) error
+	// FindUnsyncedVectors 查找写入失败且未超过重试次数的分块，以及早于staleBefore仍待写入的分块，不包括已软删除文档的分块
+	FindUnsyncedVectors(ctx context.Context, maxAttempts int, staleBefore time.Time, limit int) ([]*domain.Chunk, error)
 
 	// 批量操作
 	SaveBatch(ctx context.Context, chunks []*domain.Chunk) error
@@ -2,18 +2,20 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/pagination"
 )
 
 // DocumentRepository 文档仓储接口
 type DocumentRepository interface {
 	// 基本CRUD操作
 	Save(ctx context.Context, document *domain.Document) error
-	FindByID(ctx context.Context, id string) (*domain.Document, error)
-	FindByHash(ctx context.Context, hash string) (*domain.Document, error)
+	FindByID(ctx context.Context, id string) (*domain.Document, error) // 不存在时返回ErrNotFound
+	FindByHash(ctx context.Context, knowledgeBaseID, hash string) (*domain.Document, error) // 查找知识库中内容哈希相同的文档，不存在时返回ErrNotFound
 	Update(ctx context.Context, document *domain.Document) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error // 软删除，分块由调用方删除
 
 	// 查询操作
 	FindByKnowledgeBaseID(ctx context.Context, knowledgeBaseID string) ([]*domain.Document, error)
@@ -25,6 +27,11 @@ type DocumentRepository interface {
 	FindWithPagination(ctx context.Context, offset, limit int) ([]*domain.Document, int64, error)
 	FindByKnowledgeBaseIDWithPagination(ctx context.Context, knowledgeBaseID string, offset, limit int) ([]*domain.Document, int64, error)
 
+	// 游标分页查询，按(created_at, id)降序返回cursor之后的最多limit个文档，cursor为nil时从第一个开始
+	// 还有更多记录时返回指向本页最后一个文档的游标，否则返回nil
+	FindAfterCursor(ctx context.Context, cursor *pagination.Cursor, limit int) ([]*domain.Document, *pagination.Cursor, error)
+	FindByKnowledgeBaseIDAfterCursor(ctx context.Context, knowledgeBaseID string, cursor *pagination.Cursor, limit int) ([]*domain.Document, *pagination.Cursor, error)
+
 	// 搜索操作
 	SearchByContent(ctx context.Context, query string, knowledgeBaseID string, limit int) ([]*domain.Document, error)
 	SearchByTitle(ctx context.Context, query string, limit int) ([]*domain.Document, error)
@@ -44,6 +51,11 @@ type DocumentRepository interface {
 	MarkAsIndexing(ctx context.Context, documentID string) error
 	MarkAsIndexed(ctx context.Context, documentID string, chunks []*domain.Chunk) error
 	MarkAsIndexingFailed(ctx context.Context, documentID string, reason string) error
+
+	// 软删除管理，供审计、误删恢复和管理员清理使用
+	FindIncludingDeleted(ctx context.Context, id string) (*domain.Document, error) // 包括已软删除的文档，不存在时返回ErrNotFound
+	Restore(ctx context.Context, id string) error                                  // 恢复软删除的文档，文档不存在或未删除时返回ErrNotFound，内容哈希与未删除的文档重复时返回ErrDuplicateKey
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)             // 永久删除before之前软删除的文档及其分块和标签关联，返回删除的文档数
 }
 
 // DocumentStats 文档统计信息
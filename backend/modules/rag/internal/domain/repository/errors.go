@@ -0,0 +1,12 @@
+package repository
+
+import "errors"
+
+// ErrDuplicateKey 违反唯一约束
+// 仓储实现需要将数据库的唯一约束冲突转换为该错误（可包装），由应用层转换为具体的领域错误
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrNotFound 记录不存在
+// 按ID或唯一键查找的方法在记录不存在时返回该错误（可包装），不返回(nil, nil)；
+// 应用层据此区分不存在（转换为对应的NOT_FOUND领域错误）与数据库故障
+var ErrNotFound = errors.New("record not found")
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 )
@@ -10,10 +11,10 @@ import (
 type KnowledgeBaseRepository interface {
 	// 基本CRUD操作
 	Save(ctx context.Context, knowledgeBase *domain.KnowledgeBase) error
-	FindByID(ctx context.Context, id string) (*domain.KnowledgeBase, error)
-	FindByName(ctx context.Context, name, ownerID string) (*domain.KnowledgeBase, error)
+	FindByID(ctx context.Context, id string) (*domain.KnowledgeBase, error) // 不存在时返回ErrNotFound
+	FindByName(ctx context.Context, name, ownerID string) (*domain.KnowledgeBase, error) // 不存在时返回ErrNotFound
 	Update(ctx context.Context, knowledgeBase *domain.KnowledgeBase) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error // 软删除知识库及其文档，共享权限保留到永久删除
 
 	// 查询操作
 	FindByOwnerID(ctx context.Context, ownerID string) ([]*domain.KnowledgeBase, error)
@@ -38,10 +39,15 @@ type KnowledgeBaseRepository interface {
 	GetQueryHistory(ctx context.Context, knowledgeBaseID string, limit int) ([]QueryRecord, error)
 
 	// 权限相关
-	CheckAccess(ctx context.Context, knowledgeBaseID, userID string) (bool, error)
-	GrantAccess(ctx context.Context, knowledgeBaseID, userID string, permission Permission) error
-	RevokeAccess(ctx context.Context, knowledgeBaseID, userID string) error
-	ListAccessUsers(ctx context.Context, knowledgeBaseID string) ([]UserPermission, error)
+	CheckAccess(ctx context.Context, knowledgeBaseID, userID string, permission Permission) (bool, error) // 所有者拥有全部权限，其他用户按授予的权限判断
+	GrantAccess(ctx context.Context, knowledgeBaseID, userID string, permission Permission) error         // 已授予时覆盖原权限
+	RevokeAccess(ctx context.Context, knowledgeBaseID, userID string) error                               // 未授予时返回ErrNotFound
+	ListAccessUsers(ctx context.Context, knowledgeBaseID string) ([]UserPermission, error)                // 所有者排在最前
+
+	// 软删除管理，供审计、误删恢复和管理员清理使用
+	FindIncludingDeleted(ctx context.Context, id string) (*domain.KnowledgeBase, error) // 包括已软删除的知识库，不存在时返回ErrNotFound
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)                  // 永久删除before之前软删除的知识库及其文档、分块和共享权限，返回删除的知识库数
+	Purge(ctx context.Context, id string) error                                        // 永久删除知识库及其文档、分块和共享权限，不论是否已软删除
 }
 
 // QueryRecord 查询记录
@@ -65,6 +71,26 @@ const (
 	PermissionOwner  Permission = "owner"  // 所有者
 )
 
+// permissionLevels 权限等级，高等级包含低等级的全部权限
+var permissionLevels = map[Permission]int{
+	PermissionRead:  1,
+	PermissionWrite: 2,
+	PermissionAdmin: 3,
+	PermissionOwner: 4,
+}
+
+// IsValid 检查权限类型是否有效
+func (p Permission) IsValid() bool {
+	_, ok := permissionLevels[p]
+	return ok
+}
+
+// Includes 检查是否包含指定权限，如写权限包含读权限
+func (p Permission) Includes(required Permission) bool {
+	level, ok := permissionLevels[p]
+	return ok && level >= permissionLevels[required]
+}
+
 // UserPermission 用户权限
 type UserPermission struct {
 	UserID     string     `json:"user_id"`
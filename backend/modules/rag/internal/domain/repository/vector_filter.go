@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"strings"
+	"time"
+)
+
+// 向量元数据中的标准过滤字段
+const (
+	MetadataDocumentID   = "document_id"
+	MetadataDocumentType = "document_type"
+	MetadataChunkType    = "chunk_type"
+	MetadataSource       = "source"
+	MetadataLanguage     = "language"
+	MetadataCreatedAt    = "created_at"
+	MetadataTag          = "tag"
+	MetadataCustom       = "custom"
+)
+
+// metadataTimeLayout 元数据中的时间格式，固定宽度的UTC时间，按字符串比较即按时间比较
+const metadataTimeLayout = "2006-01-02T15:04:05Z"
+
+// FilterOperator 过滤操作符
+type FilterOperator string
+
+const (
+	FilterOpIn     FilterOperator = "in"      // 字段值为Values之一
+	FilterOpNotIn  FilterOperator = "not_in"  // 字段不存在或值不是Values中任一值
+	FilterOpGte    FilterOperator = "gte"     // 字段值不小于Values[0]，按字符串比较
+	FilterOpLte    FilterOperator = "lte"     // 字段值不大于Values[0]，按字符串比较
+	FilterOpHasAny FilterOperator = "has_any" // 多值字段包含Values中任一值，多值字段按PrefixedKey展开存储
+)
+
+// FilterCondition 元数据过滤条件
+type FilterCondition struct {
+	Field    string         `json:"field"`
+	Operator FilterOperator `json:"operator"`
+	Values   []string       `json:"values"`
+}
+
+// PrefixedKey 带前缀的元数据键
+// 多值字段（如标签）的每个值存储为独立的键，值为"true"；自定义元数据以custom为前缀存储，避免与标准字段冲突
+func PrefixedKey(prefix, name string) string {
+	return prefix + ":" + name
+}
+
+// FormatMetadataTime 格式化元数据中的时间
+func FormatMetadataTime(t time.Time) string {
+	return t.UTC().Format(metadataTimeLayout)
+}
+
+// Matches 判断元数据是否满足条件
+func (c FilterCondition) Matches(metadata map[string]string) bool {
+	switch c.Operator {
+	case FilterOpIn:
+		value, exists := metadata[c.Field]
+		if !exists {
+			return false
+		}
+		for _, v := range c.Values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	case FilterOpNotIn:
+		value := metadata[c.Field]
+		for _, v := range c.Values {
+			if v == value {
+				return false
+			}
+		}
+		return true
+	case FilterOpGte, FilterOpLte:
+		value, exists := metadata[c.Field]
+		if !exists || len(c.Values) == 0 {
+			return false
+		}
+		if c.Operator == FilterOpGte {
+			return strings.Compare(value, c.Values[0]) >= 0
+		}
+		return strings.Compare(value, c.Values[0]) <= 0
+	case FilterOpHasAny:
+		for _, v := range c.Values {
+			if _, exists := metadata[PrefixedKey(c.Field, v)]; exists {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// WithCondition 添加过滤条件，多个条件之间为AND；值为空的条件被忽略
+func (vq *VectorQuery) WithCondition(field string, operator FilterOperator, values ...string) *VectorQuery {
+	if len(values) == 0 {
+		return vq
+	}
+	vq.Conditions = append(vq.Conditions, FilterCondition{Field: field, Operator: operator, Values: values})
+	return vq
+}
+
+// WithFilterIn 设置字段值为多个值之一
+func (vq *VectorQuery) WithFilterIn(field string, values ...string) *VectorQuery {
+	return vq.WithCondition(field, FilterOpIn, values...)
+}
+
+// WithFilterNotIn 排除字段值为多个值之一的向量
+func (vq *VectorQuery) WithFilterNotIn(field string, values ...string) *VectorQuery {
+	return vq.WithCondition(field, FilterOpNotIn, values...)
+}
+
+// WithTimeRange 设置时间字段范围，零值表示不限制
+func (vq *VectorQuery) WithTimeRange(field string, start, end time.Time) *VectorQuery {
+	if !start.IsZero() {
+		vq.WithCondition(field, FilterOpGte, FormatMetadataTime(start))
+	}
+	if !end.IsZero() {
+		vq.WithCondition(field, FilterOpLte, FormatMetadataTime(end))
+	}
+	return vq
+}
+
+// MatchesFilter 判断元数据是否满足查询的所有等值过滤和过滤条件
+func (vq *VectorQuery) MatchesFilter(metadata map[string]string) bool {
+	for key, value := range vq.Filter {
+		if metadata[key] != value {
+			return false
+		}
+	}
+	for _, condition := range vq.Conditions {
+		if !condition.Matches(metadata) {
+			return false
+		}
+	}
+	return true
+}
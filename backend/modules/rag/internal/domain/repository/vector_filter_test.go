@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVectorQueryMultiValueFilter(t *testing.T) {
+	query := NewVectorQuery("kb", []float32{1}, 10).
+		WithFilterIn(MetadataDocumentType, "pdf", "markdown")
+
+	for documentType, want := range map[string]bool{"pdf": true, "markdown": true, "html": false, "": false} {
+		metadata := map[string]string{}
+		if documentType != "" {
+			metadata[MetadataDocumentType] = documentType
+		}
+		if got := query.MatchesFilter(metadata); got != want {
+			t.Fatalf("document type %q: matches = %v, want %v", documentType, got, want)
+		}
+	}
+}
+
+func TestVectorQueryConditionsAreANDed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	query := NewVectorQuery("kb", []float32{1}, 10).
+		WithFilterIn(MetadataLanguage, "zh", "en").
+		WithCondition(MetadataTag, FilterOpHasAny, "api", "guide").
+		WithTimeRange(MetadataCreatedAt, start, end).
+		WithFilterNotIn(MetadataChunkType, "summary")
+
+	match := map[string]string{
+		MetadataLanguage:                "zh",
+		PrefixedKey(MetadataTag, "api"): "true",
+		MetadataCreatedAt:               FormatMetadataTime(start.Add(24 * time.Hour)),
+		MetadataChunkType:               "text",
+	}
+	if !query.MatchesFilter(match) {
+		t.Fatal("expected match")
+	}
+
+	mutations := map[string]func(map[string]string){
+		"language":   func(m map[string]string) { m[MetadataLanguage] = "fr" },
+		"tag":        func(m map[string]string) { delete(m, PrefixedKey(MetadataTag, "api")) },
+		"too early":  func(m map[string]string) { m[MetadataCreatedAt] = FormatMetadataTime(start.Add(-time.Second)) },
+		"too late":   func(m map[string]string) { m[MetadataCreatedAt] = FormatMetadataTime(end.Add(time.Second)) },
+		"chunk type": func(m map[string]string) { m[MetadataChunkType] = "summary" },
+	}
+	for name, mutate := range mutations {
+		metadata := make(map[string]string, len(match))
+		for key, value := range match {
+			metadata[key] = value
+		}
+		mutate(metadata)
+		if query.MatchesFilter(metadata) {
+			t.Fatalf("%s: expected no match", name)
+		}
+	}
+}
+
+func TestVectorQueryIgnoresEmptyConditions(t *testing.T) {
+	query := NewVectorQuery("kb", []float32{1}, 10).
+		WithFilterIn(MetadataDocumentType).
+		WithTimeRange(MetadataCreatedAt, time.Time{}, time.Time{})
+	if len(query.Conditions) != 0 {
+		t.Fatalf("empty conditions should be ignored: %+v", query.Conditions)
+	}
+
+	// 缺少字段时not_in视为满足
+	notIn := NewVectorQuery("kb", []float32{1}, 10).WithFilterNotIn(MetadataChunkType, "summary")
+	if !notIn.MatchesFilter(map[string]string{}) {
+		t.Fatal("missing field should satisfy not_in")
+	}
+}
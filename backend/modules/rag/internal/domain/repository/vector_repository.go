@@ -17,6 +17,8 @@ type VectorRepository interface {
 	// 向量存储
 	Insert(ctx context.Context, indexName string, vectors []VectorRecord) error
 	Update(ctx context.Context, indexName string, vectors []VectorRecord) error
+	// Upsert 按ID插入或替换向量，已存在的同ID向量会被原子替换
+	Upsert(ctx context.Context, indexName string, vectors []VectorRecord) error
 	Delete(ctx context.Context, indexName string, ids []string) error
 
 	// 向量搜索
@@ -31,6 +33,9 @@ type VectorRepository interface {
 	GetVectorCount(ctx context.Context, indexName string) (int64, error)
 	GetIndexStats(ctx context.Context, indexName string) (*IndexStats, error)
 
+	// 启动预热，服务就绪前调用，如创建存储结构、加载已有索引
+	Warmup(ctx context.Context) error
+
 	// 健康检查
 	Health(ctx context.Context) error
 }
@@ -59,7 +64,8 @@ type VectorQuery struct {
 	TopK           int               `json:"top_k"`
 	ScoreThreshold float32           `json:"score_threshold"`
 	MetricType     MetricType        `json:"metric_type"`
-	Filter         map[string]string `json:"filter"`          // 元数据过滤
+	Filter         map[string]string `json:"filter"`          // 元数据等值过滤
+	Conditions     []FilterCondition `json:"conditions,omitempty"` // 元数据过滤条件，与Filter之间为AND
 	IncludeVector  bool              `json:"include_vector"`  // 是否返回向量
 	IncludeMetadata bool             `json:"include_metadata"` // 是否返回元数据
 }
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResultField 搜索结果中可返回的元数据字段
+type ResultField string
+
+const (
+	ResultFieldAuthor       ResultField = "author"        // 文档作者
+	ResultFieldTags         ResultField = "tags"          // 文档标签名，逗号分隔
+	ResultFieldSource       ResultField = "source"        // 文档来源
+	ResultFieldCategory     ResultField = "category"      // 文档分类
+	ResultFieldVersion      ResultField = "version"       // 文档版本
+	ResultFieldLanguage     ResultField = "language"      // 文档语言
+	ResultFieldSection      ResultField = "section"       // 分块所在章节
+	ResultFieldDocumentInfo ResultField = "document_info" // 文档信息document_info
+	ResultFieldDescription  ResultField = "description"   // 文档描述
+	ResultFieldKeywords     ResultField = "keywords"      // 分块关键词，分块没有时为文档关键词，逗号分隔
+	ResultFieldEntities     ResultField = "entities"      // 分块中识别的实体，逗号分隔
+	ResultFieldCustom       ResultField = "custom"        // 全部自定义元数据，键为custom.<键>；custom.<键>只返回单个键
+)
+
+// customFieldPrefix 单个自定义元数据字段的前缀
+const customFieldPrefix = "custom."
+
+// DefaultResultFields include_metadata为true且未指定字段时返回的字段
+// 描述、关键词、实体和自定义元数据可能较大，只在显式指定时返回
+var DefaultResultFields = []ResultField{
+	ResultFieldAuthor,
+	ResultFieldTags,
+	ResultFieldSource,
+	ResultFieldCategory,
+	ResultFieldVersion,
+	ResultFieldLanguage,
+	ResultFieldSection,
+	ResultFieldDocumentInfo,
+}
+
+// ResultProjection 搜索结果的元数据投影，决定结果中返回哪些文档和分块元数据
+type ResultProjection struct {
+	fields    map[ResultField]bool
+	customKey map[string]bool
+}
+
+// NewResultProjection 创建元数据投影
+// 指定了字段时只返回这些字段；未指定字段时，includeMetadata为true返回默认字段，否则不返回元数据
+func NewResultProjection(includeMetadata bool, fields []string) (*ResultProjection, error) {
+	p := &ResultProjection{
+		fields:    make(map[ResultField]bool),
+		customKey: make(map[string]bool),
+	}
+	if len(fields) == 0 {
+		if includeMetadata {
+			for _, field := range DefaultResultFields {
+				p.fields[field] = true
+			}
+		}
+		return p, nil
+	}
+
+	for _, name := range fields {
+		name = strings.TrimSpace(name)
+		if key, ok := strings.CutPrefix(name, customFieldPrefix); ok {
+			if key == "" {
+				return nil, ErrInvalidInputf("fields", "custom metadata key is empty")
+			}
+			p.customKey[key] = true
+			continue
+		}
+		field := ResultField(name)
+		if !field.IsValid() {
+			return nil, ErrInvalidInputf("fields", fmt.Sprintf("unknown result field %q", name))
+		}
+		p.fields[field] = true
+	}
+	return p, nil
+}
+
+// IsValid 检查字段是否是已知字段
+func (f ResultField) IsValid() bool {
+	switch f {
+	case ResultFieldAuthor, ResultFieldTags, ResultFieldSource, ResultFieldCategory,
+		ResultFieldVersion, ResultFieldLanguage, ResultFieldSection, ResultFieldDocumentInfo,
+		ResultFieldDescription, ResultFieldKeywords, ResultFieldEntities, ResultFieldCustom:
+		return true
+	}
+	return false
+}
+
+// IsEmpty 是否不返回任何元数据
+func (p *ResultProjection) IsEmpty() bool {
+	return len(p.fields) == 0 && len(p.customKey) == 0
+}
+
+// Includes 是否返回指定字段
+func (p *ResultProjection) Includes(field ResultField) bool {
+	return p.fields[field]
+}
+
+// NeedsDocument 投影是否需要加载文档，只需要分块元数据时不加载
+func (p *ResultProjection) NeedsDocument() bool {
+	for field := range p.fields {
+		if field != ResultFieldSection && field != ResultFieldEntities {
+			return true
+		}
+	}
+	return len(p.customKey) > 0
+}
+
+// Apply 按投影将文档和分块的元数据写入搜索结果，doc为nil时只写入分块元数据
+func (p *ResultProjection) Apply(result *SearchResult, doc *Document, chunk *Chunk) {
+	set := func(field ResultField, value string) {
+		if p.fields[field] && value != "" {
+			result.AddMetadata(string(field), value)
+		}
+	}
+
+	if chunk != nil {
+		set(ResultFieldSection, chunk.Metadata.Section)
+		set(ResultFieldEntities, strings.Join(chunk.Metadata.Entities, ","))
+	}
+
+	if doc != nil {
+		set(ResultFieldAuthor, doc.Metadata.Author)
+		set(ResultFieldSource, doc.Source)
+		set(ResultFieldCategory, doc.Metadata.Category)
+		set(ResultFieldVersion, doc.Metadata.Version)
+		set(ResultFieldLanguage, doc.Language)
+		set(ResultFieldDescription, doc.Metadata.Description)
+
+		tags := make([]string, len(doc.Tags))
+		for i, tag := range doc.Tags {
+			tags[i] = tag.Name
+		}
+		set(ResultFieldTags, strings.Join(tags, ","))
+
+		if p.fields[ResultFieldDocumentInfo] {
+			info := &DocumentInfo{
+				DocumentID:   doc.ID,
+				DocumentType: string(doc.Type),
+				Language:     doc.Language,
+				Size:         doc.Size,
+				CreatedAt:    doc.CreatedAt,
+			}
+			if doc.IndexedAt != nil {
+				info.IndexedAt = *doc.IndexedAt
+			}
+			result.SetDocumentInfo(info)
+		}
+	}
+
+	keywords := []string(nil)
+	if chunk != nil {
+		keywords = chunk.Metadata.Keywords
+	}
+	if len(keywords) == 0 && doc != nil {
+		keywords = doc.Metadata.Keywords
+	}
+	set(ResultFieldKeywords, strings.Join(keywords, ","))
+
+	// 自定义元数据，分块的值覆盖文档的同名键
+	custom := make(map[string]string)
+	if doc != nil {
+		for key, value := range doc.Metadata.Custom {
+			custom[key] = value
+		}
+	}
+	if chunk != nil {
+		for key, value := range chunk.Metadata.Custom {
+			custom[key] = value
+		}
+	}
+	for key, value := range custom {
+		if p.fields[ResultFieldCustom] || p.customKey[key] {
+			result.AddMetadata(customFieldPrefix+key, value)
+		}
+	}
+}
@@ -25,16 +25,19 @@ type SearchResult struct {
 	Highlight   string            `json:"highlight"`    // 高亮片段
 	ChunkInfo   *ChunkInfo        `json:"chunk_info,omitempty"` // 分块信息
 	DocumentInfo *DocumentInfo    `json:"document_info,omitempty"` // 文档信息
+	Context     *ResultContext    `json:"context,omitempty"` // 命中分块的前后文，开启上下文扩展时返回
 	SearchedAt  time.Time         `json:"searched_at"`  // 搜索时间
 }
 
 // ChunkInfo 分块信息
 type ChunkInfo struct {
 	Position    int    `json:"position"`     // 在文档中的位置
-	StartIndex  int    `json:"start_index"`  // 开始索引
-	EndIndex    int    `json:"end_index"`    // 结束索引
+	StartIndex  int    `json:"start_index"`  // 开始位置（字符偏移）
+	EndIndex    int    `json:"end_index"`    // 结束位置（字符偏移）
 	TokenCount  int    `json:"token_count"`  // 令牌数量
 	ChunkType   string `json:"chunk_type"`   // 分块类型
+	MergedChunkIDs []string `json:"merged_chunk_ids,omitempty"` // 合并了重叠或相邻分块时，按位置排列的分块ID
+	Image       *ChunkImage `json:"image,omitempty"` // 图片分块的来源图片
 }
 
 // DocumentInfo 文档信息
@@ -55,12 +58,18 @@ type SearchQuery struct {
 	ScoreThreshold float32          `json:"score_threshold"` // 分数阈值
 	Filters       SearchFilters     `json:"filters"`         // 过滤条件
 	SearchType    SearchType        `json:"search_type"`     // 搜索类型
+	SearchMode    SearchMode        `json:"search_mode,omitempty"` // 检索方式，为空时按SearchType确定
+	Level         SearchLevel       `json:"level,omitempty"`  // 检索粒度，为空时检索所有分块
 	Rerank        bool              `json:"rerank"`          // 是否重排序
-	IncludeMetadata bool            `json:"include_metadata"` // 是否包含元数据
+	IncludeMetadata bool            `json:"include_metadata"` // 是否包含元数据，未指定Fields时返回DefaultResultFields
+	Fields        []string          `json:"fields,omitempty"` // 返回的元数据字段，见ResultField
+	UserID        string            `json:"-"` // 请求用户，由应用服务从请求上下文设置，用于文档级权限过滤
+	ContextExpansion *ContextExpansion `json:"context_expansion,omitempty"` // 命中分块的上下文扩展，为空时不扩展
 }
 
 // SearchFilters 搜索过滤条件
 type SearchFilters struct {
+	DocumentIDs   []string          `json:"document_ids,omitempty"`   // 文档过滤，粗粒度检索后在命中文档内检索
 	DocumentTypes []string          `json:"document_types,omitempty"` // 文档类型过滤
 	Tags          []string          `json:"tags,omitempty"`           // 标签过滤
 	DateRange     *DateRange        `json:"date_range,omitempty"`     // 日期范围
@@ -84,6 +93,34 @@ const (
 	SearchTypeHybrid   SearchType = "hybrid"   // 混合搜索
 )
 
+// SearchMode 检索方式
+type SearchMode string
+
+const (
+	SearchModeVector  SearchMode = "vector"  // 向量检索
+	SearchModeKeyword SearchMode = "keyword" // 关键词检索
+	SearchModeHybrid  SearchMode = "hybrid"  // 向量与关键词检索并行执行后融合排序
+)
+
+// SearchLevel 检索粒度
+type SearchLevel string
+
+const (
+	SearchLevelAll     SearchLevel = ""        // 检索所有分块
+	SearchLevelSummary SearchLevel = "summary" // 只检索文档摘要分块，先定位相关文档
+	SearchLevelDetail  SearchLevel = "detail"  // 只检索正文和图片分块，不含摘要分块
+)
+
+// Validate 校验检索粒度
+func (l SearchLevel) Validate() error {
+	switch l {
+	case SearchLevelAll, SearchLevelSummary, SearchLevelDetail:
+		return nil
+	default:
+		return NewDomainError("INVALID_SEARCH_LEVEL", "search level must be summary or detail")
+	}
+}
+
 // SearchResults 搜索结果集合
 type SearchResults struct {
 	Results    []SearchResult `json:"results"`
@@ -102,7 +139,7 @@ func NewSearchQuery(query, knowledgeBaseID string) *SearchQuery {
 		ScoreThreshold:  0.0,
 		SearchType:      SearchTypeSemantic,
 		Rerank:          false,
-		IncludeMetadata: true,
+		IncludeMetadata: false,
 		Filters:         SearchFilters{},
 	}
 }
@@ -125,6 +162,28 @@ func (sq *SearchQuery) WithSearchType(searchType SearchType) *SearchQuery {
 	return sq
 }
 
+// WithSearchMode 设置检索方式
+func (sq *SearchQuery) WithSearchMode(mode SearchMode) *SearchQuery {
+	sq.SearchMode = mode
+	return sq
+}
+
+// ResolveSearchMode 获取实际使用的检索方式，未指定时词汇搜索使用关键词检索、混合搜索使用混合检索，其余使用向量检索
+func (sq *SearchQuery) ResolveSearchMode() SearchMode {
+	switch sq.SearchMode {
+	case SearchModeVector, SearchModeKeyword, SearchModeHybrid:
+		return sq.SearchMode
+	}
+	switch sq.SearchType {
+	case SearchTypeLexical:
+		return SearchModeKeyword
+	case SearchTypeHybrid:
+		return SearchModeHybrid
+	default:
+		return SearchModeVector
+	}
+}
+
 // WithFilters 设置过滤条件
 func (sq *SearchQuery) WithFilters(filters SearchFilters) *SearchQuery {
 	sq.Filters = filters
@@ -200,10 +259,7 @@ func (sr *SearchResult) IsRelevant(threshold float32) bool {
 
 // GetPreview 获取内容预览
 func (sr *SearchResult) GetPreview(length int) string {
-	if length <= 0 || length >= len(sr.Content) {
-		return sr.Content
-	}
-	return sr.Content[:length] + "..."
+	return truncateRunes(sr.Content, length)
 }
 
 // NewSearchResults 创建搜索结果集合
@@ -244,6 +300,14 @@ func (srs *SearchResults) HasResults() bool {
 	return len(srs.Results) > 0
 }
 
+// Truncate 截断结果到前N个
+func (srs *SearchResults) Truncate(n int) {
+	if n >= 0 && n < len(srs.Results) {
+		srs.Results = srs.Results[:n]
+		srs.Total = len(srs.Results)
+	}
+}
+
 // GetTopResults 获取前N个结果
 func (srs *SearchResults) GetTopResults(n int) []SearchResult {
 	if n >= len(srs.Results) {
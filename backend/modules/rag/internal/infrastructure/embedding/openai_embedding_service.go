@@ -11,6 +11,9 @@ import (
 
 	"github.com/noah-loop/backend/modules/rag/internal/application/service"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/retry"
+	"go.uber.org/zap"
 )
 
 // OpenAIEmbeddingService OpenAI嵌入服务实现
@@ -27,9 +30,8 @@ func NewOpenAIEmbeddingService(config *service.EmbeddingConfig, logger infrastru
 		config = service.DefaultEmbeddingConfig()
 	}
 	
-	httpClient := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
-	}
+	// 单次请求的超时由重试策略通过上下文控制
+	httpClient := &http.Client{}
 	
 	return &OpenAIEmbeddingService{
 		config:     config,
@@ -135,7 +137,7 @@ func (s *OpenAIEmbeddingService) generateBatchEmbeddings(ctx context.Context, te
 	return embeddings, nil
 }
 
-// makeRequest 发起HTTP请求
+// makeRequest 发起HTTP请求，单次请求受Timeout限制，超时、限流和服务端错误按配置退避重试
 func (s *OpenAIEmbeddingService) makeRequest(ctx context.Context, reqBody map[string]interface{}) ([][]float32, int, error) {
 	// 序列化请求体
 	jsonBody, err := json.Marshal(reqBody)
@@ -143,6 +145,36 @@ func (s *OpenAIEmbeddingService) makeRequest(ctx context.Context, reqBody map[st
 		return nil, 0, fmt.Errorf("failed to marshal request: %w", err)
 	}
 	
+	provider := string(s.config.Provider)
+	policy := s.config.RetryPolicy()
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		metrics.IncProviderRetry(provider, "embedding")
+		s.logger.Warn("Embedding request failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+	}
+	
+	var (
+		embeddings [][]float32
+		tokenCount int
+	)
+	err = retry.Do(ctx, policy, func(ctx context.Context) error {
+		start := time.Now()
+		var err error
+		embeddings, tokenCount, err = s.doRequest(ctx, jsonBody)
+		metrics.ObserveProviderCall(provider, "embedding", time.Since(start), metrics.ProviderStatus(err))
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	return embeddings, tokenCount, nil
+}
+
+// doRequest 发起单次HTTP请求，不可重试的错误使用retry.Permanent标记
+func (s *OpenAIEmbeddingService) doRequest(ctx context.Context, jsonBody []byte) ([][]float32, int, error) {
 	// 创建HTTP请求
 	apiURL := s.config.APIBase
 	if apiURL == "" {
@@ -150,9 +182,9 @@ func (s *OpenAIEmbeddingService) makeRequest(ctx context.Context, reqBody map[st
 	}
 	apiURL += "/v1/embeddings"
 	
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(jsonBody))
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, retry.Permanent(fmt.Errorf("failed to create request: %w", err))
 	}
 	
 	// 设置请求头
@@ -172,16 +204,20 @@ func (s *OpenAIEmbeddingService) makeRequest(ctx context.Context, reqBody map[st
 		return nil, 0, fmt.Errorf("failed to read response: %w", err)
 	}
 	
-	// 检查HTTP状态码
+	// 检查HTTP状态码，只有超时、限流和服务端错误可以重试
 	if resp.StatusCode != http.StatusOK {
-		return nil, 0, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		err = fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		if !retry.IsTransientStatus(resp.StatusCode) {
+			err = retry.Permanent(err)
+		}
+		return nil, 0, err
 	}
 	
 	// 解析响应
 	var apiResp OpenAIEmbeddingResponse
 	err = json.Unmarshal(respBody, &apiResp)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, 0, retry.Permanent(fmt.Errorf("failed to unmarshal response: %w", err))
 	}
 	
 	// 提取嵌入向量
@@ -207,6 +243,29 @@ func (s *OpenAIEmbeddingService) GetModel() string {
 	return s.config.Model
 }
 
+// ForLanguage 获取适用于指定语言的嵌入服务
+// 配置了语言专用模型时返回使用该模型的服务，与当前服务共享HTTP客户端和指标
+func (s *OpenAIEmbeddingService) ForLanguage(language string) service.EmbeddingService {
+	model, exists := s.config.LanguageModel(language)
+	if !exists {
+		return s
+	}
+	
+	config := *s.config
+	config.Model = model.Model
+	if model.Dimension > 0 {
+		config.Dimension = model.Dimension
+	}
+	config.LanguageModels = nil
+	
+	return &OpenAIEmbeddingService{
+		config:     &config,
+		httpClient: s.httpClient,
+		logger:     s.logger,
+		metrics:    s.metrics,
+	}
+}
+
 // ValidateEmbedding 验证嵌入向量
 func (s *OpenAIEmbeddingService) ValidateEmbedding(embedding []float32) error {
 	if len(embedding) == 0 {
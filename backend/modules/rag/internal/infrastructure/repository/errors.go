@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/dberror"
+	"gorm.io/gorm"
+)
+
+// translateError 将数据库错误转换为仓储错误
+// 记录不存在转换为repository.ErrNotFound，唯一约束冲突转换为repository.ErrDuplicateKey
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", repository.ErrNotFound, err)
+	}
+	if dberror.IsDuplicateKey(err) {
+		return fmt.Errorf("%w: %v", repository.ErrDuplicateKey, err)
+	}
+	return err
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormChunkRepository GORM分块仓储实现
@@ -31,10 +34,7 @@ func (r *GormChunkRepository) FindByID(ctx context.Context, id string) (*domain.
 	err := r.db.WithContext(ctx).First(&chunk, "id = ?", id).Error
 	
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
 	
 	return &chunk, nil
@@ -95,6 +95,42 @@ func (r *GormChunkRepository) FindByType(ctx context.Context, chunkType domain.C
 	return chunks, err
 }
 
+// SearchByKeywords 按关键词检索分块
+// 关键词按不区分大小写的子串匹配，命中关键词多、内容短的分块排在前面
+func (r *GormChunkRepository) SearchByKeywords(ctx context.Context, knowledgeBaseID string, keywords []string, limit int) ([]*domain.Chunk, error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+	
+	conditions := make([]string, len(keywords))
+	scores := make([]string, len(keywords))
+	patterns := make([]interface{}, len(keywords))
+	for i, keyword := range keywords {
+		conditions[i] = "chunks.content ILIKE ?"
+		scores[i] = "CASE WHEN chunks.content ILIKE ? THEN 1 ELSE 0 END"
+		patterns[i] = "%" + likeEscaper.Replace(keyword) + "%"
+	}
+	
+	var chunks []*domain.Chunk
+	err := r.db.WithContext(ctx).
+		Select("chunks.*").
+		Joins("JOIN documents ON documents.id = chunks.document_id").
+		Where("documents.knowledge_base_id = ?", knowledgeBaseID).
+		Where("("+strings.Join(conditions, " OR ")+")", patterns...).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(" + strings.Join(scores, " + ") + ") DESC, length(chunks.content) ASC",
+			Vars:               patterns,
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&chunks).Error
+	
+	return chunks, err
+}
+
+// likeEscaper 转义LIKE模式中的通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // FindWithoutEmbedding 查找没有嵌入向量的分块
 func (r *GormChunkRepository) FindWithoutEmbedding(ctx context.Context, limit int) ([]*domain.Chunk, error) {
 	var chunks []*domain.Chunk
@@ -107,6 +143,20 @@ func (r *GormChunkRepository) FindWithoutEmbedding(ctx context.Context, limit in
 	return chunks, err
 }
 
+// FindUnsyncedVectors 查找需要重新写入向量库的分块，已软删除文档的分块不写入向量库
+func (r *GormChunkRepository) FindUnsyncedVectors(ctx context.Context, maxAttempts int, staleBefore time.Time, limit int) ([]*domain.Chunk, error) {
+	var chunks []*domain.Chunk
+	err := r.db.WithContext(ctx).
+		Where("(vector_status = ? AND vector_sync_attempts < ?) OR (vector_status = ? AND created_at < ?)",
+			domain.VectorSyncFailed, maxAttempts, domain.VectorSyncPending, staleBefore).
+		Where("document_id IN (?)", r.db.Model(&domain.Document{}).Select("id")).
+		Limit(limit).
+		Order("document_id ASC, position ASC").
+		Find(&chunks).Error
+	
+	return chunks, err
+}
+
 // UpdateEmbedding 更新嵌入向量
 func (r *GormChunkRepository) UpdateEmbedding(ctx context.Context, chunkID string, embedding []float32) error {
 	now := gorm.Expr("NOW()")
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/pagination"
 	"gorm.io/gorm"
 )
 
@@ -21,9 +23,9 @@ func NewGormDocumentRepository(db *gorm.DB) repository.DocumentRepository {
 	}
 }
 
-// Save 保存文档
+// Save 保存文档，同一知识库中内容哈希重复时返回repository.ErrDuplicateKey
 func (r *GormDocumentRepository) Save(ctx context.Context, document *domain.Document) error {
-	return r.db.WithContext(ctx).Create(document).Error
+	return translateError(r.db.WithContext(ctx).Create(document).Error)
 }
 
 // FindByID 根据ID查找文档
@@ -35,38 +37,33 @@ func (r *GormDocumentRepository) FindByID(ctx context.Context, id string) (*doma
 		First(&document, "id = ?", id).Error
 	
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
 	
 	return &document, nil
 }
 
-// FindByHash 根据哈希查找文档
-func (r *GormDocumentRepository) FindByHash(ctx context.Context, hash string) (*domain.Document, error) {
+// FindByHash 根据知识库和内容哈希查找文档
+func (r *GormDocumentRepository) FindByHash(ctx context.Context, knowledgeBaseID, hash string) (*domain.Document, error) {
 	var document domain.Document
 	err := r.db.WithContext(ctx).
 		Preload("Tags").
-		First(&document, "hash = ?", hash).Error
+		First(&document, "knowledge_base_id = ? AND hash = ?", knowledgeBaseID, hash).Error
 	
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
 	
 	return &document, nil
 }
 
-// Update 更新文档
+// Update 更新文档，分块由分块仓储维护，不随文档保存
+// 同一知识库中内容哈希重复时返回repository.ErrDuplicateKey
 func (r *GormDocumentRepository) Update(ctx context.Context, document *domain.Document) error {
-	return r.db.WithContext(ctx).Save(document).Error
+	return translateError(r.db.WithContext(ctx).Omit("Chunks").Save(document).Error)
 }
 
-// Delete 删除文档
+// Delete 软删除文档
 func (r *GormDocumentRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(&domain.Document{}, "id = ?", id).Error
 }
@@ -161,6 +158,23 @@ func (r *GormDocumentRepository) FindByKnowledgeBaseIDWithPagination(ctx context
 	return documents, total, err
 }
 
+// FindAfterCursor 游标分页查找文档
+func (r *GormDocumentRepository) FindAfterCursor(ctx context.Context, cursor *pagination.Cursor, limit int) ([]*domain.Document, *pagination.Cursor, error) {
+	return r.findAfterCursor(r.db.WithContext(ctx), cursor, limit)
+}
+
+// FindByKnowledgeBaseIDAfterCursor 根据知识库ID游标分页查找文档
+func (r *GormDocumentRepository) FindByKnowledgeBaseIDAfterCursor(ctx context.Context, knowledgeBaseID string, cursor *pagination.Cursor, limit int) ([]*domain.Document, *pagination.Cursor, error) {
+	return r.findAfterCursor(r.db.WithContext(ctx).Where("knowledge_base_id = ?", knowledgeBaseID), cursor, limit)
+}
+
+// findAfterCursor 查询cursor之后的一页文档
+func (r *GormDocumentRepository) findAfterCursor(query *gorm.DB, cursor *pagination.Cursor, limit int) ([]*domain.Document, *pagination.Cursor, error) {
+	return pagination.FindAfterCursor(query.Preload("Tags"), cursor, limit, func(doc *domain.Document) (time.Time, string) {
+		return doc.CreatedAt, doc.ID
+	})
+}
+
 // SearchByContent 根据内容搜索文档
 func (r *GormDocumentRepository) SearchByContent(ctx context.Context, query string, knowledgeBaseID string, limit int) ([]*domain.Document, error) {
 	var documents []*domain.Document
@@ -392,3 +406,62 @@ func (r *GormDocumentRepository) MarkAsIndexingFailed(ctx context.Context, docum
 		Where("id = ?", documentID).
 		Updates(updates).Error
 }
+
+// FindIncludingDeleted 根据ID查找文档，包括已软删除的文档
+func (r *GormDocumentRepository) FindIncludingDeleted(ctx context.Context, id string) (*domain.Document, error) {
+	var document domain.Document
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("Tags").
+		First(&document, "id = ?", id).Error
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &document, nil
+}
+
+// Restore 恢复软删除的文档，文档的分块在永久删除前一直保留
+func (r *GormDocumentRepository) Restore(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&domain.Document{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: deleted document %s", repository.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+// PurgeDeleted 永久删除before之前软删除的文档
+func (r *GormDocumentRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = purgeDocuments(tx, "deleted_at IS NOT NULL AND deleted_at < ?", before)
+		return err
+	})
+
+	return purged, err
+}
+
+// purgeDocuments 永久删除满足条件的文档（包括已软删除的），同时删除其分块和标签关联，返回删除的文档数
+func purgeDocuments(tx *gorm.DB, query interface{}, args ...interface{}) (int64, error) {
+	ids := tx.Unscoped().Model(&domain.Document{}).Select("id").Where(query, args...)
+
+	if err := tx.Where("document_id IN (?)", ids).Delete(&domain.Chunk{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Exec("DELETE FROM document_tags WHERE document_id IN (?)", ids).Error; err != nil {
+		return 0, err
+	}
+
+	result := tx.Unscoped().Where(query, args...).Delete(&domain.Document{})
+	return result.RowsAffected, result.Error
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/noah-loop/backend/modules/rag/internal/domain"
 	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormKnowledgeBaseRepository GORM知识库仓储实现
@@ -34,10 +37,7 @@ func (r *GormKnowledgeBaseRepository) FindByID(ctx context.Context, id string) (
 		First(&kb, "id = ?", id).Error
 	
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
 	
 	return &kb, nil
@@ -51,10 +51,7 @@ func (r *GormKnowledgeBaseRepository) FindByName(ctx context.Context, name, owne
 		First(&kb).Error
 	
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
 	
 	return &kb, nil
@@ -65,7 +62,7 @@ func (r *GormKnowledgeBaseRepository) Update(ctx context.Context, knowledgeBase
 	return r.db.WithContext(ctx).Save(knowledgeBase).Error
 }
 
-// Delete 删除知识库
+// Delete 软删除知识库及其文档
 func (r *GormKnowledgeBaseRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 先删除相关的文档
@@ -73,7 +70,7 @@ func (r *GormKnowledgeBaseRepository) Delete(ctx context.Context, id string) err
 			return err
 		}
 		
-		// 删除知识库
+		// 删除知识库，共享权限保留到永久删除，以便恢复
 		return tx.Delete(&domain.KnowledgeBase{}, "id = ?", id).Error
 	})
 }
@@ -260,8 +257,8 @@ func (r *GormKnowledgeBaseRepository) GetQueryHistory(ctx context.Context, knowl
 	return []repository.QueryRecord{}, nil
 }
 
-// CheckAccess 检查访问权限
-func (r *GormKnowledgeBaseRepository) CheckAccess(ctx context.Context, knowledgeBaseID, userID string) (bool, error) {
+// CheckAccess 检查用户是否拥有知识库的指定权限，所有者拥有全部权限
+func (r *GormKnowledgeBaseRepository) CheckAccess(ctx context.Context, knowledgeBaseID, userID string, permission repository.Permission) (bool, error) {
 	var count int64
 	
 	// 检查是否是所有者
@@ -278,27 +275,132 @@ func (r *GormKnowledgeBaseRepository) CheckAccess(ctx context.Context, knowledge
 		return true, nil
 	}
 	
-	// TODO: 检查共享权限表
-	// 这里需要实现权限表的查询逻辑
+	// 检查共享权限表
+	var grant domain.KnowledgeBasePermission
+	err = r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND user_id = ?", knowledgeBaseID, userID).
+		First(&grant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
 	
-	return false, nil
+	return repository.Permission(grant.Permission).Includes(permission), nil
 }
 
-// GrantAccess 授予访问权限
+// GrantAccess 授予访问权限，已授予时覆盖原权限
 func (r *GormKnowledgeBaseRepository) GrantAccess(ctx context.Context, knowledgeBaseID, userID string, permission repository.Permission) error {
-	// TODO: 实现权限授予逻辑
-	// 这里需要创建权限表并实现相关逻辑
-	return nil
+	grant := domain.KnowledgeBasePermission{
+		KnowledgeBaseID: knowledgeBaseID,
+		UserID:          userID,
+		Permission:      string(permission),
+		GrantedAt:       time.Now(),
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "knowledge_base_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"permission", "granted_at"}),
+		}).
+		Create(&grant).Error
 }
 
-// RevokeAccess 撤销访问权限
+// RevokeAccess 撤销访问权限，未授予时返回repository.ErrNotFound
 func (r *GormKnowledgeBaseRepository) RevokeAccess(ctx context.Context, knowledgeBaseID, userID string) error {
-	// TODO: 实现权限撤销逻辑
+	result := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND user_id = ?", knowledgeBaseID, userID).
+		Delete(&domain.KnowledgeBasePermission{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
 	return nil
 }
 
-// ListAccessUsers 列出有访问权限的用户
+// ListAccessUsers 列出有访问权限的用户，所有者排在最前，其余按授予时间排序
 func (r *GormKnowledgeBaseRepository) ListAccessUsers(ctx context.Context, knowledgeBaseID string) ([]repository.UserPermission, error) {
-	// TODO: 实现权限用户列表逻辑
-	return []repository.UserPermission{}, nil
+	var kb domain.KnowledgeBase
+	if err := r.db.WithContext(ctx).Select("id", "owner_id", "created_at").First(&kb, "id = ?", knowledgeBaseID).Error; err != nil {
+		return nil, translateError(err)
+	}
+	
+	var grants []domain.KnowledgeBasePermission
+	err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ?", knowledgeBaseID).
+		Order("granted_at ASC").
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	
+	users := make([]repository.UserPermission, 0, len(grants)+1)
+	users = append(users, repository.UserPermission{
+		UserID:     kb.OwnerID,
+		Permission: repository.PermissionOwner,
+		GrantedAt:  kb.CreatedAt.Format(time.RFC3339),
+	})
+	for _, grant := range grants {
+		users = append(users, repository.UserPermission{
+			UserID:     grant.UserID,
+			Permission: repository.Permission(grant.Permission),
+			GrantedAt:  grant.GrantedAt.Format(time.RFC3339),
+		})
+	}
+	return users, nil
+}
+
+// FindIncludingDeleted 根据ID查找知识库，包括已软删除的知识库
+func (r *GormKnowledgeBaseRepository) FindIncludingDeleted(ctx context.Context, id string) (*domain.KnowledgeBase, error) {
+	var kb domain.KnowledgeBase
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("Tags").
+		First(&kb, "id = ?", id).Error
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &kb, nil
+}
+
+// PurgeDeleted 永久删除before之前软删除的知识库
+func (r *GormKnowledgeBaseRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = purgeKnowledgeBases(tx, "deleted_at IS NOT NULL AND deleted_at < ?", before)
+		return err
+	})
+
+	return purged, err
+}
+
+// Purge 永久删除知识库，不论是否已软删除
+func (r *GormKnowledgeBaseRepository) Purge(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := purgeKnowledgeBases(tx, "id = ?", id)
+		return err
+	})
+}
+
+// purgeKnowledgeBases 永久删除满足条件的知识库（包括已软删除的），同时删除其全部文档、分块、共享权限和标签关联，返回删除的知识库数
+func purgeKnowledgeBases(tx *gorm.DB, query interface{}, args ...interface{}) (int64, error) {
+	ids := tx.Unscoped().Model(&domain.KnowledgeBase{}).Select("id").Where(query, args...)
+
+	if _, err := purgeDocuments(tx, "knowledge_base_id IN (?)", ids); err != nil {
+		return 0, err
+	}
+	if err := tx.Where("knowledge_base_id IN (?)", ids).Delete(&domain.KnowledgeBasePermission{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Exec("DELETE FROM knowledge_base_tags WHERE knowledge_base_id IN (?)", ids).Error; err != nil {
+		return 0, err
+	}
+
+	result := tx.Unscoped().Where(query, args...).Delete(&domain.KnowledgeBase{})
+	return result.RowsAffected, result.Error
 }
@@ -0,0 +1,127 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/httpclient"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+)
+
+// HTTPReranker 调用外部重排序服务（交叉编码器）的重排序器
+// 兼容Cohere、Jina、Xinference、vLLM等提供的 /v1/rerank 接口
+type HTTPReranker struct {
+	config     service.RerankerConfig
+	httpClient *http.Client
+}
+
+// rerankRequest 重排序请求
+type rerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// rerankResponse 重排序响应
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewHTTPReranker 创建HTTP重排序器
+// 使用共享出站客户端，超时取重排序配置；重排失败时沿用检索顺序，因此不重试
+func NewHTTPReranker(config service.RerankerConfig) *HTTPReranker {
+	clientConfig := httpclient.DefaultConfig()
+	if config.Timeout > 0 {
+		clientConfig.Timeout = config.Timeout
+	}
+	clientConfig.Retry.MaxAttempts = 1
+
+	return &HTTPReranker{
+		config:     config,
+		httpClient: httpclient.New(clientConfig),
+	}
+}
+
+// Rerank 对候选重新打分，返回按相关性降序排列的结果
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, documents []service.RerankDocument) ([]service.RerankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	texts := make([]string, len(documents))
+	for i, document := range documents {
+		texts[i] = document.Content
+	}
+	body, err := json.Marshal(rerankRequest{
+		Model:     r.config.Model,
+		Query:     query,
+		Documents: texts,
+		TopN:      len(texts),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rerank request: %w", err)
+	}
+
+	if r.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	results, err := r.doRequest(ctx, body, len(documents))
+	metrics.ObserveProviderCall("http", "rerank", time.Since(start), metrics.ProviderStatus(err))
+	return results, err
+}
+
+// doRequest 发起单次HTTP请求，重排失败时由调用方沿用检索顺序，因此不重试
+func (r *HTTPReranker) doRequest(ctx context.Context, body []byte, count int) ([]service.RerankResult, error) {
+	apiURL := strings.TrimRight(r.config.APIBase, "/") + "/v1/rerank"
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rerank request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send rerank request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rerank response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var apiResp rerankResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rerank response: %w", err)
+	}
+
+	results := make([]service.RerankResult, 0, len(apiResp.Results))
+	for _, item := range apiResp.Results {
+		if item.Index < 0 || item.Index >= count {
+			return nil, fmt.Errorf("rerank result index %d out of range", item.Index)
+		}
+		results = append(results, service.RerankResult{Index: item.Index, Score: float32(item.RelevanceScore)})
+	}
+	return results, nil
+}
@@ -0,0 +1,129 @@
+package summary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+)
+
+// summaryPrompt 要求模型用文档原语言概括主题和要点，摘要用于检索，不加开场白
+const summaryPrompt = `Summarize the following document in one paragraph of at most 200 words. ` +
+	`Cover its main topic, key points and the terms a reader would search for. ` +
+	`Write in the same language as the document and reply with the summary only.`
+
+// OpenAISummarizer 调用OpenAI兼容的对话模型（/v1/chat/completions）生成文档摘要
+// 与嵌入和图片识别共用OpenAI兼容服务的密钥，也可指向vLLM、Ollama等自建服务
+type OpenAISummarizer struct {
+	config     service.SummarizationConfig
+	httpClient *http.Client
+}
+
+// chatRequest 对话请求
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+}
+
+// chatMessage 对话消息
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponse 对话响应
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAISummarizer 创建对话模型文档摘要生成器
+func NewOpenAISummarizer(config service.SummarizationConfig) *OpenAISummarizer {
+	return &OpenAISummarizer{
+		config:     config,
+		httpClient: &http.Client{},
+	}
+}
+
+// Summarize 生成文档摘要，正文超出MaxInputChars时只概括开头部分
+func (s *OpenAISummarizer) Summarize(ctx context.Context, doc *domain.Document) (string, error) {
+	content := service.TruncateForSummary(doc.Content, s.config.MaxInputChars)
+	if doc.Title != "" {
+		content = "Title: " + doc.Title + "\n\n" + content
+	}
+	body, err := json.Marshal(chatRequest{
+		Model: s.config.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: content},
+		},
+		MaxTokens: s.config.MaxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal summary request: %w", err)
+	}
+
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	summary, err := s.doRequest(ctx, body)
+	metrics.ObserveProviderCall("openai", "summarization", time.Since(start), metrics.ProviderStatus(err))
+	return summary, err
+}
+
+// doRequest 发起单次HTTP请求，摘要失败不影响文档索引，因此不重试
+func (s *OpenAISummarizer) doRequest(ctx context.Context, body []byte) (string, error) {
+	apiURL := strings.TrimRight(s.config.APIBase, "/") + "/v1/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create summary request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send summary request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read summary response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("summary request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var apiResp chatResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal summary response: %w", err)
+	}
+	if len(apiResp.Choices) == 0 {
+		return "", fmt.Errorf("summary response has no choices")
+	}
+	summary := strings.TrimSpace(apiResp.Choices[0].Message.Content)
+	if summary == "" {
+		return "", fmt.Errorf("summary response is empty")
+	}
+	return summary, nil
+}
@@ -2,34 +2,92 @@ package vector
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
 	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/retry"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 集合字段
+const (
+	fieldID       = "id"
+	fieldVector   = "vector"
+	fieldMetadata = "metadata"
+
+	maxIDLength = 128
+
+	// HNSW索引参数
+	hnswM              = 16
+	hnswEfConstruction = 200
+	hnswMinSearchEf    = 64
 )
 
 // MilvusVectorRepository Milvus向量仓储实现
+// 每个索引对应一个Milvus集合，字段为 id（主键）、vector 和 metadata（JSON）
 type MilvusVectorRepository struct {
-	// client   milvus.Client // 需要引入Milvus Go SDK
-	config   *MilvusConfig
-	logger   infrastructure.Logger
-	indexMap map[string]*repository.IndexInfo
+	config *MilvusConfig
+	logger infrastructure.Logger
+
+	// client 底层为gRPC连接，多路复用且并发安全，所有请求共享同一个连接
+	client client.Client
+	mu     sync.Mutex
+	loaded map[string]repository.MetricType // 已加载到内存的集合 -> 向量索引的度量类型
+	stats  map[string]*indexQueryStats      // 集合名 -> 查询统计
+}
+
+// indexQueryStats 索引查询统计
+type indexQueryStats struct {
+	queryCount    int64
+	totalLatency  time.Duration
+	lastQueryTime time.Time
 }
 
 // MilvusConfig Milvus配置
 type MilvusConfig struct {
-	Host        string `json:"host"`
-	Port        int    `json:"port"`
-	Username    string `json:"username"`
-	Password    string `json:"password"`
-	Database    string `json:"database"`
-	Timeout     int    `json:"timeout"`
-	MaxRetries  int    `json:"max_retries"`
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Database   string `json:"database"`
+	Timeout    int    `json:"timeout"`     // 单次请求超时（秒）
+	MaxRetries int    `json:"max_retries"` // 连接失败、超时和限流时的最大重试次数
+
+	// MetricType 写入时自动创建的集合使用的度量类型，默认余弦相似度
+	MetricType repository.MetricType `json:"metric_type"`
+}
+
+// Address Milvus服务地址
+func (c *MilvusConfig) Address() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// RetryPolicy 根据配置生成重试策略
+func (c *MilvusConfig) RetryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = c.MaxRetries + 1
+	policy.InitialDelay = 200 * time.Millisecond
+	policy.MaxDelay = 5 * time.Second
+	if c.Timeout > 0 {
+		policy.AttemptTimeout = time.Duration(c.Timeout) * time.Second
+	}
+	return policy
 }
 
 // NewMilvusVectorRepository 创建Milvus向量仓储
-func NewMilvusVectorRepository(config *MilvusConfig, logger infrastructure.Logger) repository.VectorRepository {
+// 连接在首次使用时建立，Milvus暂时不可用不会阻止服务启动；返回的清理函数关闭连接
+func NewMilvusVectorRepository(config *MilvusConfig, logger infrastructure.Logger) (*MilvusVectorRepository, func()) {
 	if config == nil {
 		config = &MilvusConfig{
 			Host:       "localhost",
@@ -39,184 +97,468 @@ func NewMilvusVectorRepository(config *MilvusConfig, logger infrastructure.Logge
 			MaxRetries: 3,
 		}
 	}
-	
-	return &MilvusVectorRepository{
-		config:   config,
-		logger:   logger,
-		indexMap: make(map[string]*repository.IndexInfo),
+	if config.MetricType == "" {
+		config.MetricType = repository.MetricTypeCosine
+	}
+
+	r := &MilvusVectorRepository{
+		config: config,
+		logger: logger,
+		loaded: make(map[string]repository.MetricType),
+		stats:  make(map[string]*indexQueryStats),
+	}
+
+	cleanup := func() {
+		if err := r.Close(); err != nil {
+			logger.Warn("Failed to close milvus connection", zap.Error(err))
+		}
+	}
+
+	return r, cleanup
+}
+
+// Close 关闭Milvus连接
+func (r *MilvusVectorRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client == nil {
+		return nil
 	}
+	err := r.client.Close()
+	r.client = nil
+	r.loaded = make(map[string]repository.MetricType)
+	return err
+}
+
+// getClient 获取Milvus客户端，未连接时建立连接
+func (r *MilvusVectorRepository) getClient(ctx context.Context) (client.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+
+	c, err := client.NewClient(ctx, client.Config{
+		Address:  r.config.Address(),
+		Username: r.config.Username,
+		Password: r.config.Password,
+		DBName:   r.config.Database,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to milvus at %s: %w", r.config.Address(), err)
+	}
+
+	r.client = c
+	r.logger.Info("Connected to milvus", zap.String("address", r.config.Address()), zap.String("database", r.config.Database))
+	return c, nil
+}
+
+// do 执行Milvus请求，连接失败、超时和限流时按MaxRetries重试
+func (r *MilvusVectorRepository) do(ctx context.Context, operation string, fn func(ctx context.Context, c client.Client) error) error {
+	policy := r.config.RetryPolicy()
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		r.logger.Warn("Milvus request failed, retrying",
+			zap.String("operation", operation),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+	}
+
+	return retry.Do(ctx, policy, func(ctx context.Context) error {
+		c, err := r.getClient(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(ctx, c); err != nil {
+			if isTransientMilvusError(err) {
+				return err
+			}
+			return retry.Permanent(err)
+		}
+		return nil
+	})
+}
+
+// isTransientMilvusError 判断是否为可重试的临时错误
+func isTransientMilvusError(err error) bool {
+	if retry.IsTimeout(err) {
+		return true
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+	return false
 }
 
 // CreateIndex 创建向量索引
+// 创建集合和HNSW索引并加载到内存，集合已存在时沿用已有索引的度量类型
 func (r *MilvusVectorRepository) CreateIndex(ctx context.Context, indexName string, dimension int, metricType repository.MetricType) error {
 	r.logger.Info("Creating vector index",
-		"index_name", indexName,
-		"dimension", dimension,
-		"metric_type", metricType)
-	
-	// TODO: 实现Milvus索引创建逻辑
-	// 1. 连接到Milvus
-	// 2. 检查集合是否存在
-	// 3. 创建集合和字段
-	// 4. 构建索引
-	
-	// 模拟实现
-	indexInfo := &repository.IndexInfo{
-		Name:        indexName,
-		Dimension:   dimension,
-		MetricType:  metricType,
-		VectorCount: 0,
-		IndexSize:   0,
-		CreatedAt:   time.Now().Format(time.RFC3339),
-		UpdatedAt:   time.Now().Format(time.RFC3339),
-	}
-	
-	r.indexMap[indexName] = indexInfo
-	
+		zap.String("index_name", indexName),
+		zap.Int("dimension", dimension),
+		zap.String("metric_type", string(metricType)))
+
+	return r.ensureCollection(ctx, indexName, dimension, metricType)
+}
+
+// ensureCollection 确保集合存在、已建索引并已加载，新建索引使用metricType
+func (r *MilvusVectorRepository) ensureCollection(ctx context.Context, indexName string, dimension int, metricType repository.MetricType) error {
+	name := collectionName(indexName)
+
+	r.mu.Lock()
+	_, loaded := r.loaded[name]
+	r.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	if dimension <= 0 {
+		return fmt.Errorf("invalid vector dimension: %d", dimension)
+	}
+	metric, err := toMilvusMetric(metricType)
+	if err != nil {
+		return err
+	}
+
+	err = r.do(ctx, "create_collection", func(ctx context.Context, c client.Client) error {
+		exists, err := c.HasCollection(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			schema := entity.NewSchema().
+				WithName(name).
+				WithDescription(fmt.Sprintf("noah-loop vector index %s", indexName)).
+				WithField(entity.NewField().WithName(fieldID).WithDataType(entity.FieldTypeVarChar).WithIsPrimaryKey(true).WithMaxLength(maxIDLength)).
+				WithField(entity.NewField().WithName(fieldVector).WithDataType(entity.FieldTypeFloatVector).WithDim(int64(dimension))).
+				WithField(entity.NewField().WithName(fieldMetadata).WithDataType(entity.FieldTypeJSON))
+
+			if err := c.CreateCollection(ctx, schema, entity.DefaultShardNumber); err != nil {
+				// 并发创建时集合可能已由其他请求创建
+				if exists, hasErr := c.HasCollection(ctx, name); hasErr != nil || !exists {
+					return err
+				}
+			}
+		}
+
+		indexes, err := c.DescribeIndex(ctx, name, fieldVector)
+		if err != nil || len(indexes) == 0 {
+			index, err := entity.NewIndexHNSW(metric, hnswM, hnswEfConstruction)
+			if err != nil {
+				return retry.Permanent(err)
+			}
+			if err := c.CreateIndex(ctx, name, fieldVector, index, false); err != nil {
+				return err
+			}
+		} else if existing := indexMetricType(indexes[0]); existing != metricType {
+			r.logger.Warn("Milvus collection already indexed with a different metric type",
+				zap.String("collection", name),
+				zap.String("metric_type", string(existing)),
+				zap.String("requested_metric_type", string(metricType)))
+			metricType = existing
+		}
+
+		return c.LoadCollection(ctx, name, false)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prepare milvus collection %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	r.loaded[name] = metricType
+	r.mu.Unlock()
 	return nil
 }
 
 // DeleteIndex 删除向量索引
 func (r *MilvusVectorRepository) DeleteIndex(ctx context.Context, indexName string) error {
-	r.logger.Info("Deleting vector index", "index_name", indexName)
-	
-	// TODO: 实现Milvus索引删除逻辑
-	// 1. 检查集合是否存在
-	// 2. 删除集合
-	
-	// 模拟实现
-	delete(r.indexMap, indexName)
-	
+	r.logger.Info("Deleting vector index", zap.String("index_name", indexName))
+
+	name := collectionName(indexName)
+	err := r.do(ctx, "drop_collection", func(ctx context.Context, c client.Client) error {
+		exists, err := c.HasCollection(ctx, name)
+		if err != nil || !exists {
+			return err
+		}
+		return c.DropCollection(ctx, name)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop milvus collection %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	delete(r.loaded, name)
+	delete(r.stats, name)
+	r.mu.Unlock()
 	return nil
 }
 
 // ListIndexes 列出所有索引
 func (r *MilvusVectorRepository) ListIndexes(ctx context.Context) ([]repository.IndexInfo, error) {
-	// TODO: 实现Milvus索引列表逻辑
-	
-	// 模拟实现
-	var indexes []repository.IndexInfo
-	for _, info := range r.indexMap {
+	var collections []*entity.Collection
+	err := r.do(ctx, "list_collections", func(ctx context.Context, c client.Client) error {
+		var err error
+		collections, err = c.ListCollections(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milvus collections: %w", err)
+	}
+
+	indexes := make([]repository.IndexInfo, 0, len(collections))
+	for _, collection := range collections {
+		info, err := r.GetIndexInfo(ctx, collection.Name)
+		if err != nil {
+			return nil, err
+		}
 		indexes = append(indexes, *info)
 	}
-	
+
 	return indexes, nil
 }
 
 // GetIndexInfo 获取索引信息
 func (r *MilvusVectorRepository) GetIndexInfo(ctx context.Context, indexName string) (*repository.IndexInfo, error) {
-	// TODO: 实现Milvus索引信息获取逻辑
-	
-	// 模拟实现
-	if info, exists := r.indexMap[indexName]; exists {
-		return info, nil
+	name := collectionName(indexName)
+	info := &repository.IndexInfo{Name: indexName}
+
+	err := r.do(ctx, "describe_collection", func(ctx context.Context, c client.Client) error {
+		exists, err := c.HasCollection(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return retry.Permanent(fmt.Errorf("index %s not found", indexName))
+		}
+
+		collection, err := c.DescribeCollection(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, field := range collection.Schema.Fields {
+			if field.Name == fieldVector {
+				info.Dimension, _ = strconv.Atoi(field.TypeParams[entity.TypeParamDim])
+			}
+		}
+
+		indexes, err := c.DescribeIndex(ctx, name, fieldVector)
+		if err == nil && len(indexes) > 0 {
+			info.MetricType = indexMetricType(indexes[0])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := r.GetVectorCount(ctx, indexName)
+	if err != nil {
+		return nil, err
 	}
-	
-	return nil, fmt.Errorf("index %s not found", indexName)
+	info.VectorCount = count
+
+	return info, nil
 }
 
 // Insert 插入向量
+// Milvus插入不校验主键唯一，为保证超时重试不产生重复向量，按主键写入（Upsert）
 func (r *MilvusVectorRepository) Insert(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
 	r.logger.Info("Inserting vectors",
-		"index_name", indexName,
-		"count", len(vectors))
-	
-	// TODO: 实现Milvus向量插入逻辑
-	// 1. 检查集合是否存在
-	// 2. 准备数据
-	// 3. 插入数据
-	// 4. 刷新集合
-	
-	// 模拟实现
-	if info, exists := r.indexMap[indexName]; exists {
-		info.VectorCount += int64(len(vectors))
-		info.UpdatedAt = time.Now().Format(time.RFC3339)
-	}
-	
-	return nil
+		zap.String("index_name", indexName),
+		zap.Int("count", len(vectors)))
+
+	return r.write(ctx, indexName, vectors)
 }
 
 // Update 更新向量
+// Milvus 不支持直接更新，按Upsert语义替换同ID的向量
 func (r *MilvusVectorRepository) Update(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
 	r.logger.Info("Updating vectors",
-		"index_name", indexName,
-		"count", len(vectors))
-	
-	// TODO: 实现Milvus向量更新逻辑
-	// Milvus 通常不支持直接更新，需要先删除再插入
-	
+		zap.String("index_name", indexName),
+		zap.Int("count", len(vectors)))
+
+	return r.Upsert(ctx, indexName, vectors)
+}
+
+// Upsert 插入或替换向量
+// 使用Milvus原生Upsert，同ID的旧向量在同一次请求中被替换，重复处理同一分块时不会留下过期的重复向量
+func (r *MilvusVectorRepository) Upsert(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
+	r.logger.Info("Upserting vectors",
+		zap.String("index_name", indexName),
+		zap.Int("count", len(vectors)))
+
+	return r.write(ctx, indexName, vectors)
+}
+
+// write 校验并按主键写入向量，集合不存在时按向量维度以余弦相似度创建
+func (r *MilvusVectorRepository) write(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	dimension := len(vectors[0].Vector)
+	ids := make([]string, 0, len(vectors))
+	embeddings := make([][]float32, 0, len(vectors))
+	metadata := make([][]byte, 0, len(vectors))
+	seen := make(map[string]struct{}, len(vectors))
+	for _, vector := range vectors {
+		if vector.ID == "" {
+			return fmt.Errorf("vector ID cannot be empty")
+		}
+		if len(vector.ID) > maxIDLength {
+			return fmt.Errorf("vector ID exceeds %d characters: %s", maxIDLength, vector.ID)
+		}
+		if _, exists := seen[vector.ID]; exists {
+			return fmt.Errorf("duplicate vector ID in upsert batch: %s", vector.ID)
+		}
+		if len(vector.Vector) != dimension {
+			return fmt.Errorf("vector dimensions mismatch in batch: %d vs %d", len(vector.Vector), dimension)
+		}
+		seen[vector.ID] = struct{}{}
+
+		encoded, err := json.Marshal(nonNilMetadata(vector.Metadata))
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of vector %s: %w", vector.ID, err)
+		}
+
+		ids = append(ids, vector.ID)
+		embeddings = append(embeddings, vector.Vector)
+		metadata = append(metadata, encoded)
+	}
+
+	if err := r.ensureCollection(ctx, indexName, dimension, r.config.MetricType); err != nil {
+		return err
+	}
+
+	name := collectionName(indexName)
+	err := r.do(ctx, "upsert", func(ctx context.Context, c client.Client) error {
+		_, err := c.Upsert(ctx, name, "",
+			entity.NewColumnVarChar(fieldID, ids),
+			entity.NewColumnFloatVector(fieldVector, dimension, embeddings),
+			entity.NewColumnJSONBytes(fieldMetadata, metadata),
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write vectors to milvus collection %s: %w", name, err)
+	}
+
 	return nil
 }
 
 // Delete 删除向量
 func (r *MilvusVectorRepository) Delete(ctx context.Context, indexName string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
 	r.logger.Info("Deleting vectors",
-		"index_name", indexName,
-		"count", len(ids))
-	
-	// TODO: 实现Milvus向量删除逻辑
-	// 1. 检查集合是否存在
-	// 2. 删除指定ID的数据
-	
-	// 模拟实现
-	if info, exists := r.indexMap[indexName]; exists {
-		info.VectorCount -= int64(len(ids))
-		if info.VectorCount < 0 {
-			info.VectorCount = 0
-		}
-		info.UpdatedAt = time.Now().Format(time.RFC3339)
-	}
-	
+		zap.String("index_name", indexName),
+		zap.Int("count", len(ids)))
+
+	name := collectionName(indexName)
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = strconv.Quote(id)
+	}
+	expr := fmt.Sprintf("%s in [%s]", fieldID, strings.Join(quoted, ","))
+
+	err := r.do(ctx, "delete", func(ctx context.Context, c client.Client) error {
+		exists, err := c.HasCollection(ctx, name)
+		if err != nil || !exists {
+			// 集合不存在时没有需要删除的向量
+			return err
+		}
+		return c.Delete(ctx, name, "", expr)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete vectors from milvus collection %s: %w", name, err)
+	}
+
 	return nil
 }
 
 // Search 搜索相似向量
+// 按集合索引的度量类型搜索，query.MetricType只在无法获取索引度量类型时使用；
+// 余弦相似度和点积的分数越大越相似，返回分数不低于ScoreThreshold的结果；
+// 欧氏距离的分数为距离，越小越相似，ScoreThreshold大于0时作为最大距离
 func (r *MilvusVectorRepository) Search(ctx context.Context, query *repository.VectorQuery) (*repository.VectorSearchResult, error) {
 	start := time.Now()
-	
+
 	r.logger.Info("Searching vectors",
-		"index_name", query.IndexName,
-		"top_k", query.TopK,
-		"metric_type", query.MetricType)
-	
-	// TODO: 实现Milvus向量搜索逻辑
-	// 1. 检查集合是否存在且已加载
-	// 2. 构建搜索参数
-	// 3. 执行搜索
-	// 4. 处理结果
-	
-	// 模拟实现
+		zap.String("index_name", query.IndexName),
+		zap.Int("top_k", query.TopK),
+		zap.String("metric_type", string(query.MetricType)))
+
+	if query.TopK <= 0 {
+		return nil, fmt.Errorf("top_k must be positive: %d", query.TopK)
+	}
+	if len(query.QueryVector) == 0 {
+		return nil, fmt.Errorf("query vector cannot be empty")
+	}
+
+	name := collectionName(query.IndexName)
+	metricType, err := r.ensureLoaded(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if metricType == "" {
+		metricType = query.MetricType
+	}
+	if metricType == "" {
+		metricType = repository.MetricTypeCosine
+	}
+	metric, err := toMilvusMetric(metricType)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := buildFilterExpr(query)
+	if err != nil {
+		return nil, err
+	}
+
+	searchParam, err := entity.NewIndexHNSWSearchParam(maxInt(query.TopK, hnswMinSearchEf))
+	if err != nil {
+		return nil, err
+	}
+
+	var outputFields []string
+	if query.IncludeMetadata {
+		outputFields = append(outputFields, fieldMetadata)
+	}
+	if query.IncludeVector {
+		outputFields = append(outputFields, fieldVector)
+	}
+
+	var searchResults []client.SearchResult
+	err = r.do(ctx, "search", func(ctx context.Context, c client.Client) error {
+		var err error
+		searchResults, err = c.Search(ctx, name, nil, expr, outputFields,
+			[]entity.Vector{entity.FloatVector(query.QueryVector)}, fieldVector, metric, query.TopK, searchParam)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search milvus collection %s: %w", name, err)
+	}
+
 	var results []repository.VectorSearchMatch
-	
-	// 生成一些模拟结果
-	for i := 0; i < query.TopK && i < 5; i++ {
-		score := 0.9 - float32(i)*0.1 // 模拟递减的相似度分数
-		if score >= query.ScoreThreshold {
-			match := repository.VectorSearchMatch{
-				ID:    fmt.Sprintf("chunk_%d", i+1),
-				Score: score,
-			}
-			
-			if query.IncludeVector {
-				// 生成模拟向量
-				match.Vector = make([]float32, len(query.QueryVector))
-				copy(match.Vector, query.QueryVector)
-			}
-			
-			if query.IncludeMetadata {
-				match.Metadata = map[string]string{
-					"document_id": fmt.Sprintf("doc_%d", i+1),
-					"chunk_type":  "text",
-					"position":    fmt.Sprintf("%d", i),
-				}
-			}
-			
-			results = append(results, match)
+	if len(searchResults) > 0 {
+		results, err = toSearchMatches(searchResults[0], query, metricType)
+		if err != nil {
+			return nil, err
 		}
 	}
-	
+
 	duration := time.Since(start)
-	
+	r.recordQuery(name, duration)
+
 	return &repository.VectorSearchResult{
 		Query:    query,
 		Results:  results,
@@ -225,14 +567,103 @@ func (r *MilvusVectorRepository) Search(ctx context.Context, query *repository.V
 	}, nil
 }
 
+// ensureLoaded 确保集合已加载到内存，Milvus只能搜索已加载的集合
+// 返回集合向量索引的度量类型，集合没有索引时为空
+func (r *MilvusVectorRepository) ensureLoaded(ctx context.Context, name string) (repository.MetricType, error) {
+	r.mu.Lock()
+	metricType, loaded := r.loaded[name]
+	r.mu.Unlock()
+	if loaded {
+		return metricType, nil
+	}
+
+	err := r.do(ctx, "load_collection", func(ctx context.Context, c client.Client) error {
+		exists, err := c.HasCollection(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return retry.Permanent(fmt.Errorf("index %s not found", name))
+		}
+		if indexes, err := c.DescribeIndex(ctx, name, fieldVector); err == nil && len(indexes) > 0 {
+			metricType = indexMetricType(indexes[0])
+		}
+		return c.LoadCollection(ctx, name, false)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.loaded[name] = metricType
+	r.mu.Unlock()
+	return metricType, nil
+}
+
+// toSearchMatches 转换Milvus搜索结果并按分数阈值过滤
+func toSearchMatches(result client.SearchResult, query *repository.VectorQuery, metricType repository.MetricType) ([]repository.VectorSearchMatch, error) {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	var metadataColumn *entity.ColumnJSONBytes
+	var vectorColumn *entity.ColumnFloatVector
+	if column, ok := result.Fields.GetColumn(fieldMetadata).(*entity.ColumnJSONBytes); ok {
+		metadataColumn = column
+	}
+	if column, ok := result.Fields.GetColumn(fieldVector).(*entity.ColumnFloatVector); ok {
+		vectorColumn = column
+	}
+
+	matches := make([]repository.VectorSearchMatch, 0, result.ResultCount)
+	for i := 0; i < result.ResultCount; i++ {
+		score := result.Scores[i]
+		if !passesThreshold(metricType, score, query.ScoreThreshold) {
+			continue
+		}
+
+		id, err := result.IDs.GetAsString(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vector ID: %w", err)
+		}
+		match := repository.VectorSearchMatch{
+			ID:    id,
+			Score: score,
+		}
+
+		if query.IncludeMetadata && metadataColumn != nil {
+			raw, err := metadataColumn.ValueByIdx(i)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read metadata of vector %s: %w", id, err)
+			}
+			if err := json.Unmarshal(raw, &match.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode metadata of vector %s: %w", id, err)
+			}
+		}
+		if query.IncludeVector && vectorColumn != nil && i < len(vectorColumn.Data()) {
+			match.Vector = vectorColumn.Data()[i]
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+// passesThreshold 判断分数是否满足阈值
+func passesThreshold(metricType repository.MetricType, score, threshold float32) bool {
+	if metricType == repository.MetricTypeEuclidean {
+		return threshold <= 0 || score <= threshold
+	}
+	return score >= threshold
+}
+
 // SearchBatch 批量搜索向量
 func (r *MilvusVectorRepository) SearchBatch(ctx context.Context, queries []*repository.VectorQuery) ([]*repository.VectorSearchResult, error) {
-	r.logger.Info("Batch searching vectors", "count", len(queries))
-	
-	// TODO: 实现Milvus批量搜索逻辑
-	
-	// 模拟实现：顺序执行每个查询
-	var results []*repository.VectorSearchResult
+	r.logger.Info("Batch searching vectors", zap.Int("count", len(queries)))
+
+	// 各查询的集合、过滤条件和参数可能不同，逐个执行
+	results := make([]*repository.VectorSearchResult, 0, len(queries))
 	for _, query := range queries {
 		result, err := r.Search(ctx, query)
 		if err != nil {
@@ -240,7 +671,7 @@ func (r *MilvusVectorRepository) SearchBatch(ctx context.Context, queries []*rep
 		}
 		results = append(results, result)
 	}
-	
+
 	return results, nil
 }
 
@@ -249,7 +680,7 @@ func (r *MilvusVectorRepository) ComputeSimilarity(ctx context.Context, vector1,
 	if len(vector1) != len(vector2) {
 		return 0, fmt.Errorf("vector dimensions mismatch: %d vs %d", len(vector1), len(vector2))
 	}
-	
+
 	switch metricType {
 	case repository.MetricTypeCosine:
 		return computeCosineSimilarity(vector1, vector2), nil
@@ -265,7 +696,7 @@ func (r *MilvusVectorRepository) ComputeSimilarity(ctx context.Context, vector1,
 // ComputeSimilarityBatch 批量计算相似度
 func (r *MilvusVectorRepository) ComputeSimilarityBatch(ctx context.Context, queryVector []float32, vectors [][]float32, metricType repository.MetricType) ([]float32, error) {
 	similarities := make([]float32, len(vectors))
-	
+
 	for i, vector := range vectors {
 		similarity, err := r.ComputeSimilarity(ctx, queryVector, vector, metricType)
 		if err != nil {
@@ -273,65 +704,255 @@ func (r *MilvusVectorRepository) ComputeSimilarityBatch(ctx context.Context, que
 		}
 		similarities[i] = similarity
 	}
-	
+
 	return similarities, nil
 }
 
 // GetVectorCount 获取向量数量
+// 使用强一致性计数，刚写入或删除的向量会立即反映在结果中
 func (r *MilvusVectorRepository) GetVectorCount(ctx context.Context, indexName string) (int64, error) {
-	// TODO: 实现Milvus向量计数逻辑
-	
-	// 模拟实现
-	if info, exists := r.indexMap[indexName]; exists {
-		return info.VectorCount, nil
+	name := collectionName(indexName)
+	if _, err := r.ensureLoaded(ctx, name); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err := r.do(ctx, "count", func(ctx context.Context, c client.Client) error {
+		resultSet, err := c.Query(ctx, name, nil, "", []string{"count(*)"},
+			client.WithSearchQueryConsistencyLevel(entity.ClStrong))
+		if err != nil {
+			return err
+		}
+
+		column, ok := resultSet.GetColumn("count(*)").(*entity.ColumnInt64)
+		if !ok || column.Len() == 0 {
+			return retry.Permanent(fmt.Errorf("unexpected count result from milvus"))
+		}
+		count, err = column.ValueByIdx(0)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count vectors in milvus collection %s: %w", name, err)
 	}
-	
-	return 0, fmt.Errorf("index %s not found", indexName)
+
+	return count, nil
 }
 
 // GetIndexStats 获取索引统计信息
+// 查询次数和延迟为本实例的统计
 func (r *MilvusVectorRepository) GetIndexStats(ctx context.Context, indexName string) (*repository.IndexStats, error) {
-	// TODO: 实现Milvus索引统计逻辑
-	
-	// 模拟实现
-	if info, exists := r.indexMap[indexName]; exists {
-		return &repository.IndexStats{
-			VectorCount:    info.VectorCount,
-			IndexSize:      info.IndexSize,
-			MemoryUsage:    info.IndexSize,
-			QueryCount:     0,
-			AverageLatency: 50.0,
-			LastQueryAt:    time.Now().Format(time.RFC3339),
-		}, nil
-	}
-	
-	return nil, fmt.Errorf("index %s not found", indexName)
+	count, err := r.GetVectorCount(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &repository.IndexStats{VectorCount: count}
+
+	r.mu.Lock()
+	if queryStats, exists := r.stats[collectionName(indexName)]; exists && queryStats.queryCount > 0 {
+		stats.QueryCount = queryStats.queryCount
+		stats.AverageLatency = float64(queryStats.totalLatency.Milliseconds()) / float64(queryStats.queryCount)
+		stats.LastQueryAt = queryStats.lastQueryTime.Format(time.RFC3339)
+	}
+	r.mu.Unlock()
+
+	return stats, nil
+}
+
+// recordQuery 记录一次查询
+func (r *MilvusVectorRepository) recordQuery(name string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queryStats, exists := r.stats[name]
+	if !exists {
+		queryStats = &indexQueryStats{}
+		r.stats[name] = queryStats
+	}
+	queryStats.queryCount++
+	queryStats.totalLatency += latency
+	queryStats.lastQueryTime = time.Now()
+}
+
+// Warmup 将已有集合加载到内存，避免就绪后的首次搜索等待加载
+func (r *MilvusVectorRepository) Warmup(ctx context.Context) error {
+	var collections []*entity.Collection
+	err := r.do(ctx, "list_collections", func(ctx context.Context, c client.Client) error {
+		var err error
+		collections, err = c.ListCollections(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list milvus collections: %w", err)
+	}
+
+	for _, collection := range collections {
+		if _, err := r.ensureLoaded(ctx, collection.Name); err != nil {
+			return fmt.Errorf("failed to load milvus collection %s: %w", collection.Name, err)
+		}
+	}
+
+	r.logger.Info("Milvus collections loaded", zap.Int("count", len(collections)))
+	return nil
 }
 
 // Health 健康检查
 func (r *MilvusVectorRepository) Health(ctx context.Context) error {
-	// TODO: 实现Milvus健康检查逻辑
-	// 1. 连接检查
-	// 2. 版本检查
-	// 3. 状态检查
-	
-	return nil
+	return r.do(ctx, "health", func(ctx context.Context, c client.Client) error {
+		state, err := c.CheckHealth(ctx)
+		if err != nil {
+			return err
+		}
+		if !state.IsHealthy {
+			return fmt.Errorf("milvus is unhealthy: %s", strings.Join(state.Reasons, "; "))
+		}
+		return nil
+	})
+}
+
+// collectionName 将索引名转换为合法的Milvus集合名：只能包含字母、数字和下划线，且不能以数字开头
+func collectionName(indexName string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, indexName)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// buildFilterExpr 将查询的元数据过滤转换为Milvus布尔表达式，各条件之间为AND
+func buildFilterExpr(query *repository.VectorQuery) (string, error) {
+	keys := make([]string, 0, len(query.Filter))
+	for key := range query.Filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]string, 0, len(keys)+len(query.Conditions))
+	for _, key := range keys {
+		conditions = append(conditions, fmt.Sprintf("%s == %s", metadataPath(key), strconv.Quote(query.Filter[key])))
+	}
+	for _, condition := range query.Conditions {
+		expr, err := buildConditionExpr(condition)
+		if err != nil {
+			return "", err
+		}
+		if expr != "" {
+			conditions = append(conditions, expr)
+		}
+	}
+	return strings.Join(conditions, " && "), nil
+}
+
+// buildConditionExpr 转换单个过滤条件
+func buildConditionExpr(condition repository.FilterCondition) (string, error) {
+	if len(condition.Values) == 0 {
+		return "", nil
+	}
+
+	switch condition.Operator {
+	case repository.FilterOpIn:
+		values := make([]string, len(condition.Values))
+		for i, value := range condition.Values {
+			values[i] = strconv.Quote(value)
+		}
+		return fmt.Sprintf("%s in [%s]", metadataPath(condition.Field), strings.Join(values, ", ")), nil
+	case repository.FilterOpNotIn:
+		values := make([]string, len(condition.Values))
+		for i, value := range condition.Values {
+			values[i] = strconv.Quote(value)
+		}
+		return fmt.Sprintf("not (%s in [%s])", metadataPath(condition.Field), strings.Join(values, ", ")), nil
+	case repository.FilterOpGte:
+		return fmt.Sprintf("%s >= %s", metadataPath(condition.Field), strconv.Quote(condition.Values[0])), nil
+	case repository.FilterOpLte:
+		return fmt.Sprintf("%s <= %s", metadataPath(condition.Field), strconv.Quote(condition.Values[0])), nil
+	case repository.FilterOpHasAny:
+		alternatives := make([]string, len(condition.Values))
+		for i, value := range condition.Values {
+			alternatives[i] = "exists " + metadataPath(repository.PrefixedKey(condition.Field, value))
+		}
+		return "(" + strings.Join(alternatives, " || ") + ")", nil
+	default:
+		// 未知操作符不能静默忽略，否则会放宽过滤
+		return "", fmt.Errorf("unsupported filter operator: %s", condition.Operator)
+	}
+}
+
+// metadataPath 元数据JSON字段中的键
+func metadataPath(key string) string {
+	return fmt.Sprintf("%s[%s]", fieldMetadata, strconv.Quote(key))
+}
+
+// toMilvusMetric 转换距离度量类型
+func toMilvusMetric(metricType repository.MetricType) (entity.MetricType, error) {
+	switch metricType {
+	case repository.MetricTypeCosine:
+		return entity.COSINE, nil
+	case repository.MetricTypeEuclidean:
+		return entity.L2, nil
+	case repository.MetricTypeDotProduct:
+		return entity.IP, nil
+	default:
+		// 汉明距离只适用于二进制向量
+		return "", fmt.Errorf("unsupported metric type for float vectors: %s", metricType)
+	}
+}
+
+// fromMilvusMetric 将Milvus距离度量转换为仓储度量类型
+func fromMilvusMetric(metric entity.MetricType) repository.MetricType {
+	switch entity.MetricType(strings.ToUpper(string(metric))) {
+	case entity.L2:
+		return repository.MetricTypeEuclidean
+	case entity.IP:
+		return repository.MetricTypeDotProduct
+	case entity.HAMMING:
+		return repository.MetricTypeHamming
+	default:
+		return repository.MetricTypeCosine
+	}
+}
+
+// indexMetricType 向量索引的度量类型
+func indexMetricType(index entity.Index) repository.MetricType {
+	return fromMilvusMetric(entity.MetricType(index.Params()["metric_type"]))
+}
+
+// nonNilMetadata 空元数据写入为空对象，便于按键过滤
+func nonNilMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // 辅助函数：计算余弦相似度
 func computeCosineSimilarity(vector1, vector2 []float32) float32 {
 	var dotProduct, norm1, norm2 float32
-	
+
 	for i := 0; i < len(vector1); i++ {
 		dotProduct += vector1[i] * vector2[i]
 		norm1 += vector1[i] * vector1[i]
 		norm2 += vector2[i] * vector2[i]
 	}
-	
+
 	if norm1 == 0 || norm2 == 0 {
 		return 0
 	}
-	
+
 	// 计算余弦相似度
 	similarity := dotProduct / (sqrt(norm1) * sqrt(norm2))
 	return similarity
@@ -340,23 +961,23 @@ func computeCosineSimilarity(vector1, vector2 []float32) float32 {
 // 辅助函数：计算欧氏距离
 func computeEuclideanDistance(vector1, vector2 []float32) float32 {
 	var sum float32
-	
+
 	for i := 0; i < len(vector1); i++ {
 		diff := vector1[i] - vector2[i]
 		sum += diff * diff
 	}
-	
+
 	return sqrt(sum)
 }
 
 // 辅助函数：计算点积
 func computeDotProduct(vector1, vector2 []float32) float32 {
 	var dotProduct float32
-	
+
 	for i := 0; i < len(vector1); i++ {
 		dotProduct += vector1[i] * vector2[i]
 	}
-	
+
 	return dotProduct
 }
 
@@ -366,7 +987,7 @@ func sqrt(x float32) float32 {
 	if x == 0 {
 		return 0
 	}
-	
+
 	z := x
 	for i := 0; i < 10; i++ {
 		z = (z + x/z) / 2
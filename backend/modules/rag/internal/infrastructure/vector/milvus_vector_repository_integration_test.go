@@ -0,0 +1,112 @@
+//go:build integration
+
+package vector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+)
+
+// newIntegrationMilvusRepository 连接MILVUS_HOST指定的Milvus，未设置时跳过测试
+func newIntegrationMilvusRepository(t *testing.T, metricType repository.MetricType) *MilvusVectorRepository {
+	t.Helper()
+
+	host := os.Getenv("MILVUS_HOST")
+	if host == "" {
+		t.Skip("MILVUS_HOST is not set")
+	}
+	port, err := strconv.Atoi(os.Getenv("MILVUS_PORT"))
+	if err != nil || port <= 0 {
+		port = 19530
+	}
+
+	logger, err := infrastructure.NewZapLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, cleanup := NewMilvusVectorRepository(&MilvusConfig{
+		Host:       host,
+		Port:       port,
+		Database:   "default",
+		Timeout:    30,
+		MaxRetries: 1,
+		MetricType: metricType,
+	}, logger)
+	t.Cleanup(cleanup)
+	return repo
+}
+
+func TestMilvusCollectionUsesConfiguredMetric(t *testing.T) {
+	for _, metricType := range []repository.MetricType{
+		repository.MetricTypeCosine,
+		repository.MetricTypeEuclidean,
+		repository.MetricTypeDotProduct,
+	} {
+		t.Run(string(metricType), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			repo := newIntegrationMilvusRepository(t, metricType)
+			indexName := fmt.Sprintf("it_metric_%s_%d", metricType, time.Now().UnixNano())
+			t.Cleanup(func() { _ = repo.DeleteIndex(context.Background(), indexName) })
+
+			vectors := []repository.VectorRecord{
+				{ID: "near", Vector: []float32{1, 0, 0, 0}},
+				{ID: "far", Vector: []float32{0, 0, 0, 1}},
+			}
+			if err := repo.Upsert(ctx, indexName, vectors); err != nil {
+				t.Fatalf("upsert: %v", err)
+			}
+
+			info, err := repo.GetIndexInfo(ctx, indexName)
+			if err != nil {
+				t.Fatalf("get index info: %v", err)
+			}
+			if info.MetricType != metricType {
+				t.Fatalf("collection metric type = %s, want %s", info.MetricType, metricType)
+			}
+
+			// 查询的度量类型与集合不一致时仍按集合索引的度量类型搜索
+			query := repository.NewVectorQuery(indexName, []float32{0.9, 0.1, 0, 0}, 2)
+			result, err := repo.Search(ctx, query)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(result.Results) == 0 || result.Results[0].ID != "near" {
+				t.Fatalf("unexpected search results: %+v", result.Results)
+			}
+		})
+	}
+}
+
+func TestMilvusExistingCollectionKeepsItsMetric(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	indexName := fmt.Sprintf("it_existing_%d", time.Now().UnixNano())
+	creator := newIntegrationMilvusRepository(t, repository.MetricTypeEuclidean)
+	t.Cleanup(func() { _ = creator.DeleteIndex(context.Background(), indexName) })
+	if err := creator.CreateIndex(ctx, indexName, 4, repository.MetricTypeEuclidean); err != nil {
+		t.Fatalf("create index: %v", err)
+	}
+
+	repo := newIntegrationMilvusRepository(t, repository.MetricTypeCosine)
+	if err := repo.Upsert(ctx, indexName, []repository.VectorRecord{{ID: "a", Vector: []float32{1, 2, 3, 4}}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	result, err := repo.Search(ctx, repository.NewVectorQuery(indexName, []float32{1, 2, 3, 4}, 1))
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Results) != 1 || result.Results[0].Score > 1e-6 {
+		t.Fatalf("expected euclidean distance 0, got %+v", result.Results)
+	}
+}
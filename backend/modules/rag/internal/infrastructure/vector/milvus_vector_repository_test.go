@@ -0,0 +1,93 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+)
+
+func TestMilvusMetricRoundTrip(t *testing.T) {
+	for _, metricType := range []repository.MetricType{
+		repository.MetricTypeCosine,
+		repository.MetricTypeEuclidean,
+		repository.MetricTypeDotProduct,
+	} {
+		metric, err := toMilvusMetric(metricType)
+		if err != nil {
+			t.Fatalf("%s: %v", metricType, err)
+		}
+		if got := fromMilvusMetric(metric); got != metricType {
+			t.Fatalf("%s: round trip = %s", metricType, got)
+		}
+	}
+
+	if _, err := toMilvusMetric(repository.MetricTypeHamming); err == nil {
+		t.Fatal("hamming should be rejected for float vectors")
+	}
+	if got := fromMilvusMetric("l2"); got != repository.MetricTypeEuclidean {
+		t.Fatalf("lowercase metric = %s", got)
+	}
+}
+
+func TestIndexMetricType(t *testing.T) {
+	for metric, want := range map[entity.MetricType]repository.MetricType{
+		entity.COSINE: repository.MetricTypeCosine,
+		entity.L2:     repository.MetricTypeEuclidean,
+		entity.IP:     repository.MetricTypeDotProduct,
+	} {
+		index, err := entity.NewIndexHNSW(metric, hnswM, hnswEfConstruction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := indexMetricType(index); got != want {
+			t.Fatalf("%s: index metric = %s, want %s", metric, got, want)
+		}
+	}
+}
+
+func TestPassesThreshold(t *testing.T) {
+	// 欧氏距离越小越相似
+	if !passesThreshold(repository.MetricTypeEuclidean, 0.5, 1) || passesThreshold(repository.MetricTypeEuclidean, 2, 1) {
+		t.Fatal("euclidean threshold is an upper bound")
+	}
+	if !passesThreshold(repository.MetricTypeEuclidean, 2, 0) {
+		t.Fatal("zero euclidean threshold should not filter")
+	}
+	if !passesThreshold(repository.MetricTypeCosine, 0.8, 0.7) || passesThreshold(repository.MetricTypeCosine, 0.6, 0.7) {
+		t.Fatal("cosine threshold is a lower bound")
+	}
+}
+
+func TestBuildFilterExpr(t *testing.T) {
+	query := repository.NewVectorQuery("kb", []float32{1}, 10).
+		WithFilter("b", "2").
+		WithFilter("a", `say "hi"`).
+		WithFilterIn(repository.MetadataDocumentType, "pdf", "markdown").
+		WithFilterNotIn(repository.MetadataChunkType, "summary").
+		WithCondition(repository.MetadataCreatedAt, repository.FilterOpGte, "2024-01-01T00:00:00Z").
+		WithCondition(repository.MetadataTag, repository.FilterOpHasAny, "api", "guide")
+
+	expr, err := buildFilterExpr(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `metadata["a"] == "say \"hi\"" && metadata["b"] == "2" && ` +
+		`metadata["document_type"] in ["pdf", "markdown"] && ` +
+		`not (metadata["chunk_type"] in ["summary"]) && ` +
+		`metadata["created_at"] >= "2024-01-01T00:00:00Z" && ` +
+		`(exists metadata["tag:api"] || exists metadata["tag:guide"])`
+	if expr != want {
+		t.Fatalf("expr = %s\nwant   %s", expr, want)
+	}
+
+	empty, err := buildFilterExpr(repository.NewVectorQuery("kb", []float32{1}, 10))
+	if err != nil || empty != "" {
+		t.Fatalf("empty expr = %q, %v", empty, err)
+	}
+
+	unknown := repository.NewVectorQuery("kb", []float32{1}, 10).WithCondition("x", repository.FilterOperator("like"), "a")
+	if _, err := buildFilterExpr(unknown); err == nil {
+		t.Fatal("unknown operator should be rejected")
+	}
+}
@@ -0,0 +1,829 @@
+package vector
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// pgvector表
+const (
+	pgVectorTable      = "vectors"
+	pgVectorIndexTable = "vector_indexes"
+)
+
+// pgvector索引类型
+const (
+	PgVectorIndexHNSW    = "hnsw"
+	PgVectorIndexIVFFlat = "ivfflat"
+)
+
+// PgVectorConfig pgvector配置
+type PgVectorConfig struct {
+	IndexType          string `json:"index_type"`           // hnsw 或 ivfflat
+	HNSWM              int    `json:"hnsw_m"`               // HNSW每个节点的最大连接数
+	HNSWEfConstruction int    `json:"hnsw_ef_construction"` // HNSW构建时的候选列表大小
+	HNSWEfSearch       int    `json:"hnsw_ef_search"`       // HNSW搜索时的最小候选列表大小
+	IVFFlatLists       int    `json:"ivfflat_lists"`        // IVFFlat聚类数
+	IVFFlatProbes      int    `json:"ivfflat_probes"`       // IVFFlat搜索时探查的聚类数
+}
+
+// DefaultPgVectorConfig 默认配置：HNSW索引，参数与pgvector默认值一致
+func DefaultPgVectorConfig() *PgVectorConfig {
+	return &PgVectorConfig{
+		IndexType:          PgVectorIndexHNSW,
+		HNSWM:              16,
+		HNSWEfConstruction: 64,
+		HNSWEfSearch:       40,
+		IVFFlatLists:       100,
+		IVFFlatProbes:      10,
+	}
+}
+
+// pgVectorIndex 索引登记信息
+type pgVectorIndex struct {
+	Name       string    `gorm:"column:name"`
+	Dimension  int       `gorm:"column:dimension"`
+	MetricType string    `gorm:"column:metric_type"`
+	IndexType  string    `gorm:"column:index_type"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+// PgVectorRepository 基于Postgres pgvector扩展的向量仓储实现
+// 所有索引的向量存储在同一张vectors表中，以index_name区分；每个索引创建一个按维度转换的部分表达式索引（HNSW或IVFFlat），
+// 搜索时使用与索引相同的表达式和过滤条件，使查询能命中对应索引；元数据存储为JSONB并建立GIN索引用于过滤
+type PgVectorRepository struct {
+	db     *gorm.DB
+	config *PgVectorConfig
+	logger infrastructure.Logger
+
+	mu          sync.Mutex
+	schemaReady bool
+	indexes     map[string]*pgVectorIndex   // 索引名 -> 登记信息
+	stats       map[string]*indexQueryStats // 索引名 -> 查询统计
+}
+
+// NewPgVectorRepository 创建pgvector向量仓储
+// 扩展和表在首次使用时创建，数据库用户需要有CREATE EXTENSION权限，或由管理员预先安装vector扩展
+func NewPgVectorRepository(db *gorm.DB, config *PgVectorConfig, logger infrastructure.Logger) *PgVectorRepository {
+	if config == nil {
+		config = DefaultPgVectorConfig()
+	}
+
+	return &PgVectorRepository{
+		db:      db,
+		config:  config,
+		logger:  logger,
+		indexes: make(map[string]*pgVectorIndex),
+		stats:   make(map[string]*indexQueryStats),
+	}
+}
+
+// ensureSchema 确保vector扩展和表已创建
+func (r *PgVectorRepository) ensureSchema(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.schemaReady {
+		return nil
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		`CREATE TABLE IF NOT EXISTS ` + pgVectorIndexTable + ` (
+			name        TEXT PRIMARY KEY,
+			dimension   INTEGER NOT NULL,
+			metric_type TEXT NOT NULL,
+			index_type  TEXT NOT NULL,
+			created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + pgVectorTable + ` (
+			index_name TEXT NOT NULL,
+			id         TEXT NOT NULL,
+			embedding  vector NOT NULL,
+			metadata   JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (index_name, id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS vectors_metadata_idx ON ` + pgVectorTable + ` USING gin (metadata jsonb_path_ops)`,
+	}
+	for _, statement := range statements {
+		if err := r.db.WithContext(ctx).Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to prepare pgvector schema: %w", err)
+		}
+	}
+
+	r.schemaReady = true
+	return nil
+}
+
+// CreateIndex 创建向量索引
+// 登记索引并创建对应度量的HNSW或IVFFlat索引，索引已存在时直接返回；
+// IVFFlat索引的聚类基于创建时的数据，建议在写入数据后再创建
+func (r *PgVectorRepository) CreateIndex(ctx context.Context, indexName string, dimension int, metricType repository.MetricType) error {
+	r.logger.Info("Creating vector index",
+		zap.String("index_name", indexName),
+		zap.Int("dimension", dimension),
+		zap.String("metric_type", string(metricType)))
+
+	_, err := r.ensureIndex(ctx, indexName, dimension, metricType)
+	return err
+}
+
+// ensureIndex 确保索引已登记并已创建，返回登记信息
+func (r *PgVectorRepository) ensureIndex(ctx context.Context, indexName string, dimension int, metricType repository.MetricType) (*pgVectorIndex, error) {
+	if index, err := r.getIndex(ctx, indexName); err != nil || index != nil {
+		return index, err
+	}
+
+	if dimension <= 0 {
+		return nil, fmt.Errorf("invalid vector dimension: %d", dimension)
+	}
+	opClass, err := pgVectorOpClass(metricType)
+	if err != nil {
+		return nil, err
+	}
+	indexType := r.config.IndexType
+	if indexType != PgVectorIndexIVFFlat {
+		indexType = PgVectorIndexHNSW
+	}
+
+	var params string
+	if indexType == PgVectorIndexHNSW {
+		params = fmt.Sprintf("m = %d, ef_construction = %d", r.config.HNSWM, r.config.HNSWEfConstruction)
+	} else {
+		params = fmt.Sprintf("lists = %d", r.config.IVFFlatLists)
+	}
+
+	createIndex := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING %s ((%s) %s) WITH (%s) WHERE index_name = %s`,
+		pgIndexIdentifier(indexName), pgVectorTable, indexType, embeddingExpr(dimension), opClass, params, quoteLiteral(indexName))
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 并发创建时以先登记的为准
+		result := tx.Exec(`INSERT INTO `+pgVectorIndexTable+` (name, dimension, metric_type, index_type) VALUES (?, ?, ?, ?) ON CONFLICT (name) DO NOTHING`,
+			indexName, dimension, string(metricType), indexType)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Exec(createIndex).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pgvector index %s: %w", indexName, err)
+	}
+
+	r.mu.Lock()
+	delete(r.indexes, indexName)
+	r.mu.Unlock()
+
+	index, err := r.getIndex(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("index %s not found", indexName)
+	}
+	return index, nil
+}
+
+// getIndex 获取索引登记信息，不存在时返回nil
+func (r *PgVectorRepository) getIndex(ctx context.Context, indexName string) (*pgVectorIndex, error) {
+	if err := r.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	index, cached := r.indexes[indexName]
+	r.mu.Unlock()
+	if cached {
+		return index, nil
+	}
+
+	var found pgVectorIndex
+	err := r.db.WithContext(ctx).Table(pgVectorIndexTable).Where("name = ?", indexName).Take(&found).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pgvector index %s: %w", indexName, err)
+	}
+
+	r.mu.Lock()
+	r.indexes[indexName] = &found
+	r.mu.Unlock()
+	return &found, nil
+}
+
+// DeleteIndex 删除向量索引及其所有向量
+func (r *PgVectorRepository) DeleteIndex(ctx context.Context, indexName string) error {
+	r.logger.Info("Deleting vector index", zap.String("index_name", indexName))
+
+	if err := r.ensureSchema(ctx); err != nil {
+		return err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DROP INDEX IF EXISTS ` + pgIndexIdentifier(indexName)).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM `+pgVectorTable+` WHERE index_name = ?`, indexName).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`DELETE FROM `+pgVectorIndexTable+` WHERE name = ?`, indexName).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete pgvector index %s: %w", indexName, err)
+	}
+
+	r.mu.Lock()
+	delete(r.indexes, indexName)
+	delete(r.stats, indexName)
+	r.mu.Unlock()
+	return nil
+}
+
+// ListIndexes 列出所有索引
+func (r *PgVectorRepository) ListIndexes(ctx context.Context) ([]repository.IndexInfo, error) {
+	if err := r.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if err := r.db.WithContext(ctx).Table(pgVectorIndexTable).Order("name").Pluck("name", &names).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pgvector indexes: %w", err)
+	}
+
+	indexes := make([]repository.IndexInfo, 0, len(names))
+	for _, name := range names {
+		info, err := r.GetIndexInfo(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, *info)
+	}
+
+	return indexes, nil
+}
+
+// GetIndexInfo 获取索引信息
+func (r *PgVectorRepository) GetIndexInfo(ctx context.Context, indexName string) (*repository.IndexInfo, error) {
+	index, err := r.getIndex(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("index %s not found", indexName)
+	}
+
+	count, err := r.GetVectorCount(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.IndexInfo{
+		Name:        index.Name,
+		Dimension:   index.Dimension,
+		MetricType:  repository.MetricType(index.MetricType),
+		VectorCount: count,
+		IndexSize:   r.indexSize(ctx, indexName),
+		CreatedAt:   index.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   index.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// indexSize 获取向量索引占用的磁盘空间，获取失败时返回0
+func (r *PgVectorRepository) indexSize(ctx context.Context, indexName string) int64 {
+	var size int64
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT COALESCE(pg_relation_size(to_regclass(?)), 0)`, pgIndexIdentifier(indexName)).
+		Scan(&size).Error
+	if err != nil {
+		r.logger.Warn("Failed to get pgvector index size", zap.String("index_name", indexName), zap.Error(err))
+		return 0
+	}
+	return size
+}
+
+// Insert 插入向量，同ID的向量会被替换，超时重试不会产生重复向量
+func (r *PgVectorRepository) Insert(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
+	r.logger.Info("Inserting vectors",
+		zap.String("index_name", indexName),
+		zap.Int("count", len(vectors)))
+
+	return r.write(ctx, indexName, vectors)
+}
+
+// Update 更新向量，按Upsert语义替换同ID的向量
+func (r *PgVectorRepository) Update(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
+	r.logger.Info("Updating vectors",
+		zap.String("index_name", indexName),
+		zap.Int("count", len(vectors)))
+
+	return r.write(ctx, indexName, vectors)
+}
+
+// Upsert 插入或替换向量，整批在同一事务中写入
+func (r *PgVectorRepository) Upsert(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
+	r.logger.Info("Upserting vectors",
+		zap.String("index_name", indexName),
+		zap.Int("count", len(vectors)))
+
+	return r.write(ctx, indexName, vectors)
+}
+
+// pgVectorWriteBatchSize 单条INSERT语句写入的向量数
+const pgVectorWriteBatchSize = 200
+
+// write 校验并按主键写入向量，索引不存在时按向量维度以余弦相似度创建
+func (r *PgVectorRepository) write(ctx context.Context, indexName string, vectors []repository.VectorRecord) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	dimension := len(vectors[0].Vector)
+	seen := make(map[string]struct{}, len(vectors))
+	for _, vector := range vectors {
+		if vector.ID == "" {
+			return fmt.Errorf("vector ID cannot be empty")
+		}
+		if _, exists := seen[vector.ID]; exists {
+			return fmt.Errorf("duplicate vector ID in upsert batch: %s", vector.ID)
+		}
+		if len(vector.Vector) != dimension {
+			return fmt.Errorf("vector dimensions mismatch in batch: %d vs %d", len(vector.Vector), dimension)
+		}
+		seen[vector.ID] = struct{}{}
+	}
+
+	index, err := r.ensureIndex(ctx, indexName, dimension, repository.MetricTypeCosine)
+	if err != nil {
+		return err
+	}
+	if index.Dimension != dimension {
+		return fmt.Errorf("vector dimension %d does not match index %s dimension %d", dimension, indexName, index.Dimension)
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(vectors); start += pgVectorWriteBatchSize {
+			end := start + pgVectorWriteBatchSize
+			if end > len(vectors) {
+				end = len(vectors)
+			}
+
+			placeholders := make([]string, 0, end-start)
+			args := make([]interface{}, 0, (end-start)*4)
+			for _, vector := range vectors[start:end] {
+				metadata, err := json.Marshal(nonNilMetadata(vector.Metadata))
+				if err != nil {
+					return fmt.Errorf("failed to encode metadata of vector %s: %w", vector.ID, err)
+				}
+				placeholders = append(placeholders, "(?, ?, ?::vector, ?::jsonb)")
+				args = append(args, indexName, vector.ID, formatPgVector(vector.Vector), string(metadata))
+			}
+
+			statement := `INSERT INTO ` + pgVectorTable + ` (index_name, id, embedding, metadata) VALUES ` +
+				strings.Join(placeholders, ", ") +
+				` ON CONFLICT (index_name, id) DO UPDATE SET embedding = EXCLUDED.embedding, metadata = EXCLUDED.metadata, updated_at = now()`
+			if err := tx.Exec(statement, args...).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`UPDATE `+pgVectorIndexTable+` SET updated_at = now() WHERE name = ?`, indexName).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write vectors to pgvector index %s: %w", indexName, err)
+	}
+
+	return nil
+}
+
+// Delete 删除向量
+func (r *PgVectorRepository) Delete(ctx context.Context, indexName string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	r.logger.Info("Deleting vectors",
+		zap.String("index_name", indexName),
+		zap.Int("count", len(ids)))
+
+	if err := r.ensureSchema(ctx); err != nil {
+		return err
+	}
+
+	err := r.db.WithContext(ctx).
+		Exec(`DELETE FROM `+pgVectorTable+` WHERE index_name = ? AND id IN ?`, indexName, ids).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete vectors from pgvector index %s: %w", indexName, err)
+	}
+
+	return nil
+}
+
+// Search 搜索相似向量
+// 余弦相似度的分数为1减余弦距离，点积的分数为内积，均越大越相似，返回分数不低于ScoreThreshold的结果；
+// 欧氏距离的分数为距离，越小越相似，ScoreThreshold大于0时作为最大距离。
+// 度量类型必须与索引创建时一致，否则无法使用索引
+func (r *PgVectorRepository) Search(ctx context.Context, query *repository.VectorQuery) (*repository.VectorSearchResult, error) {
+	start := time.Now()
+
+	r.logger.Info("Searching vectors",
+		zap.String("index_name", query.IndexName),
+		zap.Int("top_k", query.TopK),
+		zap.String("metric_type", string(query.MetricType)))
+
+	if query.TopK <= 0 {
+		return nil, fmt.Errorf("top_k must be positive: %d", query.TopK)
+	}
+	if len(query.QueryVector) == 0 {
+		return nil, fmt.Errorf("query vector cannot be empty")
+	}
+
+	index, err := r.getIndex(ctx, query.IndexName)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("index %s not found", query.IndexName)
+	}
+	if len(query.QueryVector) != index.Dimension {
+		return nil, fmt.Errorf("query vector dimension %d does not match index %s dimension %d",
+			len(query.QueryVector), query.IndexName, index.Dimension)
+	}
+
+	metricType := query.MetricType
+	if metricType == "" {
+		metricType = repository.MetricType(index.MetricType)
+	}
+	operator, err := pgVectorOperator(metricType)
+	if err != nil {
+		return nil, err
+	}
+
+	where, filterArgs, err := buildPgFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	vectorColumn := "NULL::text"
+	if query.IncludeVector {
+		vectorColumn = "embedding::text"
+	}
+	distanceExpr := fmt.Sprintf("(%s %s ?::%s)", embeddingExpr(index.Dimension), operator, vectorType(index.Dimension))
+	queryVector := formatPgVector(query.QueryVector)
+
+	// 索引名以字面量写入，参数化时通用执行计划无法匹配部分索引的条件
+	statement := fmt.Sprintf(`SELECT id, metadata, %s AS embedding, %s AS distance FROM %s WHERE index_name = %s%s ORDER BY %s LIMIT ?`,
+		vectorColumn, distanceExpr, pgVectorTable, quoteLiteral(query.IndexName), where, distanceExpr)
+	args := append([]interface{}{queryVector}, filterArgs...)
+	args = append(args, queryVector, query.TopK)
+
+	type searchRow struct {
+		ID        string
+		Metadata  []byte
+		Embedding *string
+		Distance  float64
+	}
+	var rows []searchRow
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 候选列表不小于TopK，否则HNSW返回的结果可能少于TopK
+		if index.IndexType == PgVectorIndexHNSW {
+			if err := tx.Exec(fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", maxInt(query.TopK, r.config.HNSWEfSearch))).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Exec(fmt.Sprintf("SET LOCAL ivfflat.probes = %d", maxInt(r.config.IVFFlatProbes, 1))).Error; err != nil {
+			return err
+		}
+		return tx.Raw(statement, args...).Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search pgvector index %s: %w", query.IndexName, err)
+	}
+
+	results := make([]repository.VectorSearchMatch, 0, len(rows))
+	for _, row := range rows {
+		score := pgVectorScore(metricType, row.Distance)
+		if !passesThreshold(metricType, score, query.ScoreThreshold) {
+			continue
+		}
+
+		match := repository.VectorSearchMatch{ID: row.ID, Score: score}
+		if query.IncludeMetadata {
+			if err := json.Unmarshal(row.Metadata, &match.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode metadata of vector %s: %w", row.ID, err)
+			}
+		}
+		if query.IncludeVector && row.Embedding != nil {
+			match.Vector, err = parsePgVector(*row.Embedding)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode vector %s: %w", row.ID, err)
+			}
+		}
+		results = append(results, match)
+	}
+
+	duration := time.Since(start)
+	r.recordQuery(query.IndexName, duration)
+
+	return &repository.VectorSearchResult{
+		Query:    query,
+		Results:  results,
+		Total:    len(results),
+		Duration: duration.Milliseconds(),
+	}, nil
+}
+
+// SearchBatch 批量搜索向量
+func (r *PgVectorRepository) SearchBatch(ctx context.Context, queries []*repository.VectorQuery) ([]*repository.VectorSearchResult, error) {
+	r.logger.Info("Batch searching vectors", zap.Int("count", len(queries)))
+
+	results := make([]*repository.VectorSearchResult, 0, len(queries))
+	for _, query := range queries {
+		result, err := r.Search(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ComputeSimilarity 计算两个向量的相似度
+func (r *PgVectorRepository) ComputeSimilarity(ctx context.Context, vector1, vector2 []float32, metricType repository.MetricType) (float32, error) {
+	if len(vector1) != len(vector2) {
+		return 0, fmt.Errorf("vector dimensions mismatch: %d vs %d", len(vector1), len(vector2))
+	}
+
+	switch metricType {
+	case repository.MetricTypeCosine:
+		return computeCosineSimilarity(vector1, vector2), nil
+	case repository.MetricTypeEuclidean:
+		return computeEuclideanDistance(vector1, vector2), nil
+	case repository.MetricTypeDotProduct:
+		return computeDotProduct(vector1, vector2), nil
+	default:
+		return 0, fmt.Errorf("unsupported metric type: %s", metricType)
+	}
+}
+
+// ComputeSimilarityBatch 批量计算相似度
+func (r *PgVectorRepository) ComputeSimilarityBatch(ctx context.Context, queryVector []float32, vectors [][]float32, metricType repository.MetricType) ([]float32, error) {
+	similarities := make([]float32, len(vectors))
+
+	for i, vector := range vectors {
+		similarity, err := r.ComputeSimilarity(ctx, queryVector, vector, metricType)
+		if err != nil {
+			return nil, err
+		}
+		similarities[i] = similarity
+	}
+
+	return similarities, nil
+}
+
+// GetVectorCount 获取向量数量
+func (r *PgVectorRepository) GetVectorCount(ctx context.Context, indexName string) (int64, error) {
+	if err := r.ensureSchema(ctx); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Table(pgVectorTable).Where("index_name = ?", indexName).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count vectors in pgvector index %s: %w", indexName, err)
+	}
+
+	return count, nil
+}
+
+// GetIndexStats 获取索引统计信息
+// 查询次数和延迟为本实例的统计
+func (r *PgVectorRepository) GetIndexStats(ctx context.Context, indexName string) (*repository.IndexStats, error) {
+	count, err := r.GetVectorCount(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &repository.IndexStats{
+		VectorCount: count,
+		IndexSize:   r.indexSize(ctx, indexName),
+	}
+
+	r.mu.Lock()
+	if queryStats, exists := r.stats[indexName]; exists && queryStats.queryCount > 0 {
+		stats.QueryCount = queryStats.queryCount
+		stats.AverageLatency = float64(queryStats.totalLatency.Milliseconds()) / float64(queryStats.queryCount)
+		stats.LastQueryAt = queryStats.lastQueryTime.Format(time.RFC3339)
+	}
+	r.mu.Unlock()
+
+	return stats, nil
+}
+
+// recordQuery 记录一次查询
+func (r *PgVectorRepository) recordQuery(indexName string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queryStats, exists := r.stats[indexName]
+	if !exists {
+		queryStats = &indexQueryStats{}
+		r.stats[indexName] = queryStats
+	}
+	queryStats.queryCount++
+	queryStats.totalLatency += latency
+	queryStats.lastQueryTime = time.Now()
+}
+
+// Warmup 创建vector扩展和表，使健康检查在首次写入前即可通过
+func (r *PgVectorRepository) Warmup(ctx context.Context) error {
+	return r.ensureSchema(ctx)
+}
+
+// Health 健康检查，确认数据库可用且已安装vector扩展
+func (r *PgVectorRepository) Health(ctx context.Context) error {
+	var installed bool
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector')`).
+		Scan(&installed).Error
+	if err != nil {
+		return fmt.Errorf("pgvector health check failed: %w", err)
+	}
+	if !installed {
+		return fmt.Errorf("pgvector extension is not installed")
+	}
+	return nil
+}
+
+// buildPgFilter 将查询的元数据过滤转换为WHERE条件，各条件之间为AND
+// 等值过滤使用JSONB包含运算以命中GIN索引
+func buildPgFilter(query *repository.VectorQuery) (string, []interface{}, error) {
+	var where strings.Builder
+	var args []interface{}
+
+	if len(query.Filter) > 0 {
+		containment, err := json.Marshal(query.Filter)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode metadata filter: %w", err)
+		}
+		where.WriteString(" AND metadata @> ?::jsonb")
+		args = append(args, string(containment))
+	}
+
+	for _, condition := range query.Conditions {
+		if len(condition.Values) == 0 {
+			continue
+		}
+
+		switch condition.Operator {
+		case repository.FilterOpIn:
+			where.WriteString(" AND metadata ->> ? IN ?")
+			args = append(args, condition.Field, condition.Values)
+		case repository.FilterOpNotIn:
+			// 字段不存在时 ->> 为NULL，IN的结果也为NULL，视为不在排除列表中
+			where.WriteString(" AND NOT COALESCE(metadata ->> ? IN ?, false)")
+			args = append(args, condition.Field, condition.Values)
+		case repository.FilterOpGte:
+			where.WriteString(` AND (metadata ->> ?) COLLATE "C" >= ?`)
+			args = append(args, condition.Field, condition.Values[0])
+		case repository.FilterOpLte:
+			where.WriteString(` AND (metadata ->> ?) COLLATE "C" <= ?`)
+			args = append(args, condition.Field, condition.Values[0])
+		case repository.FilterOpHasAny:
+			alternatives := make([]string, len(condition.Values))
+			for i, value := range condition.Values {
+				containment, err := json.Marshal(map[string]string{repository.PrefixedKey(condition.Field, value): "true"})
+				if err != nil {
+					return "", nil, fmt.Errorf("failed to encode metadata filter: %w", err)
+				}
+				alternatives[i] = "metadata @> ?::jsonb"
+				args = append(args, string(containment))
+			}
+			where.WriteString(" AND (" + strings.Join(alternatives, " OR ") + ")")
+		default:
+			// 未知操作符不能静默忽略，否则会放宽过滤
+			return "", nil, fmt.Errorf("unsupported filter operator: %s", condition.Operator)
+		}
+	}
+
+	return where.String(), args, nil
+}
+
+// pgVectorOpClass 获取度量类型对应的索引操作符类
+func pgVectorOpClass(metricType repository.MetricType) (string, error) {
+	switch metricType {
+	case repository.MetricTypeCosine:
+		return "vector_cosine_ops", nil
+	case repository.MetricTypeEuclidean:
+		return "vector_l2_ops", nil
+	case repository.MetricTypeDotProduct:
+		return "vector_ip_ops", nil
+	default:
+		// 汉明距离只适用于二进制向量
+		return "", fmt.Errorf("unsupported metric type for float vectors: %s", metricType)
+	}
+}
+
+// pgVectorOperator 获取度量类型对应的距离运算符
+func pgVectorOperator(metricType repository.MetricType) (string, error) {
+	switch metricType {
+	case repository.MetricTypeCosine:
+		return "<=>", nil
+	case repository.MetricTypeEuclidean:
+		return "<->", nil
+	case repository.MetricTypeDotProduct:
+		return "<#>", nil
+	default:
+		return "", fmt.Errorf("unsupported metric type for float vectors: %s", metricType)
+	}
+}
+
+// pgVectorScore 将距离运算结果转换为分数：<=>为余弦距离，<#>为负内积
+func pgVectorScore(metricType repository.MetricType, distance float64) float32 {
+	switch metricType {
+	case repository.MetricTypeCosine:
+		return float32(1 - distance)
+	case repository.MetricTypeDotProduct:
+		return float32(-distance)
+	default:
+		return float32(distance)
+	}
+}
+
+// vectorType 指定维度的向量类型
+func vectorType(dimension int) string {
+	return fmt.Sprintf("vector(%d)", dimension)
+}
+
+// embeddingExpr 索引和搜索使用的向量表达式，vectors表的向量列不限定维度，需转换为索引的维度
+func embeddingExpr(dimension int) string {
+	return "embedding::" + vectorType(dimension)
+}
+
+// pgIndexIdentifier 向量索引在Postgres中的索引名
+// 索引名经过字符替换并可能被截断，附加原名的哈希避免不同索引映射到同一名称
+func pgIndexIdentifier(indexName string) string {
+	name := strings.ToLower(collectionName(indexName))
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	sum := sha1.Sum([]byte(indexName))
+	return `"vectors_` + name + "_" + hex.EncodeToString(sum[:])[:10] + `_idx"`
+}
+
+// quoteLiteral 转义SQL字符串字面量，用于部分索引的条件
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// formatPgVector 将向量编码为pgvector文本格式
+func formatPgVector(vector []float32) string {
+	var b strings.Builder
+	b.Grow(len(vector) * 10)
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parsePgVector 解析pgvector文本格式的向量
+func parsePgVector(text string) ([]float32, error) {
+	text = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(text), "["), "]")
+	if text == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(text, ",")
+	vector := make([]float32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, err
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
@@ -0,0 +1,162 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+)
+
+// extractionPrompt 要求模型转写图片中的文字并给出描述，以JSON返回
+const extractionPrompt = `Transcribe all text visible in this image exactly as written, preserving line breaks and reading order. ` +
+	`Then describe the image in one or two sentences. ` +
+	`Reply with a JSON object {"text": "<transcribed text, empty if none>", "caption": "<description>"} and nothing else.`
+
+// OpenAIImageExtractor 调用OpenAI兼容的视觉模型（/v1/chat/completions）识别图片中的文字和描述
+// 兼容OpenAI、vLLM、Ollama等支持image_url消息的服务，图片地址或data URI直接交给模型获取
+type OpenAIImageExtractor struct {
+	config     service.ImageExtractionConfig
+	httpClient *http.Client
+}
+
+// chatRequest 对话请求
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+// chatMessage 对话消息，内容由文字和图片组成
+type chatMessage struct {
+	Role    string        `json:"role"`
+	Content []contentPart `json:"content"`
+}
+
+// contentPart 消息内容片段
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+// imageURL 图片地址
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+// chatResponse 对话响应
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIImageExtractor 创建视觉模型图片文字提取器
+func NewOpenAIImageExtractor(config service.ImageExtractionConfig) *OpenAIImageExtractor {
+	return &OpenAIImageExtractor{
+		config:     config,
+		httpClient: &http.Client{},
+	}
+}
+
+// ExtractText 识别图片中的文字和描述
+func (e *OpenAIImageExtractor) ExtractText(ctx context.Context, image service.ImageReference) (*service.ImageText, error) {
+	prompt := extractionPrompt
+	if alt := strings.TrimSpace(image.AltText); alt != "" {
+		prompt += "\nThe document refers to this image as: " + alt
+	}
+	body, err := json.Marshal(chatRequest{
+		Model: e.config.Model,
+		Messages: []chatMessage{{
+			Role: "user",
+			Content: []contentPart{
+				{Type: "text", Text: prompt},
+				{Type: "image_url", ImageURL: &imageURL{URL: image.URL}},
+			},
+		}},
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vision request: %w", err)
+	}
+
+	if e.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	text, err := e.doRequest(ctx, body)
+	metrics.ObserveProviderCall("openai", "image_extraction", time.Since(start), metrics.ProviderStatus(err))
+	return text, err
+}
+
+// doRequest 发起单次HTTP请求，识别失败的内嵌图片由调用方跳过，因此不重试
+func (e *OpenAIImageExtractor) doRequest(ctx context.Context, body []byte) (*service.ImageText, error) {
+	apiURL := strings.TrimRight(e.config.APIBase, "/") + "/v1/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vision request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send vision request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vision response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vision request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var apiResp chatResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vision response: %w", err)
+	}
+	if len(apiResp.Choices) == 0 {
+		return nil, fmt.Errorf("vision response has no choices")
+	}
+	return parseImageText(apiResp.Choices[0].Message.Content), nil
+}
+
+// parseImageText 解析模型回复，不是JSON时将整个回复作为图片中的文字
+func parseImageText(content string) *service.ImageText {
+	content = strings.TrimSpace(content)
+	// 部分模型会用代码块包裹JSON
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(strings.TrimPrefix(content, "```json"), "```")
+		content = strings.TrimSpace(strings.TrimSuffix(content, "```"))
+	}
+
+	var text service.ImageText
+	var reply struct {
+		Text    string `json:"text"`
+		Caption string `json:"caption"`
+	}
+	if err := json.Unmarshal([]byte(content), &reply); err != nil {
+		text.Text = content
+		return &text
+	}
+	text.Text = reply.Text
+	text.Caption = reply.Caption
+	return &text
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+)
+
+// uploadFileField 上传文档的文件字段名
+const uploadFileField = "file"
+
+// maxUploadFieldBytes 上传表单中单个文本字段的最大字节数
+const maxUploadFieldBytes = 64 << 10
+
+// isMultipartRequest 请求体是否为multipart表单
+func isMultipartRequest(c *gin.Context) bool {
+	return strings.HasPrefix(c.ContentType(), "multipart/form-data")
+}
+
+// addDocumentFromUpload 从multipart表单流式导入文档
+// 文本字段须位于文件字段之前，文件内容不缓冲，直接交给流式导入；标题为空时使用文件名
+func (h *RAGHandler) addDocumentFromUpload(c *gin.Context) {
+	cmd, file, ok := readUploadForm(c)
+	if !ok {
+		return
+	}
+	if cmd.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "knowledge_base_id, title and type must be sent before the file"})
+		return
+	}
+
+	doc, err := h.ragService.AddDocumentFromReader(c.Request.Context(), cmd, file)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to add document")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"document": NewDocumentResponse(doc),
+		"message":  "Document added successfully",
+	})
+}
+
+// UploadDocument 上传文件添加文档
+// 未提交type字段时按文件扩展名或MIME类型识别文档类型；纯文本流式导入，Markdown和HTML读入内存后添加
+func (h *RAGHandler) UploadDocument(c *gin.Context) {
+	if !isMultipartRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request must be multipart/form-data"})
+		return
+	}
+
+	cmd, file, ok := readUploadForm(c)
+	if !ok {
+		return
+	}
+	if cmd.Type == "" {
+		contentType := file.Header.Get("Content-Type")
+		docType, ok := domain.DetectUploadDocumentType(file.FileName(), contentType)
+		if !ok {
+			h.errorResponse(c, domain.ErrUnsupportedFileTypef(file.FileName(), contentType), "Failed to upload document")
+			return
+		}
+		cmd.Type = docType
+	}
+
+	doc, err := h.ragService.AddUploadedDocument(c.Request.Context(), cmd, file)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to upload document")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"document": NewDocumentResponse(doc),
+		"message":  "Document uploaded successfully",
+	})
+}
+
+// readUploadForm 读取multipart表单中文件之前的文本字段，返回文件字段，文件内容留给调用方读取
+// 标题为空时使用文件名；表单无效时已写入400响应并返回false
+func readUploadForm(c *gin.Context) (*service.AddDocumentCommand, *multipart.Part, bool) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	var cmd service.AddDocumentCommand
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return nil, nil, false
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, nil, false
+		}
+
+		if part.FormName() != uploadFileField {
+			if err := setUploadField(&cmd, part); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return nil, nil, false
+			}
+			continue
+		}
+
+		if cmd.Title == "" {
+			cmd.Title = part.FileName()
+		}
+		if cmd.KnowledgeBaseID == "" || cmd.Title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "knowledge_base_id and title must be sent before the file"})
+			return nil, nil, false
+		}
+		return &cmd, part, true
+	}
+}
+
+// setUploadField 读取上传表单的文本字段，未知字段忽略
+func setUploadField(cmd *service.AddDocumentCommand, part *multipart.Part) error {
+	data, err := io.ReadAll(io.LimitReader(part, maxUploadFieldBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxUploadFieldBytes {
+		return fmt.Errorf("field %s exceeds %d bytes", part.FormName(), maxUploadFieldBytes)
+	}
+	value := strings.TrimSpace(string(data))
+
+	switch part.FormName() {
+	case "knowledge_base_id":
+		cmd.KnowledgeBaseID = value
+	case "title":
+		cmd.Title = value
+	case "type":
+		cmd.Type = domain.DocumentType(value)
+	case "source":
+		cmd.Source = value
+	case "language":
+		cmd.Language = value
+	case "access_level":
+		cmd.AccessLevel = domain.DocumentAccessLevel(value)
+	case "allowed_users":
+		// 可以重复提交，也可以用逗号分隔
+		for _, user := range strings.Split(value, ",") {
+			if user = strings.TrimSpace(user); user != "" {
+				cmd.AllowedUsers = append(cmd.AllowedUsers, user)
+			}
+		}
+	case "skip_duplicates":
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid skip_duplicates: %w", err)
+		}
+		cmd.SkipDuplicates = skip
+	case "metadata":
+		var metadata domain.DocumentMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+		cmd.Metadata = &metadata
+	}
+	return nil
+}
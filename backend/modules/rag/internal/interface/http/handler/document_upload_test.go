@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// uploadKnowledgeBaseRepository 只包含一个知识库的仓储
+type uploadKnowledgeBaseRepository struct {
+	repository.KnowledgeBaseRepository
+	kb *domain.KnowledgeBase
+}
+
+func (r *uploadKnowledgeBaseRepository) FindByID(ctx context.Context, id string) (*domain.KnowledgeBase, error) {
+	if id != r.kb.ID {
+		return nil, repository.ErrNotFound
+	}
+	copied := *r.kb
+	return &copied, nil
+}
+
+// uploadDocumentRepository 记录保存的文档，FindByID始终返回未找到，使添加文档触发的后台索引直接结束
+type uploadDocumentRepository struct {
+	repository.DocumentRepository
+	mu        sync.Mutex
+	documents []*domain.Document
+}
+
+func (r *uploadDocumentRepository) Save(ctx context.Context, doc *domain.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *doc
+	r.documents = append(r.documents, &copied)
+	return nil
+}
+
+func (r *uploadDocumentRepository) Update(ctx context.Context, doc *domain.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.documents {
+		if existing.ID == doc.ID {
+			copied := *doc
+			r.documents[i] = &copied
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *uploadDocumentRepository) FindByHash(ctx context.Context, knowledgeBaseID, hash string) (*domain.Document, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *uploadDocumentRepository) FindByID(ctx context.Context, id string) (*domain.Document, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *uploadDocumentRepository) saved() []*domain.Document {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*domain.Document(nil), r.documents...)
+}
+
+// uploadChunkRepository 丢弃流式导入保存的分块
+type uploadChunkRepository struct {
+	repository.ChunkRepository
+}
+
+func (uploadChunkRepository) SaveBatch(ctx context.Context, chunks []*domain.Chunk) error { return nil }
+
+func (uploadChunkRepository) UpdateBatch(ctx context.Context, chunks []*domain.Chunk) error {
+	return nil
+}
+
+func (uploadChunkRepository) FindByDocumentID(ctx context.Context, documentID string) ([]*domain.Chunk, error) {
+	return nil, nil
+}
+
+// uploadVectorRepository 丢弃写入的向量
+type uploadVectorRepository struct {
+	repository.VectorRepository
+}
+
+func (uploadVectorRepository) Upsert(ctx context.Context, indexName string, records []repository.VectorRecord) error {
+	return nil
+}
+
+// uploadEmbedder 为每个文本返回固定向量
+type uploadEmbedder struct {
+	service.EmbeddingService
+}
+
+func (e uploadEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		embeddings[i] = []float32{1, 0, 0}
+	}
+	return embeddings, nil
+}
+
+func (e uploadEmbedder) ForLanguage(language string) service.EmbeddingService { return e }
+
+// newUploadTestEngine 创建只注册上传路由的引擎，返回知识库ID，请求以知识库所有者身份发送
+func newUploadTestEngine(t *testing.T, streamConfig service.StreamIngestionConfig) (*gin.Engine, *uploadDocumentRepository, string) {
+	t.Helper()
+	kb, err := domain.NewKnowledgeBase("kb", "", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docRepo := &uploadDocumentRepository{}
+	ragService := service.NewRAGService(&uploadKnowledgeBaseRepository{kb: kb}, docRepo, uploadChunkRepository{},
+		uploadVectorRepository{}, uploadEmbedder{}, service.NewContentEmbeddingCache(nil, service.EmbeddingCacheConfig{}, zap.NewNop()),
+		service.NewDefaultChunkingService(nil), nil, nil, service.ImageExtractionConfig{}, nil, service.DefaultSearchConfig(),
+		streamConfig, service.EmbeddingBatchConfig{}, service.AccessControlConfig{}, nil, zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(UserContext())
+	router.POST("/documents/upload", NewRAGHandler(ragService, zap.NewNop()).UploadDocument)
+	return router, docRepo, kb.ID
+}
+
+// serveUpload 以multipart表单上传文件，文本字段在文件字段之前
+func serveUpload(t *testing.T, router *gin.Engine, knowledgeBaseID, filename, contentType, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("knowledge_base_id", knowledgeBaseID); err != nil {
+		t.Fatal(err)
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/documents/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(userIDHeader, "owner")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUploadDocumentDetectsType(t *testing.T) {
+	const markdown = "# 部署\n\n先构建镜像，再更新服务。\n"
+	const text = "Deploy the service after building the image.\n"
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		content     string
+		docType     domain.DocumentType
+		streamed    bool
+	}{
+		{name: "markdown", filename: "deploy.md", contentType: "application/octet-stream", content: markdown, docType: domain.DocumentTypeMarkdown},
+		{name: "text", filename: "deploy.txt", contentType: "text/plain", content: text, docType: domain.DocumentTypeText, streamed: true},
+		{name: "mime fallback", filename: "deploy", contentType: "text/markdown; charset=utf-8", content: markdown, docType: domain.DocumentTypeMarkdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, docRepo, kbID := newUploadTestEngine(t, service.DefaultStreamIngestionConfig())
+
+			rec := serveUpload(t, router, kbID, tt.filename, tt.contentType, tt.content)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+			}
+			var resp struct {
+				Document DocumentResponse `json:"document"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Document.Type != tt.docType || resp.Document.Streamed != tt.streamed {
+				t.Fatalf("document type %s, streamed %v, want %s, %v", resp.Document.Type, resp.Document.Streamed, tt.docType, tt.streamed)
+			}
+			if resp.Document.Title != tt.filename || resp.Document.Size != int64(len(tt.content)) {
+				t.Fatalf("document title %q, size %d", resp.Document.Title, resp.Document.Size)
+			}
+
+			saved := docRepo.saved()
+			if len(saved) != 1 || saved[0].ID != resp.Document.ID || saved[0].KnowledgeBaseID != kbID {
+				t.Fatalf("saved documents %+v, want document %s in knowledge base %s", saved, resp.Document.ID, kbID)
+			}
+			// 流式导入的文档不保存全文
+			wantContent := tt.content
+			if tt.streamed {
+				wantContent = ""
+			}
+			if saved[0].Content != wantContent {
+				t.Fatalf("saved content %q, want %q", saved[0].Content, wantContent)
+			}
+		})
+	}
+}
+
+func TestUploadDocumentRejectsUnsupportedType(t *testing.T) {
+	router, docRepo, kbID := newUploadTestEngine(t, service.DefaultStreamIngestionConfig())
+
+	rec := serveUpload(t, router, kbID, "deploy.pdf", "application/pdf", "%PDF-1.7")
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), domain.ErrUnsupportedDocumentType) {
+		t.Fatalf("body %s does not report %s", rec.Body.String(), domain.ErrUnsupportedDocumentType)
+	}
+	if n := len(docRepo.saved()); n != 0 {
+		t.Fatalf("saved %d documents for an unsupported file", n)
+	}
+}
+
+func TestUploadDocumentRejectsOversizedFile(t *testing.T) {
+	router, docRepo, kbID := newUploadTestEngine(t, service.StreamIngestionConfig{BatchSize: 8, MaxDocumentBytes: 64, MaxBufferedBytes: 64})
+
+	for _, filename := range []string{"large.md", "large.txt"} {
+		rec := serveUpload(t, router, kbID, filename, "application/octet-stream", strings.Repeat("Large upload line.\n", 10))
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: status = %d, body %s", filename, rec.Code, rec.Body.String())
+		}
+	}
+	// 流式导入在读取前已保存文档，超限后标记为失败
+	for _, doc := range docRepo.saved() {
+		if doc.Status != domain.DocumentStatusFailed {
+			t.Fatalf("oversized document %s has status %s", doc.Title, doc.Status)
+		}
+	}
+}
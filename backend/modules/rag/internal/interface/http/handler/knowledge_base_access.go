@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+)
+
+// UserContext 将网关转发的请求用户写入请求上下文，服务层据此检查知识库权限
+func UserContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetHeader(userIDHeader); userID != "" {
+			c.Request = c.Request.WithContext(service.WithUserID(c.Request.Context(), userID))
+		}
+		c.Next()
+	}
+}
+
+// ListKnowledgeBaseAccess 列出有知识库访问权限的用户
+func (h *RAGHandler) ListKnowledgeBaseAccess(c *gin.Context) {
+	users, err := h.ragService.ListKnowledgeBaseAccess(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.errorResponse(c, err, "Failed to list knowledge base access")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"total": len(users),
+	})
+}
+
+// GrantKnowledgeBaseAccess 授予用户知识库权限
+func (h *RAGHandler) GrantKnowledgeBaseAccess(c *gin.Context) {
+	var cmd service.GrantKnowledgeBaseAccessCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd.KnowledgeBaseID = c.Param("id")
+	cmd.UserID = c.Param("user_id")
+
+	if err := h.ragService.GrantKnowledgeBaseAccess(c.Request.Context(), &cmd); err != nil {
+		h.errorResponse(c, err, "Failed to grant knowledge base access")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"knowledge_base_id": cmd.KnowledgeBaseID,
+		"user_id":           cmd.UserID,
+		"permission":        cmd.Permission,
+		"message":           "Knowledge base access granted successfully",
+	})
+}
+
+// RevokeKnowledgeBaseAccess 撤销用户的知识库权限
+func (h *RAGHandler) RevokeKnowledgeBaseAccess(c *gin.Context) {
+	if err := h.ragService.RevokeKnowledgeBaseAccess(c.Request.Context(), c.Param("id"), c.Param("user_id")); err != nil {
+		h.errorResponse(c, err, "Failed to revoke knowledge base access")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Knowledge base access revoked successfully",
+	})
+}
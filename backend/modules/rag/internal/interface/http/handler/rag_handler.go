@@ -1,15 +1,23 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"go.uber.org/zap"
 )
 
+// userIDHeader 网关转发的请求用户标识头
+const userIDHeader = "X-User-ID"
+
 // RAGHandler RAG HTTP处理器
 type RAGHandler struct {
 	ragService *service.RAGService
@@ -34,13 +42,12 @@ func (h *RAGHandler) CreateKnowledgeBase(c *gin.Context) {
 
 	kb, err := h.ragService.CreateKnowledgeBase(c.Request.Context(), &cmd)
 	if err != nil {
-		h.logger.Error("Failed to create knowledge base", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to create knowledge base")
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"knowledge_base": kb,
+		"knowledge_base": NewKnowledgeBaseResponse(kb),
 		"message":        "Knowledge base created successfully",
 	})
 }
@@ -78,13 +85,12 @@ func (h *RAGHandler) UpdateKnowledgeBase(c *gin.Context) {
 
 	kb, err := h.ragService.UpdateKnowledgeBase(c.Request.Context(), &cmd)
 	if err != nil {
-		h.logger.Error("Failed to update knowledge base", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to update knowledge base")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"knowledge_base": kb,
+		"knowledge_base": NewKnowledgeBaseResponse(kb),
 		"message":        "Knowledge base updated successfully",
 	})
 }
@@ -95,8 +101,7 @@ func (h *RAGHandler) DeleteKnowledgeBase(c *gin.Context) {
 
 	err := h.ragService.DeleteDocument(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to delete knowledge base", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to delete knowledge base")
 		return
 	}
 
@@ -105,6 +110,48 @@ func (h *RAGHandler) DeleteKnowledgeBase(c *gin.Context) {
 	})
 }
 
+// SnapshotKnowledgeBase 导出知识库快照，以gzip压缩的JSON文件下载
+func (h *RAGHandler) SnapshotKnowledgeBase(c *gin.Context) {
+	id := c.Param("id")
+
+	blob, err := h.ragService.SnapshotKnowledgeBase(c.Request.Context(), id)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to snapshot knowledge base")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"kb_%s.snapshot.json.gz\"", id))
+	c.Data(http.StatusOK, "application/gzip", blob)
+}
+
+// RestoreKnowledgeBase 从快照恢复知识库，请求体为导出的快照文件
+func (h *RAGHandler) RestoreKnowledgeBase(c *gin.Context) {
+	blob, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(blob) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "snapshot is required"})
+		return
+	}
+
+	result, err := h.ragService.RestoreKnowledgeBase(c.Request.Context(), blob)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to restore knowledge base")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"knowledge_base":  result.KnowledgeBase,
+		"document_count":  result.DocumentCount,
+		"chunk_count":     result.ChunkCount,
+		"vector_count":    result.VectorCount,
+		"pending_vectors": result.PendingVectors,
+		"message":         "Knowledge base restored successfully",
+	})
+}
+
 // ListKnowledgeBases 列出知识库
 func (h *RAGHandler) ListKnowledgeBases(c *gin.Context) {
 	ownerID := c.Query("owner_id")
@@ -131,8 +178,13 @@ func (h *RAGHandler) ListKnowledgeBases(c *gin.Context) {
 	})
 }
 
-// AddDocument 添加文档
+// AddDocument 添加文档，请求体为JSON；multipart表单上传的文件按流式导入
 func (h *RAGHandler) AddDocument(c *gin.Context) {
+	if isMultipartRequest(c) {
+		h.addDocumentFromUpload(c)
+		return
+	}
+
 	var cmd service.AddDocumentCommand
 	if err := c.ShouldBindJSON(&cmd); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -141,13 +193,12 @@ func (h *RAGHandler) AddDocument(c *gin.Context) {
 
 	doc, err := h.ragService.AddDocument(c.Request.Context(), &cmd)
 	if err != nil {
-		h.logger.Error("Failed to add document", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to add document")
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"document": doc,
+		"document": NewDocumentResponse(doc),
 		"message":  "Document added successfully",
 	})
 }
@@ -164,12 +215,15 @@ func (h *RAGHandler) GetDocument(c *gin.Context) {
 		IncludeChunks:  includeChunks,
 	}
 
-	// 这里需要在RAGService中实现GetDocument方法
-	// doc, err := h.ragService.GetDocument(c.Request.Context(), cmd)
-	// 暂时返回简单响应
+	doc, err := h.ragService.GetDocument(c.Request.Context(), cmd)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to get document")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"id":      id,
-		"message": "Document retrieved successfully",
+		"document": NewDocumentResponse(doc),
+		"message":  "Document retrieved successfully",
 	})
 }
 
@@ -183,12 +237,39 @@ func (h *RAGHandler) UpdateDocument(c *gin.Context) {
 
 	cmd.ID = c.Param("id")
 
-	// 这里需要在RAGService中实现UpdateDocument方法
-	// doc, err := h.ragService.UpdateDocument(c.Request.Context(), &cmd)
-	// 暂时返回简单响应
+	doc, reindexing, err := h.ragService.UpdateDocument(c.Request.Context(), &cmd)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to update document")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"id":      cmd.ID,
-		"message": "Document updated successfully",
+		"document":   NewDocumentResponse(doc),
+		"reindexing": reindexing,
+		"message":    "Document updated successfully",
+	})
+}
+
+// UpdateDocumentAccess 更新文档访问控制
+func (h *RAGHandler) UpdateDocumentAccess(c *gin.Context) {
+	var cmd service.UpdateDocumentAccessCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd.ID = c.Param("id")
+
+	doc, err := h.ragService.UpdateDocumentAccess(c.Request.Context(), &cmd)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to update document access")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      doc.ID,
+		"access":  doc.Access,
+		"message": "Document access updated successfully",
 	})
 }
 
@@ -198,8 +279,7 @@ func (h *RAGHandler) DeleteDocument(c *gin.Context) {
 
 	err := h.ragService.DeleteDocument(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to delete document", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to delete document")
 		return
 	}
 
@@ -208,6 +288,20 @@ func (h *RAGHandler) DeleteDocument(c *gin.Context) {
 	})
 }
 
+// RestoreDocument 恢复已删除的文档
+func (h *RAGHandler) RestoreDocument(c *gin.Context) {
+	doc, err := h.ragService.RestoreDocument(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.errorResponse(c, err, "Failed to restore document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Document restored successfully",
+		"document": NewDocumentResponse(doc),
+	})
+}
+
 // ListDocuments 列出文档
 func (h *RAGHandler) ListDocuments(c *gin.Context) {
 	knowledgeBaseID := c.Query("knowledge_base_id")
@@ -225,6 +319,27 @@ func (h *RAGHandler) ListDocuments(c *gin.Context) {
 		Limit:           limit,
 	}
 
+	// 带cursor参数（第一页传空值）时使用游标分页
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		cmd.Cursor = cursor
+		docs, nextCursor, err := h.ragService.ListDocumentsAfterCursor(c.Request.Context(), cmd)
+		if err != nil {
+			h.errorResponse(c, err, "Failed to list documents")
+			return
+		}
+
+		documents := make([]*DocumentResponse, len(docs))
+		for i, doc := range docs {
+			documents[i] = NewDocumentResponse(doc)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"documents":   documents,
+			"next_cursor": nextCursor,
+			"has_more":    nextCursor != "",
+		})
+		return
+	}
+
 	// 这里需要在RAGService中实现ListDocuments方法
 	// docs, total, err := h.ragService.ListDocuments(c.Request.Context(), cmd)
 	// 暂时返回简单响应
@@ -250,8 +365,7 @@ func (h *RAGHandler) ProcessDocument(c *gin.Context) {
 
 	err := h.ragService.ProcessDocument(c.Request.Context(), cmd.DocumentID)
 	if err != nil {
-		h.logger.Error("Failed to process document", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to process document")
 		return
 	}
 
@@ -271,8 +385,7 @@ func (h *RAGHandler) Search(c *gin.Context) {
 	query := cmd.ToSearchQuery()
 	results, err := h.ragService.Search(c.Request.Context(), query)
 	if err != nil {
-		h.logger.Error("Failed to search", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.errorResponse(c, err, "Failed to search")
 		return
 	}
 
@@ -340,6 +453,46 @@ func (h *RAGHandler) BatchDeleteDocuments(c *gin.Context) {
 	})
 }
 
+// errorResponse 将服务错误转换为HTTP响应
+// 缺少用户身份返回401，没有知识库权限返回403，资源不存在返回404，文档内容重复或知识库已存在返回409，文档过大返回413，文档类型不支持返回415，
+// 其他错误（如数据库故障）记录日志并返回500
+func (h *RAGHandler) errorResponse(c *gin.Context, err error, message string) {
+	var domainErr *domain.DomainError
+	if errors.As(err, &domainErr) {
+		switch domainErr.Code {
+		case domain.ErrUnauthenticated:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		case domain.ErrPermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		case domain.ErrDocumentNotFound, domain.ErrKnowledgeBaseNotFound, domain.ErrChunkNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		case domain.ErrDocumentAlreadyExists, domain.ErrKnowledgeBaseExists:
+			c.JSON(http.StatusConflict, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		case domain.ErrInvalidInput, domain.ErrVectorDimensionMismatch, domain.ErrDocumentInvalidContent:
+			c.JSON(http.StatusBadRequest, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		case domain.ErrDocumentTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		case domain.ErrUnsupportedDocumentType:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": domainErr.Error(), "code": domainErr.Code})
+			return
+		}
+	}
+	// 服务层未转换的仓储不存在错误
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "NOT_FOUND"})
+		return
+	}
+
+	h.logger.Error(message, zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 // Health 健康检查
 func (h *RAGHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
+)
+
+// API响应结构，与GORM模型分离：不返回分块的嵌入向量、相似度缓存、向量同步重试次数和软删除标记等内部字段，
+// 未设置的时间返回省略而不是零值
+
+// KnowledgeBaseResponse 知识库响应
+type KnowledgeBaseResponse struct {
+	ID            string                       `json:"id"`
+	Name          string                       `json:"name"`
+	Description   string                       `json:"description"`
+	Status        domain.KnowledgeBaseStatus   `json:"status"`
+	OwnerID       string                       `json:"owner_id"`
+	Settings      domain.KnowledgeBaseSettings `json:"settings"`
+	Statistics    domain.KnowledgeBaseStats    `json:"statistics"`
+	Tags          []TagResponse                `json:"tags"`
+	Documents     []DocumentResponse           `json:"documents,omitempty"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+	LastIndexedAt *time.Time                   `json:"last_indexed_at,omitempty"`
+}
+
+// DocumentResponse 文档响应，未请求内容和分块时省略content和chunks
+type DocumentResponse struct {
+	ID              string                  `json:"id"`
+	KnowledgeBaseID string                  `json:"knowledge_base_id"`
+	Title           string                  `json:"title"`
+	Content         string                  `json:"content,omitempty"`
+	Type            domain.DocumentType     `json:"type"`
+	Status          domain.DocumentStatus   `json:"status"`
+	Source          string                  `json:"source"`
+	Hash            string                  `json:"hash"`
+	Size            int64                   `json:"size"`
+	Language        string                  `json:"language"`
+	Streamed        bool                    `json:"streamed"` // 流式导入，不保存全文
+	Tags            []TagResponse           `json:"tags"`
+	Metadata        domain.DocumentMetadata `json:"metadata"`
+	Access          domain.DocumentAccess   `json:"access"`
+	Chunks          []ChunkResponse         `json:"chunks,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+	IndexedAt       *time.Time              `json:"indexed_at,omitempty"`
+}
+
+// ChunkResponse 分块响应，用has_embedding代替嵌入向量
+type ChunkResponse struct {
+	ID              string                  `json:"id"`
+	DocumentID      string                  `json:"document_id"`
+	Content         string                  `json:"content"`
+	Type            domain.ChunkType        `json:"type"`
+	Position        int                     `json:"position"`
+	StartIndex      int                     `json:"start_index"`
+	EndIndex        int                     `json:"end_index"`
+	TokenCount      int                     `json:"token_count"`
+	Metadata        domain.ChunkMetadata    `json:"metadata"`
+	Image           *domain.ChunkImage      `json:"image,omitempty"`
+	HasEmbedding    bool                    `json:"has_embedding"`
+	VectorStatus    domain.VectorSyncStatus `json:"vector_status"`
+	VectorSyncError string                  `json:"vector_sync_error,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+	EmbeddedAt      *time.Time              `json:"embedded_at,omitempty"`
+}
+
+// TagResponse 标签响应
+type TagResponse struct {
+	ID    string         `json:"id"`
+	Name  string         `json:"name"`
+	Type  domain.TagType `json:"type"`
+	Color string         `json:"color,omitempty"`
+}
+
+// NewKnowledgeBaseResponse 转换知识库
+func NewKnowledgeBaseResponse(kb *domain.KnowledgeBase) *KnowledgeBaseResponse {
+	resp := &KnowledgeBaseResponse{
+		ID:            kb.ID,
+		Name:          kb.Name,
+		Description:   kb.Description,
+		Status:        kb.Status,
+		OwnerID:       kb.OwnerID,
+		Settings:      kb.Settings,
+		Statistics:    kb.Statistics,
+		Tags:          newTagResponses(kb.Tags),
+		CreatedAt:     kb.CreatedAt,
+		UpdatedAt:     kb.UpdatedAt,
+		LastIndexedAt: kb.LastIndexedAt,
+	}
+	for i := range kb.Documents {
+		resp.Documents = append(resp.Documents, *NewDocumentResponse(&kb.Documents[i]))
+	}
+	return resp
+}
+
+// NewDocumentResponse 转换文档
+func NewDocumentResponse(doc *domain.Document) *DocumentResponse {
+	resp := &DocumentResponse{
+		ID:              doc.ID,
+		KnowledgeBaseID: doc.KnowledgeBaseID,
+		Title:           doc.Title,
+		Content:         doc.Content,
+		Type:            doc.Type,
+		Status:          doc.Status,
+		Source:          doc.Source,
+		Hash:            doc.Hash,
+		Size:            doc.Size,
+		Language:        doc.Language,
+		Streamed:        doc.Streamed,
+		Tags:            newTagResponses(doc.Tags),
+		Metadata:        doc.Metadata,
+		Access:          doc.Access,
+		CreatedAt:       doc.CreatedAt,
+		UpdatedAt:       doc.UpdatedAt,
+		IndexedAt:       doc.IndexedAt,
+	}
+	for i := range doc.Chunks {
+		resp.Chunks = append(resp.Chunks, newChunkResponse(&doc.Chunks[i]))
+	}
+	return resp
+}
+
+// newChunkResponse 转换分块
+func newChunkResponse(chunk *domain.Chunk) ChunkResponse {
+	return ChunkResponse{
+		ID:              chunk.ID,
+		DocumentID:      chunk.DocumentID,
+		Content:         chunk.Content,
+		Type:            chunk.Type,
+		Position:        chunk.Position,
+		StartIndex:      chunk.StartIndex,
+		EndIndex:        chunk.EndIndex,
+		TokenCount:      chunk.TokenCount,
+		Metadata:        chunk.Metadata,
+		Image:           chunk.Image,
+		HasEmbedding:    chunk.HasEmbedding(),
+		VectorStatus:    chunk.VectorStatus,
+		VectorSyncError: chunk.VectorSyncError,
+		CreatedAt:       chunk.CreatedAt,
+		UpdatedAt:       chunk.UpdatedAt,
+		EmbeddedAt:      chunk.EmbeddedAt,
+	}
+}
+
+// newTagResponses 转换标签，空列表序列化为[]而不是null
+func newTagResponses(tags []domain.Tag) []TagResponse {
+	resp := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		resp = append(resp, TagResponse{
+			ID:    tag.ID,
+			Name:  tag.Name,
+			Type:  tag.Type,
+			Color: tag.Color,
+		})
+	}
+	return resp
+}
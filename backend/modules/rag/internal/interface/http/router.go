@@ -5,6 +5,7 @@ import (
 	"github.com/noah-loop/backend/modules/rag/internal/interface/http/handler"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/middleware"
 )
 
 // Router HTTP路由器
@@ -19,6 +20,7 @@ func NewRouter(
 	ragHandler *handler.RAGHandler,
 	metrics *infrastructure.MetricsRegistry,
 	tracingWrapper *tracing.TracingWrapper,
+	bodyLimit middleware.BodyLimitConfig,
 ) *Router {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
@@ -37,6 +39,9 @@ func NewRouter(
 		engine.Use(metrics.PrometheusMiddleware())
 	}
 
+	// 请求体大小限制，超限的请求在绑定前返回413
+	engine.Use(middleware.BodyLimit(bodyLimit))
+
 	router := &Router{
 		engine:     engine,
 		ragHandler: ragHandler,
@@ -50,12 +55,12 @@ func NewRouter(
 
 // setupRoutes 设置路由
 func (r *Router) setupRoutes() {
-	// 健康检查
+	// 存活检查，就绪探针/ready和/readyz由cmd中的就绪闸门处理
 	r.engine.GET("/health", r.ragHandler.Health)
-	r.engine.GET("/ready", r.ragHandler.Health)
 
 	// API版本
 	v1 := r.engine.Group("/api/v1")
+	v1.Use(handler.UserContext())
 
 	// 知识库相关路由
 	kbRoutes := v1.Group("/knowledge-bases")
@@ -65,17 +70,27 @@ func (r *Router) setupRoutes() {
 		kbRoutes.GET("/:id", r.ragHandler.GetKnowledgeBase)
 		kbRoutes.PUT("/:id", r.ragHandler.UpdateKnowledgeBase)
 		kbRoutes.DELETE("/:id", r.ragHandler.DeleteKnowledgeBase)
+		kbRoutes.GET("/:id/snapshot", r.ragHandler.SnapshotKnowledgeBase)
+		kbRoutes.POST("/restore", r.ragHandler.RestoreKnowledgeBase)
+
+		// 共享权限
+		kbRoutes.GET("/:id/permissions", r.ragHandler.ListKnowledgeBaseAccess)
+		kbRoutes.PUT("/:id/permissions/:user_id", r.ragHandler.GrantKnowledgeBaseAccess)
+		kbRoutes.DELETE("/:id/permissions/:user_id", r.ragHandler.RevokeKnowledgeBaseAccess)
 	}
 
 	// 文档相关路由
 	docRoutes := v1.Group("/documents")
 	{
 		docRoutes.POST("", r.ragHandler.AddDocument)
+		docRoutes.POST("/upload", r.ragHandler.UploadDocument)
 		docRoutes.GET("", r.ragHandler.ListDocuments)
 		docRoutes.GET("/:id", r.ragHandler.GetDocument)
 		docRoutes.PUT("/:id", r.ragHandler.UpdateDocument)
 		docRoutes.DELETE("/:id", r.ragHandler.DeleteDocument)
+		docRoutes.POST("/:id/restore", r.ragHandler.RestoreDocument)
 		docRoutes.POST("/:id/process", r.ragHandler.ProcessDocument)
+		docRoutes.PUT("/:id/access", r.ragHandler.UpdateDocumentAccess)
 		
 		// 批量操作
 		docRoutes.POST("/batch", r.ragHandler.BatchAddDocuments)
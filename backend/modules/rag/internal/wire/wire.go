@@ -1,17 +1,31 @@
 package wire
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/google/wire"
 	"github.com/noah-loop/backend/modules/rag/internal/application/service"
+	"github.com/noah-loop/backend/modules/rag/internal/domain"
 	"github.com/noah-loop/backend/modules/rag/internal/domain/repository"
 	"github.com/noah-loop/backend/modules/rag/internal/infrastructure/embedding"
+	"github.com/noah-loop/backend/modules/rag/internal/infrastructure/rerank"
 	infraRepo "github.com/noah-loop/backend/modules/rag/internal/infrastructure/repository"
+	"github.com/noah-loop/backend/modules/rag/internal/infrastructure/summary"
 	"github.com/noah-loop/backend/modules/rag/internal/infrastructure/vector"
+	"github.com/noah-loop/backend/modules/rag/internal/infrastructure/vision"
 	"github.com/noah-loop/backend/modules/rag/internal/interface/http"
 	"github.com/noah-loop/backend/modules/rag/internal/interface/http/handler"
+	"github.com/noah-loop/backend/shared/pkg/cache"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
 	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/metrics"
+	"github.com/noah-loop/backend/shared/pkg/middleware"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -37,8 +51,10 @@ type RAGApp struct {
 	TracingWrapper  *tracing.TracingWrapper
 
 	// RAG特定组件
+	VectorRepository repository.VectorRepository
 	EmbeddingService service.EmbeddingService
 	ChunkingService  service.ChunkingService
+	VectorSyncWorker *service.VectorSyncWorker
 }
 
 // RAGRepositoryProviderSet RAG仓储提供者集合
@@ -54,8 +70,8 @@ var RAGRepositoryProviderSet = wire.NewSet(
 // RAGVectorProviderSet RAG向量提供者集合
 var RAGVectorProviderSet = wire.NewSet(
 	NewMilvusConfig,
-	vector.NewMilvusVectorRepository,
-	wire.Bind(new(repository.VectorRepository), new(*vector.MilvusVectorRepository)),
+	NewPgVectorConfig,
+	NewVectorRepository,
 )
 
 // RAGServiceProviderSet RAG服务提供者集合
@@ -65,21 +81,65 @@ var RAGServiceProviderSet = wire.NewSet(
 	embedding.NewOpenAIEmbeddingService,
 	wire.Bind(new(service.EmbeddingService), new(*embedding.OpenAIEmbeddingService)),
 
+	// 嵌入缓存
+	NewEmbeddingCacheConfig,
+	NewEmbeddingCacheStore,
+	service.NewContentEmbeddingCache,
+
 	// 分块服务
 	NewChunkingConfig,
 	service.NewDefaultChunkingService,
 	wire.Bind(new(service.ChunkingService), new(*service.DefaultChunkingService)),
 
+	// 重排序
+	NewRerankerConfig,
+	NewReranker,
+
+	// 图片文字识别
+	NewImageExtractionConfig,
+	NewImageTextExtractor,
+
+	// 文档摘要
+	NewSummarizationConfig,
+	NewDocumentSummarizer,
+
 	// 主服务
+	NewSearchConfig,
+	NewStreamIngestionConfig,
+	NewEmbeddingBatchConfig,
+	NewAccessControlConfig,
+	NewRAGMetrics,
 	service.NewRAGService,
+
+	// 向量同步任务
+	NewVectorSyncConfig,
+	service.NewVectorSyncWorker,
 )
 
 // RAGHandlerProviderSet RAG处理器提供者集合
 var RAGHandlerProviderSet = wire.NewSet(
 	handler.NewRAGHandler,
 	http.NewRouter,
+	NewBodyLimitConfig,
 )
 
+// streamUploadFormOverhead 流式上传表单中文件以外部分（文本字段和分隔符）预留的大小
+const streamUploadFormOverhead = 1 << 20
+
+// NewBodyLimitConfig 读取请求体大小限制，环境变量为RAG_MAX_BODY_SIZE和RAG_MAX_BODY_SIZE_ROUTES
+// 添加和上传文档的请求默认放宽到流式导入的文档大小上限，以便上传大文件；RAG_MAX_BODY_SIZE_ROUTES中的配置优先
+func NewBodyLimitConfig(logger infrastructure.Logger, streamConfig service.StreamIngestionConfig) middleware.BodyLimitConfig {
+	config := middleware.LoadBodyLimitConfig("RAG", logger)
+	if streamConfig.MaxDocumentBytes > 0 {
+		maxBytes := streamConfig.MaxDocumentBytes + streamUploadFormOverhead
+		config.Routes = append(config.Routes,
+			middleware.RouteBodyLimit{Method: "POST", Path: "/api/v1/documents", MaxBytes: maxBytes},
+			middleware.RouteBodyLimit{Method: "POST", Path: "/api/v1/documents/upload", MaxBytes: maxBytes},
+		)
+	}
+	return config
+}
+
 // InitializeRAGApp 初始化RAG应用
 func InitializeRAGApp() (*RAGApp, func(), error) {
 	wire.Build(
@@ -123,9 +183,105 @@ func NewEmbeddingConfig(config *infrastructure.Config, secretManager *etcd.Secre
 	// embeddingConfig.Model = config.RAG.EmbeddingModel
 	// embeddingConfig.Dimension = config.RAG.EmbeddingDimension
 
+	// 超时与重试支持通过环境变量覆盖
+	if timeout, err := time.ParseDuration(os.Getenv("RAG_EMBEDDING_TIMEOUT")); err == nil && timeout >= time.Second {
+		embeddingConfig.Timeout = int(timeout / time.Second)
+	}
+	if maxRetries, err := strconv.Atoi(os.Getenv("RAG_EMBEDDING_MAX_RETRIES")); err == nil && maxRetries >= 0 {
+		embeddingConfig.MaxRetries = maxRetries
+	}
+	if retryDelay, err := time.ParseDuration(os.Getenv("RAG_EMBEDDING_RETRY_DELAY")); err == nil && retryDelay > 0 {
+		embeddingConfig.RetryDelay = int(retryDelay / time.Millisecond)
+	}
+	if models := parseLanguageModels(os.Getenv("RAG_EMBEDDING_LANGUAGE_MODELS")); len(models) > 0 {
+		embeddingConfig.LanguageModels = models
+	}
+
 	return embeddingConfig
 }
 
+// NewEmbeddingBatchConfig 创建嵌入请求分批配置，支持通过环境变量覆盖提供商的单次请求上限和子批次重试
+func NewEmbeddingBatchConfig() service.EmbeddingBatchConfig {
+	batchConfig := service.DefaultEmbeddingBatchConfig()
+
+	if maxInputs, err := strconv.Atoi(os.Getenv("RAG_EMBEDDING_BATCH_MAX_INPUTS")); err == nil && maxInputs > 0 {
+		batchConfig.MaxInputs = maxInputs
+	}
+	if maxTokens, err := strconv.Atoi(os.Getenv("RAG_EMBEDDING_BATCH_MAX_TOKENS")); err == nil && maxTokens > 0 {
+		batchConfig.MaxTokens = maxTokens
+	}
+	if maxRetries, err := strconv.Atoi(os.Getenv("RAG_EMBEDDING_BATCH_MAX_RETRIES")); err == nil && maxRetries >= 0 {
+		batchConfig.MaxRetries = maxRetries
+	}
+	if retryDelay, err := time.ParseDuration(os.Getenv("RAG_EMBEDDING_BATCH_RETRY_DELAY")); err == nil && retryDelay > 0 {
+		batchConfig.RetryDelay = retryDelay
+	}
+
+	return batchConfig
+}
+
+// NewEmbeddingCacheConfig 创建嵌入缓存配置，RAG_EMBEDDING_CACHE=none时禁用
+func NewEmbeddingCacheConfig() service.EmbeddingCacheConfig {
+	cacheConfig := service.DefaultEmbeddingCacheConfig()
+
+	if backend := strings.ToLower(os.Getenv("RAG_EMBEDDING_CACHE")); backend == "none" || backend == "off" {
+		cacheConfig.Enabled = false
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("RAG_EMBEDDING_CACHE_TTL")); err == nil && ttl > 0 {
+		cacheConfig.TTL = ttl
+	}
+
+	return cacheConfig
+}
+
+// NewEmbeddingCacheStore 创建嵌入缓存的存储后端
+// RAG_EMBEDDING_CACHE选择memory（默认，进程内LRU）或redis（多实例共享，连接参数来自REDIS_*环境变量），禁用时返回nil
+func NewEmbeddingCacheStore(cacheConfig service.EmbeddingCacheConfig) (cache.Cache, func(), error) {
+	if !cacheConfig.Enabled {
+		return nil, func() {}, nil
+	}
+
+	storeConfig := cache.DefaultConfig()
+	storeConfig.Prefix = "rag:"
+	if backend := strings.ToLower(os.Getenv("RAG_EMBEDDING_CACHE")); backend != "" {
+		storeConfig.Backend = backend
+	}
+	if maxEntries, err := strconv.Atoi(os.Getenv("RAG_EMBEDDING_CACHE_MAX_ENTRIES")); err == nil && maxEntries > 0 {
+		storeConfig.MaxEntries = maxEntries
+	}
+
+	redisConfig := cache.RedisConfig{Addr: "localhost:6379"}
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		redisConfig.Addr = addr
+	}
+	redisConfig.Password = os.Getenv("REDIS_PASSWORD")
+	if db, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil && db >= 0 {
+		redisConfig.DB = db
+	}
+
+	return cache.New(storeConfig, redisConfig)
+}
+
+// parseLanguageModels 解析语言专用嵌入模型配置，格式为 "zh=bge-large-zh-v1.5:1024,ja=model"，维度可省略
+func parseLanguageModels(value string) map[string]service.LanguageModel {
+	models := make(map[string]service.LanguageModel)
+	for _, entry := range strings.Split(value, ",") {
+		language, spec, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || language == "" || spec == "" {
+			continue
+		}
+
+		model := service.LanguageModel{Model: spec}
+		if i := strings.LastIndex(spec, ":"); i > 0 {
+			if dimension, err := strconv.Atoi(spec[i+1:]); err == nil && dimension > 0 {
+				model = service.LanguageModel{Model: spec[:i], Dimension: dimension}
+			}
+		}
+		models[domain.LanguageBase(language)] = model
+	}
+	return models
+}
+
 // NewChunkingConfig 创建分块配置
 func NewChunkingConfig(config *infrastructure.Config) *service.ChunkingConfig {
 	chunkingConfig := service.DefaultChunkingConfig()
@@ -137,24 +293,276 @@ func NewChunkingConfig(config *infrastructure.Config) *service.ChunkingConfig {
 	return chunkingConfig
 }
 
-// NewMilvusConfig 创建Milvus配置
+// NewSearchConfig 创建搜索配置，支持通过环境变量覆盖混合检索的融合参数
+func NewSearchConfig() service.SearchConfig {
+	searchConfig := service.DefaultSearchConfig()
+
+	if weight, err := strconv.ParseFloat(os.Getenv("RAG_HYBRID_VECTOR_WEIGHT"), 64); err == nil && weight >= 0 && weight <= 1 {
+		searchConfig.HybridVectorWeight = weight
+	}
+	if k, err := strconv.Atoi(os.Getenv("RAG_HYBRID_RRF_K")); err == nil && k > 0 {
+		searchConfig.RRFK = k
+	}
+
+	return searchConfig
+}
+
+// NewStreamIngestionConfig 创建流式导入配置，支持通过环境变量覆盖
+func NewStreamIngestionConfig(logger infrastructure.Logger) service.StreamIngestionConfig {
+	streamConfig := service.DefaultStreamIngestionConfig()
+
+	if batchSize, err := strconv.Atoi(os.Getenv("RAG_STREAM_BATCH_SIZE")); err == nil && batchSize > 0 {
+		streamConfig.BatchSize = batchSize
+	}
+	if value := os.Getenv("RAG_STREAM_MAX_DOCUMENT_SIZE"); value != "" {
+		size, err := middleware.ParseByteSize(value)
+		if err != nil {
+			logger.Warn("Invalid max streamed document size, using default",
+				zap.String("env", "RAG_STREAM_MAX_DOCUMENT_SIZE"),
+				zap.Int64("default", streamConfig.MaxDocumentBytes),
+				zap.Error(err))
+		} else {
+			streamConfig.MaxDocumentBytes = size
+		}
+	}
+	if value := os.Getenv("RAG_UPLOAD_MAX_BUFFERED_SIZE"); value != "" {
+		size, err := middleware.ParseByteSize(value)
+		if err != nil {
+			logger.Warn("Invalid max buffered upload size, using default",
+				zap.String("env", "RAG_UPLOAD_MAX_BUFFERED_SIZE"),
+				zap.Int64("default", streamConfig.MaxBufferedBytes),
+				zap.Error(err))
+		} else {
+			streamConfig.MaxBufferedBytes = size
+		}
+	}
+
+	return streamConfig
+}
+
+// NewAccessControlConfig 创建知识库访问控制配置，默认拒绝没有X-User-ID的请求
+// 仅在网关未开启认证的本地开发环境设置RAG_ALLOW_ANONYMOUS=true
+func NewAccessControlConfig(logger infrastructure.Logger) service.AccessControlConfig {
+	var accessConfig service.AccessControlConfig
+	if allowAnonymous, err := strconv.ParseBool(os.Getenv("RAG_ALLOW_ANONYMOUS")); err == nil {
+		accessConfig.AllowAnonymous = allowAnonymous
+	}
+	if accessConfig.AllowAnonymous {
+		logger.Warn("Anonymous requests skip knowledge base access checks", zap.String("env", "RAG_ALLOW_ANONYMOUS"))
+	}
+	return accessConfig
+}
+
+// NewRAGMetrics 创建检索和索引指标，注册到默认注册表，由现有的 /metrics 端点暴露
+// 知识库很多时设置RAG_METRICS_PER_KNOWLEDGE_BASE=false，指标不再按知识库区分
+func NewRAGMetrics() (*metrics.RAGMetrics, error) {
+	perKnowledgeBase := true
+	if value, err := strconv.ParseBool(os.Getenv("RAG_METRICS_PER_KNOWLEDGE_BASE")); err == nil {
+		perKnowledgeBase = value
+	}
+	return metrics.NewRAGMetrics(nil, perKnowledgeBase)
+}
+
+// NewRerankerConfig 创建重排序服务配置，支持通过环境变量覆盖
+func NewRerankerConfig(secretManager *etcd.SecretManager) service.RerankerConfig {
+	rerankerConfig := service.DefaultRerankerConfig()
+
+	rerankerConfig.APIBase = os.Getenv("RAG_RERANK_API_BASE")
+	rerankerConfig.APIKey = os.Getenv("RAG_RERANK_API_KEY")
+	if rerankerConfig.APIKey == "" && secretManager != nil {
+		if apiKey, err := secretManager.GetSecret("rerank_api_key"); err == nil && apiKey != "" {
+			rerankerConfig.APIKey = apiKey
+		}
+	}
+	if model := os.Getenv("RAG_RERANK_MODEL"); model != "" {
+		rerankerConfig.Model = model
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("RAG_RERANK_TIMEOUT")); err == nil && timeout > 0 {
+		rerankerConfig.Timeout = timeout
+	}
+
+	return rerankerConfig
+}
+
+// NewReranker 创建重排序器，未配置重排序服务地址时不调整检索顺序
+func NewReranker(rerankerConfig service.RerankerConfig) service.Reranker {
+	if rerankerConfig.APIBase == "" {
+		return service.NewNoopReranker()
+	}
+	return rerank.NewHTTPReranker(rerankerConfig)
+}
+
+// NewImageExtractionConfig 创建图片文字识别配置，支持通过环境变量覆盖
+func NewImageExtractionConfig(secretManager *etcd.SecretManager) service.ImageExtractionConfig {
+	imageConfig := service.DefaultImageExtractionConfig()
+
+	imageConfig.APIBase = os.Getenv("RAG_IMAGE_API_BASE")
+	imageConfig.APIKey = os.Getenv("RAG_IMAGE_API_KEY")
+	if imageConfig.APIKey == "" && secretManager != nil {
+		if apiKey, err := secretManager.GetSecret("openai_api_key"); err == nil && apiKey != "" {
+			imageConfig.APIKey = apiKey
+		}
+	}
+	if model := os.Getenv("RAG_IMAGE_MODEL"); model != "" {
+		imageConfig.Model = model
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("RAG_IMAGE_TIMEOUT")); err == nil && timeout > 0 {
+		imageConfig.Timeout = timeout
+	}
+	if maxImages, err := strconv.Atoi(os.Getenv("RAG_IMAGE_MAX_PER_DOCUMENT")); err == nil && maxImages >= 0 {
+		imageConfig.MaxImagesPerDocument = maxImages
+	}
+
+	return imageConfig
+}
+
+// NewImageTextExtractor 创建图片文字提取器，未配置视觉模型地址时只使用图片的替代文字
+func NewImageTextExtractor(imageConfig service.ImageExtractionConfig) service.ImageTextExtractor {
+	if imageConfig.APIBase == "" {
+		return service.NewNoopImageTextExtractor()
+	}
+	return vision.NewOpenAIImageExtractor(imageConfig)
+}
+
+// NewSummarizationConfig 创建文档摘要配置，支持通过环境变量覆盖
+// 未单独配置时使用共享的OpenAI密钥调用OpenAI服务
+func NewSummarizationConfig(secretManager *etcd.SecretManager) service.SummarizationConfig {
+	summaryConfig := service.DefaultSummarizationConfig()
+
+	summaryConfig.APIBase = os.Getenv("RAG_SUMMARY_API_BASE")
+	summaryConfig.APIKey = os.Getenv("RAG_SUMMARY_API_KEY")
+	if summaryConfig.APIKey == "" && secretManager != nil {
+		if apiKey, err := secretManager.GetSecret("openai_api_key"); err == nil && apiKey != "" {
+			summaryConfig.APIKey = apiKey
+		}
+	}
+	if summaryConfig.APIBase == "" && summaryConfig.APIKey != "" {
+		summaryConfig.APIBase = "https://api.openai.com"
+	}
+	if model := os.Getenv("RAG_SUMMARY_MODEL"); model != "" {
+		summaryConfig.Model = model
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("RAG_SUMMARY_TIMEOUT")); err == nil && timeout > 0 {
+		summaryConfig.Timeout = timeout
+	}
+	if maxChars, err := strconv.Atoi(os.Getenv("RAG_SUMMARY_MAX_INPUT_CHARS")); err == nil && maxChars > 0 {
+		summaryConfig.MaxInputChars = maxChars
+	}
+	if maxTokens, err := strconv.Atoi(os.Getenv("RAG_SUMMARY_MAX_TOKENS")); err == nil && maxTokens > 0 {
+		summaryConfig.MaxTokens = maxTokens
+	}
+
+	return summaryConfig
+}
+
+// NewDocumentSummarizer 创建文档摘要生成器，未配置模型服务时不生成摘要
+func NewDocumentSummarizer(summaryConfig service.SummarizationConfig) service.DocumentSummarizer {
+	if summaryConfig.APIBase == "" {
+		return service.NewNoopDocumentSummarizer()
+	}
+	return summary.NewOpenAISummarizer(summaryConfig)
+}
+
+// NewVectorSyncConfig 创建向量同步任务配置，支持通过环境变量覆盖
+func NewVectorSyncConfig() service.VectorSyncConfig {
+	syncConfig := service.DefaultVectorSyncConfig()
+
+	if interval, err := time.ParseDuration(os.Getenv("RAG_VECTOR_SYNC_INTERVAL")); err == nil && interval > 0 {
+		syncConfig.Interval = interval
+	}
+	if batchSize, err := strconv.Atoi(os.Getenv("RAG_VECTOR_SYNC_BATCH_SIZE")); err == nil && batchSize > 0 {
+		syncConfig.BatchSize = batchSize
+	}
+	if maxAttempts, err := strconv.Atoi(os.Getenv("RAG_VECTOR_SYNC_MAX_ATTEMPTS")); err == nil && maxAttempts > 0 {
+		syncConfig.MaxAttempts = maxAttempts
+	}
+	if staleAfter, err := time.ParseDuration(os.Getenv("RAG_VECTOR_SYNC_STALE_AFTER")); err == nil && staleAfter > 0 {
+		syncConfig.StaleAfter = staleAfter
+	}
+
+	return syncConfig
+}
+
+// NewMilvusConfig 创建Milvus配置，支持通过环境变量覆盖
 func NewMilvusConfig(config *infrastructure.Config) *vector.MilvusConfig {
-	return &vector.MilvusConfig{
+	milvusConfig := &vector.MilvusConfig{
 		Host:       "localhost",
 		Port:       19530,
 		Database:   "default",
 		Timeout:    30,
 		MaxRetries: 3,
+		MetricType: repository.MetricTypeCosine,
 	}
 
-	// 可以从配置文件覆盖设置
-	// return &vector.MilvusConfig{
-	//     Host:       config.Vector.Host,
-	//     Port:       config.Vector.Port,
-	//     Username:   config.Vector.Username,
-	//     Password:   config.Vector.Password,
-	//     Database:   config.Vector.Database,
-	//     Timeout:    config.Vector.Timeout,
-	//     MaxRetries: config.Vector.MaxRetries,
-	// }
+	if host := os.Getenv("MILVUS_HOST"); host != "" {
+		milvusConfig.Host = host
+	}
+	if port, err := strconv.Atoi(os.Getenv("MILVUS_PORT")); err == nil && port > 0 {
+		milvusConfig.Port = port
+	}
+	if username := os.Getenv("MILVUS_USERNAME"); username != "" {
+		milvusConfig.Username = username
+	}
+	if password := os.Getenv("MILVUS_PASSWORD"); password != "" {
+		milvusConfig.Password = password
+	}
+	if database := os.Getenv("MILVUS_DATABASE"); database != "" {
+		milvusConfig.Database = database
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("MILVUS_TIMEOUT")); err == nil && timeout >= time.Second {
+		milvusConfig.Timeout = int(timeout / time.Second)
+	}
+	if maxRetries, err := strconv.Atoi(os.Getenv("MILVUS_MAX_RETRIES")); err == nil && maxRetries >= 0 {
+		milvusConfig.MaxRetries = maxRetries
+	}
+	switch metricType := repository.MetricType(strings.ToLower(os.Getenv("MILVUS_METRIC_TYPE"))); metricType {
+	case repository.MetricTypeCosine, repository.MetricTypeEuclidean, repository.MetricTypeDotProduct:
+		milvusConfig.MetricType = metricType
+	}
+
+	return milvusConfig
+}
+
+// NewVectorRepository 根据环境变量RAG_VECTOR_STORE选择向量存储：milvus（默认）或pgvector
+func NewVectorRepository(
+	milvusConfig *vector.MilvusConfig,
+	pgVectorConfig *vector.PgVectorConfig,
+	db *gorm.DB,
+	logger infrastructure.Logger,
+) (repository.VectorRepository, func(), error) {
+	switch store := strings.ToLower(os.Getenv("RAG_VECTOR_STORE")); store {
+	case "", "milvus":
+		repo, cleanup := vector.NewMilvusVectorRepository(milvusConfig, logger)
+		return repo, cleanup, nil
+	case "pgvector":
+		return vector.NewPgVectorRepository(db, pgVectorConfig, logger), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported vector store: %s", store)
+	}
+}
+
+// NewPgVectorConfig 创建pgvector配置，支持通过环境变量覆盖
+func NewPgVectorConfig() *vector.PgVectorConfig {
+	pgVectorConfig := vector.DefaultPgVectorConfig()
+
+	if indexType := strings.ToLower(os.Getenv("PGVECTOR_INDEX_TYPE")); indexType == vector.PgVectorIndexHNSW || indexType == vector.PgVectorIndexIVFFlat {
+		pgVectorConfig.IndexType = indexType
+	}
+	if m, err := strconv.Atoi(os.Getenv("PGVECTOR_HNSW_M")); err == nil && m > 1 {
+		pgVectorConfig.HNSWM = m
+	}
+	if efConstruction, err := strconv.Atoi(os.Getenv("PGVECTOR_HNSW_EF_CONSTRUCTION")); err == nil && efConstruction > 0 {
+		pgVectorConfig.HNSWEfConstruction = efConstruction
+	}
+	if efSearch, err := strconv.Atoi(os.Getenv("PGVECTOR_HNSW_EF_SEARCH")); err == nil && efSearch > 0 {
+		pgVectorConfig.HNSWEfSearch = efSearch
+	}
+	if lists, err := strconv.Atoi(os.Getenv("PGVECTOR_IVFFLAT_LISTS")); err == nil && lists > 0 {
+		pgVectorConfig.IVFFlatLists = lists
+	}
+	if probes, err := strconv.Atoi(os.Getenv("PGVECTOR_IVFFLAT_PROBES")); err == nil && probes > 0 {
+		pgVectorConfig.IVFFlatProbes = probes
+	}
+
+	return pgVectorConfig
 }
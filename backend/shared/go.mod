@@ -13,14 +13,19 @@ require (
 	github.com/prometheus/client_golang v1.17.0
 	github.com/google/wire v0.5.0
 	go.etcd.io/etcd/clientv3 v3.5.10
+	go.etcd.io/etcd/server/v3 v3.5.10
 	go.opentelemetry.io/otel v1.21.0
 	go.opentelemetry.io/otel/exporters/jaeger v1.17.0
 	go.opentelemetry.io/otel/sdk v1.21.0
 	go.opentelemetry.io/otel/trace v1.21.0
 	go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin v0.46.1
 	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.46.1
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.46.1
 	go.opentelemetry.io/contrib/instrumentation/gorm.io/driver/postgres/otelpgx v0.46.1
 	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.21.0
 	google.golang.org/grpc v1.59.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d
 	github.com/IBM/sarama v1.42.1
+	github.com/redis/go-redis/v9 v9.3.0
+	golang.org/x/net v0.18.0
 )
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound 缓存键不存在或已过期
+var ErrNotFound = errors.New("cache: key not found")
+
+// 缓存后端类型
+const (
+	BackendMemory = "memory" // 进程内LRU缓存
+	BackendRedis  = "redis"  // Redis缓存，多实例共享
+)
+
+// Cache 缓存接口，各模块的缓存需求（嵌入缓存、检索缓存、限流计数等）统一使用该接口
+type Cache interface {
+	// Get 获取缓存值，不存在时返回ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set 设置缓存值，ttl小于等于0时使用默认过期时间
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存，键不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// Incr 原子增加计数并返回新值，键不存在时从0开始并设置ttl
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	// Close 释放缓存资源
+	Close() error
+}
+
+// Config 缓存配置
+type Config struct {
+	Backend    string        `mapstructure:"backend" yaml:"backend"`         // memory 或 redis
+	MaxEntries int           `mapstructure:"max_entries" yaml:"max_entries"` // 内存缓存的最大条目数
+	DefaultTTL time.Duration `mapstructure:"default_ttl" yaml:"default_ttl"` // 默认过期时间，0表示不过期
+	Prefix     string        `mapstructure:"prefix" yaml:"prefix"`           // 键前缀，用于区分服务
+}
+
+// RedisConfig Redis连接配置，与配置文件中的redis段对应
+type RedisConfig struct {
+	Addr         string        `mapstructure:"addr" yaml:"addr"`
+	Password     string        `mapstructure:"password" yaml:"password"`
+	DB           int           `mapstructure:"db" yaml:"db"`
+	PoolSize     int           `mapstructure:"pool_size" yaml:"pool_size"`
+	MinIdleConns int           `mapstructure:"min_idle_conns" yaml:"min_idle_conns"`
+	DialTimeout  time.Duration `mapstructure:"dial_timeout" yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout" yaml:"write_timeout"`
+}
+
+// DefaultConfig 默认缓存配置
+func DefaultConfig() Config {
+	return Config{
+		Backend:    BackendMemory,
+		MaxEntries: 10000,
+		DefaultTTL: 10 * time.Minute,
+	}
+}
+
+// New 根据配置创建缓存，返回的清理函数用于关闭缓存
+func New(config Config, redisConfig RedisConfig) (Cache, func(), error) {
+	defaults := DefaultConfig()
+	if config.Backend == "" {
+		config.Backend = defaults.Backend
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaults.MaxEntries
+	}
+
+	var (
+		c   Cache
+		err error
+	)
+	switch config.Backend {
+	case BackendMemory:
+		c = NewMemoryCache(config.MaxEntries, config.DefaultTTL)
+	case BackendRedis:
+		c, err = NewRedisCache(redisConfig, config.DefaultTTL)
+	default:
+		err = fmt.Errorf("cache: unsupported backend %q", config.Backend)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config.Prefix != "" {
+		c = WithPrefix(c, config.Prefix)
+	}
+
+	cleanup := func() {
+		_ = c.Close()
+	}
+	return c, cleanup, nil
+}
+
+// GetJSON 获取缓存并反序列化为JSON
+func GetJSON(ctx context.Context, c Cache, key string, value interface{}) error {
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// SetJSON 序列化为JSON后写入缓存
+func SetJSON(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cache: failed to marshal value: %w", err)
+	}
+	return c.Set(ctx, key, data, ttl)
+}
+
+// prefixCache 为所有键添加前缀
+type prefixCache struct {
+	Cache
+	prefix string
+}
+
+// WithPrefix 返回为所有键添加前缀的缓存，用于在共享后端中隔离不同模块
+func WithPrefix(c Cache, prefix string) Cache {
+	return &prefixCache{Cache: c, prefix: prefix}
+}
+
+func (c *prefixCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.Cache.Get(ctx, c.prefix+key)
+}
+
+func (c *prefixCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.Cache.Set(ctx, c.prefix+key, value, ttl)
+}
+
+func (c *prefixCache) Delete(ctx context.Context, key string) error {
+	return c.Cache.Delete(ctx, c.prefix+key)
+}
+
+func (c *prefixCache) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Cache.Incr(ctx, c.prefix+key, delta, ttl)
+}
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryCache 进程内LRU缓存
+// 超过最大条目数时淘汰最久未访问的条目，过期条目在访问时惰性删除
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	defaultTTL time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	now        func() time.Time
+}
+
+// memoryEntry 缓存条目
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // 零值表示不过期
+}
+
+// NewMemoryCache 创建内存缓存，maxEntries小于等于0时不限制条目数
+func NewMemoryCache(maxEntries int, defaultTTL time.Duration) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		defaultTTL: defaultTTL,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get 获取缓存值
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.lookup(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	value := make([]byte, len(entry.value))
+	copy(value, entry.value)
+	return value, nil
+}
+
+// Set 设置缓存值
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored := make([]byte, len(value))
+	copy(stored, value)
+	c.store(key, stored, c.expiresAt(ttl))
+	return nil
+}
+
+// Delete 删除缓存
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	return nil
+}
+
+// Incr 原子增加计数，ttl只在键创建时设置
+func (c *MemoryCache) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.lookup(key)
+	if !ok {
+		c.store(key, []byte(strconv.FormatInt(delta, 10)), c.expiresAt(ttl))
+		return delta, nil
+	}
+
+	current, err := strconv.ParseInt(string(entry.value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cache: value of %q is not an integer", key)
+	}
+
+	current += delta
+	entry.value = []byte(strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+// Len 当前条目数（包含尚未清理的过期条目）
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Close 清空缓存
+func (c *MemoryCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	return nil
+}
+
+// lookup 查找未过期的条目并标记为最近访问
+func (c *MemoryCache) lookup(key string) (*memoryEntry, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+	return entry, true
+}
+
+// store 写入条目，超出容量时淘汰最久未访问的条目
+func (c *MemoryCache) store(key string, value []byte, expiresAt time.Time) {
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// removeElement 删除条目
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*memoryEntry).key)
+}
+
+// expiresAt 计算过期时间
+func (c *MemoryCache) expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return c.now().Add(ttl)
+}
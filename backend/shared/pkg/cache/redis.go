@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript 原子增加计数，键首次创建时设置过期时间
+var incrScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// RedisCache Redis缓存，多实例之间共享缓存和计数
+type RedisCache struct {
+	client     *redis.Client
+	defaultTTL time.Duration
+}
+
+// NewRedisCache 创建Redis缓存并检查连接
+func NewRedisCache(config RedisConfig, defaultTTL time.Duration) (*RedisCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         config.Addr,
+		Password:     config.Password,
+		DB:           config.DB,
+		PoolSize:     config.PoolSize,
+		MinIdleConns: config.MinIdleConns,
+		DialTimeout:  config.DialTimeout,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("cache: failed to connect to redis at %s: %w", config.Addr, err)
+	}
+
+	return NewRedisCacheWithClient(client, defaultTTL), nil
+}
+
+// NewRedisCacheWithClient 使用已有的Redis客户端创建缓存
+func NewRedisCacheWithClient(client *redis.Client, defaultTTL time.Duration) *RedisCache {
+	return &RedisCache{
+		client:     client,
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Get 获取缓存值
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// Set 设置缓存值
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, c.ttl(ttl)).Err()
+}
+
+// Delete 删除缓存
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key).Err()
+}
+
+// Incr 原子增加计数，ttl只在键创建时设置
+func (c *RedisCache) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, c.client, []string{key}, delta, c.ttl(ttl).Milliseconds()).Int64()
+}
+
+// Close 关闭Redis连接
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+// ttl 计算过期时间，0表示不过期
+func (c *RedisCache) ttl(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return c.defaultTTL
+	}
+	return ttl
+}
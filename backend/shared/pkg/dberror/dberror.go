@@ -0,0 +1,22 @@
+// Package dberror 识别数据库驱动返回的通用错误，供各模块仓储实现转换为自己的仓储错误
+package dberror
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation PostgreSQL唯一约束冲突的错误码
+const pgUniqueViolation = "23505"
+
+// IsDuplicateKey 判断是否为唯一约束冲突
+// 开启TranslateError时GORM返回gorm.ErrDuplicatedKey，否则检查驱动错误的SQLSTATE
+func IsDuplicateKey(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == pgUniqueViolation
+}
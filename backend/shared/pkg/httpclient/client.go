@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/retry"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Config 出站HTTP客户端配置
+type Config struct {
+	Timeout             time.Duration // 整个调用（含重试）的超时，0表示只受上下文限制
+	DialTimeout         time.Duration // 建立连接超时
+	MaxIdleConns        int           // 连接池最大空闲连接数
+	MaxIdleConnsPerHost int           // 每个主机最大空闲连接数
+	MaxConnsPerHost     int           // 每个主机最大连接数，0表示不限制
+	IdleConnTimeout     time.Duration // 空闲连接保留时间
+
+	// Retry 重试策略，只对幂等请求和可重放请求体的请求生效；AttemptTimeout不生效，超时由Timeout控制
+	Retry retry.Policy
+}
+
+// DefaultConfig 默认配置：30秒超时，最多尝试3次
+func DefaultConfig() Config {
+	return Config{
+		Timeout:             30 * time.Second,
+		DialTimeout:         5 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		Retry:               retry.DefaultPolicy(),
+	}
+}
+
+// New 创建出站HTTP客户端
+// 客户端使用共享连接池，每次尝试都会创建客户端Span并向下游传播追踪上下文；
+// 网络错误和临时状态码（超时、限流、5xx）按重试策略重试
+func New(config Config) *http.Client {
+	base := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	policy := config.Retry
+	// 单次超时会在返回响应后取消上下文，导致无法读取响应体
+	policy.AttemptTimeout = 0
+
+	return &http.Client{
+		Timeout: config.Timeout,
+		Transport: &retryTransport{
+			next:   otelhttp.NewTransport(base),
+			policy: policy,
+		},
+	}
+}
+
+// retryTransport 可重试的RoundTripper
+type retryTransport struct {
+	next   http.RoundTripper
+	policy retry.Policy
+}
+
+// transientStatusError 临时错误状态码
+type transientStatusError struct {
+	statusCode int
+}
+
+func (e *transientStatusError) Error() string {
+	return fmt.Sprintf("transient http status %d", e.statusCode)
+}
+
+// RoundTrip 执行请求，失败时按策略重试；重试耗尽时返回最后一次的响应
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.policy.MaxAttempts <= 1 || !isReplayable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	var last *http.Response
+	err := retry.Do(req.Context(), t.policy, func(ctx context.Context) error {
+		if last != nil {
+			drainAndClose(last.Body)
+			last = nil
+		}
+
+		attempt := req.Clone(ctx)
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return retry.Permanent(err)
+			}
+			attempt.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attempt)
+		if err != nil {
+			return err
+		}
+		last = resp
+		if retry.IsTransientStatus(resp.StatusCode) {
+			return &transientStatusError{statusCode: resp.StatusCode}
+		}
+		return nil
+	})
+
+	var statusErr *transientStatusError
+	if err == nil || errors.As(err, &statusErr) {
+		return last, nil
+	}
+	if last != nil {
+		drainAndClose(last.Body)
+	}
+	return nil, err
+}
+
+// isReplayable 判断请求能否安全重试：方法幂等且请求体可重放
+func isReplayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		// 非幂等请求可通过Idempotency-Key声明可重试
+		if req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// drainAndClose 读完并关闭响应体以复用连接
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
@@ -0,0 +1,165 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// serviceConfigKeyPrefix 服务运行时配置键前缀
+	serviceConfigKeyPrefix = "/noah-loop/config/"
+	// configRewatchInterval 监听中断（如历史版本已压缩）后重新监听的间隔
+	configRewatchInterval = 5 * time.Second
+)
+
+// ServiceConfigKey 服务运行时配置在etcd中的键
+func ServiceConfigKey(service string) string {
+	return serviceConfigKeyPrefix + service
+}
+
+// LiveConfig 可热更新的配置，读取方通过Load获取完整快照，
+// 快照替换而不修改，读取期间不会看到更新了一半的配置
+type LiveConfig struct {
+	current atomic.Pointer[infrastructure.Config]
+	base    *infrastructure.Config
+}
+
+// NewLiveConfig 以启动时从配置文件加载的配置创建，etcd中的配置删除后恢复为该配置
+func NewLiveConfig(base *infrastructure.Config) *LiveConfig {
+	c := &LiveConfig{base: base}
+	c.current.Store(base)
+	return c
+}
+
+// Load 当前配置快照，调用方不得修改
+func (c *LiveConfig) Load() *infrastructure.Config {
+	return c.current.Load()
+}
+
+// Store 替换当前配置
+func (c *LiveConfig) Store(config *infrastructure.Config) {
+	c.current.Store(config)
+}
+
+// overlay 将YAML（兼容JSON）格式的配置覆盖到启动配置的副本上，未出现的字段保持启动配置的值
+func (c *LiveConfig) overlay(value []byte) (*infrastructure.Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(value)); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	next := *c.base
+	if err := v.Unmarshal(&next); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	return &next, nil
+}
+
+// WatchConfig 监听etcd中key的配置变更，更新live并调用onChange，服务无需重启即可调整日志级别等可变配置
+// 启动时先读取一次已发布的配置；值为启动配置之上的覆盖项，删除key后恢复启动配置；
+// 解析失败时保留当前配置；监听端口等启动后不再读取的配置修改后仍需重启生效。
+// onChange在监听协程中串行调用，配置没有实际变化时不调用；ctx结束时停止监听
+func (cm *ConfigManager) WatchConfig(ctx context.Context, key string, live *LiveConfig, onChange func(*infrastructure.Config)) error {
+	return cm.watch(ctx, cm.client.GetClient(), key, live, onChange)
+}
+
+// watch 使用client读取并监听配置
+func (cm *ConfigManager) watch(ctx context.Context, client *clientv3.Client, key string, live *LiveConfig, onChange func(*infrastructure.Config)) error {
+	revision, err := cm.loadConfig(ctx, client, key, live, onChange)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			cm.watchConfig(ctx, client, key, revision, live, onChange)
+
+			// 监听中断（历史版本已压缩、连接重建失败等），稍后重新读取并继续监听
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(configRewatchInterval):
+			}
+			rev, err := cm.loadConfig(ctx, client, key, live, onChange)
+			if err != nil {
+				cm.logger.Warn("Failed to reload config from etcd", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			revision = rev
+		}
+	}()
+
+	return nil
+}
+
+// loadConfig 读取当前配置并应用，返回读取时的版本号，从下一个版本开始监听
+func (cm *ConfigManager) loadConfig(ctx context.Context, client *clientv3.Client, key string, live *LiveConfig, onChange func(*infrastructure.Config)) (int64, error) {
+	resp, err := client.Get(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get config %s: %w", key, err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		cm.applyConfig(key, live, live.base, onChange)
+	} else {
+		cm.applyValue(key, live, resp.Kvs[0].Value, onChange)
+	}
+	return resp.Header.Revision, nil
+}
+
+// watchConfig 从revision之后监听配置变更，监听结束时返回
+func (cm *ConfigManager) watchConfig(ctx context.Context, client *clientv3.Client, key string, revision int64, live *LiveConfig, onChange func(*infrastructure.Config)) {
+	var opts []clientv3.OpOption
+	if revision > 0 {
+		opts = append(opts, clientv3.WithRev(revision+1))
+	}
+
+	for resp := range client.Watch(ctx, key, opts...) {
+		if err := resp.Err(); err != nil {
+			cm.logger.Warn("Config watch interrupted", zap.String("key", key), zap.Error(err))
+			return
+		}
+		for _, event := range resp.Events {
+			switch event.Type {
+			case clientv3.EventTypePut:
+				cm.applyValue(key, live, event.Kv.Value, onChange)
+			case clientv3.EventTypeDelete:
+				cm.logger.Info("Config removed from etcd, restoring startup config", zap.String("key", key))
+				cm.applyConfig(key, live, live.base, onChange)
+			}
+		}
+	}
+}
+
+// applyValue 解析并应用etcd中的配置值
+func (cm *ConfigManager) applyValue(key string, live *LiveConfig, value []byte, onChange func(*infrastructure.Config)) {
+	next, err := live.overlay(value)
+	if err != nil {
+		cm.logger.Error("Invalid config in etcd, keeping current config", zap.String("key", key), zap.Error(err))
+		return
+	}
+	cm.applyConfig(key, live, next, onChange)
+}
+
+// applyConfig 替换当前配置并通知变更
+func (cm *ConfigManager) applyConfig(key string, live *LiveConfig, next *infrastructure.Config, onChange func(*infrastructure.Config)) {
+	if reflect.DeepEqual(live.Load(), next) {
+		return
+	}
+
+	live.Store(next)
+	cm.logger.Info("Config reloaded from etcd", zap.String("key", key))
+	if onChange != nil {
+		onChange(next)
+	}
+}
@@ -0,0 +1,106 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// configRecorder 记录onChange收到的日志级别
+type configRecorder struct {
+	mu     sync.Mutex
+	levels []string
+}
+
+func (r *configRecorder) onChange(config *infrastructure.Config) {
+	r.mu.Lock()
+	r.levels = append(r.levels, config.Log.Level)
+	r.mu.Unlock()
+}
+
+// waitFor 等待onChange被调用n次，返回收到的日志级别
+func (r *configRecorder) waitFor(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mu.Lock()
+		levels := append([]string(nil), r.levels...)
+		r.mu.Unlock()
+		if len(levels) >= n {
+			return levels
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("onChange called with %v, want %d calls", levels, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchConfigReloadsPublishedConfig(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	client := newTestClient(t, endpoint)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	base := &infrastructure.Config{}
+	base.App.Name = "agent"
+	base.Log.Level = "info"
+	key := ServiceConfigKey("agent")
+	if _, err := client.Put(ctx, key, "log:\n  level: warn\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 启动时读取已发布的配置，未出现的字段保持启动配置的值
+	cm := &ConfigManager{logger: zap.NewNop()}
+	live := NewLiveConfig(base)
+	recorder := &configRecorder{}
+	if err := cm.watch(ctx, client, key, live, recorder.onChange); err != nil {
+		t.Fatal(err)
+	}
+	if config := live.Load(); config.Log.Level != "warn" || config.App.Name != "agent" {
+		t.Fatalf("config after start = %+v", config)
+	}
+
+	// 发布新值触发回调；没有变化和无法解析的值不触发
+	for _, value := range []string{"log:\n  level: debug\n", "log:\n  level: debug\n", "log: [bad", `{"log": {"level": "error"}}`} {
+		if _, err := client.Put(ctx, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder.waitFor(t, 3)
+
+	// 删除后恢复启动配置
+	if _, err := client.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	levels := recorder.waitFor(t, 4)
+	if want := []string{"warn", "debug", "error", "info"}; !reflect.DeepEqual(levels, want) {
+		t.Fatalf("onChange levels = %v, want %v", levels, want)
+	}
+	if live.Load() != base || base.Log.Level != "info" {
+		t.Fatalf("config after delete = %+v, want the unmodified startup config", live.Load())
+	}
+}
+
+func TestLiveConfigOverlayKeepsBase(t *testing.T) {
+	base := &infrastructure.Config{}
+	base.App.Name = "agent"
+	base.Log.Level = "info"
+	live := NewLiveConfig(base)
+
+	next, err := live.overlay([]byte("log:\n  level: debug\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Log.Level != "debug" || next.App.Name != "agent" || base.Log.Level != "info" {
+		t.Fatalf("overlay = %+v, base = %+v", next, base)
+	}
+	if _, err := live.overlay([]byte("log: [bad")); err == nil {
+		t.Fatal("invalid config should be rejected")
+	}
+}
@@ -0,0 +1,26 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// healthCheckKey 连通性检查读取的键，只统计数量，不要求键存在
+const healthCheckKey = "/noah-loop/health"
+
+// HealthCheck 创建etcd连通性检查，可作为readiness.Check添加到就绪闸门
+// 使用线性一致读，集群失去多数派时同样判定为失败
+func HealthCheck(client *Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if client == nil {
+			return errors.New("not configured")
+		}
+		if _, err := client.GetClient().Get(ctx, healthCheckKey, clientv3.WithCountOnly()); err != nil {
+			return fmt.Errorf("etcd unreachable: %w", err)
+		}
+		return nil
+	}
+}
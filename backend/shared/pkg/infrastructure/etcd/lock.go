@@ -0,0 +1,272 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// LockKeyPrefix 分布式锁键前缀
+	LockKeyPrefix = "/noah-loop/locks/"
+	// DefaultLockTTL 默认锁租约时长，持有者异常退出后最多经过该时间锁自动释放
+	DefaultLockTTL = 30 * time.Second
+	// lockReleaseTimeout 释放锁时撤销租约的超时时间
+	lockReleaseTimeout = 5 * time.Second
+)
+
+// ErrLockNotHeld 释放当前实例未持有的锁
+var ErrLockNotHeld = errors.New("etcd: lock is not held")
+
+// DistributedLock 基于etcd租约和CAS的分布式锁，用于多副本部署时只由一个实例执行定时任务
+// 获取锁时为锁键绑定租约，只有键不存在（CreateRevision为0）时写入成功；持有期间持续续约，
+// 持有者异常退出后租约过期，锁自动释放
+type DistributedLock struct {
+	client *clientv3.Client
+	owner  string
+	ttl    time.Duration
+	logger infrastructure.Logger
+
+	mu   sync.Mutex
+	held map[string]*heldLock
+}
+
+// heldLock 当前实例持有的锁
+type heldLock struct {
+	leaseID clientv3.LeaseID
+	cancel  context.CancelFunc // 停止续约
+	done    chan struct{}      // 锁释放或续约失败（租约过期、etcd不可达）时关闭
+}
+
+// NewDistributedLock 创建分布式锁，持有者标识由主机名、进程号和随机ID组成
+func NewDistributedLock(client *Client, logger infrastructure.Logger) *DistributedLock {
+	hostname, _ := os.Hostname()
+	return &DistributedLock{
+		client: client.GetClient(),
+		owner:  fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()),
+		ttl:    DefaultLockTTL,
+		logger: logger,
+		held:   make(map[string]*heldLock),
+	}
+}
+
+// SetTTL 设置锁租约时长，不足1秒时按1秒计算，只影响之后获取的锁
+func (l *DistributedLock) SetTTL(ttl time.Duration) {
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	l.mu.Lock()
+	l.ttl = ttl
+	l.mu.Unlock()
+}
+
+// Owner 持有者标识，即锁键的值
+func (l *DistributedLock) Owner() string {
+	return l.owner
+}
+
+// TryLock 尝试获取锁，不等待；锁已被其他实例或本实例持有时返回false
+func (l *DistributedLock) TryLock(ctx context.Context, key string) (bool, error) {
+	h, err := l.tryLock(ctx, key)
+	return h != nil, err
+}
+
+// tryLock 获取锁并开始续约，未获取到时返回nil
+func (l *DistributedLock) tryLock(ctx context.Context, key string) (*heldLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.held[key]; ok {
+		return nil, nil
+	}
+
+	lease, err := l.client.Grant(ctx, int64(l.ttl/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant lock lease: %w", err)
+	}
+
+	lockKey := LockKeyPrefix + key
+	resp, err := l.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(lockKey), "=", 0)).
+		Then(clientv3.OpPut(lockKey, l.owner, clientv3.WithLease(lease.ID))).
+		Commit()
+	if err != nil {
+		l.revoke(lease.ID)
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !resp.Succeeded {
+		l.revoke(lease.ID)
+		return nil, nil
+	}
+
+	keepAliveCtx, cancel := context.WithCancel(context.Background())
+	keepAlive, err := l.client.KeepAlive(keepAliveCtx, lease.ID)
+	if err != nil {
+		cancel()
+		l.revoke(lease.ID)
+		return nil, fmt.Errorf("failed to keep lock lease alive: %w", err)
+	}
+
+	h := &heldLock{
+		leaseID: lease.ID,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	l.held[key] = h
+	go l.watchKeepAlive(keepAliveCtx, key, h, keepAlive)
+
+	l.logger.Debug("Distributed lock acquired", zap.String("key", key), zap.String("owner", l.owner))
+	return h, nil
+}
+
+// watchKeepAlive 消费续约响应，续约通道关闭说明锁已释放或租约丢失
+func (l *DistributedLock) watchKeepAlive(ctx context.Context, key string, h *heldLock, keepAlive <-chan *clientv3.LeaseKeepAliveResponse) {
+	for range keepAlive {
+	}
+
+	if ctx.Err() == nil {
+		l.logger.Warn("Distributed lock lost, lease keep-alive stopped", zap.String("key", key))
+	}
+
+	l.mu.Lock()
+	if l.held[key] == h {
+		delete(l.held, key)
+	}
+	l.mu.Unlock()
+	close(h.done)
+}
+
+// Unlock 释放锁，撤销租约后锁键随之删除
+func (l *DistributedLock) Unlock(ctx context.Context, key string) error {
+	l.mu.Lock()
+	h, ok := l.held[key]
+	if ok {
+		delete(l.held, key)
+	}
+	l.mu.Unlock()
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	h.cancel()
+	if _, err := l.client.Revoke(ctx, h.leaseID); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", key, err)
+	}
+
+	l.logger.Debug("Distributed lock released", zap.String("key", key))
+	return nil
+}
+
+// WithLock 获取到锁时执行fn并在结束后释放，未获取到时不执行并返回false
+// 执行期间锁丢失（租约过期）时取消fn的上下文
+func (l *DistributedLock) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error) {
+	h, err := l.tryLock(ctx, key)
+	if err != nil || h == nil {
+		return false, err
+	}
+	defer l.release(key)
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-h.done:
+			cancel()
+		case <-fnCtx.Done():
+		}
+	}()
+
+	return true, fn(fnCtx)
+}
+
+// RunPeriodic 按间隔执行定时任务，多个实例中只有持有锁的实例执行
+// 持有者一直持有锁并续约，其他实例每个周期尝试获取一次，持有者退出或锁丢失后由其他实例接替；ctx结束时释放锁
+func (l *DistributedLock) RunPeriodic(ctx context.Context, key string, interval time.Duration, task func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer l.release(key)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h := l.holding(key)
+		if h == nil {
+			acquired, err := l.tryLock(ctx, key)
+			if err != nil {
+				l.logger.Warn("Failed to acquire task lock", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			if acquired == nil {
+				continue
+			}
+			l.logger.Info("Acquired task lock, running periodic task on this instance", zap.String("key", key))
+			h = acquired
+		}
+
+		taskCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-h.done:
+				cancel()
+			case <-taskCtx.Done():
+			}
+		}()
+		task(taskCtx)
+		cancel()
+	}
+}
+
+// Close 释放当前实例持有的所有锁，服务关闭时调用，其他实例无需等待租约过期即可接替
+func (l *DistributedLock) Close(ctx context.Context) error {
+	l.mu.Lock()
+	keys := make([]string, 0, len(l.held))
+	for key := range l.held {
+		keys = append(keys, key)
+	}
+	l.mu.Unlock()
+
+	var errs []error
+	for _, key := range keys {
+		if err := l.Unlock(ctx, key); err != nil && !errors.Is(err, ErrLockNotHeld) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// holding 返回当前实例持有的锁
+func (l *DistributedLock) holding(key string) *heldLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held[key]
+}
+
+// release 释放锁，记录除未持有外的错误
+func (l *DistributedLock) release(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockReleaseTimeout)
+	defer cancel()
+	if err := l.Unlock(ctx, key); err != nil && !errors.Is(err, ErrLockNotHeld) {
+		l.logger.Warn("Failed to release distributed lock", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// revoke 撤销未使用的租约
+func (l *DistributedLock) revoke(leaseID clientv3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockReleaseTimeout)
+	defer cancel()
+	if _, err := l.client.Revoke(ctx, leaseID); err != nil {
+		l.logger.Warn("Failed to revoke lock lease", zap.Error(err))
+	}
+}
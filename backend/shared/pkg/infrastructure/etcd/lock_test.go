@@ -0,0 +1,279 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.uber.org/zap"
+)
+
+// testLockTTL 测试使用的锁租约时长，etcd会把过短的租约提升到最小值（约2秒）
+const testLockTTL = time.Second
+
+// freeURL 返回本机一个空闲端口的URL
+func freeURL(t *testing.T) url.URL {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return url.URL{Scheme: "http", Host: listener.Addr().String()}
+}
+
+// startEmbeddedEtcd 启动单节点嵌入式etcd，返回客户端地址
+func startEmbeddedEtcd(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("embedded etcd is slow, skipped in short mode")
+	}
+
+	clientURL, peerURL := freeURL(t), freeURL(t)
+	config := embed.NewConfig()
+	config.Dir = t.TempDir()
+	config.LogLevel = "error"
+	config.ListenClientUrls = []url.URL{clientURL}
+	config.AdvertiseClientUrls = []url.URL{clientURL}
+	config.ListenPeerUrls = []url.URL{peerURL}
+	config.AdvertisePeerUrls = []url.URL{peerURL}
+	config.InitialCluster = config.InitialClusterFromName(config.Name)
+
+	server, err := embed.StartEtcd(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("embedded etcd did not become ready")
+	}
+	return clientURL.String()
+}
+
+// newTestClient 创建连接到嵌入式etcd的客户端
+func newTestClient(t *testing.T, endpoint string) *clientv3.Client {
+	t.Helper()
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// newTestLocks 创建n个分布式锁，每个锁使用独立的客户端，模拟n个服务实例
+func newTestLocks(t *testing.T, endpoint string, n int) []*DistributedLock {
+	t.Helper()
+	locks := make([]*DistributedLock, n)
+	for i := range locks {
+		locks[i] = &DistributedLock{
+			client: newTestClient(t, endpoint),
+			owner:  fmt.Sprintf("instance-%d", i),
+			ttl:    testLockTTL,
+			logger: zap.NewNop(),
+			held:   make(map[string]*heldLock),
+		}
+	}
+	return locks
+}
+
+// lockOwner 返回锁键的值和租约，锁不存在时返回空值
+func lockOwner(t *testing.T, client *clientv3.Client, key string) (string, clientv3.LeaseID) {
+	t.Helper()
+	resp, err := client.Get(context.Background(), LockKeyPrefix+key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) == 0 {
+		return "", 0
+	}
+	return string(resp.Kvs[0].Value), clientv3.LeaseID(resp.Kvs[0].Lease)
+}
+
+func TestTryLockMutualExclusion(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	locks := newTestLocks(t, endpoint, 2)
+	ctx := context.Background()
+
+	if acquired, err := locks[0].TryLock(ctx, "cleanup"); err != nil || !acquired {
+		t.Fatalf("first TryLock = %v, %v", acquired, err)
+	}
+	if acquired, err := locks[1].TryLock(ctx, "cleanup"); err != nil || acquired {
+		t.Fatalf("second instance acquired a held lock: %v, %v", acquired, err)
+	}
+	if acquired, _ := locks[0].TryLock(ctx, "cleanup"); acquired {
+		t.Fatal("lock should not be reentrant")
+	}
+
+	// 持有期间续约，超过租约时长后仍被持有
+	time.Sleep(3 * time.Second)
+	if owner, _ := lockOwner(t, locks[1].client, "cleanup"); owner != locks[0].Owner() {
+		t.Fatalf("lock owner after the lease TTL = %q, want %q", owner, locks[0].Owner())
+	}
+	if acquired, _ := locks[1].TryLock(ctx, "cleanup"); acquired {
+		t.Fatal("second instance acquired the lock after the lease TTL")
+	}
+
+	if err := locks[1].Unlock(ctx, "cleanup"); err != ErrLockNotHeld {
+		t.Fatalf("unlocking a lock held elsewhere = %v, want ErrLockNotHeld", err)
+	}
+	if err := locks[0].Unlock(ctx, "cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, err := locks[1].TryLock(ctx, "cleanup"); err != nil || !acquired {
+		t.Fatalf("TryLock after unlock = %v, %v", acquired, err)
+	}
+}
+
+func TestWithLockMutualExclusion(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	locks := newTestLocks(t, endpoint, 4)
+
+	var running, peak, runs int32
+	var wg sync.WaitGroup
+	for _, lock := range locks {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(lock *DistributedLock) {
+				defer wg.Done()
+				_, err := lock.WithLock(context.Background(), "job", func(ctx context.Context) error {
+					n := atomic.AddInt32(&running, 1)
+					for {
+						p := atomic.LoadInt32(&peak)
+						if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+							break
+						}
+					}
+					atomic.AddInt32(&runs, 1)
+					time.Sleep(10 * time.Millisecond)
+					atomic.AddInt32(&running, -1)
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}(lock)
+		}
+	}
+	wg.Wait()
+
+	if peak != 1 {
+		t.Fatalf("%d instances ran the job at the same time", peak)
+	}
+	if runs == 0 {
+		t.Fatal("no instance ran the job")
+	}
+	if owner, _ := lockOwner(t, locks[0].client, "job"); owner != "" {
+		t.Fatalf("lock still held by %q after WithLock returned", owner)
+	}
+}
+
+func TestWithLockCancelledWhenLockLost(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	locks := newTestLocks(t, endpoint, 1)
+	admin := newTestClient(t, endpoint)
+
+	acquired, err := locks[0].WithLock(context.Background(), "job", func(ctx context.Context) error {
+		// 模拟租约丢失
+		_, leaseID := lockOwner(t, admin, "job")
+		if _, err := admin.Revoke(context.Background(), leaseID); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(10 * time.Second):
+			return fmt.Errorf("context not cancelled after the lock was lost")
+		}
+	})
+	if err != nil || !acquired {
+		t.Fatalf("WithLock = %v, %v", acquired, err)
+	}
+}
+
+func TestRunPeriodicSingleHolderAndFailover(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	locks := newTestLocks(t, endpoint, 3)
+
+	var counts [3]int32
+	cancels := make([]context.CancelFunc, len(locks))
+	var wg sync.WaitGroup
+	for i, lock := range locks {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels[i] = cancel
+		wg.Add(1)
+		go func(i int, lock *DistributedLock) {
+			defer wg.Done()
+			lock.RunPeriodic(ctx, "tasks", 50*time.Millisecond, func(ctx context.Context) {
+				atomic.AddInt32(&counts[i], 1)
+			})
+		}(i, lock)
+	}
+
+	// runningInstances 返回计数清零后一段时间内执行过任务的实例
+	runningInstances := func() []int {
+		for i := range counts {
+			atomic.StoreInt32(&counts[i], 0)
+		}
+		time.Sleep(500 * time.Millisecond)
+		var running []int
+		for i := range counts {
+			if atomic.LoadInt32(&counts[i]) > 0 {
+				running = append(running, i)
+			}
+		}
+		return running
+	}
+
+	running := runningInstances()
+	if len(running) != 1 {
+		t.Fatalf("instances running the task = %v, want exactly one", running)
+	}
+
+	// 持有者关闭后释放锁，由其他实例接替
+	holder := running[0]
+	cancels[holder]()
+	time.Sleep(200 * time.Millisecond)
+	running = runningInstances()
+	if len(running) != 1 || running[0] == holder {
+		t.Fatalf("instances running the task after the holder stopped = %v", running)
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	wg.Wait()
+	if owner, _ := lockOwner(t, locks[0].client, "tasks"); owner != "" {
+		t.Fatalf("lock still held by %q after shutdown", owner)
+	}
+}
+
+func TestDistributedLockClose(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	locks := newTestLocks(t, endpoint, 1)
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b"} {
+		if acquired, err := locks[0].TryLock(ctx, key); err != nil || !acquired {
+			t.Fatalf("TryLock(%s) = %v, %v", key, acquired, err)
+		}
+	}
+	if err := locks[0].Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if owner, _ := lockOwner(t, locks[0].client, key); owner != "" {
+			t.Fatalf("lock %s still held by %q after Close", key, owner)
+		}
+	}
+}
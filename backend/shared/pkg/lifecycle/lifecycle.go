@@ -0,0 +1,106 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// Manager 后台任务生命周期管理
+// 通过Go启动的任务共享同一个上下文，Stop时取消上下文并等待所有任务返回，
+// 保证服务关闭后不再有后台任务执行
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger infrastructure.Logger
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool
+	running map[string]int
+}
+
+// NewManager 创建后台任务管理器
+func NewManager(logger infrastructure.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logger,
+		running: make(map[string]int),
+	}
+}
+
+// Go 启动后台任务，fn应在ctx结束时尽快返回；已停止时不再启动，返回false
+// 任务panic时记录日志，不影响其他任务和服务进程
+func (m *Manager) Go(name string, fn func(ctx context.Context)) bool {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		m.logger.Warn("Background task not started, lifecycle is stopping", zap.String("task", name))
+		return false
+	}
+	m.running[name]++
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		defer m.done(name)
+		defer func() {
+			if r := recover(); r != nil {
+				m.logger.Error("Background task panicked", zap.String("task", name), zap.Any("panic", r))
+			}
+		}()
+		fn(m.ctx)
+	}()
+	return true
+}
+
+// done 任务返回后从运行列表中移除
+func (m *Manager) done(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running[name]--; m.running[name] <= 0 {
+		delete(m.running, name)
+	}
+}
+
+// Running 仍在运行的任务名，按名称排序
+func (m *Manager) Running() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stop 取消所有任务的上下文并等待其返回，之后调用Go不再启动任务
+// ctx结束时仍有任务未返回则返回错误，列出未返回的任务；重复调用时等待同一批任务
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks did not stop: %s: %w", strings.Join(m.Running(), ", "), ctx.Err())
+	}
+}
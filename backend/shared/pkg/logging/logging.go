@@ -0,0 +1,211 @@
+package logging
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AdminPath 查看和调整日志级别的管理接口路径
+const AdminPath = "/admin/loglevel"
+
+// level 进程内所有通过本包创建的日志器共享的动态级别，调整后立即对所有日志器生效
+var level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+// revert 临时调整级别后的自动恢复
+var revert struct {
+	mu       sync.Mutex
+	timer    *time.Timer
+	at       time.Time
+	previous zapcore.Level // 临时调整前的级别
+}
+
+// NewLogger 创建使用动态级别的zap日志器，initial为初始级别，format为json或console
+func NewLogger(initial, format string) (*zap.Logger, error) {
+	if initial != "" {
+		if err := SetLevel(initial); err != nil {
+			return nil, err
+		}
+	}
+
+	config := zap.NewProductionConfig()
+	if format == "console" {
+		config.Encoding = "console"
+		config.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	}
+	config.EncoderConfig.TimeKey = "timestamp"
+	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	config.Level = level
+	return config.Build()
+}
+
+// ProvideLogger 按配置创建使用动态级别的日志器，供wire注入
+func ProvideLogger(config *infrastructure.Config) (infrastructure.Logger, error) {
+	return NewLogger(config.Log.Level, config.Log.Format)
+}
+
+// Level 当前日志级别
+func Level() string {
+	return level.Level().String()
+}
+
+// SetLevel 调整日志级别，并取消尚未执行的自动恢复
+func SetLevel(text string) error {
+	parsed, err := zapcore.ParseLevel(strings.TrimSpace(text))
+	if err != nil {
+		return fmt.Errorf("invalid log level %q", text)
+	}
+
+	revert.mu.Lock()
+	defer revert.mu.Unlock()
+	stopRevert()
+	level.SetLevel(parsed)
+	return nil
+}
+
+// SetLevelFor 临时调整日志级别，duration后恢复为调整前的级别
+func SetLevelFor(text string, duration time.Duration) error {
+	parsed, err := zapcore.ParseLevel(strings.TrimSpace(text))
+	if err != nil {
+		return fmt.Errorf("invalid log level %q", text)
+	}
+
+	revert.mu.Lock()
+	defer revert.mu.Unlock()
+	// 连续临时调整时恢复为最初的级别
+	previous := level.Level()
+	if revert.timer != nil {
+		previous = revert.previous
+	}
+	stopRevert()
+	level.SetLevel(parsed)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		revert.mu.Lock()
+		defer revert.mu.Unlock()
+		// 期间级别被再次调整时不恢复
+		if revert.timer != timer {
+			return
+		}
+		level.SetLevel(previous)
+		revert.timer = nil
+	})
+	revert.timer = timer
+	revert.at = time.Now().Add(duration)
+	revert.previous = previous
+	return nil
+}
+
+// stopRevert 取消自动恢复，调用方需持有revert.mu
+func stopRevert() {
+	if revert.timer != nil {
+		revert.timer.Stop()
+		revert.timer = nil
+	}
+}
+
+// levelRequest 调整日志级别请求
+type levelRequest struct {
+	Level    string `json:"level"`
+	Duration string `json:"duration,omitempty"` // 如"15m"，为空表示不自动恢复
+}
+
+// levelResponse 日志级别响应
+type levelResponse struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Handler 日志级别管理接口：GET返回当前级别，POST或PUT调整级别
+// 请求需要携带 Authorization: Bearer <token>；token为空时接口关闭，所有请求返回403
+func Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin endpoint is disabled: no admin token configured"})
+			return
+		}
+		if !validToken(r, token) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid admin token"})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			var req levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+				return
+			}
+			if err := applyLevel(req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, currentLevel())
+	})
+}
+
+// Wrap 在next之前处理日志级别管理接口，其余请求交给next
+// 放在就绪闸门之外，服务未就绪时也可以调整级别排查启动问题
+func Wrap(next http.Handler, token string) http.Handler {
+	admin := Handler(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == AdminPath {
+			admin.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// applyLevel 按请求调整级别
+func applyLevel(req levelRequest) error {
+	if req.Duration == "" {
+		return SetLevel(req.Level)
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid duration %q", req.Duration)
+	}
+	return SetLevelFor(req.Level, duration)
+}
+
+// currentLevel 当前级别和自动恢复时间
+func currentLevel() levelResponse {
+	revert.mu.Lock()
+	defer revert.mu.Unlock()
+	response := levelResponse{Level: Level()}
+	if revert.timer != nil {
+		at := revert.at
+		response.RevertAt = &at
+	}
+	return response
+}
+
+// validToken 校验管理令牌
+func validToken(r *http.Request, token string) bool {
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
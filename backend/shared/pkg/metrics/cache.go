@@ -0,0 +1,33 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// 缓存查找结果
+const (
+	CacheResultHit  = "hit"
+	CacheResultMiss = "miss"
+)
+
+// cacheLookups 缓存查找次数，按结果区分，命中率 = hit / (hit + miss)
+var cacheLookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "noah_loop",
+		Name:      "cache_lookups_total",
+		Help:      "Number of cache lookups by cache name and result.",
+	},
+	[]string{"cache", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(cacheLookups)
+}
+
+// ObserveCacheLookups 记录一批缓存查找的命中和未命中次数
+func ObserveCacheLookups(cache string, hits, misses int) {
+	if hits > 0 {
+		cacheLookups.WithLabelValues(cache, CacheResultHit).Add(float64(hits))
+	}
+	if misses > 0 {
+		cacheLookups.WithLabelValues(cache, CacheResultMiss).Add(float64(misses))
+	}
+}
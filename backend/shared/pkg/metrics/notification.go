@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 单个接收者的发送结果
+const (
+	NotificationSendSuccess   = "success"   // 发送成功
+	NotificationSendFailed    = "failed"    // 发送失败，不再重试
+	NotificationSendRetryable = "retryable" // 临时失败，等待重试
+	NotificationSendDeferred  = "deferred"  // 处于免打扰时段或被渠道限流，延后发送
+)
+
+// NotificationMetrics 通知服务的领域指标
+type NotificationMetrics struct {
+	created      *prometheus.CounterVec
+	sends        *prometheus.CounterVec
+	sendDuration *prometheus.HistogramVec
+	retries      *prometheus.CounterVec
+}
+
+// NewNotificationMetrics 创建通知指标并注册到registerer，registerer为nil时注册到默认注册表（由 /metrics 暴露）
+func NewNotificationMetrics(registerer prometheus.Registerer) (*NotificationMetrics, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	m := &NotificationMetrics{
+		created: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "noah_loop",
+				Name:      "notifications_created_total",
+				Help:      "Number of notifications created, by channel and notification type.",
+			},
+			[]string{"channel", "type"},
+		),
+		sends: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "noah_loop",
+				Name:      "notification_sends_total",
+				Help:      "Number of sends to individual recipients, by channel and result.",
+			},
+			[]string{"channel", "status"},
+		),
+		// 发送耗时为调用渠道提供商的时间，不含排队和模板渲染
+		sendDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "notification_send_duration_seconds",
+				Help:      "Duration of sends to individual recipients through channel providers.",
+				Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"channel", "status"},
+		),
+		retries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "noah_loop",
+				Name:      "notification_retries_total",
+				Help:      "Number of recipients reset for another send attempt, by channel.",
+			},
+			[]string{"channel"},
+		),
+	}
+
+	for _, collector := range []prometheus.Collector{m.created, m.sends, m.sendDuration, m.retries} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ObserveCreated 记录一条新创建的通知
+func (m *NotificationMetrics) ObserveCreated(channel, notificationType string) {
+	m.created.WithLabelValues(channel, notificationType).Inc()
+}
+
+// ObserveSend 记录一次发送给单个接收者的结果和耗时
+func (m *NotificationMetrics) ObserveSend(channel, status string, duration time.Duration) {
+	m.sends.WithLabelValues(channel, status).Inc()
+	m.sendDuration.WithLabelValues(channel, status).Observe(duration.Seconds())
+}
+
+// ObserveRetries 记录重新发送的接收者数
+func (m *NotificationMetrics) ObserveRetries(channel string, recipients int) {
+	if recipients > 0 {
+		m.retries.WithLabelValues(channel).Add(float64(recipients))
+	}
+}
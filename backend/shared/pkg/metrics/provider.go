@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/retry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 外部提供商调用结果
+const (
+	ProviderStatusSuccess = "success"
+	ProviderStatusTimeout = "timeout"
+	ProviderStatusError   = "error"
+)
+
+var (
+	// providerRequestDuration 提供商调用耗时，按结果区分，可计算延迟分位和错误率
+	providerRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "noah_loop",
+			Name:      "provider_request_duration_seconds",
+			Help:      "Duration of calls to external model providers (embedding, LLM).",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"provider", "operation", "status"},
+	)
+
+	// providerRetries 提供商调用重试次数
+	providerRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "noah_loop",
+			Name:      "provider_retries_total",
+			Help:      "Number of retried calls to external model providers.",
+		},
+		[]string{"provider", "operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(providerRequestDuration, providerRetries)
+}
+
+// ObserveProviderCall 记录一次提供商调用（单次尝试）的耗时和结果
+func ObserveProviderCall(provider, operation string, duration time.Duration, status string) {
+	providerRequestDuration.WithLabelValues(provider, operation, status).Observe(duration.Seconds())
+}
+
+// IncProviderRetry 记录一次提供商调用重试
+func IncProviderRetry(provider, operation string) {
+	providerRetries.WithLabelValues(provider, operation).Inc()
+}
+
+// ProviderStatus 根据调用错误确定调用结果
+func ProviderStatus(err error) string {
+	switch {
+	case err == nil:
+		return ProviderStatusSuccess
+	case retry.IsTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return ProviderStatusTimeout
+	default:
+		return ProviderStatusError
+	}
+}
@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 嵌入生成的用途
+const (
+	EmbeddingOperationQuery    = "query"    // 检索时为查询生成向量
+	EmbeddingOperationDocument = "document" // 索引时为分块生成向量
+)
+
+// KnowledgeBaseLabelAll 不按知识库区分时knowledge_base标签的取值
+const KnowledgeBaseLabelAll = "all"
+
+// RAGMetrics RAG服务的检索质量和索引性能指标
+type RAGMetrics struct {
+	searchDuration    *prometheus.HistogramVec
+	searchResults     *prometheus.HistogramVec
+	searchScore       *prometheus.HistogramVec
+	embeddingDuration *prometheus.HistogramVec
+	indexedChunks     *prometheus.CounterVec
+	indexDuration     *prometheus.HistogramVec
+	indexThroughput   *prometheus.HistogramVec
+
+	perKnowledgeBase bool
+}
+
+// NewRAGMetrics 创建RAG指标并注册到registerer，registerer为nil时注册到默认注册表（由 /metrics 暴露）
+// perKnowledgeBase为false时knowledge_base标签统一为"all"，知识库很多时用来控制时间序列数量
+func NewRAGMetrics(registerer prometheus.Registerer, perKnowledgeBase bool) (*RAGMetrics, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	m := &RAGMetrics{
+		searchDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_search_duration_seconds",
+				Help:      "Duration of completed knowledge base searches, including embedding, retrieval and reranking.",
+				Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"knowledge_base", "mode"},
+		),
+		searchResults: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_search_results",
+				Help:      "Number of results returned per knowledge base search.",
+				Buckets:   []float64{0, 1, 2, 3, 5, 10, 20, 50},
+			},
+			[]string{"knowledge_base", "mode"},
+		),
+		// 向量相似度、融合分数和重排分数的取值范围不同，按检索方式区分
+		searchScore: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_search_average_score",
+				Help:      "Average score of the results of each knowledge base search that returned results.",
+				Buckets:   []float64{0.01, 0.02, 0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1},
+			},
+			[]string{"knowledge_base", "mode"},
+		),
+		// 端到端耗时，包含提供商重试，不含缓存命中的文本
+		embeddingDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_embedding_duration_seconds",
+				Help:      "Duration of embedding generation for search queries and document chunks.",
+				Buckets:   []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"operation"},
+		),
+		indexedChunks: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_indexed_chunks_total",
+				Help:      "Number of chunks indexed by successful document indexing runs.",
+			},
+			[]string{"knowledge_base"},
+		),
+		indexDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_index_duration_seconds",
+				Help:      "Duration of successful document indexing runs, from chunking to vector sync.",
+				Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+			},
+			[]string{"knowledge_base"},
+		),
+		indexThroughput: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "noah_loop",
+				Name:      "rag_index_throughput_chunks_per_second",
+				Help:      "Chunks indexed per second by each successful document indexing run.",
+				Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+			},
+			[]string{"knowledge_base"},
+		),
+		perKnowledgeBase: perKnowledgeBase,
+	}
+
+	for _, collector := range []prometheus.Collector{
+		m.searchDuration, m.searchResults, m.searchScore, m.embeddingDuration,
+		m.indexedChunks, m.indexDuration, m.indexThroughput,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ObserveSearch 记录一次完成的检索：耗时、结果数和结果平均分，没有结果时不记录平均分
+func (m *RAGMetrics) ObserveSearch(knowledgeBaseID, mode string, duration time.Duration, results int, averageScore float64) {
+	kb := m.knowledgeBaseLabel(knowledgeBaseID)
+	m.searchDuration.WithLabelValues(kb, mode).Observe(duration.Seconds())
+	m.searchResults.WithLabelValues(kb, mode).Observe(float64(results))
+	if results > 0 {
+		m.searchScore.WithLabelValues(kb, mode).Observe(averageScore)
+	}
+}
+
+// ObserveEmbedding 记录一次嵌入生成的耗时
+func (m *RAGMetrics) ObserveEmbedding(operation string, duration time.Duration) {
+	m.embeddingDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// ObserveIndexing 记录一次成功的文档索引，吞吐量为分块数除以索引耗时
+func (m *RAGMetrics) ObserveIndexing(knowledgeBaseID string, chunks int, duration time.Duration) {
+	kb := m.knowledgeBaseLabel(knowledgeBaseID)
+	m.indexedChunks.WithLabelValues(kb).Add(float64(chunks))
+	m.indexDuration.WithLabelValues(kb).Observe(duration.Seconds())
+	if seconds := duration.Seconds(); seconds > 0 {
+		m.indexThroughput.WithLabelValues(kb).Observe(float64(chunks) / seconds)
+	}
+}
+
+// knowledgeBaseLabel 知识库标签的取值
+func (m *RAGMetrics) knowledgeBaseLabel(knowledgeBaseID string) string {
+	if !m.perKnowledgeBase {
+		return KnowledgeBaseLabelAll
+	}
+	return knowledgeBaseID
+}
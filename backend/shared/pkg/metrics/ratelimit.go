@@ -0,0 +1,22 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// rateLimitThrottled 被限流拒绝的请求数
+var rateLimitThrottled = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "noah_loop",
+		Name:      "rate_limit_throttled_total",
+		Help:      "Number of requests rejected by rate limiting, by scope and route.",
+	},
+	[]string{"scope", "route"},
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitThrottled)
+}
+
+// IncRateLimitThrottled 记录一次被限流拒绝的请求
+func IncRateLimitThrottled(scope, route string) {
+	rateLimitThrottled.WithLabelValues(scope, route).Inc()
+}
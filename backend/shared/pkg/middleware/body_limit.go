@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxBodySize 默认请求体大小上限
+const DefaultMaxBodySize int64 = 10 << 20
+
+// BodyLimitConfig 请求体大小限制配置
+type BodyLimitConfig struct {
+	// MaxBytes 全局上限，小于等于0表示不限制
+	MaxBytes int64
+	// Routes 路由上限，覆盖全局上限，按顺序匹配
+	Routes []RouteBodyLimit
+}
+
+// RouteBodyLimit 路由的请求体大小上限
+type RouteBodyLimit struct {
+	Method   string // 请求方法，为空或ANY表示所有方法
+	Path     string // 路径模式，:name匹配任意一段，末尾的*匹配其余部分，如 /api/v1/knowledge-bases/:id/documents
+	MaxBytes int64  // 小于等于0表示不限制
+}
+
+// DefaultBodyLimitConfig 默认请求体大小限制配置
+func DefaultBodyLimitConfig() BodyLimitConfig {
+	return BodyLimitConfig{MaxBytes: DefaultMaxBodySize}
+}
+
+// Matches 请求是否匹配路由规则
+func (r RouteBodyLimit) Matches(method, path string) bool {
+	if r.Method != "" && r.Method != "ANY" && r.Method != method {
+		return false
+	}
+
+	patternSegments := strings.Split(strings.Trim(r.Path, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range patternSegments {
+		if segment == "*" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if !strings.HasPrefix(segment, ":") && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+// limitFor 请求适用的上限，匹配的路由规则优先
+func (c BodyLimitConfig) limitFor(method, path string) int64 {
+	for _, route := range c.Routes {
+		if route.Matches(method, path) {
+			return route.MaxBytes
+		}
+	}
+	return c.MaxBytes
+}
+
+// BodyLimit 限制请求体大小的中间件，超限时返回413，不读取请求体
+// 声明了Content-Length的请求直接按长度判断；分块传输的请求最多读取上限+1字节，内存占用不超过上限
+func BodyLimit(config BodyLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := config.limitFor(c.Request.Method, c.Request.URL.Path)
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			BodyTooLargeResponse(c, limit)
+			return
+		}
+
+		if c.Request.ContentLength < 0 {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"success":    false,
+					"message":    "Failed to read request body.",
+					"error":      "invalid_request_body",
+					"request_id": c.GetString("request_id"),
+				})
+				return
+			}
+			if int64(len(body)) > limit {
+				BodyTooLargeResponse(c, limit)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		}
+
+		c.Next()
+	}
+}
+
+// BodyTooLargeResponse 返回统一的413响应并终止请求
+func BodyTooLargeResponse(c *gin.Context, limit int64) {
+	// 请求体未读取，响应后关闭连接，避免服务端继续接收剩余的请求体
+	c.Header("Connection", "close")
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"success":    false,
+		"message":    fmt.Sprintf("Request body exceeds the limit of %d bytes.", limit),
+		"error":      "request_entity_too_large",
+		"max_bytes":  limit,
+		"request_id": c.GetString("request_id"),
+	})
+}
+
+// LoadBodyLimitConfig 从环境变量读取请求体大小限制：
+// <prefix>_MAX_BODY_SIZE 为全局上限，如 "10MB"，0表示不限制；
+// <prefix>_MAX_BODY_SIZE_ROUTES 为逗号分隔的路由上限，格式为 [方法 ]路径模式=大小，如 "POST /api/v1/documents/*=50MB"。
+// 无效的配置记录警告后忽略
+func LoadBodyLimitConfig(prefix string, logger infrastructure.Logger) BodyLimitConfig {
+	config := DefaultBodyLimitConfig()
+
+	if value := os.Getenv(prefix + "_MAX_BODY_SIZE"); value != "" {
+		size, err := ParseByteSize(value)
+		if err != nil {
+			logger.Warn("Invalid max body size, using default",
+				zap.String("env", prefix+"_MAX_BODY_SIZE"),
+				zap.Int64("default", config.MaxBytes),
+				zap.Error(err))
+		} else {
+			config.MaxBytes = size
+		}
+	}
+
+	for _, entry := range strings.Split(os.Getenv(prefix+"_MAX_BODY_SIZE_ROUTES"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, err := parseRouteBodyLimit(entry)
+		if err != nil {
+			logger.Warn("Invalid route body size limit",
+				zap.String("env", prefix+"_MAX_BODY_SIZE_ROUTES"),
+				zap.String("entry", entry),
+				zap.Error(err))
+			continue
+		}
+		config.Routes = append(config.Routes, route)
+	}
+
+	return config
+}
+
+// parseRouteBodyLimit 解析路由上限，格式为 [方法 ]路径模式=大小
+func parseRouteBodyLimit(entry string) (RouteBodyLimit, error) {
+	routeSpec, sizeSpec, found := strings.Cut(strings.TrimSpace(entry), "=")
+	if !found {
+		return RouteBodyLimit{}, errors.New("missing size")
+	}
+
+	method, path := "ANY", strings.TrimSpace(routeSpec)
+	if prefix, rest, found := strings.Cut(path, " "); found {
+		method, path = strings.ToUpper(prefix), strings.TrimSpace(rest)
+	}
+	if !strings.HasPrefix(path, "/") {
+		return RouteBodyLimit{}, errors.New("path must start with /")
+	}
+
+	size, err := ParseByteSize(sizeSpec)
+	if err != nil {
+		return RouteBodyLimit{}, err
+	}
+	return RouteBodyLimit{Method: method, Path: path, MaxBytes: size}, nil
+}
+
+// byteSizeUnits 大小单位，按1024进位
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize 解析大小，如 "512KB"、"10MB"、"1GB"，不带单位时为字节数
+func ParseByteSize(value string) (int64, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	normalized = strings.Replace(normalized, "IB", "B", 1)
+
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(normalized, unit.suffix) {
+			normalized = strings.TrimSpace(strings.TrimSuffix(normalized, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(normalized, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	if size > 0 && multiplier > (1<<62)/size {
+		return 0, fmt.Errorf("size %q is too large", value)
+	}
+	return size * multiplier, nil
+}
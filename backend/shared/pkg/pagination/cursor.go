@@ -0,0 +1,56 @@
+// Package pagination 提供按(created_at, id)降序的游标分页，供各模块的列表接口共用
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor 分页游标无法解析
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor 游标分页位置，指向上一页的最后一条记录
+// 列表按(created_at, id)降序排列，下一页从该记录之后开始，翻页期间新创建的记录不会导致重复或遗漏
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// NewCursor 创建指向指定记录的游标
+func NewCursor(createdAt time.Time, id string) *Cursor {
+	return &Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// Encode 将游标编码为不透明字符串，用于API响应中的next_cursor
+func (c *Cursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor 解析Encode生成的游标，空字符串表示第一页，返回nil
+func DecodeCursor(encoded string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return NewCursor(time.Unix(0, unixNano).UTC(), id), nil
+}
@@ -0,0 +1,91 @@
+package pagination
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	cursor := NewCursor(createdAt, "notification:42")
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 保留纳秒精度，ID中可以包含分隔符
+	if !decoded.CreatedAt.Equal(createdAt) || decoded.ID != cursor.ID {
+		t.Fatalf("decoded cursor %+v, want %+v", decoded, cursor)
+	}
+
+	if encoded := (*Cursor)(nil).Encode(); encoded != "" {
+		t.Fatalf("nil cursor encoded as %q", encoded)
+	}
+	if decoded, err := DecodeCursor(""); decoded != nil || err != nil {
+		t.Fatalf("empty cursor decoded as %+v, %v", decoded, err)
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	for _, encoded := range []string{
+		"not base64!",
+		NewCursor(time.Unix(0, 1), "").Encode(),
+		"MTIzNDU",     // 没有分隔符
+		"YWJjOmlkLTE", // 时间不是数字
+	} {
+		if _, err := DecodeCursor(encoded); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", encoded, err)
+		}
+	}
+}
+
+type cursorRow struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+// dryRunDB 返回只生成SQL、不连接数据库的会话
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestAfterCursorQuery(t *testing.T) {
+	db := dryRunDB(t)
+
+	var rows []cursorRow
+	stmt := AfterCursor(db.Model(&cursorRow{}), nil, 10).Find(&rows).Statement
+	sql := stmt.SQL.String()
+	if strings.Contains(sql, "WHERE") {
+		t.Fatalf("first page should not filter: %s", sql)
+	}
+	for _, fragment := range []string{"ORDER BY created_at DESC,id DESC", "LIMIT 11"} {
+		if !strings.Contains(sql, fragment) {
+			t.Fatalf("query %s does not contain %q", sql, fragment)
+		}
+	}
+
+	// 后续页从游标记录之后开始，创建时间相同时按ID继续
+	cursor := NewCursor(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), "id-5")
+	stmt = AfterCursor(db.Model(&cursorRow{}), cursor, 10).Find(&rows).Statement
+	sql = stmt.SQL.String()
+	if !strings.Contains(sql, "WHERE (created_at < $1 OR (created_at = $2 AND id < $3))") {
+		t.Fatalf("query %s does not filter after the cursor", sql)
+	}
+	if want := []interface{}{cursor.CreatedAt, cursor.CreatedAt, cursor.ID}; !reflect.DeepEqual(stmt.Vars, want) {
+		t.Fatalf("query vars = %v, want %v", stmt.Vars, want)
+	}
+}
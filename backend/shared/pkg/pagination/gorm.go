@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AfterCursor 按(created_at, id)降序查询cursor之后的记录，查询limit+1条，调用方据此判断是否还有下一页
+// id作为第二排序键保证创建时间相同的记录顺序稳定，翻页时不会重复或遗漏
+func AfterCursor(query *gorm.DB, cursor *Cursor, limit int) *gorm.DB {
+	if cursor != nil {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	return query.
+		Order("created_at DESC").
+		Order("id DESC").
+		Limit(limit + 1)
+}
+
+// FindAfterCursor 查询cursor之后的一页记录，多查一条用于判断是否还有下一页
+// position返回记录的创建时间和ID，没有更多记录时返回的游标为nil
+func FindAfterCursor[T any](query *gorm.DB, cursor *Cursor, limit int, position func(T) (time.Time, string)) ([]T, *Cursor, error) {
+	var items []T
+	if err := AfterCursor(query, cursor, limit).Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(items) <= limit {
+		return items, nil, nil
+	}
+	items = items[:limit]
+	return items, NewCursor(position(items[limit-1])), nil
+}
@@ -0,0 +1,84 @@
+//go:build integration
+
+package pagination
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newIntegrationDB 连接POSTGRES_DSN指定的数据库并创建临时表，未设置时跳过测试
+func newIntegrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := fmt.Sprintf("it_cursor_rows_%d", time.Now().UnixNano())
+	db = db.Table(table)
+	if err := db.Migrator().CreateTable(&cursorRow{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Migrator().DropTable(table) })
+	return db
+}
+
+func insertRows(t *testing.T, db *gorm.DB, createdAt time.Time, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := db.Session(&gorm.Session{}).Create(&cursorRow{ID: id, CreatedAt: createdAt}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindAfterCursorWithConcurrentInserts(t *testing.T) {
+	db := newIntegrationDB(t)
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	// 多条记录创建时间相同，按ID区分顺序
+	insertRows(t, db, base, "a", "b", "c")
+	insertRows(t, db, base.Add(time.Second), "d", "e")
+	insertRows(t, db, base.Add(2*time.Second), "f", "g")
+	want := []string{"g", "f", "e", "d", "c", "b", "a"}
+
+	position := func(row cursorRow) (time.Time, string) { return row.CreatedAt, row.ID }
+	var got []string
+	var cursor *Cursor
+	for page := 0; ; page++ {
+		rows, next, err := FindAfterCursor(db.Session(&gorm.Session{}), cursor, 2, position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			got = append(got, row.ID)
+		}
+		if next == nil {
+			break
+		}
+
+		// 翻页期间插入更新的记录，以及与游标创建时间相同、ID更大的记录，都排在游标之前，不影响后续页
+		insertRows(t, db, base.Add(time.Duration(10+page)*time.Second), fmt.Sprintf("new-%d", page))
+		insertRows(t, db, next.CreatedAt, next.ID+"z")
+
+		cursor, err = DecodeCursor(next.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("paged ids = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理空闲令牌桶的间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 进程内令牌桶存储，配额只在当前实例内生效
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// bucket 令牌桶状态
+type bucket struct {
+	rule       Rule
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewMemoryStore 创建进程内令牌桶存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take 从key对应的令牌桶中取一个令牌
+func (s *MemoryStore) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, exists := s.buckets[key]
+	// 规则变更后按新规则重新开始计算
+	if !exists || b.rule != rule {
+		b = &bucket{
+			rule:       rule,
+			tokens:     float64(rule.Burst),
+			lastRefill: now,
+		}
+		s.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.lastRefill).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * rule.Rate
+		if b.tokens > float64(rule.Burst) {
+			b.tokens = float64(rule.Burst)
+		}
+		b.lastRefill = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(allowed, rule, b.tokens, now), nil
+}
+
+// sweep 删除已经补满的令牌桶，避免客户端数量增长导致内存无限增长
+// 补满的令牌桶与新建的相同，删除不影响限流结果
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.lastRefill) >= b.rule.refillDuration() {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Close 释放存储资源
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestMemoryStore 创建使用可控时钟的内存存储
+func newTestMemoryStore() (*MemoryStore, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestMemoryStoreBurst(t *testing.T) {
+	store, now := newTestMemoryStore()
+	ctx := context.Background()
+	rule := PerMinute(60, 5) // 每秒1个，突发5个
+
+	for i := 0; i < 5; i++ {
+		result, err := store.Take(ctx, "a", rule)
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d: %+v, %v", i, result, err)
+		}
+		if result.Info.Limit != 5 || result.Info.Remaining != 4-i {
+			t.Fatalf("request %d: %+v", i, result.Info)
+		}
+	}
+
+	result, _ := store.Take(ctx, "a", rule)
+	if result.Allowed {
+		t.Fatal("request beyond the burst should be rejected")
+	}
+	if wait := result.Info.Reset.Sub(*now); wait != time.Second {
+		t.Fatalf("reset in %s, want the next token in 1s", wait)
+	}
+
+	// 不同客户端的令牌桶相互独立
+	if result, _ := store.Take(ctx, "b", rule); !result.Allowed {
+		t.Fatal("another key should have its own bucket")
+	}
+}
+
+func TestMemoryStoreSustainedRate(t *testing.T) {
+	store, now := newTestMemoryStore()
+	ctx := context.Background()
+	rule := PerMinute(60, 5)
+
+	// 先用完突发配额
+	for i := 0; i < 5; i++ {
+		store.Take(ctx, "a", rule)
+	}
+
+	// 每秒请求2次，只放行持续速率内的1次
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		*now = now.Add(time.Second)
+		for j := 0; j < 2; j++ {
+			if result, _ := store.Take(ctx, "a", rule); result.Allowed {
+				allowed++
+			}
+		}
+	}
+	if allowed != 20 {
+		t.Fatalf("allowed %d of 40 requests over 20s, want 20", allowed)
+	}
+
+	// 空闲后补满到突发容量，不会超过容量
+	*now = now.Add(time.Minute)
+	allowed = 0
+	for i := 0; i < 10; i++ {
+		if result, _ := store.Take(ctx, "a", rule); result.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Fatalf("allowed %d after idling, want the burst of 5", allowed)
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	store, now := newTestMemoryStore()
+	ctx := context.Background()
+	rule := PerMinute(60, 5)
+
+	for _, key := range []string{"a", "b", "c"} {
+		store.Take(ctx, key, rule)
+	}
+	*now = now.Add(time.Hour)
+	store.Take(ctx, "d", rule)
+	if len(store.buckets) != 1 {
+		t.Fatalf("%d buckets left after sweep, want 1", len(store.buckets))
+	}
+}
+
+func TestMemoryStoreRuleChange(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	ctx := context.Background()
+
+	store.Take(ctx, "a", PerMinute(60, 1))
+	if result, _ := store.Take(ctx, "a", PerMinute(60, 1)); result.Allowed {
+		t.Fatal("bucket should be empty")
+	}
+	// 规则变更后按新规则重新计算
+	if result, _ := store.Take(ctx, "a", PerMinute(60, 3)); !result.Allowed || result.Info.Remaining != 2 {
+		t.Fatalf("after rule change: %+v", result)
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/utils"
+)
+
+// 令牌桶存储类型
+const (
+	StoreMemory = "memory" // 进程内令牌桶，适用于单实例
+	StoreRedis  = "redis"  // Redis令牌桶，多实例共享配额
+)
+
+// Rule 令牌桶规则
+type Rule struct {
+	Rate  float64 // 每秒补充的令牌数，即持续速率
+	Burst int     // 桶容量，即允许的突发请求数
+}
+
+// PerMinute 创建每分钟requests个请求、突发burst个请求的规则
+func PerMinute(requests, burst int) Rule {
+	return Rule{
+		Rate:  float64(requests) / time.Minute.Seconds(),
+		Burst: burst,
+	}
+}
+
+// Unlimited 速率或容量小于等于0表示不限流
+func (r Rule) Unlimited() bool {
+	return r.Rate <= 0 || r.Burst <= 0
+}
+
+// refillDuration 空桶补满所需的时间，超过该时间未使用的令牌桶与新建的相同
+func (r Rule) refillDuration() time.Duration {
+	return time.Duration(float64(r.Burst) / r.Rate * float64(time.Second))
+}
+
+// Result 取令牌的结果
+type Result struct {
+	Allowed bool
+	Info    utils.RateLimitInfo
+}
+
+// Store 令牌桶存储，同一key的令牌桶在所有使用该存储的实例间共享
+type Store interface {
+	// Take 从key对应的令牌桶中取一个令牌，令牌桶不存在时按规则创建满桶
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+	// Close 释放存储资源
+	Close() error
+}
+
+// newResult 根据取令牌后剩余的令牌数计算限流信息
+// 有剩余配额时Reset为令牌桶补满的时间，否则为下一个令牌可用的时间
+func newResult(allowed bool, rule Rule, tokens float64, now time.Time) Result {
+	missing := float64(rule.Burst) - tokens
+	if tokens < 1 {
+		missing = 1 - tokens
+	}
+
+	reset := now
+	if missing > 0 {
+		reset = now.Add(time.Duration(missing / rule.Rate * float64(time.Second)))
+	}
+
+	return Result{
+		Allowed: allowed,
+		Info: utils.RateLimitInfo{
+			Limit:     rule.Burst,
+			Remaining: int(math.Floor(tokens)),
+			Reset:     reset,
+		},
+	}
+}
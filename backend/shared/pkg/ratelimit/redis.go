@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript 原子地补充令牌并取一个令牌，返回是否允许和剩余令牌数
+// KEYS[1]为令牌桶，ARGV为每毫秒补充的令牌数、桶容量、当前时间（毫秒）和过期时间（毫秒）
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore Redis令牌桶存储，多个实例共享同一个key的配额
+// 令牌按调用方的时钟补充，各实例的时钟需要同步
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore 使用已有的Redis客户端创建令牌桶存储，prefix用于区分使用方
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+		now:    time.Now,
+	}
+}
+
+// Take 从key对应的令牌桶中取一个令牌
+func (s *RedisStore) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := s.now()
+	// 令牌桶补满后过期，过期与补满的令牌桶相同
+	ttl := rule.refillDuration() + time.Second
+
+	values, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		rule.Rate/1000,
+		rule.Burst,
+		now.UnixMilli(),
+		ttl.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: failed to take token from redis: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected redis script result %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensValue, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensValue, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: invalid token count %q: %w", tokensValue, err)
+	}
+
+	return newResult(allowed == 1, rule, tokens, now), nil
+}
+
+// Close 关闭Redis客户端
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
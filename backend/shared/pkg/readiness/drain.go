@@ -0,0 +1,71 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultDrainGracePeriod 默认摘除流量后等待负载均衡器感知的时间
+	DefaultDrainGracePeriod = 5 * time.Second
+	// drainGracePeriodEnv 摘除流量等待时间的环境变量，如"10s"，0表示不等待
+	drainGracePeriodEnv = "SHUTDOWN_GRACE_PERIOD"
+)
+
+// DrainGracePeriodFromEnv 从SHUTDOWN_GRACE_PERIOD读取摘除流量的等待时间，未设置或无效时使用默认值
+func DrainGracePeriodFromEnv() time.Duration {
+	if period, err := time.ParseDuration(os.Getenv(drainGracePeriodEnv)); err == nil && period >= 0 {
+		return period
+	}
+	return DefaultDrainGracePeriod
+}
+
+// OnDrain 注册开始摘除流量时的回调，如将etcd中的健康状态置为unhealthy、gRPC健康状态置为NOT_SERVING
+func (g *Gate) OnDrain(fn func(ctx context.Context) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onDrain = append(g.onDrain, fn)
+}
+
+// IsDraining 是否正在摘除流量
+func (g *Gate) IsDraining() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.draining
+}
+
+// Drain 关闭服务器前摘除流量：标记为未就绪（就绪探针返回503）并调用摘除回调，
+// 再等待gracePeriod让负载均衡器和服务发现感知，等待期间仍处理已路由过来的请求。
+// 返回后再关闭HTTP和gRPC服务器，处理完进行中的请求；ctx结束时提前返回。
+// 回调失败不影响后续回调和等待，错误合并后返回；重复调用直接返回
+func (g *Gate) Drain(ctx context.Context, gracePeriod time.Duration) error {
+	g.mu.Lock()
+	if g.draining {
+		g.mu.Unlock()
+		return nil
+	}
+	g.ready = false
+	g.draining = true
+	g.reason = "draining"
+	callbacks := append([]func(ctx context.Context) error(nil), g.onDrain...)
+	g.mu.Unlock()
+
+	var errs []error
+	for _, fn := range callbacks {
+		if err := fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if gracePeriod > 0 {
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+	}
+	return errors.Join(errs...)
+}
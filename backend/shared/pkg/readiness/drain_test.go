@@ -0,0 +1,94 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDrainStateTransitions(t *testing.T) {
+	gate := NewGate(DefaultConfig())
+	var mu sync.Mutex
+	var drained []string
+	gate.OnDrain(func(ctx context.Context) error {
+		mu.Lock()
+		drained = append(drained, "etcd")
+		mu.Unlock()
+		return errors.New("etcd unavailable")
+	})
+	gate.OnDrain(func(ctx context.Context) error {
+		mu.Lock()
+		drained = append(drained, "grpc")
+		mu.Unlock()
+		return nil
+	})
+	gate.MarkReady()
+	handler := gate.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- gate.Drain(context.Background(), 200*time.Millisecond) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// 等待期间就绪探针失败，已路由过来的业务请求照常处理
+	if !gate.IsDraining() || gate.IsReady() {
+		t.Fatalf("draining = %v, ready = %v", gate.IsDraining(), gate.IsReady())
+	}
+	if code, report := serveProbe(t, handler, "/readyz"); code != http.StatusServiceUnavailable || report.Reason != "draining" {
+		t.Fatalf("/readyz while draining = %d %+v", code, report)
+	}
+	if code, _ := serveProbe(t, handler, "/api"); code != http.StatusNoContent {
+		t.Fatalf("/api while draining = %d, want 204", code)
+	}
+
+	// 回调失败不影响其他回调和等待
+	err := <-done
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("Drain returned after %s, before the grace period", elapsed)
+	}
+	if err == nil || len(drained) != 2 {
+		t.Fatalf("Drain = %v, callbacks = %v", err, drained)
+	}
+
+	// 摘除流量后不再标记为就绪，重复调用不再执行回调
+	gate.MarkReady()
+	if gate.IsReady() {
+		t.Fatal("gate became ready again after draining")
+	}
+	if err := gate.Drain(context.Background(), time.Hour); err != nil || len(drained) != 2 {
+		t.Fatalf("second Drain = %v, callbacks = %v", err, drained)
+	}
+}
+
+func TestDrainStopsWaitingWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := NewGate(DefaultConfig()).Drain(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Drain waited %s after the context was done", elapsed)
+	}
+}
+
+func TestDrainGracePeriodFromEnv(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":    DefaultDrainGracePeriod,
+		"10s": 10 * time.Second,
+		"0s":  0,
+		"-1s": DefaultDrainGracePeriod,
+		"bad": DefaultDrainGracePeriod,
+	} {
+		t.Setenv(drainGracePeriodEnv, value)
+		if got := DrainGracePeriodFromEnv(); got != want {
+			t.Errorf("%s=%q: got %s, want %s", drainGracePeriodEnv, value, got, want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check 就绪检查，返回nil表示依赖可用
+type Check func(ctx context.Context) error
+
+// Step 启动步骤，如数据库迁移、缓存预热
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Config 就绪闸门配置
+type Config struct {
+	CheckTimeout   time.Duration // 单个检查的超时
+	PollInterval   time.Duration // 启动时重复检查的间隔
+	StartupTimeout time.Duration // 启动步骤和首次检查通过的最长时间
+}
+
+// DefaultConfig 默认配置：单个检查5秒超时，每2秒重试，最多等待2分钟
+func DefaultConfig() Config {
+	return Config{
+		CheckTimeout:   5 * time.Second,
+		PollInterval:   2 * time.Second,
+		StartupTimeout: 2 * time.Minute,
+	}
+}
+
+// namedCheck 命名的就绪检查
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Status 依赖检查汇总的健康状态，与服务注册中心上报的健康状态取值一致
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"   // 所有检查通过
+	StatusUnhealthy Status = "unhealthy" // 任一检查失败
+)
+
+// Report 就绪检查结果
+type Report struct {
+	Ready  bool              `json:"ready"`
+	Status Status            `json:"status"` // 只反映检查结果，不考虑是否已就绪
+	Reason string            `json:"reason,omitempty"`
+	Checks map[string]string `json:"checks,omitempty"` // 检查名称 -> ok或错误信息
+}
+
+// Gate 服务就绪闸门
+// 启动步骤全部完成且所有检查通过后才标记为就绪；未就绪时就绪探针返回503，业务请求被拒绝，
+// 服务不应注册到服务发现中
+type Gate struct {
+	config Config
+
+	mu       sync.RWMutex
+	ready    bool
+	draining bool
+	reason   string
+	checks   []namedCheck
+	onReady  []func()
+	onDrain  []func(ctx context.Context) error
+}
+
+// NewGate 创建就绪闸门，初始为未就绪
+func NewGate(config Config) *Gate {
+	defaults := DefaultConfig()
+	if config.CheckTimeout <= 0 {
+		config.CheckTimeout = defaults.CheckTimeout
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.StartupTimeout <= 0 {
+		config.StartupTimeout = defaults.StartupTimeout
+	}
+
+	return &Gate{
+		config: config,
+		reason: "starting",
+	}
+}
+
+// AddCheck 添加就绪检查
+func (g *Gate) AddCheck(name string, check Check) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checks = append(g.checks, namedCheck{name: name, check: check})
+}
+
+// OnReady 注册就绪时的回调，如将gRPC健康状态置为SERVING；已就绪时立即调用
+func (g *Gate) OnReady(fn func()) {
+	g.mu.Lock()
+	if !g.ready {
+		g.onReady = append(g.onReady, fn)
+		g.mu.Unlock()
+		return
+	}
+	g.mu.Unlock()
+	fn()
+}
+
+// IsReady 是否已就绪
+func (g *Gate) IsReady() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.ready
+}
+
+// MarkReady 标记为就绪并调用就绪回调，开始摘除流量后不再标记为就绪
+func (g *Gate) MarkReady() {
+	g.mu.Lock()
+	if g.ready || g.draining {
+		g.mu.Unlock()
+		return
+	}
+	g.ready = true
+	g.reason = ""
+	callbacks := g.onReady
+	g.onReady = nil
+	g.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// MarkNotReady 标记为未就绪，如开始关闭时停止接收新流量
+func (g *Gate) MarkNotReady(reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ready = false
+	g.reason = reason
+}
+
+// Check 执行所有就绪检查
+func (g *Gate) Check(ctx context.Context) Report {
+	g.mu.RLock()
+	ready, reason := g.ready, g.reason
+	checks := append([]namedCheck(nil), g.checks...)
+	g.mu.RUnlock()
+
+	report := Report{
+		Ready:  ready,
+		Status: StatusHealthy,
+		Reason: reason,
+		Checks: make(map[string]string, len(checks)),
+	}
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, g.config.CheckTimeout)
+		err := c.check(checkCtx)
+		cancel()
+		if err != nil {
+			report.Ready = false
+			report.Status = StatusUnhealthy
+			report.Checks[c.name] = err.Error()
+			if report.Reason == "" {
+				report.Reason = fmt.Sprintf("check %s failed", c.name)
+			}
+			continue
+		}
+		report.Checks[c.name] = "ok"
+	}
+	return report
+}
+
+// Startup 按顺序执行启动步骤，等待所有检查通过后标记为就绪
+// 任一步骤失败或超过StartupTimeout时返回错误，闸门保持未就绪
+func (g *Gate) Startup(ctx context.Context, steps ...Step) error {
+	ctx, cancel := context.WithTimeout(ctx, g.config.StartupTimeout)
+	defer cancel()
+
+	for _, step := range steps {
+		g.setReason("running startup step " + step.Name)
+		if err := step.Run(ctx); err != nil {
+			g.setReason("startup step " + step.Name + " failed")
+			return fmt.Errorf("startup step %s failed: %w", step.Name, err)
+		}
+	}
+
+	g.setReason("waiting for readiness checks")
+	ticker := time.NewTicker(g.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		report := g.checkAll(ctx)
+		if report.Ready {
+			g.MarkReady()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("readiness checks did not pass: %s: %w", report.Reason, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll 执行检查，不考虑当前是否已标记就绪
+func (g *Gate) checkAll(ctx context.Context) Report {
+	report := g.Check(ctx)
+	report.Ready = true
+	report.Reason = ""
+	for name, result := range report.Checks {
+		if result != "ok" {
+			report.Ready = false
+			report.Reason = fmt.Sprintf("check %s failed: %s", name, result)
+		}
+	}
+	return report
+}
+
+// setReason 设置未就绪原因
+func (g *Gate) setReason(reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.ready {
+		g.reason = reason
+	}
+}
+
+// Handler 就绪探针，就绪且检查通过时返回200，否则返回503和检查详情
+func (g *Gate) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := g.Check(r.Context())
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// HealthHandler 健康检查，所有检查通过时返回200，否则返回503和检查详情
+// 与就绪探针执行相同的检查，但不考虑是否已就绪，启动和关闭过程中依赖可用时仍为healthy
+func (g *Gate) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := g.Check(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusHealthy {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// Wrap 包装服务的HTTP处理器
+// /ready、/readyz和/healthz由闸门处理；/health为不检查依赖的存活探针，依赖故障时不会导致进程被重启；
+// 未就绪时除存活探针和指标外的请求返回503，客户端可按Retry-After重试；摘除流量期间仍处理业务请求
+func (g *Gate) Wrap(next http.Handler) http.Handler {
+	probe := g.Handler()
+	health := g.HealthHandler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ready", "/readyz":
+			probe.ServeHTTP(w, r)
+			return
+		case "/healthz":
+			health.ServeHTTP(w, r)
+			return
+		case "/health", "/metrics":
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !g.IsReady() && !g.IsDraining() {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": "service is not ready"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Pinger 支持连通性检查的依赖，如*sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck 创建依赖连通性检查
+func PingCheck(pinger Pinger) Check {
+	return func(ctx context.Context) error {
+		if pinger == nil {
+			return errors.New("not configured")
+		}
+		return pinger.PingContext(ctx)
+	}
+}
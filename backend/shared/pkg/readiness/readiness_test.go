@@ -0,0 +1,105 @@
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// stubDatabase 可切换为连接失败的数据库
+type stubDatabase struct {
+	down atomic.Bool
+}
+
+func (d *stubDatabase) PingContext(ctx context.Context) error {
+	if d.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// serveProbe 通过闸门包装的处理器发送GET请求，返回状态码和检查结果
+func serveProbe(t *testing.T, handler http.Handler, path string) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	if rec.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, report
+}
+
+func TestCheckFlipsToUnhealthyWhenDatabaseFails(t *testing.T) {
+	database := &stubDatabase{}
+	gate := NewGate(DefaultConfig())
+	gate.AddCheck("database", PingCheck(database))
+	gate.AddCheck("etcd", func(ctx context.Context) error { return nil })
+	gate.MarkReady()
+
+	if report := gate.Check(context.Background()); report.Status != StatusHealthy || !report.Ready {
+		t.Fatalf("report = %+v, want healthy and ready", report)
+	}
+
+	database.down.Store(true)
+	report := gate.Check(context.Background())
+	if report.Status != StatusUnhealthy || report.Ready {
+		t.Fatalf("report = %+v, want unhealthy and not ready", report)
+	}
+	if report.Checks["database"] != "connection refused" || report.Checks["etcd"] != "ok" || report.Reason != "check database failed" {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// 依赖恢复后状态随之恢复
+	database.down.Store(false)
+	if report := gate.Check(context.Background()); report.Status != StatusHealthy || !report.Ready {
+		t.Fatalf("report = %+v, want healthy after recovery", report)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	database := &stubDatabase{}
+	gate := NewGate(DefaultConfig())
+	gate.AddCheck("database", PingCheck(database))
+	handler := gate.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// 启动中：依赖可用时healthz为200，readyz和业务请求为503，存活探针不受影响
+	for path, want := range map[string]int{"/healthz": 200, "/readyz": 503, "/ready": 503, "/api": 503, "/health": 204} {
+		if code, _ := serveProbe(t, handler, path); code != want {
+			t.Errorf("starting: %s = %d, want %d", path, code, want)
+		}
+	}
+
+	gate.MarkReady()
+	for path, want := range map[string]int{"/healthz": 200, "/readyz": 200, "/api": 204} {
+		if code, _ := serveProbe(t, handler, path); code != want {
+			t.Errorf("ready: %s = %d, want %d", path, code, want)
+		}
+	}
+
+	database.down.Store(true)
+	code, report := serveProbe(t, handler, "/healthz")
+	if code != http.StatusServiceUnavailable || report.Status != StatusUnhealthy || report.Checks["database"] != "connection refused" {
+		t.Fatalf("database down: /healthz = %d %+v", code, report)
+	}
+	if code, _ := serveProbe(t, handler, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("database down: /readyz = %d, want 503", code)
+	}
+	if code, _ := serveProbe(t, handler, "/health"); code != http.StatusNoContent {
+		t.Fatalf("database down: /health = %d, liveness should not check dependencies", code)
+	}
+}
+
+func TestPingCheckNotConfigured(t *testing.T) {
+	if err := PingCheck(nil)(context.Background()); err == nil {
+		t.Fatal("missing dependency should fail the check")
+	}
+}
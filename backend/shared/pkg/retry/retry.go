@@ -0,0 +1,179 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Policy 重试策略
+type Policy struct {
+	MaxAttempts    int           // 最大尝试次数（含首次调用），小于等于1时不重试
+	InitialDelay   time.Duration // 首次重试前的等待时间
+	MaxDelay       time.Duration // 最大等待时间，0表示不限制
+	Multiplier     float64       // 每次重试的等待时间倍数
+	Jitter         float64       // 随机抖动比例，0~1
+	AttemptTimeout time.Duration // 单次调用超时，0表示只受调用方上下文限制
+
+	// OnRetry 每次重试前调用，可用于记录日志和指标
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultPolicy 默认重试策略：最多3次，500毫秒起每次翻倍，最长10秒
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:  3,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+// Delay 计算第attempt次失败后的等待时间，random为[0,1)的随机数
+func (p Policy) Delay(attempt int, random float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*random-1)
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
+
+// Do 按策略执行fn，遇到可重试错误时退避后重试
+// 每次调用使用独立的超时上下文；调用方上下文结束或遇到Permanent错误时立即返回
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = call(ctx, policy.AttemptTimeout, fn)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+
+		delay := policy.Delay(attempt, rand.Float64())
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return permanent.err
+	}
+	return err
+}
+
+// call 执行单次调用
+func call(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(attemptCtx)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &TimeoutError{Timeout: timeout, Err: err}
+	}
+	return err
+}
+
+// permanentError 不可重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent 将错误标记为不可重试，如参数错误、鉴权失败
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// TimeoutError 单次调用超时
+type TimeoutError struct {
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("call timed out after %s: %v", e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// IsTimeout 判断错误是否为单次调用超时
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// IsRetryable 判断错误是否可重试：Permanent错误和调用方取消不重试
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return true
+}
+
+// IsTransientStatus 判断HTTP状态码是否为临时错误（超时、限流、服务端错误）
+func IsTransientStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError
+}
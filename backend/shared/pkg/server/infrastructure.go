@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure/tracing"
+	"github.com/noah-loop/backend/shared/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// Infrastructure 服务共用的基础设施组件
+type Infrastructure struct {
+	Config           *infrastructure.Config
+	Logger           infrastructure.Logger
+	TracerManager    *tracing.TracerManager
+	EtcdClient       *etcd.Client
+	ServiceRegistry  *etcd.ServiceRegistry
+	ServiceDiscovery *etcd.ServiceDiscovery
+	ConfigManager    *etcd.ConfigManager
+	SecretManager    *etcd.SecretManager
+}
+
+// NewInfrastructure 从configDir加载配置，初始化日志、链路追踪和etcd组件
+// 返回的清理函数关闭追踪管理器和etcd客户端
+func NewInfrastructure(serviceName, configDir string) (*Infrastructure, func(), error) {
+	config, err := infrastructure.LoadConfig(configDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// 初始化日志，级别可通过 /admin/loglevel 在运行时调整
+	logger, err := logging.NewLogger(config.Log.Level, config.Log.Format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	tracingConfig := tracing.NewTracingConfigFromInfrastructure(config, serviceName)
+	tracerManager, err := tracing.NewTracerManager(tracingConfig, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize tracer: %w", err)
+	}
+
+	etcdConfig := etcd.NewConfigFromInfrastructure(config)
+	etcdClient, err := etcd.NewClient(etcdConfig, logger)
+	if err != nil {
+		tracerManager.Close(context.Background())
+		return nil, nil, fmt.Errorf("failed to initialize etcd client: %w", err)
+	}
+
+	infra := &Infrastructure{
+		Config:           config,
+		Logger:           logger,
+		TracerManager:    tracerManager,
+		EtcdClient:       etcdClient,
+		ServiceRegistry:  etcd.NewServiceRegistry(etcdClient, serviceName, logger),
+		ServiceDiscovery: etcd.NewServiceDiscovery(etcdClient, logger),
+		ConfigManager:    etcd.NewConfigManager(etcdClient, logger),
+		SecretManager:    etcd.NewSecretManager(etcdClient, logger),
+	}
+
+	cleanup := func() {
+		tracerManager.Close(context.Background())
+		etcdClient.Close()
+	}
+
+	return infra, cleanup, nil
+}
+
+// WatchConfig 监听etcd中的服务配置，日志级别变化时立即生效，ctx结束时停止监听
+// 只在配置中的级别变化时调整，不覆盖通过 /admin/loglevel 临时调整的级别
+func (i *Infrastructure) WatchConfig(ctx context.Context, serviceName string) {
+	logLevel := i.Config.Log.Level
+	liveConfig := etcd.NewLiveConfig(i.Config)
+	err := i.ConfigManager.WatchConfig(ctx, etcd.ServiceConfigKey(serviceName), liveConfig, func(config *infrastructure.Config) {
+		if config.Log.Level == logLevel {
+			return
+		}
+		logLevel = config.Log.Level
+		if err := logging.SetLevel(config.Log.Level); err != nil {
+			i.Logger.Warn("Invalid log level in config", zap.String("level", config.Log.Level), zap.Error(err))
+			return
+		}
+		i.Logger.Info("Log level changed by config", zap.String("level", config.Log.Level))
+	})
+	if err != nil {
+		i.Logger.Warn("Failed to watch config, runtime config changes are disabled", zap.Error(err))
+	}
+}
@@ -0,0 +1,335 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure"
+	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
+	"github.com/noah-loop/backend/shared/pkg/lifecycle"
+	"github.com/noah-loop/backend/shared/pkg/logging"
+	"github.com/noah-loop/backend/shared/pkg/readiness"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultHealthInterval 默认的健康状态上报间隔
+	DefaultHealthInterval = 10 * time.Second
+	// DefaultRegisterTTL 默认的服务注册租约
+	DefaultRegisterTTL = 30 * time.Second
+	// DefaultShutdownTimeout 默认的关闭超时，包含摘除流量的等待时间
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// Registry 服务注册，*etcd.ServiceRegistry 实现了该接口
+type Registry interface {
+	Register(ctx context.Context, info etcd.ServiceInfo, ttl time.Duration) error
+	UpdateHealth(ctx context.Context, status etcd.HealthStatus, message string) error
+	Deregister(ctx context.Context) error
+}
+
+// Options 服务运行时选项
+type Options struct {
+	Name        string // 服务名，同时用作gRPC健康检查的服务名
+	Version     string
+	Environment string // development环境下启用gRPC反射
+	Host        string // 注册到etcd的地址，默认localhost
+	HTTPPort    int
+	GRPCPort    int
+
+	Handler      http.Handler // 业务路由，外层包装就绪闸门和日志级别管理接口
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	GRPCOptions  []grpc.ServerOption  // 拦截器等gRPC服务器选项
+	RegisterGRPC func(s *grpc.Server) // 注册业务gRPC服务，为空时只提供健康检查
+
+	Gate       *readiness.Gate // 为空时使用默认配置、不含检查的闸门
+	Registry   Registry
+	Logger     infrastructure.Logger
+	Metadata   map[string]string
+	AdminToken string // /admin/loglevel 的访问令牌，默认读取ADMIN_TOKEN；为空时接口关闭
+
+	HealthInterval  time.Duration
+	RegisterTTL     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Runtime 服务运行时，统一处理HTTP与gRPC服务器的启动、就绪后注册、健康状态上报和优雅关闭
+type Runtime struct {
+	opts       Options
+	gate       *readiness.Gate
+	tasks      *lifecycle.Manager
+	httpServer *http.Server
+	grpcServer *grpc.Server
+}
+
+// New 创建服务运行时，服务器在Run时才开始监听
+func New(opts Options) *Runtime {
+	if opts.Host == "" {
+		opts.Host = "localhost"
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = 30 * time.Second
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 30 * time.Second
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 120 * time.Second
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = DefaultHealthInterval
+	}
+	if opts.RegisterTTL <= 0 {
+		opts.RegisterTTL = DefaultRegisterTTL
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if opts.AdminToken == "" {
+		opts.AdminToken = os.Getenv("ADMIN_TOKEN")
+	}
+	if opts.Handler == nil {
+		opts.Handler = http.NotFoundHandler()
+	}
+
+	gate := opts.Gate
+	if gate == nil {
+		gate = readiness.NewGate(readiness.DefaultConfig())
+	}
+
+	r := &Runtime{opts: opts, gate: gate, tasks: lifecycle.NewManager(opts.Logger)}
+	r.httpServer = &http.Server{
+		Addr:         fmt.Sprintf(":%d", opts.HTTPPort),
+		Handler:      logging.Wrap(gate.Wrap(opts.Handler), opts.AdminToken),
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		IdleTimeout:  opts.IdleTimeout,
+	}
+	r.grpcServer = r.newGRPCServer()
+	return r
+}
+
+// newGRPCServer 创建gRPC服务器，健康状态在就绪前和摘除流量后为NOT_SERVING
+func (r *Runtime) newGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(r.opts.GRPCOptions...)
+
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	setServingStatus := func(status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+		healthServer.SetServingStatus(r.opts.Name, status)
+		healthServer.SetServingStatus("", status)
+	}
+	setServingStatus(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	r.gate.OnReady(func() {
+		setServingStatus(grpc_health_v1.HealthCheckResponse_SERVING)
+	})
+	r.gate.OnDrain(func(ctx context.Context) error {
+		setServingStatus(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		return nil
+	})
+
+	if r.opts.RegisterGRPC != nil {
+		r.opts.RegisterGRPC(grpcServer)
+	}
+
+	if r.opts.Environment == "development" {
+		reflection.Register(grpcServer)
+	}
+
+	return grpcServer
+}
+
+// Gate 运行时使用的就绪闸门
+func (r *Runtime) Gate() *readiness.Gate {
+	return r.gate
+}
+
+// GRPCServer 运行时的gRPC服务器
+func (r *Runtime) GRPCServer() *grpc.Server {
+	return r.grpcServer
+}
+
+// Go 注册后台任务，服务就绪后启动，关闭服务器前取消ctx并等待任务返回
+// fn应阻塞到ctx结束，如定时任务循环；返回后需要的清理（如释放任务锁）放在fn末尾
+func (r *Runtime) Go(name string, fn func(ctx context.Context)) {
+	r.gate.OnReady(func() {
+		r.tasks.Go(name, fn)
+	})
+}
+
+// Run 启动服务器并执行启动步骤，就绪后注册到etcd并定期上报健康状态，
+// 直到ctx结束（通常是收到关闭信号）或服务器异常退出，然后依次摘除流量、停止后台任务、关闭服务器并注销服务。
+// 监听端口、启动步骤或注册失败时返回错误，此时已启动的服务器会被关闭
+func (r *Runtime) Run(ctx context.Context, steps ...readiness.Step) error {
+	httpListener, err := net.Listen("tcp", r.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on HTTP port: %w", err)
+	}
+	grpcAddr := fmt.Sprintf(":%d", r.opts.GRPCPort)
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		httpListener.Close()
+		return fmt.Errorf("failed to listen on gRPC port: %w", err)
+	}
+
+	// 启动服务器，就绪前只有存活探针可用，/readyz和业务请求返回503
+	serveErr := make(chan error, 2)
+	go func() {
+		r.opts.Logger.Info("Starting HTTP server",
+			zap.String("addr", httpListener.Addr().String()),
+			zap.String("service", r.opts.Name))
+		if err := r.httpServer.Serve(httpListener); err != nil && err != http.ErrServerClosed {
+			serveErr <- fmt.Errorf("HTTP server failed: %w", err)
+		}
+	}()
+	go func() {
+		r.opts.Logger.Info("Starting gRPC server",
+			zap.String("addr", grpcListener.Addr().String()),
+			zap.String("service", r.opts.Name))
+		if err := r.grpcServer.Serve(grpcListener); err != nil {
+			serveErr <- fmt.Errorf("gRPC server failed: %w", err)
+		}
+	}()
+
+	// 执行启动步骤并等待就绪检查通过，收到关闭信号时放弃启动
+	if err := r.gate.Startup(ctx, steps...); err != nil {
+		r.stop()
+		return fmt.Errorf("service failed to become ready: %w", err)
+	}
+	r.opts.Logger.Info("Service is ready", zap.String("service", r.opts.Name))
+
+	// 就绪后再注册服务到etcd
+	if err := r.register(ctx); err != nil {
+		r.stop()
+		return fmt.Errorf("failed to register service: %w", err)
+	}
+
+	// 关闭时先将etcd中的健康状态置为不健康，服务发现不再选择该实例
+	r.gate.OnDrain(func(ctx context.Context) error {
+		return r.opts.Registry.UpdateHealth(ctx, etcd.HealthStatusUnhealthy, "shutting down")
+	})
+
+	r.tasks.Go("health-updater", r.updateHealth)
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		r.opts.Logger.Info("Shutting down service", zap.String("service", r.opts.Name))
+	case runErr = <-serveErr:
+		r.opts.Logger.Error("Server stopped unexpectedly, shutting down", zap.Error(runErr))
+	}
+
+	r.shutdown()
+	r.deregister()
+
+	r.opts.Logger.Info("Service stopped", zap.String("service", r.opts.Name))
+	return runErr
+}
+
+// register 以HTTP和gRPC端点注册服务
+func (r *Runtime) register(ctx context.Context) error {
+	info := etcd.ServiceInfo{
+		Name:    r.opts.Name,
+		Version: r.opts.Version,
+		HTTP: etcd.EndpointInfo{
+			Host: r.opts.Host,
+			Port: r.opts.HTTPPort,
+		},
+		GRPC: etcd.EndpointInfo{
+			Host: r.opts.Host,
+			Port: r.opts.GRPCPort,
+		},
+		Metadata: r.opts.Metadata,
+	}
+	return r.opts.Registry.Register(ctx, info, r.opts.RegisterTTL)
+}
+
+// updateHealth 定期执行就绪检查并上报健康状态，未通过时附带失败原因
+func (r *Runtime) updateHealth(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		status, message := etcd.HealthStatusHealthy, ""
+		if report := r.gate.Check(checkCtx); !report.Ready {
+			status, message = etcd.HealthStatusUnhealthy, report.Reason
+			r.opts.Logger.Warn("Service is unhealthy", zap.String("reason", report.Reason))
+		}
+
+		if err := r.opts.Registry.UpdateHealth(checkCtx, status, message); err != nil {
+			r.opts.Logger.Error("Failed to update health status", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// shutdown 先摘除流量并等待负载均衡器感知，再停止后台任务、关闭服务器处理完进行中的请求
+func (r *Runtime) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.ShutdownTimeout)
+	defer cancel()
+
+	if err := r.gate.Drain(ctx, readiness.DrainGracePeriodFromEnv()); err != nil {
+		r.opts.Logger.Warn("Failed to mark service as draining", zap.Error(err))
+	}
+	r.stopWithin(ctx)
+}
+
+// stop 启动失败时停止后台任务并关闭服务器，不等待摘除流量
+func (r *Runtime) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.ShutdownTimeout)
+	defer cancel()
+	r.stopWithin(ctx)
+}
+
+// stopWithin 停止后台任务，再优雅关闭HTTP和gRPC服务器，超时后强制关闭gRPC服务器
+// 后台任务未能在超时前返回时记录仍在运行的任务，继续关闭服务器
+func (r *Runtime) stopWithin(ctx context.Context) {
+	if err := r.tasks.Stop(ctx); err != nil {
+		r.opts.Logger.Error("Background tasks forced to stop", zap.Error(err))
+	}
+
+	if err := r.httpServer.Shutdown(ctx); err != nil {
+		r.opts.Logger.Error("HTTP server forced to shutdown", zap.Error(err))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		r.opts.Logger.Warn("gRPC server forced to stop due to timeout")
+		r.grpcServer.Stop()
+	}
+}
+
+// deregister 从etcd注销服务
+func (r *Runtime) deregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.opts.Registry.Deregister(ctx); err != nil {
+		r.opts.Logger.Warn("Failed to deregister service", zap.Error(err))
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/noah-loop/backend/shared/pkg/infrastructure/etcd"
+	"github.com/noah-loop/backend/shared/pkg/readiness"
+	"go.uber.org/zap"
+)
+
+// fakeRegistry 按顺序记录注册、健康状态上报和注销
+type fakeRegistry struct {
+	mu          sync.Mutex
+	events      []string
+	info        etcd.ServiceInfo
+	ttl         time.Duration
+	registerErr error
+}
+
+func (r *fakeRegistry) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *fakeRegistry) Register(ctx context.Context, info etcd.ServiceInfo, ttl time.Duration) error {
+	r.mu.Lock()
+	r.info, r.ttl = info, ttl
+	r.mu.Unlock()
+	r.record("register")
+	return r.registerErr
+}
+
+func (r *fakeRegistry) UpdateHealth(ctx context.Context, status etcd.HealthStatus, message string) error {
+	r.record("health:" + string(status) + ":" + message)
+	return nil
+}
+
+func (r *fakeRegistry) Deregister(ctx context.Context) error {
+	r.record("deregister")
+	return nil
+}
+
+func (r *fakeRegistry) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// waitForEvent 等待注册中心收到event
+func (r *fakeRegistry) waitForEvent(t *testing.T, event string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		for _, e := range r.snapshot() {
+			if e == event {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("registry events = %v, want %q", r.snapshot(), event)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newTestRuntime 创建监听随机端口、不等待摘除流量的运行时
+func newTestRuntime(t *testing.T, registry *fakeRegistry, gate *readiness.Gate) *Runtime {
+	t.Setenv("SHUTDOWN_GRACE_PERIOD", "0s")
+	return New(Options{
+		Name:           "agent",
+		Version:        "1.0.0",
+		Gate:           gate,
+		Registry:       registry,
+		Logger:         zap.NewNop(),
+		HealthInterval: 20 * time.Millisecond,
+	})
+}
+
+// startRuntime 在后台运行，返回停止函数，停止函数返回Run的结果
+func startRuntime(runtime *Runtime, steps ...readiness.Step) func() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runtime.Run(ctx, steps...) }()
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func TestRuntimeLifecycle(t *testing.T) {
+	registry := &fakeRegistry{}
+	runtime := newTestRuntime(t, registry, nil)
+	var stepRan atomic.Bool
+	stop := startRuntime(runtime, readiness.Step{Name: "migrate", Run: func(ctx context.Context) error {
+		stepRan.Store(true)
+		return nil
+	}})
+
+	// 启动步骤完成、就绪后才注册，之后定期上报健康状态
+	registry.waitForEvent(t, "health:healthy:")
+	if !stepRan.Load() || !runtime.Gate().IsReady() {
+		t.Fatalf("step ran = %v, ready = %v", stepRan.Load(), runtime.Gate().IsReady())
+	}
+
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 关闭时先置为不健康，最后注销
+	events := registry.snapshot()
+	if events[0] != "register" || events[len(events)-2] != "health:unhealthy:shutting down" || events[len(events)-1] != "deregister" {
+		t.Fatalf("registry events = %v", events)
+	}
+	info := registry.info
+	if info.Name != "agent" || info.Version != "1.0.0" || info.HTTP.Host != "localhost" || info.GRPC.Host != "localhost" || registry.ttl != DefaultRegisterTTL {
+		t.Fatalf("registered %+v with ttl %s", info, registry.ttl)
+	}
+	if runtime.Gate().IsReady() || !runtime.Gate().IsDraining() {
+		t.Fatal("gate should be draining after shutdown")
+	}
+}
+
+// freePort 返回本机一个空闲端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestRuntimeRegistersHTTPAndGRPCEndpoints(t *testing.T) {
+	t.Setenv("SHUTDOWN_GRACE_PERIOD", "0s")
+	registry := &fakeRegistry{}
+	httpPort, grpcPort := freePort(t), freePort(t)
+	stop := startRuntime(New(Options{
+		Name:        "rag",
+		Version:     "2.0.0",
+		Host:        "rag.internal",
+		HTTPPort:    httpPort,
+		GRPCPort:    grpcPort,
+		Registry:    registry,
+		Logger:      zap.NewNop(),
+		Metadata:    map[string]string{"zone": "a"},
+		RegisterTTL: time.Minute,
+	}))
+	registry.waitForEvent(t, "register")
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := etcd.ServiceInfo{
+		Name:     "rag",
+		Version:  "2.0.0",
+		HTTP:     etcd.EndpointInfo{Host: "rag.internal", Port: httpPort},
+		GRPC:     etcd.EndpointInfo{Host: "rag.internal", Port: grpcPort},
+		Metadata: map[string]string{"zone": "a"},
+	}
+	if !reflect.DeepEqual(registry.info, want) || registry.ttl != time.Minute {
+		t.Fatalf("registered %+v with ttl %s, want %+v", registry.info, registry.ttl, want)
+	}
+}
+
+func TestRuntimeReportsUnhealthyWhenCheckFails(t *testing.T) {
+	var failing atomic.Bool
+	gate := readiness.NewGate(readiness.DefaultConfig())
+	gate.AddCheck("database", func(ctx context.Context) error {
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	registry := &fakeRegistry{}
+	stop := startRuntime(newTestRuntime(t, registry, gate))
+	defer stop()
+
+	registry.waitForEvent(t, "health:healthy:")
+	failing.Store(true)
+	registry.waitForEvent(t, "health:unhealthy:check database failed")
+}
+
+func TestRuntimeStartupFailure(t *testing.T) {
+	registry := &fakeRegistry{}
+	runtime := newTestRuntime(t, registry, nil)
+
+	err := runtime.Run(context.Background(), readiness.Step{Name: "migrate", Run: func(ctx context.Context) error {
+		return errors.New("migration failed")
+	}})
+	if err == nil || !strings.Contains(err.Error(), "migration failed") {
+		t.Fatalf("Run = %v, want the startup step error", err)
+	}
+	if events := registry.snapshot(); len(events) != 0 {
+		t.Fatalf("registry events = %v, a service that never became ready must not register", events)
+	}
+}
+
+func TestRuntimeRegisterFailure(t *testing.T) {
+	registry := &fakeRegistry{registerErr: errors.New("etcd unavailable")}
+	runtime := newTestRuntime(t, registry, nil)
+
+	if err := runtime.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "etcd unavailable") {
+		t.Fatalf("Run = %v, want the register error", err)
+	}
+}
+
+func TestRuntimeStopsTasksBeforeServers(t *testing.T) {
+	registry := &fakeRegistry{}
+	runtime := newTestRuntime(t, registry, nil)
+
+	started := make(chan struct{})
+	var servingWhenStopped atomic.Bool
+	runtime.Go("cleanup", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		// 后台任务停止时服务器仍在运行
+		servingWhenStopped.Store(runtime.GRPCServer().GetServiceInfo() != nil)
+	})
+
+	stop := startRuntime(runtime)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background task did not start after the service became ready")
+	}
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	if !servingWhenStopped.Load() {
+		t.Fatal("servers were closed before the background task stopped")
+	}
+}
@@ -0,0 +1,101 @@
+// Package textformat 提供HTML和Markdown转纯文本的通用工具，供通知内容格式转换和文档预处理共用
+package textformat
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Builder 纯文本构建器，折叠行内空白，块之间按需插入换行
+type Builder struct {
+	// OnWrite 每段文本写入前调用，参数为该段文本在结果中的字符（rune）偏移，可用于记录标题位置
+	OnWrite func(offset int)
+
+	sb       strings.Builder
+	length   int  // 已写入的字符数
+	newlines int  // 下一段文本前待写入的换行数
+	space    bool // 下一段文本前待写入空格
+}
+
+// LineBreak 要求下一段文本前至少有n个换行，1为换行，2为分段
+func (b *Builder) LineBreak(n int) {
+	if n > b.newlines {
+		b.newlines = n
+	}
+	b.space = false
+}
+
+// Separate 要求下一段文本前有空格，如表格单元格之间
+func (b *Builder) Separate() {
+	if b.newlines == 0 {
+		b.space = true
+	}
+}
+
+// WriteInline 写入行内文本，连续空白折叠为一个空格
+func (b *Builder) WriteInline(text string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		if text != "" {
+			b.Separate()
+		}
+		return
+	}
+
+	if r, _ := utf8.DecodeRuneInString(text); unicode.IsSpace(r) {
+		b.Separate()
+	}
+	b.Write(strings.Join(fields, " "))
+	if r, _ := utf8.DecodeLastRuneInString(text); unicode.IsSpace(r) {
+		b.Separate()
+	}
+}
+
+// WriteRaw 原样写入文本，用于代码块等需要保留空白的内容，末尾的换行转为待写入的换行
+func (b *Builder) WriteRaw(text string) {
+	trimmed := strings.TrimRight(text, " \t\n")
+	if trimmed == "" {
+		return
+	}
+	b.Write(trimmed)
+	if trailing := strings.Count(text[len(trimmed):], "\n"); trailing > 0 {
+		b.LineBreak(min(trailing, 2))
+	}
+}
+
+// Write 写入文本，先补上待写入的换行或空格
+func (b *Builder) Write(text string) {
+	if text == "" {
+		return
+	}
+	if b.length > 0 {
+		if b.newlines > 0 {
+			b.emit(strings.Repeat("\n", b.newlines))
+		} else if b.space {
+			b.emit(" ")
+		}
+	}
+	b.newlines, b.space = 0, false
+
+	if b.OnWrite != nil {
+		b.OnWrite(b.length)
+	}
+	b.emit(text)
+}
+
+// emit 写入并累计字符数
+func (b *Builder) emit(text string) {
+	b.sb.WriteString(text)
+	b.length += utf8.RuneCountInString(text)
+}
+
+// Len 返回已写入的字符数
+func (b *Builder) Len() int {
+	return b.length
+}
+
+// String 返回构建的文本
+func (b *Builder) String() string {
+	return b.sb.String()
+}
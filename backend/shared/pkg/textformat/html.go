@@ -0,0 +1,84 @@
+package textformat
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SkippedHTMLElements 不包含正文的HTML元素，连同子节点一起忽略
+var SkippedHTMLElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// HTMLBlockBreaks 块级元素前后的换行数，1为换行，2为分段；未列出的元素为行内元素
+var HTMLBlockBreaks = map[atom.Atom]int{
+	atom.P:          2,
+	atom.Pre:        2,
+	atom.Blockquote: 2,
+	atom.Ul:         2,
+	atom.Ol:         2,
+	atom.Dl:         2,
+	atom.Table:      2,
+	atom.Section:    2,
+	atom.Article:    2,
+	atom.Header:     2,
+	atom.Footer:     2,
+	atom.Main:       2,
+	atom.Aside:      2,
+	atom.Figure:     2,
+	atom.Form:       2,
+	atom.Fieldset:   2,
+	atom.Details:    2,
+	atom.Address:    2,
+	atom.Hr:         2,
+	atom.Div:        1,
+	atom.Li:         1,
+	atom.Tr:         1,
+	atom.Dt:         1,
+	atom.Dd:         1,
+	atom.Caption:    1,
+	atom.Figcaption: 1,
+	atom.Summary:    1,
+	atom.Legend:     1,
+}
+
+// HTMLHeadingLevels 标题元素的级别
+var HTMLHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1,
+	atom.H2: 2,
+	atom.H3: 3,
+	atom.H4: 4,
+	atom.H5: 5,
+	atom.H6: 6,
+}
+
+// HTMLAttr 获取元素属性，不存在时返回空字符串
+func HTMLAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// IsSafeURL 只接受http、https、mailto和相对地址，避免生成javascript:等链接
+func IsSafeURL(href string) bool {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return false
+	}
+	lower := strings.ToLower(href)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:") {
+		return true
+	}
+	return !strings.Contains(strings.SplitN(lower, "/", 2)[0], ":")
+}
@@ -0,0 +1,92 @@
+package textformat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markdown块级语法
+var (
+	MarkdownATXHeading    = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	MarkdownFence         = regexp.MustCompile("^\\s{0,3}(```+|~~~+)")
+	MarkdownThematicBreak = regexp.MustCompile(`^\s{0,3}((\*\s*){3,}|(-\s*){3,}|(_\s*){3,})$`)
+)
+
+// Markdown行内语法，图片和链接捕获文字和地址
+var (
+	MarkdownCodeSpan      = regexp.MustCompile("`([^`]+)`")
+	MarkdownImage         = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	MarkdownLink          = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	MarkdownReferenceLink = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	MarkdownAutolink      = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	MarkdownHTMLTag       = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<!--.*?-->`)
+	MarkdownStrong        = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	MarkdownEmphasisStar  = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	MarkdownEmphasisUnder = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^_\s](?:[^_]*[^_\s])?)_($|[^\p{L}\p{N}_])`)
+	MarkdownStrikethrough = regexp.MustCompile(`~~([^~]+)~~`)
+	MarkdownEscape        = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!|>~])")
+
+	protectedPlaceholder = regexp.MustCompile("\uE000(\\d+)\uE001")
+)
+
+// Protector 在行内转换期间用私有使用区字符占位保护代码和转义字符，转换完成后还原
+type Protector struct {
+	values []string
+}
+
+// Protect 保存值并返回占位符
+func (p *Protector) Protect(value string) string {
+	p.values = append(p.values, value)
+	return "\uE000" + strconv.Itoa(len(p.values)-1) + "\uE001"
+}
+
+// Restore 还原占位符
+func (p *Protector) Restore(text string) string {
+	if len(p.values) == 0 {
+		return text
+	}
+	return protectedPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		index, err := strconv.Atoi(protectedPlaceholder.FindStringSubmatch(match)[1])
+		if err != nil || index >= len(p.values) {
+			return match
+		}
+		return p.values[index]
+	})
+}
+
+// StripOptions 移除行内Markdown格式的选项
+type StripOptions struct {
+	KeepLinkURLs  bool // 安全链接保留地址，如"文档 (https://example.com)"，否则只保留文字
+	StripHTMLTags bool // 移除内嵌的HTML标签和注释，关闭时原样保留，避免误删"a<b and c>d"这类文字
+}
+
+// StripInlineMarkdown 移除行内格式标记，行内代码和转义字符原样保留，图片保留替代文字
+func StripInlineMarkdown(text string, options StripOptions) string {
+	p := &Protector{}
+	text = MarkdownEscape.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect(match[1:])
+	})
+	text = MarkdownCodeSpan.ReplaceAllStringFunc(text, func(match string) string {
+		return p.Protect(match[1 : len(match)-1])
+	})
+	text = MarkdownImage.ReplaceAllString(text, "$1")
+	text = MarkdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		parts := MarkdownLink.FindStringSubmatch(match)
+		if !options.KeepLinkURLs || parts[1] == parts[2] || !IsSafeURL(parts[2]) {
+			return parts[1]
+		}
+		return fmt.Sprintf("%s (%s)", parts[1], strings.TrimPrefix(parts[2], "mailto:"))
+	})
+	text = MarkdownReferenceLink.ReplaceAllString(text, "$1")
+	text = MarkdownAutolink.ReplaceAllString(text, "$1")
+	if options.StripHTMLTags {
+		text = MarkdownHTMLTag.ReplaceAllString(text, "")
+	}
+	text = MarkdownStrong.ReplaceAllString(text, "$1$2")
+	text = MarkdownStrikethrough.ReplaceAllString(text, "$1")
+	text = MarkdownEmphasisStar.ReplaceAllString(text, "$1")
+	text = MarkdownEmphasisUnder.ReplaceAllString(text, "$1$2$3")
+	return p.Restore(text)
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 标准限流响应头
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// RateLimitInfo 限流状态
+type RateLimitInfo struct {
+	Limit     int       `json:"limit"`     // 窗口内允许的请求数
+	Remaining int       `json:"remaining"` // 窗口内剩余请求数
+	Reset     time.Time `json:"reset"`     // 配额恢复时间
+}
+
+// RetryAfter 距离配额恢复的秒数，至少为1秒
+func (i RateLimitInfo) RetryAfter() int {
+	seconds := int(math.Ceil(time.Until(i.Reset).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// RateLimitError 限流错误，由服务层返回、接口层转换为429响应
+type RateLimitError struct {
+	Scope string // 触发限流的子系统，如 gateway、channel:email、tool:search
+	Info  RateLimitInfo
+}
+
+// NewRateLimitError 创建限流错误
+func NewRateLimitError(scope string, info RateLimitInfo) *RateLimitError {
+	return &RateLimitError{Scope: scope, Info: info}
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s, retry after %ds", e.Scope, e.Info.RetryAfter())
+}
+
+// AsRateLimitError 判断错误是否为限流错误
+func AsRateLimitError(err error) (*RateLimitError, bool) {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr, true
+	}
+	return nil, false
+}
+
+// SetRateLimitHeaders 设置标准限流响应头
+func SetRateLimitHeaders(c *gin.Context, info RateLimitInfo) {
+	remaining := info.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	c.Header(HeaderRateLimitLimit, strconv.Itoa(info.Limit))
+	c.Header(HeaderRateLimitRemaining, strconv.Itoa(remaining))
+	c.Header(HeaderRateLimitReset, strconv.FormatInt(info.Reset.Unix(), 10))
+}
+
+// RateLimitExceededResponse 返回统一的429限流响应并终止请求
+func RateLimitExceededResponse(c *gin.Context, scope string, info RateLimitInfo) {
+	SetRateLimitHeaders(c, info)
+	c.Header(HeaderRetryAfter, strconv.Itoa(info.RetryAfter()))
+
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"success":     false,
+		"message":     "Rate limit exceeded. Please try again later.",
+		"error":       "rate_limit_exceeded",
+		"scope":       scope,
+		"retry_after": info.RetryAfter(),
+		"request_id":  c.GetString("request_id"),
+	})
+}
+
+// RateLimitErrorResponse 将限流错误转换为429响应，非限流错误返回false
+func RateLimitErrorResponse(c *gin.Context, err error) bool {
+	rateLimitErr, ok := AsRateLimitError(err)
+	if !ok {
+		return false
+	}
+
+	RateLimitExceededResponse(c, rateLimitErr.Scope, rateLimitErr.Info)
+	return true
+}
@@ -0,0 +1,76 @@
+package validation
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor gRPC一元校验拦截器
+// 请求实现Validatable时先校验，失败返回InvalidArgument，不再调用处理方法
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor gRPC流式校验拦截器，校验客户端发送的每条消息
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingServerStream{ServerStream: stream})
+	}
+}
+
+// validatingServerStream 接收消息后进行校验的服务端流
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+// RecvMsg 接收并校验消息
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(m)
+}
+
+// validate 校验实现了Validatable的消息
+func validate(m interface{}) error {
+	v, ok := m.(Validatable)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return StatusError(err)
+	}
+	return nil
+}
+
+// StatusError 将校验错误转换为InvalidArgument状态
+// 字段校验错误作为BadRequest详情返回，客户端可通过status.FromError(err).Details()读取
+func StatusError(err error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	fields := FieldErrors(err)
+	if len(fields) == 0 {
+		return st.Err()
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, field := range fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field.Field,
+			Description: field.Message,
+		})
+	}
+	if detailed, detailErr := st.WithDetails(badRequest); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
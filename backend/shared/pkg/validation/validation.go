@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// Validatable 可校验的命令、查询或请求
+// HTTP处理器直接调用Validate，gRPC请求由校验拦截器统一调用
+type Validatable interface {
+	Validate() error
+}
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewFieldError 创建字段校验错误，field使用请求中的字段名（如owner_id）
+func NewFieldError(field, message string) *FieldError {
+	return &FieldError{Field: field, Message: message}
+}
+
+// Error 实现error接口，返回错误信息
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// Errors 多个字段校验错误
+type Errors []*FieldError
+
+// Error 实现error接口，多个错误以分号分隔
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FieldErrors 从错误中取出全部字段校验错误，支持Errors、errors.Join和包装的FieldError
+func FieldErrors(err error) []*FieldError {
+	if err == nil {
+		return nil
+	}
+
+	var list Errors
+	if errors.As(err, &list) {
+		return list
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var fields []*FieldError
+		for _, e := range joined.Unwrap() {
+			fields = append(fields, FieldErrors(e)...)
+		}
+		return fields
+	}
+
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return []*FieldError{fieldErr}
+	}
+	return nil
+}